@@ -0,0 +1,43 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/supergodk/go-utils/v1/phoneutil"
+)
+
+// TestParsePhoneChina 解析中国大陆手机号和固定电话，包括区号 010 的北京固定电话
+//
+// TestParsePhoneChina parses mainland China mobile and landline numbers, including Beijing landlines with area code 010
+func TestParsePhoneChina(t *testing.T) {
+	valid := []struct {
+		raw    string
+		region string
+		want   string
+	}{
+		{"010 12345678", phoneutil.RegionCN, "+861012345678"},
+		{"+86 10 1234 5678", "", "+861012345678"},
+		{"(0755) 1234 5678", phoneutil.RegionCN, "+8675512345678"},
+		{"138-1234-5678", phoneutil.RegionCN, "+8613812345678"},
+		{"+8613812345678", "", "+8613812345678"},
+		{"06 1234 5678", "IT", "+390612345678"},
+	}
+	for _, tc := range valid {
+		got, err := phoneutil.NormalizeE164(tc.raw, tc.region)
+		if err != nil {
+			t.Errorf("NormalizeE164(%q, %q): %v", tc.raw, tc.region, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("NormalizeE164(%q, %q) = %q, want %q", tc.raw, tc.region, got, tc.want)
+		}
+	}
+
+	invalid := []string{"010 1234567", "12012345678", "1381234567"}
+	for _, raw := range invalid {
+		if _, err := phoneutil.ParsePhone(raw, phoneutil.RegionCN); !errors.Is(err, phoneutil.ErrInvalidPhone) {
+			t.Errorf("ParsePhone(%q): got %v, want ErrInvalidPhone", raw, err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/cacheutil"
+	"github.com/supergodk/go-utils/v1/cryptoutil"
+	"github.com/supergodk/go-utils/v1/testutil"
+)
+
+// TestReplayGuardWithinLeeway 已过期但仍在 Leeway 内的令牌只能使用一次
+//
+// TestReplayGuardWithinLeeway checks that a token past exp but within the Leeway can be used only once
+func TestReplayGuardWithinLeeway(t *testing.T) {
+	guards := map[string]cryptoutil.ReplayGuard{
+		"memory": cryptoutil.NewMemoryReplayGuard(),
+		"cache":  cryptoutil.NewCacheReplayGuard(cacheutil.NewMemoryCache(), ""),
+	}
+	for name, guard := range guards {
+		t.Run(name, func(t *testing.T) {
+			jwks := testutil.FakeJWKSServer(t, "k1")
+			verifier := &cryptoutil.JwtVerifier{
+				PublicKey:    jwks.PublicKey("k1"),
+				KeyAlgorithm: cryptoutil.KeyAlgorithmRS256,
+				Leeway:       time.Minute,
+				ReplayGuard:  guard,
+			}
+			token := jwks.Sign(t, "k1", map[string]any{
+				"sub": "u1",
+				"jti": "leeway-" + name,
+				"exp": time.Now().Add(-10 * time.Second).Unix(),
+			})
+			if _, err := verifier.Verify(context.Background(), token); err != nil {
+				t.Fatalf("first use: %v", err)
+			}
+			if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, cryptoutil.ErrTokenReplayed) {
+				t.Fatalf("second use: got %v, want ErrTokenReplayed", err)
+			}
+		})
+	}
+}
+
+// TestWithReplayGuardWithinLeeway WithReplayGuard 包装的验证器在 Leeway 内同样拒绝重放
+//
+// TestWithReplayGuardWithinLeeway checks that a verifier wrapped by WithReplayGuard rejects replays within the Leeway too
+func TestWithReplayGuardWithinLeeway(t *testing.T) {
+	jwks := testutil.FakeJWKSServer(t, "k1")
+	verifier := cryptoutil.WithReplayGuard(&cryptoutil.JwtVerifier{
+		PublicKey:    jwks.PublicKey("k1"),
+		KeyAlgorithm: cryptoutil.KeyAlgorithmRS256,
+		Leeway:       time.Minute,
+	}, cryptoutil.NewCacheReplayGuard(cacheutil.NewMemoryCache(), ""))
+	token := jwks.Sign(t, "k1", map[string]any{
+		"sub": "u1",
+		"jti": "wrapped",
+		"exp": time.Now().Add(-10 * time.Second).Unix(),
+	})
+	if _, err := verifier.Verify(context.Background(), token); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if _, err := verifier.Verify(context.Background(), token); !errors.Is(err, cryptoutil.ErrTokenReplayed) {
+		t.Fatalf("second use: got %v, want ErrTokenReplayed", err)
+	}
+}
@@ -0,0 +1,227 @@
+// Package batchutil 提供按数量或时间触发的批处理工具，用于批量写库、日志投递等场景
+//
+// Package batchutil provides size- or time-triggered batching for bulk database inserts, log shipping and similar workloads.
+package batchutil
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBatchSize 默认的批大小
+	//
+	// DefaultBatchSize is the default batch size
+	DefaultBatchSize = 100
+	// DefaultBatchInterval 默认的最长等待时间
+	//
+	// DefaultBatchInterval is the default maximum wait before a flush
+	DefaultBatchInterval = time.Second
+)
+
+// ErrBatcherClosed 表示 Batcher 已关闭
+//
+// ErrBatcherClosed indicates that the Batcher is closed
+var ErrBatcherClosed = errors.New("batcher closed")
+
+// BatcherOptions 批处理选项
+// Size: 累积多少条时立即刷新，默认 DefaultBatchSize
+// Interval: 第一条数据到达后最多等待多久刷新，默认 DefaultBatchInterval
+// QueueSize: 等待处理的缓冲区大小，默认与 Size 相同；缓冲区满时 Add 会阻塞（背压）
+// OnError: 刷新失败时的回调，可以为 nil
+//
+// BatcherOptions contains batching options.
+// Size: Flush as soon as this many items accumulate, defaults to DefaultBatchSize
+// Interval: Maximum wait after the first item arrives before flushing, defaults to DefaultBatchInterval
+// QueueSize: Size of the pending buffer, defaults to Size; Add blocks while it is full (backpressure)
+// OnError: Callback invoked when a flush fails, may be nil
+type BatcherOptions[T any] struct {
+	Size      int
+	Interval  time.Duration
+	QueueSize int
+	OnError   func(batch []T, err error)
+}
+
+// Batcher 收集数据并在达到 Size 条或等待超过 Interval 时调用刷新函数，刷新函数在单个 goroutine 中顺序调用
+//
+// Batcher collects items and calls the flush function when Size items accumulate or Interval elapses; the flush function is called sequentially from a single goroutine.
+type Batcher[T any] struct {
+	opts    BatcherOptions[T]
+	flush   func(batch []T) error
+	items   chan T
+	flushes chan chan struct{}
+	done    chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewBatcher 创建并启动 Batcher
+// 参数:
+//   - flush: 刷新函数，每次收到一个新的切片，可以安全持有
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *Batcher[T]: 批处理器，使用完毕后需要调用 Close
+//
+// NewBatcher creates and starts a Batcher.
+// Parameters:
+//   - flush: The flush function; it receives a fresh slice each time and may retain it
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *Batcher[T]: The batcher; call Close when done
+func NewBatcher[T any](flush func(batch []T) error, opts *BatcherOptions[T]) *Batcher[T] {
+	var o BatcherOptions[T]
+	if opts != nil {
+		o = *opts
+	}
+	if o.Size <= 0 {
+		o.Size = DefaultBatchSize
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultBatchInterval
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = o.Size
+	}
+	b := &Batcher[T]{
+		opts:    o,
+		flush:   flush,
+		items:   make(chan T, o.QueueSize),
+		flushes: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Add 添加一条数据，缓冲区满时阻塞
+// 返回:
+//   - error: 已关闭时返回 ErrBatcherClosed，ctx 结束时返回 ctx.Err()
+//
+// Add adds an item, blocking while the buffer is full.
+// Returns:
+//   - error: ErrBatcherClosed if closed, or ctx.Err() when ctx is done
+func (b *Batcher[T]) Add(ctx context.Context, item T) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return ErrBatcherClosed
+	}
+	select {
+	case b.items <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// TryAdd 非阻塞地添加一条数据，缓冲区已满或已关闭时返回 false
+//
+// TryAdd adds an item without blocking, returning false if the buffer is full or the batcher is closed.
+func (b *Batcher[T]) TryAdd(item T) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return false
+	}
+	select {
+	case b.items <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// Flush 立即刷新已收集的数据并等待完成（调用前已进入缓冲区的数据不一定包含在内）
+// 返回:
+//   - error: 已关闭时返回 ErrBatcherClosed，ctx 结束时返回 ctx.Err()
+//
+// Flush flushes the collected items now and waits for completion (items still in the buffer may not be included).
+// Returns:
+//   - error: ErrBatcherClosed if closed, or ctx.Err() when ctx is done
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	ack := make(chan struct{})
+	select {
+	case b.flushes <- ack:
+	case <-b.done:
+		return ErrBatcherClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ack:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close 停止接收数据，刷新剩余的全部数据并等待后台 goroutine 退出；重复调用是安全的
+// 返回:
+//   - error: ctx 在刷新完成前结束时返回 ctx.Err()，此时剩余数据仍会在后台继续刷新
+//
+// Close stops accepting items, flushes everything remaining and waits for the background goroutine to exit; calling it again is safe.
+// Returns:
+//   - error: ctx.Err() if ctx is done before flushing finishes; the remaining items keep flushing in the background
+func (b *Batcher[T]) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.items)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 后台收集和刷新数据
+//
+// run collects and flushes items in the background
+func (b *Batcher[T]) run() {
+	defer close(b.done)
+	batch := make([]T, 0, b.opts.Size)
+	timer := time.NewTimer(b.opts.Interval)
+	timer.Stop()
+
+	doFlush := func() {
+		timer.Stop()
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.flush(batch); err != nil && b.opts.OnError != nil {
+			b.opts.OnError(batch, err)
+		}
+		batch = make([]T, 0, b.opts.Size)
+	}
+
+	for {
+		select {
+		case item, ok := <-b.items:
+			if !ok {
+				doFlush()
+				return
+			}
+			if len(batch) == 0 {
+				timer.Reset(b.opts.Interval)
+			}
+			batch = append(batch, item)
+			if len(batch) >= b.opts.Size {
+				doFlush()
+			}
+		case <-timer.C:
+			doFlush()
+		case ack := <-b.flushes:
+			doFlush()
+			close(ack)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+//go:build !purego
+
+package bytesutil
+
+import "unsafe"
+
+// BytesToString 零拷贝地将 []byte 转换为 string
+// 注意: 返回的字符串与 b 共享内存，之后绝不能再修改 b，否则会破坏字符串不可变的约定并导致难以排查的错误；
+// 只应用于性能敏感且 b 不再被修改的场景（例如只读的解析缓冲区）。使用 purego 构建标签时退化为普通的复制转换
+//
+// BytesToString converts a []byte to a string without copying.
+// Note: the string shares memory with b, so b must never be modified afterwards; doing so breaks string immutability and causes hard-to-trace bugs.
+// Use it only on hot paths where b is no longer mutated (such as read-only parse buffers). With the purego build tag it falls back to an ordinary copying conversion.
+func BytesToString(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
+
+// StringToBytes 零拷贝地将 string 转换为 []byte
+// 注意: 返回的切片与 s 共享内存且可能指向只读内存，绝不能修改，否则可能直接导致程序崩溃；
+// 只应用于将字符串传给只读取 []byte 的函数（例如哈希或写入）。使用 purego 构建标签时退化为普通的复制转换
+//
+// StringToBytes converts a string to a []byte without copying.
+// Note: the slice shares memory with s and may point to read-only memory; it must never be modified, or the program may crash outright.
+// Use it only to pass strings to functions that merely read a []byte (such as hashing or writing). With the purego build tag it falls back to an ordinary copying conversion.
+func StringToBytes(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
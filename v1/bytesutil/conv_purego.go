@@ -0,0 +1,17 @@
+//go:build purego
+
+package bytesutil
+
+// BytesToString 将 []byte 复制转换为 string（purego 构建，不使用 unsafe）
+//
+// BytesToString converts a []byte to a string by copying (purego build, no unsafe).
+func BytesToString(b []byte) string {
+	return string(b)
+}
+
+// StringToBytes 将 string 复制转换为 []byte（purego 构建，不使用 unsafe）
+//
+// StringToBytes converts a string to a []byte by copying (purego build, no unsafe).
+func StringToBytes(s string) []byte {
+	return []byte(s)
+}
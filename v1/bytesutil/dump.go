@@ -0,0 +1,28 @@
+package bytesutil
+
+import (
+	"encoding/hex"
+	"strconv"
+)
+
+// HexDump 以 hexdump -C 的格式转储数据（偏移、十六进制和 ASCII），用于调试日志
+// 参数:
+//   - data: 要转储的数据
+//   - limit: 最多转储的字节数，小于等于 0 时不限制；被截断时末尾会注明剩余字节数
+//
+// 返回:
+//   - string: 转储结果
+//
+// HexDump dumps data in hexdump -C format (offset, hex and ASCII) for debug logs.
+// Parameters:
+//   - data: The data to dump
+//   - limit: Maximum number of bytes to dump, unlimited when <= 0; a note with the remaining byte count is appended when truncated
+//
+// Returns:
+//   - string: The dump
+func HexDump(data []byte, limit int) string {
+	if limit <= 0 || len(data) <= limit {
+		return hex.Dump(data)
+	}
+	return hex.Dump(data[:limit]) + "... " + strconv.Itoa(len(data)-limit) + " more bytes\n"
+}
@@ -0,0 +1,139 @@
+// Package bytesutil 提供字节数据相关的工具函数，包括可读的容量格式、十六进制转储和零拷贝转换
+//
+// Package bytesutil provides byte data utility functions, including human-readable sizes, hex dumps and zero-copy conversions.
+package bytesutil
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 二进制单位（1024 进制）
+//
+// Binary units (powers of 1024)
+const (
+	KiB int64 = 1 << (10 * (iota + 1))
+	MiB
+	GiB
+	TiB
+	PiB
+	EiB
+)
+
+// SI 单位（1000 进制）
+//
+// SI units (powers of 1000)
+const (
+	KB int64 = 1000
+	MB       = KB * 1000
+	GB       = MB * 1000
+	TB       = GB * 1000
+	PB       = TB * 1000
+	EB       = PB * 1000
+)
+
+// ErrInvalidSize 表示容量字符串格式错误或超出范围
+//
+// ErrInvalidSize indicates that a size string is malformed or out of range
+var ErrInvalidSize = errors.New("invalid size")
+
+// sizeUnits 单位后缀（小写）对应的字节数
+//
+// sizeUnits maps lowercase unit suffixes to byte counts
+var sizeUnits = map[string]int64{
+	"": 1, "b": 1,
+	"k": KiB, "kib": KiB, "kb": KB,
+	"m": MiB, "mib": MiB, "mb": MB,
+	"g": GiB, "gib": GiB, "gb": GB,
+	"t": TiB, "tib": TiB, "tb": TB,
+	"p": PiB, "pib": PiB, "pb": PB,
+	"e": EiB, "eib": EiB, "eb": EB,
+}
+
+// ParseSize 解析可读的容量字符串
+// KiB/MiB/GiB 等为 1024 进制，KB/MB/GB 等为 1000 进制，单字母 K/M/G 按 1024 进制处理；单位不区分大小写，数字与单位之间可以有空格
+// 参数:
+//   - s: 容量字符串，例如 "1.5GiB"、"100 MB"、"512"
+//
+// 返回:
+//   - int64: 字节数（小数部分向下取整）
+//   - error: 格式错误、为负数或超出 int64 范围时返回 ErrInvalidSize
+//
+// ParseSize parses a human-readable size string.
+// KiB/MiB/GiB etc. are powers of 1024, KB/MB/GB etc. are powers of 1000, and single letters K/M/G count as powers of 1024; units are case-insensitive and may be separated from the number by spaces.
+// Parameters:
+//   - s: The size string, e.g. "1.5GiB", "100 MB", "512"
+//
+// Returns:
+//   - int64: Number of bytes (fractions are truncated)
+//   - error: Returns ErrInvalidSize if malformed, negative or out of int64 range
+func ParseSize(s string) (int64, error) {
+	str := strings.TrimSpace(s)
+	i := strings.IndexFunc(str, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(str)
+	}
+	num, unit := str[:i], strings.ToLower(strings.TrimSpace(str[i:]))
+	mult, ok := sizeUnits[unit]
+	if !ok || num == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+	}
+
+	if n, err := strconv.ParseInt(num, 10, 64); err == nil {
+		if n > math.MaxInt64/mult {
+			return 0, fmt.Errorf("%w: %q overflows int64", ErrInvalidSize, s)
+		}
+		return n * mult, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, s)
+	}
+	total := f * float64(mult)
+	if total >= math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %q overflows int64", ErrInvalidSize, s)
+	}
+	return int64(total), nil
+}
+
+// FormatSize 使用二进制单位格式化字节数，保留一位小数，例如 1536 -> "1.5 KiB"
+//
+// FormatSize formats a byte count with binary units and one decimal place, e.g. 1536 -> "1.5 KiB".
+func FormatSize(bytes int64) string {
+	return formatSize(bytes, 1024, []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"})
+}
+
+// FormatSizeSI 使用 SI 单位格式化字节数，保留一位小数，例如 1500 -> "1.5 kB"
+//
+// FormatSizeSI formats a byte count with SI units and one decimal place, e.g. 1500 -> "1.5 kB".
+func FormatSizeSI(bytes int64) string {
+	return formatSize(bytes, 1000, []string{"B", "kB", "MB", "GB", "TB", "PB", "EB"})
+}
+
+// formatSize 按指定进制格式化字节数
+//
+// formatSize formats a byte count with the given base
+func formatSize(bytes int64, base float64, units []string) string {
+	sign := ""
+	f := float64(bytes)
+	if bytes < 0 {
+		sign, f = "-", -f
+	}
+	if f < base {
+		return sign + strconv.FormatInt(int64(f), 10) + " B"
+	}
+	i := 0
+	for f >= base && i < len(units)-1 {
+		f /= base
+		i++
+	}
+	// 四舍五入后达到下一级时进位，避免出现 "1024.0 KiB"
+	if math.Round(f*10)/10 >= base && i < len(units)-1 {
+		f /= base
+		i++
+	}
+	return sign + strconv.FormatFloat(f, 'f', 1, 64) + " " + units[i]
+}
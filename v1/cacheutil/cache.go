@@ -0,0 +1,181 @@
+// Package cacheutil 提供带过期时间的键值缓存接口及内存实现
+//
+// Package cacheutil provides a key-value cache interface with expiration and an in-memory implementation.
+package cacheutil
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Cache 带过期时间的键值缓存，接口语义与 Redis 对应命令保持一致，方便替换为分布式实现
+// ttl 小于等于 0 表示永不过期
+//
+// Cache is a key-value cache with expiration. Its semantics match the corresponding Redis commands,
+// so it can easily be replaced with a distributed implementation.
+// A ttl <= 0 means the entry never expires.
+type Cache interface {
+	// Get 获取值，键不存在或已过期时 ok 为 false
+	//
+	// Get returns the value; ok is false if the key does not exist or has expired.
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	// Set 设置值
+	//
+	// Set sets the value.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// SetNX 仅在键不存在时设置值，返回是否设置成功（对应 Redis SET NX）
+	//
+	// SetNX sets the value only if the key does not exist and reports whether it was set (Redis SET NX).
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	// Incr 将整数值加 1 并返回新值，键不存在时从 0 开始并设置 ttl（对应 Redis INCR + EXPIRE）
+	//
+	// Incr increments the integer value by 1 and returns the new value; a missing key starts at 0 and gets the ttl (Redis INCR + EXPIRE).
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	// Delete 删除键，键不存在时不返回错误
+	//
+	// Delete deletes the key; no error is returned if it does not exist.
+	Delete(ctx context.Context, key string) error
+}
+
+// sweepInterval 内存缓存清理过期数据的最小间隔
+//
+// sweepInterval is the minimum interval between sweeps of expired entries in the memory cache
+const sweepInterval = time.Minute
+
+// entry 内存缓存中的一项
+//
+// entry is an item in the memory cache
+type entry struct {
+	value    []byte
+	expireAt time.Time
+}
+
+// expired 判断是否已过期
+//
+// expired reports whether the entry has expired
+func (e entry) expired(now time.Time) bool {
+	return !e.expireAt.IsZero() && !now.Before(e.expireAt)
+}
+
+// MemoryCache 基于 map 的内存缓存，适用于单实例部署和测试
+// 过期数据在访问时惰性删除，并在写入时定期清理
+//
+// MemoryCache is a map based in-memory cache, suitable for single-instance deployments and tests.
+// Expired entries are removed lazily on access and swept periodically on writes.
+type MemoryCache struct {
+	mu        sync.Mutex
+	items     map[string]entry
+	lastSweep time.Time
+}
+
+// NewMemoryCache 创建内存缓存
+//
+// NewMemoryCache creates an in-memory cache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{items: make(map[string]entry), lastSweep: time.Now()}
+}
+
+// Get 实现 Cache 接口
+//
+// Get implements the Cache interface.
+func (c *MemoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if e.expired(time.Now()) {
+		delete(c.items, key)
+		return nil, false, nil
+	}
+	return append([]byte(nil), e.value...), true, nil
+}
+
+// Set 实现 Cache 接口
+//
+// Set implements the Cache interface.
+func (c *MemoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value, ttl, time.Now())
+	return nil
+}
+
+// SetNX 实现 Cache 接口
+//
+// SetNX implements the Cache interface.
+func (c *MemoryCache) SetNX(_ context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if e, ok := c.items[key]; ok && !e.expired(now) {
+		return false, nil
+	}
+	c.set(key, value, ttl, now)
+	return true, nil
+}
+
+// Incr 实现 Cache 接口，值不是整数时返回错误
+//
+// Incr implements the Cache interface; an error is returned if the value is not an integer.
+func (c *MemoryCache) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	e, ok := c.items[key]
+	if !ok || e.expired(now) {
+		c.set(key, []byte("1"), ttl, now)
+		return 1, nil
+	}
+	n, err := strconv.ParseInt(string(e.value), 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	n++
+	// 与 Redis 一致，递增不会改变已有的过期时间
+	e.value = []byte(strconv.FormatInt(n, 10))
+	c.items[key] = e
+	return n, nil
+}
+
+// Delete 实现 Cache 接口
+//
+// Delete implements the Cache interface.
+func (c *MemoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	delete(c.items, key)
+	c.mu.Unlock()
+	return nil
+}
+
+// Len 返回缓存中的条目数（可能包含尚未清理的过期条目）
+//
+// Len returns the number of entries in the cache (possibly including expired entries not yet swept).
+func (c *MemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// set 写入条目并在需要时清理过期数据，调用方必须持有锁
+//
+// set writes an entry and sweeps expired entries when due; the caller must hold the lock
+func (c *MemoryCache) set(key string, value []byte, ttl time.Duration, now time.Time) {
+	e := entry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		e.expireAt = now.Add(ttl)
+	}
+	c.items[key] = e
+
+	if now.Sub(c.lastSweep) >= sweepInterval {
+		c.lastSweep = now
+		for k, v := range c.items {
+			if v.expired(now) {
+				delete(c.items, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,287 @@
+// Package checksumutil 提供文件校验和与完整性清单相关的工具函数，用于部署产物校验
+//
+// Package checksumutil provides file checksum and integrity manifest utilities for validating deployment artifacts.
+package checksumutil
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/supergodk/go-utils/v1/fileutil"
+)
+
+var (
+	// ErrManifestMismatch 表示文件与清单不一致
+	//
+	// ErrManifestMismatch indicates that the files do not match the manifest
+	ErrManifestMismatch = errors.New("manifest mismatch")
+	// ErrInvalidManifest 表示清单格式错误
+	//
+	// ErrInvalidManifest indicates that a manifest is malformed
+	ErrInvalidManifest = errors.New("invalid manifest")
+)
+
+// Manifest 完整性清单，键为以 "/" 分隔的相对路径，值为小写十六进制的 SHA-256
+//
+// Manifest is an integrity manifest mapping "/"-separated relative paths to lowercase hex SHA-256 digests.
+type Manifest map[string]string
+
+// SHA256File 计算文件的 SHA-256
+// 参数:
+//   - path: 文件路径
+//
+// 返回:
+//   - string: 小写十六进制的摘要
+//   - error: 读取失败时返回错误
+//
+// SHA256File computes the SHA-256 of a file.
+// Parameters:
+//   - path: The file path
+//
+// Returns:
+//   - string: The lowercase hex digest
+//   - error: Returns an error if reading fails
+func SHA256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return SHA256Reader(f)
+}
+
+// SHA256Reader 计算数据流的 SHA-256
+//
+// SHA256Reader computes the SHA-256 of a stream.
+func SHA256Reader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// CreateManifest 为目录下的所有普通文件生成清单（跟随目录但不跟随符号链接）
+// 参数:
+//   - dir: 目录
+//
+// 返回:
+//   - Manifest: 清单
+//   - error: 遍历或读取失败时返回错误
+//
+// CreateManifest builds a manifest of every regular file under a directory (symlinks are not followed).
+// Parameters:
+//   - dir: The directory
+//
+// Returns:
+//   - Manifest: The manifest
+//   - error: Returns an error if walking or reading fails
+func CreateManifest(dir string) (Manifest, error) {
+	return CreateManifestFS(os.DirFS(dir))
+}
+
+// CreateManifestFS 为文件系统中的所有普通文件生成清单，可用于 embed.FS 或其他 fs.FS 实现
+//
+// CreateManifestFS builds a manifest of every regular file in a file system, such as an embed.FS or other fs.FS implementation.
+func CreateManifestFS(fsys fs.FS) (Manifest, error) {
+	return createManifest(context.Background(), fsys, nil)
+}
+
+// CreateManifestFiltered 按 fileutil.WalkOptions 的包含、排除模式和符号链接策略为目录生成清单，Workers 大于 1 时并发计算摘要
+// 使用 fileutil.SymlinkInclude 时，指向文件的链接以链接路径记录，摘要是目标文件内容的摘要
+// 参数:
+//   - ctx: 上下文，取消后停止
+//   - dir: 目录
+//   - opts: 遍历选项，可以为 nil
+//
+// 返回:
+//   - Manifest: 清单
+//   - error: 模式无效、遍历或读取失败、ctx 被取消时返回错误
+//
+// CreateManifestFiltered builds a manifest of a directory using the include and exclude patterns and symlink policy of fileutil.WalkOptions, hashing concurrently when Workers is greater than 1.
+// With fileutil.SymlinkInclude, links to files are recorded under the link's path with the digest of the target's content.
+// Parameters:
+//   - ctx: Context; cancelling it stops the work
+//   - dir: The directory
+//   - opts: Walk options, may be nil
+//
+// Returns:
+//   - Manifest: The manifest
+//   - error: Returns an error if a pattern is invalid, walking or reading fails or ctx is cancelled
+func CreateManifestFiltered(ctx context.Context, dir string, opts *fileutil.WalkOptions) (Manifest, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	return createManifest(ctx, os.DirFS(dir), opts)
+}
+
+// createManifest 遍历文件系统并计算每个文件的摘要
+//
+// createManifest walks a file system and computes the digest of every file
+func createManifest(ctx context.Context, fsys fs.FS, opts *fileutil.WalkOptions) (Manifest, error) {
+	m := Manifest{}
+	var mu sync.Mutex
+	err := fileutil.WalkFilteredFS(ctx, fsys, func(e fileutil.WalkEntry) error {
+		// SymlinkInclude 交出的链接按目标文件的内容计算摘要，与 sha256sum -c 的校验方式一致
+		f, err := fsys.Open(e.Path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		sum, err := SHA256Reader(f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.Path, err)
+		}
+		mu.Lock()
+		m[e.Path] = sum
+		mu.Unlock()
+		return nil
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ManifestDiff 两份清单的差异
+// Missing: 期望存在但缺失的路径
+// Extra: 清单之外多出的路径
+// Mismatched: 摘要不一致的路径
+//
+// ManifestDiff is the difference between two manifests.
+// Missing: Paths expected but absent
+// Extra: Paths present but not in the expected manifest
+// Mismatched: Paths whose digests differ
+type ManifestDiff struct {
+	Missing    []string
+	Extra      []string
+	Mismatched []string
+}
+
+// Empty 报告两份清单是否完全一致
+//
+// Empty reports whether the manifests are identical.
+func (d *ManifestDiff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Mismatched) == 0
+}
+
+// Diff 比较实际清单 m 与期望清单 want，结果中的路径已排序，可用于决定同步时需要上传或删除的文件
+//
+// Diff compares the actual manifest m with the expected manifest want; paths in the result are sorted, which helps decide what to upload or delete when syncing.
+func (m Manifest) Diff(want Manifest) *ManifestDiff {
+	d := &ManifestDiff{}
+	for _, p := range slices.Sorted(maps.Keys(want)) {
+		got, ok := m[p]
+		switch {
+		case !ok:
+			d.Missing = append(d.Missing, p)
+		case !strings.EqualFold(got, want[p]):
+			d.Mismatched = append(d.Mismatched, p)
+		}
+	}
+	for _, p := range slices.Sorted(maps.Keys(m)) {
+		if _, ok := want[p]; !ok {
+			d.Extra = append(d.Extra, p)
+		}
+	}
+	return d
+}
+
+// VerifyManifest 校验目录中的文件是否与清单一致
+// 参数:
+//   - dir: 目录
+//   - want: 期望的清单
+//   - allowExtra: 为 true 时允许目录中存在清单之外的文件
+//
+// 返回:
+//   - *ManifestDiff: 差异
+//   - error: 读取失败时返回错误；不一致时返回 ErrManifestMismatch
+//
+// VerifyManifest verifies that the files in a directory match a manifest.
+// Parameters:
+//   - dir: The directory
+//   - want: The expected manifest
+//   - allowExtra: When true, files not in the manifest are allowed
+//
+// Returns:
+//   - *ManifestDiff: The difference
+//   - error: Returns an error if reading fails; returns ErrManifestMismatch if they differ
+func VerifyManifest(dir string, want Manifest, allowExtra bool) (*ManifestDiff, error) {
+	got, err := CreateManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	d := got.Diff(want)
+	if allowExtra {
+		d.Extra = nil
+	}
+	if !d.Empty() {
+		return d, fmt.Errorf("%w: %d missing, %d extra, %d mismatched", ErrManifestMismatch, len(d.Missing), len(d.Extra), len(d.Mismatched))
+	}
+	return d, nil
+}
+
+// WriteTo 以 sha256sum 兼容的格式（"摘要  路径"，按路径排序）写出清单，可直接用 sha256sum -c 校验
+//
+// WriteTo writes the manifest in sha256sum-compatible format ("digest  path", sorted by path), so it can be checked with sha256sum -c.
+func (m Manifest) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, p := range slices.Sorted(maps.Keys(m)) {
+		n, err := fmt.Fprintf(w, "%s  %s\n", m[p], p)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// ReadManifest 读取 sha256sum 格式的清单，忽略空行和以 # 开头的行
+// 参数:
+//   - r: 清单内容
+//
+// 返回:
+//   - Manifest: 清单
+//   - error: 格式错误时返回 ErrInvalidManifest
+//
+// ReadManifest reads a manifest in sha256sum format, ignoring blank lines and lines starting with #.
+// Parameters:
+//   - r: The manifest content
+//
+// Returns:
+//   - Manifest: The manifest
+//   - error: Returns ErrInvalidManifest if malformed
+func ReadManifest(r io.Reader) (Manifest, error) {
+	m := Manifest{}
+	sc := bufio.NewScanner(r)
+	line := 0
+	for sc.Scan() {
+		line++
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		sum, path, ok := strings.Cut(text, " ")
+		// sha256sum 二进制模式的路径前带有 "*"
+		path = strings.TrimPrefix(strings.TrimLeft(path, " "), "*")
+		if _, err := hex.DecodeString(sum); !ok || err != nil || len(sum) != sha256.Size*2 || path == "" {
+			return nil, fmt.Errorf("%w: line %d", ErrInvalidManifest, line)
+		}
+		m[path] = strings.ToLower(sum)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
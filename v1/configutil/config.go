@@ -0,0 +1,216 @@
+// Package configutil 提供配置文件的加载、校验和热更新
+//
+// Package configutil provides loading, validation and hot reloading of config files.
+package configutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/supergodk/go-utils/v1/fileutil"
+	"github.com/supergodk/go-utils/v1/validateutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Load 读取并解析配置文件，然后用 validateutil.Validate 校验
+// 扩展名为 .yaml 或 .yml 时按 YAML 解析（使用 yaml 标签），其他按 JSON 解析
+// 参数:
+//   - path: 配置文件路径
+//   - dst: 解析目标，通常是结构体指针
+//
+// 返回:
+//   - error: 读取、解析或校验失败时返回错误，校验失败时可以用 errors.As 取出 *validateutil.ValidationError
+//
+// Load reads and parses a config file, then validates it with validateutil.Validate.
+// Files with a .yaml or .yml extension are parsed as YAML (using yaml tags); anything else is parsed as JSON.
+// Parameters:
+//   - path: The config file path
+//   - dst: The target, usually a pointer to a struct
+//
+// Returns:
+//   - error: Returns an error if reading, parsing or validation fails; use errors.As to get the *validateutil.ValidationError of a validation failure
+func Load(path string, dst any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return parse(path, data, dst)
+}
+
+// parse 按扩展名解析配置并校验
+//
+// parse parses a config by extension and validates it
+func parse(path string, data []byte, dst any) error {
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, dst)
+	default:
+		err = json.Unmarshal(data, dst)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	if err := validateutil.Validate(dst); err != nil {
+		return fmt.Errorf("invalid config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Watcher 持有配置的当前快照，文件变化时原子替换并通知订阅者
+// 快照在替换后不会被修改，调用者也不应修改 Get 返回的值，因此可以在任意 goroutine 中无锁读取
+//
+// Watcher holds the current snapshot of a config, atomically swapping it on file changes and notifying subscribers.
+// Snapshots are never modified once replaced, and callers must not modify the value returned by Get either, so it can be read from any goroutine without locks.
+type Watcher[T any] struct {
+	path     string
+	defaults func() *T
+	current  atomic.Pointer[T]
+	reloadMu sync.Mutex
+	data     []byte
+	mu       sync.Mutex
+	nextID   uint64
+	subs     []subscriber[T]
+}
+
+// subscriber 配置变化的订阅者
+//
+// subscriber is a subscriber to config changes
+type subscriber[T any] struct {
+	id uint64
+	fn func(old, cur *T)
+}
+
+// WatchConfig 加载配置并在文件变化时热更新，直到 ctx 结束，用于不重启服务即可调整限流阈值等参数
+// 文件每次变化时解析为新的 T 并校验，通过后原子替换当前快照，再依次调用 onChange 和订阅者；
+// 解析或校验失败时保持原来的快照并调用 opts.OnError，内容没有变化时不通知
+// 每次加载（包括初始加载）都解析到 defaults 返回的新值中，因此文件中缺少的键保持默认值，而不是在热更新后变成零值
+// 参数:
+//   - ctx: 上下文，结束后停止监听
+//   - path: 配置文件路径，格式见 Load
+//   - defaults: 返回填好默认值的新配置，每次加载调用一次，不能返回共享的值；为 nil 时使用 new(T)
+//   - onChange: 配置变化后的回调，参数是旧快照和新快照，可以为 nil
+//   - opts: 监听选项，可以为 nil
+//
+// 返回:
+//   - *Watcher[T]: 配置监听器
+//   - error: 初始加载失败时返回错误，此时不会开始监听
+//
+// WatchConfig loads a config and hot-reloads it when the file changes until ctx is done, for tuning parameters such as rate limits without restarts.
+// On every change the file is parsed into a new T and validated; once it passes, the current snapshot is swapped atomically and onChange and the subscribers are called in turn.
+// On parse or validation failures the current snapshot is kept and opts.OnError is called; nothing is notified when the content did not change.
+// Every load, including the initial one, decodes into a fresh value returned by defaults, so keys missing from the file keep their defaults instead of dropping to zero on a hot reload.
+// Parameters:
+//   - ctx: Context; watching stops when it is done
+//   - path: The config file path, in a format described by Load
+//   - defaults: Returns a new config with defaults filled in; it is called once per load and must not return a shared value. new(T) is used when nil
+//   - onChange: Callback invoked after a change with the old and new snapshots, may be nil
+//   - opts: Watch options, may be nil
+//
+// Returns:
+//   - *Watcher[T]: The config watcher
+//   - error: Returns an error if the initial load fails, in which case nothing is watched
+func WatchConfig[T any](ctx context.Context, path string, defaults func() *T, onChange func(old, cur *T), opts *fileutil.WatchOptions) (*Watcher[T], error) {
+	if defaults == nil {
+		defaults = func() *T { return new(T) }
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg := defaults()
+	if err := parse(path, data, cfg); err != nil {
+		return nil, err
+	}
+	w := &Watcher[T]{path: path, defaults: defaults, data: data}
+	w.current.Store(cfg)
+	if onChange != nil {
+		w.Subscribe(onChange)
+	}
+
+	var onError func(error)
+	if opts != nil {
+		onError = opts.OnError
+	}
+	go fileutil.Watch(ctx, path, func() {
+		if err := w.Reload(); err != nil && onError != nil {
+			onError(err)
+		}
+	}, opts)
+	return w, nil
+}
+
+// Get 返回当前的配置快照
+//
+// Get returns the current config snapshot.
+func (w *Watcher[T]) Get() *T {
+	return w.current.Load()
+}
+
+// Subscribe 订阅配置变化，回调在重新加载的 goroutine 中按订阅顺序执行
+// 参数:
+//   - fn: 回调，参数是旧快照和新快照
+//
+// 返回:
+//   - func(): 取消订阅的函数，可以重复调用
+//
+// Subscribe subscribes to config changes; callbacks run on the reloading goroutine in subscription order.
+// Parameters:
+//   - fn: The callback, receiving the old and new snapshots
+//
+// Returns:
+//   - func(): A function that cancels the subscription; it may be called more than once
+func (w *Watcher[T]) Subscribe(fn func(old, cur *T)) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nextID++
+	id := w.nextID
+	w.subs = append(w.subs, subscriber[T]{id: id, fn: fn})
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for i, s := range w.subs {
+			if s.id == id {
+				w.subs = append(w.subs[:i:i], w.subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Reload 立即重新加载配置，例如在收到 SIGHUP 时调用；内容没有变化时不通知
+//
+// Reload reloads the config immediately, e.g. on SIGHUP; nothing is notified when the content did not change.
+func (w *Watcher[T]) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(data, w.data) {
+		return nil
+	}
+	cur := w.defaults()
+	if err := parse(w.path, data, cur); err != nil {
+		return err
+	}
+	w.data = data
+	old := w.current.Swap(cur)
+
+	// 回调在 reloadMu 内执行，保证订阅者按加载顺序收到变化；不持有 mu，回调中可以订阅或取消订阅
+	w.mu.Lock()
+	subs := w.subs
+	w.mu.Unlock()
+	for _, s := range subs {
+		s.fn(old, cur)
+	}
+	return nil
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/lestrrat-go/jwx/v3/jwa"
 	"github.com/lestrrat-go/jwx/v3/jwk"
+
+	"github.com/supergodk/go-utils/v1/tracing"
 )
 
 const (
@@ -52,13 +54,10 @@ const (
 )
 
 var (
-	// 全局公钥缓存
+	// 包级函数使用的默认获取器
 	//
-	// Global public key cache
-	globalKeyCache = &keyCache{
-		keys:      make(map[string]*rsa.PublicKey),
-		fetchTime: time.Time{}, // 零值表示未初始化
-	}
+	// Default fetcher used by the package-level functions
+	defaultAppleKeyFetcher = NewAppleKeyFetcher(nil)
 	// ErrPublicKeyNotFound 表示 Apple 公钥未找到
 	//
 	// ErrPublicKeyNotFound indicates that the Apple public key was not found
@@ -92,6 +91,74 @@ type keyCache struct {
 	mutex     sync.RWMutex              // 读写锁
 }
 
+// AppleKeyFetcherOptions AppleKeyFetcher 的选项
+// Client: 发送请求的 HTTP 客户端，例如配置了代理、自定义 TLS 或链路追踪的客户端，默认 http.DefaultClient
+// URL: 公钥地址，默认 AppleAuthKeysURL，测试时可以指向 testutil.FakeJWKSServer
+// Timeout: 单次请求的超时时间，默认 HTTPRequestTimeout
+// CacheTTL: 公钥缓存有效期，默认 KeyCacheTTL
+//
+// AppleKeyFetcherOptions contains options for AppleKeyFetcher.
+// Client: The HTTP client sending requests, e.g. one configured with a proxy, custom TLS or tracing; http.DefaultClient by default
+// URL: The key endpoint, AppleAuthKeysURL by default; point it at testutil.FakeJWKSServer in tests
+// Timeout: Timeout of one request, HTTPRequestTimeout by default
+// CacheTTL: Public key cache validity period, KeyCacheTTL by default
+type AppleKeyFetcherOptions struct {
+	Client   *http.Client
+	URL      string
+	Timeout  time.Duration
+	CacheTTL time.Duration
+}
+
+// AppleKeyFetcher 获取并缓存 Apple 公钥，GetApplePublicKey、VerifyAppleToken 等包级函数使用默认选项的实例
+//
+// AppleKeyFetcher fetches and caches Apple public keys; the package-level functions such as GetApplePublicKey and VerifyAppleToken use an instance with default options.
+type AppleKeyFetcher struct {
+	client  *http.Client
+	url     string
+	timeout time.Duration
+	ttl     time.Duration
+	cache   keyCache
+}
+
+// NewAppleKeyFetcher 创建 Apple 公钥获取器
+// 参数:
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *AppleKeyFetcher: 公钥获取器
+//
+// NewAppleKeyFetcher creates an Apple public key fetcher.
+// Parameters:
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *AppleKeyFetcher: The public key fetcher
+func NewAppleKeyFetcher(opts *AppleKeyFetcherOptions) *AppleKeyFetcher {
+	var o AppleKeyFetcherOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	if o.URL == "" {
+		o.URL = AppleAuthKeysURL
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = HTTPRequestTimeout
+	}
+	if o.CacheTTL <= 0 {
+		o.CacheTTL = KeyCacheTTL
+	}
+	return &AppleKeyFetcher{
+		client:  o.Client,
+		url:     o.URL,
+		timeout: o.Timeout,
+		ttl:     o.CacheTTL,
+		cache:   keyCache{keys: make(map[string]*rsa.PublicKey)},
+	}
+}
+
 // GetApplePublicKey 获取指定 Kid 的 Apple 公钥
 // 如果公钥未缓存或缓存已过期，会自动从 Apple 服务器获取
 // 参数:
@@ -112,16 +179,37 @@ type keyCache struct {
 //   - *rsa.PublicKey: RSA public key
 //   - error: Returns an error if retrieval fails
 func GetApplePublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	return defaultAppleKeyFetcher.PublicKey(ctx, kid)
+}
+
+// PublicKey 获取指定 Kid 的公钥，公钥未缓存或缓存已过期时自动重新获取
+// 参数:
+//   - ctx: 上下文，用于控制请求超时和取消
+//   - kid: 密钥 ID（Key ID）
+//
+// 返回:
+//   - *rsa.PublicKey: RSA 公钥
+//   - error: 如果获取失败，返回错误
+//
+// PublicKey retrieves the public key for the specified Kid, fetching again if it is not cached or the cache has expired.
+// Parameters:
+//   - ctx: Context for controlling request timeout and cancellation
+//   - kid: Key ID
+//
+// Returns:
+//   - *rsa.PublicKey: RSA public key
+//   - error: Returns an error if retrieval fails
+func (f *AppleKeyFetcher) PublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
 	if kid == "" {
 		return nil, fmt.Errorf("%w: key ID (kid) is empty", ErrPublicKeyNotFound)
 	}
 
 	// 首先尝试从缓存中读取（读锁）
-	globalKeyCache.mutex.RLock()
-	key, exists := globalKeyCache.keys[kid]
-	isCacheValid := !globalKeyCache.fetchTime.IsZero() &&
-		time.Since(globalKeyCache.fetchTime) < KeyCacheTTL
-	globalKeyCache.mutex.RUnlock()
+	f.cache.mutex.RLock()
+	key, exists := f.cache.keys[kid]
+	isCacheValid := !f.cache.fetchTime.IsZero() &&
+		time.Since(f.cache.fetchTime) < f.ttl
+	f.cache.mutex.RUnlock()
 
 	// 如果密钥存在且缓存有效，直接返回
 	if exists && isCacheValid {
@@ -129,26 +217,26 @@ func GetApplePublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error)
 	}
 
 	// 缓存无效或密钥不存在，需要刷新缓存（写锁）
-	globalKeyCache.mutex.Lock()
+	f.cache.mutex.Lock()
 	// 双重检查，防止在获取锁的过程中其他协程已经更新了缓存
-	isCacheStillValid := !globalKeyCache.fetchTime.IsZero() &&
-		time.Since(globalKeyCache.fetchTime) < KeyCacheTTL
+	isCacheStillValid := !f.cache.fetchTime.IsZero() &&
+		time.Since(f.cache.fetchTime) < f.ttl
 	if !isCacheStillValid {
 		// 缓存已过期，获取新的公钥
-		newKeys, err := FetchApplePublicKeys(ctx)
+		newKeys, err := f.FetchKeys(ctx)
 		if err != nil {
-			globalKeyCache.mutex.Unlock()
+			f.cache.mutex.Unlock()
 			return nil, err
 		}
 
 		// 更新缓存
-		globalKeyCache.keys = newKeys
-		globalKeyCache.fetchTime = time.Now()
+		f.cache.keys = newKeys
+		f.cache.fetchTime = time.Now()
 	}
 
 	// 从更新后的缓存中查找密钥
-	key, exists = globalKeyCache.keys[kid]
-	globalKeyCache.mutex.Unlock()
+	key, exists = f.cache.keys[kid]
+	f.cache.mutex.Unlock()
 
 	if !exists {
 		return nil, fmt.Errorf("%w: kid=%s", ErrPublicKeyNotFound, kid)
@@ -157,6 +245,22 @@ func GetApplePublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error)
 	return key, nil
 }
 
+// AppleKeysFetchedAt 返回缓存的 Apple 公钥最后一次成功获取的时间，从未获取时返回零值，可用于健康检查
+//
+// AppleKeysFetchedAt returns when the cached Apple public keys were last fetched successfully, or the zero time if never; useful for health checks.
+func AppleKeysFetchedAt() time.Time {
+	return defaultAppleKeyFetcher.FetchedAt()
+}
+
+// FetchedAt 返回缓存的公钥最后一次成功获取的时间，从未获取时返回零值，可用于健康检查
+//
+// FetchedAt returns when the cached public keys were last fetched successfully, or the zero time if never; useful for health checks.
+func (f *AppleKeyFetcher) FetchedAt() time.Time {
+	f.cache.mutex.RLock()
+	defer f.cache.mutex.RUnlock()
+	return f.cache.fetchTime
+}
+
 // FetchApplePublicKeys 从 Apple 服务器获取最新的公钥
 // 返回公钥映射（kid -> 公钥）和可能的错误
 // 参数:
@@ -175,17 +279,46 @@ func GetApplePublicKey(ctx context.Context, kid string) (*rsa.PublicKey, error)
 //   - map[string]*rsa.PublicKey: Public key mapping (kid -> public key)
 //   - error: Returns an error if fetching fails
 func FetchApplePublicKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	return defaultAppleKeyFetcher.FetchKeys(ctx)
+}
+
+// FetchKeys 从服务器获取最新的公钥，不读取也不更新缓存
+// 参数:
+//   - ctx: 上下文，用于控制请求超时和取消
+//
+// 返回:
+//   - map[string]*rsa.PublicKey: 公钥映射（kid -> 公钥）
+//   - error: 如果获取失败，返回错误
+//
+// FetchKeys fetches the latest public keys from the server, neither reading nor updating the cache.
+// Parameters:
+//   - ctx: Context for controlling request timeout and cancellation
+//
+// Returns:
+//   - map[string]*rsa.PublicKey: Public key mapping (kid -> public key)
+//   - error: Returns an error if fetching fails
+func (f *AppleKeyFetcher) FetchKeys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	ctx, end := tracing.StartSpan(ctx, "cryptoutil.FetchApplePublicKeys")
+	keys, err := f.fetch(ctx)
+	end(err)
+	return keys, err
+}
+
+// fetch 实际获取公钥
+//
+// fetch does the actual fetch of the public keys
+func (f *AppleKeyFetcher) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
 	// 创建带超时的HTTP请求
-	reqCtx, cancel := context.WithTimeout(ctx, HTTPRequestTimeout)
+	reqCtx, cancel := context.WithTimeout(ctx, f.timeout)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, AppleAuthKeysURL, nil)
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, f.url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("%w: failed to create request: %v", ErrFetchKeys, err)
 	}
 
 	// 发送请求
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := f.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("%w: HTTP request failed: %v", ErrFetchKeys, err)
 	}
@@ -273,6 +406,27 @@ func FetchApplePublicKeys(ctx context.Context) (map[string]*rsa.PublicKey, error
 //   - string: User identifier (Subject) from the token
 //   - error: Returns an error if verification fails
 func VerifyAppleToken(tokenString string) (string, error) {
+	return defaultAppleKeyFetcher.VerifyToken(context.Background(), tokenString)
+}
+
+// VerifyToken 验证 Apple JWT token 并返回用户标识（Subject），使用本获取器的公钥
+// 参数:
+//   - ctx: 上下文，用于控制获取公钥的请求
+//   - tokenString: Apple JWT token 字符串
+//
+// 返回:
+//   - string: token 中的用户标识（Subject）
+//   - error: 如果验证失败，返回错误
+//
+// VerifyToken verifies an Apple JWT token and returns the user identifier (Subject), using this fetcher's public keys.
+// Parameters:
+//   - ctx: Context controlling the public key request
+//   - tokenString: Apple JWT token string
+//
+// Returns:
+//   - string: User identifier (Subject) from the token
+//   - error: Returns an error if verification fails
+func (f *AppleKeyFetcher) VerifyToken(ctx context.Context, tokenString string) (string, error) {
 	// 创建标准JWT声明结构
 	claims := &jwt.RegisteredClaims{}
 
@@ -289,12 +443,8 @@ func VerifyAppleToken(tokenString string) (string, error) {
 			return nil, fmt.Errorf("%s", ErrMissingKID)
 		}
 
-		// 创建带超时的上下文
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		// 获取Apple公钥
-		pubKey, err := GetApplePublicKey(ctx, kid)
+		// 获取Apple公钥，请求超时由 f.timeout 控制
+		pubKey, err := f.PublicKey(ctx, kid)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get Apple public key: %w", err)
 		}
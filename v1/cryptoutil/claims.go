@@ -0,0 +1,384 @@
+package cryptoutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidClaims 表示令牌声明未通过 ClaimsValidator 的校验
+//
+// ErrInvalidClaims indicates that the token claims failed ClaimsValidator checks
+var ErrInvalidClaims = errors.New("invalid claims")
+
+// ClaimType 声明值的期望类型
+//
+// ClaimType is the expected type of a claim value
+type ClaimType int
+
+const (
+	// ClaimAny 不检查类型
+	//
+	// ClaimAny does not check the type
+	ClaimAny ClaimType = iota
+	// ClaimString 字符串
+	//
+	// ClaimString is a string
+	ClaimString
+	// ClaimNumber 数字
+	//
+	// ClaimNumber is a number
+	ClaimNumber
+	// ClaimBool 布尔值
+	//
+	// ClaimBool is a boolean
+	ClaimBool
+	// ClaimStrings 字符串或字符串数组，例如 aud 和 scope
+	//
+	// ClaimStrings is a string or an array of strings, such as aud and scope
+	ClaimStrings
+	// ClaimObject JSON 对象
+	//
+	// ClaimObject is a JSON object
+	ClaimObject
+)
+
+// String 返回类型名称
+//
+// String returns the type name.
+func (t ClaimType) String() string {
+	switch t {
+	case ClaimString:
+		return "string"
+	case ClaimNumber:
+		return "number"
+	case ClaimBool:
+		return "bool"
+	case ClaimStrings:
+		return "string or string array"
+	case ClaimObject:
+		return "object"
+	}
+	return "any"
+}
+
+// ClaimRule 单个声明的自定义规则，值不符合时返回描述原因的错误
+//
+// ClaimRule is a custom rule for a single claim; it returns an error describing the reason when the value does not conform.
+type ClaimRule func(value any) error
+
+// ClaimViolation 一条校验失败
+// Claim: 声明名称，跨声明规则为空
+// Reason: 失败原因
+//
+// ClaimViolation is one failed check.
+// Claim: The claim name, empty for cross-claim rules
+// Reason: The reason for the failure
+type ClaimViolation struct {
+	Claim  string
+	Reason string
+}
+
+// ClaimsError 声明校验失败的结构化错误，包含全部失败项，errors.Is(err, ErrInvalidClaims) 为 true
+//
+// ClaimsError is the structured error for failed claim checks, listing every violation; errors.Is(err, ErrInvalidClaims) is true.
+type ClaimsError struct {
+	Violations []ClaimViolation
+}
+
+// Error 实现 error 接口
+//
+// Error implements the error interface.
+func (e *ClaimsError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		if v.Claim == "" {
+			parts[i] = v.Reason
+		} else {
+			parts[i] = v.Claim + ": " + v.Reason
+		}
+	}
+	return ErrInvalidClaims.Error() + ": " + strings.Join(parts, "; ")
+}
+
+// Unwrap 返回 ErrInvalidClaims
+//
+// Unwrap returns ErrInvalidClaims.
+func (e *ClaimsError) Unwrap() error {
+	return ErrInvalidClaims
+}
+
+// claimSpec 单个声明的校验规则
+//
+// claimSpec holds the checks for a single claim
+type claimSpec struct {
+	name     string
+	required bool
+	typ      ClaimType
+	rules    []ClaimRule
+}
+
+// ClaimsValidator 声明校验器，在令牌验证通过后集中校验声明的存在性、类型和业务规则，避免每个处理函数各自检查
+// 通过 Require、Optional 和 Rule 链式构建，构建完成后并发安全
+//
+// ClaimsValidator checks the presence, types and business rules of claims in one place once a token is verified, so handlers stop re-checking them ad hoc.
+// Build it by chaining Require, Optional and Rule; it is safe for concurrent use once built.
+type ClaimsValidator struct {
+	specs []claimSpec
+	rules []func(Claims) error
+}
+
+// NewClaimsValidator 创建空的声明校验器
+//
+// NewClaimsValidator creates an empty claims validator.
+func NewClaimsValidator() *ClaimsValidator {
+	return &ClaimsValidator{}
+}
+
+// Require 要求声明存在且类型为 typ，并满足全部规则
+// 参数:
+//   - claim: 声明名称
+//   - typ: 期望类型，ClaimAny 表示不检查
+//   - rules: 自定义规则，例如 ClaimContains("orders:write")
+//
+// 返回:
+//   - *ClaimsValidator: 校验器本身，便于链式调用
+//
+// Require requires the claim to be present, of type typ, and to satisfy every rule.
+// Parameters:
+//   - claim: The claim name
+//   - typ: The expected type; ClaimAny skips the check
+//   - rules: Custom rules, e.g. ClaimContains("orders:write")
+//
+// Returns:
+//   - *ClaimsValidator: The validator itself, for chaining
+func (v *ClaimsValidator) Require(claim string, typ ClaimType, rules ...ClaimRule) *ClaimsValidator {
+	v.specs = append(v.specs, claimSpec{name: claim, required: true, typ: typ, rules: rules})
+	return v
+}
+
+// Optional 与 Require 相同，但声明不存在时不报错
+//
+// Optional is like Require but does not fail when the claim is absent.
+func (v *ClaimsValidator) Optional(claim string, typ ClaimType, rules ...ClaimRule) *ClaimsValidator {
+	v.specs = append(v.specs, claimSpec{name: claim, typ: typ, rules: rules})
+	return v
+}
+
+// Rule 添加涉及多个声明的规则，例如 "role 为 admin 时必须有 org"，返回的错误作为一条 Claim 为空的失败项
+//
+// Rule adds a rule spanning several claims, e.g. "org is required when role is admin"; a returned error becomes a violation with an empty Claim.
+func (v *ClaimsValidator) Rule(rule func(Claims) error) *ClaimsValidator {
+	v.rules = append(v.rules, rule)
+	return v
+}
+
+// Validate 校验声明，按添加顺序收集所有失败项
+// 参数:
+//   - claims: 已验证的令牌声明
+//
+// 返回:
+//   - error: 全部通过时返回 nil，否则返回 *ClaimsError
+//
+// Validate checks the claims, collecting every violation in the order the checks were added.
+// Parameters:
+//   - claims: The claims of a verified token
+//
+// Returns:
+//   - error: nil if every check passes, otherwise a *ClaimsError
+func (v *ClaimsValidator) Validate(claims Claims) error {
+	var violations []ClaimViolation
+	for _, spec := range v.specs {
+		value, ok := claims[spec.name]
+		if !ok || value == nil {
+			if spec.required {
+				violations = append(violations, ClaimViolation{Claim: spec.name, Reason: "required"})
+			}
+			continue
+		}
+		if !claimHasType(value, spec.typ) {
+			violations = append(violations, ClaimViolation{Claim: spec.name, Reason: fmt.Sprintf("must be %v", spec.typ)})
+			continue
+		}
+		for _, rule := range spec.rules {
+			if err := rule(value); err != nil {
+				violations = append(violations, ClaimViolation{Claim: spec.name, Reason: err.Error()})
+			}
+		}
+	}
+	for _, rule := range v.rules {
+		if err := rule(claims); err != nil {
+			violations = append(violations, ClaimViolation{Reason: err.Error()})
+		}
+	}
+	if len(violations) > 0 {
+		return &ClaimsError{Violations: violations}
+	}
+	return nil
+}
+
+// WithClaimsValidator 为任意验证器增加声明校验，签名和有效期验证通过后再执行 validator
+//
+// WithClaimsValidator adds claim checks to any verifier; validator runs after the signature and validity checks pass.
+func WithClaimsValidator(verifier TokenVerifier, validator *ClaimsValidator) TokenVerifier {
+	return &claimsVerifier{next: verifier, validator: validator}
+}
+
+// claimsVerifier WithClaimsValidator 返回的验证器
+//
+// claimsVerifier is the verifier returned by WithClaimsValidator
+type claimsVerifier struct {
+	next      TokenVerifier
+	validator *ClaimsValidator
+}
+
+// Verify 实现 TokenVerifier 接口
+//
+// Verify implements the TokenVerifier interface.
+func (v *claimsVerifier) Verify(ctx context.Context, token string) (Claims, error) {
+	claims, err := v.next.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.validator.Validate(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// OneTime 实现 OneTimeVerifier 接口，与被包装的验证器一致
+//
+// OneTime implements the OneTimeVerifier interface, following the wrapped verifier.
+func (v *claimsVerifier) OneTime() bool {
+	return isOneTime(v.next)
+}
+
+// leeway 返回被包装的验证器的时钟容差
+//
+// leeway returns the clock leeway of the wrapped verifier
+func (v *claimsVerifier) leeway() time.Duration {
+	return verifierLeeway(v.next)
+}
+
+// ClaimContains 要求声明包含全部 values：字符串按空白分割（OAuth 的 scope 格式），数组按元素比较
+//
+// ClaimContains requires the claim to contain all values: strings are split on whitespace (the OAuth scope format), arrays are compared element by element.
+func ClaimContains(values ...string) ClaimRule {
+	return func(value any) error {
+		var have []string
+		if s, ok := value.(string); ok {
+			have = strings.Fields(s)
+		} else {
+			have, _ = claimStrings(value)
+		}
+		for _, want := range values {
+			if !slices.Contains(have, want) {
+				return fmt.Errorf("must contain %q", want)
+			}
+		}
+		return nil
+	}
+}
+
+// ClaimOneOf 要求声明的值在 allowed 中；声明为数组时（例如多受众的 aud）至少一个元素在 allowed 中
+//
+// ClaimOneOf requires the claim value to be in allowed; for arrays (e.g. an aud with several audiences) at least one element must be.
+func ClaimOneOf(allowed ...string) ClaimRule {
+	return func(value any) error {
+		have, _ := claimStrings(value)
+		for _, s := range have {
+			if slices.Contains(allowed, s) {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(allowed, ", "))
+	}
+}
+
+// ClaimEquals 要求声明等于 want，数字按数值比较，例如 ClaimEquals(true) 或 ClaimEquals(2)
+//
+// ClaimEquals requires the claim to equal want, comparing numbers by value, e.g. ClaimEquals(true) or ClaimEquals(2).
+func ClaimEquals(want any) ClaimRule {
+	return func(value any) error {
+		if a, ok := claimNumber(value); ok {
+			if b, ok := claimNumber(want); ok && a == b {
+				return nil
+			}
+		} else if reflect.DeepEqual(value, want) {
+			return nil
+		}
+		return fmt.Errorf("must equal %v", want)
+	}
+}
+
+// claimHasType 判断声明值是否为 typ 类型
+//
+// claimHasType reports whether the claim value is of type typ
+func claimHasType(value any, typ ClaimType) bool {
+	switch typ {
+	case ClaimString:
+		_, ok := value.(string)
+		return ok
+	case ClaimNumber:
+		_, ok := claimNumber(value)
+		return ok
+	case ClaimBool:
+		_, ok := value.(bool)
+		return ok
+	case ClaimStrings:
+		_, ok := claimStrings(value)
+		return ok
+	case ClaimObject:
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return true
+}
+
+// claimStrings 将字符串或字符串数组声明转换为字符串切片
+//
+// claimStrings converts a string or string array claim to a string slice
+func claimStrings(value any) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, true
+	case []string:
+		return v, true
+	case []any:
+		out := make([]string, 0, len(v))
+		for _, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, false
+			}
+			out = append(out, s)
+		}
+		return out, true
+	}
+	return nil, false
+}
+
+// claimNumber 将数字声明转换为 float64，JSON 解码的数字为 float64 或 json.Number
+//
+// claimNumber converts a numeric claim to float64; JSON-decoded numbers are float64 or json.Number
+func claimNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
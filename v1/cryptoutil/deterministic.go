@@ -0,0 +1,209 @@
+package cryptoutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// deterministicMagic 确定性密文的格式标识
+	//
+	// deterministicMagic identifies the deterministic ciphertext format
+	deterministicMagic = "DE"
+	// deterministicFormatVersion 确定性密文的格式版本
+	//
+	// deterministicFormatVersion is the version of the deterministic ciphertext format
+	deterministicFormatVersion = 1
+	// sivSize 合成 IV 的长度
+	//
+	// sivSize is the size of the synthetic IV
+	sivSize = 16
+)
+
+// DeterministicEncryptor 确定性加密器，相同的明文和附加数据总是得到相同的密文，因此加密后的字段仍可做等值查询（例如按邮箱查找用户）
+// 构造方式为 HMAC 派生的合成 IV（SIV）：IV = HMAC-SHA256(macKey, aad, 明文) 的前 16 字节，再以该 IV 用 AES-256-CTR 加密，解密时重新计算并校验 IV，可发现篡改
+//
+// 取舍：确定性加密会泄露"两条记录的值是否相同"以及值的出现频率，对取值空间小的字段（性别、状态等）几乎等于明文，只应用于需要等值查询的高基数字段；
+// 不支持范围查询和模糊查询；密文长度会泄露明文长度。加密前应先规范化明文（例如邮箱转小写），并用 aad 绑定字段（例如 "users.email"），避免不同字段间的密文可以互相比较。
+// 密钥必须与信封加密（Encryptor）的主密钥分开，不要复用同一个密钥
+//
+// DeterministicEncryptor performs deterministic encryption: the same plaintext and associated data always produce the same ciphertext, so encrypted fields stay equality-searchable (e.g. looking users up by email).
+// It uses an HMAC-derived synthetic IV (SIV): IV = the first 16 bytes of HMAC-SHA256(macKey, aad, plaintext), then AES-256-CTR under that IV; decryption recomputes and checks the IV, detecting tampering.
+//
+// Trade-offs: deterministic encryption reveals whether two records hold the same value and how often each value occurs, which for low-cardinality fields (gender, status, ...) is nearly plaintext, so use it only for high-cardinality fields that need equality lookups.
+// Range and fuzzy queries are not supported, and the ciphertext length reveals the plaintext length. Normalize plaintexts before encrypting (e.g. lowercase emails) and bind the field with aad (e.g. "users.email") so ciphertexts of different fields cannot be compared.
+// Its key must be separate from the envelope encryption (Encryptor) master keys; never reuse the same key.
+type DeterministicEncryptor struct {
+	block  cipher.Block
+	macKey []byte
+}
+
+// NewDeterministicEncryptor 创建确定性加密器，加密密钥和 MAC 密钥通过 HKDF-SHA256 从 key 派生
+// 参数:
+//   - key: 32 字节的专用密钥
+//
+// 返回:
+//   - *DeterministicEncryptor: 加密器，并发安全
+//   - error: 密钥长度不是 32 字节时返回 ErrInvalidMasterKey
+//
+// NewDeterministicEncryptor creates a deterministic encryptor; the encryption and MAC keys are derived from key with HKDF-SHA256.
+// Parameters:
+//   - key: A dedicated 32-byte key
+//
+// Returns:
+//   - *DeterministicEncryptor: The encryptor, safe for concurrent use
+//   - error: Returns ErrInvalidMasterKey if the key is not 32 bytes
+func NewDeterministicEncryptor(key []byte) (*DeterministicEncryptor, error) {
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("%w: key must be %d bytes", ErrInvalidMasterKey, dataKeySize)
+	}
+	encKey, err := hkdf.Key(sha256.New, key, nil, "go-utils cryptoutil deterministic v1 enc", dataKeySize)
+	if err != nil {
+		return nil, err
+	}
+	macKey, err := hkdf.Key(sha256.New, key, nil, "go-utils cryptoutil deterministic v1 mac", sha256.Size)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	return &DeterministicEncryptor{block: block, macKey: macKey}, nil
+}
+
+// Encrypt 确定性地加密明文
+// 参数:
+//   - plaintext: 明文，应先规范化
+//   - aad: 附加认证数据，通常是字段名，解密时必须相同
+//
+// 返回:
+//   - []byte: 密文，格式为 "DE" + 版本 + IV + 密文
+//
+// Encrypt encrypts the plaintext deterministically.
+// Parameters:
+//   - plaintext: The plaintext, normalized beforehand
+//   - aad: Additional authenticated data, usually the field name; it must match on decryption
+//
+// Returns:
+//   - []byte: The ciphertext: "DE" + version + IV + ciphertext
+func (d *DeterministicEncryptor) Encrypt(plaintext, aad []byte) []byte {
+	out := make([]byte, len(deterministicMagic)+1+sivSize+len(plaintext))
+	n := copy(out, deterministicMagic)
+	out[n] = deterministicFormatVersion
+	siv := out[n+1 : n+1+sivSize]
+	copy(siv, d.siv(plaintext, aad))
+	cipher.NewCTR(d.block, siv).XORKeyStream(out[n+1+sivSize:], plaintext)
+	return out
+}
+
+// Decrypt 解密 Encrypt 生成的密文
+// 参数:
+//   - ciphertext: 密文
+//   - aad: 加密时使用的附加认证数据
+//
+// 返回:
+//   - []byte: 明文
+//   - error: 密文格式错误、被篡改或 aad 不匹配时返回 ErrInvalidCiphertext
+//
+// Decrypt decrypts a ciphertext produced by Encrypt.
+// Parameters:
+//   - ciphertext: The ciphertext
+//   - aad: The additional authenticated data used when encrypting
+//
+// Returns:
+//   - []byte: The plaintext
+//   - error: Returns ErrInvalidCiphertext if the ciphertext is malformed, tampered with or the aad does not match
+func (d *DeterministicEncryptor) Decrypt(ciphertext, aad []byte) ([]byte, error) {
+	header := len(deterministicMagic) + 1
+	if len(ciphertext) < header+sivSize || string(ciphertext[:len(deterministicMagic)]) != deterministicMagic ||
+		ciphertext[len(deterministicMagic)] != deterministicFormatVersion {
+		return nil, ErrInvalidCiphertext
+	}
+	siv := ciphertext[header : header+sivSize]
+	plaintext := make([]byte, len(ciphertext)-header-sivSize)
+	cipher.NewCTR(d.block, siv).XORKeyStream(plaintext, ciphertext[header+sivSize:])
+	if !hmac.Equal(siv, d.siv(plaintext, aad)) {
+		return nil, ErrInvalidCiphertext
+	}
+	return plaintext, nil
+}
+
+// EncryptString 确定性地加密字符串，返回 URL 安全无填充 Base64 编码的密文，可直接存入字符串列并建索引
+//
+// EncryptString encrypts a string deterministically and returns the ciphertext as URL-safe unpadded Base64, ready to store in an indexed string column.
+func (d *DeterministicEncryptor) EncryptString(plaintext, aad string) string {
+	return base64.RawURLEncoding.EncodeToString(d.Encrypt([]byte(plaintext), []byte(aad)))
+}
+
+// DecryptString 解密 EncryptString 生成的密文
+//
+// DecryptString decrypts a ciphertext produced by EncryptString.
+func (d *DeterministicEncryptor) DecryptString(ciphertext, aad string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidCiphertext, err)
+	}
+	plaintext, err := d.Decrypt(data, []byte(aad))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// siv 计算合成 IV，aad 带长度前缀以避免 aad 与明文的边界被混淆
+//
+// siv computes the synthetic IV; aad is length-prefixed so the aad/plaintext boundary cannot be confused
+func (d *DeterministicEncryptor) siv(plaintext, aad []byte) []byte {
+	mac := hmac.New(sha256.New, d.macKey)
+	var l [8]byte
+	binary.BigEndian.PutUint64(l[:], uint64(len(aad)))
+	mac.Write(l[:])
+	mac.Write(aad)
+	mac.Write(plaintext)
+	return mac.Sum(nil)[:sivSize]
+}
+
+// EncryptDeterministic 使用 key 确定性地加密明文，等价于 NewDeterministicEncryptor(key) 后调用 Encrypt；频繁调用时请复用 DeterministicEncryptor
+// 参数:
+//   - key: 32 字节的专用密钥，不要与信封加密的主密钥复用
+//   - plaintext: 明文
+//   - aad: 附加认证数据，通常是字段名
+//
+// 返回:
+//   - []byte: 密文
+//   - error: 密钥长度不是 32 字节时返回 ErrInvalidMasterKey
+//
+// EncryptDeterministic encrypts the plaintext deterministically with key; it is NewDeterministicEncryptor(key) followed by Encrypt, so reuse a DeterministicEncryptor for frequent calls.
+// Parameters:
+//   - key: A dedicated 32-byte key; do not reuse an envelope encryption master key
+//   - plaintext: The plaintext
+//   - aad: Additional authenticated data, usually the field name
+//
+// Returns:
+//   - []byte: The ciphertext
+//   - error: Returns ErrInvalidMasterKey if the key is not 32 bytes
+func EncryptDeterministic(key, plaintext, aad []byte) ([]byte, error) {
+	d, err := NewDeterministicEncryptor(key)
+	if err != nil {
+		return nil, err
+	}
+	return d.Encrypt(plaintext, aad), nil
+}
+
+// DecryptDeterministic 解密 EncryptDeterministic 生成的密文
+//
+// DecryptDeterministic decrypts a ciphertext produced by EncryptDeterministic.
+func DecryptDeterministic(key, ciphertext, aad []byte) ([]byte, error) {
+	d, err := NewDeterministicEncryptor(key)
+	if err != nil {
+		return nil, err
+	}
+	return d.Decrypt(ciphertext, aad)
+}
@@ -0,0 +1,171 @@
+package cryptoutil
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// ErrKeyExchange 表示密钥交换或密钥派生失败
+//
+// ErrKeyExchange indicates that key exchange or key derivation failed
+var ErrKeyExchange = errors.New("key exchange failed")
+
+// GenerateECDHKey 生成 ECDH 密钥对
+// 参数:
+//   - curve: 曲线，例如 ecdh.X25519() 或 ecdh.P256()
+//
+// 返回:
+//   - *ecdh.PrivateKey: 私钥，公钥通过 PublicKey().Bytes() 发送给对方
+//   - error: 如果生成失败，返回错误
+//
+// GenerateECDHKey generates an ECDH key pair.
+// Parameters:
+//   - curve: The curve, e.g. ecdh.X25519() or ecdh.P256()
+//
+// Returns:
+//   - *ecdh.PrivateKey: The private key; send PublicKey().Bytes() to the peer
+//   - error: Returns an error if generation fails
+func GenerateECDHKey(curve ecdh.Curve) (*ecdh.PrivateKey, error) {
+	return curve.GenerateKey(rand.Reader)
+}
+
+// ParseECDHPublicKey 解析对方发来的公钥：X25519 为 32 字节，NIST 曲线为未压缩点格式
+//
+// ParseECDHPublicKey parses a public key received from the peer: 32 bytes for X25519, an uncompressed point for the NIST curves.
+func ParseECDHPublicKey(curve ecdh.Curve, raw []byte) (*ecdh.PublicKey, error) {
+	pub, err := curve.NewPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyExchange, err)
+	}
+	return pub, nil
+}
+
+// DeriveSharedSecret 计算 ECDH 共享密钥，双方用各自的私钥和对方的公钥得到相同的结果
+// 共享密钥不是均匀分布的，不能直接用作对称密钥，应通过 HKDF 或 DeriveSessionKey 派生
+// 参数:
+//   - priv: 己方私钥
+//   - peerPub: 对方公钥，必须与私钥使用同一曲线
+//
+// 返回:
+//   - []byte: 共享密钥
+//   - error: 曲线不一致或对方公钥无效（例如 X25519 的低阶点）时返回 ErrKeyExchange
+//
+// DeriveSharedSecret computes the ECDH shared secret; both sides get the same result from their own private key and the peer's public key.
+// The secret is not uniformly distributed and must not be used as a symmetric key directly; derive one with HKDF or DeriveSessionKey.
+// Parameters:
+//   - priv: Our private key
+//   - peerPub: The peer's public key, on the same curve as the private key
+//
+// Returns:
+//   - []byte: The shared secret
+//   - error: Returns ErrKeyExchange if the curves differ or the peer key is invalid (e.g. a low-order X25519 point)
+func DeriveSharedSecret(priv *ecdh.PrivateKey, peerPub *ecdh.PublicKey) ([]byte, error) {
+	secret, err := priv.ECDH(peerPub)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyExchange, err)
+	}
+	return secret, nil
+}
+
+// HKDFExtract HKDF 的提取步骤（RFC 5869），从输入密钥材料中提取伪随机密钥
+// 参数:
+//   - h: 哈希函数，例如 sha256.New
+//   - secret: 输入密钥材料，例如 ECDH 共享密钥
+//   - salt: 盐，可以为 nil
+//
+// 返回:
+//   - []byte: 伪随机密钥，长度为哈希输出长度
+//   - error: 如果提取失败，返回 ErrKeyExchange
+//
+// HKDFExtract is the HKDF extract step (RFC 5869), extracting a pseudorandom key from the input keying material.
+// Parameters:
+//   - h: The hash, e.g. sha256.New
+//   - secret: The input keying material, e.g. an ECDH shared secret
+//   - salt: The salt, may be nil
+//
+// Returns:
+//   - []byte: The pseudorandom key, as long as the hash output
+//   - error: Returns ErrKeyExchange if extraction fails
+func HKDFExtract(h func() hash.Hash, secret, salt []byte) ([]byte, error) {
+	prk, err := hkdf.Extract(h, secret, salt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyExchange, err)
+	}
+	return prk, nil
+}
+
+// HKDFExpand HKDF 的扩展步骤（RFC 5869），从伪随机密钥派生指定长度的密钥，不同的 info 得到互相独立的密钥
+// 参数:
+//   - h: 哈希函数，必须与提取时一致
+//   - prk: HKDFExtract 得到的伪随机密钥
+//   - info: 上下文信息，例如 "chat:v1:encrypt"
+//   - length: 派生的密钥长度，不能超过哈希输出长度的 255 倍
+//
+// 返回:
+//   - []byte: 派生的密钥
+//   - error: 如果长度超出限制，返回 ErrKeyExchange
+//
+// HKDFExpand is the HKDF expand step (RFC 5869), deriving a key of the given length from the pseudorandom key; different info values give independent keys.
+// Parameters:
+//   - h: The hash, the same as for extraction
+//   - prk: The pseudorandom key from HKDFExtract
+//   - info: Context information, e.g. "chat:v1:encrypt"
+//   - length: The derived key length, at most 255 times the hash output size
+//
+// Returns:
+//   - []byte: The derived key
+//   - error: Returns ErrKeyExchange if the length is out of range
+func HKDFExpand(h func() hash.Hash, prk []byte, info string, length int) ([]byte, error) {
+	key, err := hkdf.Expand(h, prk, info, length)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyExchange, err)
+	}
+	return key, nil
+}
+
+// HKDF 依次执行提取和扩展步骤，参见 HKDFExtract 和 HKDFExpand
+//
+// HKDF runs the extract and expand steps in turn. See HKDFExtract and HKDFExpand.
+func HKDF(h func() hash.Hash, secret, salt []byte, info string, length int) ([]byte, error) {
+	key, err := hkdf.Key(h, secret, salt, info, length)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyExchange, err)
+	}
+	return key, nil
+}
+
+// DeriveSessionKey 通过 ECDH 和 HKDF-SHA256 派生双方共享的会话密钥，例如用于端到端加密消息的 AES-256-GCM 密钥（length 为 32）
+// 参数:
+//   - priv: 己方私钥
+//   - peerPub: 对方公钥
+//   - salt: 盐，例如会话 ID，可以为 nil
+//   - info: 上下文信息，区分不同用途的密钥
+//   - length: 密钥长度
+//
+// 返回:
+//   - []byte: 会话密钥
+//   - error: 如果密钥交换或派生失败，返回 ErrKeyExchange
+//
+// DeriveSessionKey derives a session key shared by both sides via ECDH and HKDF-SHA256, e.g. an AES-256-GCM key (length 32) for end-to-end encrypted messages.
+// Parameters:
+//   - priv: Our private key
+//   - peerPub: The peer's public key
+//   - salt: The salt, e.g. a session ID, may be nil
+//   - info: Context information separating keys for different purposes
+//   - length: The key length
+//
+// Returns:
+//   - []byte: The session key
+//   - error: Returns ErrKeyExchange if key exchange or derivation fails
+func DeriveSessionKey(priv *ecdh.PrivateKey, peerPub *ecdh.PublicKey, salt []byte, info string, length int) ([]byte, error) {
+	secret, err := DeriveSharedSecret(priv, peerPub)
+	if err != nil {
+		return nil, err
+	}
+	return HKDF(sha256.New, secret, salt, info, length)
+}
@@ -0,0 +1,434 @@
+package cryptoutil
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// envelopeMagic 信封密文的格式标识
+	//
+	// envelopeMagic identifies the envelope ciphertext format
+	envelopeMagic = "EV"
+	// envelopeFormatVersion 信封密文的格式版本
+	//
+	// envelopeFormatVersion is the version of the envelope ciphertext format
+	envelopeFormatVersion = 1
+	// dataKeySize 数据密钥长度（AES-256）
+	//
+	// dataKeySize is the data key size (AES-256)
+	dataKeySize = 32
+)
+
+var (
+	// ErrInvalidCiphertext 表示密文格式无效或已被篡改
+	//
+	// ErrInvalidCiphertext indicates that the ciphertext is malformed or has been tampered with
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	// ErrInvalidMasterKey 表示主密钥长度或版本标识无效
+	//
+	// ErrInvalidMasterKey indicates that the master key length or version identifier is invalid
+	ErrInvalidMasterKey = errors.New("invalid master key")
+	// ErrUnknownKeyVersion 表示密文使用的主密钥版本不在密钥环中
+	//
+	// ErrUnknownKeyVersion indicates that the master key version of the ciphertext is not in the key ring
+	ErrUnknownKeyVersion = errors.New("unknown master key version")
+)
+
+// MasterKey 主密钥，用于加密（包装）和解密数据密钥
+// 可以是本地密钥（NewLocalMasterKey），也可以对接云 KMS 的 Encrypt/Decrypt 接口，主密钥本身不离开 KMS
+//
+// MasterKey is a master key used to wrap and unwrap data keys.
+// It can be a local key (NewLocalMasterKey) or backed by the Encrypt/Decrypt APIs of a cloud KMS, so the master key never leaves the KMS.
+type MasterKey interface {
+	// Version 返回主密钥的版本标识，会被写入密文头部，长度不超过 255 字节
+	//
+	// Version returns the version identifier of the master key, written to the ciphertext header; at most 255 bytes.
+	Version() string
+	// WrapKey 加密数据密钥
+	//
+	// WrapKey encrypts a data key.
+	WrapKey(ctx context.Context, dataKey []byte) ([]byte, error)
+	// UnwrapKey 解密数据密钥
+	//
+	// UnwrapKey decrypts a data key.
+	UnwrapKey(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// LocalMasterKey 使用 AES-256-GCM 在本地包装数据密钥的主密钥
+//
+// LocalMasterKey is a master key that wraps data keys locally with AES-256-GCM.
+type LocalMasterKey struct {
+	version string
+	aead    cipher.AEAD
+}
+
+// NewLocalMasterKey 创建本地主密钥
+// 参数:
+//   - version: 版本标识，例如 "2024-01"
+//   - key: 32 字节的 AES-256 密钥
+//
+// 返回:
+//   - *LocalMasterKey: 主密钥
+//   - error: 如果密钥长度无效，返回错误
+//
+// NewLocalMasterKey creates a local master key.
+// Parameters:
+//   - version: Version identifier, e.g. "2024-01"
+//   - key: 32-byte AES-256 key
+//
+// Returns:
+//   - *LocalMasterKey: The master key
+//   - error: Returns an error if the key length is invalid
+func NewLocalMasterKey(version string, key []byte) (*LocalMasterKey, error) {
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("%w: master key must be %d bytes", ErrInvalidMasterKey, dataKeySize)
+	}
+	if version == "" || len(version) > 255 {
+		return nil, fmt.Errorf("%w: version must be 1-255 bytes", ErrInvalidMasterKey)
+	}
+	aead, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalMasterKey{version: version, aead: aead}, nil
+}
+
+// Version 实现 MasterKey 接口
+//
+// Version implements the MasterKey interface.
+func (k *LocalMasterKey) Version() string {
+	return k.version
+}
+
+// WrapKey 实现 MasterKey 接口
+//
+// WrapKey implements the MasterKey interface.
+func (k *LocalMasterKey) WrapKey(_ context.Context, dataKey []byte) ([]byte, error) {
+	return gcmSeal(k.aead, dataKey, []byte(k.version))
+}
+
+// UnwrapKey 实现 MasterKey 接口
+//
+// UnwrapKey implements the MasterKey interface.
+func (k *LocalMasterKey) UnwrapKey(_ context.Context, wrapped []byte) ([]byte, error) {
+	return gcmOpen(k.aead, wrapped, []byte(k.version))
+}
+
+// Encryptor 信封加密器
+// 每次加密生成随机数据密钥，用 AES-256-GCM 加密数据，再用当前主密钥包装数据密钥，
+// 主密钥版本记录在密文头部，因此轮换主密钥后仍可透明解密旧密文
+//
+// Encryptor is an envelope encryptor.
+// Each encryption generates a random data key, encrypts the data with AES-256-GCM and wraps the data key with the current master key.
+// The master key version is recorded in the ciphertext header, so old ciphertexts remain transparently decryptable after rotation.
+type Encryptor struct {
+	mu      sync.RWMutex
+	current MasterKey
+	keys    map[string]MasterKey
+}
+
+// NewEncryptor 创建信封加密器
+// 参数:
+//   - current: 用于加密的当前主密钥
+//   - previous: 仅用于解密的历史主密钥
+//
+// NewEncryptor creates an envelope encryptor.
+// Parameters:
+//   - current: The current master key used for encryption
+//   - previous: Previous master keys, used only for decryption
+func NewEncryptor(current MasterKey, previous ...MasterKey) *Encryptor {
+	e := &Encryptor{keys: make(map[string]MasterKey)}
+	for _, k := range previous {
+		e.keys[k.Version()] = k
+	}
+	e.Rotate(current)
+	return e
+}
+
+// Rotate 轮换主密钥，之后的加密使用新主密钥，旧主密钥保留用于解密
+//
+// Rotate rotates the master key; subsequent encryptions use the new key while old keys are kept for decryption.
+func (e *Encryptor) Rotate(current MasterKey) {
+	e.mu.Lock()
+	e.current = current
+	e.keys[current.Version()] = current
+	e.mu.Unlock()
+}
+
+// CurrentVersion 返回当前主密钥版本
+//
+// CurrentVersion returns the current master key version.
+func (e *Encryptor) CurrentVersion() string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.current.Version()
+}
+
+// Encrypt 加密数据
+// 密文格式: "EV" | 格式版本(1) | 版本长度(1) | 主密钥版本 | 包装密钥长度(2) | 包装后的数据密钥 | nonce | 密文
+// 头部会作为附加数据参与认证，防止篡改版本或数据密钥
+// 参数:
+//   - ctx: 上下文，传递给主密钥（例如 KMS 调用）
+//   - plaintext: 明文
+//   - aad: 附加认证数据（例如记录 ID），解密时必须相同，可以为 nil
+//
+// 返回:
+//   - []byte: 密文
+//   - error: 如果包装数据密钥失败，返回错误
+//
+// Encrypt encrypts data.
+// Ciphertext format: "EV" | format version(1) | version length(1) | master key version | wrapped key length(2) | wrapped data key | nonce | ciphertext
+// The header is authenticated as additional data to prevent tampering with the version or data key.
+// Parameters:
+//   - ctx: Context, passed to the master key (e.g. for KMS calls)
+//   - plaintext: Plaintext
+//   - aad: Additional authenticated data (e.g. a record ID), must be identical when decrypting, may be nil
+//
+// Returns:
+//   - []byte: Ciphertext
+//   - error: Returns an error if wrapping the data key fails
+func (e *Encryptor) Encrypt(ctx context.Context, plaintext, aad []byte) ([]byte, error) {
+	e.mu.RLock()
+	master := e.current
+	e.mu.RUnlock()
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	wrapped, err := master.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return sealEnvelope(master.Version(), wrapped, dataKey, plaintext, aad)
+}
+
+// Decrypt 解密数据，自动选择密文头部记录的主密钥版本
+// 参数:
+//   - ctx: 上下文
+//   - ciphertext: Encrypt 生成的密文
+//   - aad: 加密时使用的附加认证数据
+//
+// 返回:
+//   - []byte: 明文
+//   - error: 密文无效返回 ErrInvalidCiphertext，主密钥版本未知返回 ErrUnknownKeyVersion
+//
+// Decrypt decrypts data, selecting the master key version recorded in the ciphertext header.
+// Parameters:
+//   - ctx: Context
+//   - ciphertext: Ciphertext produced by Encrypt
+//   - aad: Additional authenticated data used when encrypting
+//
+// Returns:
+//   - []byte: Plaintext
+//   - error: ErrInvalidCiphertext for invalid ciphertext, ErrUnknownKeyVersion for an unknown master key version
+func (e *Encryptor) Decrypt(ctx context.Context, ciphertext, aad []byte) ([]byte, error) {
+	env, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := e.unwrap(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(aead, env.body, append(env.header, aad...))
+}
+
+// ReEncrypt 使用当前主密钥重新包装密文中的数据密钥，数据本身不会重新加密
+// 用于轮换后逐步迁移旧密文，之后即可下线旧主密钥；密文已使用当前版本时原样返回
+//
+// ReEncrypt rewraps the data key in the ciphertext with the current master key without re-encrypting the data itself.
+// Used to gradually migrate old ciphertexts after rotation so the old master key can be retired; ciphertexts already on the current version are returned as is.
+func (e *Encryptor) ReEncrypt(ctx context.Context, ciphertext, aad []byte) ([]byte, error) {
+	env, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	e.mu.RLock()
+	master := e.current
+	e.mu.RUnlock()
+	if env.version == master.Version() {
+		return ciphertext, nil
+	}
+
+	dataKey, err := e.unwrap(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := gcmOpen(aead, env.body, append(env.header, aad...))
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := master.WrapKey(ctx, dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return sealEnvelope(master.Version(), wrapped, dataKey, plaintext, aad)
+}
+
+// EncryptString 加密字符串并返回 base64 编码的密文，适合存入数据库文本字段
+//
+// EncryptString encrypts a string and returns base64 encoded ciphertext, suitable for database text columns.
+func (e *Encryptor) EncryptString(ctx context.Context, plaintext string, aad []byte) (string, error) {
+	ct, err := e.Encrypt(ctx, []byte(plaintext), aad)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ct), nil
+}
+
+// DecryptString 解密 EncryptString 生成的 base64 密文
+//
+// DecryptString decrypts base64 ciphertext produced by EncryptString.
+func (e *Encryptor) DecryptString(ctx context.Context, ciphertext string, aad []byte) (string, error) {
+	ct, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidCiphertext, err)
+	}
+	pt, err := e.Decrypt(ctx, ct, aad)
+	if err != nil {
+		return "", err
+	}
+	return string(pt), nil
+}
+
+// EnvelopeKeyVersion 返回密文使用的主密钥版本，可用于统计尚未迁移的旧密文
+//
+// EnvelopeKeyVersion returns the master key version used by the ciphertext, useful for finding ciphertexts not yet migrated.
+func EnvelopeKeyVersion(ciphertext []byte) (string, error) {
+	env, err := parseEnvelope(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return env.version, nil
+}
+
+// envelope 解析后的信封密文
+//
+// envelope is a parsed envelope ciphertext
+type envelope struct {
+	header  []byte
+	version string
+	wrapped []byte
+	body    []byte
+}
+
+// unwrap 使用密文对应版本的主密钥解密数据密钥
+//
+// unwrap decrypts the data key with the master key of the ciphertext's version
+func (e *Encryptor) unwrap(ctx context.Context, env *envelope) ([]byte, error) {
+	e.mu.RLock()
+	master, ok := e.keys[env.version]
+	e.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKeyVersion, env.version)
+	}
+	dataKey, err := master.UnwrapKey(ctx, env.wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to unwrap data key: %v", ErrInvalidCiphertext, err)
+	}
+	return dataKey, nil
+}
+
+// sealEnvelope 构造头部并加密数据
+//
+// sealEnvelope builds the header and encrypts the data
+func sealEnvelope(version string, wrapped, dataKey, plaintext, aad []byte) ([]byte, error) {
+	if len(version) == 0 || len(version) > 255 || len(wrapped) > 0xffff {
+		return nil, fmt.Errorf("%w: key version or wrapped key too long", ErrInvalidCiphertext)
+	}
+	header := make([]byte, 0, len(envelopeMagic)+4+len(version)+len(wrapped))
+	header = append(header, envelopeMagic...)
+	header = append(header, envelopeFormatVersion, byte(len(version)))
+	header = append(header, version...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+
+	aead, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	body, err := gcmSeal(aead, plaintext, append(header[:len(header):len(header)], aad...))
+	if err != nil {
+		return nil, err
+	}
+	return append(header, body...), nil
+}
+
+// parseEnvelope 解析信封密文头部
+//
+// parseEnvelope parses the envelope ciphertext header
+func parseEnvelope(data []byte) (*envelope, error) {
+	n := len(envelopeMagic)
+	if len(data) < n+2 || string(data[:n]) != envelopeMagic || data[n] != envelopeFormatVersion {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidCiphertext)
+	}
+	vlen := int(data[n+1])
+	pos := n + 2
+	if len(data) < pos+vlen+2 {
+		return nil, fmt.Errorf("%w: truncated header", ErrInvalidCiphertext)
+	}
+	version := string(data[pos : pos+vlen])
+	pos += vlen
+	wlen := int(binary.BigEndian.Uint16(data[pos:]))
+	pos += 2
+	if len(data) < pos+wlen {
+		return nil, fmt.Errorf("%w: truncated header", ErrInvalidCiphertext)
+	}
+	wrapped := data[pos : pos+wlen]
+	pos += wlen
+	return &envelope{header: data[:pos:pos], version: version, wrapped: wrapped, body: data[pos:]}, nil
+}
+
+// newGCM 创建 AES-GCM
+//
+// newGCM creates an AES-GCM AEAD
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// gcmSeal 使用随机 nonce 加密，nonce 放在密文前面
+//
+// gcmSeal encrypts with a random nonce prepended to the ciphertext
+func gcmSeal(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+// gcmOpen 解密 gcmSeal 生成的数据
+//
+// gcmOpen decrypts data produced by gcmSeal
+func gcmOpen(aead cipher.AEAD, data, aad []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize()+aead.Overhead() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, ct := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, ct, aad)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return plain, nil
+}
@@ -0,0 +1,438 @@
+package cryptoutil
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const (
+	// fileMagic 加密文件的格式标识
+	//
+	// fileMagic identifies the encrypted file format
+	fileMagic = "cryptoutil-file/v1\n"
+	// fileKeySize 文件密钥长度，每个文件随机生成
+	//
+	// fileKeySize is the file key size; a random key is generated per file
+	fileKeySize = 16
+	// fileNonceSize 派生数据密钥使用的随机数长度
+	//
+	// fileNonceSize is the size of the nonce used to derive the payload key
+	fileNonceSize = 16
+	// fileChunkSize 每个数据块的明文长度
+	//
+	// fileChunkSize is the plaintext size of each payload chunk
+	fileChunkSize = 64 << 10
+	// fileStanzaSize 每个接收者条目的长度：临时公钥加上包装后的文件密钥
+	//
+	// fileStanzaSize is the size of each recipient stanza: the ephemeral public key plus the wrapped file key
+	fileStanzaSize = 32 + fileKeySize + chacha20poly1305.Overhead
+)
+
+var (
+	// ErrInvalidRecipient 表示接收者不是 X25519 公钥
+	//
+	// ErrInvalidRecipient indicates that a recipient is not an X25519 public key
+	ErrInvalidRecipient = errors.New("invalid recipient")
+	// ErrNoMatchingIdentity 表示给定的私钥都不是该文件的接收者
+	//
+	// ErrNoMatchingIdentity indicates that none of the given private keys is a recipient of the file
+	ErrNoMatchingIdentity = errors.New("no matching identity")
+	// ErrInvalidEncryptedFile 表示加密文件格式无效、被截断或已被篡改
+	//
+	// ErrInvalidEncryptedFile indicates that the encrypted file is malformed, truncated or has been tampered with
+	ErrInvalidEncryptedFile = errors.New("invalid encrypted file")
+)
+
+// EncryptFile 流式加密，适用于数据库备份等大文件在上传前的客户端加密
+// 格式参考 age：每个文件随机生成文件密钥，用 X25519 为每个接收者包装一份，数据按 64 KiB 分块用 ChaCha20-Poly1305 加密，
+// 块序号和最后一块标记参与认证，因此重排、截断或追加都会在解密时被发现；格式与 age 不兼容
+// 参数:
+//   - dst: 密文输出
+//   - src: 明文输入
+//   - recipients: 接收者的 X25519 公钥，任意一个对应的私钥都可以解密，例如同时包含运维密钥和离线备份的恢复密钥
+//
+// 返回:
+//   - error: 没有接收者或接收者不是 X25519 公钥时返回 ErrInvalidRecipient，读写失败时返回对应错误
+//
+// EncryptFile encrypts a stream, suitable for client-side encryption of large files such as database backups before upload.
+// The format follows age: a random file key is generated per file and wrapped for each recipient with X25519, and the data is encrypted in 64 KiB ChaCha20-Poly1305 chunks
+// whose sequence number and last-chunk flag are authenticated, so reordering, truncation or appended data is detected on decryption; the format is not age-compatible.
+// Parameters:
+//   - dst: Ciphertext output
+//   - src: Plaintext input
+//   - recipients: Recipients' X25519 public keys; the private key of any of them can decrypt, e.g. an operations key plus an offline recovery key
+//
+// Returns:
+//   - error: Returns ErrInvalidRecipient if there are no recipients or one is not an X25519 key, or the read/write error
+func EncryptFile(dst io.Writer, src io.Reader, recipients ...*ecdh.PublicKey) error {
+	w, err := NewFileEncryptWriter(dst, recipients...)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// DecryptFile 流式解密 EncryptFile 的输出
+// 注意: 数据在校验通过后按块写入 dst，文件在中途被篡改或截断时 dst 中已有前面的部分明文，调用者应在返回错误时丢弃输出
+// 参数:
+//   - dst: 明文输出
+//   - src: 密文输入
+//   - identities: 可用的 X25519 私钥，依次尝试
+//
+// 返回:
+//   - error: 没有匹配的私钥时返回 ErrNoMatchingIdentity，文件无效时返回 ErrInvalidEncryptedFile
+//
+// DecryptFile decrypts the output of EncryptFile as a stream.
+// Note: data is written to dst chunk by chunk once each chunk is authenticated, so when the file is tampered with or truncated midway dst already holds the earlier plaintext; callers should discard the output on error.
+// Parameters:
+//   - dst: Plaintext output
+//   - src: Ciphertext input
+//   - identities: Available X25519 private keys, tried in turn
+//
+// Returns:
+//   - error: Returns ErrNoMatchingIdentity if no key matches, or ErrInvalidEncryptedFile if the file is invalid
+func DecryptFile(dst io.Writer, src io.Reader, identities ...*ecdh.PrivateKey) error {
+	r, err := NewFileDecryptReader(src, identities...)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dst, r)
+	return err
+}
+
+// fileEncryptWriter 加密写入器
+//
+// fileEncryptWriter is the encrypting writer
+type fileEncryptWriter struct {
+	dst    io.Writer
+	aead   cipher.AEAD
+	buf    []byte
+	out    []byte
+	seq    uint64
+	err    error
+	closed bool
+}
+
+// NewFileEncryptWriter 返回加密写入器，写入的明文加密后写入 dst，必须调用 Close 写入最后一块；格式见 EncryptFile
+// 可以与 io.Pipe 配合，把加密后的数据直接交给 ossutil 的 Put 上传
+//
+// NewFileEncryptWriter returns an encrypting writer whose plaintext is written to dst encrypted; Close must be called to write the last chunk. See EncryptFile for the format.
+// It can be combined with io.Pipe to hand the encrypted data straight to ossutil's Put.
+func NewFileEncryptWriter(dst io.Writer, recipients ...*ecdh.PublicKey) (io.WriteCloser, error) {
+	if len(recipients) == 0 || len(recipients) > 255 {
+		return nil, fmt.Errorf("%w: need 1-255 recipients, got %d", ErrInvalidRecipient, len(recipients))
+	}
+	fileKey := make([]byte, fileKeySize)
+	_, _ = rand.Read(fileKey)
+
+	header := bytes.NewBufferString(fileMagic)
+	header.WriteByte(byte(len(recipients)))
+	for _, pub := range recipients {
+		if pub == nil || pub.Curve() != ecdh.X25519() {
+			return nil, fmt.Errorf("%w: not an X25519 public key", ErrInvalidRecipient)
+		}
+		eph, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		shared, err := eph.ECDH(pub)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidRecipient, err)
+		}
+		aead, err := stanzaAEAD(shared, eph.PublicKey().Bytes(), pub.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		header.Write(eph.PublicKey().Bytes())
+		header.Write(aead.Seal(nil, make([]byte, chacha20poly1305.NonceSize), fileKey, nil))
+	}
+	mac, err := headerMAC(fileKey, header.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	header.Write(mac)
+
+	nonce := make([]byte, fileNonceSize)
+	_, _ = rand.Read(nonce)
+	header.Write(nonce)
+	aead, err := payloadAEAD(fileKey, nonce)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := dst.Write(header.Bytes()); err != nil {
+		return nil, err
+	}
+	return &fileEncryptWriter{
+		dst:  dst,
+		aead: aead,
+		buf:  make([]byte, 0, fileChunkSize),
+		out:  make([]byte, 0, fileChunkSize+chacha20poly1305.Overhead),
+	}, nil
+}
+
+// Write 实现 io.Writer 接口
+//
+// Write implements the io.Writer interface.
+func (w *fileEncryptWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("write to closed file encrypt writer")
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := 0
+	for len(p) > 0 {
+		// 缓冲区满且还有数据时才写出，保证最后一块在 Close 时写出并带上最后一块标记
+		if len(w.buf) == fileChunkSize {
+			if w.err = w.flush(false); w.err != nil {
+				return n, w.err
+			}
+		}
+		k := copy(w.buf[len(w.buf):fileChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+k]
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// Close 写入最后一块，不会关闭 dst
+//
+// Close writes the last chunk; it does not close dst.
+func (w *fileEncryptWriter) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	if w.err != nil {
+		return w.err
+	}
+	w.err = w.flush(true)
+	return w.err
+}
+
+// flush 加密并写出缓冲区中的一块
+//
+// flush encrypts and writes out the buffered chunk
+func (w *fileEncryptWriter) flush(last bool) error {
+	w.out = w.aead.Seal(w.out[:0], chunkNonce(w.seq, last), w.buf, nil)
+	w.seq++
+	w.buf = w.buf[:0]
+	_, err := w.dst.Write(w.out)
+	return err
+}
+
+// fileDecryptReader 解密读取器
+//
+// fileDecryptReader is the decrypting reader
+type fileDecryptReader struct {
+	src  io.Reader
+	aead cipher.AEAD
+	in   []byte
+	out  []byte
+	seq  uint64
+	done bool
+	err  error
+}
+
+// NewFileDecryptReader 返回解密读取器，读取时逐块校验并解密 src，适合解密从对象存储下载的流
+// 参数:
+//   - src: 密文输入
+//   - identities: 可用的 X25519 私钥，依次尝试
+//
+// 返回:
+//   - io.Reader: 明文读取器，文件被截断或篡改时 Read 返回 ErrInvalidEncryptedFile
+//   - error: 没有匹配的私钥时返回 ErrNoMatchingIdentity，文件头无效时返回 ErrInvalidEncryptedFile
+//
+// NewFileDecryptReader returns a decrypting reader that authenticates and decrypts src chunk by chunk, suitable for streams downloaded from object storage.
+// Parameters:
+//   - src: Ciphertext input
+//   - identities: Available X25519 private keys, tried in turn
+//
+// Returns:
+//   - io.Reader: The plaintext reader; Read returns ErrInvalidEncryptedFile if the file is truncated or tampered with
+//   - error: Returns ErrNoMatchingIdentity if no key matches, or ErrInvalidEncryptedFile if the header is invalid
+func NewFileDecryptReader(src io.Reader, identities ...*ecdh.PrivateKey) (io.Reader, error) {
+	prefix := make([]byte, len(fileMagic)+1)
+	if _, err := io.ReadFull(src, prefix); err != nil {
+		return nil, fmt.Errorf("%w: read header: %v", ErrInvalidEncryptedFile, err)
+	}
+	if string(prefix[:len(fileMagic)]) != fileMagic || prefix[len(fileMagic)] == 0 {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidEncryptedFile)
+	}
+	stanzas := make([]byte, int(prefix[len(fileMagic)])*fileStanzaSize)
+	rest := make([]byte, sha256.Size+fileNonceSize)
+	if _, err := io.ReadFull(src, stanzas); err != nil {
+		return nil, fmt.Errorf("%w: read header: %v", ErrInvalidEncryptedFile, err)
+	}
+	if _, err := io.ReadFull(src, rest); err != nil {
+		return nil, fmt.Errorf("%w: read header: %v", ErrInvalidEncryptedFile, err)
+	}
+
+	fileKey := unwrapFileKey(stanzas, identities)
+	if fileKey == nil {
+		return nil, ErrNoMatchingIdentity
+	}
+	mac, err := headerMAC(fileKey, append(prefix, stanzas...))
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(mac, rest[:sha256.Size]) {
+		return nil, fmt.Errorf("%w: header authentication failed", ErrInvalidEncryptedFile)
+	}
+	aead, err := payloadAEAD(fileKey, rest[sha256.Size:])
+	if err != nil {
+		return nil, err
+	}
+	return &fileDecryptReader{src: src, aead: aead, in: make([]byte, fileChunkSize+chacha20poly1305.Overhead+1)}, nil
+}
+
+// Read 实现 io.Reader 接口
+//
+// Read implements the io.Reader interface.
+func (r *fileDecryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		r.err = r.next()
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// next 读取并解密下一块
+// 多读一个字节用于判断是否还有后续数据：完整长度的块之后没有数据时它就是最后一块
+//
+// next reads and decrypts the next chunk.
+// One extra byte is read to tell whether more data follows: a full-size chunk with nothing after it is the last one
+func (r *fileDecryptReader) next() error {
+	full := fileChunkSize + chacha20poly1305.Overhead
+	// in 的第一个字节可能是上一次多读的字节
+	have := 0
+	if r.seq > 0 {
+		have = 1
+	}
+	n, err := io.ReadFull(r.src, r.in[have:])
+	n += have
+	switch {
+	case err == io.ErrUnexpectedEOF || err == io.EOF:
+	case err != nil:
+		return err
+	}
+	if n < chacha20poly1305.Overhead {
+		return fmt.Errorf("%w: truncated", ErrInvalidEncryptedFile)
+	}
+	last := n <= full
+	chunk := r.in[:min(n, full)]
+	plain, err := r.aead.Open(chunk[:0:0], chunkNonce(r.seq, last), chunk, nil)
+	if err != nil {
+		return fmt.Errorf("%w: chunk %d authentication failed", ErrInvalidEncryptedFile, r.seq)
+	}
+	if !last && len(plain) == 0 {
+		return fmt.Errorf("%w: empty chunk", ErrInvalidEncryptedFile)
+	}
+	r.seq++
+	r.out = plain
+	r.done = last
+	if !last {
+		r.in[0] = r.in[full]
+	}
+	return nil
+}
+
+// unwrapFileKey 用给定的私钥依次尝试解开接收者条目，返回文件密钥，都失败时返回 nil
+//
+// unwrapFileKey tries the given private keys against the recipient stanzas in turn, returning the file key, or nil if all fail
+func unwrapFileKey(stanzas []byte, identities []*ecdh.PrivateKey) []byte {
+	for _, priv := range identities {
+		if priv == nil || priv.Curve() != ecdh.X25519() {
+			continue
+		}
+		for s := stanzas; len(s) >= fileStanzaSize; s = s[fileStanzaSize:] {
+			ephPub, err := ecdh.X25519().NewPublicKey(s[:32])
+			if err != nil {
+				continue
+			}
+			shared, err := priv.ECDH(ephPub)
+			if err != nil {
+				continue
+			}
+			aead, err := stanzaAEAD(shared, s[:32], priv.PublicKey().Bytes())
+			if err != nil {
+				continue
+			}
+			if key, err := aead.Open(nil, make([]byte, chacha20poly1305.NonceSize), s[32:fileStanzaSize], nil); err == nil {
+				return key
+			}
+		}
+	}
+	return nil
+}
+
+// stanzaAEAD 由 X25519 共享密钥派生包装文件密钥的 AEAD，盐值绑定临时公钥和接收者公钥
+//
+// stanzaAEAD derives the AEAD wrapping the file key from the X25519 shared secret, with a salt binding the ephemeral and recipient public keys
+func stanzaAEAD(shared, ephPub, recipientPub []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, shared, append(bytes.Clone(ephPub), recipientPub...), fileMagic+"X25519", chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyExchange, err)
+	}
+	return chacha20poly1305.New(key)
+}
+
+// headerMAC 计算文件头的认证码，防止接收者条目被替换或删除
+//
+// headerMAC computes the header authentication code, preventing recipient stanzas from being replaced or removed
+func headerMAC(fileKey, header []byte) ([]byte, error) {
+	key, err := hkdf.Key(sha256.New, fileKey, nil, fileMagic+"header", sha256.Size)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyExchange, err)
+	}
+	h := hmac.New(sha256.New, key)
+	h.Write(header)
+	return h.Sum(nil), nil
+}
+
+// payloadAEAD 由文件密钥和随机数派生加密数据块的 AEAD
+//
+// payloadAEAD derives the AEAD encrypting the payload chunks from the file key and nonce
+func payloadAEAD(fileKey, nonce []byte) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, fileKey, nonce, fileMagic+"payload", chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyExchange, err)
+	}
+	return chacha20poly1305.New(key)
+}
+
+// chunkNonce 数据块的 nonce：11 字节大端序块序号加 1 字节最后一块标记
+//
+// chunkNonce is a chunk's nonce: an 11-byte big-endian sequence number followed by a 1-byte last-chunk flag
+func chunkNonce(seq uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	for i := 10; i >= 3; i-- {
+		nonce[i] = byte(seq)
+		seq >>= 8
+	}
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
@@ -0,0 +1,126 @@
+package cryptoutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+// ErrInvalidPrivateKey 表示无效的私钥格式
+//
+// ErrInvalidPrivateKey indicates an invalid private key format
+var ErrInvalidPrivateKey = errors.New("invalid private key format")
+
+// ParsePrivateKeyPEM 解析 PEM 编码的私钥
+// 支持 PKCS#1（RSA PRIVATE KEY）、SEC 1（EC PRIVATE KEY）和 PKCS#8（PRIVATE KEY）格式，包括 SM2 私钥
+// 参数:
+//   - data: PEM 编码的私钥
+//
+// 返回:
+//   - crypto.Signer: 私钥，具体类型为 *rsa.PrivateKey、*ecdsa.PrivateKey、ed25519.PrivateKey 或 *sm2.PrivateKey
+//   - error: 如果解析失败，返回错误
+//
+// ParsePrivateKeyPEM parses a PEM encoded private key.
+// Supports PKCS#1 (RSA PRIVATE KEY), SEC 1 (EC PRIVATE KEY) and PKCS#8 (PRIVATE KEY) formats, including SM2 private keys.
+// Parameters:
+//   - data: PEM encoded private key
+//
+// Returns:
+//   - crypto.Signer: The private key, concretely *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey or *sm2.PrivateKey
+//   - error: Returns an error if parsing fails
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidPrivateKey)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+		}
+		return key, nil
+	case "EC PRIVATE KEY":
+		key, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			// 标准库不识别 SM2 曲线
+			if sm2Key, sm2Err := smx509.ParseSM2PrivateKey(block.Bytes); sm2Err == nil {
+				return sm2Key, nil
+			}
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+		}
+		return key, nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		sm2Key, sm2Err := smx509.ParsePKCS8PrivateKey(block.Bytes)
+		if sm2Err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPrivateKey, err)
+		}
+		key = sm2Key
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey, *sm2.PrivateKey:
+		return k.(crypto.Signer), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported key type %T", ErrInvalidPrivateKey, key)
+}
+
+// ParsePublicKeyPEM 解析 PEM 编码的公钥
+// 支持 PKIX（PUBLIC KEY）、PKCS#1（RSA PUBLIC KEY）格式以及证书（CERTIFICATE，返回证书中的公钥）
+// 参数:
+//   - data: PEM 编码的公钥或证书
+//
+// 返回:
+//   - crypto.PublicKey: 公钥，具体类型为 *rsa.PublicKey、*ecdsa.PublicKey 或 ed25519.PublicKey
+//   - error: 如果解析失败，返回错误
+//
+// ParsePublicKeyPEM parses a PEM encoded public key.
+// Supports PKIX (PUBLIC KEY), PKCS#1 (RSA PUBLIC KEY) and certificates (CERTIFICATE, returning the certificate's public key).
+// Parameters:
+//   - data: PEM encoded public key or certificate
+//
+// Returns:
+//   - crypto.PublicKey: The public key, concretely *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey
+//   - error: Returns an error if parsing fails
+func ParsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: no PEM block found", ErrInvalidKeyFormat)
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFormat, err)
+		}
+		return key, nil
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFormat, err)
+		}
+		return cert.PublicKey, nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		sm2Key, sm2Err := smx509.ParsePKIXPublicKey(block.Bytes)
+		if sm2Err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeyFormat, err)
+		}
+		return sm2Key, nil
+	}
+	return key, nil
+}
@@ -0,0 +1,228 @@
+package cryptoutil
+
+import (
+	"crypto/ed25519"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// licenseGroupSize 许可证字符串每组的字符数
+//
+// licenseGroupSize is the number of characters per group in license strings
+const licenseGroupSize = 5
+
+var (
+	// ErrInvalidLicense 表示许可证格式错误或签名无效
+	//
+	// ErrInvalidLicense indicates that a license is malformed or its signature is invalid
+	ErrInvalidLicense = errors.New("invalid license")
+	// ErrLicenseExpired 表示许可证已过期或尚未生效
+	//
+	// ErrLicenseExpired indicates that a license has expired or is not yet valid
+	ErrLicenseExpired = errors.New("license expired")
+
+	// licenseEncoding 不带填充的 base32 编码，只含大写字母和数字，便于人工抄写
+	//
+	// licenseEncoding is unpadded base32, containing only uppercase letters and digits so it is easy to copy by hand
+	licenseEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// License 许可证内容
+// ID: 许可证编号
+// Subject: 被授权方，例如客户名称
+// IssuedAt: 签发时间，精确到秒
+// NotBefore: 生效时间，零值表示签发后立即生效
+// ExpiresAt: 过期时间，零值表示永不过期
+// Features: 授权的功能列表
+// Meta: 其他自定义信息，例如最大用户数
+//
+// License is the content of a license.
+// ID: License identifier
+// Subject: The licensee, e.g. a customer name
+// IssuedAt: Issue time, with second precision
+// NotBefore: Start of validity; the zero value means valid immediately
+// ExpiresAt: Expiry time; the zero value means it never expires
+// Features: Licensed features
+// Meta: Other custom information, e.g. the maximum number of users
+type License struct {
+	ID        string
+	Subject   string
+	IssuedAt  time.Time
+	NotBefore time.Time
+	ExpiresAt time.Time
+	Features  []string
+	Meta      map[string]string
+}
+
+// licensePayload 许可证的序列化格式，时间以 Unix 秒表示，字段名尽量短以缩短许可证字符串
+//
+// licensePayload is the serialized form of a license; times are Unix seconds and field names are short to keep license strings compact
+type licensePayload struct {
+	ID        string            `json:"id,omitempty"`
+	Subject   string            `json:"sub,omitempty"`
+	IssuedAt  int64             `json:"iat"`
+	NotBefore int64             `json:"nbf,omitempty"`
+	ExpiresAt int64             `json:"exp,omitempty"`
+	Features  []string          `json:"f,omitempty"`
+	Meta      map[string]string `json:"m,omitempty"`
+}
+
+// unixOrZero 将时间转换为 Unix 秒，零值转换为 0
+//
+// unixOrZero converts a time to Unix seconds, mapping the zero value to 0
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// timeOrZero 将 Unix 秒转换为时间，0 转换为零值
+//
+// timeOrZero converts Unix seconds to a time, mapping 0 to the zero value
+func timeOrZero(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// HasFeature 报告许可证是否授权了指定功能
+//
+// HasFeature reports whether the license grants the feature.
+func (l *License) HasFeature(feature string) bool {
+	return slices.Contains(l.Features, feature)
+}
+
+// ValidAt 报告许可证在指定时间是否有效
+//
+// ValidAt reports whether the license is valid at the given time.
+func (l *License) ValidAt(t time.Time) bool {
+	if !l.NotBefore.IsZero() && t.Before(l.NotBefore) {
+		return false
+	}
+	return l.ExpiresAt.IsZero() || t.Before(l.ExpiresAt)
+}
+
+// GenerateLicense 使用 Ed25519 私钥签发许可证，生成形如 "ABCDE-FGHIJ-..." 的分组 base32 字符串，可在离线环境中用公钥校验
+// 许可证内容不加密，持有者可以读取，因此不要放入敏感信息
+// 参数:
+//   - license: 许可证内容，IssuedAt 为零值时使用当前时间
+//   - privateKey: Ed25519 私钥，只应保存在签发端
+//
+// 返回:
+//   - string: 许可证字符串
+//   - error: 私钥无效或序列化失败时返回错误
+//
+// GenerateLicense signs a license with an Ed25519 private key, producing a grouped base32 string such as "ABCDE-FGHIJ-..." that can be verified offline with the public key.
+// The license content is not encrypted and can be read by its holder, so keep secrets out of it.
+// Parameters:
+//   - license: The license content; a zero IssuedAt is set to the current time
+//   - privateKey: The Ed25519 private key, which should only live on the issuing side
+//
+// Returns:
+//   - string: The license string
+//   - error: Returns an error if the private key is invalid or serialization fails
+func GenerateLicense(license *License, privateKey ed25519.PrivateKey) (string, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return "", errors.New("cryptoutil: invalid ed25519 private key")
+	}
+	issuedAt := license.IssuedAt
+	if issuedAt.IsZero() {
+		issuedAt = time.Now()
+	}
+	payload, err := json.Marshal(&licensePayload{
+		ID:        license.ID,
+		Subject:   license.Subject,
+		IssuedAt:  issuedAt.Unix(),
+		NotBefore: unixOrZero(license.NotBefore),
+		ExpiresAt: unixOrZero(license.ExpiresAt),
+		Features:  license.Features,
+		Meta:      license.Meta,
+	})
+	if err != nil {
+		return "", err
+	}
+	data := append(payload, ed25519.Sign(privateKey, payload)...)
+	encoded := licenseEncoding.EncodeToString(data)
+
+	var b strings.Builder
+	b.Grow(len(encoded) + len(encoded)/licenseGroupSize)
+	for i := 0; i < len(encoded); i += licenseGroupSize {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		b.WriteString(encoded[i:min(i+licenseGroupSize, len(encoded))])
+	}
+	return b.String(), nil
+}
+
+// VerifyLicense 使用公钥离线校验许可证，并检查当前时间是否在有效期内
+// 参数:
+//   - license: 许可证字符串，忽略大小写、分隔符和空白
+//   - publicKey: Ed25519 公钥，可内置在分发的程序中
+//
+// 返回:
+//   - *License: 许可证内容
+//   - error: 格式错误或签名无效时返回 ErrInvalidLicense；不在有效期内时返回 ErrLicenseExpired，同时返回许可证内容以便提示续期
+//
+// VerifyLicense verifies a license offline with the public key and checks that the current time is within its validity period.
+// Parameters:
+//   - license: The license string; case, separators and whitespace are ignored
+//   - publicKey: The Ed25519 public key, which can be embedded in the distributed program
+//
+// Returns:
+//   - *License: The license content
+//   - error: Returns ErrInvalidLicense if malformed or the signature is invalid; returns ErrLicenseExpired together with the license content when outside its validity period, so renewal can be prompted
+func VerifyLicense(license string, publicKey ed25519.PublicKey) (*License, error) {
+	return VerifyLicenseAt(license, publicKey, time.Now())
+}
+
+// VerifyLicenseAt 与 VerifyLicense 相同，但按指定时间检查有效期，可用于传入可信时间源以防止回拨系统时钟
+//
+// VerifyLicenseAt is like VerifyLicense but checks validity at the given time, e.g. from a trusted time source to resist clock rollback.
+func VerifyLicenseAt(license string, publicKey ed25519.PublicKey, now time.Time) (*License, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%w: invalid ed25519 public key", ErrInvalidLicense)
+	}
+	cleaned := strings.Map(func(r rune) rune {
+		switch r {
+		case '-', ' ', '\t', '\r', '\n':
+			return -1
+		}
+		return r
+	}, strings.ToUpper(license))
+	data, err := licenseEncoding.DecodeString(cleaned)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLicense, err)
+	}
+	if len(data) <= ed25519.SignatureSize {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidLicense)
+	}
+	payload, sig := data[:len(data)-ed25519.SignatureSize], data[len(data)-ed25519.SignatureSize:]
+	if !ed25519.Verify(publicKey, payload, sig) {
+		return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidLicense)
+	}
+	var p licensePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidLicense, err)
+	}
+	l := &License{
+		ID:        p.ID,
+		Subject:   p.Subject,
+		IssuedAt:  time.Unix(p.IssuedAt, 0),
+		NotBefore: timeOrZero(p.NotBefore),
+		ExpiresAt: timeOrZero(p.ExpiresAt),
+		Features:  p.Features,
+		Meta:      p.Meta,
+	}
+	if !l.ValidAt(now) {
+		return l, ErrLicenseExpired
+	}
+	return l, nil
+}
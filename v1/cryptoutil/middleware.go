@@ -0,0 +1,197 @@
+package cryptoutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/cacheutil"
+)
+
+const (
+	// DefaultAuthCacheTTL 验证结果的默认缓存时间
+	//
+	// DefaultAuthCacheTTL is the default cache TTL of verification results
+	DefaultAuthCacheTTL = time.Minute
+	// DefaultAuthCachePrefix 缓存键的默认前缀
+	//
+	// DefaultAuthCachePrefix is the default cache key prefix
+	DefaultAuthCachePrefix = "authtoken:"
+)
+
+// ErrMissingToken 表示请求中缺少 Bearer 令牌
+//
+// ErrMissingToken indicates that the request has no Bearer token
+var ErrMissingToken = errors.New("missing bearer token")
+
+// claimsKey Claims 在 context 中的键
+//
+// claimsKey is the context key of Claims
+type claimsKey struct{}
+
+// AuthMiddlewareOptions 认证中间件选项
+// Cache: 验证结果缓存，默认使用进程内 cacheutil.MemoryCache，多实例部署时可传入共享缓存
+// CacheTTL: 验证成功结果的缓存时间，默认 DefaultAuthCacheTTL，实际不超过令牌的 exp；设为负数表示不缓存，verifier 实现了 OneTimeVerifier（例如带有重放保护）时总是不缓存
+// CachePrefix: 缓存键前缀，默认 DefaultAuthCachePrefix
+// Optional: 为 true 时没有令牌的请求也会放行（不注入声明），携带无效令牌的请求仍会被拒绝
+// ErrorHandler: 认证失败时的处理函数，默认返回 401 和 WWW-Authenticate 头，声明校验失败（ErrInvalidClaims）时返回 403
+//
+// AuthMiddlewareOptions contains options for the authentication middleware.
+// Cache: Verification result cache, defaults to an in-process cacheutil.MemoryCache; pass a shared cache for multi-instance deployments
+// CacheTTL: Cache TTL of successful results, defaults to DefaultAuthCacheTTL and never exceeds the token's exp; negative disables caching, and caching is always disabled when verifier implements OneTimeVerifier (e.g. with replay protection)
+// CachePrefix: Cache key prefix, defaults to DefaultAuthCachePrefix
+// Optional: When true, requests without a token are passed through (without claims); requests with an invalid token are still rejected
+// ErrorHandler: Handler invoked when authentication fails, defaults to a 401 with a WWW-Authenticate header, or a 403 when claim checks fail (ErrInvalidClaims)
+type AuthMiddlewareOptions struct {
+	Cache        cacheutil.Cache
+	CacheTTL     time.Duration
+	CachePrefix  string
+	Optional     bool
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// AuthMiddleware 创建 HTTP 认证中间件
+// 从 Authorization 头提取 Bearer 令牌，使用 verifier 验证，并将声明注入请求的 context（通过 ClaimsFromContext 读取）；
+// 验证成功的结果按令牌的 SHA-256 摘要短时间缓存，避免每个请求都验签或请求远端公钥；
+// 一次性令牌的验证器（见 OneTimeVerifier，例如设置了 ReplayGuard 的 *JwtVerifier 或 WithReplayGuard 的返回值）不缓存，否则缓存命中时会跳过重放检查
+// 参数:
+//   - verifier: 令牌验证器，例如 *JwtVerifier 或 AppleTokenVerifier()
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - func(http.Handler) http.Handler: 中间件
+//
+// AuthMiddleware creates an HTTP authentication middleware.
+// It extracts the Bearer token from the Authorization header, verifies it with verifier and injects the claims into the request context (read with ClaimsFromContext);
+// successful results are cached briefly, keyed by the token's SHA-256 digest, so not every request verifies signatures or fetches remote keys.
+// Verifiers of one-time tokens (see OneTimeVerifier, e.g. a *JwtVerifier with a ReplayGuard or the result of WithReplayGuard) are never cached, since a cache hit would skip the replay check.
+// Parameters:
+//   - verifier: Token verifier, e.g. *JwtVerifier or AppleTokenVerifier()
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - func(http.Handler) http.Handler: The middleware
+func AuthMiddleware(verifier TokenVerifier, opts *AuthMiddlewareOptions) func(http.Handler) http.Handler {
+	var o AuthMiddlewareOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Cache == nil {
+		o.Cache = cacheutil.NewMemoryCache()
+	}
+	if o.CacheTTL == 0 {
+		o.CacheTTL = DefaultAuthCacheTTL
+	}
+	if o.CachePrefix == "" {
+		o.CachePrefix = DefaultAuthCachePrefix
+	}
+	if o.ErrorHandler == nil {
+		o.ErrorHandler = defaultAuthErrorHandler
+	}
+	if isOneTime(verifier) {
+		o.CacheTTL = -1
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := BearerToken(r)
+			if token == "" {
+				if o.Optional {
+					next.ServeHTTP(w, r)
+					return
+				}
+				o.ErrorHandler(w, r, ErrMissingToken)
+				return
+			}
+
+			claims, err := o.verify(r.Context(), verifier, token)
+			if err != nil {
+				o.ErrorHandler(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(ContextWithClaims(r.Context(), claims)))
+		})
+	}
+}
+
+// verify 优先从缓存读取验证结果，未命中时调用 verifier 并缓存成功的结果，缓存出错时直接验证
+//
+// verify reads the result from the cache first; on a miss it calls verifier and caches a successful result; cache errors fall back to verifying directly
+func (o *AuthMiddlewareOptions) verify(ctx context.Context, verifier TokenVerifier, token string) (Claims, error) {
+	if o.CacheTTL < 0 {
+		return verifier.Verify(ctx, token)
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	key := o.CachePrefix + hex.EncodeToString(sum[:])
+	if data, ok, err := o.Cache.Get(ctx, key); err == nil && ok {
+		var claims Claims
+		if json.Unmarshal(data, &claims) == nil {
+			return claims, nil
+		}
+	}
+
+	claims, err := verifier.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	ttl := o.CacheTTL
+	if exp := claims.ExpiresAt(); !exp.IsZero() {
+		ttl = min(ttl, time.Until(exp))
+	}
+	if ttl > 0 {
+		if data, err := json.Marshal(claims); err == nil {
+			o.Cache.Set(ctx, key, data, ttl)
+		}
+	}
+	return claims, nil
+}
+
+// BearerToken 从 Authorization 头提取 Bearer 令牌，不存在时返回空字符串
+//
+// BearerToken extracts the Bearer token from the Authorization header, or returns an empty string if absent.
+func BearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// ContextWithClaims 将声明写入 context
+//
+// ContextWithClaims stores the claims in the context.
+func ContextWithClaims(ctx context.Context, claims Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// ClaimsFromContext 从 context 读取 AuthMiddleware 注入的声明
+//
+// ClaimsFromContext reads the claims injected by AuthMiddleware from the context.
+func ClaimsFromContext(ctx context.Context) (Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(Claims)
+	return claims, ok
+}
+
+// defaultAuthErrorHandler 默认的认证失败处理函数
+//
+// defaultAuthErrorHandler is the default authentication failure handler
+func defaultAuthErrorHandler(w http.ResponseWriter, _ *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrMissingToken):
+		w.Header().Set("WWW-Authenticate", `Bearer`)
+	case errors.Is(err, ErrInvalidClaims):
+		// 令牌有效但声明不满足要求，按 RFC 6750 返回 403
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope"`)
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	default:
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
@@ -0,0 +1,342 @@
+package cryptoutil
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// OTPAlgorithmSHA1 HMAC-SHA1，Google Authenticator 等应用的默认算法
+	//
+	// OTPAlgorithmSHA1 is HMAC-SHA1, the default algorithm of apps such as Google Authenticator
+	OTPAlgorithmSHA1 = "SHA1"
+	// OTPAlgorithmSHA256 HMAC-SHA256
+	//
+	// OTPAlgorithmSHA256 is HMAC-SHA256
+	OTPAlgorithmSHA256 = "SHA256"
+	// OTPAlgorithmSHA512 HMAC-SHA512
+	//
+	// OTPAlgorithmSHA512 is HMAC-SHA512
+	OTPAlgorithmSHA512 = "SHA512"
+
+	// DefaultTOTPPeriod 默认的 TOTP 时间步长
+	//
+	// DefaultTOTPPeriod is the default TOTP time step
+	DefaultTOTPPeriod = 30 * time.Second
+	// DefaultOTPDigits 默认的一次性密码位数
+	//
+	// DefaultOTPDigits is the default number of one-time password digits
+	DefaultOTPDigits = 6
+	// DefaultTOTPSecretSize 默认生成的 TOTP 密钥字节数（160 位，RFC 4226 推荐值）
+	//
+	// DefaultTOTPSecretSize is the default size in bytes of generated TOTP secrets (160 bits, as recommended by RFC 4226)
+	DefaultTOTPSecretSize = 20
+)
+
+var (
+	// ErrInvalidOTPSecret 表示无效的 base32 密钥
+	//
+	// ErrInvalidOTPSecret indicates an invalid base32 secret
+	ErrInvalidOTPSecret = errors.New("invalid otp secret")
+	// ErrUnsupportedOTPAlgorithm 表示不支持的 OTP 算法
+	//
+	// ErrUnsupportedOTPAlgorithm indicates an unsupported otp algorithm
+	ErrUnsupportedOTPAlgorithm = errors.New("unsupported otp algorithm")
+	// ErrInvalidOTPOptions 表示无效的 OTP 选项，例如时间步长小于 1 秒或位数不在 6～8 之间
+	//
+	// ErrInvalidOTPOptions indicates invalid otp options, e.g. a period below one second or digits outside 6 to 8
+	ErrInvalidOTPOptions = errors.New("invalid otp options")
+
+	// otpEncoding 不带填充的 base32 编码，与认证器应用使用的格式一致
+	//
+	// otpEncoding is unpadded base32, matching the format used by authenticator apps
+	otpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// TOTPOptions TOTP/HOTP 选项
+// Period: 时间步长，默认 DefaultTOTPPeriod（仅 TOTP），不能小于 1 秒且必须是整秒
+// Digits: 密码位数，默认 DefaultOTPDigits，取值 6～8
+// Algorithm: HMAC 算法，默认 OTPAlgorithmSHA1
+// Skew: 校验时允许前后偏差的时间步数，默认 1（即容忍 ±30 秒的时钟误差），设为负数表示不容忍偏差
+//
+// TOTPOptions contains options for TOTP/HOTP.
+// Period: Time step, defaults to DefaultTOTPPeriod (TOTP only); must be a whole number of seconds and at least one second
+// Digits: Number of digits, defaults to DefaultOTPDigits, between 6 and 8
+// Algorithm: HMAC algorithm, defaults to OTPAlgorithmSHA1
+// Skew: Number of time steps tolerated before and after during validation, defaults to 1 (i.e. ±30 seconds of clock drift); negative disables tolerance
+type TOTPOptions struct {
+	Period    time.Duration
+	Digits    int
+	Algorithm string
+	Skew      int
+}
+
+// withDefaults 返回填充了默认值的选项副本，时间步长或位数无效时返回 ErrInvalidOTPOptions
+//
+// withDefaults returns a copy of the options with defaults filled in, or ErrInvalidOTPOptions if the period or digits are invalid
+func (o *TOTPOptions) withDefaults() (TOTPOptions, error) {
+	var out TOTPOptions
+	if o != nil {
+		out = *o
+	}
+	if out.Period == 0 {
+		out.Period = DefaultTOTPPeriod
+	}
+	if out.Period < time.Second || out.Period%time.Second != 0 {
+		return out, fmt.Errorf("%w: period %s", ErrInvalidOTPOptions, out.Period)
+	}
+	if out.Digits == 0 {
+		out.Digits = DefaultOTPDigits
+	}
+	if out.Digits < 6 || out.Digits > 8 {
+		return out, fmt.Errorf("%w: digits %d", ErrInvalidOTPOptions, out.Digits)
+	}
+	if out.Algorithm == "" {
+		out.Algorithm = OTPAlgorithmSHA1
+	}
+	if out.Skew == 0 {
+		out.Skew = 1
+	} else if out.Skew < 0 {
+		out.Skew = 0
+	}
+	return out, nil
+}
+
+// GenerateTOTPSecret 生成随机的 TOTP 密钥（base32 编码，不带填充）
+// 返回:
+//   - string: base32 编码的密钥，可直接用于 TOTPURL 或手动输入认证器应用
+//   - error: 如果读取随机数失败，返回错误
+//
+// GenerateTOTPSecret generates a random TOTP secret (base32 encoded, unpadded).
+// Returns:
+//   - string: The base32 encoded secret, usable with TOTPURL or for manual entry in authenticator apps
+//   - error: Returns an error if reading random numbers fails
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, DefaultTOTPSecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return otpEncoding.EncodeToString(b), nil
+}
+
+// HOTPCode 按 RFC 4226 计算基于计数器的一次性密码
+// 参数:
+//   - secret: base32 编码的密钥（忽略大小写、空格和填充）
+//   - counter: 计数器
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - string: 一次性密码，位数不足时左侧补 0
+//   - error: 如果密钥无效、算法不支持或选项无效，返回错误
+//
+// HOTPCode computes a counter based one-time password according to RFC 4226.
+// Parameters:
+//   - secret: base32 encoded secret (case, spaces and padding are ignored)
+//   - counter: Counter
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - string: The one-time password, left-padded with zeros
+//   - error: Returns an error if the secret is invalid, the algorithm is unsupported or the options are invalid
+func HOTPCode(secret string, counter uint64, opts *TOTPOptions) (string, error) {
+	o, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
+	key, err := decodeOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	newHash, err := otpHash(o.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, counter, o.Digits, newHash), nil
+}
+
+// TOTPCode 按 RFC 6238 计算指定时间的一次性密码
+// 参数:
+//   - secret: base32 编码的密钥
+//   - t: 时间，通常为 time.Now()
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - string: 一次性密码
+//   - error: 如果密钥无效、算法不支持或选项无效，返回错误
+//
+// TOTPCode computes the time based one-time password for the given time according to RFC 6238.
+// Parameters:
+//   - secret: base32 encoded secret
+//   - t: Time, usually time.Now()
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - string: The one-time password
+//   - error: Returns an error if the secret is invalid, the algorithm is unsupported or the options are invalid
+func TOTPCode(secret string, t time.Time, opts *TOTPOptions) (string, error) {
+	o, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
+	return HOTPCode(secret, uint64(t.Unix())/uint64(o.Period/time.Second), &o)
+}
+
+// ValidateTOTP 校验一次性密码，允许前后 Skew 个时间步的偏差
+// 注意: 同一个密码在有效窗口内可以被重复使用，如需防止重放，调用方应记录最近一次成功的时间步
+// 参数:
+//   - secret: base32 编码的密钥
+//   - code: 用户输入的密码
+//   - t: 校验时间，通常为 time.Now()
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - bool: 密码是否正确
+//   - error: 如果密钥无效、算法不支持或选项无效，返回错误
+//
+// ValidateTOTP validates a one-time password, tolerating Skew time steps before and after.
+// Note: the same code can be reused within the valid window; to prevent replay the caller should record the last successful time step.
+// Parameters:
+//   - secret: base32 encoded secret
+//   - code: Code entered by the user
+//   - t: Validation time, usually time.Now()
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - bool: Whether the code is correct
+//   - error: Returns an error if the secret is invalid, the algorithm is unsupported or the options are invalid
+func ValidateTOTP(secret, code string, t time.Time, opts *TOTPOptions) (bool, error) {
+	o, err := opts.withDefaults()
+	if err != nil {
+		return false, err
+	}
+	key, err := decodeOTPSecret(secret)
+	if err != nil {
+		return false, err
+	}
+	newHash, err := otpHash(o.Algorithm)
+	if err != nil {
+		return false, err
+	}
+	code = strings.TrimSpace(code)
+	if len(code) != o.Digits {
+		return false, nil
+	}
+
+	counter := int64(t.Unix()) / int64(o.Period/time.Second)
+	valid := false
+	// 遍历整个窗口，避免通过耗时推断匹配的位置
+	for i := -o.Skew; i <= o.Skew; i++ {
+		c := counter + int64(i)
+		if c < 0 {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(c), o.Digits, newHash)), []byte(code)) == 1 {
+			valid = true
+		}
+	}
+	return valid, nil
+}
+
+// TOTPURL 生成 otpauth:// 格式的配置 URL，可通过 qrutil 生成二维码供认证器应用扫描
+// 参数:
+//   - issuer: 发行方，例如应用名称
+//   - account: 账户名，例如邮箱
+//   - secret: base32 编码的密钥
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - string: otpauth URL
+//   - error: 如果选项无效，返回 ErrInvalidOTPOptions
+//
+// TOTPURL generates an otpauth:// provisioning URL, which can be turned into a QR code with qrutil for authenticator apps to scan.
+// Parameters:
+//   - issuer: Issuer, e.g. the application name
+//   - account: Account name, e.g. an email address
+//   - secret: base32 encoded secret
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - string: The otpauth URL
+//   - error: Returns ErrInvalidOTPOptions if the options are invalid
+func TOTPURL(issuer, account, secret string, opts *TOTPOptions) (string, error) {
+	o, err := opts.withDefaults()
+	if err != nil {
+		return "", err
+	}
+	label := account
+	if issuer != "" {
+		label = issuer + ":" + account
+	}
+	q := url.Values{}
+	q.Set("secret", strings.ToUpper(strings.TrimRight(strings.ReplaceAll(secret, " ", ""), "=")))
+	if issuer != "" {
+		q.Set("issuer", issuer)
+	}
+	q.Set("algorithm", o.Algorithm)
+	q.Set("digits", strconv.Itoa(o.Digits))
+	q.Set("period", strconv.Itoa(int(o.Period/time.Second)))
+
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: q.Encode()}
+	return u.String(), nil
+}
+
+// hotp 计算 HOTP 值
+//
+// hotp computes the HOTP value
+func hotp(key []byte, counter uint64, digits int, newHash func() hash.Hash) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(newHash, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	// 动态截断
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// decodeOTPSecret 解码 base32 密钥，忽略大小写、空格和填充
+//
+// decodeOTPSecret decodes a base32 secret, ignoring case, spaces and padding
+func decodeOTPSecret(secret string) ([]byte, error) {
+	s := strings.ToUpper(strings.TrimRight(strings.ReplaceAll(secret, " ", ""), "="))
+	key, err := otpEncoding.DecodeString(s)
+	if err != nil || len(key) == 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOTPSecret, err)
+	}
+	return key, nil
+}
+
+// otpHash 返回算法对应的哈希函数
+//
+// otpHash returns the hash function of the algorithm
+func otpHash(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToUpper(algorithm) {
+	case OTPAlgorithmSHA1:
+		return sha1.New, nil
+	case OTPAlgorithmSHA256:
+		return sha256.New, nil
+	case OTPAlgorithmSHA512:
+		return sha512.New, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedOTPAlgorithm, algorithm)
+}
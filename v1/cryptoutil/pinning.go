@@ -0,0 +1,195 @@
+package cryptoutil
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrPinMismatch 表示服务端证书链中没有与任何固定值匹配的公钥
+	//
+	// ErrPinMismatch indicates that no public key in the server's certificate chain matches any pin
+	ErrPinMismatch = errors.New("certificate pin mismatch")
+	// ErrInvalidPin 表示固定值格式无效
+	//
+	// ErrInvalidPin indicates that a pin is malformed
+	ErrInvalidPin = errors.New("invalid certificate pin")
+)
+
+// spkiPinPrefix 固定值字符串的前缀，与 HPKP 和 OkHttp CertificatePinner 的格式一致
+//
+// spkiPinPrefix is the pin string prefix, matching the format of HPKP and OkHttp's CertificatePinner
+const spkiPinPrefix = "sha256/"
+
+// SPKIHash 证书公钥（SubjectPublicKeyInfo）的 SHA-256 摘要
+// 固定公钥而不是证书，证书续期时只要沿用同一密钥固定值就不变
+//
+// SPKIHash is the SHA-256 digest of a certificate's public key (SubjectPublicKeyInfo).
+// Pinning the key rather than the certificate keeps the pin stable across renewals that reuse the key.
+type SPKIHash [sha256.Size]byte
+
+// SPKIHashOf 计算证书的公钥摘要
+//
+// SPKIHashOf computes the public key digest of a certificate.
+func SPKIHashOf(cert *x509.Certificate) SPKIHash {
+	return sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+}
+
+// ParseSPKIHash 解析固定值字符串
+// 参数:
+//   - s: "sha256/<base64>" 或不带前缀的 base64，例如 openssl 输出的
+//     `openssl x509 -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64`
+//
+// 返回:
+//   - SPKIHash: 公钥摘要
+//   - error: 格式无效时返回 ErrInvalidPin
+//
+// ParseSPKIHash parses a pin string.
+// Parameters:
+//   - s: "sha256/<base64>" or bare base64, e.g. the output of
+//     `openssl x509 -pubkey -noout | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64`
+//
+// Returns:
+//   - SPKIHash: The public key digest
+//   - error: Returns ErrInvalidPin if the string is malformed
+func ParseSPKIHash(s string) (SPKIHash, error) {
+	var h SPKIHash
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), spkiPinPrefix))
+	if err != nil {
+		return h, fmt.Errorf("%w: %v", ErrInvalidPin, err)
+	}
+	if len(raw) != len(h) {
+		return h, fmt.Errorf("%w: expected %d bytes, got %d", ErrInvalidPin, len(h), len(raw))
+	}
+	copy(h[:], raw)
+	return h, nil
+}
+
+// SPKIHashesFromPEM 计算 PEM 中所有证书的公钥摘要，用于从合作方提供的证书文件生成固定值
+//
+// SPKIHashesFromPEM computes the public key digests of every certificate in PEM data, for deriving pins from certificate files supplied by a partner.
+func SPKIHashesFromPEM(data []byte) ([]SPKIHash, error) {
+	var hashes []SPKIHash
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPin, err)
+		}
+		hashes = append(hashes, SPKIHashOf(cert))
+	}
+	if len(hashes) == 0 {
+		return nil, fmt.Errorf("%w: no certificate found", ErrInvalidPin)
+	}
+	return hashes, nil
+}
+
+// String 返回 "sha256/<base64>" 格式的固定值
+//
+// String returns the pin in "sha256/<base64>" form.
+func (h SPKIHash) String() string {
+	return spkiPinPrefix + base64.StdEncoding.EncodeToString(h[:])
+}
+
+// PinnedTLSConfig 返回在常规证书校验之外还校验公钥固定值的 TLS 配置
+// 已校验的证书链中任意一个证书（叶子、中间或根）的公钥与任意一个固定值匹配即通过，
+// 因此可以同时固定当前密钥和备用密钥，轮换时先发布新固定值、再更换服务端证书
+// base 设置了 InsecureSkipVerify 时不校验证书链，只比对叶子证书的公钥（适用于固定自签名证书）
+// 会话恢复的连接同样会校验
+// 参数:
+//   - base: 基础配置，可以为 nil，不会被修改
+//   - pins: 允许的公钥摘要，为空时所有连接都会失败
+//
+// 返回:
+//   - *tls.Config: TLS 配置
+//
+// PinnedTLSConfig returns a TLS config that checks public key pins in addition to regular certificate verification.
+// A connection passes when the key of any certificate in a verified chain (leaf, intermediate or root) matches any pin,
+// so the current and backup keys can be pinned together; to rotate, publish the new pin first and then replace the server certificate.
+// When base sets InsecureSkipVerify the chain is not verified and only the leaf certificate's key is compared (suitable for pinning self-signed certificates).
+// Resumed connections are checked as well.
+// Parameters:
+//   - base: Base config, may be nil; it is not modified
+//   - pins: Allowed public key digests; every connection fails when empty
+//
+// Returns:
+//   - *tls.Config: The TLS config
+func PinnedTLSConfig(base *tls.Config, pins []SPKIHash) *tls.Config {
+	cfg := &tls.Config{}
+	if base != nil {
+		cfg = base.Clone()
+	}
+	pins = slices.Clone(pins)
+	next := cfg.VerifyConnection
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		if err := verifyPins(cs, pins); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(cs)
+		}
+		return nil
+	}
+	return cfg
+}
+
+// NewPinnedTransport 返回校验公钥固定值的 http.RoundTripper，用于回调银行、支付等敏感合作方
+// 基于 http.DefaultTransport 的副本，代理和超时设置与默认传输一致；固定规则见 PinnedTLSConfig
+// 参数:
+//   - pins: 允许的公钥摘要，通常包含当前密钥和至少一个备用密钥
+//
+// 返回:
+//   - http.RoundTripper: 传输层
+//
+// NewPinnedTransport returns an http.RoundTripper that enforces public key pins, for callbacks to sensitive partners such as banks and payment providers.
+// It is based on a copy of http.DefaultTransport, so proxy and timeout settings match the default transport; see PinnedTLSConfig for the pinning rules.
+// Parameters:
+//   - pins: Allowed public key digests, usually the current key and at least one backup
+//
+// Returns:
+//   - http.RoundTripper: The transport
+func NewPinnedTransport(pins []SPKIHash) http.RoundTripper {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = PinnedTLSConfig(t.TLSClientConfig, pins)
+	return t
+}
+
+// verifyPins 检查连接的证书是否匹配固定值
+//
+// verifyPins checks whether the connection's certificates match a pin
+func verifyPins(cs tls.ConnectionState, pins []SPKIHash) error {
+	var candidates []*x509.Certificate
+	switch {
+	case len(cs.VerifiedChains) > 0:
+		for _, chain := range cs.VerifiedChains {
+			candidates = append(candidates, chain...)
+		}
+	case len(cs.PeerCertificates) > 0:
+		// 证书链未经校验时，只有叶子证书的私钥经过握手证明，中间证书可以被伪造
+		candidates = cs.PeerCertificates[:1]
+	}
+	for _, cert := range candidates {
+		if slices.Contains(pins, SPKIHashOf(cert)) {
+			return nil
+		}
+	}
+	if cs.ServerName == "" {
+		return ErrPinMismatch
+	}
+	return fmt.Errorf("%w: %s", ErrPinMismatch, cs.ServerName)
+}
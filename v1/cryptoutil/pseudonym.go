@@ -0,0 +1,185 @@
+package cryptoutil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+)
+
+// pseudonymSize 假名令牌的字节数（128 位，碰撞概率可忽略）
+//
+// pseudonymSize is the size in bytes of pseudonym tokens (128 bits, negligible collision probability)
+const pseudonymSize = 16
+
+// Pseudonymize 使用 HMAC-SHA256 为值生成稳定的假名令牌，相同的 key 和值总是得到相同的令牌，便于在导出的分析数据中关联同一用户而不暴露原值
+// 令牌不可逆，但对取值空间小的值（手机号、身份证号等）持有 key 的人可以穷举，因此 key 必须保密，且不应与其他用途的密钥复用
+// 参数:
+//   - value: 原始值，应先规范化（例如邮箱转小写）
+//   - key: HMAC 密钥，建议至少 32 字节随机数
+//
+// 返回:
+//   - string: URL 安全无填充 Base64 编码的令牌（22 个字符）
+//
+// Pseudonymize derives a stable pseudonym token for a value with HMAC-SHA256; the same key and value always give the same token, so exported analytics data can link records of the same user without exposing the original value.
+// Tokens are not reversible, but anyone holding the key can enumerate small value spaces (phone numbers, ID numbers, ...), so the key must stay secret and must not be shared with other purposes.
+// Parameters:
+//   - value: The original value, normalized beforehand (e.g. lowercase emails)
+//   - key: The HMAC key, ideally at least 32 random bytes
+//
+// Returns:
+//   - string: The token as URL-safe unpadded Base64 (22 characters)
+func Pseudonymize(value string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:pseudonymSize])
+}
+
+// Pseudonymizer 按字段生成假名，每个字段使用从主密钥派生的独立子密钥，同一个值在不同字段中得到不同的令牌，避免跨字段关联
+// 并发安全
+//
+// Pseudonymizer generates pseudonyms per field; each field uses its own sub-key derived from the master key, so the same value yields different tokens in different fields and cannot be linked across them.
+// It is safe for concurrent use.
+type Pseudonymizer struct {
+	key []byte
+}
+
+// NewPseudonymizer 创建按字段生成假名的 Pseudonymizer
+// 参数:
+//   - key: 主密钥，建议至少 32 字节随机数；更换密钥后所有令牌都会改变
+//
+// 返回:
+//   - *Pseudonymizer: 假名生成器
+//
+// NewPseudonymizer creates a Pseudonymizer that generates pseudonyms per field.
+// Parameters:
+//   - key: The master key, ideally at least 32 random bytes; rotating it changes every token
+//
+// Returns:
+//   - *Pseudonymizer: The pseudonymizer
+func NewPseudonymizer(key []byte) *Pseudonymizer {
+	return &Pseudonymizer{key: key}
+}
+
+// Token 为字段的值生成稳定的假名令牌，格式与 Pseudonymize 相同
+// 参数:
+//   - field: 字段名，例如 "user_id"
+//   - value: 原始值
+//
+// 返回:
+//   - string: 令牌
+//
+// Token derives a stable pseudonym token for a field value, in the same format as Pseudonymize.
+// Parameters:
+//   - field: The field name, e.g. "user_id"
+//   - value: The original value
+//
+// Returns:
+//   - string: The token
+func (p *Pseudonymizer) Token(field, value string) string {
+	return Pseudonymize(value, p.fieldKey(field))
+}
+
+// Digits 保留格式地为手机号、身份证号等数字串生成假名：只替换数字，分隔符和其他字符（如身份证号末尾的 X）保持不变，
+// 开头 keepStart 个和结尾 keepEnd 个数字原样保留，其余数字替换为由原值确定的伪随机数字；结果稳定且长度不变，可直接用于要求固定格式的下游系统
+// 注意保留的数字越多，可穷举的空间越小；不同原值可能得到相同的结果，不要将其作为唯一键
+// 参数:
+//   - field: 字段名
+//   - value: 原始值，例如 "+86 138-1234-5678"
+//   - keepStart: 开头保留的数字个数
+//   - keepEnd: 结尾保留的数字个数
+//
+// 返回:
+//   - string: 格式不变的假名，例如 keepStart 为 5、keepEnd 为 4 时得到 "+86 138-7302-5678"
+//
+// Digits pseudonymizes digit strings such as phone and ID numbers while preserving their format: only digits are replaced, separators and other characters (such as a trailing X in ID numbers) are kept,
+// the first keepStart and last keepEnd digits stay as they are and the rest become pseudo-random digits determined by the original value; the result is stable and has the same length, so it fits downstream systems that expect a fixed format.
+// The more digits are kept, the smaller the space left to enumerate; different values may map to the same result, so do not use it as a unique key.
+// Parameters:
+//   - field: The field name
+//   - value: The original value, e.g. "+86 138-1234-5678"
+//   - keepStart: Number of leading digits to keep
+//   - keepEnd: Number of trailing digits to keep
+//
+// Returns:
+//   - string: The format-preserving pseudonym, e.g. "+86 138-7302-5678" with keepStart 5 and keepEnd 4
+func (p *Pseudonymizer) Digits(field, value string, keepStart, keepEnd int) string {
+	total := 0
+	for i := range len(value) {
+		if value[i] >= '0' && value[i] <= '9' {
+			total++
+		}
+	}
+	if total == 0 {
+		return value
+	}
+	keepStart, keepEnd = max(keepStart, 0), max(keepEnd, 0)
+
+	stream := newDigitStream(p.fieldKey(field), value)
+	var b strings.Builder
+	b.Grow(len(value))
+	seen := 0
+	for i := range len(value) {
+		c := value[i]
+		if c < '0' || c > '9' {
+			b.WriteByte(c)
+			continue
+		}
+		if seen >= keepStart && seen < total-keepEnd {
+			c = stream.next()
+		}
+		b.WriteByte(c)
+		seen++
+	}
+	return b.String()
+}
+
+// fieldKey 派生字段的子密钥
+//
+// fieldKey derives the sub-key of a field
+func (p *Pseudonymizer) fieldKey(field string) []byte {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte("go-utils cryptoutil pseudonym field\x00"))
+	mac.Write([]byte(field))
+	return mac.Sum(nil)
+}
+
+// digitStream 以 HMAC-SHA256 计数器模式生成由原值确定的伪随机十进制数字
+//
+// digitStream produces pseudo-random decimal digits determined by the original value, using HMAC-SHA256 in counter mode
+type digitStream struct {
+	key     []byte
+	value   string
+	counter uint64
+	buf     []byte
+}
+
+// newDigitStream 创建数字流
+//
+// newDigitStream creates a digit stream
+func newDigitStream(key []byte, value string) *digitStream {
+	return &digitStream{key: key, value: value}
+}
+
+// next 返回下一个数字字符，丢弃 250 及以上的字节以避免取模偏差
+//
+// next returns the next digit character, discarding bytes of 250 and above to avoid modulo bias
+func (s *digitStream) next() byte {
+	for {
+		if len(s.buf) == 0 {
+			mac := hmac.New(sha256.New, s.key)
+			var ctr [8]byte
+			binary.BigEndian.PutUint64(ctr[:], s.counter)
+			s.counter++
+			mac.Write(ctr[:])
+			mac.Write([]byte(s.value))
+			s.buf = mac.Sum(nil)
+		}
+		c := s.buf[0]
+		s.buf = s.buf[1:]
+		if c < 250 {
+			return '0' + c%10
+		}
+	}
+}
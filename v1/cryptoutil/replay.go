@@ -0,0 +1,224 @@
+package cryptoutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/cacheutil"
+)
+
+const (
+	// DefaultReplayTTL 令牌没有 exp 时记录 jti 的默认时长
+	//
+	// DefaultReplayTTL is the default time a jti is remembered when the token has no exp
+	DefaultReplayTTL = 24 * time.Hour
+	// MinReplayTTL 记录 jti 的最短时长，过期时间已过但仍在验证器时钟容差内的令牌也至少记录这么久
+	//
+	// MinReplayTTL is the minimum time a jti is remembered, so tokens past exp but still within the verifier's leeway are recorded too
+	MinReplayTTL = time.Minute
+	// DefaultReplayCachePrefix CacheReplayGuard 的默认缓存键前缀
+	//
+	// DefaultReplayCachePrefix is the default cache key prefix of CacheReplayGuard
+	DefaultReplayCachePrefix = "replay:"
+)
+
+// ErrTokenReplayed 表示一次性令牌被重复使用
+//
+// ErrTokenReplayed indicates that a one-time token was used again
+var ErrTokenReplayed = errors.New("token replayed")
+
+// ReplayGuard 重放保护，记录已使用的令牌标识（jti）直到令牌过期
+// 自定义的验证器应把时钟容差计入 exp，即传入令牌最晚被接受的时间
+//
+// ReplayGuard provides replay protection by remembering used token identifiers (jti) until the token expires.
+// Custom verifiers should include their clock leeway in exp, i.e. pass the latest time the token is accepted.
+type ReplayGuard interface {
+	// Seen 记录 jti 并报告它在此之前是否已出现过；exp 为零值时使用 DefaultReplayTTL，已过或即将到来时至少记录 MinReplayTTL
+	//
+	// Seen records jti and reports whether it had been seen before; DefaultReplayTTL is used when exp is zero, and at least MinReplayTTL when it is past or imminent.
+	Seen(jti string, exp time.Time) bool
+}
+
+// MemoryReplayGuard 进程内的 ReplayGuard，仅适用于单实例部署
+//
+// MemoryReplayGuard is an in-process ReplayGuard, suitable only for single-instance deployments.
+type MemoryReplayGuard struct {
+	mu        sync.Mutex
+	seen      map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryReplayGuard 创建进程内的 ReplayGuard
+//
+// NewMemoryReplayGuard creates an in-process ReplayGuard.
+func NewMemoryReplayGuard() *MemoryReplayGuard {
+	return &MemoryReplayGuard{seen: make(map[string]time.Time), lastSweep: time.Now()}
+}
+
+// Seen 记录 jti 并报告它在此之前是否已出现过
+//
+// Seen records jti and reports whether it had been seen before.
+func (g *MemoryReplayGuard) Seen(jti string, exp time.Time) bool {
+	now := time.Now()
+	if exp.IsZero() {
+		exp = now.Add(DefaultReplayTTL)
+	} else if floor := now.Add(MinReplayTTL); exp.Before(floor) {
+		exp = floor
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if now.Sub(g.lastSweep) >= time.Minute {
+		for k, e := range g.seen {
+			if !now.Before(e) {
+				delete(g.seen, k)
+			}
+		}
+		g.lastSweep = now
+	}
+	if e, ok := g.seen[jti]; ok && now.Before(e) {
+		return true
+	}
+	g.seen[jti] = exp
+	return false
+}
+
+// CacheReplayGuard 基于 cacheutil.Cache 的 ReplayGuard，传入共享缓存即可用于多实例部署
+// 缓存出错时按已出现处理（拒绝请求），宁可误拒也不放过重放
+//
+// CacheReplayGuard is a ReplayGuard backed by cacheutil.Cache; pass a shared cache for multi-instance deployments.
+// Cache errors are treated as seen (the request is rejected), preferring false rejections over missed replays.
+type CacheReplayGuard struct {
+	cache  cacheutil.Cache
+	prefix string
+}
+
+// NewCacheReplayGuard 创建基于缓存的 ReplayGuard
+// 参数:
+//   - cache: 缓存，需要支持 SetNX
+//   - prefix: 缓存键前缀，为空时使用 DefaultReplayCachePrefix
+//
+// 返回:
+//   - *CacheReplayGuard: 重放保护
+//
+// NewCacheReplayGuard creates a cache backed ReplayGuard.
+// Parameters:
+//   - cache: The cache, which must support SetNX
+//   - prefix: Cache key prefix; DefaultReplayCachePrefix is used when empty
+//
+// Returns:
+//   - *CacheReplayGuard: The replay guard
+func NewCacheReplayGuard(cache cacheutil.Cache, prefix string) *CacheReplayGuard {
+	if prefix == "" {
+		prefix = DefaultReplayCachePrefix
+	}
+	return &CacheReplayGuard{cache: cache, prefix: prefix}
+}
+
+// Seen 通过 SetNX 原子地记录 jti 并报告它在此之前是否已出现过
+//
+// Seen atomically records jti with SetNX and reports whether it had been seen before.
+func (g *CacheReplayGuard) Seen(jti string, exp time.Time) bool {
+	ttl := DefaultReplayTTL
+	if !exp.IsZero() {
+		// 过期时间已过的令牌可能仍在验证器的时钟容差内，同样需要记录
+		ttl = max(time.Until(exp), MinReplayTTL)
+	}
+	ok, err := g.cache.SetNX(context.Background(), g.prefix+jti, []byte{1}, ttl)
+	return err != nil || !ok
+}
+
+// OneTimeVerifier 可选接口，OneTime 返回 true 表示每次验证都会消耗令牌（例如带有重放保护），结果不能被缓存
+// AuthMiddleware 对这类验证器不缓存验证结果，否则一次性令牌在缓存有效期内可以被重复使用；
+// *JwtVerifier（设置了 ReplayGuard 时）、WithReplayGuard 和 WithClaimsValidator 的返回值都实现了该接口，自定义的包装验证器应同样实现
+//
+// OneTimeVerifier is an optional interface; OneTime returning true means every verification consumes the token (e.g. with replay protection), so results must not be cached.
+// AuthMiddleware does not cache results of such verifiers, as a one-time token would otherwise be accepted again while the cache entry lives;
+// *JwtVerifier (with a ReplayGuard set) and the verifiers returned by WithReplayGuard and WithClaimsValidator implement it, and custom wrapping verifiers should too.
+type OneTimeVerifier interface {
+	OneTime() bool
+}
+
+// isOneTime 报告验证器是否实现了 OneTimeVerifier 且 OneTime 返回 true
+//
+// isOneTime reports whether the verifier implements OneTimeVerifier and OneTime returns true
+func isOneTime(verifier TokenVerifier) bool {
+	v, ok := verifier.(OneTimeVerifier)
+	return ok && v.OneTime()
+}
+
+// verifierLeeway 返回验证器校验 exp 时的时钟容差，被包装的 *JwtVerifier 返回其 Leeway，其他验证器返回 0
+//
+// verifierLeeway returns the clock leeway a verifier applies to exp: the Leeway of a wrapped *JwtVerifier, or 0 for other verifiers
+func verifierLeeway(verifier TokenVerifier) time.Duration {
+	if v, ok := verifier.(interface{ leeway() time.Duration }); ok {
+		return v.leeway()
+	}
+	return 0
+}
+
+// WithReplayGuard 为任意验证器增加重放保护
+// 验证成功后以 jti 声明作为标识；令牌没有 jti 时（例如 Apple 授权码）使用令牌的 SHA-256 摘要
+// 返回的验证器实现了 OneTimeVerifier，AuthMiddleware 不会缓存它的结果，每个请求都会检查重放
+// 参数:
+//   - verifier: 令牌验证器
+//   - guard: 重放保护
+//
+// 返回:
+//   - TokenVerifier: 令牌第二次出现时返回 ErrTokenReplayed 的验证器
+//
+// WithReplayGuard adds replay protection to any verifier.
+// After successful verification, the jti claim is used as the identifier; tokens without a jti (such as Apple authorization codes) use the token's SHA-256 digest.
+// The returned verifier implements OneTimeVerifier, so AuthMiddleware does not cache its results and every request is checked for replays.
+// Parameters:
+//   - verifier: The token verifier
+//   - guard: The replay guard
+//
+// Returns:
+//   - TokenVerifier: A verifier that returns ErrTokenReplayed when a token appears a second time
+func WithReplayGuard(verifier TokenVerifier, guard ReplayGuard) TokenVerifier {
+	return &replayVerifier{next: verifier, guard: guard}
+}
+
+// replayVerifier WithReplayGuard 返回的验证器
+//
+// replayVerifier is the verifier returned by WithReplayGuard
+type replayVerifier struct {
+	next  TokenVerifier
+	guard ReplayGuard
+}
+
+// Verify 实现 TokenVerifier 接口
+//
+// Verify implements the TokenVerifier interface.
+func (v *replayVerifier) Verify(ctx context.Context, token string) (Claims, error) {
+	claims, err := v.next.Verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		sum := sha256.Sum256([]byte(token))
+		jti = hex.EncodeToString(sum[:])
+	}
+	exp := claims.ExpiresAt()
+	if !exp.IsZero() {
+		exp = exp.Add(verifierLeeway(v.next))
+	}
+	if v.guard.Seen(jti, exp) {
+		return nil, fmt.Errorf("%w: %s", ErrTokenReplayed, jti)
+	}
+	return claims, nil
+}
+
+// OneTime 实现 OneTimeVerifier 接口
+//
+// OneTime implements the OneTimeVerifier interface.
+func (v *replayVerifier) OneTime() bool {
+	return true
+}
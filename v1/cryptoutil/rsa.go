@@ -0,0 +1,259 @@
+package cryptoutil
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+)
+
+// ErrRSA 表示 RSA 加解密、签名或验签失败
+//
+// ErrRSA indicates that RSA encryption, decryption, signing or verification failed
+var ErrRSA = errors.New("rsa operation failed")
+
+// RSAPadding RSA 加密的填充方式
+//
+// RSAPadding is the padding scheme for RSA encryption
+type RSAPadding int
+
+const (
+	// RSAPaddingOAEP OAEP 填充（默认），MGF1 使用与 Hash 相同的哈希函数
+	//
+	// RSAPaddingOAEP is OAEP padding (the default); MGF1 uses the same hash as Hash
+	RSAPaddingOAEP RSAPadding = iota
+	// RSAPaddingPKCS1v15 PKCS#1 v1.5 填充，仅用于对接要求该方式的旧系统
+	//
+	// RSAPaddingPKCS1v15 is PKCS#1 v1.5 padding, only for legacy systems that require it
+	RSAPaddingPKCS1v15
+)
+
+// RSAEncryptOptions RSA 加解密的选项
+// Padding: 填充方式，默认为 RSAPaddingOAEP
+// Hash: OAEP 使用的哈希函数，默认为 crypto.SHA256
+// Label: OAEP 的标签，加解密两端必须一致，通常为空
+//
+// RSAEncryptOptions contains options for RSA encryption and decryption.
+// Padding: The padding scheme, RSAPaddingOAEP by default
+// Hash: The hash used by OAEP, crypto.SHA256 by default
+// Label: The OAEP label, which must match on both ends; usually empty
+type RSAEncryptOptions struct {
+	Padding RSAPadding
+	Hash    crypto.Hash
+	Label   []byte
+}
+
+// RSASignOptions RSA 签名的选项
+// PSS: 是否使用 PSS 填充，默认使用 PKCS#1 v1.5（即 SHA256withRSA 等）
+// Hash: 摘要使用的哈希函数，默认为 crypto.SHA256
+//
+// RSASignOptions contains options for RSA signatures.
+// PSS: Whether to use PSS padding; PKCS#1 v1.5 (i.e. SHA256withRSA and friends) is used by default
+// Hash: The hash used for the digest, crypto.SHA256 by default
+type RSASignOptions struct {
+	PSS  bool
+	Hash crypto.Hash
+}
+
+// EncryptRSA 使用 RSA 公钥加密，超过单块上限的明文按块分段加密，密文为各块密文（每块与模数等长）的拼接
+// 参数:
+//   - pub: RSA 公钥
+//   - plaintext: 明文，长度不限
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - []byte: 密文，长度为模数字节数的整数倍
+//   - error: 如果加密失败，返回 ErrRSA
+//
+// EncryptRSA encrypts with an RSA public key; plaintext beyond the per-block limit is encrypted in chunks, and the ciphertext is the concatenation of the blocks, each as long as the modulus.
+// Parameters:
+//   - pub: RSA public key
+//   - plaintext: The plaintext, of any length
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - []byte: The ciphertext, a multiple of the modulus size in length
+//   - error: Returns ErrRSA if encryption fails
+func EncryptRSA(pub *rsa.PublicKey, plaintext []byte, opts *RSAEncryptOptions) ([]byte, error) {
+	o, err := rsaEncryptDefaults(opts)
+	if err != nil {
+		return nil, err
+	}
+	chunk := pub.Size() - 11
+	if o.Padding == RSAPaddingOAEP {
+		chunk = pub.Size() - 2*o.Hash.Size() - 2
+	}
+	if chunk <= 0 {
+		return nil, fmt.Errorf("%w: key too small for %v", ErrRSA, o.Hash)
+	}
+
+	out := make([]byte, 0, (len(plaintext)/chunk+1)*pub.Size())
+	for start := 0; start == 0 || start < len(plaintext); start += chunk {
+		block := plaintext[start:min(start+chunk, len(plaintext))]
+		var ct []byte
+		if o.Padding == RSAPaddingOAEP {
+			ct, err = rsa.EncryptOAEP(o.Hash.New(), rand.Reader, pub, block, o.Label)
+		} else {
+			ct, err = rsa.EncryptPKCS1v15(rand.Reader, pub, block)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRSA, err)
+		}
+		out = append(out, ct...)
+	}
+	return out, nil
+}
+
+// DecryptRSA 使用 RSA 私钥解密 EncryptRSA 的结果，逐块解密后拼接，选项必须与加密时一致
+// 参数:
+//   - priv: RSA 私钥
+//   - ciphertext: 密文，长度必须为模数字节数的整数倍
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - []byte: 明文
+//   - error: 如果密文长度不正确或解密失败，返回 ErrRSA
+//
+// DecryptRSA decrypts the output of EncryptRSA with an RSA private key, block by block; the options must match those used for encryption.
+// Parameters:
+//   - priv: RSA private key
+//   - ciphertext: The ciphertext, a multiple of the modulus size in length
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - []byte: The plaintext
+//   - error: Returns ErrRSA if the ciphertext length is wrong or decryption fails
+func DecryptRSA(priv *rsa.PrivateKey, ciphertext []byte, opts *RSAEncryptOptions) ([]byte, error) {
+	o, err := rsaEncryptDefaults(opts)
+	if err != nil {
+		return nil, err
+	}
+	size := priv.Size()
+	if len(ciphertext) == 0 || len(ciphertext)%size != 0 {
+		return nil, fmt.Errorf("%w: ciphertext length %d is not a multiple of %d", ErrRSA, len(ciphertext), size)
+	}
+
+	var out []byte
+	for start := 0; start < len(ciphertext); start += size {
+		block := ciphertext[start : start+size]
+		var pt []byte
+		if o.Padding == RSAPaddingOAEP {
+			pt, err = rsa.DecryptOAEP(o.Hash.New(), nil, priv, block, o.Label)
+		} else {
+			pt, err = rsa.DecryptPKCS1v15(nil, priv, block)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRSA, err)
+		}
+		out = append(out, pt...)
+	}
+	return out, nil
+}
+
+// SignRSA 使用 RSA 私钥对消息签名，内部计算摘要
+// 参数:
+//   - priv: RSA 私钥
+//   - msg: 原始消息（不是摘要）
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - []byte: 签名
+//   - error: 如果签名失败，返回 ErrRSA
+//
+// SignRSA signs a message with an RSA private key, computing the digest internally.
+// Parameters:
+//   - priv: RSA private key
+//   - msg: The raw message (not a digest)
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - []byte: The signature
+//   - error: Returns ErrRSA if signing fails
+func SignRSA(priv *rsa.PrivateKey, msg []byte, opts *RSASignOptions) ([]byte, error) {
+	o, digest, err := rsaDigest(msg, opts)
+	if err != nil {
+		return nil, err
+	}
+	var sig []byte
+	if o.PSS {
+		sig, err = rsa.SignPSS(rand.Reader, priv, o.Hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	} else {
+		sig, err = rsa.SignPKCS1v15(nil, priv, o.Hash, digest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrRSA, err)
+	}
+	return sig, nil
+}
+
+// VerifyRSA 使用 RSA 公钥验证 SignRSA 的签名，选项必须与签名时一致；PSS 签名接受任意盐长度
+// 参数:
+//   - pub: RSA 公钥
+//   - msg: 原始消息
+//   - sig: 签名
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - error: 签名无效时返回 ErrRSA
+//
+// VerifyRSA verifies a signature from SignRSA with an RSA public key; the options must match those used for signing. PSS signatures with any salt length are accepted.
+// Parameters:
+//   - pub: RSA public key
+//   - msg: The raw message
+//   - sig: The signature
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - error: Returns ErrRSA if the signature is invalid
+func VerifyRSA(pub *rsa.PublicKey, msg, sig []byte, opts *RSASignOptions) error {
+	o, digest, err := rsaDigest(msg, opts)
+	if err != nil {
+		return err
+	}
+	if o.PSS {
+		err = rsa.VerifyPSS(pub, o.Hash, digest, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	} else {
+		err = rsa.VerifyPKCS1v15(pub, o.Hash, digest, sig)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrRSA, err)
+	}
+	return nil
+}
+
+// rsaEncryptDefaults 复制加解密选项并填充默认值
+//
+// rsaEncryptDefaults copies the encryption options and fills in defaults
+func rsaEncryptDefaults(opts *RSAEncryptOptions) (RSAEncryptOptions, error) {
+	var o RSAEncryptOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Hash == 0 {
+		o.Hash = crypto.SHA256
+	}
+	if o.Padding == RSAPaddingOAEP && !o.Hash.Available() {
+		return o, fmt.Errorf("%w: hash %v unavailable", ErrRSA, o.Hash)
+	}
+	return o, nil
+}
+
+// rsaDigest 复制签名选项并填充默认值，然后计算消息摘要
+//
+// rsaDigest copies the signing options, fills in defaults and computes the message digest
+func rsaDigest(msg []byte, opts *RSASignOptions) (RSASignOptions, []byte, error) {
+	var o RSASignOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Hash == 0 {
+		o.Hash = crypto.SHA256
+	}
+	if !o.Hash.Available() {
+		return o, nil, fmt.Errorf("%w: hash %v unavailable", ErrRSA, o.Hash)
+	}
+	h := o.Hash.New()
+	h.Write(msg)
+	return o, h.Sum(nil), nil
+}
@@ -0,0 +1,313 @@
+package cryptoutil
+
+import (
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/emmansun/gmsm/sm2"
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"github.com/emmansun/gmsm/smx509"
+)
+
+// SM4KeySize SM4 密钥长度（字节）
+//
+// SM4KeySize is the SM4 key size in bytes
+const SM4KeySize = 16
+
+// ErrSM2 表示 SM2 签名、验签或加解密失败
+//
+// ErrSM2 indicates that SM2 signing, verification, encryption or decryption failed
+var ErrSM2 = errors.New("sm2 operation failed")
+
+// NewSM3 返回 SM3 哈希函数，可用于 hmac.New 等需要 hash.Hash 的场景
+//
+// NewSM3 returns an SM3 hash, usable wherever a hash.Hash is needed, e.g. hmac.New.
+func NewSM3() hash.Hash {
+	return sm3.New()
+}
+
+// SM3Sum 计算数据的 SM3 摘要
+//
+// SM3Sum computes the SM3 digest of the data.
+func SM3Sum(data []byte) []byte {
+	sum := sm3.Sum(data)
+	return sum[:]
+}
+
+// SM3Hex 计算数据的 SM3 摘要并返回小写十六进制字符串
+//
+// SM3Hex computes the SM3 digest of the data and returns it as a lower-case hex string.
+func SM3Hex(data []byte) string {
+	return hex.EncodeToString(SM3Sum(data))
+}
+
+// GenerateSM2Key 生成 SM2 密钥对
+//
+// GenerateSM2Key generates an SM2 key pair.
+func GenerateSM2Key() (*sm2.PrivateKey, error) {
+	return sm2.GenerateKey(rand.Reader)
+}
+
+// SM2Sign 使用 SM2 签名（内部按 GB/T 32918 计算 Z 值和 SM3 摘要）
+// 参数:
+//   - priv: SM2 私钥
+//   - msg: 原始消息（不是摘要）
+//   - uid: 用户标识，为 nil 时使用标准默认值 "1234567812345678"
+//
+// 返回:
+//   - []byte: ASN.1 DER 编码的签名
+//   - error: 如果签名失败，返回错误
+//
+// SM2Sign signs with SM2 (computing the Z value and SM3 digest internally per GB/T 32918).
+// Parameters:
+//   - priv: SM2 private key
+//   - msg: The raw message (not a digest)
+//   - uid: User ID; the standard default "1234567812345678" is used when nil
+//
+// Returns:
+//   - []byte: ASN.1 DER encoded signature
+//   - error: Returns an error if signing fails
+func SM2Sign(priv *sm2.PrivateKey, msg, uid []byte) ([]byte, error) {
+	sig, err := priv.SignWithSM2(rand.Reader, uid, msg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSM2, err)
+	}
+	return sig, nil
+}
+
+// SM2Verify 验证 SM2 签名
+// 参数:
+//   - pub: SM2 公钥
+//   - msg: 原始消息
+//   - sig: ASN.1 DER 编码的签名
+//   - uid: 用户标识，为 nil 时使用标准默认值
+//
+// 返回:
+//   - error: 签名无效时返回 ErrSM2
+//
+// SM2Verify verifies an SM2 signature.
+// Parameters:
+//   - pub: SM2 public key
+//   - msg: The raw message
+//   - sig: ASN.1 DER encoded signature
+//   - uid: User ID; the standard default is used when nil
+//
+// Returns:
+//   - error: Returns ErrSM2 if the signature is invalid
+func SM2Verify(pub *ecdsa.PublicKey, msg, sig, uid []byte) error {
+	if !sm2.VerifyASN1WithSM2(pub, uid, msg, sig) {
+		return fmt.Errorf("%w: invalid signature", ErrSM2)
+	}
+	return nil
+}
+
+// SM2Encrypt 使用 SM2 公钥加密，输出 GB/T 32918.4 标准的 C1C3C2 格式
+//
+// SM2Encrypt encrypts with an SM2 public key, producing the C1C3C2 format of GB/T 32918.4.
+func SM2Encrypt(pub *ecdsa.PublicKey, plaintext []byte) ([]byte, error) {
+	ct, err := sm2.Encrypt(rand.Reader, pub, plaintext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSM2, err)
+	}
+	return ct, nil
+}
+
+// SM2Decrypt 使用 SM2 私钥解密 C1C3C2 格式的密文
+//
+// SM2Decrypt decrypts C1C3C2 ciphertext with an SM2 private key.
+func SM2Decrypt(priv *sm2.PrivateKey, ciphertext []byte) ([]byte, error) {
+	pt, err := sm2.Decrypt(priv, ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSM2, err)
+	}
+	return pt, nil
+}
+
+// MarshalSM2PrivateKeyPEM 将 SM2 私钥编码为 PKCS#8 PEM，可通过 ParsePrivateKeyPEM 解析
+//
+// MarshalSM2PrivateKeyPEM encodes an SM2 private key as PKCS#8 PEM, parseable by ParsePrivateKeyPEM.
+func MarshalSM2PrivateKeyPEM(priv *sm2.PrivateKey) ([]byte, error) {
+	der, err := smx509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// MarshalSM2PublicKeyPEM 将 SM2 公钥编码为 PKIX PEM，可通过 ParsePublicKeyPEM 解析
+//
+// MarshalSM2PublicKeyPEM encodes an SM2 public key as PKIX PEM, parseable by ParsePublicKeyPEM.
+func MarshalSM2PublicKeyPEM(pub *ecdsa.PublicKey) ([]byte, error) {
+	der, err := smx509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// SM4EncryptGCM 使用 SM4-GCM 加密，随机 nonce 放在密文前面
+// 参数:
+//   - key: 16 字节密钥
+//   - plaintext: 明文
+//   - aad: 附加认证数据，可以为 nil
+//
+// 返回:
+//   - []byte: nonce 与密文
+//   - error: 如果密钥长度无效，返回错误
+//
+// SM4EncryptGCM encrypts with SM4-GCM, prepending a random nonce to the ciphertext.
+// Parameters:
+//   - key: 16-byte key
+//   - plaintext: Plaintext
+//   - aad: Additional authenticated data, may be nil
+//
+// Returns:
+//   - []byte: Nonce and ciphertext
+//   - error: Returns an error if the key length is invalid
+func SM4EncryptGCM(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := newSM4GCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcmSeal(aead, plaintext, aad)
+}
+
+// SM4DecryptGCM 解密 SM4EncryptGCM 生成的密文
+//
+// SM4DecryptGCM decrypts ciphertext produced by SM4EncryptGCM.
+func SM4DecryptGCM(key, ciphertext, aad []byte) ([]byte, error) {
+	aead, err := newSM4GCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(aead, ciphertext, aad)
+}
+
+// SM4EncryptCBC 使用 SM4-CBC 和 PKCS#7 填充加密，常用于对接要求 CBC 模式的政务系统
+// 参数:
+//   - key: 16 字节密钥
+//   - iv: 16 字节初始向量，为 nil 时随机生成并放在密文前面
+//   - plaintext: 明文
+//
+// 返回:
+//   - []byte: 密文（iv 为 nil 时包含前置的 iv）
+//   - error: 如果密钥或 iv 长度无效，返回错误
+//
+// SM4EncryptCBC encrypts with SM4-CBC and PKCS#7 padding, commonly required by government systems using CBC mode.
+// Parameters:
+//   - key: 16-byte key
+//   - iv: 16-byte initialization vector; when nil a random one is generated and prepended to the ciphertext
+//   - plaintext: Plaintext
+//
+// Returns:
+//   - []byte: Ciphertext (including the prepended iv when iv is nil)
+//   - error: Returns an error if the key or iv length is invalid
+func SM4EncryptCBC(key, iv, plaintext []byte) ([]byte, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	var out []byte
+	if iv == nil {
+		iv = make([]byte, sm4.BlockSize)
+		if _, err := rand.Read(iv); err != nil {
+			return nil, err
+		}
+		out = append(out, iv...)
+	} else if len(iv) != sm4.BlockSize {
+		return nil, fmt.Errorf("%w: iv must be %d bytes", ErrInvalidCiphertext, sm4.BlockSize)
+	}
+
+	padded := pkcs7Pad(plaintext, sm4.BlockSize)
+	ct := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ct, padded)
+	return append(out, ct...), nil
+}
+
+// SM4DecryptCBC 解密 SM4-CBC 密文并去除 PKCS#7 填充
+// 参数:
+//   - key: 16 字节密钥
+//   - iv: 加密时使用的 iv，为 nil 时从密文前 16 字节读取
+//   - ciphertext: 密文
+//
+// 返回:
+//   - []byte: 明文
+//   - error: 如果密文或填充无效，返回错误
+//
+// SM4DecryptCBC decrypts SM4-CBC ciphertext and removes the PKCS#7 padding.
+// Parameters:
+//   - key: 16-byte key
+//   - iv: The iv used for encryption; read from the first 16 bytes of the ciphertext when nil
+//   - ciphertext: Ciphertext
+//
+// Returns:
+//   - []byte: Plaintext
+//   - error: Returns an error if the ciphertext or padding is invalid
+func SM4DecryptCBC(key, iv, ciphertext []byte) ([]byte, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if iv == nil {
+		if len(ciphertext) < sm4.BlockSize {
+			return nil, ErrInvalidCiphertext
+		}
+		iv, ciphertext = ciphertext[:sm4.BlockSize], ciphertext[sm4.BlockSize:]
+	}
+	if len(iv) != sm4.BlockSize || len(ciphertext) == 0 || len(ciphertext)%sm4.BlockSize != 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	pt := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(pt, ciphertext)
+	return pkcs7Unpad(pt, sm4.BlockSize)
+}
+
+// newSM4GCM 创建 SM4-GCM
+//
+// newSM4GCM creates an SM4-GCM AEAD
+func newSM4GCM(key []byte) (cipher.AEAD, error) {
+	block, err := sm4.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pkcs7Pad 添加 PKCS#7 填充
+//
+// pkcs7Pad adds PKCS#7 padding
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	n := blockSize - len(data)%blockSize
+	out := make([]byte, len(data), len(data)+n)
+	copy(out, data)
+	for range n {
+		out = append(out, byte(n))
+	}
+	return out
+}
+
+// pkcs7Unpad 去除 PKCS#7 填充
+//
+// pkcs7Unpad removes PKCS#7 padding
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, ErrInvalidCiphertext
+	}
+	n := int(data[len(data)-1])
+	if n == 0 || n > blockSize || n > len(data) {
+		return nil, ErrInvalidCiphertext
+	}
+	for _, b := range data[len(data)-n:] {
+		if int(b) != n {
+			return nil, ErrInvalidCiphertext
+		}
+	}
+	return data[:len(data)-n], nil
+}
@@ -0,0 +1,176 @@
+package cryptoutil
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrVerifyToken 表示令牌验证失败
+//
+// ErrVerifyToken indicates that token verification failed
+var ErrVerifyToken = errors.New("token verification failed")
+
+// Claims 令牌声明
+//
+// Claims are the claims of a token.
+type Claims map[string]any
+
+// Subject 返回 sub 声明，不存在时返回空字符串
+//
+// Subject returns the sub claim, or an empty string if absent.
+func (c Claims) Subject() string {
+	s, _ := c["sub"].(string)
+	return s
+}
+
+// ExpiresAt 返回 exp 声明，不存在时返回零值
+//
+// ExpiresAt returns the exp claim, or the zero time if absent.
+func (c Claims) ExpiresAt() time.Time {
+	switch v := c["exp"].(type) {
+	case float64:
+		return time.Unix(int64(v), 0)
+	case int64:
+		return time.Unix(v, 0)
+	case int:
+		return time.Unix(int64(v), 0)
+	}
+	return time.Time{}
+}
+
+// TokenVerifier 令牌验证器，验证签名和有效期并返回声明
+//
+// TokenVerifier verifies a token's signature and validity and returns its claims.
+type TokenVerifier interface {
+	Verify(ctx context.Context, token string) (Claims, error)
+}
+
+// TokenVerifierFunc 函数形式的 TokenVerifier
+//
+// TokenVerifierFunc is a function adapter for TokenVerifier.
+type TokenVerifierFunc func(ctx context.Context, token string) (Claims, error)
+
+// Verify 调用 f 验证令牌
+//
+// Verify calls f to verify the token.
+func (f TokenVerifierFunc) Verify(ctx context.Context, token string) (Claims, error) {
+	return f(ctx, token)
+}
+
+// AppleTokenVerifier 返回基于 VerifyAppleToken 的验证器，声明中只包含 sub
+//
+// AppleTokenVerifier returns a verifier based on VerifyAppleToken; the claims contain only sub.
+func AppleTokenVerifier() TokenVerifier {
+	return defaultAppleKeyFetcher.TokenVerifier()
+}
+
+// TokenVerifier 返回基于 VerifyToken 的验证器，声明中只包含 sub
+//
+// TokenVerifier returns a verifier based on VerifyToken; the claims contain only sub.
+func (f *AppleKeyFetcher) TokenVerifier() TokenVerifier {
+	return TokenVerifierFunc(func(ctx context.Context, token string) (Claims, error) {
+		sub, err := f.VerifyToken(ctx, token)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrVerifyToken, err)
+		}
+		return Claims{"sub": sub}, nil
+	})
+}
+
+// JwtVerifier JWT token 验证器，与 JwtGenerator 配合使用
+// PublicKey: 公钥，例如 ParsePublicKeyPEM 的返回值
+// KeyAlgorithm: 密钥算法字符串，应使用常量：KeyAlgorithmEdDSA、KeyAlgorithmRS256、KeyAlgorithmES256
+// TokenIssuer: 期望的发行者，为空时不校验
+// Audience: 期望的受众，为空时不校验
+// Leeway: 校验 exp/nbf 时容忍的时钟偏差
+// ReplayGuard: 重放保护，设置后令牌必须带有 jti 且只能使用一次；此时 AuthMiddleware 不缓存验证结果（见 OneTimeVerifier）
+//
+// JwtVerifier is a JWT token verifier, the counterpart of JwtGenerator.
+// PublicKey: Public key, e.g. as returned by ParsePublicKeyPEM
+// KeyAlgorithm: Key algorithm string, should use constants: KeyAlgorithmEdDSA, KeyAlgorithmRS256, KeyAlgorithmES256
+// TokenIssuer: Expected issuer, not checked when empty
+// Audience: Expected audience, not checked when empty
+// Leeway: Clock skew tolerated when checking exp/nbf
+// ReplayGuard: Replay protection; when set, tokens must carry a jti and can be used only once, and AuthMiddleware does not cache verification results (see OneTimeVerifier)
+type JwtVerifier struct {
+	PublicKey    crypto.PublicKey
+	KeyAlgorithm string
+	TokenIssuer  string
+	Audience     string
+	Leeway       time.Duration
+	ReplayGuard  ReplayGuard
+}
+
+// Verify 验证 JWT token 的签名、算法、有效期以及发行者和受众
+// 参数:
+//   - ctx: 上下文（本验证器不发起网络请求）
+//   - token: JWT token 字符串
+//
+// 返回:
+//   - Claims: token 中的声明
+//   - error: 如果验证失败，返回 ErrVerifyToken；令牌被重复使用时返回 ErrTokenReplayed
+//
+// Verify verifies the JWT token's signature, algorithm, validity period, issuer and audience.
+// Parameters:
+//   - ctx: Context (this verifier makes no network requests)
+//   - token: JWT token string
+//
+// Returns:
+//   - Claims: The claims in the token
+//   - error: Returns ErrVerifyToken if verification fails, or ErrTokenReplayed if the token is used again
+func (j *JwtVerifier) Verify(_ context.Context, token string) (Claims, error) {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{j.KeyAlgorithm}),
+		jwt.WithLeeway(j.Leeway),
+		jwt.WithExpirationRequired(),
+	}
+	if j.TokenIssuer != "" {
+		opts = append(opts, jwt.WithIssuer(j.TokenIssuer))
+	}
+	if j.Audience != "" {
+		opts = append(opts, jwt.WithAudience(j.Audience))
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return j.PublicKey, nil
+	}, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerifyToken, err)
+	}
+
+	if j.ReplayGuard != nil {
+		jti, _ := claims["jti"].(string)
+		if jti == "" {
+			return nil, fmt.Errorf("%w: missing jti", ErrVerifyToken)
+		}
+		// 解析器接受 exp+Leeway 之前的令牌，jti 需要记录到那时
+		exp := Claims(claims).ExpiresAt()
+		if !exp.IsZero() {
+			exp = exp.Add(j.Leeway)
+		}
+		if j.ReplayGuard.Seen(jti, exp) {
+			return nil, fmt.Errorf("%w: %s", ErrTokenReplayed, jti)
+		}
+	}
+	return Claims(claims), nil
+}
+
+// OneTime 实现 OneTimeVerifier 接口，设置了 ReplayGuard 时返回 true
+//
+// OneTime implements the OneTimeVerifier interface, returning true when a ReplayGuard is set.
+func (j *JwtVerifier) OneTime() bool {
+	return j.ReplayGuard != nil
+}
+
+// leeway 返回校验 exp 时的时钟容差，供 WithReplayGuard 计算 jti 的记录时长
+//
+// leeway returns the clock leeway applied to exp, used by WithReplayGuard to decide how long to remember a jti
+func (j *JwtVerifier) leeway() time.Duration {
+	return j.Leeway
+}
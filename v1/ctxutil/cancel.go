@@ -0,0 +1,58 @@
+package ctxutil
+
+import (
+	"context"
+	"time"
+)
+
+// DetachValues 返回一个保留 ctx 中所有值、但不继承取消信号和截止时间的 context
+// 适用于请求结束后仍需继续执行的后台 goroutine（例如异步上报），同时保留请求 ID 等上下文信息
+//
+// DetachValues returns a context that keeps all values of ctx but does not inherit its cancellation and deadline.
+// It is suitable for background goroutines that must continue after the request ends (e.g. async reporting)
+// while keeping context information such as the request ID.
+func DetachValues(ctx context.Context) context.Context {
+	return context.WithoutCancel(ctx)
+}
+
+// DetachValuesWithTimeout 与 DetachValues 相同，但为新的 context 设置超时，避免后台任务无限运行
+//
+// DetachValuesWithTimeout is the same as DetachValues but sets a timeout on the new context
+// to prevent background tasks from running forever.
+func DetachValuesWithTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), timeout)
+}
+
+// MergeCancel 合并两个 context 的取消信号
+// 返回的 context 的值和截止时间来自 ctx1，当 ctx1 或 ctx2 任意一个被取消时，返回的 context 也会被取消
+// 取消原因（context.Cause）与先被取消的那个 context 一致
+// 参数:
+//   - ctx1: 主 context，提供值和截止时间
+//   - ctx2: 辅助 context，只提供取消信号
+//
+// 返回:
+//   - context.Context: 合并后的 context
+//   - context.CancelFunc: 用于释放资源的取消函数，使用完毕后必须调用
+//
+// MergeCancel merges the cancellation signals of two contexts.
+// The values and deadline of the returned context come from ctx1, and it is canceled when either ctx1 or ctx2 is canceled.
+// The cancellation cause (context.Cause) matches whichever context was canceled first.
+// Parameters:
+//   - ctx1: The primary context, providing values and deadline
+//   - ctx2: The secondary context, providing only the cancellation signal
+//
+// Returns:
+//   - context.Context: The merged context
+//   - context.CancelFunc: The cancel function to release resources, must be called when done
+func MergeCancel(ctx1, ctx2 context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx1)
+
+	stop := context.AfterFunc(ctx2, func() {
+		cancel(context.Cause(ctx2))
+	})
+
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
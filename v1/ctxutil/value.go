@@ -0,0 +1,103 @@
+// Package ctxutil 提供 context 相关的工具函数
+//
+// Package ctxutil provides context utility functions.
+package ctxutil
+
+import (
+	"context"
+)
+
+// Key 类型安全的 context 键
+// 每次调用 NewKey 都会创建一个新的唯一键，即使名称相同也不会冲突
+// 名称仅用于调试输出
+//
+// Key is a type-safe context key.
+// Each call to NewKey creates a new unique key; keys never collide even if their names are the same.
+// The name is only used for debugging output.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey 创建一个新的类型安全的 context 键
+//
+// NewKey creates a new type-safe context key.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String 返回键的名称，实现 fmt.Stringer 接口
+//
+// String returns the name of the key, implementing the fmt.Stringer interface.
+func (k *Key[T]) String() string {
+	return "ctxutil.Key(" + k.name + ")"
+}
+
+// Set 将值写入 context
+// 参数:
+//   - ctx: 父 context
+//   - key: 由 NewKey 创建的键
+//   - value: 要写入的值
+//
+// 返回:
+//   - 携带该值的新 context
+//
+// Set stores the value in the context.
+// Parameters:
+//   - ctx: The parent context
+//   - key: A key created by NewKey
+//   - value: The value to store
+//
+// Returns:
+//   - A new context carrying the value
+func Set[T any](ctx context.Context, key *Key[T], value T) context.Context {
+	return context.WithValue(ctx, key, value)
+}
+
+// Get 从 context 中读取值
+// 参数:
+//   - ctx: context
+//   - key: 由 NewKey 创建的键
+//
+// 返回:
+//   - 读取到的值，不存在时为零值
+//   - 值是否存在
+//
+// Get reads the value from the context.
+// Parameters:
+//   - ctx: The context
+//   - key: A key created by NewKey
+//
+// Returns:
+//   - The value read, or the zero value if it does not exist
+//   - Whether the value exists
+func Get[T any](ctx context.Context, key *Key[T]) (T, bool) {
+	if ctx == nil {
+		var zero T
+		return zero, false
+	}
+	v, ok := ctx.Value(key).(T)
+	return v, ok
+}
+
+// GetOr 从 context 中读取值，不存在时返回 def
+//
+// GetOr reads the value from the context, returning def if it does not exist.
+func GetOr[T any](ctx context.Context, key *Key[T], def T) T {
+	if v, ok := Get(ctx, key); ok {
+		return v
+	}
+	return def
+}
+
+// MustGet 从 context 中读取值，不存在时 panic
+// 仅用于由中间件保证一定存在的值
+//
+// MustGet reads the value from the context and panics if it does not exist.
+// Only use it for values guaranteed to be present by middleware.
+func MustGet[T any](ctx context.Context, key *Key[T]) T {
+	v, ok := Get(ctx, key)
+	if !ok {
+		panic("ctxutil: missing context value " + key.String())
+	}
+	return v
+}
@@ -0,0 +1,367 @@
+// Package currencyutil 提供基于最小货币单位（整数）的金额计算工具，避免使用 float64 带来的精度问题
+//
+// Package currencyutil provides money arithmetic based on minor units (integers), avoiding float64 precision bugs.
+package currencyutil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrCurrencyMismatch 表示两个金额的币种不一致
+	//
+	// ErrCurrencyMismatch indicates that the currencies of two amounts do not match.
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	// ErrOverflow 表示金额计算溢出 int64
+	//
+	// ErrOverflow indicates that the money calculation overflowed int64.
+	ErrOverflow = errors.New("money amount overflow")
+	// ErrInvalidAmount 表示无效的金额字符串
+	//
+	// ErrInvalidAmount indicates an invalid amount string.
+	ErrInvalidAmount = errors.New("invalid money amount")
+	// ErrInvalidRatio 表示无效的比例或分配参数
+	//
+	// ErrInvalidRatio indicates an invalid ratio or allocation argument.
+	ErrInvalidRatio = errors.New("invalid money ratio")
+)
+
+// Money 金额，使用最小货币单位（例如人民币的“分”）的 int64 表示
+// 零值表示没有币种的 0，通常应通过 New 或 Parse 创建
+//
+// Money is an amount represented as int64 minor units (e.g. "fen" for CNY).
+// The zero value is 0 without a currency; it should normally be created via New or Parse.
+type Money struct {
+	amount   int64
+	currency string
+}
+
+// New 创建金额
+// 参数:
+//   - amount: 最小货币单位的数量，例如 1234 表示 12.34 元
+//   - currency: ISO 4217 币种代码，例如 CNY
+//
+// New creates an amount.
+// Parameters:
+//   - amount: Number of minor units, e.g. 1234 means 12.34 CNY
+//   - currency: ISO 4217 currency code, e.g. CNY
+func New(amount int64, currency string) Money {
+	return Money{amount: amount, currency: strings.ToUpper(currency)}
+}
+
+// Zero 创建指定币种的零金额
+//
+// Zero creates a zero amount of the specified currency.
+func Zero(currency string) Money {
+	return New(0, currency)
+}
+
+// Amount 返回最小货币单位的数量
+//
+// Amount returns the number of minor units.
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+// Currency 返回币种代码
+//
+// Currency returns the currency code.
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// IsZero 判断金额是否为 0
+//
+// IsZero reports whether the amount is zero.
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+// IsNegative 判断金额是否为负数
+//
+// IsNegative reports whether the amount is negative.
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+// IsPositive 判断金额是否为正数
+//
+// IsPositive reports whether the amount is positive.
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+// SameCurrency 判断两个金额的币种是否一致
+//
+// SameCurrency reports whether two amounts have the same currency.
+func (m Money) SameCurrency(o Money) bool {
+	return m.currency == o.currency
+}
+
+// Add 金额相加，币种不一致或溢出时返回错误
+//
+// Add adds two amounts, returning an error if the currencies differ or the result overflows.
+func (m Money) Add(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	sum := m.amount + o.amount
+	// 同号相加结果变号说明溢出
+	if (m.amount > 0 && o.amount > 0 && sum < 0) || (m.amount < 0 && o.amount < 0 && sum >= 0) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: sum, currency: m.currency}, nil
+}
+
+// Sub 金额相减，币种不一致或溢出时返回错误
+//
+// Sub subtracts o from m, returning an error if the currencies differ or the result overflows.
+func (m Money) Sub(o Money) (Money, error) {
+	if o.amount == math.MinInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(Money{amount: -o.amount, currency: o.currency})
+}
+
+// Neg 返回相反数
+//
+// Neg returns the negated amount.
+func (m Money) Neg() Money {
+	return Money{amount: -m.amount, currency: m.currency}
+}
+
+// Abs 返回绝对值
+//
+// Abs returns the absolute amount.
+func (m Money) Abs() Money {
+	if m.amount < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// Cmp 比较两个金额，m < o 返回 -1，相等返回 0，m > o 返回 1；币种不一致时返回错误
+//
+// Cmp compares two amounts, returning -1 if m < o, 0 if equal, 1 if m > o; returns an error if the currencies differ.
+func (m Money) Cmp(o Money) (int, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return 0, err
+	}
+	switch {
+	case m.amount < o.amount:
+		return -1, nil
+	case m.amount > o.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Equal 判断两个金额是否相等（币种和数量都相同）
+//
+// Equal reports whether two amounts are equal (same currency and amount).
+func (m Money) Equal(o Money) bool {
+	return m.currency == o.currency && m.amount == o.amount
+}
+
+// Mul 金额乘以整数，溢出时返回错误
+//
+// Mul multiplies the amount by an integer, returning an error on overflow.
+func (m Money) Mul(n int64) (Money, error) {
+	return m.MulRatio(n, 1, RoundHalfUp)
+}
+
+// MulRatio 金额乘以比例 num/den，并按指定舍入模式取整到最小货币单位
+// 中间结果使用大整数计算，不会因乘法溢出而丢失精度
+// 例如计算 6% 的税费：m.MulRatio(6, 100, RoundHalfUp)
+// 参数:
+//   - num: 分子
+//   - den: 分母，不能为 0
+//   - mode: 舍入模式
+//
+// 返回:
+//   - Money: 计算结果
+//   - error: 分母为 0 或结果溢出时返回错误
+//
+// MulRatio multiplies the amount by the ratio num/den and rounds to minor units using the given rounding mode.
+// Intermediate results are computed with big integers, so no precision is lost due to multiplication overflow.
+// For example, computing a 6% tax: m.MulRatio(6, 100, RoundHalfUp)
+// Parameters:
+//   - num: Numerator
+//   - den: Denominator, must not be 0
+//   - mode: Rounding mode
+//
+// Returns:
+//   - Money: The result
+//   - error: Returns an error if the denominator is 0 or the result overflows
+func (m Money) MulRatio(num, den int64, mode RoundingMode) (Money, error) {
+	if den == 0 {
+		return Money{}, fmt.Errorf("%w: denominator is zero", ErrInvalidRatio)
+	}
+	product := new(big.Int).Mul(big.NewInt(m.amount), big.NewInt(num))
+	q := divRound(product, big.NewInt(den), mode)
+	if !q.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: q.Int64(), currency: m.currency}, nil
+}
+
+// Allocate 按比例分配金额，保证各部分之和严格等于原金额（精确到分）
+// 先按比例向零取整，剩余的最小单位按余数从大到小依次分配
+// 例如 100 分按 [1, 1, 1] 分配得到 [34, 33, 33]
+// 参数:
+//   - ratios: 分配比例，不能为负数，且总和必须大于 0
+//
+// 返回:
+//   - []Money: 分配结果，顺序与 ratios 一致
+//   - error: 比例无效时返回错误
+//
+// Allocate splits the amount by ratios, guaranteeing that the parts sum exactly to the original amount (fen-accurate).
+// Each part is first truncated toward zero, then the remaining minor units are distributed by descending remainder.
+// For example, 100 fen allocated by [1, 1, 1] yields [34, 33, 33].
+// Parameters:
+//   - ratios: Allocation ratios, must be non-negative with a positive sum
+//
+// Returns:
+//   - []Money: Allocation results in the same order as ratios
+//   - error: Returns an error if the ratios are invalid
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, fmt.Errorf("%w: no ratios", ErrInvalidRatio)
+	}
+	total := new(big.Int)
+	for _, r := range ratios {
+		if r < 0 {
+			return nil, fmt.Errorf("%w: negative ratio %d", ErrInvalidRatio, r)
+		}
+		total.Add(total, big.NewInt(r))
+	}
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("%w: ratios sum to zero", ErrInvalidRatio)
+	}
+
+	amount := big.NewInt(m.amount)
+	parts := make([]Money, len(ratios))
+	remainders := make([]*big.Int, len(ratios))
+	allocated := int64(0)
+	for i, r := range ratios {
+		q, rem := new(big.Int).QuoRem(new(big.Int).Mul(amount, big.NewInt(r)), total, new(big.Int))
+		parts[i] = Money{amount: q.Int64(), currency: m.currency}
+		remainders[i] = rem.Abs(rem)
+		allocated += q.Int64()
+	}
+
+	// 剩余部分（绝对值小于 len(ratios)）按余数从大到小分配，余数相同时靠前的优先
+	left := m.amount - allocated
+	step := int64(1)
+	if left < 0 {
+		step = -1
+		left = -left
+	}
+	used := make([]bool, len(ratios))
+	for ; left > 0; left-- {
+		best := -1
+		for i := range remainders {
+			if used[i] || ratios[i] == 0 {
+				continue
+			}
+			if best < 0 || remainders[i].Cmp(remainders[best]) > 0 {
+				best = i
+			}
+		}
+		used[best] = true
+		parts[best].amount += step
+	}
+	return parts, nil
+}
+
+// Split 将金额平均分成 n 份，余数从前往后依次分配，保证各部分之和等于原金额
+//
+// Split divides the amount evenly into n parts, distributing the remainder from the front,
+// guaranteeing that the parts sum to the original amount.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: split count must be positive", ErrInvalidRatio)
+	}
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
+// Decimal 返回金额的十进制字符串（不含币种），例如 "12.34"、"-0.05"、"100"（JPY）
+//
+// Decimal returns the decimal string of the amount (without currency), e.g. "12.34", "-0.05", "100" (JPY).
+func (m Money) Decimal() string {
+	digits := MinorUnits(m.currency)
+	neg := m.amount < 0
+	// 使用 uint64 处理 math.MinInt64 的绝对值
+	abs := uint64(m.amount)
+	if neg {
+		abs = -abs
+	}
+	s := strconv.FormatUint(abs, 10)
+	if digits > 0 {
+		if len(s) <= digits {
+			s = strings.Repeat("0", digits-len(s)+1) + s
+		}
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	if neg {
+		s = "-" + s
+	}
+	return s
+}
+
+// String 返回带币种的字符串，例如 "12.34 CNY"，实现 fmt.Stringer 接口
+//
+// String returns the string with currency, e.g. "12.34 CNY", implementing the fmt.Stringer interface.
+func (m Money) String() string {
+	if m.currency == "" {
+		return m.Decimal()
+	}
+	return m.Decimal() + " " + m.currency
+}
+
+// moneyJSON Money 的 JSON 表示
+//
+// moneyJSON is the JSON representation of Money
+type moneyJSON struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON 实现 json.Marshaler 接口，输出 {"amount":1234,"currency":"CNY"}
+//
+// MarshalJSON implements the json.Marshaler interface, producing {"amount":1234,"currency":"CNY"}.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.amount, Currency: m.currency})
+}
+
+// UnmarshalJSON 实现 json.Unmarshaler 接口，接受 {"amount":1234,"currency":"CNY"}
+//
+// UnmarshalJSON implements the json.Unmarshaler interface, accepting {"amount":1234,"currency":"CNY"}.
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAmount, err)
+	}
+	*m = New(v.Amount, v.Currency)
+	return nil
+}
+
+// checkCurrency 检查币种是否一致
+//
+// checkCurrency checks whether the currencies match
+func (m Money) checkCurrency(o Money) error {
+	if m.currency != o.currency {
+		return fmt.Errorf("%w: %s vs %s", ErrCurrencyMismatch, m.currency, o.currency)
+	}
+	return nil
+}
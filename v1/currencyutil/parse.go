@@ -0,0 +1,168 @@
+package currencyutil
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// CNY 人民币
+	//
+	// CNY is the Chinese Yuan
+	CNY = "CNY"
+	// USD 美元
+	//
+	// USD is the US Dollar
+	USD = "USD"
+	// EUR 欧元
+	//
+	// EUR is the Euro
+	EUR = "EUR"
+	// HKD 港币
+	//
+	// HKD is the Hong Kong Dollar
+	HKD = "HKD"
+	// JPY 日元（无小数位）
+	//
+	// JPY is the Japanese Yen (no minor units)
+	JPY = "JPY"
+)
+
+// minorUnits 币种的小数位数，未列出的币种默认为 2 位
+//
+// minorUnits is the number of decimal places for currencies; unlisted currencies default to 2
+var minorUnits = map[string]int{
+	JPY:   0,
+	"KRW": 0,
+	"VND": 0,
+	"CLP": 0,
+	"ISK": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+	"JOD": 3,
+	"TND": 3,
+}
+
+// MinorUnits 返回币种的小数位数（ISO 4217），例如 CNY 为 2、JPY 为 0
+//
+// MinorUnits returns the number of decimal places of the currency (ISO 4217), e.g. 2 for CNY and 0 for JPY.
+func MinorUnits(currency string) int {
+	if d, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return d
+	}
+	return 2
+}
+
+// Parse 将十进制字符串解析为金额，不经过 float64
+// 支持 "12.34"、"-0.5"、"+3"、"1,234.56" 等写法
+// 小数位数超过币种精度时返回错误，需要舍入时请使用 ParseRound
+// 参数:
+//   - s: 十进制金额字符串
+//   - currency: 币种代码
+//
+// 返回:
+//   - Money: 解析后的金额
+//   - error: 格式无效、精度超出或溢出时返回错误
+//
+// Parse parses a decimal string into an amount without going through float64.
+// Supports forms such as "12.34", "-0.5", "+3" and "1,234.56".
+// Returns an error if there are more decimal places than the currency allows; use ParseRound when rounding is needed.
+// Parameters:
+//   - s: Decimal amount string
+//   - currency: Currency code
+//
+// Returns:
+//   - Money: The parsed amount
+//   - error: Returns an error if the format is invalid, the precision is exceeded or the value overflows
+func Parse(s string, currency string) (Money, error) {
+	num, den, err := parseDecimal(s)
+	if err != nil {
+		return Money{}, err
+	}
+	scaled := new(big.Int).Mul(num, pow10(MinorUnits(currency)))
+	q, r := new(big.Int).QuoRem(scaled, den, new(big.Int))
+	if r.Sign() != 0 {
+		return Money{}, fmt.Errorf("%w: %q has more than %d decimal places", ErrInvalidAmount, s, MinorUnits(currency))
+	}
+	if !q.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return New(q.Int64(), currency), nil
+}
+
+// ParseRound 与 Parse 相同，但小数位数超过币种精度时按指定模式舍入
+//
+// ParseRound is the same as Parse but rounds using the given mode when there are more decimal places than the currency allows.
+func ParseRound(s string, currency string, mode RoundingMode) (Money, error) {
+	num, den, err := parseDecimal(s)
+	if err != nil {
+		return Money{}, err
+	}
+	scaled := new(big.Int).Mul(num, pow10(MinorUnits(currency)))
+	q := divRound(scaled, den, mode)
+	if !q.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return New(q.Int64(), currency), nil
+}
+
+// MustParse 与 Parse 相同，但解析失败时 panic，适用于常量金额
+//
+// MustParse is the same as Parse but panics on failure, suitable for constant amounts.
+func MustParse(s string, currency string) Money {
+	m, err := Parse(s, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// parseDecimal 将十进制字符串解析为 num/den（den 为 10 的幂）
+//
+// parseDecimal parses a decimal string into num/den (den is a power of 10)
+func parseDecimal(s string) (*big.Int, *big.Int, error) {
+	raw := s
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if s == "" {
+		return nil, nil, fmt.Errorf("%w: empty input", ErrInvalidAmount)
+	}
+
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if intPart == "" && fracPart == "" {
+		return nil, nil, fmt.Errorf("%w: %q", ErrInvalidAmount, raw)
+	}
+	for _, part := range []string{intPart, fracPart} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return nil, nil, fmt.Errorf("%w: %q", ErrInvalidAmount, raw)
+			}
+		}
+	}
+
+	num, ok := new(big.Int).SetString("0"+intPart+fracPart, 10)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q", ErrInvalidAmount, raw)
+	}
+	if neg {
+		num.Neg(num)
+	}
+	return num, pow10(len(fracPart)), nil
+}
+
+// pow10 返回 10 的 n 次方
+//
+// pow10 returns 10 to the power of n
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,80 @@
+package currencyutil
+
+import "math/big"
+
+// RoundingMode 舍入模式
+//
+// RoundingMode is the rounding mode
+type RoundingMode int
+
+const (
+	// RoundHalfUp 四舍五入（0.5 远离零），例如 2.5 -> 3，-2.5 -> -3
+	//
+	// RoundHalfUp rounds half away from zero, e.g. 2.5 -> 3, -2.5 -> -3
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven 银行家舍入（四舍六入五成双），例如 2.5 -> 2，3.5 -> 4
+	//
+	// RoundHalfEven rounds half to even (banker's rounding), e.g. 2.5 -> 2, 3.5 -> 4
+	RoundHalfEven
+	// RoundHalfDown 五舍六入（0.5 向零），例如 2.5 -> 2，-2.5 -> -2
+	//
+	// RoundHalfDown rounds half toward zero, e.g. 2.5 -> 2, -2.5 -> -2
+	RoundHalfDown
+	// RoundDown 向零截断，例如 2.9 -> 2，-2.9 -> -2
+	//
+	// RoundDown truncates toward zero, e.g. 2.9 -> 2, -2.9 -> -2
+	RoundDown
+	// RoundUp 远离零进位，例如 2.1 -> 3，-2.1 -> -3
+	//
+	// RoundUp rounds away from zero, e.g. 2.1 -> 3, -2.1 -> -3
+	RoundUp
+	// RoundFloor 向负无穷取整，例如 2.9 -> 2，-2.1 -> -3
+	//
+	// RoundFloor rounds toward negative infinity, e.g. 2.9 -> 2, -2.1 -> -3
+	RoundFloor
+	// RoundCeiling 向正无穷取整，例如 2.1 -> 3，-2.9 -> -2
+	//
+	// RoundCeiling rounds toward positive infinity, e.g. 2.1 -> 3, -2.9 -> -2
+	RoundCeiling
+)
+
+// divRound 计算 x/y 并按指定模式舍入为整数
+//
+// divRound computes x/y and rounds it to an integer using the given mode
+func divRound(x, y *big.Int, mode RoundingMode) *big.Int {
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	// 结果的符号（QuoRem 向零截断）
+	sign := x.Sign() * y.Sign()
+
+	// 比较 2|r| 与 |y|，判断是否超过一半
+	twice := new(big.Int).Abs(r)
+	twice.Lsh(twice, 1)
+	half := twice.Cmp(new(big.Int).Abs(y))
+
+	awayFromZero := false
+	switch mode {
+	case RoundHalfUp:
+		awayFromZero = half >= 0
+	case RoundHalfDown:
+		awayFromZero = half > 0
+	case RoundHalfEven:
+		awayFromZero = half > 0 || (half == 0 && q.Bit(0) == 1)
+	case RoundUp:
+		awayFromZero = true
+	case RoundDown:
+		awayFromZero = false
+	case RoundFloor:
+		awayFromZero = sign < 0
+	case RoundCeiling:
+		awayFromZero = sign > 0
+	}
+
+	if awayFromZero {
+		q.Add(q, big.NewInt(int64(sign)))
+	}
+	return q
+}
@@ -0,0 +1,337 @@
+package dbutil
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultBulkChunkSize BulkInsert 默认每条语句插入的行数
+	//
+	// DefaultBulkChunkSize is the default number of rows per statement in BulkInsert
+	DefaultBulkChunkSize = 500
+	// maxPlaceholders 单条语句的参数上限，取 PostgreSQL 和 MySQL 中较小的 65535
+	//
+	// maxPlaceholders is the per-statement parameter limit, the smaller 65535 of PostgreSQL and MySQL
+	maxPlaceholders = 65535
+)
+
+var (
+	// ErrInvalidIdentifier 表示表名或列名不是合法的标识符
+	//
+	// ErrInvalidIdentifier indicates that a table or column name is not a valid identifier
+	ErrInvalidIdentifier = errors.New("invalid sql identifier")
+	// ErrInvalidRows 表示批量插入的行为空或列不一致
+	//
+	// ErrInvalidRows indicates that the rows to bulk insert are empty or have inconsistent columns
+	ErrInvalidRows = errors.New("invalid rows")
+)
+
+// Dialect 占位符风格
+//
+// Dialect is a placeholder style.
+type Dialect int
+
+const (
+	// DialectQuestion 使用 ? 占位符，适用于 MySQL 和 SQLite
+	//
+	// DialectQuestion uses ? placeholders, for MySQL and SQLite
+	DialectQuestion Dialect = iota
+	// DialectDollar 使用 $1、$2 占位符，适用于 PostgreSQL
+	//
+	// DialectDollar uses $1, $2 placeholders, for PostgreSQL
+	DialectDollar
+)
+
+// Statement 带参数的 SQL 语句
+// Query: 使用 ? 占位符的语句
+// Args: 按顺序对应占位符的参数
+//
+// Statement is a SQL statement with its arguments.
+// Query: The statement using ? placeholders
+// Args: The arguments matching the placeholders in order
+type Statement struct {
+	Query string
+	Args  []any
+}
+
+// BuildInClause 生成参数化的 IN 条件，例如 "id IN (?, ?, ?)"，值全部作为参数传递，不会拼接进 SQL
+// values 为空时返回恒为假的 "1 = 0"，避免生成语法错误的 "IN ()"
+// 参数:
+//   - column: 列名，可以带表名前缀，例如 "u.id"
+//   - values: 候选值
+//
+// 返回:
+//   - string: 使用 ? 占位符的条件，PostgreSQL 需要再经过 Rebind
+//   - []any: 参数
+//   - error: 列名不合法时返回 ErrInvalidIdentifier
+//
+// BuildInClause builds a parameterized IN condition such as "id IN (?, ?, ?)"; all values are passed as arguments and never spliced into the SQL.
+// When values is empty it returns the always-false "1 = 0" instead of the invalid "IN ()".
+// Parameters:
+//   - column: Column name, optionally qualified such as "u.id"
+//   - values: Candidate values
+//
+// Returns:
+//   - string: The condition with ? placeholders; pass it through Rebind for PostgreSQL
+//   - []any: The arguments
+//   - error: Returns ErrInvalidIdentifier if the column name is invalid
+func BuildInClause[T any](column string, values []T) (string, []any, error) {
+	if err := checkIdentifier(column); err != nil {
+		return "", nil, err
+	}
+	if len(values) == 0 {
+		return "1 = 0", nil, nil
+	}
+	args := make([]any, len(values))
+	for i, v := range values {
+		args[i] = v
+	}
+	var b strings.Builder
+	b.Grow(len(column) + 6 + 3*len(values))
+	b.WriteString(column)
+	b.WriteString(" IN (")
+	writePlaceholders(&b, len(values))
+	b.WriteByte(')')
+	return b.String(), args, nil
+}
+
+// BulkInsert 生成分块的多行 INSERT 语句，列取所有行的键并按名称排序，每行必须包含相同的列
+// 每条语句的行数不超过 chunkSize，且参数总数不超过 65535
+// 参数:
+//   - table: 表名，可以带模式前缀，例如 "public.users"
+//   - rows: 要插入的行，键为列名
+//   - chunkSize: 每条语句的最大行数，小于等于 0 时使用 DefaultBulkChunkSize
+//
+// 返回:
+//   - []Statement: 使用 ? 占位符的语句，按顺序执行
+//   - error: 名称不合法时返回 ErrInvalidIdentifier，行为空或列不一致时返回 ErrInvalidRows
+//
+// BulkInsert builds chunked multi-row INSERT statements. The columns are the rows' keys sorted by name, and every row must have the same columns.
+// Each statement holds at most chunkSize rows and at most 65535 arguments.
+// Parameters:
+//   - table: Table name, optionally schema-qualified such as "public.users"
+//   - rows: The rows to insert, keyed by column name
+//   - chunkSize: Maximum rows per statement; DefaultBulkChunkSize when not positive
+//
+// Returns:
+//   - []Statement: Statements with ? placeholders, to be executed in order
+//   - error: Returns ErrInvalidIdentifier for invalid names, or ErrInvalidRows if rows is empty or the columns differ
+func BulkInsert(table string, rows []map[string]any, chunkSize int) ([]Statement, error) {
+	if err := checkIdentifier(table); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: no rows", ErrInvalidRows)
+	}
+	columns := make([]string, 0, len(rows[0]))
+	for col := range rows[0] {
+		if err := checkIdentifier(col); err != nil {
+			return nil, err
+		}
+		columns = append(columns, col)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("%w: no columns", ErrInvalidRows)
+	}
+	slices.Sort(columns)
+	for i, row := range rows[1:] {
+		if len(row) != len(columns) {
+			return nil, fmt.Errorf("%w: row %d has %d columns, want %d", ErrInvalidRows, i+1, len(row), len(columns))
+		}
+		for _, col := range columns {
+			if _, ok := row[col]; !ok {
+				return nil, fmt.Errorf("%w: row %d is missing column %s", ErrInvalidRows, i+1, col)
+			}
+		}
+	}
+
+	if chunkSize <= 0 {
+		chunkSize = DefaultBulkChunkSize
+	}
+	chunkSize = min(chunkSize, maxPlaceholders/len(columns))
+	if chunkSize == 0 {
+		return nil, fmt.Errorf("%w: too many columns", ErrInvalidRows)
+	}
+	prefix := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES "
+
+	var stmts []Statement
+	for chunk := range slices.Chunk(rows, chunkSize) {
+		var b strings.Builder
+		b.WriteString(prefix)
+		args := make([]any, 0, len(chunk)*len(columns))
+		for i, row := range chunk {
+			if i > 0 {
+				b.WriteString(", ")
+			}
+			b.WriteByte('(')
+			writePlaceholders(&b, len(columns))
+			b.WriteByte(')')
+			for _, col := range columns {
+				args = append(args, row[col])
+			}
+		}
+		stmts = append(stmts, Statement{Query: b.String(), Args: args})
+	}
+	return stmts, nil
+}
+
+// Rebind 将语句中的 ? 占位符转换为指定风格，引号内的 ? 保持不变
+// 注意 PostgreSQL 的 jsonb ? 运算符也会被转换，请改用 jsonb_exists 等函数
+// 参数:
+//   - d: 目标占位符风格
+//   - query: 使用 ? 占位符的语句
+//
+// 返回:
+//   - string: 转换后的语句
+//
+// Rebind converts the ? placeholders in a statement to the given style; a ? inside quotes is left unchanged.
+// Note that the PostgreSQL jsonb ? operator is converted too; use functions such as jsonb_exists instead.
+// Parameters:
+//   - d: Target placeholder style
+//   - query: Statement using ? placeholders
+//
+// Returns:
+//   - string: The converted statement
+func Rebind(d Dialect, query string) string {
+	if d != DialectDollar {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 16)
+	var quote byte
+	n := 0
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			// 引号内原样输出，连续两个引号的转义会先关闭再打开，结果相同
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// writePlaceholders 写入 n 个以逗号分隔的 ? 占位符
+//
+// writePlaceholders writes n comma-separated ? placeholders
+func writePlaceholders(b *strings.Builder, n int) {
+	for i := range n {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('?')
+	}
+}
+
+// checkIdentifier 校验由点分隔的标识符，每段只能包含字母、数字和下划线且不能以数字开头
+//
+// checkIdentifier validates a dot-separated identifier; each part may only contain letters, digits and underscores and must not start with a digit
+func checkIdentifier(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: empty", ErrInvalidIdentifier)
+	}
+	for part := range strings.SplitSeq(name, ".") {
+		if part == "" {
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+		}
+		for i := 0; i < len(part); i++ {
+			c := part[i]
+			if c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 0 && c >= '0' && c <= '9' {
+				continue
+			}
+			return fmt.Errorf("%w: %q", ErrInvalidIdentifier, name)
+		}
+	}
+	return nil
+}
+
+// ToNull 将指针转换为 sql.Null，nil 表示 NULL
+//
+// ToNull converts a pointer to sql.Null; nil means NULL.
+func ToNull[T any](p *T) sql.Null[T] {
+	if p == nil {
+		return sql.Null[T]{}
+	}
+	return sql.Null[T]{V: *p, Valid: true}
+}
+
+// FromNull 将 sql.Null 转换为指针，NULL 返回 nil
+//
+// FromNull converts sql.Null to a pointer; NULL returns nil.
+func FromNull[T any](n sql.Null[T]) *T {
+	if !n.Valid {
+		return nil
+	}
+	v := n.V
+	return &v
+}
+
+// NullString 将字符串转换为 sql.NullString，空字符串视为 NULL
+//
+// NullString converts a string to sql.NullString, treating the empty string as NULL.
+func NullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// NullInt64 将指针转换为 sql.NullInt64，nil 表示 NULL
+//
+// NullInt64 converts a pointer to sql.NullInt64; nil means NULL.
+func NullInt64(p *int64) sql.NullInt64 {
+	if p == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: *p, Valid: true}
+}
+
+// NullFloat64 将指针转换为 sql.NullFloat64，nil 表示 NULL
+//
+// NullFloat64 converts a pointer to sql.NullFloat64; nil means NULL.
+func NullFloat64(p *float64) sql.NullFloat64 {
+	if p == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *p, Valid: true}
+}
+
+// NullBool 将指针转换为 sql.NullBool，nil 表示 NULL
+//
+// NullBool converts a pointer to sql.NullBool; nil means NULL.
+func NullBool(p *bool) sql.NullBool {
+	if p == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *p, Valid: true}
+}
+
+// NullTime 将时间转换为 sql.NullTime，零值时间视为 NULL
+//
+// NullTime converts a time to sql.NullTime, treating the zero time as NULL.
+func NullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// StringOrEmpty 返回 sql.NullString 的值，NULL 返回空字符串
+//
+// StringOrEmpty returns the value of a sql.NullString, or the empty string for NULL.
+func StringOrEmpty(n sql.NullString) string {
+	if !n.Valid {
+		return ""
+	}
+	return n.String
+}
@@ -0,0 +1,247 @@
+// Package dbutil 提供基于 database/sql 的数据库工具函数
+//
+// Package dbutil provides database utility functions built on database/sql.
+package dbutil
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTxMaxAttempts 默认的事务最大尝试次数（包含第一次）
+	//
+	// DefaultTxMaxAttempts is the default maximum number of transaction attempts, including the first
+	DefaultTxMaxAttempts = 3
+	// DefaultTxInitialBackoff 默认的第一次重试前的等待时间
+	//
+	// DefaultTxInitialBackoff is the default wait before the first retry
+	DefaultTxInitialBackoff = 20 * time.Millisecond
+	// DefaultTxMaxBackoff 默认的最大重试等待时间
+	//
+	// DefaultTxMaxBackoff is the default maximum wait between retries
+	DefaultTxMaxBackoff = time.Second
+)
+
+// TxBeginner 可以开启事务的对象，*sql.DB 和 *sql.Conn 都实现了该接口
+//
+// TxBeginner is anything that can begin a transaction; both *sql.DB and *sql.Conn implement it.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// TxOptions 事务选项
+// Isolation: 隔离级别，默认使用数据库的默认级别
+// ReadOnly: 是否只读事务
+// MaxAttempts: 最大尝试次数（包含第一次），为 0 时使用 DefaultTxMaxAttempts，为 1 表示不重试
+// InitialBackoff: 第一次重试前的等待时间，之后每次翻倍并加入随机抖动，默认 DefaultTxInitialBackoff
+// MaxBackoff: 最大等待时间，默认 DefaultTxMaxBackoff
+// Retryable: 判断错误是否可以重试整个事务，默认 IsSerializationError
+//
+// TxOptions contains transaction options.
+// Isolation: Isolation level; the database default when unset
+// ReadOnly: Whether the transaction is read-only
+// MaxAttempts: Maximum number of attempts including the first; DefaultTxMaxAttempts when 0, 1 disables retries
+// InitialBackoff: Wait before the first retry, doubled with random jitter after each retry; defaults to DefaultTxInitialBackoff
+// MaxBackoff: Maximum wait; defaults to DefaultTxMaxBackoff
+// Retryable: Reports whether an error allows retrying the whole transaction; defaults to IsSerializationError
+type TxOptions struct {
+	Isolation      sql.IsolationLevel
+	ReadOnly       bool
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Retryable      func(error) bool
+}
+
+// txKey 上下文中保存当前事务的键
+//
+// txKey is the context key holding the current transaction
+type txKey struct{}
+
+// txState 上下文中保存的事务状态
+//
+// txState is the transaction state held in the context
+type txState struct {
+	tx        *sql.Tx
+	savepoint int
+}
+
+// TxFromContext 返回 WithTx 放入上下文中的事务，供深层的函数复用同一个事务
+//
+// TxFromContext returns the transaction WithTx placed in the context, so deeper functions can reuse it.
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	st, ok := ctx.Value(txKey{}).(*txState)
+	if !ok {
+		return nil, false
+	}
+	return st.tx, true
+}
+
+// WithTx 在事务中执行 fn，使用默认选项，详见 WithTxOptions
+//
+// WithTx runs fn in a transaction with default options; see WithTxOptions.
+func WithTx(ctx context.Context, db TxBeginner, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	return WithTxOptions(ctx, db, nil, fn)
+}
+
+// WithTxOptions 在事务中执行 fn：fn 返回 nil 时提交，返回错误或 panic 时回滚（panic 在回滚后继续抛出）
+// 如果 ctx 中已有 WithTx 开启的事务，则改为在该事务中创建保存点，fn 失败时只回滚到保存点，不会重试
+// 最外层的事务遇到死锁或序列化失败时，会等待退避时间后重新开启事务并再次执行 fn，因此 fn 必须可以安全地重复执行
+// 保存点使用 SAVEPOINT / ROLLBACK TO SAVEPOINT / RELEASE SAVEPOINT 语法，适用于 PostgreSQL、MySQL 和 SQLite
+// 参数:
+//   - ctx: 上下文，fn 收到的上下文中带有当前事务
+//   - db: 开启事务的对象，例如 *sql.DB
+//   - opts: 事务选项，可以为 nil；嵌套调用时忽略
+//   - fn: 在事务中执行的函数
+//
+// 返回:
+//   - error: fn 返回的错误，或开启、提交事务失败的错误；重试用尽时返回最后一次的错误
+//
+// WithTxOptions runs fn in a transaction: it commits when fn returns nil and rolls back when fn returns an error or panics (the panic is re-raised after the rollback).
+// If ctx already carries a transaction opened by WithTx, a savepoint is created in it instead; a failing fn only rolls back to the savepoint and is not retried.
+// When the outermost transaction hits a deadlock or serialization failure it waits for a backoff, begins a new transaction and runs fn again, so fn must be safe to repeat.
+// Savepoints use the SAVEPOINT / ROLLBACK TO SAVEPOINT / RELEASE SAVEPOINT syntax of PostgreSQL, MySQL and SQLite.
+// Parameters:
+//   - ctx: Context; the context passed to fn carries the current transaction
+//   - db: The object that begins transactions, such as *sql.DB
+//   - opts: Transaction options, may be nil; ignored for nested calls
+//   - fn: The function to run in the transaction
+//
+// Returns:
+//   - error: The error returned by fn, or the error beginning or committing the transaction; the last error when retries are exhausted
+func WithTxOptions(ctx context.Context, db TxBeginner, opts *TxOptions, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	if st, ok := ctx.Value(txKey{}).(*txState); ok {
+		return withSavepoint(ctx, st, fn)
+	}
+
+	var o TxOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = DefaultTxMaxAttempts
+	}
+	if o.InitialBackoff <= 0 {
+		o.InitialBackoff = DefaultTxInitialBackoff
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = DefaultTxMaxBackoff
+	}
+	if o.Retryable == nil {
+		o.Retryable = IsSerializationError
+	}
+	txOpts := &sql.TxOptions{Isolation: o.Isolation, ReadOnly: o.ReadOnly}
+
+	backoff := o.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, db, txOpts, fn)
+		if err == nil || attempt >= o.MaxAttempts || !o.Retryable(err) {
+			return err
+		}
+		// 加入随机抖动，避免冲突的事务再次同时重试
+		wait := backoff/2 + rand.N(backoff/2+1)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, o.MaxBackoff)
+	}
+}
+
+// runTx 执行一次完整的事务
+//
+// runTx runs one complete transaction
+func runTx(ctx context.Context, db TxBeginner, opts *sql.TxOptions, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			_ = tx.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &txState{tx: tx}), tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil && !errors.Is(rbErr, sql.ErrTxDone) {
+			return errors.Join(err, fmt.Errorf("rollback: %w", rbErr))
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// withSavepoint 在已有事务中通过保存点执行 fn
+//
+// withSavepoint runs fn under a savepoint in an existing transaction
+func withSavepoint(ctx context.Context, st *txState, fn func(ctx context.Context, tx *sql.Tx) error) error {
+	st.savepoint++
+	name := fmt.Sprintf("dbutil_sp_%d", st.savepoint)
+	if _, err := st.tx.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			_, _ = st.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+name)
+			panic(r)
+		}
+	}()
+
+	if err := fn(ctx, st.tx); err != nil {
+		if _, rbErr := st.tx.ExecContext(context.WithoutCancel(ctx), "ROLLBACK TO SAVEPOINT "+name); rbErr != nil {
+			return errors.Join(err, fmt.Errorf("rollback to savepoint: %w", rbErr))
+		}
+		return err
+	}
+	_, err := st.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+	return err
+}
+
+// sqlStateError 提供 SQLSTATE 错误码的驱动错误，pgx 和 lib/pq 的错误类型都实现了该接口
+//
+// sqlStateError is a driver error exposing its SQLSTATE code; the error types of pgx and lib/pq implement it
+type sqlStateError interface {
+	SQLState() string
+}
+
+// IsSerializationError 判断错误是否为死锁或序列化失败，这类错误在重新执行整个事务后通常可以成功
+// 优先检查 SQLSTATE 40001（序列化失败）和 40P01（死锁），驱动未提供错误码时按 MySQL 和 SQLite 的错误信息判断
+//
+// IsSerializationError reports whether err is a deadlock or serialization failure, which usually succeeds when the whole transaction is retried.
+// SQLSTATE 40001 (serialization failure) and 40P01 (deadlock) are checked first; when the driver gives no code the MySQL and SQLite error messages are matched.
+func IsSerializationError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var se sqlStateError
+	if errors.As(err, &se) {
+		switch se.SQLState() {
+		case "40001", "40P01":
+			return true
+		}
+		return false
+	}
+	msg := err.Error()
+	for _, s := range []string{
+		"Error 1213",                 // MySQL ER_LOCK_DEADLOCK
+		"Error 1205",                 // MySQL ER_LOCK_WAIT_TIMEOUT
+		"Deadlock found",             // MySQL
+		"database is locked",         // SQLite SQLITE_BUSY
+		"could not serialize access", // PostgreSQL
+	} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package emailutil
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/cryptoutil"
+)
+
+// ErrDKIMSign 表示 DKIM 签名失败
+//
+// ErrDKIMSign indicates that DKIM signing failed.
+var ErrDKIMSign = errors.New("failed to sign email with DKIM")
+
+// DefaultDKIMHeaders 默认参与 DKIM 签名的邮件头（只签名邮件中实际存在的头）
+//
+// DefaultDKIMHeaders are the headers signed by DKIM by default (only headers present in the message are signed)
+var DefaultDKIMHeaders = []string{
+	"From", "To", "Cc", "Reply-To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type",
+}
+
+// DKIMOptions DKIM 签名选项，使用 relaxed/relaxed 规范化
+// Domain: 签名域名（d=）
+// Selector: 选择器（s=），公钥发布在 <Selector>._domainkey.<Domain> 的 TXT 记录中
+// PrivateKey: 私钥，支持 *rsa.PrivateKey（rsa-sha256）和 ed25519.PrivateKey（ed25519-sha256），可以通过 cryptoutil.ParsePrivateKeyPEM 加载
+// Headers: 参与签名的邮件头，默认 DefaultDKIMHeaders
+//
+// DKIMOptions contains options for DKIM signing, using relaxed/relaxed canonicalization.
+// Domain: Signing domain (d=)
+// Selector: Selector (s=); the public key is published in the TXT record of <Selector>._domainkey.<Domain>
+// PrivateKey: Private key, *rsa.PrivateKey (rsa-sha256) and ed25519.PrivateKey (ed25519-sha256) are supported; it can be loaded with cryptoutil.ParsePrivateKeyPEM
+// Headers: Headers to sign, defaults to DefaultDKIMHeaders
+type DKIMOptions struct {
+	Domain     string
+	Selector   string
+	PrivateKey crypto.Signer
+	Headers    []string
+}
+
+// NewDKIMOptions 使用 PEM 编码的私钥创建 DKIM 签名选项
+// 参数:
+//   - domain: 签名域名
+//   - selector: 选择器
+//   - privateKeyPEM: PEM 编码的 RSA 或 Ed25519 私钥
+//
+// 返回:
+//   - *DKIMOptions: DKIM 签名选项
+//   - error: 如果私钥无效，返回错误
+//
+// NewDKIMOptions creates DKIM signing options from a PEM encoded private key.
+// Parameters:
+//   - domain: Signing domain
+//   - selector: Selector
+//   - privateKeyPEM: PEM encoded RSA or Ed25519 private key
+//
+// Returns:
+//   - *DKIMOptions: DKIM signing options
+//   - error: Returns an error if the private key is invalid
+func NewDKIMOptions(domain, selector string, privateKeyPEM []byte) (*DKIMOptions, error) {
+	key, err := cryptoutil.ParsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDKIMSign, err)
+	}
+	return &DKIMOptions{Domain: domain, Selector: selector, PrivateKey: key}, nil
+}
+
+// SignDKIM 为编码后的邮件添加 DKIM-Signature 头
+// 参数:
+//   - raw: 使用 CRLF 换行的完整邮件，通常来自 Message.Bytes
+//   - opts: DKIM 签名选项
+//
+// 返回:
+//   - []byte: 添加了 DKIM-Signature 头的邮件
+//   - error: 如果签名失败，返回错误
+//
+// SignDKIM adds a DKIM-Signature header to an encoded message.
+// Parameters:
+//   - raw: Complete message with CRLF line endings, usually from Message.Bytes
+//   - opts: DKIM signing options
+//
+// Returns:
+//   - []byte: The message with the DKIM-Signature header added
+//   - error: Returns an error if signing fails
+func SignDKIM(raw []byte, opts *DKIMOptions) ([]byte, error) {
+	if opts == nil || opts.PrivateKey == nil || opts.Domain == "" || opts.Selector == "" {
+		return nil, fmt.Errorf("%w: domain, selector and private key are required", ErrDKIMSign)
+	}
+
+	var algorithm string
+	switch opts.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		algorithm = "rsa-sha256"
+	case ed25519.PrivateKey:
+		algorithm = "ed25519-sha256"
+	default:
+		return nil, fmt.Errorf("%w: unsupported key type %T", ErrDKIMSign, opts.PrivateKey)
+	}
+
+	headerPart, body, ok := bytes.Cut(raw, []byte("\r\n\r\n"))
+	if !ok {
+		return nil, fmt.Errorf("%w: message has no header/body separator", ErrDKIMSign)
+	}
+	headers := splitHeaders(string(headerPart) + "\r\n")
+
+	bodyHash := sha256.Sum256(relaxedBody(body))
+
+	// 按顺序挑选存在的头，同名头从下往上取
+	wanted := opts.Headers
+	if len(wanted) == 0 {
+		wanted = DefaultDKIMHeaders
+	}
+	used := make(map[int]bool)
+	var signed []string
+	var canon strings.Builder
+	for _, name := range wanted {
+		for i := len(headers) - 1; i >= 0; i-- {
+			if used[i] || !strings.EqualFold(headerName(headers[i]), name) {
+				continue
+			}
+			used[i] = true
+			signed = append(signed, strings.ToLower(name))
+			canon.WriteString(relaxedHeader(headers[i]))
+			break
+		}
+	}
+
+	sigValue := "v=1; a=" + algorithm + "; c=relaxed/relaxed; d=" + opts.Domain +
+		"; s=" + opts.Selector + "; t=" + strconv.FormatInt(time.Now().Unix(), 10) +
+		"; h=" + strings.Join(signed, ":") +
+		"; bh=" + base64.StdEncoding.EncodeToString(bodyHash[:]) + "; b="
+	// 签名数据中的 DKIM-Signature 头不包含结尾的 CRLF
+	canon.WriteString(strings.TrimSuffix(relaxedHeader("DKIM-Signature: "+sigValue), "\r\n"))
+
+	digest := sha256.Sum256([]byte(canon.String()))
+	var (
+		sig []byte
+		err error
+	)
+	if algorithm == "ed25519-sha256" {
+		sig, err = opts.PrivateKey.Sign(rand.Reader, digest[:], crypto.Hash(0))
+	} else {
+		sig, err = opts.PrivateKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDKIMSign, err)
+	}
+
+	var out bytes.Buffer
+	out.WriteString("DKIM-Signature: ")
+	out.WriteString(sigValue)
+	// 只在 b= 的值中折行，其它标签保持不变
+	encoded := base64.StdEncoding.EncodeToString(sig)
+	for len(encoded) > 72 {
+		out.WriteString(encoded[:72])
+		out.WriteString("\r\n ")
+		encoded = encoded[72:]
+	}
+	out.WriteString(encoded)
+	out.WriteString("\r\n")
+	out.Write(raw)
+	return out.Bytes(), nil
+}
+
+// splitHeaders 将头部拆分为单个头（保留折行）
+//
+// splitHeaders splits the header block into individual headers (folding preserved)
+func splitHeaders(s string) []string {
+	var headers []string
+	for _, line := range strings.SplitAfter(s, "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(headers) > 0 {
+			headers[len(headers)-1] += line
+			continue
+		}
+		headers = append(headers, line)
+	}
+	return headers
+}
+
+// headerName 返回头的名称
+//
+// headerName returns the name of a header
+func headerName(h string) string {
+	name, _, _ := strings.Cut(h, ":")
+	return strings.TrimSpace(name)
+}
+
+// relaxedHeader 按 RFC 6376 relaxed 算法规范化一个头
+//
+// relaxedHeader canonicalizes a header with the RFC 6376 relaxed algorithm
+func relaxedHeader(h string) string {
+	name, value, _ := strings.Cut(h, ":")
+	value = strings.NewReplacer("\r\n", "").Replace(value)
+	value = strings.Join(strings.FieldsFunc(value, isWSP), " ")
+	return strings.ToLower(strings.TrimSpace(name)) + ":" + value + "\r\n"
+}
+
+// relaxedBody 按 RFC 6376 relaxed 算法规范化正文
+//
+// relaxedBody canonicalizes the body with the RFC 6376 relaxed algorithm
+func relaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	for i, line := range lines {
+		line = strings.TrimRightFunc(line, isWSP)
+		var b strings.Builder
+		prevWSP := false
+		for _, r := range line {
+			if isWSP(r) {
+				if !prevWSP {
+					b.WriteByte(' ')
+				}
+				prevWSP = true
+				continue
+			}
+			prevWSP = false
+			b.WriteRune(r)
+		}
+		lines[i] = b.String()
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// isWSP 判断是否为空格或制表符
+//
+// isWSP reports whether r is a space or a tab
+func isWSP(r rune) bool {
+	return r == ' ' || r == '\t'
+}
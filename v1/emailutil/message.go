@@ -0,0 +1,320 @@
+// Package emailutil 提供基于 SMTP 的邮件发送工具函数，支持 TLS/STARTTLS、HTML 与纯文本正文、附件、DKIM 签名和失败重试
+//
+// Package emailutil provides SMTP based email sending utility functions, supporting TLS/STARTTLS,
+// HTML and plain-text bodies, attachments, DKIM signing and retries.
+package emailutil
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidMessage 表示邮件内容不完整或地址无效
+	//
+	// ErrInvalidMessage indicates that the message is incomplete or contains an invalid address.
+	ErrInvalidMessage = errors.New("invalid email message")
+)
+
+// Attachment 邮件附件
+// Filename: 文件名，可以包含中文
+// ContentType: MIME 类型，为空时根据文件扩展名推断
+// Data: 文件内容
+// Inline: 是否为内嵌资源（例如 HTML 中通过 cid: 引用的图片）
+// ContentID: 内嵌资源的 Content-ID，HTML 中使用 <img src="cid:ContentID"> 引用
+//
+// Attachment is an email attachment.
+// Filename: File name, may contain non-ASCII characters
+// ContentType: MIME type, inferred from the file extension when empty
+// Data: File content
+// Inline: Whether it is an inline resource (e.g. an image referenced from HTML via cid:)
+// ContentID: Content-ID of the inline resource, referenced in HTML as <img src="cid:ContentID">
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+	Inline      bool
+	ContentID   string
+}
+
+// Message 邮件内容
+// From: 发件人，例如 "客服 <support@example.com>"
+// To、Cc、Bcc: 收件人、抄送和密送地址，Bcc 不会出现在邮件头中
+// ReplyTo: 回复地址
+// Subject: 主题
+// Text: 纯文本正文
+// HTML: HTML 正文，与 Text 同时设置时生成 multipart/alternative
+// Attachments: 附件
+// Headers: 额外的邮件头
+//
+// Message is the content of an email.
+// From: Sender, e.g. "Support <support@example.com>"
+// To, Cc, Bcc: Recipient, carbon copy and blind carbon copy addresses; Bcc is not written to the headers
+// ReplyTo: Reply-to address
+// Subject: Subject
+// Text: Plain-text body
+// HTML: HTML body; a multipart/alternative is generated when both Text and HTML are set
+// Attachments: Attachments
+// Headers: Extra headers
+type Message struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+	Headers     map[string]string
+}
+
+// mimePart 一个 MIME 部件
+//
+// mimePart is a MIME part
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// Recipients 返回信封收件人地址（To、Cc、Bcc，不含显示名称）
+// 返回:
+//   - []string: 收件人地址
+//   - error: 如果地址无效，返回错误
+//
+// Recipients returns the envelope recipient addresses (To, Cc and Bcc, without display names).
+// Returns:
+//   - []string: Recipient addresses
+//   - error: Returns an error if an address is invalid
+func (m *Message) Recipients() ([]string, error) {
+	var out []string
+	for _, list := range [][]string{m.To, m.Cc, m.Bcc} {
+		addrs, err := parseAddressList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range addrs {
+			out = append(out, a.Address)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("%w: no recipients", ErrInvalidMessage)
+	}
+	return out, nil
+}
+
+// Bytes 将邮件编码为 RFC 5322 格式（使用 CRLF 换行）
+// 返回:
+//   - []byte: 编码后的邮件
+//   - error: 如果地址无效，返回错误
+//
+// Bytes encodes the message in RFC 5322 format (with CRLF line endings).
+// Returns:
+//   - []byte: The encoded message
+//   - error: Returns an error if an address is invalid
+func (m *Message) Bytes() ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("%w: from: %v", ErrInvalidMessage, err)
+	}
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "From", from.String())
+	for _, h := range []struct {
+		name string
+		list []string
+	}{{"To", m.To}, {"Cc", m.Cc}} {
+		if len(h.list) == 0 {
+			continue
+		}
+		addrs, err := parseAddressList(h.list)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]string, len(addrs))
+		for i, a := range addrs {
+			values[i] = a.String()
+		}
+		writeHeader(&buf, h.name, strings.Join(values, ", "))
+	}
+	if m.ReplyTo != "" {
+		replyTo, err := mail.ParseAddress(m.ReplyTo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: reply-to: %v", ErrInvalidMessage, err)
+		}
+		writeHeader(&buf, "Reply-To", replyTo.String())
+	}
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	writeHeader(&buf, "Date", time.Now().Format(time.RFC1123Z))
+	writeHeader(&buf, "Message-ID", newMessageID(from.Address))
+	for _, k := range slices.Sorted(maps.Keys(m.Headers)) {
+		writeHeader(&buf, textproto.CanonicalMIMEHeaderKey(k), mime.QEncoding.Encode("utf-8", m.Headers[k]))
+	}
+	writeHeader(&buf, "MIME-Version", "1.0")
+
+	root := m.rootPart()
+	for _, k := range []string{"Content-Type", "Content-Transfer-Encoding"} {
+		if v := root.header.Get(k); v != "" {
+			writeHeader(&buf, k, v)
+		}
+	}
+	buf.WriteString("\r\n")
+	buf.Write(root.body)
+	return buf.Bytes(), nil
+}
+
+// rootPart 构建邮件正文的 MIME 结构
+// mixed(related(alternative(text, html), 内嵌资源), 附件)，只有一个子部件的层级会被省略
+//
+// rootPart builds the MIME structure of the body:
+// mixed(related(alternative(text, html), inline resources), attachments); levels with a single child are omitted
+func (m *Message) rootPart() mimePart {
+	var alternatives []mimePart
+	if m.Text != "" || m.HTML == "" {
+		alternatives = append(alternatives, textPart("text/plain", m.Text))
+	}
+	if m.HTML != "" {
+		alternatives = append(alternatives, textPart("text/html", m.HTML))
+	}
+	body := multipartOf("alternative", alternatives)
+
+	var inline, attached []mimePart
+	for _, a := range m.Attachments {
+		if a.Inline {
+			inline = append(inline, attachmentPart(a))
+		} else {
+			attached = append(attached, attachmentPart(a))
+		}
+	}
+	body = multipartOf("related", append([]mimePart{body}, inline...))
+	return multipartOf("mixed", append([]mimePart{body}, attached...))
+}
+
+// textPart 使用 quoted-printable 编码的文本部件
+//
+// textPart is a text part encoded with quoted-printable
+func textPart(contentType, s string) mimePart {
+	var buf bytes.Buffer
+	qw := quotedprintable.NewWriter(&buf)
+	qw.Write([]byte(s))
+	qw.Close()
+	return mimePart{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		body: buf.Bytes(),
+	}
+}
+
+// attachmentPart 使用 base64 编码的附件部件
+//
+// attachmentPart is an attachment part encoded with base64
+func attachmentPart(a Attachment) mimePart {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(a.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	disposition := "attachment"
+	if a.Inline {
+		disposition = "inline"
+	}
+
+	header := textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename})},
+	}
+	if a.ContentID != "" {
+		header.Set("Content-ID", "<"+a.ContentID+">")
+	}
+
+	// base64 每行最多 76 个字符
+	encoded := base64.StdEncoding.EncodeToString(a.Data)
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	return mimePart{header: header, body: buf.Bytes()}
+}
+
+// multipartOf 将多个部件组合为 multipart/subtype，只有一个部件时直接返回该部件
+//
+// multipartOf combines parts into a multipart/subtype, returning the part itself when there is only one
+func multipartOf(subtype string, parts []mimePart) mimePart {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		w, _ := mw.CreatePart(p.header)
+		w.Write(p.body)
+	}
+	mw.Close()
+	return mimePart{
+		header: textproto.MIMEHeader{
+			"Content-Type": {"multipart/" + subtype + "; boundary=" + mw.Boundary()},
+		},
+		body: buf.Bytes(),
+	}
+}
+
+// parseAddressList 解析地址列表
+//
+// parseAddressList parses a list of addresses
+func parseAddressList(list []string) ([]*mail.Address, error) {
+	out := make([]*mail.Address, 0, len(list))
+	for _, s := range list {
+		a, err := mail.ParseAddress(s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidMessage, s, err)
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// writeHeader 写入一行邮件头，并去掉值中的换行，防止头部注入
+//
+// writeHeader writes a header line, stripping line breaks from the value to prevent header injection
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	value = strings.NewReplacer("\r", "", "\n", "").Replace(value)
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(value)
+	buf.WriteString("\r\n")
+}
+
+// newMessageID 生成唯一的 Message-ID
+//
+// newMessageID generates a unique Message-ID
+func newMessageID(from string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	domain := "localhost"
+	if _, d, ok := strings.Cut(from, "@"); ok && d != "" {
+		domain = d
+	}
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,288 @@
+package emailutil
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/errorutil"
+)
+
+// Security SMTP 连接的加密方式
+//
+// Security is the encryption mode of the SMTP connection
+type Security int
+
+const (
+	// SecurityAuto 端口 465 使用隐式 TLS，其它端口在服务器支持时使用 STARTTLS
+	//
+	// SecurityAuto uses implicit TLS on port 465 and STARTTLS on other ports when the server supports it
+	SecurityAuto Security = iota
+	// SecurityTLS 隐式 TLS（SMTPS）
+	//
+	// SecurityTLS is implicit TLS (SMTPS)
+	SecurityTLS
+	// SecurityStartTLS 必须使用 STARTTLS，服务器不支持时返回错误
+	//
+	// SecurityStartTLS requires STARTTLS and fails if the server does not support it
+	SecurityStartTLS
+	// SecurityNone 不加密，仅用于本地中继或测试
+	//
+	// SecurityNone disables encryption, only for local relays or testing
+	SecurityNone
+)
+
+// DefaultTimeout 默认的 SMTP 连接超时
+//
+// DefaultTimeout is the default SMTP connection timeout
+const DefaultTimeout = 30 * time.Second
+
+var (
+	// ErrSendMail 表示发送邮件失败
+	//
+	// ErrSendMail indicates that sending the email failed.
+	ErrSendMail = errors.New("failed to send email")
+	// ErrStartTLSUnsupported 表示服务器不支持 STARTTLS
+	//
+	// ErrStartTLSUnsupported indicates that the server does not support STARTTLS.
+	ErrStartTLSUnsupported = errors.New("smtp server does not support STARTTLS")
+)
+
+// RetryPolicy 发送失败时的重试策略，只重试 errorutil.IsRetryable 判断为临时的错误（网络错误、SMTP 4xx）以及接受邮件之前的断开连接
+// MaxAttempts: 最大尝试次数（包含第一次），小于等于 1 表示不重试
+// InitialBackoff: 第一次重试前的等待时间，之后每次翻倍，默认 1 秒
+// MaxBackoff: 最大等待时间，默认 30 秒
+//
+// RetryPolicy is the retry policy for failed sends; only errors errorutil.IsRetryable deems transient (network errors, SMTP 4xx) and disconnects before the message is accepted are retried.
+// MaxAttempts: Maximum number of attempts (including the first), values <= 1 disable retries
+// InitialBackoff: Wait time before the first retry, doubled after each retry, defaults to 1 second
+// MaxBackoff: Maximum wait time, defaults to 30 seconds
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Config SMTP 服务器配置
+// Host、Port: 服务器地址和端口
+// Username、Password: 认证信息，Username 为空时不认证
+// Security: 加密方式，默认 SecurityAuto
+// TLSConfig: 自定义 TLS 配置，为空时使用 Host 作为 ServerName
+// Timeout: 单次发送的超时时间，默认 DefaultTimeout
+// LocalName: HELO/EHLO 使用的主机名，默认 "localhost"
+// DKIM: DKIM 签名选项，为 nil 时不签名
+// Retry: 重试策略，为 nil 时不重试
+//
+// Config is the SMTP server configuration.
+// Host, Port: Server host and port
+// Username, Password: Credentials; no authentication is performed when Username is empty
+// Security: Encryption mode, defaults to SecurityAuto
+// TLSConfig: Custom TLS configuration; Host is used as ServerName when nil
+// Timeout: Timeout of a single send attempt, defaults to DefaultTimeout
+// LocalName: Host name used for HELO/EHLO, defaults to "localhost"
+// DKIM: DKIM signing options, no signing when nil
+// Retry: Retry policy, no retries when nil
+type Config struct {
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	Security  Security
+	TLSConfig *tls.Config
+	Timeout   time.Duration
+	LocalName string
+	DKIM      *DKIMOptions
+	Retry     *RetryPolicy
+}
+
+// SendMail 发送邮件
+// 参数:
+//   - ctx: 上下文，用于控制超时和取消（包括重试等待）
+//   - cfg: SMTP 服务器配置
+//   - msg: 邮件内容
+//
+// 返回:
+//   - error: 如果邮件无效或发送失败，返回错误
+//
+// SendMail sends an email.
+// Parameters:
+//   - ctx: Context for controlling timeout and cancellation (including waits between retries)
+//   - cfg: SMTP server configuration
+//   - msg: Email message
+//
+// Returns:
+//   - error: Returns an error if the message is invalid or sending fails
+func SendMail(ctx context.Context, cfg *Config, msg *Message) error {
+	if cfg == nil || cfg.Host == "" {
+		return fmt.Errorf("%w: smtp host is required", ErrSendMail)
+	}
+	if msg == nil {
+		return fmt.Errorf("%w: message is nil", ErrInvalidMessage)
+	}
+
+	from, err := mail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("%w: from: %v", ErrInvalidMessage, err)
+	}
+	rcpts, err := msg.Recipients()
+	if err != nil {
+		return err
+	}
+	raw, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+	if cfg.DKIM != nil {
+		if raw, err = SignDKIM(raw, cfg.DKIM); err != nil {
+			return err
+		}
+	}
+
+	attempts, backoff, maxBackoff := 1, time.Second, 30*time.Second
+	if cfg.Retry != nil {
+		attempts = max(cfg.Retry.MaxAttempts, 1)
+		if cfg.Retry.InitialBackoff > 0 {
+			backoff = cfg.Retry.InitialBackoff
+		}
+		if cfg.Retry.MaxBackoff > 0 {
+			maxBackoff = cfg.Retry.MaxBackoff
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = send(ctx, cfg, from.Address, rcpts, raw)
+		if err == nil {
+			return nil
+		}
+		// 服务器在接受邮件之前断开连接，重新投递不会产生重复邮件
+		if errors.Is(err, io.EOF) {
+			err = errorutil.MarkRetryable(err)
+		}
+		if attempt >= attempts || !errorutil.IsRetryable(err) {
+			return fmt.Errorf("%w: %v", ErrSendMail, err)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w: %v (last error: %v)", ErrSendMail, ctx.Err(), err)
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// send 建立一次 SMTP 会话并投递邮件
+//
+// send establishes a single SMTP session and delivers the message
+func send(ctx context.Context, cfg *Config, from string, rcpts []string, raw []byte) error {
+	port := cfg.Port
+	security := cfg.Security
+	if port == 0 {
+		switch security {
+		case SecurityTLS:
+			port = 465
+		case SecurityNone:
+			port = 25
+		default:
+			port = 587
+		}
+	}
+	if security == SecurityAuto && port == 465 {
+		security = SecurityTLS
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tlsConfig := cfg.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: cfg.Host}
+	}
+
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	dialer := &net.Dialer{}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if security == SecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	// 上下文取消时关闭连接，使阻塞的读写立即返回
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	localName := cfg.LocalName
+	if localName == "" {
+		localName = "localhost"
+	}
+	if err := c.Hello(localName); err != nil {
+		return err
+	}
+
+	if security == SecurityAuto || security == SecurityStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); ok {
+			if err := c.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if security == SecurityStartTLS {
+			return ErrStartTLSUnsupported
+		}
+	}
+
+	if cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range rcpts {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	// DATA 结束后服务器已经接受邮件，QUIT 失败不影响投递，不能当作发送失败重试，否则会重复投递
+	c.Quit()
+	return nil
+}
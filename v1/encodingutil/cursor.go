@@ -0,0 +1,129 @@
+// Package encodingutil 提供数据编码相关的工具函数
+//
+// Package encodingutil provides data encoding utility functions.
+package encodingutil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidCursor 表示游标格式错误或签名无效
+//
+// ErrInvalidCursor indicates that a cursor is malformed or its signature is invalid
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// EncodeCursor 将值编码为不透明的游标字符串（JSON 后使用 URL 安全无填充 Base64），可直接放入 URL 查询参数
+// 注意: 游标未签名，客户端可以解码和篡改，需要防篡改时使用 EncodeSignedCursor
+// 参数:
+//   - v: 游标内容，例如上一页最后一条记录的排序键
+//
+// 返回:
+//   - string: 游标字符串
+//   - error: 如果 JSON 编码失败，返回错误
+//
+// EncodeCursor encodes a value into an opaque cursor string (JSON, then URL-safe unpadded Base64) that can go directly into a URL query.
+// Note: the cursor is not signed, so clients can decode and tamper with it; use EncodeSignedCursor when tampering matters.
+// Parameters:
+//   - v: Cursor content, e.g. the sort key of the last record of the previous page
+//
+// Returns:
+//   - string: The cursor string
+//   - error: Returns an error if JSON encoding fails
+func EncodeCursor(v any) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor 解码 EncodeCursor 生成的游标
+// 参数:
+//   - cursor: 游标字符串
+//   - v: 接收游标内容的指针
+//
+// 返回:
+//   - error: 如果游标格式错误，返回 ErrInvalidCursor
+//
+// DecodeCursor decodes a cursor produced by EncodeCursor.
+// Parameters:
+//   - cursor: The cursor string
+//   - v: Pointer receiving the cursor content
+//
+// Returns:
+//   - error: Returns ErrInvalidCursor if the cursor is malformed
+func DecodeCursor(cursor string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return nil
+}
+
+// EncodeSignedCursor 与 EncodeCursor 相同，但附加 HMAC-SHA256 签名，防止客户端伪造游标
+// 参数:
+//   - v: 游标内容
+//   - key: 签名密钥
+//
+// 返回:
+//   - string: 格式为 "内容.签名" 的游标字符串
+//   - error: 如果 JSON 编码失败，返回错误
+//
+// EncodeSignedCursor is like EncodeCursor but appends an HMAC-SHA256 signature so clients cannot forge cursors.
+// Parameters:
+//   - v: Cursor content
+//   - key: Signing key
+//
+// Returns:
+//   - string: A cursor string of the form "payload.signature"
+//   - error: Returns an error if JSON encoding fails
+func EncodeSignedCursor(v any, key []byte) (string, error) {
+	payload, err := EncodeCursor(v)
+	if err != nil {
+		return "", err
+	}
+	return payload + "." + cursorSignature(payload, key), nil
+}
+
+// DecodeSignedCursor 校验签名并解码 EncodeSignedCursor 生成的游标
+// 参数:
+//   - cursor: 游标字符串
+//   - key: 签名密钥
+//   - v: 接收游标内容的指针
+//
+// 返回:
+//   - error: 如果游标格式错误或签名无效，返回 ErrInvalidCursor
+//
+// DecodeSignedCursor verifies the signature of and decodes a cursor produced by EncodeSignedCursor.
+// Parameters:
+//   - cursor: The cursor string
+//   - key: Signing key
+//   - v: Pointer receiving the cursor content
+//
+// Returns:
+//   - error: Returns ErrInvalidCursor if the cursor is malformed or the signature is invalid
+func DecodeSignedCursor(cursor string, key []byte, v any) error {
+	payload, sig, ok := strings.Cut(cursor, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(cursorSignature(payload, key))) {
+		return fmt.Errorf("%w: bad signature", ErrInvalidCursor)
+	}
+	return DecodeCursor(payload, v)
+}
+
+// cursorSignature 计算游标内容的签名
+//
+// cursorSignature computes the signature of a cursor payload
+func cursorSignature(payload string, key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
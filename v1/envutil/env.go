@@ -0,0 +1,286 @@
+// Package envutil 提供类型化读取环境变量的工具函数，可以一次性收集所有缺失或无效的变量
+//
+// Package envutil provides typed environment variable access and can collect every missing or invalid variable at once.
+package envutil
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMissingEnv 表示缺少必需的环境变量
+	//
+	// ErrMissingEnv indicates that a required environment variable is missing
+	ErrMissingEnv = errors.New("missing required environment variable")
+	// ErrInvalidEnv 表示环境变量的值无法解析
+	//
+	// ErrInvalidEnv indicates that an environment variable's value cannot be parsed
+	ErrInvalidEnv = errors.New("invalid environment variable")
+)
+
+// EnvError 汇总所有缺失和无效的环境变量，可通过 errors.Is 匹配 ErrMissingEnv 和 ErrInvalidEnv
+// Missing: 缺失的变量名
+// Invalid: 无效的变量及原因
+//
+// EnvError collects every missing and invalid environment variable; it matches ErrMissingEnv and ErrInvalidEnv with errors.Is.
+// Missing: Names of missing variables
+// Invalid: Invalid variables and the reasons
+type EnvError struct {
+	Missing []string
+	Invalid []error
+}
+
+// Error 返回错误描述
+//
+// Error returns the error description.
+func (e *EnvError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing required environment variables: "+strings.Join(e.Missing, ", "))
+	}
+	for _, err := range e.Invalid {
+		parts = append(parts, err.Error())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Is 报告错误是否匹配 ErrMissingEnv 或 ErrInvalidEnv
+//
+// Is reports whether the error matches ErrMissingEnv or ErrInvalidEnv.
+func (e *EnvError) Is(target error) bool {
+	return (target == ErrMissingEnv && len(e.Missing) > 0) || (target == ErrInvalidEnv && len(e.Invalid) > 0)
+}
+
+// Env 环境变量读取器，会记录读取过程中的所有错误，最后通过 Err 一次性返回
+//
+// Env reads environment variables and records every error along the way, returning them all at once from Err.
+type Env struct {
+	lookup func(string) (string, bool)
+	err    EnvError
+}
+
+// New 创建读取进程环境变量的 Env
+//
+// New creates an Env that reads the process environment.
+func New() *Env {
+	return &Env{lookup: os.LookupEnv}
+}
+
+// NewWithLookup 创建使用自定义查找函数的 Env，便于测试或从其他来源读取
+//
+// NewWithLookup creates an Env with a custom lookup function, useful for tests or other sources.
+func NewWithLookup(lookup func(string) (string, bool)) *Env {
+	return &Env{lookup: lookup}
+}
+
+// Err 返回所有缺失和无效的变量，没有错误时返回 nil
+//
+// Err returns every missing and invalid variable, or nil if there were none.
+func (e *Env) Err() error {
+	if len(e.err.Missing) == 0 && len(e.err.Invalid) == 0 {
+		return nil
+	}
+	err := e.err
+	return &err
+}
+
+// value 查找变量，空字符串视为未设置
+//
+// value looks a variable up; an empty string counts as unset
+func (e *Env) value(key string, required bool) (string, bool) {
+	v, ok := e.lookup(key)
+	v = strings.TrimSpace(v)
+	if !ok || v == "" {
+		if required {
+			e.err.Missing = append(e.err.Missing, key)
+		}
+		return "", false
+	}
+	return v, true
+}
+
+// invalid 记录无效的变量
+//
+// invalid records an invalid variable
+func (e *Env) invalid(key string, err error) {
+	e.err.Invalid = append(e.err.Invalid, fmt.Errorf("%w %s: %v", ErrInvalidEnv, key, err))
+}
+
+// String 读取字符串，未设置时返回 def
+//
+// String reads a string, returning def if unset.
+func (e *Env) String(key, def string) string {
+	if v, ok := e.value(key, false); ok {
+		return v
+	}
+	return def
+}
+
+// RequireString 读取必需的字符串，未设置时记录错误
+//
+// RequireString reads a required string, recording an error if unset.
+func (e *Env) RequireString(key string) string {
+	v, _ := e.value(key, true)
+	return v
+}
+
+// Int 读取整数，未设置时返回 def，无效时记录错误并返回 def
+//
+// Int reads an integer, returning def if unset; an invalid value is recorded and def returned.
+func (e *Env) Int(key string, def int) int {
+	return parse(e, key, false, def, strconv.Atoi)
+}
+
+// RequireInt 读取必需的整数
+//
+// RequireInt reads a required integer.
+func (e *Env) RequireInt(key string) int {
+	return parse(e, key, true, 0, strconv.Atoi)
+}
+
+// Bool 读取布尔值，支持 true/false、1/0、yes/no、on/off，未设置时返回 def
+//
+// Bool reads a boolean, accepting true/false, 1/0, yes/no and on/off, returning def if unset.
+func (e *Env) Bool(key string, def bool) bool {
+	return parse(e, key, false, def, parseBool)
+}
+
+// RequireBool 读取必需的布尔值
+//
+// RequireBool reads a required boolean.
+func (e *Env) RequireBool(key string) bool {
+	return parse(e, key, true, false, parseBool)
+}
+
+// Duration 读取时长（time.ParseDuration 格式，如 "30s"），未设置时返回 def
+//
+// Duration reads a duration (time.ParseDuration format, e.g. "30s"), returning def if unset.
+func (e *Env) Duration(key string, def time.Duration) time.Duration {
+	return parse(e, key, false, def, time.ParseDuration)
+}
+
+// RequireDuration 读取必需的时长
+//
+// RequireDuration reads a required duration.
+func (e *Env) RequireDuration(key string) time.Duration {
+	return parse(e, key, true, 0, time.ParseDuration)
+}
+
+// URL 读取绝对 URL，未设置时解析 def，def 为空时返回 nil
+//
+// URL reads an absolute URL, parsing def if unset; returns nil when def is empty.
+func (e *Env) URL(key, def string) *url.URL {
+	v, ok := e.value(key, false)
+	if !ok {
+		if def == "" {
+			return nil
+		}
+		u, _ := parseURL(def)
+		return u
+	}
+	u, err := parseURL(v)
+	if err != nil {
+		e.invalid(key, err)
+	}
+	return u
+}
+
+// RequireURL 读取必需的绝对 URL
+//
+// RequireURL reads a required absolute URL.
+func (e *Env) RequireURL(key string) *url.URL {
+	return parse(e, key, true, nil, parseURL)
+}
+
+// MustOK 在有缺失或无效的变量时 panic，用于启动时快速失败
+//
+// MustOK panics if any variable was missing or invalid, for failing fast at startup.
+func (e *Env) MustOK() {
+	if err := e.Err(); err != nil {
+		panic(err)
+	}
+}
+
+// parse 读取并解析变量
+//
+// parse reads and parses a variable
+func parse[T any](e *Env, key string, required bool, def T, fn func(string) (T, error)) T {
+	v, ok := e.value(key, required)
+	if !ok {
+		return def
+	}
+	t, err := fn(v)
+	if err != nil {
+		e.invalid(key, err)
+		return def
+	}
+	return t
+}
+
+// parseBool 解析布尔值
+//
+// parseBool parses a boolean
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+// parseURL 解析绝对 URL
+//
+// parseURL parses an absolute URL
+func parseURL(s string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("not an absolute URL: %q", s)
+	}
+	return u, nil
+}
+
+// GetString 读取字符串环境变量，未设置时返回 def
+//
+// GetString reads a string environment variable, returning def if unset.
+func GetString(key, def string) string {
+	return New().String(key, def)
+}
+
+// GetInt 读取整数环境变量，未设置或无效时返回 def
+//
+// GetInt reads an integer environment variable, returning def if unset or invalid.
+func GetInt(key string, def int) int {
+	return New().Int(key, def)
+}
+
+// GetBool 读取布尔环境变量，未设置或无效时返回 def
+//
+// GetBool reads a boolean environment variable, returning def if unset or invalid.
+func GetBool(key string, def bool) bool {
+	return New().Bool(key, def)
+}
+
+// GetDuration 读取时长环境变量，未设置或无效时返回 def
+//
+// GetDuration reads a duration environment variable, returning def if unset or invalid.
+func GetDuration(key string, def time.Duration) time.Duration {
+	return New().Duration(key, def)
+}
+
+// GetURL 读取 URL 环境变量，未设置时解析 def，无效时返回 nil
+//
+// GetURL reads a URL environment variable, parsing def if unset; returns nil if invalid.
+func GetURL(key, def string) *url.URL {
+	return New().URL(key, def)
+}
@@ -0,0 +1,176 @@
+package envutil
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	urlType      = reflect.TypeFor[url.URL]()
+)
+
+// Parse 根据结构体标签从进程环境变量填充配置，并一次性返回所有缺失或无效的变量
+// 支持的标签：env:"NAME"（变量名）、default:"值"（默认值）、required:"true"（必需）；
+// 没有 env 标签的嵌套结构体会被递归处理
+// 支持的字段类型：string、bool、整数、浮点数、time.Duration、url.URL、*url.URL 以及逗号分隔的 []string
+// 参数:
+//   - v: 结构体指针
+//
+// 返回:
+//   - error: v 不是结构体指针或字段类型不支持时返回错误；有缺失或无效的变量时返回 *EnvError
+//
+// Parse fills a configuration struct from the process environment according to its struct tags, returning every missing or invalid variable at once.
+// Supported tags: env:"NAME" (variable name), default:"value" (default value), required:"true" (required);
+// nested structs without an env tag are processed recursively.
+// Supported field types: string, bool, integers, floats, time.Duration, url.URL, *url.URL and comma-separated []string.
+// Parameters:
+//   - v: Pointer to a struct
+//
+// Returns:
+//   - error: Returns an error if v is not a pointer to a struct or a field type is unsupported; returns *EnvError if variables are missing or invalid
+func Parse(v any) error {
+	return New().Parse(v)
+}
+
+// MustParse 与 Parse 相同，但出错时 panic，用于启动时快速失败
+//
+// MustParse is like Parse but panics on error, for failing fast at startup.
+func MustParse(v any) {
+	if err := Parse(v); err != nil {
+		panic(err)
+	}
+}
+
+// Parse 使用 e 的查找函数填充结构体，错误与 e 中已记录的错误合并返回
+//
+// Parse fills the struct using e's lookup function; errors are returned together with those already recorded in e.
+func (e *Env) Parse(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("envutil: Parse requires a non-nil pointer to a struct")
+	}
+	if err := e.parseStruct(rv.Elem()); err != nil {
+		return err
+	}
+	return e.Err()
+}
+
+// parseStruct 填充结构体的字段
+//
+// parseStruct fills the fields of a struct
+func (e *Env) parseStruct(rv reflect.Value) error {
+	rt := rv.Type()
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := rv.Field(i)
+		key, ok := field.Tag.Lookup("env")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct && field.Type != urlType {
+				if err := e.parseStruct(fv); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		required, _ := strconv.ParseBool(field.Tag.Get("required"))
+		def, hasDef := field.Tag.Lookup("default")
+		s, found := e.value(key, required && !hasDef)
+		if !found {
+			if !hasDef {
+				continue
+			}
+			s = def
+		}
+		if err := setField(fv, s); err != nil {
+			if errors.Is(err, errUnsupported) {
+				return fmt.Errorf("envutil: field %s: %w", field.Name, err)
+			}
+			e.invalid(key, err)
+		}
+	}
+	return nil
+}
+
+// errUnsupported 表示字段类型不支持
+//
+// errUnsupported indicates an unsupported field type
+var errUnsupported = errors.New("unsupported field type")
+
+// setField 将字符串解析后写入字段
+//
+// setField parses the string and stores it in the field
+func setField(fv reflect.Value, s string) error {
+	switch fv.Type() {
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		fv.SetInt(int64(d))
+		return nil
+	case urlType, reflect.PointerTo(urlType):
+		u, err := parseURL(s)
+		if err != nil {
+			return err
+		}
+		if fv.Kind() == reflect.Pointer {
+			fv.Set(reflect.ValueOf(u))
+		} else {
+			fv.Set(reflect.ValueOf(*u))
+		}
+		return nil
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(s)
+	case reflect.Bool:
+		b, err := parseBool(s)
+		if err != nil {
+			return err
+		}
+		fv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, fv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		fv.SetFloat(f)
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%w %s", errUnsupported, fv.Type())
+		}
+		var items []string
+		for item := range strings.SplitSeq(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		fv.Set(reflect.ValueOf(items).Convert(fv.Type()))
+	default:
+		return fmt.Errorf("%w %s", errUnsupported, fv.Type())
+	}
+	return nil
+}
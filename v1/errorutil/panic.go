@@ -0,0 +1,141 @@
+// Package errorutil 提供错误处理相关的工具函数
+//
+// Package errorutil provides error handling utility functions.
+package errorutil
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+var (
+	// ErrPanic 表示函数执行过程中发生了 panic
+	//
+	// ErrPanic indicates that a panic occurred during function execution.
+	ErrPanic = errors.New("panic recovered")
+
+	// panicHook 全局 panic 上报钩子
+	//
+	// panicHook is the global panic reporting hook
+	panicHook atomic.Pointer[func(*PanicError)]
+)
+
+// PanicError 表示被捕获的 panic，包含 panic 值和发生时的调用栈
+// Value: panic 传入的原始值
+// Stack: panic 发生时的调用栈信息
+//
+// PanicError represents a recovered panic, containing the panic value and the stack trace at the time.
+// Value: The original value passed to panic
+// Stack: The stack trace at the time of the panic
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error 实现 error 接口
+//
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrPanic, e.Value)
+}
+
+// Unwrap 支持 errors.Is / errors.As，同时匹配 ErrPanic 和 panic 值本身（如果它是 error）
+//
+// Unwrap supports errors.Is / errors.As, matching both ErrPanic and the panic value itself (if it is an error).
+func (e *PanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrPanic, err}
+	}
+	return []error{ErrPanic}
+}
+
+// SetPanicHook 设置全局 panic 上报钩子，Recover 和 SafeGo 捕获到 panic 时都会调用
+// 传入 nil 表示移除钩子
+//
+// SetPanicHook sets the global panic reporting hook, which is called whenever Recover or SafeGo catches a panic.
+// Passing nil removes the hook.
+func SetPanicHook(hook func(*PanicError)) {
+	if hook == nil {
+		panicHook.Store(nil)
+		return
+	}
+	panicHook.Store(&hook)
+}
+
+// NewPanicError 根据 panic 值创建 PanicError，并捕获当前调用栈
+// 应在 defer 的 recover 处理中调用，以获得准确的调用栈
+//
+// NewPanicError creates a PanicError from a panic value and captures the current stack trace.
+// It should be called inside a deferred recover handler to get an accurate stack trace.
+func NewPanicError(value any) *PanicError {
+	return &PanicError{
+		Value: value,
+		Stack: debug.Stack(),
+	}
+}
+
+// Recover 执行 fn 并捕获其中的 panic，将其转换为 *PanicError 返回
+// 如果 fn 正常返回，则原样返回 fn 的错误
+// 参数:
+//   - fn: 要执行的函数
+//
+// 返回:
+//   - fn 返回的错误，或发生 panic 时的 *PanicError
+//
+// Recover executes fn and catches any panic in it, converting it to a *PanicError.
+// If fn returns normally, its error is returned as is.
+// Parameters:
+//   - fn: The function to execute
+//
+// Returns:
+//   - The error returned by fn, or a *PanicError if a panic occurred
+func Recover(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			pe := NewPanicError(r)
+			reportPanic(pe)
+			err = pe
+		}
+	}()
+	return fn()
+}
+
+// SafeGo 在新的 goroutine 中执行 fn，并捕获其中的 panic，避免整个进程崩溃
+// 发生 panic 时会先调用 onPanic（如果不为 nil），再调用全局钩子
+// 参数:
+//   - fn: 要在 goroutine 中执行的函数
+//   - onPanic: 发生 panic 时的回调，可以为 nil
+//
+// SafeGo executes fn in a new goroutine and catches any panic in it, preventing the whole process from crashing.
+// When a panic occurs, onPanic is called first (if not nil), followed by the global hook.
+// Parameters:
+//   - fn: The function to execute in the goroutine
+//   - onPanic: Callback invoked on panic, may be nil
+func SafeGo(fn func(), onPanic func(*PanicError)) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				pe := NewPanicError(r)
+				if onPanic != nil {
+					onPanic(pe)
+				}
+				reportPanic(pe)
+			}
+		}()
+		fn()
+	}()
+}
+
+// reportPanic 调用全局钩子上报 panic，钩子本身的 panic 会被忽略
+//
+// reportPanic reports the panic via the global hook; panics raised by the hook itself are ignored.
+func reportPanic(pe *PanicError) {
+	hook := panicHook.Load()
+	if hook == nil {
+		return
+	}
+	defer func() { _ = recover() }()
+	(*hook)(pe)
+}
@@ -0,0 +1,222 @@
+package errorutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// markedError 被显式标记为可重试或不可重试的错误
+//
+// markedError is an error explicitly marked as retryable or not
+type markedError struct {
+	err       error
+	retryable bool
+}
+
+// Error 实现 error 接口
+//
+// Error implements the error interface.
+func (e *markedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap 支持 errors.Is / errors.As
+//
+// Unwrap supports errors.Is / errors.As.
+func (e *markedError) Unwrap() error {
+	return e.err
+}
+
+// MarkRetryable 把错误标记为可重试，IsRetryable 会对它返回 true，不改变错误信息，errors.Is / errors.As 仍然匹配原错误
+// 参数:
+//   - err: 原错误，为 nil 时返回 nil
+//
+// 返回:
+//   - error: 标记后的错误
+//
+// MarkRetryable marks an error as retryable so IsRetryable returns true for it; the message is unchanged and errors.Is / errors.As still match the original error.
+// Parameters:
+//   - err: The original error; nil is returned for nil
+//
+// Returns:
+//   - error: The marked error
+func MarkRetryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &markedError{err: err, retryable: true}
+}
+
+// MarkPermanent 把错误标记为不可重试，优先于 IsRetryable 的自动分类，例如参数校验失败的 5xx 响应
+// 参数:
+//   - err: 原错误，为 nil 时返回 nil
+//
+// 返回:
+//   - error: 标记后的错误
+//
+// MarkPermanent marks an error as not retryable, overriding IsRetryable's automatic classification, e.g. for a 5xx response caused by invalid input.
+// Parameters:
+//   - err: The original error; nil is returned for nil
+//
+// Returns:
+//   - error: The marked error
+func MarkPermanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &markedError{err: err, retryable: false}
+}
+
+// StatusError 表示 HTTP 响应的状态码不成功，供 HTTP 客户端返回，使 IsRetryable 能按状态码分类
+// StatusCode: 状态码
+// RetryAfter: 响应 Retry-After 头给出的等待时间，没有时为 0
+//
+// StatusError indicates an unsuccessful HTTP status, returned by HTTP clients so IsRetryable can classify by status code.
+// StatusCode: The status code
+// RetryAfter: The wait given by the response's Retry-After header; 0 when absent
+type StatusError struct {
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+// Error 实现 error 接口
+//
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("http status %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// HTTPStatusCode 返回状态码，与 AWS SDK 等库的响应错误使用相同的方法名
+//
+// HTTPStatusCode returns the status code, using the same method name as the response errors of libraries such as the AWS SDK.
+func (e *StatusError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// NewStatusError 根据响应创建 StatusError，解析以秒或 HTTP 日期表示的 Retry-After 头
+// 参数:
+//   - resp: HTTP 响应
+//
+// 返回:
+//   - *StatusError: 状态码错误
+//
+// NewStatusError creates a StatusError from a response, parsing a Retry-After header given in seconds or as an HTTP date.
+// Parameters:
+//   - resp: The HTTP response
+//
+// Returns:
+//   - *StatusError: The status error
+func NewStatusError(resp *http.Response) *StatusError {
+	e := &StatusError{StatusCode: resp.StatusCode}
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseInt(v, 10, 64); err == nil && secs > 0 {
+			e.RetryAfter = time.Duration(secs) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			e.RetryAfter = max(time.Until(t), 0)
+		}
+	}
+	return e
+}
+
+// IsRetryableStatus 判断 HTTP 状态码是否值得重试：408、425、429 和除 501、505 之外的 5xx
+//
+// IsRetryableStatus reports whether an HTTP status code is worth retrying: 408, 425, 429 and every 5xx except 501 and 505.
+func IsRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented, http.StatusHTTPVersionNotSupported:
+		return false
+	}
+	return code >= 500 && code <= 599
+}
+
+// IsRetryable 判断错误是否是临时的、重试可能成功，重试逻辑应统一使用它做决定，例如 emailutil.SendMail
+// 按以下顺序判断，第一个命中的规则决定结果:
+//   - MarkRetryable / MarkPermanent 的显式标记，最外层的标记优先
+//   - 实现了 Retryable() bool 方法的错误
+//   - context.Canceled 不可重试，context.DeadlineExceeded 可重试（单次尝试超时，调用者应另行检查总的 ctx）
+//   - 实现了 HTTPStatusCode() int 方法的错误（例如 StatusError、AWS SDK 的响应错误）按 IsRetryableStatus 判断
+//   - *textproto.Error（SMTP 等文本协议的错误回复）中 4xx 可重试，其他回复码不可重试
+//   - 网络超时、连接被拒绝或重置、连接意外断开和 DNS 临时失败可重试
+//
+// 其余错误不可重试
+// 参数:
+//   - err: 错误
+//
+// 返回:
+//   - bool: 是否可重试，err 为 nil 时返回 false
+//
+// IsRetryable reports whether an error is transient so a retry may succeed; retry logic such as emailutil.SendMail should use it for consistent decisions.
+// The rules are checked in this order and the first match decides:
+//   - Explicit marks from MarkRetryable / MarkPermanent, the outermost mark winning
+//   - Errors with a Retryable() bool method
+//   - context.Canceled is not retryable and context.DeadlineExceeded is (a single attempt timed out; callers should check the overall ctx separately)
+//   - Errors with an HTTPStatusCode() int method (such as StatusError and AWS SDK response errors) are classified by IsRetryableStatus
+//   - *textproto.Error replies (from text protocols such as SMTP) are retryable for 4xx codes and not for any other code
+//   - Network timeouts, refused or reset connections, unexpectedly closed connections and temporary DNS failures are retryable
+//
+// Any other error is not retryable.
+// Parameters:
+//   - err: The error
+//
+// Returns:
+//   - bool: Whether it is retryable; false when err is nil
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var marked *markedError
+	if errors.As(err, &marked) {
+		return marked.retryable
+	}
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, context.DeadlineExceeded):
+		return true
+	}
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) {
+		return IsRetryableStatus(status.HTTPStatusCode())
+	}
+	var reply *textproto.Error
+	if errors.As(err, &reply) {
+		return reply.Code >= 400 && reply.Code < 500
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// RetryAfter 返回错误链中 StatusError 携带的 Retry-After 等待时间，重试前应至少等待这么久
+//
+// RetryAfter returns the Retry-After wait carried by a StatusError in the error chain; a retry should wait at least this long.
+func RetryAfter(err error) (time.Duration, bool) {
+	var se *StatusError
+	if errors.As(err, &se) && se.RetryAfter > 0 {
+		return se.RetryAfter, true
+	}
+	return 0, false
+}
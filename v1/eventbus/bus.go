@@ -0,0 +1,362 @@
+// Package eventbus 提供进程内的类型化发布订阅，用于解耦单体服务内的各个模块
+//
+// Package eventbus provides in-process typed publish/subscribe for decoupling modules inside a monolith service.
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/supergodk/go-utils/v1/ctxutil"
+	"github.com/supergodk/go-utils/v1/errorutil"
+)
+
+// DefaultBufferSize 异步订阅者默认的缓冲区大小
+//
+// DefaultBufferSize is the default buffer size of asynchronous subscribers
+const DefaultBufferSize = 256
+
+var (
+	// ErrClosed 表示事件总线已关闭
+	//
+	// ErrClosed indicates that the event bus is closed
+	ErrClosed = errors.New("event bus closed")
+	// ErrBufferFull 表示异步订阅者的缓冲区已满，事件被丢弃
+	//
+	// ErrBufferFull indicates that an asynchronous subscriber's buffer is full and the event was dropped
+	ErrBufferFull = errors.New("subscriber buffer full")
+)
+
+// Options 事件总线的选项
+// OnError: 异步处理函数返回错误或 panic 时的回调（panic 为 *errorutil.PanicError），为 nil 时忽略；同步处理函数的错误直接由 Publish 返回
+//
+// Options contains event bus options.
+// OnError: Callback for errors or panics (as *errorutil.PanicError) of asynchronous handlers; ignored when nil. Synchronous handler errors are returned by Publish directly
+type Options struct {
+	OnError func(event any, err error)
+}
+
+// AsyncOptions 异步订阅的选项
+// BufferSize: 缓冲区大小，为 0 时使用 DefaultBufferSize
+// DropWhenFull: 缓冲区满时丢弃事件并让 Publish 返回 ErrBufferFull，默认阻塞发布者直到有空间或 ctx 取消
+//
+// AsyncOptions contains options for asynchronous subscriptions.
+// BufferSize: Buffer size; DefaultBufferSize when 0
+// DropWhenFull: Drop the event and make Publish return ErrBufferFull when the buffer is full; by default the publisher blocks until there is room or ctx is cancelled
+type AsyncOptions struct {
+	BufferSize   int
+	DropWhenFull bool
+}
+
+// Bus 事件总线，按事件的静态类型分发给订阅者；并发安全
+// 每个处理函数中的 panic 都会被捕获并转换为错误，不会影响其他订阅者和发布者
+//
+// Bus is an event bus that dispatches events to subscribers by their static type; it is safe for concurrent use.
+// A panic in any handler is recovered and turned into an error, so it affects neither other subscribers nor the publisher.
+type Bus struct {
+	o Options
+
+	mu     sync.RWMutex
+	subs   map[reflect.Type][]*subscriber
+	nextID uint64
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// subscriber 一个订阅者
+//
+// subscriber is one subscriber
+type subscriber struct {
+	id   uint64
+	call func(ctx context.Context, event any) error
+
+	// 以下字段仅用于异步订阅者
+	queue        chan queued
+	quit         chan struct{}
+	quitOnce     sync.Once
+	dropWhenFull bool
+}
+
+// queued 异步订阅者缓冲区中的事件
+//
+// queued is an event in an asynchronous subscriber's buffer
+type queued struct {
+	ctx   context.Context
+	event any
+}
+
+// New 创建事件总线
+// 参数:
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *Bus: 事件总线
+//
+// New creates an event bus.
+// Parameters:
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *Bus: The event bus
+func New(opts *Options) *Bus {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	return &Bus{o: o, subs: make(map[reflect.Type][]*subscriber)}
+}
+
+// Subscribe 同步订阅 T 类型的事件，处理函数在 Publish 的调用方 goroutine 中按订阅顺序执行
+// 参数:
+//   - b: 事件总线
+//   - handler: 处理函数，返回的错误由 Publish 返回
+//
+// 返回:
+//   - func(): 取消订阅的函数，可以重复调用
+//
+// Subscribe subscribes synchronously to events of type T; the handler runs in the Publish caller's goroutine, in subscription order.
+// Parameters:
+//   - b: The event bus
+//   - handler: The handler; its error is returned by Publish
+//
+// Returns:
+//   - func(): A function that cancels the subscription; it may be called more than once
+func Subscribe[T any](b *Bus, handler func(ctx context.Context, event T) error) func() {
+	return b.add(reflect.TypeFor[T](), &subscriber{call: wrap(handler)})
+}
+
+// SubscribeAsync 异步订阅 T 类型的事件，事件进入缓冲区后由专属 goroutine 依次处理，同一订阅者收到的事件保持发布顺序
+// 处理函数收到的 ctx 保留发布者 ctx 中的值，但不继承其取消信号
+// 参数:
+//   - b: 事件总线
+//   - handler: 处理函数，错误交给 Options.OnError
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - func(): 取消订阅的函数，已缓冲的事件仍会处理完；可以重复调用
+//
+// SubscribeAsync subscribes asynchronously to events of type T. Events enter a buffer and a dedicated goroutine handles them one by one, so a subscriber sees events in publish order.
+// The ctx passed to the handler keeps the values of the publisher's ctx but not its cancellation.
+// Parameters:
+//   - b: The event bus
+//   - handler: The handler; its errors go to Options.OnError
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - func(): A function that cancels the subscription; buffered events are still handled. It may be called more than once
+func SubscribeAsync[T any](b *Bus, handler func(ctx context.Context, event T) error, opts *AsyncOptions) func() {
+	var o AsyncOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.BufferSize <= 0 {
+		o.BufferSize = DefaultBufferSize
+	}
+	s := &subscriber{
+		call:         wrap(handler),
+		queue:        make(chan queued, o.BufferSize),
+		quit:         make(chan struct{}),
+		dropWhenFull: o.DropWhenFull,
+	}
+	return b.add(reflect.TypeFor[T](), s)
+}
+
+// Publish 发布 T 类型的事件：同步订阅者依次执行，异步订阅者放入缓冲区
+// 参数:
+//   - ctx: 上下文
+//   - b: 事件总线
+//   - event: 事件
+//
+// 返回:
+//   - error: 同步处理函数的错误（多个错误合并）、ErrBufferFull、ctx 的错误，总线已关闭时返回 ErrClosed
+//
+// Publish publishes an event of type T: synchronous subscribers run in turn and asynchronous ones get it in their buffer.
+// Parameters:
+//   - ctx: Context
+//   - b: The event bus
+//   - event: The event
+//
+// Returns:
+//   - error: Errors from synchronous handlers (joined), ErrBufferFull, or ctx's error; ErrClosed if the bus is closed
+func Publish[T any](ctx context.Context, b *Bus, event T) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	subs := b.subs[reflect.TypeFor[T]()]
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, s := range subs {
+		if s.queue == nil {
+			if err := s.call(ctx, event); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if err := s.enqueue(ctx, queued{ctx: ctxutil.DetachValues(ctx), event: event}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close 关闭事件总线，之后的 Publish 返回 ErrClosed，并等待异步订阅者处理完已缓冲的事件
+// 参数:
+//   - ctx: 上下文，用于限制等待时间
+//
+// 返回:
+//   - error: ctx 在处理完成前结束时返回 ctx 的错误
+//
+// Close closes the bus so later Publish calls return ErrClosed, then waits for asynchronous subscribers to handle their buffered events.
+// Parameters:
+//   - ctx: Context bounding the wait
+//
+// Returns:
+//   - error: ctx's error if it ends before handling completes
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		for _, subs := range b.subs {
+			for _, s := range subs {
+				s.stop()
+			}
+		}
+		b.subs = nil
+	}
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// add 注册订阅者并返回取消订阅的函数
+//
+// add registers a subscriber and returns the function that cancels it
+func (b *Bus) add(typ reflect.Type, s *subscriber) func() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return func() {}
+	}
+	b.nextID++
+	s.id = b.nextID
+	// 写时复制，Publish 可以在锁外遍历快照
+	b.subs[typ] = append(b.subs[typ][:len(b.subs[typ]):len(b.subs[typ])], s)
+	if s.queue != nil {
+		b.wg.Add(1)
+		go b.run(s)
+	}
+	return func() { b.remove(typ, s.id) }
+}
+
+// remove 取消订阅
+//
+// remove cancels a subscription
+func (b *Bus) remove(typ reflect.Type, id uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	subs := b.subs[typ]
+	for i, s := range subs {
+		if s.id != id {
+			continue
+		}
+		next := make([]*subscriber, 0, len(subs)-1)
+		next = append(next, subs[:i]...)
+		b.subs[typ] = append(next, subs[i+1:]...)
+		s.stop()
+		return
+	}
+}
+
+// run 异步订阅者的处理循环，退出前处理完已缓冲的事件
+//
+// run is an asynchronous subscriber's loop; buffered events are handled before it exits
+func (b *Bus) run(s *subscriber) {
+	defer b.wg.Done()
+	for {
+		select {
+		case q := <-s.queue:
+			b.handle(s, q)
+		case <-s.quit:
+			for {
+				select {
+				case q := <-s.queue:
+					b.handle(s, q)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// handle 执行异步处理函数并上报错误
+//
+// handle runs an asynchronous handler and reports its error
+func (b *Bus) handle(s *subscriber, q queued) {
+	if err := s.call(q.ctx, q.event); err != nil && b.o.OnError != nil {
+		b.o.OnError(q.event, err)
+	}
+}
+
+// enqueue 将事件放入缓冲区
+//
+// enqueue puts an event into the buffer
+func (s *subscriber) enqueue(ctx context.Context, q queued) error {
+	// 已取消订阅时不再放入，避免在处理循环退出后积压
+	select {
+	case <-s.quit:
+		return nil
+	default:
+	}
+	if s.dropWhenFull {
+		select {
+		case s.queue <- q:
+		case <-s.quit:
+		default:
+			return ErrBufferFull
+		}
+		return nil
+	}
+	select {
+	case s.queue <- q:
+	case <-s.quit:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// stop 通知异步订阅者退出
+//
+// stop tells an asynchronous subscriber to exit
+func (s *subscriber) stop() {
+	if s.quit != nil {
+		s.quitOnce.Do(func() { close(s.quit) })
+	}
+}
+
+// wrap 将类型化的处理函数包装为统一的形式，并捕获 panic
+//
+// wrap turns a typed handler into the common form and recovers panics
+func wrap[T any](handler func(ctx context.Context, event T) error) func(ctx context.Context, event any) error {
+	return func(ctx context.Context, event any) error {
+		return errorutil.Recover(func() error {
+			return handler(ctx, event.(T))
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package excelutil
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// utf8BOM UTF-8 字节序标记，Excel 需要它才能正确识别包含中文的 CSV
+//
+// utf8BOM is the UTF-8 byte order mark, which Excel needs to correctly recognize CSV files containing Chinese
+const utf8BOM = "\xEF\xBB\xBF"
+
+// ErrMissingHeader 表示 CSV 缺少表头或缺少必需的列
+//
+// ErrMissingHeader indicates that the CSV is missing the header row or a required column.
+var ErrMissingHeader = errors.New("csv header missing")
+
+// Options CSV 读写选项
+// BOM: 写入时是否在开头添加 UTF-8 BOM（方便 Excel 打开中文 CSV），读取时总会自动跳过 BOM
+// Comma: 字段分隔符，默认为逗号
+// StrictHeader: 读取时是否要求结构体的每一列都出现在表头中
+//
+// Options contains options for reading and writing CSV.
+// BOM: Whether to prepend a UTF-8 BOM when writing (so Excel opens Chinese CSV correctly); a BOM is always skipped when reading
+// Comma: Field delimiter, defaults to comma
+// StrictHeader: Whether reading requires every struct column to appear in the header
+type Options struct {
+	BOM          bool
+	Comma        rune
+	StrictHeader bool
+}
+
+// RowError 单行解析错误
+// Row: 该行在文件中的起始行号（从 1 开始，表头为第 1 行）
+// Column: 出错的列名，为空表示整行错误
+// Err: 具体错误
+//
+// RowError is a parsing error for a single row.
+// Row: Starting line number of the row in the file (starting at 1, the header is line 1)
+// Column: Name of the failing column, empty for whole-row errors
+// Err: The underlying error
+type RowError struct {
+	Row    int
+	Column string
+	Err    error
+}
+
+// Error 实现 error 接口
+//
+// Error implements the error interface.
+func (e *RowError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+	}
+	return fmt.Sprintf("row %d, column %s: %v", e.Row, e.Column, e.Err)
+}
+
+// Unwrap 返回底层错误
+//
+// Unwrap returns the underlying error.
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// WriteCSV 按结构体标签将切片写为 CSV，第一行为表头
+// 参数:
+//   - w: 输出目标
+//   - rows: 结构体或结构体指针切片
+//   - opts: 写入选项，可以为 nil
+//
+// 返回:
+//   - error: 类型不支持或写入失败时返回错误
+//
+// WriteCSV writes the slice as CSV according to struct tags, with the header as the first row.
+// Parameters:
+//   - w: Output destination
+//   - rows: Slice of structs or struct pointers
+//   - opts: Write options, may be nil
+//
+// Returns:
+//   - error: Returns an error if the type is unsupported or writing fails
+func WriteCSV[T any](w io.Writer, rows []T, opts *Options) error {
+	cols, err := columnsOf(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	if opts.BOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+	if err := cw.Write(headers(cols)); err != nil {
+		return err
+	}
+	for i := range rows {
+		record, err := rowValues(reflect.ValueOf(&rows[i]).Elem(), cols)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", i+2, err)
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV 读取 CSV 并按表头列名映射到结构体切片
+// 单行解析失败不会中断读取，失败的行会被跳过并记录在 rowErrs 中，方便向用户逐行反馈
+// 表头中不认识的列会被忽略
+// 参数:
+//   - r: CSV 数据来源
+//   - opts: 读取选项，可以为 nil
+//
+// 返回:
+//   - rows: 成功解析的行
+//   - rowErrs: 每个解析失败的行对应的错误
+//   - err: 表头缺失、类型不支持或 CSV 格式严重错误时返回错误
+//
+// ReadCSV reads CSV and maps it to a slice of structs by header column names.
+// A failing row does not abort reading; it is skipped and recorded in rowErrs so that feedback can be given per row.
+// Unknown header columns are ignored.
+// Parameters:
+//   - r: CSV data source
+//   - opts: Read options, may be nil
+//
+// Returns:
+//   - rows: Successfully parsed rows
+//   - rowErrs: The error for each row that failed to parse
+//   - err: Returns an error if the header is missing, the type is unsupported or the CSV is severely malformed
+func ReadCSV[T any](r io.Reader, opts *Options) (rows []T, rowErrs []*RowError, err error) {
+	typ := reflect.TypeFor[T]()
+	cols, err := columnsOf(typ)
+	if err != nil {
+		return nil, nil, err
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, ErrMissingHeader
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], utf8BOM)
+	}
+
+	// 按结构体列顺序记录每列在表头中的位置
+	positions := make([]columnPosition, 0, len(cols))
+	for _, col := range cols {
+		found := false
+		for i, h := range header {
+			if strings.TrimSpace(h) == col.header {
+				positions = append(positions, columnPosition{pos: i, col: col})
+				found = true
+				break
+			}
+		}
+		if !found && opts.StrictHeader {
+			return nil, nil, fmt.Errorf("%w: column %s", ErrMissingHeader, col.header)
+		}
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var pe *csv.ParseError
+			if errors.As(err, &pe) && !errors.Is(pe.Err, csv.ErrQuote) {
+				rowErrs = append(rowErrs, &RowError{Row: pe.StartLine, Err: pe.Err})
+				continue
+			}
+			return rows, rowErrs, err
+		}
+
+		// 使用文件中的实际行号，兼容包含换行的带引号字段
+		line, _ := cr.FieldPos(0)
+		row, ok := parseRow[T](record, positions, line, &rowErrs)
+		if ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows, rowErrs, nil
+}
+
+// columnPosition 结构体列及其在表头中的位置
+//
+// columnPosition is a struct column and its position in the header
+type columnPosition struct {
+	pos int
+	col column
+}
+
+// parseRow 将一行 CSV 记录解析为结构体，出错的列记录到 rowErrs
+//
+// parseRow parses a CSV record into a struct, recording failing columns in rowErrs
+func parseRow[T any](record []string, positions []columnPosition, line int, rowErrs *[]*RowError) (T, bool) {
+	var out T
+	v := reflect.ValueOf(&out).Elem()
+	if v.Kind() == reflect.Pointer {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	ok := true
+	for _, p := range positions {
+		if p.pos >= len(record) {
+			continue
+		}
+		f := fieldByIndexAlloc(v, p.col.index)
+		if err := parseValue(record[p.pos], f, p.col); err != nil {
+			*rowErrs = append(*rowErrs, &RowError{Row: line, Column: p.col.header, Err: err})
+			ok = false
+		}
+	}
+	return out, ok
+}
+
+// fieldByIndexAlloc 按索引路径获取字段，遇到 nil 的嵌入指针时自动分配
+//
+// fieldByIndexAlloc gets the field by index path, allocating nil embedded pointers along the way
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
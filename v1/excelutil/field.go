@@ -0,0 +1,322 @@
+// Package excelutil 提供基于结构体标签的 CSV/XLSX 导入导出工具函数
+//
+// 通过 excel 标签控制列名和格式，例如:
+//
+//	type Order struct {
+//		ID        int64     `excel:"订单号"`
+//		Amount    float64   `excel:"金额,format=%.2f"`
+//		CreatedAt time.Time `excel:"下单时间,format=2006-01-02 15:04,tz=Asia/Shanghai"`
+//		Internal  string    `excel:"-"`
+//	}
+//
+// Package excelutil provides struct-tag based CSV/XLSX import and export utility functions.
+//
+// The excel tag controls column names and formats, for example:
+//
+//	type Order struct {
+//		ID        int64     `excel:"Order ID"`
+//		Amount    float64   `excel:"Amount,format=%.2f"`
+//		CreatedAt time.Time `excel:"Created At,format=2006-01-02 15:04,tz=Asia/Shanghai"`
+//		Internal  string    `excel:"-"`
+//	}
+package excelutil
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// TagName 结构体标签名
+	//
+	// TagName is the struct tag name
+	TagName = "excel"
+
+	// DefaultTimeLayout 时间字段的默认格式
+	//
+	// DefaultTimeLayout is the default layout for time fields
+	DefaultTimeLayout = time.DateTime
+)
+
+var (
+	// ErrNotStruct 表示元素类型不是结构体
+	//
+	// ErrNotStruct indicates that the element type is not a struct.
+	ErrNotStruct = errors.New("element type must be a struct or pointer to struct")
+	// ErrUnsupportedType 表示字段类型不支持
+	//
+	// ErrUnsupportedType indicates that the field type is not supported.
+	ErrUnsupportedType = errors.New("unsupported field type")
+
+	// fieldCache 结构体类型到列定义的缓存
+	//
+	// fieldCache caches column definitions by struct type
+	fieldCache sync.Map
+
+	timeType            = reflect.TypeOf(time.Time{})
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// column 一列的定义
+// header: 列名
+// index: 字段索引路径
+// format: 格式（时间为 layout，数字为 fmt 格式）
+// loc: 时间字段使用的时区
+// numeric: 是否为未指定格式的数字列（XLSX 中写为数字单元格）
+//
+// column is the definition of a column.
+// header: Column name
+// index: Field index path
+// format: Format (layout for times, fmt verb for numbers)
+// loc: Time zone used for time fields
+// numeric: Whether it is a numeric column without a format (written as numeric cells in XLSX)
+type column struct {
+	header  string
+	index   []int
+	format  string
+	loc     *time.Location
+	numeric bool
+}
+
+// columnsOf 解析结构体类型的列定义，结果会被缓存
+//
+// columnsOf parses the column definitions of a struct type; results are cached
+func columnsOf(t reflect.Type) ([]column, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: %s", ErrNotStruct, t)
+	}
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.([]column), nil
+	}
+
+	var cols []column
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		tag, hasTag := f.Tag.Lookup(TagName)
+		if tag == "-" {
+			continue
+		}
+
+		col := column{header: f.Name, index: f.Index}
+		if hasTag {
+			parts := strings.Split(tag, ",")
+			if parts[0] != "" {
+				col.header = parts[0]
+			}
+			for _, opt := range parts[1:] {
+				key, value, _ := strings.Cut(opt, "=")
+				switch strings.TrimSpace(key) {
+				case "format":
+					col.format = value
+				case "tz":
+					loc, err := time.LoadLocation(value)
+					if err != nil {
+						return nil, fmt.Errorf("invalid tz for field %s: %w", f.Name, err)
+					}
+					col.loc = loc
+				}
+			}
+		}
+		col.numeric = col.format == "" && isNumeric(f.Type)
+		cols = append(cols, col)
+	}
+
+	fieldCache.Store(t, cols)
+	return cols, nil
+}
+
+// isNumeric 判断字段类型是否为数字（指针会被解引用）
+//
+// isNumeric reports whether the field type is numeric (pointers are dereferenced)
+func isNumeric(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// headers 返回所有列名
+//
+// headers returns all column names
+func headers(cols []column) []string {
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		out[i] = c.header
+	}
+	return out
+}
+
+// formatValue 将字段值格式化为字符串
+//
+// formatValue formats a field value as a string
+func formatValue(v reflect.Value, col column) (string, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return "", nil
+		}
+		if col.loc != nil {
+			t = t.In(col.loc)
+		}
+		layout := col.format
+		if layout == "" {
+			layout = DefaultTimeLayout
+		}
+		return t.Format(layout), nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		b, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	if col.format != "" {
+		return fmt.Sprintf(col.format, v.Interface()), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), nil
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return s.String(), nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+}
+
+// parseValue 将字符串解析到字段中
+//
+// parseValue parses a string into a field
+func parseValue(s string, v reflect.Value, col column) error {
+	if v.Kind() == reflect.Pointer {
+		if s == "" {
+			v.SetZero()
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if v.Type() == timeType {
+		if s == "" {
+			v.SetZero()
+			return nil
+		}
+		layout := col.format
+		if layout == "" {
+			layout = DefaultTimeLayout
+		}
+		loc := col.loc
+		if loc == nil {
+			loc = time.Local
+		}
+		t, err := time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	if s == "" && v.Kind() != reflect.String {
+		v.SetZero()
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSpace(s), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, v.Type())
+	}
+	return nil
+}
+
+// rowValues 将一行结构体转换为字符串切片
+//
+// rowValues converts a struct row into a string slice
+func rowValues(row reflect.Value, cols []column) ([]string, error) {
+	for row.Kind() == reflect.Pointer {
+		if row.IsNil() {
+			return make([]string, len(cols)), nil
+		}
+		row = row.Elem()
+	}
+	out := make([]string, len(cols))
+	for i, col := range cols {
+		f, err := row.FieldByIndexErr(col.index)
+		if err != nil {
+			// 嵌入的指针为 nil，该列留空
+			continue
+		}
+		s, err := formatValue(f, col)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", col.header, err)
+		}
+		out[i] = s
+	}
+	return out, nil
+}
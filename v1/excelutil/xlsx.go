@@ -0,0 +1,174 @@
+package excelutil
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// DefaultSheetName 默认工作表名称
+//
+// DefaultSheetName is the default worksheet name
+const DefaultSheetName = "Sheet1"
+
+// XLSXOptions XLSX 写入选项
+// SheetName: 工作表名称，默认 DefaultSheetName
+//
+// XLSXOptions contains options for writing XLSX.
+// SheetName: Worksheet name, defaults to DefaultSheetName
+type XLSXOptions struct {
+	SheetName string
+}
+
+// xlsx 包中固定不变的部件
+//
+// Static parts of the xlsx package
+const (
+	xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/><Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/></Types>`
+	xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+	xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/><Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/></Relationships>`
+	xlsxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts><fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills><borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders><cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs><cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs></styleSheet>`
+	xlsxWorkbookFmt = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+)
+
+// WriteXLSX 按结构体标签将切片写为 XLSX 文件，第一行为加粗的表头
+// 未指定 format 的数字字段写为数字单元格（可在 Excel 中直接求和），其中的 NaN 和 ±Inf 写为文本；其余字段写为文本
+// 参数:
+//   - w: 输出目标
+//   - rows: 结构体或结构体指针切片
+//   - opts: 写入选项，可以为 nil
+//
+// 返回:
+//   - error: 类型不支持或写入失败时返回错误
+//
+// WriteXLSX writes the slice as an XLSX file according to struct tags, with a bold header as the first row.
+// Numeric fields without a format are written as numeric cells (so they can be summed in Excel), except NaN and ±Inf, which are written as text; other fields are written as text.
+// Parameters:
+//   - w: Output destination
+//   - rows: Slice of structs or struct pointers
+//   - opts: Write options, may be nil
+//
+// Returns:
+//   - error: Returns an error if the type is unsupported or writing fails
+func WriteXLSX[T any](w io.Writer, rows []T, opts *XLSXOptions) error {
+	cols, err := columnsOf(reflect.TypeFor[T]())
+	if err != nil {
+		return err
+	}
+	sheetName := DefaultSheetName
+	if opts != nil && opts.SheetName != "" {
+		sheetName = opts.SheetName
+	}
+
+	zw := zip.NewWriter(w)
+	static := []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbookFmt, escapeXML(sheetName))},
+	}
+	for _, part := range static {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	bw.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	bw.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	// 表头使用样式 1（加粗）
+	writeXLSXRow(bw, 1, headers(cols), nil, 1)
+	for i := range rows {
+		record, err := rowValues(reflect.ValueOf(&rows[i]).Elem(), cols)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", i+2, err)
+		}
+		writeXLSXRow(bw, i+2, record, cols, 0)
+	}
+
+	bw.WriteString(`</sheetData></worksheet>`)
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeXLSXRow 写入一行单元格，cols 为 nil 时全部按文本处理
+//
+// writeXLSXRow writes a row of cells; all cells are treated as text when cols is nil
+func writeXLSXRow(bw *bufio.Writer, rowNum int, values []string, cols []column, style int) {
+	styleAttr := ""
+	if style > 0 {
+		styleAttr = ` s="` + strconv.Itoa(style) + `"`
+	}
+	bw.WriteString(`<row r="` + strconv.Itoa(rowNum) + `">`)
+	for i, v := range values {
+		if v == "" {
+			continue
+		}
+		ref := columnName(i) + strconv.Itoa(rowNum)
+		if cols != nil && cols[i].numeric && isFiniteNumber(v) {
+			bw.WriteString(`<c r="` + ref + `"` + styleAttr + `><v>` + v + `</v></c>`)
+			continue
+		}
+		bw.WriteString(`<c r="` + ref + `" t="inlineStr"` + styleAttr + `><is><t xml:space="preserve">`)
+		bw.WriteString(escapeXML(v))
+		bw.WriteString(`</t></is></c>`)
+	}
+	bw.WriteString(`</row>`)
+}
+
+// isFiniteNumber 判断数字列的值能否写为数字单元格，NaN 和 ±Inf 会让 Excel 认为文件已损坏，只能写为文本
+//
+// isFiniteNumber reports whether a numeric column value can be written as a numeric cell; NaN and ±Inf make Excel report the file as corrupt, so they are written as text
+func isFiniteNumber(v string) bool {
+	f, err := strconv.ParseFloat(v, 64)
+	return err == nil && !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// columnName 将从 0 开始的列序号转换为 Excel 列名，例如 0 -> A，26 -> AA
+//
+// columnName converts a zero-based column index to an Excel column name, e.g. 0 -> A, 26 -> AA
+func columnName(i int) string {
+	var b []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		b = append([]byte{byte('A' + (i-1)%26)}, b...)
+	}
+	return string(b)
+}
+
+// escapeXML 转义 XML 特殊字符，并去掉 XML 1.0 不允许出现的控制字符
+//
+// escapeXML escapes XML special characters and removes control characters not allowed in XML 1.0
+func escapeXML(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,399 @@
+// Package featureflag 提供简单的功能开关：支持布尔开关、按用户 ID 稳定分桶的百分比灰度和按属性匹配的规则，可从 JSON 或环境变量加载并热更新
+//
+// Package featureflag provides simple feature flags: boolean switches, percentage rollouts with stable per-user hashing and attribute-match rules,
+// loaded from JSON or environment variables with hot reload.
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/supergodk/go-utils/v1/fileutil"
+	"github.com/supergodk/go-utils/v1/randutil"
+)
+
+// 规则的匹配操作符
+//
+// Operators of rules
+const (
+	// OpEquals 属性等于 Values 中的任意一个
+	//
+	// OpEquals matches when the attribute equals any of Values
+	OpEquals = "eq"
+	// OpNotEquals 属性不等于 Values 中的任何一个
+	//
+	// OpNotEquals matches when the attribute equals none of Values
+	OpNotEquals = "neq"
+	// OpPrefix 属性以 Values 中的任意一个开头
+	//
+	// OpPrefix matches when the attribute starts with any of Values
+	OpPrefix = "prefix"
+	// OpSuffix 属性以 Values 中的任意一个结尾
+	//
+	// OpSuffix matches when the attribute ends with any of Values
+	OpSuffix = "suffix"
+	// OpContains 属性包含 Values 中的任意一个
+	//
+	// OpContains matches when the attribute contains any of Values
+	OpContains = "contains"
+)
+
+// ErrInvalidFlag 表示功能开关的定义无效
+//
+// ErrInvalidFlag indicates an invalid flag definition
+var ErrInvalidFlag = errors.New("invalid feature flag")
+
+// Rule 属性匹配规则，匹配时直接返回 Enabled
+// Attribute: 属性名，"id" 表示用户 ID
+// Op: 操作符，默认 OpEquals
+// Values: 比较的值
+// Enabled: 匹配时的结果
+//
+// Rule is an attribute-match rule; when it matches, Enabled is returned.
+// Attribute: Attribute name; "id" refers to the user ID
+// Op: Operator, defaults to OpEquals
+// Values: Values to compare with
+// Enabled: Result when the rule matches
+type Rule struct {
+	Attribute string   `json:"attribute"`
+	Op        string   `json:"op,omitempty"`
+	Values    []string `json:"values"`
+	Enabled   bool     `json:"enabled"`
+}
+
+// Flag 功能开关定义，按以下顺序求值：Enabled 为 false 时关闭（总开关）；按顺序第一个匹配的规则决定结果；
+// 设置了 Percentage 时按用户 ID 稳定分桶；否则开启
+// Enabled: 总开关
+// Rules: 属性匹配规则，按顺序匹配
+// Percentage: 灰度百分比（0-100），nil 表示不灰度；调大百分比时已开启的用户保持开启
+//
+// Flag is a flag definition, evaluated in this order: off when Enabled is false (kill switch); the first matching rule decides;
+// with Percentage set, users are bucketed stably by ID; otherwise on.
+// Enabled: The kill switch
+// Rules: Attribute-match rules, matched in order
+// Percentage: Rollout percentage (0-100), nil for no rollout; raising it keeps already enabled users enabled
+type Flag struct {
+	Enabled    bool     `json:"enabled"`
+	Rules      []Rule   `json:"rules,omitempty"`
+	Percentage *float64 `json:"percentage,omitempty"`
+}
+
+// User 求值的用户
+// ID: 用户 ID，用于百分比灰度
+// Attributes: 用户属性，例如 country、plan、email
+//
+// User is the user a flag is evaluated for.
+// ID: User ID, used for percentage rollouts
+// Attributes: User attributes, e.g. country, plan, email
+type User struct {
+	ID         string
+	Attributes map[string]string
+}
+
+// Flags 功能开关集合，并发安全，可在运行时整体替换
+//
+// Flags is a set of feature flags; it is safe for concurrent use and can be replaced as a whole at runtime.
+type Flags struct {
+	mu    sync.RWMutex
+	flags map[string]Flag
+}
+
+// New 创建功能开关集合
+// 参数:
+//   - flags: 开关名到定义的映射，可以为 nil
+//
+// 返回:
+//   - *Flags: 功能开关集合
+//   - error: 定义无效时返回 ErrInvalidFlag
+//
+// New creates a set of feature flags.
+// Parameters:
+//   - flags: Map from flag name to definition, may be nil
+//
+// Returns:
+//   - *Flags: The flag set
+//   - error: Returns ErrInvalidFlag if a definition is invalid
+func New(flags map[string]Flag) (*Flags, error) {
+	f := &Flags{}
+	if err := f.Replace(flags); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// LoadFile 从 JSON 文件创建功能开关集合，格式见 ParseJSON
+//
+// LoadFile creates a flag set from a JSON file; see ParseJSON for the format.
+func LoadFile(path string) (*Flags, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := ParseJSON(data)
+	if err != nil {
+		return nil, err
+	}
+	return New(flags)
+}
+
+// ParseJSON 解析 JSON 格式的开关定义，例如
+// {"new_checkout": {"enabled": true, "percentage": 20, "rules": [{"attribute": "plan", "values": ["beta"], "enabled": true}]}}
+//
+// ParseJSON parses flag definitions in JSON, e.g.
+// {"new_checkout": {"enabled": true, "percentage": 20, "rules": [{"attribute": "plan", "values": ["beta"], "enabled": true}]}}
+func ParseJSON(data []byte) (map[string]Flag, error) {
+	var flags map[string]Flag
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFlag, err)
+	}
+	for name, flag := range flags {
+		if err := flag.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return flags, nil
+}
+
+// FromEnv 从环境变量读取开关定义：prefix 之后的部分转为小写作为开关名，值为布尔值（true、false、1、0 等）
+// 或百分比（例如 "25%"），例如 FLAG_NEW_CHECKOUT=25% 定义开关 new_checkout
+// 参数:
+//   - prefix: 环境变量前缀，例如 "FLAG_"
+//
+// 返回:
+//   - map[string]Flag: 开关定义
+//   - error: 值无效时返回 ErrInvalidFlag
+//
+// FromEnv reads flag definitions from environment variables: the part after prefix, lowercased, is the flag name, and the value is a boolean (true, false, 1, 0, ...)
+// or a percentage (e.g. "25%"); for example FLAG_NEW_CHECKOUT=25% defines the flag new_checkout.
+// Parameters:
+//   - prefix: Environment variable prefix, e.g. "FLAG_"
+//
+// Returns:
+//   - map[string]Flag: The flag definitions
+//   - error: Returns ErrInvalidFlag if a value is invalid
+func FromEnv(prefix string) (map[string]Flag, error) {
+	flags := make(map[string]Flag)
+	for _, kv := range os.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		name, ok := strings.CutPrefix(k, prefix)
+		if !ok || name == "" {
+			continue
+		}
+		flag, err := parseEnvFlag(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidFlag, k, err)
+		}
+		flags[strings.ToLower(name)] = flag
+	}
+	return flags, nil
+}
+
+// parseEnvFlag 解析环境变量中的开关值
+//
+// parseEnvFlag parses a flag value from an environment variable
+func parseEnvFlag(v string) (Flag, error) {
+	if num, ok := strings.CutSuffix(v, "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+		if err != nil {
+			return Flag{}, err
+		}
+		flag := Flag{Enabled: true, Percentage: &p}
+		return flag, flag.validate()
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return Flag{}, err
+	}
+	return Flag{Enabled: b}, nil
+}
+
+// Replace 整体替换开关定义，定义无效时保持原有定义不变
+//
+// Replace replaces all flag definitions; the existing definitions are kept if any is invalid.
+func (f *Flags) Replace(flags map[string]Flag) error {
+	for name, flag := range flags {
+		if err := flag.validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	flags = maps.Clone(flags)
+	f.mu.Lock()
+	f.flags = flags
+	f.mu.Unlock()
+	return nil
+}
+
+// Merge 合并开关定义，同名的开关被覆盖，例如用环境变量覆盖文件中的定义
+//
+// Merge merges flag definitions, overriding flags with the same name, e.g. letting environment variables override a file.
+func (f *Flags) Merge(flags map[string]Flag) error {
+	for name, flag := range flags {
+		if err := flag.validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	merged := maps.Clone(f.flags)
+	if merged == nil {
+		merged = make(map[string]Flag, len(flags))
+	}
+	maps.Copy(merged, flags)
+	f.flags = merged
+	return nil
+}
+
+// Names 返回所有开关名，按名称排序
+//
+// Names returns all flag names, sorted.
+func (f *Flags) Names() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return slices.Sorted(maps.Keys(f.flags))
+}
+
+// Enabled 判断开关对用户是否开启，未定义的开关返回 false
+// 参数:
+//   - name: 开关名
+//   - user: 用户，可以为 nil（此时规则不匹配，百分比灰度视为关闭）
+//
+// 返回:
+//   - bool: 是否开启
+//
+// Enabled reports whether a flag is on for a user; undefined flags are off.
+// Parameters:
+//   - name: Flag name
+//   - user: The user, may be nil (rules then do not match and percentage rollouts are off)
+//
+// Returns:
+//   - bool: Whether the flag is on
+func (f *Flags) Enabled(name string, user *User) bool {
+	f.mu.RLock()
+	flag, ok := f.flags[name]
+	f.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	return flag.evaluate(name, user)
+}
+
+// WatchFile 监听 JSON 文件并在变化时重新加载，直到 ctx 结束；解析失败时保持原有定义并调用 opts.OnError
+// 参数:
+//   - ctx: 上下文，结束后停止监听
+//   - path: JSON 文件路径
+//   - opts: 监听选项，可以为 nil
+//
+// 返回:
+//   - error: ctx.Err()
+//
+// WatchFile watches a JSON file and reloads it on changes until ctx is done; on parse failures the existing definitions are kept and opts.OnError is called.
+// Parameters:
+//   - ctx: Context; watching stops when it is done
+//   - path: JSON file path
+//   - opts: Watch options, may be nil
+//
+// Returns:
+//   - error: ctx.Err()
+func (f *Flags) WatchFile(ctx context.Context, path string, opts *fileutil.WatchOptions) error {
+	var onError func(error)
+	if opts != nil {
+		onError = opts.OnError
+	}
+	return fileutil.Watch(ctx, path, func() {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			var flags map[string]Flag
+			if flags, err = ParseJSON(data); err == nil {
+				err = f.Replace(flags)
+			}
+		}
+		if err != nil && onError != nil {
+			onError(err)
+		}
+	}, opts)
+}
+
+// validate 校验开关定义
+//
+// validate validates the flag definition
+func (flag Flag) validate() error {
+	if p := flag.Percentage; p != nil && (*p < 0 || *p > 100) {
+		return fmt.Errorf("%w: percentage %v out of range", ErrInvalidFlag, *p)
+	}
+	for _, r := range flag.Rules {
+		switch r.Op {
+		case "", OpEquals, OpNotEquals, OpPrefix, OpSuffix, OpContains:
+		default:
+			return fmt.Errorf("%w: unknown operator %q", ErrInvalidFlag, r.Op)
+		}
+	}
+	return nil
+}
+
+// evaluate 按 Flag 文档中的顺序求值
+//
+// evaluate evaluates the flag in the order documented on Flag
+func (flag Flag) evaluate(name string, user *User) bool {
+	if !flag.Enabled {
+		return false
+	}
+	if user != nil {
+		for _, r := range flag.Rules {
+			if r.match(user) {
+				return r.Enabled
+			}
+		}
+	}
+	if flag.Percentage == nil {
+		return true
+	}
+	if user == nil || user.ID == "" {
+		return false
+	}
+	// 以万分之一为单位分桶，"on" 桶在前，调大百分比只会让更多用户开启
+	on := uint32(math.Round(*flag.Percentage * 100))
+	bucket, err := randutil.AssignBucket(user.ID, "featureflag:"+name, []randutil.Bucket{
+		{Name: "on", Weight: on},
+		{Name: "off", Weight: 10000 - on},
+	})
+	return err == nil && bucket == "on"
+}
+
+// match 判断规则是否匹配用户
+//
+// match reports whether the rule matches the user
+func (r Rule) match(user *User) bool {
+	var v string
+	var ok bool
+	if r.Attribute == "id" {
+		v, ok = user.ID, user.ID != ""
+	} else {
+		v, ok = user.Attributes[r.Attribute]
+	}
+	if !ok {
+		return false
+	}
+	var cmp func(string, string) bool
+	switch r.Op {
+	case OpNotEquals:
+		return !slices.Contains(r.Values, v)
+	case OpPrefix:
+		cmp = strings.HasPrefix
+	case OpSuffix:
+		cmp = strings.HasSuffix
+	case OpContains:
+		cmp = strings.Contains
+	default:
+		return slices.Contains(r.Values, v)
+	}
+	return slices.ContainsFunc(r.Values, func(want string) bool { return cmp(v, want) })
+}
@@ -0,0 +1,468 @@
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// SymlinkPolicy 遍历目录时对符号链接的处理方式
+//
+// SymlinkPolicy is how symlinks are handled while walking a directory tree
+type SymlinkPolicy int
+
+const (
+	// SymlinkSkip 忽略符号链接，默认值
+	//
+	// SymlinkSkip ignores symlinks; the default
+	SymlinkSkip SymlinkPolicy = iota
+	// SymlinkFollow 跟随符号链接，指向目录时进入目录（跳过形成环的链接），指向文件时按目标文件处理，失效的链接被忽略
+	//
+	// SymlinkFollow follows symlinks: links to directories are descended into (skipping links that form a loop),
+	// links to files are treated as their targets, and broken links are ignored
+	SymlinkFollow
+	// SymlinkInclude 不跟随符号链接，但把指向普通文件的链接本身作为文件交给回调，此时 WalkEntry.Symlink 为 true，WalkEntry.Info 是链接自身的信息；
+	// 指向目录或其他非普通文件的链接以及失效的链接被忽略
+	//
+	// SymlinkInclude does not follow symlinks but passes links to regular files to the callback as files, with WalkEntry.Symlink set and WalkEntry.Info describing the link itself;
+	// links to directories or other non-regular files and broken links are ignored
+	SymlinkInclude
+)
+
+// WalkOptions WalkFiltered 的选项
+// Include: 包含的文件模式，为空时包含所有文件；只对文件生效，目录总会被遍历
+// Exclude: 排除的文件和目录模式，被排除的目录不会进入，优先于 Include
+// Workers: 并发调用回调的 goroutine 数，默认 1，此时按路径顺序依次调用
+// Symlinks: 符号链接的处理方式，默认 SymlinkSkip
+//
+// 模式采用 .gitignore 的语法，路径相对于根目录并以 "/" 分隔:
+//   - 不含 "/" 的模式匹配任意层级的文件名或目录名，例如 "*.log"、"node_modules"
+//   - 以 "/" 开头或中间含有 "/" 的模式相对于根目录匹配，例如 "/build"、"docs/*.md"
+//   - 以 "/" 结尾的模式只匹配目录，例如 "tmp/"
+//   - "*"、"?" 和 "[...]" 不匹配 "/"，"**" 匹配任意层目录，例如 "**/testdata"、"assets/**"
+//   - 以 "!" 开头的模式取反，列表中最后一个匹配的模式决定结果；目录被匹配后其中的文件都算匹配，无法单独取反
+//   - 空行和以 "#" 开头的行被忽略，因此可以直接传入 .gitignore 文件按行拆分的结果
+//
+// WalkOptions contains options for WalkFiltered.
+// Include: Patterns of files to include; every file when empty. They apply to files only; directories are always walked
+// Exclude: Patterns of files and directories to exclude; excluded directories are not descended into. Takes precedence over Include
+// Workers: Number of goroutines calling the callback, 1 by default, in which case it is called in path order
+// Symlinks: How symlinks are handled, SymlinkSkip by default
+//
+// Patterns use .gitignore syntax, with paths relative to the root and separated by "/":
+//   - A pattern without "/" matches a file or directory name at any depth, e.g. "*.log", "node_modules"
+//   - A pattern starting with "/" or containing "/" in the middle matches relative to the root, e.g. "/build", "docs/*.md"
+//   - A pattern ending in "/" only matches directories, e.g. "tmp/"
+//   - "*", "?" and "[...]" do not match "/"; "**" matches any number of directories, e.g. "**/testdata", "assets/**"
+//   - A pattern starting with "!" negates, and the last matching pattern in the list decides; once a directory matches, every file in it matches and cannot be negated individually
+//   - Blank lines and lines starting with "#" are ignored, so the lines of a .gitignore file can be passed as is
+type WalkOptions struct {
+	Include  []string
+	Exclude  []string
+	Workers  int
+	Symlinks SymlinkPolicy
+}
+
+// WalkEntry WalkFiltered 交给回调的文件
+// Path: 相对于根目录、以 "/" 分隔的路径
+// Info: 文件信息，跟随符号链接时是目标文件的信息
+// Symlink: 是否为 SymlinkInclude 交出的符号链接，此时 Info 是链接自身的信息，打开 Path 读到的是目标文件的内容
+//
+// WalkEntry is a file passed to the WalkFiltered callback.
+// Path: The path relative to the root, separated by "/"
+// Info: The file info; the target's info when following symlinks
+// Symlink: Whether this is a symlink passed on by SymlinkInclude, in which case Info describes the link itself and opening Path reads the target's content
+type WalkEntry struct {
+	Path    string
+	Info    fs.FileInfo
+	Symlink bool
+}
+
+// WalkFiltered 遍历目录树，对通过过滤的每个普通文件调用 fn，是对象存储同步和校验清单等功能共用的遍历实现
+// 参数:
+//   - ctx: 上下文，取消后停止遍历
+//   - root: 根目录
+//   - fn: 回调，返回错误时停止遍历；Workers 大于 1 时会被并发调用
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - error: 模式无效、读取目录失败、ctx 被取消或 fn 返回错误时返回第一个错误
+//
+// WalkFiltered walks a directory tree and calls fn for every regular file passing the filters; it is the walking implementation shared by features such as object storage sync and checksum manifests.
+// Parameters:
+//   - ctx: Context; walking stops when it is cancelled
+//   - root: The root directory
+//   - fn: The callback; returning an error stops the walk. It is called concurrently when Workers is greater than 1
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - error: The first error of an invalid pattern, a failed directory read, ctx being cancelled or fn
+func WalkFiltered(ctx context.Context, root string, fn func(WalkEntry) error, opts *WalkOptions) error {
+	if _, err := os.Stat(root); err != nil {
+		return err
+	}
+	return WalkFilteredFS(ctx, os.DirFS(root), fn, opts)
+}
+
+// WalkFilteredFS 与 WalkFiltered 相同，但遍历 fs.FS，例如 embed.FS
+//
+// WalkFilteredFS is like WalkFiltered but walks an fs.FS, such as an embed.FS.
+func WalkFilteredFS(ctx context.Context, fsys fs.FS, fn func(WalkEntry) error, opts *WalkOptions) error {
+	var o WalkOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+	filter, err := NewPathFilter(o.Include, o.Exclude)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	w := &walker{fsys: fsys, filter: filter, symlinks: o.Symlinks}
+	var root []fs.FileInfo
+	if o.Symlinks == SymlinkFollow {
+		info, err := fs.Stat(fsys, ".")
+		if err != nil {
+			return err
+		}
+		root = []fs.FileInfo{info}
+	}
+	if o.Workers == 1 {
+		w.emit = fn
+		err := w.walkDir(ctx, ".", root)
+		return firstError(err, context.Cause(ctx))
+	}
+
+	var wg sync.WaitGroup
+	entries := make(chan WalkEntry)
+	for range o.Workers {
+		wg.Go(func() {
+			for e := range entries {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(e); err != nil {
+					cancel(err)
+				}
+			}
+		})
+	}
+	w.emit = func(e WalkEntry) error {
+		select {
+		case entries <- e:
+			return nil
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+	err = w.walkDir(ctx, ".", root)
+	close(entries)
+	wg.Wait()
+	return firstError(context.Cause(ctx), err)
+}
+
+// firstError 返回第一个非 nil 且不是 context.Canceled 的错误，都不是时返回第一个非 nil 的错误
+// 回调失败会取消遍历，此时遍历本身返回的 context.Canceled 不是根本原因
+//
+// firstError returns the first non-nil error that is not context.Canceled, falling back to the first non-nil error;
+// a failing callback cancels the walk, so the context.Canceled returned by the walk itself is not the root cause
+func firstError(errs ...error) error {
+	var first error
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, context.Canceled) {
+			return err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// walker 一次遍历的状态
+//
+// walker is the state of one walk
+type walker struct {
+	fsys     fs.FS
+	filter   *PathFilter
+	symlinks SymlinkPolicy
+	emit     func(WalkEntry) error
+}
+
+// walkDir 按名称顺序遍历目录，ancestors 是跟随符号链接时用于检测环的祖先目录信息
+//
+// walkDir walks a directory in name order; ancestors holds the ancestor directories' info used to detect loops when following symlinks
+func (w *walker) walkDir(ctx context.Context, dir string, ancestors []fs.FileInfo) error {
+	entries, err := fs.ReadDir(w.fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, d := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := path.Join(dir, d.Name())
+		isDir := d.IsDir()
+		var info fs.FileInfo
+		symlink := false
+		if d.Type()&fs.ModeSymlink != 0 {
+			switch w.symlinks {
+			case SymlinkFollow:
+				info, err = fs.Stat(w.fsys, name)
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				isDir = info.IsDir()
+				if !isDir && !info.Mode().IsRegular() {
+					continue
+				}
+			case SymlinkInclude:
+				target, err := fs.Stat(w.fsys, name)
+				if errors.Is(err, fs.ErrNotExist) {
+					continue
+				}
+				if err != nil {
+					return err
+				}
+				if !target.Mode().IsRegular() {
+					continue
+				}
+				isDir = false
+				symlink = true
+			default:
+				continue
+			}
+		} else if !isDir && !d.Type().IsRegular() {
+			continue
+		}
+
+		if w.filter.excluded(name, isDir) {
+			continue
+		}
+		if isDir {
+			if w.symlinks == SymlinkFollow {
+				if info == nil {
+					if info, err = d.Info(); err != nil {
+						return err
+					}
+				}
+				if inLoop(info, ancestors) {
+					continue
+				}
+			}
+			if err := w.walkDir(ctx, name, append(ancestors, info)); err != nil {
+				return err
+			}
+			continue
+		}
+		if !w.filter.included(name) {
+			continue
+		}
+		if info == nil {
+			if info, err = d.Info(); err != nil {
+				return err
+			}
+		}
+		if err := w.emit(WalkEntry{Path: name, Info: info, Symlink: symlink}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inLoop 判断目录是否是某个祖先目录本身，即符号链接形成了环；依赖 os.SameFile，只对操作系统的文件系统有效
+//
+// inLoop reports whether a directory is one of its ancestors, i.e. a symlink forms a loop; it relies on os.SameFile and only works for the OS file system
+func inLoop(info fs.FileInfo, ancestors []fs.FileInfo) bool {
+	for _, a := range ancestors {
+		if a != nil && os.SameFile(a, info) {
+			return true
+		}
+	}
+	return false
+}
+
+// PathFilter 按 WalkOptions 描述的 .gitignore 风格模式过滤相对路径，可用于在遍历之外做同样的判断，
+// 例如同步时只删除未被排除的远端对象
+//
+// PathFilter filters relative paths by the .gitignore-style patterns described on WalkOptions; it makes the same decisions outside a walk,
+// e.g. deleting only the remote objects that are not excluded during a sync
+type PathFilter struct {
+	include []pattern
+	exclude []pattern
+}
+
+// NewPathFilter 编译包含和排除模式
+// 参数:
+//   - include: 包含的文件模式，为空时包含所有文件
+//   - exclude: 排除的文件和目录模式
+//
+// 返回:
+//   - *PathFilter: 路径过滤器
+//   - error: 模式无效时返回包装了 path.ErrBadPattern 的错误
+//
+// NewPathFilter compiles include and exclude patterns.
+// Parameters:
+//   - include: Patterns of files to include; every file when empty
+//   - exclude: Patterns of files and directories to exclude
+//
+// Returns:
+//   - *PathFilter: The path filter
+//   - error: Returns an error wrapping path.ErrBadPattern if a pattern is invalid
+func NewPathFilter(include, exclude []string) (*PathFilter, error) {
+	f := &PathFilter{}
+	var err error
+	if f.include, err = compilePatterns(include); err != nil {
+		return nil, err
+	}
+	if f.exclude, err = compilePatterns(exclude); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Match 判断文件是否通过过滤：没有被排除（包括所在目录被排除），并且被包含
+// 参数:
+//   - rel: 相对于根目录、以 "/" 分隔的文件路径
+//
+// 返回:
+//   - bool: 是否通过
+//
+// Match reports whether a file passes the filter: it is not excluded (including through an excluded directory) and it is included.
+// Parameters:
+//   - rel: The file path relative to the root, separated by "/"
+//
+// Returns:
+//   - bool: Whether it passes
+func (f *PathFilter) Match(rel string) bool {
+	segs := strings.Split(strings.Trim(rel, "/"), "/")
+	for i := 1; i < len(segs); i++ {
+		if matchList(f.exclude, segs[:i], true) {
+			return false
+		}
+	}
+	return !matchList(f.exclude, segs, false) && f.included(strings.Join(segs, "/"))
+}
+
+// excluded 判断路径本身是否被排除，遍历时被排除的目录不会进入，因此不需要检查祖先目录
+//
+// excluded reports whether the path itself is excluded; excluded directories are not descended into during a walk, so ancestors need no check
+func (f *PathFilter) excluded(rel string, isDir bool) bool {
+	return matchList(f.exclude, strings.Split(rel, "/"), isDir)
+}
+
+// included 判断文件是否被包含，文件本身或任意祖先目录匹配即可
+//
+// included reports whether a file is included, which holds if the file or any ancestor directory matches
+func (f *PathFilter) included(rel string) bool {
+	if len(f.include) == 0 {
+		return true
+	}
+	segs := strings.Split(rel, "/")
+	for i := 1; i < len(segs); i++ {
+		if matchList(f.include, segs[:i], true) {
+			return true
+		}
+	}
+	return matchList(f.include, segs, false)
+}
+
+// pattern 编译后的模式
+//
+// pattern is a compiled pattern
+type pattern struct {
+	segs    []string
+	negate  bool
+	dirOnly bool
+}
+
+// compilePatterns 编译模式列表，跳过空行和注释
+//
+// compilePatterns compiles a list of patterns, skipping blank lines and comments
+func compilePatterns(list []string) ([]pattern, error) {
+	var out []pattern
+	for _, raw := range list {
+		s := strings.TrimSpace(raw)
+		if s == "" || strings.HasPrefix(s, "#") {
+			continue
+		}
+		var p pattern
+		s, p.negate = strings.CutPrefix(s, "!")
+		s, p.dirOnly = strings.CutSuffix(s, "/")
+		anchored := strings.Contains(s, "/")
+		s = strings.TrimPrefix(s, "/")
+		if s == "" {
+			return nil, fmt.Errorf("invalid pattern %q: %w", raw, path.ErrBadPattern)
+		}
+		p.segs = strings.Split(s, "/")
+		if !anchored {
+			p.segs = append([]string{"**"}, p.segs...)
+		}
+		for _, seg := range p.segs {
+			if _, err := path.Match(seg, ""); err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %w", raw, err)
+			}
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// matchList 按顺序匹配模式列表，最后一个匹配的模式决定结果
+//
+// matchList matches a list of patterns in order; the last matching pattern decides
+func matchList(list []pattern, segs []string, isDir bool) bool {
+	matched := false
+	for _, p := range list {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if matchSegs(p.segs, segs) {
+			matched = !p.negate
+		}
+	}
+	return matched
+}
+
+// matchSegs 逐段匹配路径，"**" 匹配任意段；结尾的 "**" 至少匹配一段，因此 "a/**" 不匹配 a 本身
+//
+// matchSegs matches a path segment by segment, "**" matching any number of segments; a trailing "**" matches at least one, so "a/**" does not match a itself
+func matchSegs(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			pat = pat[1:]
+			if len(pat) == 0 {
+				return len(segs) > 0
+			}
+			for i := range len(segs) + 1 {
+				if matchSegs(pat, segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
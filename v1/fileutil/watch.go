@@ -0,0 +1,104 @@
+// Package fileutil 提供文件相关的工具函数
+//
+// Package fileutil provides file utility functions.
+package fileutil
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/timeutil"
+)
+
+// DefaultWatchInterval Watch 默认的轮询间隔
+//
+// DefaultWatchInterval is the default polling interval of Watch
+const DefaultWatchInterval = time.Second
+
+// WatchOptions Watch 的选项
+// Interval: 轮询间隔，默认 DefaultWatchInterval
+// OnError: 获取文件信息失败（文件不存在除外）或 onChange panic 时的回调，可以为 nil
+//
+// WatchOptions contains options for Watch.
+// Interval: Polling interval, defaults to DefaultWatchInterval
+// OnError: Callback invoked when stat fails (other than the file not existing) or onChange panics, may be nil
+type WatchOptions struct {
+	Interval time.Duration
+	OnError  func(error)
+}
+
+// fileState 用于判断文件是否变化的状态
+//
+// fileState is the state used to detect file changes
+type fileState struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// Watch 轮询监听文件变化，直到 ctx 结束：修改时间或大小变化、文件被创建或删除时调用 onChange
+// 使用轮询而不是系统通知，以便在容器挂载的 ConfigMap（通过符号链接原子替换）和网络文件系统上同样可用；
+// onChange 在轮询协程中同步执行
+// 参数:
+//   - ctx: 上下文，结束后停止监听
+//   - path: 文件路径，会跟随符号链接
+//   - onChange: 文件变化时的回调
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - error: ctx.Err()
+//
+// Watch polls a file for changes until ctx is done, calling onChange when its modification time or size changes or it is created or removed.
+// Polling rather than OS notifications keeps it working with container-mounted ConfigMaps (atomically swapped symlinks) and network file systems;
+// onChange runs synchronously on the polling goroutine.
+// Parameters:
+//   - ctx: Context; watching stops when it is done
+//   - path: File path; symlinks are followed
+//   - onChange: Callback invoked on changes
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - error: ctx.Err()
+func Watch(ctx context.Context, path string, onChange func(), opts *WatchOptions) error {
+	var o WatchOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultWatchInterval
+	}
+
+	last, err := statFile(path)
+	if err != nil && o.OnError != nil {
+		o.OnError(err)
+	}
+	return timeutil.RunEvery(ctx, o.Interval, func(context.Context) error {
+		cur, err := statFile(path)
+		if err != nil {
+			return err
+		}
+		if cur == last {
+			return nil
+		}
+		last = cur
+		onChange()
+		return nil
+	}, &timeutil.RunEveryOptions{Mode: timeutil.FixedDelay, OnError: o.OnError})
+}
+
+// statFile 获取文件状态，文件不存在时返回 exists 为 false 的状态
+//
+// statFile returns the file state; a missing file yields a state with exists set to false
+func statFile(path string) (fileState, error) {
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fileState{}, nil
+	}
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{exists: true, size: fi.Size(), modTime: fi.ModTime()}, nil
+}
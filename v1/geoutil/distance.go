@@ -0,0 +1,172 @@
+// Package geoutil 提供地理位置计算相关的工具函数，例如距离计算、范围查询和 Geohash 编码
+//
+// Package geoutil provides geographic utility functions such as distance calculation, range queries and geohash encoding.
+package geoutil
+
+import (
+	"math"
+)
+
+// EarthRadius 地球平均半径（米）
+//
+// EarthRadius is the mean radius of the Earth in meters
+const EarthRadius = 6371008.8
+
+// Point 经纬度坐标（WGS-84，单位为度）
+// Lat: 纬度，范围 [-90, 90]
+// Lng: 经度，范围 [-180, 180]
+//
+// Point is a latitude/longitude coordinate (WGS-84, in degrees).
+// Lat: Latitude, range [-90, 90]
+// Lng: Longitude, range [-180, 180]
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+// Box 经纬度矩形范围
+// 当范围跨越 180 度经线时，MinLng 会大于 MaxLng
+//
+// Box is a latitude/longitude rectangle.
+// When the box crosses the 180th meridian, MinLng is greater than MaxLng.
+type Box struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// Contains 判断点是否在矩形范围内（包含边界），支持跨越 180 度经线的范围
+//
+// Contains reports whether the point is within the box (inclusive), supporting boxes crossing the 180th meridian.
+func (b Box) Contains(p Point) bool {
+	if p.Lat < b.MinLat || p.Lat > b.MaxLat {
+		return false
+	}
+	if b.MinLng <= b.MaxLng {
+		return p.Lng >= b.MinLng && p.Lng <= b.MaxLng
+	}
+	return p.Lng >= b.MinLng || p.Lng <= b.MaxLng
+}
+
+// Center 返回矩形范围的中心点
+//
+// Center returns the center point of the box.
+func (b Box) Center() Point {
+	lng := (b.MinLng + b.MaxLng) / 2
+	if b.MinLng > b.MaxLng {
+		lng = normalizeLng(lng + 180)
+	}
+	return Point{Lat: (b.MinLat + b.MaxLat) / 2, Lng: lng}
+}
+
+// HaversineDistance 使用 Haversine 公式计算两点之间的球面距离
+// 参数:
+//   - a: 起点
+//   - b: 终点
+//
+// 返回:
+//   - 两点之间的距离（米）
+//
+// HaversineDistance calculates the great-circle distance between two points using the haversine formula.
+// Parameters:
+//   - a: The start point
+//   - b: The end point
+//
+// Returns:
+//   - The distance between the two points in meters
+func HaversineDistance(a, b Point) float64 {
+	lat1 := toRadians(a.Lat)
+	lat2 := toRadians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := toRadians(b.Lng - a.Lng)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	// 浮点误差可能导致 h 略大于 1
+	h = math.Min(1, h)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(h))
+}
+
+// BoundingBox 计算以 center 为中心、radius 米为半径的圆的外接矩形
+// 常用于“附近的人”等查询的数据库预筛选，再用 HaversineDistance 精确过滤
+// 靠近极点时纬度会被截断到 [-90, 90]，经度覆盖全部范围
+// 参数:
+//   - center: 中心点
+//   - radius: 半径（米），负数按 0 处理
+//
+// 返回:
+//   - Box: 外接矩形
+//
+// BoundingBox calculates the bounding box of a circle centered at center with a radius of radius meters.
+// It is commonly used to pre-filter database queries such as "people nearby", followed by exact filtering with HaversineDistance.
+// Near the poles the latitude is clamped to [-90, 90] and the longitude covers the full range.
+// Parameters:
+//   - center: The center point
+//   - radius: Radius in meters, negative values are treated as 0
+//
+// Returns:
+//   - Box: The bounding box
+func BoundingBox(center Point, radius float64) Box {
+	if radius < 0 {
+		radius = 0
+	}
+	angular := radius / EarthRadius
+	lat := toRadians(center.Lat)
+	lng := toRadians(center.Lng)
+
+	minLat := lat - angular
+	maxLat := lat + angular
+
+	var minLng, maxLng float64
+	if minLat > -math.Pi/2 && maxLat < math.Pi/2 {
+		deltaLng := math.Asin(math.Sin(angular) / math.Cos(lat))
+		minLng = lng - deltaLng
+		maxLng = lng + deltaLng
+		// 跨越 180 度经线时保持 MinLng > MaxLng 的约定
+		if minLng < -math.Pi {
+			minLng += 2 * math.Pi
+		}
+		if maxLng > math.Pi {
+			maxLng -= 2 * math.Pi
+		}
+	} else {
+		// 范围包含极点，经度覆盖全部
+		minLat = math.Max(minLat, -math.Pi/2)
+		maxLat = math.Min(maxLat, math.Pi/2)
+		minLng = -math.Pi
+		maxLng = math.Pi
+	}
+
+	return Box{
+		MinLat: toDegrees(minLat),
+		MinLng: toDegrees(minLng),
+		MaxLat: toDegrees(maxLat),
+		MaxLng: toDegrees(maxLng),
+	}
+}
+
+// toRadians 角度转弧度
+//
+// toRadians converts degrees to radians
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// toDegrees 弧度转角度
+//
+// toDegrees converts radians to degrees
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// normalizeLng 将经度规范化到 [-180, 180)
+//
+// normalizeLng normalizes a longitude to [-180, 180)
+func normalizeLng(lng float64) float64 {
+	lng = math.Mod(lng+180, 360)
+	if lng < 0 {
+		lng += 360
+	}
+	return lng - 180
+}
@@ -0,0 +1,273 @@
+package geoutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// geohashBase32 Geohash 使用的 base32 字符表
+	//
+	// geohashBase32 is the base32 alphabet used by geohash
+	geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+	// MaxGeohashPrecision 支持的最大 Geohash 精度（字符数）
+	//
+	// MaxGeohashPrecision is the maximum supported geohash precision (number of characters)
+	MaxGeohashPrecision = 12
+)
+
+// Direction 相邻方向
+//
+// Direction is a neighbor direction
+type Direction int
+
+const (
+	// North 北
+	//
+	// North direction
+	North Direction = iota
+	// NorthEast 东北
+	//
+	// NorthEast direction
+	NorthEast
+	// East 东
+	//
+	// East direction
+	East
+	// SouthEast 东南
+	//
+	// SouthEast direction
+	SouthEast
+	// South 南
+	//
+	// South direction
+	South
+	// SouthWest 西南
+	//
+	// SouthWest direction
+	SouthWest
+	// West 西
+	//
+	// West direction
+	West
+	// NorthWest 西北
+	//
+	// NorthWest direction
+	NorthWest
+)
+
+var (
+	// ErrInvalidGeohash 表示无效的 Geohash 字符串
+	//
+	// ErrInvalidGeohash indicates an invalid geohash string.
+	ErrInvalidGeohash = errors.New("invalid geohash")
+
+	// geohashDecodeMap base32 字符到数值的映射
+	//
+	// geohashDecodeMap maps base32 characters to values
+	geohashDecodeMap = func() [256]int8 {
+		var m [256]int8
+		for i := range m {
+			m[i] = -1
+		}
+		for i := 0; i < len(geohashBase32); i++ {
+			m[geohashBase32[i]] = int8(i)
+		}
+		return m
+	}()
+)
+
+// EncodeGeohash 将坐标编码为 Geohash 字符串
+// 参数:
+//   - p: 坐标
+//   - precision: 精度（字符数），范围 1-12，超出范围时按边界值处理
+//
+// 返回:
+//   - Geohash 字符串
+//
+// EncodeGeohash encodes a coordinate into a geohash string.
+// Parameters:
+//   - p: The coordinate
+//   - precision: Precision (number of characters), range 1-12, clamped when out of range
+//
+// Returns:
+//   - The geohash string
+func EncodeGeohash(p Point, precision int) string {
+	if precision < 1 {
+		precision = 1
+	}
+	if precision > MaxGeohashPrecision {
+		precision = MaxGeohashPrecision
+	}
+
+	latMin, latMax := -90.0, 90.0
+	lngMin, lngMax := -180.0, 180.0
+
+	var b strings.Builder
+	b.Grow(precision)
+	evenBit := true
+	bit, ch := 0, 0
+	for b.Len() < precision {
+		if evenBit {
+			mid := (lngMin + lngMax) / 2
+			if p.Lng >= mid {
+				ch = ch<<1 | 1
+				lngMin = mid
+			} else {
+				ch <<= 1
+				lngMax = mid
+			}
+		} else {
+			mid := (latMin + latMax) / 2
+			if p.Lat >= mid {
+				ch = ch<<1 | 1
+				latMin = mid
+			} else {
+				ch <<= 1
+				latMax = mid
+			}
+		}
+		evenBit = !evenBit
+
+		bit++
+		if bit == 5 {
+			b.WriteByte(geohashBase32[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return b.String()
+}
+
+// DecodeGeohash 解码 Geohash 字符串
+// 参数:
+//   - hash: Geohash 字符串（不区分大小写）
+//
+// 返回:
+//   - Point: 单元格中心点
+//   - Box: 单元格范围
+//   - error: Geohash 无效时返回错误
+//
+// DecodeGeohash decodes a geohash string.
+// Parameters:
+//   - hash: The geohash string (case-insensitive)
+//
+// Returns:
+//   - Point: The center of the cell
+//   - Box: The bounds of the cell
+//   - error: Returns an error if the geohash is invalid
+func DecodeGeohash(hash string) (Point, Box, error) {
+	if hash == "" || len(hash) > MaxGeohashPrecision {
+		return Point{}, Box{}, fmt.Errorf("%w: %q", ErrInvalidGeohash, hash)
+	}
+	hash = strings.ToLower(hash)
+
+	box := Box{MinLat: -90, MaxLat: 90, MinLng: -180, MaxLng: 180}
+	evenBit := true
+	for i := 0; i < len(hash); i++ {
+		v := geohashDecodeMap[hash[i]]
+		if v < 0 {
+			return Point{}, Box{}, fmt.Errorf("%w: %q", ErrInvalidGeohash, hash)
+		}
+		for mask := 16; mask > 0; mask >>= 1 {
+			on := int(v)&mask != 0
+			if evenBit {
+				mid := (box.MinLng + box.MaxLng) / 2
+				if on {
+					box.MinLng = mid
+				} else {
+					box.MaxLng = mid
+				}
+			} else {
+				mid := (box.MinLat + box.MaxLat) / 2
+				if on {
+					box.MinLat = mid
+				} else {
+					box.MaxLat = mid
+				}
+			}
+			evenBit = !evenBit
+		}
+	}
+
+	center := Point{
+		Lat: (box.MinLat + box.MaxLat) / 2,
+		Lng: (box.MinLng + box.MaxLng) / 2,
+	}
+	return center, box, nil
+}
+
+// GeohashNeighbor 计算指定方向上相邻的 Geohash 单元格
+// 经度方向会跨越 180 度经线环绕；纬度方向到达极点时返回错误
+// 参数:
+//   - hash: Geohash 字符串
+//   - dir: 方向
+//
+// 返回:
+//   - 相邻单元格的 Geohash（与输入精度相同）
+//   - error: Geohash 无效或相邻单元格越过极点时返回错误
+//
+// GeohashNeighbor calculates the adjacent geohash cell in the given direction.
+// Longitude wraps around the 180th meridian; an error is returned when the latitude would pass a pole.
+// Parameters:
+//   - hash: The geohash string
+//   - dir: The direction
+//
+// Returns:
+//   - The geohash of the adjacent cell (same precision as the input)
+//   - error: Returns an error if the geohash is invalid or the neighbor would pass a pole
+func GeohashNeighbor(hash string, dir Direction) (string, error) {
+	center, box, err := DecodeGeohash(hash)
+	if err != nil {
+		return "", err
+	}
+	latStep := box.MaxLat - box.MinLat
+	lngStep := box.MaxLng - box.MinLng
+
+	var dLat, dLng float64
+	switch dir {
+	case North:
+		dLat = 1
+	case NorthEast:
+		dLat, dLng = 1, 1
+	case East:
+		dLng = 1
+	case SouthEast:
+		dLat, dLng = -1, 1
+	case South:
+		dLat = -1
+	case SouthWest:
+		dLat, dLng = -1, -1
+	case West:
+		dLng = -1
+	case NorthWest:
+		dLat, dLng = 1, -1
+	}
+
+	lat := center.Lat + dLat*latStep
+	if lat > 90 || lat < -90 {
+		return "", fmt.Errorf("%w: neighbor of %q crosses a pole", ErrInvalidGeohash, hash)
+	}
+	lng := normalizeLng(center.Lng + dLng*lngStep)
+	return EncodeGeohash(Point{Lat: lat, Lng: lng}, len(hash)), nil
+}
+
+// GeohashNeighbors 计算 8 个方向上相邻的 Geohash 单元格，下标与 Direction 常量对应
+// 越过极点的方向返回空字符串
+// 常用于附近搜索：查询中心单元格及其 8 个邻居，避免边界上的点被遗漏
+//
+// GeohashNeighbors calculates the adjacent geohash cells in all 8 directions, indexed by the Direction constants.
+// Directions that would pass a pole are returned as empty strings.
+// Commonly used for nearby search: query the center cell and its 8 neighbors so that points on the edges are not missed.
+func GeohashNeighbors(hash string) ([8]string, error) {
+	var out [8]string
+	if _, _, err := DecodeGeohash(hash); err != nil {
+		return out, err
+	}
+	for dir := North; dir <= NorthWest; dir++ {
+		// 越过极点的方向留空
+		out[dir], _ = GeohashNeighbor(hash, dir)
+	}
+	return out, nil
+}
@@ -0,0 +1,354 @@
+// Package graceful 提供服务的生命周期管理：按顺序启动组件，收到信号后按相反顺序优雅停止
+//
+// Package graceful provides service lifecycle management: components start in order and stop gracefully in reverse order on a signal.
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/errorutil"
+)
+
+const (
+	// DefaultStartTimeout 单个组件启动的默认超时时间
+	//
+	// DefaultStartTimeout is the default start timeout of one component
+	DefaultStartTimeout = 30 * time.Second
+	// DefaultStopTimeout 单个组件停止的默认超时时间
+	//
+	// DefaultStopTimeout is the default stop timeout of one component
+	DefaultStopTimeout = 30 * time.Second
+	// DefaultShutdownTimeout 整个停止流程的默认超时时间
+	//
+	// DefaultShutdownTimeout is the default timeout of the whole shutdown
+	DefaultShutdownTimeout = time.Minute
+)
+
+// ErrStarted 表示生命周期已经启动，不能再注册组件或重复启动
+//
+// ErrStarted indicates that the lifecycle has already started, so components can no longer be registered and it cannot start again
+var ErrStarted = errors.New("lifecycle already started")
+
+// Hook 一个组件的启动和停止函数
+// Name: 组件名称，用于错误信息
+// Start: 启动函数，必须在组件就绪后返回而不是阻塞运行，可以为 nil
+// Stop: 停止函数，应在 ctx 结束前返回，可以为 nil
+// StartTimeout: 启动超时时间，为 0 时使用 Options.StartTimeout
+// StopTimeout: 停止超时时间，为 0 时使用 Options.StopTimeout
+//
+// Hook holds a component's start and stop functions.
+// Name: Component name, used in errors
+// Start: Start function; it must return once the component is ready rather than block while running. May be nil
+// Stop: Stop function; it should return before ctx ends. May be nil
+// StartTimeout: Start timeout; Options.StartTimeout when 0
+// StopTimeout: Stop timeout; Options.StopTimeout when 0
+type Hook struct {
+	Name         string
+	Start        func(ctx context.Context) error
+	Stop         func(ctx context.Context) error
+	StartTimeout time.Duration
+	StopTimeout  time.Duration
+}
+
+// Options 生命周期选项
+// Signals: 触发停止的信号，为 nil 时使用 SIGINT 和 SIGTERM
+// StartTimeout: 组件默认的启动超时时间，为 0 时使用 DefaultStartTimeout
+// StopTimeout: 组件默认的停止超时时间，为 0 时使用 DefaultStopTimeout
+// ShutdownTimeout: 整个停止流程的超时时间，为 0 时使用 DefaultShutdownTimeout
+// ShutdownDelay: 收到信号后、开始停止组件前的等待时间，让负载均衡有时间摘除实例，默认不等待
+// OnStop: 每个组件停止后的回调，err 为停止函数的错误，可以为 nil
+//
+// Options contains lifecycle options.
+// Signals: Signals that trigger shutdown; SIGINT and SIGTERM when nil
+// StartTimeout: Default component start timeout; DefaultStartTimeout when 0
+// StopTimeout: Default component stop timeout; DefaultStopTimeout when 0
+// ShutdownTimeout: Timeout of the whole shutdown; DefaultShutdownTimeout when 0
+// ShutdownDelay: Wait after the signal and before stopping components, giving load balancers time to deregister the instance; no wait by default
+// OnStop: Callback after each component stops, with the stop function's error; may be nil
+type Options struct {
+	Signals         []os.Signal
+	StartTimeout    time.Duration
+	StopTimeout     time.Duration
+	ShutdownTimeout time.Duration
+	ShutdownDelay   time.Duration
+	OnStop          func(name string, err error)
+}
+
+// Lifecycle 管理一组组件的启动和停止：按注册顺序启动，按相反顺序停止，使依赖方先于被依赖方停止；并发安全
+//
+// Lifecycle manages the start and stop of a set of components: they start in registration order and stop in reverse, so dependents stop before their dependencies. It is safe for concurrent use.
+type Lifecycle struct {
+	o Options
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started int
+	running bool
+
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+	failErr      error
+}
+
+// New 创建生命周期管理器
+// 参数:
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *Lifecycle: 生命周期管理器
+//
+// New creates a lifecycle manager.
+// Parameters:
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *Lifecycle: The lifecycle manager
+func New(opts *Options) *Lifecycle {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Signals == nil {
+		o.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	if o.StartTimeout <= 0 {
+		o.StartTimeout = DefaultStartTimeout
+	}
+	if o.StopTimeout <= 0 {
+		o.StopTimeout = DefaultStopTimeout
+	}
+	if o.ShutdownTimeout <= 0 {
+		o.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	return &Lifecycle{o: o, shutdown: make(chan struct{})}
+}
+
+// Append 注册组件，必须在 Start 之前调用
+//
+// Append registers a component; it must be called before Start.
+func (l *Lifecycle) Append(h Hook) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.running {
+		return ErrStarted
+	}
+	l.hooks = append(l.hooks, h)
+	return nil
+}
+
+// Start 按注册顺序启动所有组件，某个组件启动失败时按相反顺序停止已启动的组件并返回错误
+// 参数:
+//   - ctx: 上下文，每个组件的启动超时时间从它派生
+//
+// 返回:
+//   - error: 启动失败的组件错误，已启动组件的停止错误会一并合并
+//
+// Start starts every component in registration order; if one fails, the components already started are stopped in reverse order and the error is returned.
+// Parameters:
+//   - ctx: Context from which each component's start timeout is derived
+//
+// Returns:
+//   - error: The failing component's error, joined with any stop errors of the components already started
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	if l.running {
+		l.mu.Unlock()
+		return ErrStarted
+	}
+	l.running = true
+	hooks := l.hooks
+	l.mu.Unlock()
+
+	for i, h := range hooks {
+		if h.Start != nil {
+			timeout := h.StartTimeout
+			if timeout <= 0 {
+				timeout = l.o.StartTimeout
+			}
+			hctx, cancel := context.WithTimeout(ctx, timeout)
+			err := errorutil.Recover(func() error { return h.Start(hctx) })
+			cancel()
+			if err != nil {
+				err = fmt.Errorf("start %s: %w", h.Name, err)
+				stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.o.ShutdownTimeout)
+				defer cancel()
+				return errors.Join(err, l.stopHooks(stopCtx, hooks[:i]))
+			}
+		}
+		l.mu.Lock()
+		l.started = i + 1
+		l.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop 按相反顺序停止所有已启动的组件，每个组件的停止超时相互独立，一个组件失败不影响其他组件停止
+// 参数:
+//   - ctx: 上下文，限制整个停止流程
+//
+// 返回:
+//   - error: 各组件停止错误的合并
+//
+// Stop stops every started component in reverse order; each has its own stop timeout, and a failing component does not keep the others from stopping.
+// Parameters:
+//   - ctx: Context bounding the whole shutdown
+//
+// Returns:
+//   - error: The stop errors of all components, joined
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := l.hooks[:l.started]
+	l.started = 0
+	l.mu.Unlock()
+	return l.stopHooks(ctx, hooks)
+}
+
+// Shutdown 从代码中触发停止，使 Run 返回，例如在管理接口中调用
+//
+// Shutdown triggers shutdown from code, making Run return, e.g. from an admin endpoint.
+func (l *Lifecycle) Shutdown() {
+	l.shutdownOnce.Do(func() { close(l.shutdown) })
+}
+
+// Fail 报告组件在运行中发生的致命错误并触发停止，Run 会返回该错误；只记录第一次的错误
+//
+// Fail reports a fatal error of a running component and triggers shutdown; Run returns the error. Only the first error is kept.
+func (l *Lifecycle) Fail(err error) {
+	l.mu.Lock()
+	if l.failErr == nil {
+		l.failErr = err
+	}
+	l.mu.Unlock()
+	l.Shutdown()
+}
+
+// Run 启动所有组件，然后等待信号、ctx 结束、Shutdown 或 Fail，再停止所有组件
+// 停止过程中再次收到信号时立即放弃等待并返回
+// 参数:
+//   - ctx: 上下文，结束时触发停止
+//
+// 返回:
+//   - error: 启动错误，或 Fail 报告的错误与停止错误的合并；正常停止时为 nil
+//
+// Run starts every component, waits for a signal, the end of ctx, Shutdown or Fail, and then stops every component.
+// A second signal during shutdown abandons the remaining waits and returns immediately.
+// Parameters:
+//   - ctx: Context whose end triggers shutdown
+//
+// Returns:
+//   - error: The start error, or the error reported by Fail joined with the stop errors; nil on a clean shutdown
+func (l *Lifecycle) Run(ctx context.Context) error {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, l.o.Signals...)
+	defer signal.Stop(sigs)
+
+	if err := l.Start(ctx); err != nil {
+		return err
+	}
+	select {
+	case <-sigs:
+	case <-ctx.Done():
+	case <-l.shutdown:
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), l.o.ShutdownTimeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-sigs:
+			cancel()
+		case <-stopCtx.Done():
+		}
+	}()
+	if l.o.ShutdownDelay > 0 {
+		timer := time.NewTimer(l.o.ShutdownDelay)
+		select {
+		case <-timer.C:
+		case <-stopCtx.Done():
+			timer.Stop()
+		}
+	}
+	stopErr := l.Stop(stopCtx)
+
+	l.mu.Lock()
+	failErr := l.failErr
+	l.mu.Unlock()
+	return errors.Join(failErr, stopErr)
+}
+
+// HTTPServer 返回管理 http.Server 的组件：启动时同步监听端口（端口被占用等错误由 Start 返回），停止时调用 Shutdown 等待请求处理完
+// 运行中 Serve 失败时调用 Fail
+// 参数:
+//   - name: 组件名称
+//   - srv: HTTP 服务器，Addr 为空时监听 ":http"
+//
+// 返回:
+//   - Hook: 组件
+//
+// HTTPServer returns a component managing an http.Server: Start listens synchronously (so errors such as a port in use come back from Start), and Stop calls Shutdown to wait for in-flight requests.
+// If Serve fails while running, Fail is called.
+// Parameters:
+//   - name: Component name
+//   - srv: The HTTP server; ":http" is used when Addr is empty
+//
+// Returns:
+//   - Hook: The component
+func (l *Lifecycle) HTTPServer(name string, srv *http.Server) Hook {
+	return Hook{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			addr := srv.Addr
+			if addr == "" {
+				addr = ":http"
+			}
+			var lc net.ListenConfig
+			ln, err := lc.Listen(ctx, "tcp", addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					l.Fail(fmt.Errorf("%s: %w", name, err))
+				}
+			}()
+			return nil
+		},
+		Stop: srv.Shutdown,
+	}
+}
+
+// stopHooks 按相反顺序停止组件
+//
+// stopHooks stops components in reverse order
+func (l *Lifecycle) stopHooks(ctx context.Context, hooks []Hook) error {
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.Stop == nil {
+			continue
+		}
+		timeout := h.StopTimeout
+		if timeout <= 0 {
+			timeout = l.o.StopTimeout
+		}
+		hctx, cancel := context.WithTimeout(ctx, timeout)
+		err := errorutil.Recover(func() error { return h.Stop(hctx) })
+		cancel()
+		if l.o.OnStop != nil {
+			l.o.OnStop(h.Name, err)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stop %s: %w", h.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,145 @@
+package gziputil
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Format 压缩格式
+//
+// Format is a compression format
+type Format string
+
+const (
+	// FormatPlain 未压缩
+	//
+	// FormatPlain means uncompressed
+	FormatPlain Format = "plain"
+	// FormatGzip gzip 格式
+	//
+	// FormatGzip is the gzip format
+	FormatGzip Format = "gzip"
+	// FormatZstd zstd 格式
+	//
+	// FormatZstd is the zstd format
+	FormatZstd Format = "zstd"
+)
+
+var (
+	// ErrDecompress 表示创建解压缩读取器失败
+	//
+	// ErrDecompress indicates that creating a decompressing reader failed.
+	ErrDecompress = errors.New("decompression failed")
+
+	// zstdMagic zstd 帧的魔数
+	//
+	// zstdMagic is the magic number of a zstd frame
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// IsZstd 检查数据是否为 zstd 格式
+// 通过检查数据的魔数（0x28 0xb5 0x2f 0xfd）来判断
+//
+// IsZstd checks whether the data is in zstd format.
+// It determines by checking the magic number (0x28 0xb5 0x2f 0xfd) of the data.
+func IsZstd(data []byte) bool {
+	return bytes.HasPrefix(data, zstdMagic)
+}
+
+// DetectFormat 根据魔数判断数据的压缩格式
+//
+// DetectFormat determines the compression format of the data by its magic number.
+func DetectFormat(data []byte) Format {
+	switch {
+	case IsGzipped(data):
+		return FormatGzip
+	case IsZstd(data):
+		return FormatZstd
+	}
+	return FormatPlain
+}
+
+// AutoReader 自动识别压缩格式的读取器，读取到的始终是解压后的数据
+//
+// AutoReader is a reader that detects the compression format automatically; it always yields decompressed data.
+type AutoReader struct {
+	r      io.Reader
+	format Format
+	close  func() error
+}
+
+// NewAutoReader 通过魔数识别 gzip、zstd 或未压缩的数据流，返回透明解压的读取器
+// 用于同时接收压缩和原始数据的场景，调用方无需区分
+// 参数:
+//   - r: 源数据流
+//
+// 返回:
+//   - *AutoReader: 读取器，使用完毕后需要调用 Close（不会关闭 r）
+//   - error: 如果读取数据流头部或创建解压缩器失败，返回错误
+//
+// NewAutoReader detects gzip, zstd or uncompressed streams by magic number and returns a transparently decompressing reader.
+// It lets ingestion code accept both compressed and raw streams with one code path.
+// Parameters:
+//   - r: The source stream
+//
+// Returns:
+//   - *AutoReader: The reader; call Close when done (r itself is not closed)
+//   - error: Returns an error if reading the stream header or creating the decompressor fails
+func NewAutoReader(r io.Reader) (*AutoReader, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%w: %v", ErrDecompress, err)
+	}
+
+	ar := &AutoReader{format: DetectFormat(head)}
+	switch ar.format {
+	case FormatGzip:
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDecompress, err)
+		}
+		ar.r, ar.close = gr, gr.Close
+	case FormatZstd:
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrDecompress, err)
+		}
+		ar.r = zr
+		ar.close = func() error {
+			zr.Close()
+			return nil
+		}
+	default:
+		ar.r = br
+		ar.close = func() error { return nil }
+	}
+	return ar, nil
+}
+
+// Read 读取解压后的数据
+//
+// Read reads decompressed data.
+func (a *AutoReader) Read(p []byte) (int, error) {
+	return a.r.Read(p)
+}
+
+// Format 返回识别出的压缩格式
+//
+// Format returns the detected compression format.
+func (a *AutoReader) Format() Format {
+	return a.format
+}
+
+// Close 释放解压缩器的资源，不会关闭源数据流
+//
+// Close releases the decompressor's resources; it does not close the source stream.
+func (a *AutoReader) Close() error {
+	return a.close()
+}
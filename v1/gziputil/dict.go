@@ -0,0 +1,294 @@
+package gziputil
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/supergodk/go-utils/v1/maputil"
+)
+
+const (
+	// DefaultDictSize TrainDict 默认的字典大小上限
+	//
+	// DefaultDictSize is the default dictionary size limit for TrainDict
+	DefaultDictSize = 16 << 10
+
+	// maxCachedDictCodecs CompressWithDict 和 DecompressWithDict 最多缓存的编解码器数量
+	//
+	// maxCachedDictCodecs is the maximum number of codecs cached by CompressWithDict and DecompressWithDict
+	maxCachedDictCodecs = 16
+)
+
+var (
+	// ErrDict 表示字典无效，或数据无法用该字典压缩、解压
+	//
+	// ErrDict indicates that the dictionary is invalid, or the data cannot be compressed or decompressed with it.
+	ErrDict = errors.New("compression dictionary failed")
+
+	// zstdDictMagic zstd 字典格式的魔数
+	//
+	// zstdDictMagic is the magic number of the zstd dictionary format
+	zstdDictMagic = []byte{0x37, 0xa4, 0x30, 0xec}
+
+	// dictCodecs CompressWithDict 和 DecompressWithDict 按字典内容缓存的编解码器，最多 maxCachedDictCodecs 个
+	// 被淘汰的编解码器可能仍在其他 goroutine 中使用，因此不调用 Close，由垃圾回收释放
+	//
+	// dictCodecs caches the codecs used by CompressWithDict and DecompressWithDict, keyed by dictionary content, up to maxCachedDictCodecs of them.
+	// Evicted codecs may still be in use by other goroutines, so they are not closed but left to the garbage collector
+	dictCodecs = maputil.NewLRU[string, *DictCodec](maxCachedDictCodecs, nil)
+)
+
+// TrainDict 从样本中构建 zstd 字典，适用于大量相似的小数据（例如按用户存储的 JSON），字典需要与数据一起长期保存
+// 样本越接近实际数据效果越好，通常几百到几千个样本即可；较晚出现的样本优先放入字典内容；构建可能需要数秒，应离线进行
+// 样本总量太小、无法统计出 zstd 字典时（例如总大小不超过 maxSize），返回由样本拼接成的原始内容字典，同样可用于 CompressWithDict 和 NewDictCodec
+// 参数:
+//   - samples: 样本数据
+//   - maxSize: 字典大小上限（字节），小于等于 0 时使用 DefaultDictSize
+//
+// 返回:
+//   - []byte: zstd 格式的字典，可用于 CompressWithDict 和 NewDictCodec，也可被 zstd 命令行工具使用；回退时是原始内容字典
+//   - error: 样本为空时返回 ErrDict
+//
+// TrainDict builds a zstd dictionary from samples, for many small similar payloads (such as JSON stored per user); keep the dictionary as long as the data.
+// The closer the samples are to real data the better; a few hundred to a few thousand usually suffice. Later samples take precedence for the dictionary content. Building can take seconds, so do it offline.
+// When the samples are too small to build a zstd dictionary from (e.g. their total size does not exceed maxSize), the concatenated samples are returned as a raw content dictionary, which also works with CompressWithDict and NewDictCodec.
+// Parameters:
+//   - samples: Sample data
+//   - maxSize: Dictionary size limit in bytes; DefaultDictSize is used when not positive
+//
+// Returns:
+//   - []byte: A dictionary in zstd format, usable with CompressWithDict, NewDictCodec and the zstd command line tool; a raw content dictionary on fallback
+//   - error: Returns ErrDict if the samples are empty
+func TrainDict(samples [][]byte, maxSize int) ([]byte, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultDictSize
+	}
+	// 从后往前取样本作为字典内容，zstd 对靠近字典末尾的内容引用代价更低
+	var parts [][]byte
+	size := 0
+	for i := len(samples) - 1; i >= 0 && size < maxSize; i-- {
+		s := samples[i]
+		if len(s) > maxSize-size {
+			s = s[len(s)-(maxSize-size):]
+		}
+		parts = append(parts, s)
+		size += len(s)
+	}
+	history := make([]byte, 0, size)
+	for i := len(parts) - 1; i >= 0; i-- {
+		history = append(history, parts[i]...)
+	}
+
+	if len(history) == 0 {
+		return nil, fmt.Errorf("%w: no sample data", ErrDict)
+	}
+
+	dict, err := zstd.BuildDict(zstd.BuildDictOptions{
+		ID:       dictID(history),
+		Contents: samples,
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+	})
+	if err != nil {
+		// 字典内容取自样本本身，样本全部放进字典内容后就没有可统计的字面量，此时直接使用原始内容字典；
+		// 以 zstd 字典魔数开头的内容会被 NewDictCodec 当作 zstd 字典解析，不能回退
+		if bytes.HasPrefix(history, zstdDictMagic) {
+			return nil, fmt.Errorf("%w: %v", ErrDict, err)
+		}
+		return history, nil
+	}
+	return dict, nil
+}
+
+// DictCodec 使用固定字典的 zstd 编解码器，并发安全，创建开销较大，应长期复用
+//
+// DictCodec is a zstd codec with a fixed dictionary; it is safe for concurrent use and costly to create, so reuse it.
+type DictCodec struct {
+	enc *zstd.Encoder
+	dec *zstd.Decoder
+}
+
+// NewDictCodec 创建使用 dict 的编解码器
+// 参数:
+//   - dict: TrainDict 生成的 zstd 字典；不是 zstd 字典格式时作为原始内容字典使用，例如一份典型的 JSON 样本
+//
+// 返回:
+//   - *DictCodec: 编解码器，不再使用时调用 Close
+//   - error: 字典无效时返回 ErrDict
+//
+// NewDictCodec creates a codec using dict.
+// Parameters:
+//   - dict: A zstd dictionary from TrainDict; anything not in the zstd dictionary format is used as a raw content dictionary, e.g. a typical JSON sample
+//
+// Returns:
+//   - *DictCodec: The codec; call Close when done with it
+//   - error: Returns ErrDict if the dictionary is invalid
+func NewDictCodec(dict []byte) (*DictCodec, error) {
+	if len(dict) == 0 {
+		return nil, fmt.Errorf("%w: empty dictionary", ErrDict)
+	}
+	var (
+		eopt zstd.EOption
+		dopt zstd.DOption
+	)
+	if bytes.HasPrefix(dict, zstdDictMagic) {
+		eopt, dopt = zstd.WithEncoderDict(dict), zstd.WithDecoderDicts(dict)
+	} else {
+		id := dictID(dict)
+		eopt, dopt = zstd.WithEncoderDictRaw(id, dict), zstd.WithDecoderDictRaw(id, dict)
+	}
+
+	enc, err := zstd.NewWriter(nil, eopt)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDict, err)
+	}
+	dec, err := zstd.NewReader(nil, dopt, zstd.WithDecoderConcurrency(0))
+	if err != nil {
+		enc.Close()
+		return nil, fmt.Errorf("%w: %v", ErrDict, err)
+	}
+	return &DictCodec{enc: enc, dec: dec}, nil
+}
+
+// Compress 使用字典压缩数据，输出为标准 zstd 帧（帧头记录字典 ID）
+//
+// Compress compresses the data with the dictionary, producing a standard zstd frame (the frame header records the dictionary ID).
+func (c *DictCodec) Compress(input []byte) []byte {
+	return c.enc.EncodeAll(input, nil)
+}
+
+// Decompress 使用字典解压 Compress 的输出
+//
+// Decompress decompresses the output of Compress with the dictionary.
+func (c *DictCodec) Decompress(input []byte) ([]byte, error) {
+	out, err := c.dec.DecodeAll(input, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDict, err)
+	}
+	return out, nil
+}
+
+// Close 释放编解码器占用的资源
+//
+// Close releases the codec's resources.
+func (c *DictCodec) Close() {
+	c.enc.Close()
+	c.dec.Close()
+}
+
+// CompressWithDict 使用字典进行 zstd 压缩，编解码器按字典内容缓存（最多 16 个，最久未使用的被淘汰），适用于少量长期使用的字典，参见 NewDictCodec
+// 参数:
+//   - input: 要压缩的数据
+//   - dict: 字典
+//
+// 返回:
+//   - []byte: zstd 压缩数据
+//   - error: 字典无效时返回 ErrDict
+//
+// CompressWithDict compresses with zstd using the dictionary; codecs are cached by dictionary content (up to 16, evicting the least recently used), which suits a few long-lived dictionaries. See NewDictCodec.
+// Parameters:
+//   - input: The data to compress
+//   - dict: The dictionary
+//
+// Returns:
+//   - []byte: The zstd compressed data
+//   - error: Returns ErrDict if the dictionary is invalid
+func CompressWithDict(input, dict []byte) ([]byte, error) {
+	c, err := cachedDictCodec(dict)
+	if err != nil {
+		return nil, err
+	}
+	return c.Compress(input), nil
+}
+
+// DecompressWithDict 使用字典解压 CompressWithDict 的输出，字典必须与压缩时相同
+//
+// DecompressWithDict decompresses the output of CompressWithDict; the dictionary must be the one used for compression.
+func DecompressWithDict(input, dict []byte) ([]byte, error) {
+	c, err := cachedDictCodec(dict)
+	if err != nil {
+		return nil, err
+	}
+	return c.Decompress(input)
+}
+
+// DeflateWithDict 使用预置字典进行 deflate 压缩（RFC 1951 原始格式，没有 gzip 头），适用于只能使用标准库或需要与 zlib 互通的场景
+// 字典的最后 32KB 有效，应把最常见的内容放在末尾
+// 参数:
+//   - input: 要压缩的数据
+//   - dict: 预置字典，例如一份典型的 JSON 样本
+//   - level: 压缩级别，超出范围时使用默认级别
+//
+// 返回:
+//   - []byte: 压缩数据
+//   - error: 如果压缩失败，返回 ErrDict
+//
+// DeflateWithDict compresses with deflate using a preset dictionary (raw RFC 1951, no gzip header), for when only the standard library is available or zlib interop is needed.
+// Only the last 32KB of the dictionary are used, so put the most common content at the end.
+// Parameters:
+//   - input: The data to compress
+//   - dict: The preset dictionary, e.g. a typical JSON sample
+//   - level: Compression level; the default is used when out of range
+//
+// Returns:
+//   - []byte: The compressed data
+//   - error: Returns ErrDict if compression fails
+func DeflateWithDict(input, dict []byte, level int) ([]byte, error) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		level = flate.DefaultCompression
+	}
+	var buf bytes.Buffer
+	w, err := flate.NewWriterDict(&buf, level, dict)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDict, err)
+	}
+	if _, err := w.Write(input); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDict, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDict, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// InflateWithDict 使用预置字典解压 DeflateWithDict 的输出，字典必须与压缩时相同
+//
+// InflateWithDict decompresses the output of DeflateWithDict; the dictionary must be the one used for compression.
+func InflateWithDict(input, dict []byte) ([]byte, error) {
+	r := flate.NewReaderDict(bytes.NewReader(input), dict)
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDict, err)
+	}
+	return out, nil
+}
+
+// cachedDictCodec 返回缓存的编解码器，不存在时创建
+//
+// cachedDictCodec returns the cached codec, creating it if absent
+func cachedDictCodec(dict []byte) (*DictCodec, error) {
+	if c, ok := dictCodecs.Get(string(dict)); ok {
+		return c, nil
+	}
+	c, err := NewDictCodec(dict)
+	if err != nil {
+		return nil, err
+	}
+	// 并发创建时后写入的覆盖先写入的，被覆盖的编解码器同样交给垃圾回收
+	dictCodecs.Set(string(dict), c)
+	return c, nil
+}
+
+// dictID 由字典内容计算字典 ID，避开 zstd 保留的 0～32767 和 2^31 以上的范围
+//
+// dictID computes a dictionary ID from its content, avoiding the ranges 0-32767 and above 2^31 reserved by zstd
+func dictID(content []byte) uint32 {
+	const low, high = 1 << 15, 1 << 31
+	return low + crc32.ChecksumIEEE(content)%(high-low)
+}
@@ -0,0 +1,154 @@
+package gziputil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// DefaultParallelBlockSize GzipParallel 默认的分块大小
+//
+// DefaultParallelBlockSize is the default block size for GzipParallel
+const DefaultParallelBlockSize = 1 << 20
+
+// gzipBlock 一个分块的压缩结果
+//
+// gzipBlock is the compression result of one block
+type gzipBlock struct {
+	data []byte
+	err  error
+}
+
+// GzipParallel 将 r 按块并发压缩后写入 w，输出为标准的多成员 gzip 流（每块一个成员），可被 UnGzip、gzip 命令行工具等任何 gzip 读取器完整解压
+// 块之间不共享压缩上下文，压缩率比串行压缩略低（块越大越接近），适用于几百 MB 的日志文件等大数据
+// 参数:
+//   - w: 输出目标
+//   - r: 输入数据
+//   - level: 压缩级别，超出范围时使用默认级别
+//   - blockSize: 分块大小（字节），小于等于 0 时使用 DefaultParallelBlockSize
+//   - workers: 并发数，小于等于 0 时使用 GOMAXPROCS；内存占用约为 2 * workers * blockSize
+//
+// 返回:
+//   - error: 读取、压缩或写入失败时返回错误，压缩失败为 ErrGzipCompress
+//
+// GzipParallel compresses r block by block concurrently and writes to w, producing a standard multi-member gzip stream (one member per block) that UnGzip, the gzip command line tool and any other gzip reader decompress in full.
+// Blocks share no compression context, so the ratio is slightly below serial compression (closer with larger blocks); it suits large data such as log files of several hundred MB.
+// Parameters:
+//   - w: Output destination
+//   - r: Input data
+//   - level: Compression level; the default is used when out of range
+//   - blockSize: Block size in bytes; DefaultParallelBlockSize is used when not positive
+//   - workers: Concurrency; GOMAXPROCS is used when not positive. Memory use is about 2 * workers * blockSize
+//
+// Returns:
+//   - error: Returns an error if reading, compressing or writing fails; compression failures are ErrGzipCompress
+func GzipParallel(w io.Writer, r io.Reader, level, blockSize, workers int) error {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	if blockSize <= 0 {
+		blockSize = DefaultParallelBlockSize
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	writers := sync.Pool{New: func() any {
+		// level 已校验，不会出错
+		zw, _ := gzip.NewWriterLevel(nil, level)
+		return zw
+	}}
+	compress := func(data []byte) gzipBlock {
+		var buf bytes.Buffer
+		buf.Grow(len(data)/2 + 64)
+		zw := writers.Get().(*gzip.Writer)
+		defer writers.Put(zw)
+		zw.Reset(&buf)
+		if _, err := zw.Write(data); err != nil {
+			return gzipBlock{err: fmt.Errorf("%w: %v", ErrGzipCompress, err)}
+		}
+		if err := zw.Close(); err != nil {
+			return gzipBlock{err: fmt.Errorf("%w: %v", ErrGzipCompress, err)}
+		}
+		return gzipBlock{data: buf.Bytes()}
+	}
+
+	// results 按输入顺序排列的结果通道，容量限制了在途的块数
+	results := make(chan chan gzipBlock, workers)
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		defer close(results)
+		sem := make(chan struct{}, workers)
+		for {
+			buf := make([]byte, blockSize)
+			n, err := io.ReadFull(r, buf)
+			if n > 0 {
+				res := make(chan gzipBlock, 1)
+				select {
+				case results <- res:
+				case <-done:
+					return
+				}
+				select {
+				case sem <- struct{}{}:
+				case <-done:
+					return
+				}
+				go func(data []byte) {
+					defer func() { <-sem }()
+					res <- compress(data)
+				}(buf[:n])
+			}
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return
+			}
+			if err != nil {
+				res := make(chan gzipBlock, 1)
+				res <- gzipBlock{err: err}
+				select {
+				case results <- res:
+				case <-done:
+				}
+				return
+			}
+		}
+	}()
+
+	wrote := false
+	for res := range results {
+		b := <-res
+		if b.err != nil {
+			return b.err
+		}
+		if _, err := w.Write(b.data); err != nil {
+			return err
+		}
+		wrote = true
+	}
+	if !wrote {
+		// 空输入也输出一个空成员，保证结果是合法的 gzip 流
+		b := compress(nil)
+		if b.err != nil {
+			return b.err
+		}
+		if _, err := w.Write(b.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GzipParallelBytes 与 GzipParallel 相同，但输入和输出都是字节切片
+//
+// GzipParallelBytes is like GzipParallel but takes and returns byte slices.
+func GzipParallelBytes(input []byte, level, blockSize, workers int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := GzipParallel(&buf, bytes.NewReader(input), level, blockSize, workers); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
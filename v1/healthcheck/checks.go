@@ -0,0 +1,90 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/timeutil"
+)
+
+// ClockDriftCheck 返回检查本地时钟偏差的检查函数，偏差超过 threshold 时失败
+// 时钟偏差会导致 JWT、签名 URL 和许可证的时间校验出错；NTP 查询本身较慢，建议配合 RunBackground 使用
+// 参数:
+//   - servers: NTP 服务器，为空时使用 timeutil.DefaultNTPServers
+//   - threshold: 阈值，小于等于 0 时使用 timeutil.DefaultDriftThreshold
+//
+// ClockDriftCheck returns a check of the local clock drift that fails when the drift exceeds threshold.
+// Clock drift breaks time validation of JWTs, signed URLs and licenses; NTP queries are slow, so prefer using it with RunBackground.
+// Parameters:
+//   - servers: NTP servers; timeutil.DefaultNTPServers when empty
+//   - threshold: The threshold; timeutil.DefaultDriftThreshold when not positive
+func ClockDriftCheck(servers []string, threshold time.Duration) CheckFunc {
+	if threshold <= 0 {
+		threshold = timeutil.DefaultDriftThreshold
+	}
+	return func(ctx context.Context) error {
+		drift, err := timeutil.CheckClockDrift(ctx, servers)
+		if err != nil {
+			return err
+		}
+		if drift.Offset > threshold || drift.Offset < -threshold {
+			return fmt.Errorf("clock drift %v exceeds %v", drift.Offset, threshold)
+		}
+		return nil
+	}
+}
+
+// FreshnessCheck 返回检查数据新鲜度的检查函数，例如 JWKS 公钥或配置最后一次刷新的时间；从未刷新（零值）或超过 maxAge 时失败
+// 参数:
+//   - lastUpdated: 返回最后一次刷新时间的函数，例如 cryptoutil.AppleKeysFetchedAt
+//   - maxAge: 允许的最大时长
+//
+// FreshnessCheck returns a check of data freshness, e.g. when JWKS keys or configuration were last refreshed; it fails when never refreshed (zero time) or older than maxAge.
+// Parameters:
+//   - lastUpdated: Function returning the last refresh time, e.g. cryptoutil.AppleKeysFetchedAt
+//   - maxAge: The maximum allowed age
+func FreshnessCheck(lastUpdated func() time.Time, maxAge time.Duration) CheckFunc {
+	return func(context.Context) error {
+		t := lastUpdated()
+		if t.IsZero() {
+			return errors.New("never updated")
+		}
+		if age := time.Since(t); age > maxAge {
+			return fmt.Errorf("last updated %v ago, more than %v", age.Truncate(time.Second), maxAge)
+		}
+		return nil
+	}
+}
+
+// HTTPCheck 返回检查 HTTP 端点可达性的检查函数，响应状态码不是 2xx 或 3xx 时失败
+// 参数:
+//   - client: HTTP 客户端，为 nil 时使用 http.DefaultClient
+//   - url: 端点地址
+//
+// HTTPCheck returns a check of an HTTP endpoint's reachability that fails unless the status code is 2xx or 3xx.
+// Parameters:
+//   - client: HTTP client; http.DefaultClient when nil
+//   - url: The endpoint URL
+func HTTPCheck(client *http.Client, url string) CheckFunc {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusBadRequest {
+			return fmt.Errorf("unexpected status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
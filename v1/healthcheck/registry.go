@@ -0,0 +1,369 @@
+// Package healthcheck 提供存活和就绪检查：各模块向 CheckRegistry 注册命名的检查，由 HTTP 处理器以 JSON 输出状态，并支持后台定期检查和结果缓存
+//
+// Package healthcheck provides liveness and readiness checks: modules register named checks with a CheckRegistry, HTTP handlers report the status as JSON,
+// and checks can be evaluated periodically in the background with cached results.
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/errorutil"
+	"github.com/supergodk/go-utils/v1/timeutil"
+)
+
+const (
+	// DefaultCheckTimeout 单个检查默认的超时时间
+	//
+	// DefaultCheckTimeout is the default timeout of a single check
+	DefaultCheckTimeout = 5 * time.Second
+	// DefaultCacheTTL 检查结果默认的缓存时长
+	//
+	// DefaultCacheTTL is the default duration check results are cached for
+	DefaultCacheTTL = 10 * time.Second
+)
+
+// Status 检查状态
+//
+// Status is a check status.
+type Status string
+
+const (
+	// StatusUp 正常
+	//
+	// StatusUp means healthy
+	StatusUp Status = "up"
+	// StatusDegraded 只有可选检查失败
+	//
+	// StatusDegraded means only optional checks failed
+	StatusDegraded Status = "degraded"
+	// StatusDown 有必需的检查失败
+	//
+	// StatusDown means a required check failed
+	StatusDown Status = "down"
+)
+
+// CheckFunc 检查函数，返回 nil 表示正常；应遵守 ctx 的超时
+//
+// CheckFunc is a check function; nil means healthy. It should honor the ctx deadline.
+type CheckFunc func(ctx context.Context) error
+
+// CheckOptions 检查的选项
+// Timeout: 超时时间，默认 DefaultCheckTimeout
+// Optional: 是否可选，可选检查失败时整体状态为 StatusDegraded，就绪检查仍返回 200
+// Liveness: 是否参与存活检查；存活检查失败通常会导致进程被重启，只应包含进程自身无法恢复的问题
+//
+// CheckOptions contains options for a check.
+// Timeout: Timeout, defaults to DefaultCheckTimeout
+// Optional: Whether the check is optional; failing optional checks make the overall status StatusDegraded and readiness still answers 200
+// Liveness: Whether the check takes part in liveness; failed liveness usually restarts the process, so only include problems the process cannot recover from
+type CheckOptions struct {
+	Timeout  time.Duration
+	Optional bool
+	Liveness bool
+}
+
+// CheckResult 单个检查的结果
+// Status: 状态
+// Error: 失败原因
+// Latency: 耗时
+// CheckedAt: 检查时间
+// Optional: 是否可选
+//
+// CheckResult is the result of a single check.
+// Status: The status
+// Error: The failure reason
+// Latency: How long the check took
+// CheckedAt: When the check ran
+// Optional: Whether the check is optional
+type CheckResult struct {
+	Status    Status        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Latency   time.Duration `json:"-"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Optional  bool          `json:"optional,omitempty"`
+}
+
+// MarshalJSON 额外输出以毫秒为单位的 latency_ms
+//
+// MarshalJSON additionally emits latency_ms in milliseconds.
+func (r CheckResult) MarshalJSON() ([]byte, error) {
+	type plain CheckResult
+	return json.Marshal(struct {
+		plain
+		LatencyMs float64 `json:"latency_ms"`
+	}{plain(r), float64(r.Latency.Microseconds()) / 1000})
+}
+
+// Report 检查报告
+// Status: 整体状态
+// Checks: 各检查的结果
+//
+// Report is a health report.
+// Status: The overall status
+// Checks: Results by check name
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// RegistryOptions CheckRegistry 的选项
+// CacheTTL: 处理器复用最近一次检查结果的时长，默认 DefaultCacheTTL，避免频繁的探针请求压垮依赖的服务
+//
+// RegistryOptions contains options for a CheckRegistry.
+// CacheTTL: How long the handlers reuse the latest results, defaults to DefaultCacheTTL, so frequent probes do not overload dependencies
+type RegistryOptions struct {
+	CacheTTL time.Duration
+}
+
+// check 已注册的检查
+//
+// check is a registered check
+type check struct {
+	fn   CheckFunc
+	opts CheckOptions
+}
+
+// CheckRegistry 检查注册表，并发安全
+//
+// CheckRegistry is a registry of checks; it is safe for concurrent use.
+type CheckRegistry struct {
+	cacheTTL time.Duration
+
+	mu      sync.Mutex
+	checks  map[string]*check
+	results map[string]CheckResult
+	lastRun time.Time
+	// runMu 保证同一时间只有一轮检查在执行
+	runMu sync.Mutex
+}
+
+// NewCheckRegistry 创建检查注册表
+//
+// NewCheckRegistry creates a check registry.
+func NewCheckRegistry(opts *RegistryOptions) *CheckRegistry {
+	var o RegistryOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.CacheTTL <= 0 {
+		o.CacheTTL = DefaultCacheTTL
+	}
+	return &CheckRegistry{
+		cacheTTL: o.CacheTTL,
+		checks:   make(map[string]*check),
+		results:  make(map[string]CheckResult),
+	}
+}
+
+// Register 注册检查，同名的检查会被替换
+// 参数:
+//   - name: 检查名称，例如 "oss"、"jwks"、"clock"
+//   - fn: 检查函数
+//   - opts: 选项，可以为 nil
+//
+// Register registers a check, replacing any check with the same name.
+// Parameters:
+//   - name: Check name, e.g. "oss", "jwks", "clock"
+//   - fn: The check function
+//   - opts: Options, may be nil
+func (r *CheckRegistry) Register(name string, fn CheckFunc, opts *CheckOptions) {
+	var o CheckOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultCheckTimeout
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks[name] = &check{fn: fn, opts: o}
+	delete(r.results, name)
+	r.lastRun = time.Time{}
+}
+
+// Unregister 移除检查
+//
+// Unregister removes a check.
+func (r *CheckRegistry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.checks, name)
+	delete(r.results, name)
+}
+
+// Run 立即并发执行所有检查并更新缓存
+//
+// Run runs all checks concurrently right away and updates the cache.
+func (r *CheckRegistry) Run(ctx context.Context) *Report {
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+	return r.run(ctx)
+}
+
+// run 执行所有检查，调用方需持有 runMu
+//
+// run runs all checks; the caller must hold runMu
+func (r *CheckRegistry) run(ctx context.Context) *Report {
+	r.mu.Lock()
+	checks := maps.Clone(r.checks)
+	r.mu.Unlock()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(checks))
+	)
+	for name, c := range checks {
+		wg.Go(func() {
+			res := runCheck(ctx, c)
+			mu.Lock()
+			results[name] = res
+			mu.Unlock()
+		})
+	}
+	wg.Wait()
+
+	r.mu.Lock()
+	for name, res := range results {
+		// 执行期间被移除或替换的检查不写入缓存
+		if r.checks[name] == checks[name] {
+			r.results[name] = res
+		}
+	}
+	r.lastRun = time.Now()
+	r.mu.Unlock()
+	return newReport(results, false)
+}
+
+// Report 返回检查报告：缓存未过期时直接使用缓存，否则立即执行检查
+// 参数:
+//   - ctx: 上下文，只传递其中的值；刷新缓存时不受它的取消影响，每个检查只受自身 Timeout 限制，避免探针断开或超时把失败结果写入缓存
+//   - liveness: 为 true 时只包含参与存活检查的检查
+//
+// Report returns a health report: cached results are used while fresh, otherwise the checks run right away.
+// Parameters:
+//   - ctx: Context, used only for its values; refreshing the cache ignores its cancellation and each check is bound by its own Timeout, so a disconnected or timed-out probe does not cache failures
+//   - liveness: When true, only checks taking part in liveness are included
+func (r *CheckRegistry) Report(ctx context.Context, liveness bool) *Report {
+	if !r.fresh() {
+		r.runMu.Lock()
+		// 并发的探针请求只执行一轮检查
+		if !r.fresh() {
+			r.run(context.WithoutCancel(ctx))
+		}
+		r.runMu.Unlock()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make(map[string]CheckResult, len(r.results))
+	for name, res := range r.results {
+		if !liveness || r.checks[name].opts.Liveness {
+			results[name] = res
+		}
+	}
+	return newReport(results, liveness)
+}
+
+// fresh 判断缓存是否未过期
+//
+// fresh reports whether the cache is still fresh
+func (r *CheckRegistry) fresh() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !r.lastRun.IsZero() && time.Since(r.lastRun) < r.cacheTTL
+}
+
+// RunBackground 立即执行一次检查，然后按 interval 定期执行，直到 ctx 结束；interval 应小于 CacheTTL，使处理器总是读取缓存
+//
+// RunBackground runs the checks once right away and then every interval until ctx is done; keep interval below CacheTTL so the handlers always read the cache.
+func (r *CheckRegistry) RunBackground(ctx context.Context, interval time.Duration) error {
+	return timeutil.RunEvery(ctx, interval, func(ctx context.Context) error {
+		r.Run(ctx)
+		return nil
+	}, &timeutil.RunEveryOptions{Immediate: true, Mode: timeutil.FixedDelay})
+}
+
+// Handler 返回就绪检查的处理器：整体状态为 StatusDown 时返回 503，否则返回 200，响应体为 JSON 格式的 Report
+//
+// Handler returns the readiness handler: 503 when the overall status is StatusDown, 200 otherwise, with the Report as a JSON body.
+func (r *CheckRegistry) Handler() http.Handler {
+	return r.handler(false)
+}
+
+// LivenessHandler 返回存活检查的处理器：只包含 Liveness 为 true 的检查，没有这类检查时总是返回 200
+//
+// LivenessHandler returns the liveness handler: only checks with Liveness set are included, so it always answers 200 when there are none.
+func (r *CheckRegistry) LivenessHandler() http.Handler {
+	return r.handler(true)
+}
+
+// handler 输出 JSON 格式的检查报告
+//
+// handler writes the health report as JSON
+func (r *CheckRegistry) handler(liveness bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Report(req.Context(), liveness)
+		status := http.StatusOK
+		if report.Status == StatusDown {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if req.Method != http.MethodHead {
+			json.NewEncoder(w).Encode(report)
+		}
+	})
+}
+
+// runCheck 在超时时间内执行检查并捕获 panic；不遵守 ctx 的检查在超时后被视为失败，其协程在返回后退出
+//
+// runCheck runs a check within its timeout, recovering panics; checks ignoring ctx are failed at the timeout and their goroutine exits once they return
+func runCheck(ctx context.Context, c *check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, c.opts.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- errorutil.Recover(func() error { return c.fn(ctx) })
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %v: %w", c.opts.Timeout, ctx.Err())
+	}
+
+	res := CheckResult{Status: StatusUp, Latency: time.Since(start), CheckedAt: start, Optional: c.opts.Optional}
+	if err != nil {
+		res.Status, res.Error = StatusDown, err.Error()
+	}
+	return res
+}
+
+// newReport 汇总整体状态；存活检查不区分可选检查
+//
+// newReport aggregates the overall status; liveness does not distinguish optional checks
+func newReport(results map[string]CheckResult, liveness bool) *Report {
+	report := &Report{Status: StatusUp, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusDown {
+			continue
+		}
+		if res.Optional && !liveness {
+			report.Status = StatusDegraded
+			continue
+		}
+		report.Status = StatusDown
+		break
+	}
+	return report
+}
@@ -0,0 +1,234 @@
+// Package httputil 提供 HTTP 服务端和客户端相关的工具函数
+//
+// Package httputil provides HTTP server and client utility functions.
+package httputil
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/supergodk/go-utils/v1/gziputil"
+)
+
+// ErrBodyTooLarge 表示请求体（或解压后的请求体）超过了限制
+//
+// ErrBodyTooLarge indicates that the request body (or the decompressed body) exceeds the limit
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// errorResponse 中间件返回的 JSON 错误
+//
+// errorResponse is the JSON error returned by the middlewares
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeJSONError 写入 JSON 格式的错误响应
+//
+// writeJSONError writes a JSON error response
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Code: status, Message: message})
+}
+
+// LimitBody 限制请求体大小的中间件
+// Content-Length 超过限制的请求直接返回 413；未声明长度的请求在读取超过限制时返回 ErrBodyTooLarge，
+// 若处理函数尚未写入响应，则自动返回 413 JSON 错误，之后处理函数写入的内容会被丢弃
+// 参数:
+//   - next: 下一个处理器
+//   - maxBytes: 允许的最大字节数
+//
+// 返回:
+//   - http.Handler: 包装后的处理器
+//
+// LimitBody is a middleware that limits the request body size.
+// Requests whose Content-Length exceeds the limit get a 413 immediately; for requests without a declared length, reads past the limit return ErrBodyTooLarge,
+// and if the handler has not written a response yet a 413 JSON error is sent automatically, discarding whatever the handler writes afterwards.
+// Parameters:
+//   - next: The next handler
+//   - maxBytes: Maximum number of bytes allowed
+//
+// Returns:
+//   - http.Handler: The wrapped handler
+func LimitBody(next http.Handler, maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, ErrBodyTooLarge.Error())
+			return
+		}
+		if r.Body == nil || r.Body == http.NoBody {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &guardWriter{ResponseWriter: w}
+		r2 := r.Clone(r.Context())
+		r2.Body = &limitedBody{r: r.Body, c: r.Body, n: maxBytes, max: maxBytes, w: gw}
+		next.ServeHTTP(gw, r2)
+	})
+}
+
+// DecompressBody 解压请求体的中间件，支持 Content-Encoding 为 gzip 和 zstd 的请求
+// 解压后的大小同样受 maxBytes 限制，超出时的行为与 LimitBody 相同，用于防御解压炸弹；
+// 通常与 LimitBody 组合使用：LimitBody(DecompressBody(h, 解压后上限), 压缩数据上限)
+// 不支持的编码返回 415，数据与声明的编码不符时返回 400
+// 参数:
+//   - next: 下一个处理器
+//   - maxBytes: 解压后允许的最大字节数
+//
+// 返回:
+//   - http.Handler: 包装后的处理器
+//
+// DecompressBody is a middleware that decompresses request bodies with a Content-Encoding of gzip or zstd.
+// The decompressed size is also bounded by maxBytes, behaving like LimitBody when exceeded, which guards against decompression bombs;
+// it is usually combined with LimitBody: LimitBody(DecompressBody(h, decompressedLimit), compressedLimit).
+// Unsupported encodings get a 415, and bodies that do not match the declared encoding get a 400.
+// Parameters:
+//   - next: The next handler
+//   - maxBytes: Maximum number of decompressed bytes allowed
+//
+// Returns:
+//   - http.Handler: The wrapped handler
+func DecompressBody(next http.Handler, maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+		var want gziputil.Format
+		switch encoding {
+		case "", "identity":
+			next.ServeHTTP(w, r)
+			return
+		case "gzip", "x-gzip":
+			want = gziputil.FormatGzip
+		case "zstd":
+			want = gziputil.FormatZstd
+		default:
+			writeJSONError(w, http.StatusUnsupportedMediaType, "unsupported content encoding: "+encoding)
+			return
+		}
+
+		ar, err := gziputil.NewAutoReader(r.Body)
+		if err != nil || ar.Format() != want {
+			writeJSONError(w, http.StatusBadRequest, "invalid "+encoding+" body")
+			return
+		}
+		defer ar.Close()
+
+		gw := &guardWriter{ResponseWriter: w}
+		r2 := r.Clone(r.Context())
+		r2.Body = &limitedBody{r: ar, c: r.Body, n: maxBytes, max: maxBytes, w: gw}
+		r2.ContentLength = -1
+		r2.Header.Del("Content-Encoding")
+		r2.Header.Del("Content-Length")
+		next.ServeHTTP(gw, r2)
+	})
+}
+
+// guardWriter 在请求体超限时接管响应的 ResponseWriter
+//
+// guardWriter is a ResponseWriter that takes over the response when the body exceeds the limit
+type guardWriter struct {
+	http.ResponseWriter
+	mu      sync.Mutex
+	wrote   bool
+	tripped bool
+}
+
+// WriteHeader 写入状态码，超限后忽略
+//
+// WriteHeader writes the status code; ignored after the limit was exceeded.
+func (g *guardWriter) WriteHeader(code int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.tripped {
+		return
+	}
+	g.wrote = true
+	g.ResponseWriter.WriteHeader(code)
+}
+
+// Write 写入响应体，超限后丢弃
+//
+// Write writes the response body; discarded after the limit was exceeded.
+func (g *guardWriter) Write(b []byte) (int, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.tripped {
+		return 0, ErrBodyTooLarge
+	}
+	g.wrote = true
+	return g.ResponseWriter.Write(b)
+}
+
+// Unwrap 返回原始的 ResponseWriter，供 http.ResponseController 使用
+//
+// Unwrap returns the original ResponseWriter for use by http.ResponseController.
+func (g *guardWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+// trip 标记超限，若尚未写入响应则返回 413
+//
+// trip marks the limit as exceeded and sends a 413 if no response was written yet
+func (g *guardWriter) trip() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.tripped {
+		return
+	}
+	if !g.wrote {
+		writeJSONError(g.ResponseWriter, http.StatusRequestEntityTooLarge, ErrBodyTooLarge.Error())
+	}
+	g.tripped = true
+}
+
+// limitedBody 限制读取字节数的请求体
+//
+// limitedBody is a request body limited to a number of bytes
+type limitedBody struct {
+	r   io.Reader
+	c   io.Closer
+	n   int64
+	max int64
+	w   *guardWriter
+	err error
+}
+
+// Read 读取请求体，超过限制时返回 ErrBodyTooLarge
+//
+// Read reads the body, returning ErrBodyTooLarge past the limit.
+func (l *limitedBody) Read(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	// 多读一个字节以判断是否超限
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) <= l.n {
+		l.n -= int64(n)
+		l.err = err
+		return n, err
+	}
+	n = int(l.n)
+	l.n = 0
+	l.err = fmt.Errorf("%w: limit %d bytes", ErrBodyTooLarge, l.max)
+	l.w.trip()
+	return n, l.err
+}
+
+// Close 关闭原始请求体
+//
+// Close closes the original request body.
+func (l *limitedBody) Close() error {
+	return l.c.Close()
+}
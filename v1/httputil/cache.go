@@ -0,0 +1,381 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/cacheutil"
+)
+
+const (
+	// DefaultHTTPCachePrefix CachingTransport 默认的缓存键前缀
+	//
+	// DefaultHTTPCachePrefix is the default cache key prefix of CachingTransport
+	DefaultHTTPCachePrefix = "httputil:http:"
+	// DefaultHTTPCacheRetention CachingTransport 默认在响应过期后继续保留的时长，用于条件请求和 stale-if-error
+	//
+	// DefaultHTTPCacheRetention is how long CachingTransport keeps a response after it goes stale by default, for conditional requests and stale-if-error
+	DefaultHTTPCacheRetention = 24 * time.Hour
+	// DefaultHTTPCacheMaxBodySize CachingTransport 默认缓存的最大响应体大小
+	//
+	// DefaultHTTPCacheMaxBodySize is the default largest response body cached by CachingTransport
+	DefaultHTTPCacheMaxBodySize = 1 << 20
+)
+
+// CachingTransportOptions CachingTransport 的选项
+// Cache: 缓存后端，默认 cacheutil.NewMemoryCache()；使用共享的缓存（例如 Redis）时整个集群共用一份响应
+// Prefix: 缓存键前缀，默认为 DefaultHTTPCachePrefix
+// Retention: 响应过期后继续保留的时长，默认为 DefaultHTTPCacheRetention
+// StaleIfError: 响应没有指定 stale-if-error 时，源站出错（网络错误或 5xx）后仍可返回过期响应的时长；为 0 时只按响应的指令处理
+// MaxBodySize: 只缓存不超过该大小的响应体，默认为 DefaultHTTPCacheMaxBodySize
+//
+// CachingTransportOptions contains options for CachingTransport.
+// Cache: Cache backend, cacheutil.NewMemoryCache() by default; with a shared cache (e.g. Redis) the whole fleet shares one copy of each response
+// Prefix: Cache key prefix, DefaultHTTPCachePrefix by default
+// Retention: How long a response is kept after it goes stale, DefaultHTTPCacheRetention by default
+// StaleIfError: How long a stale response may still be served when the origin fails (network error or 5xx) if the response sets no stale-if-error; when 0 only the response's directive applies
+// MaxBodySize: Only response bodies up to this size are cached, DefaultHTTPCacheMaxBodySize by default
+type CachingTransportOptions struct {
+	Cache        cacheutil.Cache
+	Prefix       string
+	Retention    time.Duration
+	StaleIfError time.Duration
+	MaxBodySize  int64
+}
+
+// CachingTransport 遵循 Cache-Control 的共享缓存 RoundTripper，用于减少对 JWKS、远程配置等很少变化的资源的重复请求；并发安全
+// 只缓存 GET 请求的 200 响应；新鲜度按 s-maxage、max-age、Expires 依次确定，过期后用 ETag / Last-Modified 发起条件请求，
+// 源站返回 304 时继续使用缓存的响应体；源站出错时在 stale-if-error 允许的时间内返回过期响应
+// 带 Authorization 的请求只有在响应声明 public、s-maxage 或 must-revalidate 时才缓存，响应声明 no-store、private 或 Vary: * 时不缓存
+//
+// CachingTransport is a shared-cache RoundTripper honouring Cache-Control, cutting repeated fetches of rarely changing resources such as JWKS and remote configuration; it is safe for concurrent use.
+// Only 200 responses to GET are cached. Freshness comes from s-maxage, max-age and Expires in that order; stale entries are revalidated with ETag / Last-Modified,
+// a 304 from the origin keeps the cached body, and while the origin fails a stale response is served within the stale-if-error window.
+// Requests with Authorization are cached only when the response declares public, s-maxage or must-revalidate; responses with no-store, private or Vary: * are never cached.
+type CachingTransport struct {
+	base http.RoundTripper
+	o    CachingTransportOptions
+}
+
+// NewCachingTransport 创建带缓存的 RoundTripper
+// 参数:
+//   - base: 底层 RoundTripper，为 nil 时使用 http.DefaultTransport
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *CachingTransport: 带缓存的 RoundTripper
+//
+// NewCachingTransport creates a caching RoundTripper.
+// Parameters:
+//   - base: The underlying RoundTripper; http.DefaultTransport when nil
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *CachingTransport: The caching RoundTripper
+func NewCachingTransport(base http.RoundTripper, opts *CachingTransportOptions) *CachingTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	t := &CachingTransport{base: base}
+	if opts != nil {
+		t.o = *opts
+	}
+	if t.o.Cache == nil {
+		t.o.Cache = cacheutil.NewMemoryCache()
+	}
+	if t.o.Prefix == "" {
+		t.o.Prefix = DefaultHTTPCachePrefix
+	}
+	if t.o.Retention <= 0 {
+		t.o.Retention = DefaultHTTPCacheRetention
+	}
+	if t.o.MaxBodySize <= 0 {
+		t.o.MaxBodySize = DefaultHTTPCacheMaxBodySize
+	}
+	return t
+}
+
+// cachedHTTPResponse 缓存的响应
+// Header: 响应头
+// Body: 响应体
+// StoredAt: 收到响应或最近一次 304 的时间
+// Vary: 响应 Vary 头列出的请求头在原请求中的值
+//
+// cachedHTTPResponse is a cached response
+// Header: Response headers
+// Body: Response body
+// StoredAt: When the response or the latest 304 arrived
+// Vary: Values in the original request of the request headers listed by the response's Vary header
+type cachedHTTPResponse struct {
+	Header   http.Header       `json:"header"`
+	Body     []byte            `json:"body"`
+	StoredAt time.Time         `json:"stored_at"`
+	Vary     map[string]string `json:"vary,omitempty"`
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+//
+// RoundTrip implements the http.RoundTripper interface.
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqCC := parseCacheControl(req.Header.Get("Cache-Control"))
+	_, noStore := reqCC["no-store"]
+	// 调用者自己发起的条件请求和 Range 请求直接透传
+	if req.Method != http.MethodGet || noStore || req.Header.Get("Range") != "" ||
+		req.Header.Get("If-None-Match") != "" || req.Header.Get("If-Modified-Since") != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx := req.Context()
+	key := t.o.Prefix + req.URL.String()
+	entry := t.load(req, key)
+	if entry != nil {
+		_, noCache := reqCC["no-cache"]
+		if !noCache && entry.age() < entry.freshness() {
+			return entry.response(req), nil
+		}
+	}
+
+	out := req
+	if entry != nil {
+		out = req.Clone(ctx)
+		if etag := entry.Header.Get("ETag"); etag != "" {
+			out.Header.Set("If-None-Match", etag)
+		}
+		if lm := entry.Header.Get("Last-Modified"); lm != "" {
+			out.Header.Set("If-Modified-Since", lm)
+		}
+	}
+	resp, err := t.base.RoundTrip(out)
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		if entry != nil && entry.age() < entry.freshness()+t.staleIfError(entry) {
+			if resp != nil {
+				io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+			return entry.response(req), nil
+		}
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		// 304 携带的头更新缓存的头，例如新的 Cache-Control、Expires 和 Date
+		for k, vs := range resp.Header {
+			entry.Header[k] = vs
+		}
+		entry.StoredAt = time.Now()
+		t.save(key, entry, req)
+		return entry.response(req), nil
+	}
+	if resp.StatusCode != http.StatusOK || !cacheable(req, resp) {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.o.MaxBodySize+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > t.o.MaxBodySize {
+		// 太大的响应不缓存，把已读的内容拼接回去后透传
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+	entry = &cachedHTTPResponse{Header: resp.Header.Clone(), Body: body, StoredAt: time.Now()}
+	if vary := resp.Header.Values("Vary"); len(vary) > 0 {
+		entry.Vary = make(map[string]string)
+		for _, v := range vary {
+			for name := range strings.SplitSeq(v, ",") {
+				name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+				entry.Vary[name] = req.Header.Get(name)
+			}
+		}
+	}
+	t.save(key, entry, req)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// CloseIdleConnections 关闭底层 RoundTripper 的空闲连接
+//
+// CloseIdleConnections closes the underlying RoundTripper's idle connections.
+func (t *CachingTransport) CloseIdleConnections() {
+	if c, ok := t.base.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
+
+// load 读取缓存的响应，出错、数据损坏或 Vary 不匹配时返回 nil
+//
+// load reads a cached response, returning nil on errors, corrupt data or a Vary mismatch
+func (t *CachingTransport) load(req *http.Request, key string) *cachedHTTPResponse {
+	data, ok, err := t.o.Cache.Get(req.Context(), key)
+	if err != nil || !ok {
+		return nil
+	}
+	var entry cachedHTTPResponse
+	if err := json.Unmarshal(data, &entry); err != nil || entry.Header == nil {
+		return nil
+	}
+	for name, value := range entry.Vary {
+		if req.Header.Get(name) != value {
+			return nil
+		}
+	}
+	return &entry
+}
+
+// save 写入缓存，保留时长为新鲜期、stale-if-error 窗口和 Retention 之和，写入失败时忽略
+//
+// save writes the cache, keeping the entry for its freshness lifetime plus the stale-if-error window and Retention, ignoring write failures
+func (t *CachingTransport) save(key string, entry *cachedHTTPResponse, req *http.Request) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	ttl := entry.freshness() + t.staleIfError(entry) + t.o.Retention
+	_ = t.o.Cache.Set(req.Context(), key, data, ttl)
+}
+
+// staleIfError 返回源站出错时过期响应仍可使用的时长，must-revalidate 时为 0
+//
+// staleIfError returns how long a stale response may be served while the origin fails; 0 with must-revalidate
+func (t *CachingTransport) staleIfError(entry *cachedHTTPResponse) time.Duration {
+	cc := parseCacheControl(entry.Header.Get("Cache-Control"))
+	if _, ok := cc["must-revalidate"]; ok {
+		return 0
+	}
+	if _, ok := cc["proxy-revalidate"]; ok {
+		return 0
+	}
+	if d, ok := ccSeconds(cc, "stale-if-error"); ok {
+		return d
+	}
+	return t.o.StaleIfError
+}
+
+// freshness 返回响应的新鲜期，依次取 s-maxage、max-age 和 Expires 减 Date；no-cache 时为 0
+//
+// freshness returns the response's freshness lifetime from s-maxage, max-age and Expires minus Date in that order; 0 with no-cache
+func (e *cachedHTTPResponse) freshness() time.Duration {
+	cc := parseCacheControl(e.Header.Get("Cache-Control"))
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	if d, ok := ccSeconds(cc, "s-maxage"); ok {
+		return d
+	}
+	if d, ok := ccSeconds(cc, "max-age"); ok {
+		return d
+	}
+	if expires := e.Header.Get("Expires"); expires != "" {
+		exp, err := http.ParseTime(expires)
+		if err != nil {
+			// 无效的 Expires 表示已过期
+			return 0
+		}
+		date, err := http.ParseTime(e.Header.Get("Date"))
+		if err != nil {
+			date = e.StoredAt
+		}
+		return max(exp.Sub(date), 0)
+	}
+	return 0
+}
+
+// age 返回响应的当前年龄，包括源站或上游缓存报告的 Age
+//
+// age returns the response's current age, including the Age reported by the origin or upstream caches
+func (e *cachedHTTPResponse) age() time.Duration {
+	age := time.Since(e.StoredAt)
+	if s, err := strconv.ParseInt(e.Header.Get("Age"), 10, 64); err == nil && s > 0 {
+		age += time.Duration(s) * time.Second
+	}
+	return age
+}
+
+// response 根据缓存构造响应，Age 头为当前年龄
+//
+// response builds a response from the cache, with the Age header set to the current age
+func (e *cachedHTTPResponse) response(req *http.Request) *http.Response {
+	h := e.Header.Clone()
+	h.Set("Age", strconv.FormatInt(int64(e.age()/time.Second), 10))
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		Body:          io.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Request:       req,
+	}
+}
+
+// cacheable 判断响应是否可以存入共享缓存，且能够在之后使用（有新鲜期或可以校验）
+//
+// cacheable reports whether a response may be stored in a shared cache and can be used later (it has a freshness lifetime or can be revalidated)
+func cacheable(req *http.Request, resp *http.Response) bool {
+	cc := parseCacheControl(resp.Header.Get("Cache-Control"))
+	for _, d := range []string{"no-store", "private"} {
+		if _, ok := cc[d]; ok {
+			return false
+		}
+	}
+	for _, v := range resp.Header.Values("Vary") {
+		if strings.TrimSpace(v) == "*" {
+			return false
+		}
+	}
+	if req.Header.Get("Authorization") != "" {
+		_, public := cc["public"]
+		_, smaxage := cc["s-maxage"]
+		_, mustRevalidate := cc["must-revalidate"]
+		if !public && !smaxage && !mustRevalidate {
+			return false
+		}
+	}
+	e := cachedHTTPResponse{Header: resp.Header}
+	return e.freshness() > 0 || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// parseCacheControl 解析 Cache-Control 头，指令名转换为小写，没有值的指令对应空字符串
+//
+// parseCacheControl parses a Cache-Control header; directive names are lower-cased and directives without a value map to an empty string
+func parseCacheControl(h string) map[string]string {
+	cc := make(map[string]string)
+	for part := range strings.SplitSeq(h, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		cc[strings.ToLower(name)] = strings.Trim(value, `"`)
+	}
+	return cc
+}
+
+// ccSeconds 读取以秒为单位的指令值
+//
+// ccSeconds reads a directive value in seconds
+func ccSeconds(cc map[string]string, name string) (time.Duration, bool) {
+	v, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	s, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || s < 0 {
+		return 0, false
+	}
+	return time.Duration(s) * time.Second, true
+}
@@ -0,0 +1,307 @@
+package httputil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/cacheutil"
+)
+
+// DefaultJSONCacheTTL CacheJSON 默认的缓存时长
+//
+// DefaultJSONCacheTTL is the default cache duration of CacheJSON
+const DefaultJSONCacheTTL = time.Minute
+
+// ComputeETag 根据内容计算强 ETag（带引号的 SHA-256 前 16 字节十六进制）
+//
+// ComputeETag computes a strong ETag from the content (the quoted hex of the first 16 bytes of its SHA-256).
+func ComputeETag(content []byte) string {
+	sum := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ComputeETagReader 与 ComputeETag 相同，但流式读取内容，适用于大文件
+//
+// ComputeETagReader is like ComputeETag but streams the content, for large files.
+func ComputeETagReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`, nil
+}
+
+// NotModified 按 RFC 9110 判断条件请求是否可以返回 304：有 If-None-Match 时只比较 ETag（弱比较），否则比较 If-Modified-Since
+// 只对 GET 和 HEAD 请求生效
+// 参数:
+//   - r: 请求
+//   - etag: 当前内容的 ETag，为空时不比较
+//   - modTime: 当前内容的修改时间，零值时不比较
+//
+// 返回:
+//   - bool: 是否应返回 304 Not Modified
+//
+// NotModified decides per RFC 9110 whether a conditional request can be answered with 304: with If-None-Match only ETags are compared (weakly), otherwise If-Modified-Since is compared.
+// It only applies to GET and HEAD requests.
+// Parameters:
+//   - r: The request
+//   - etag: ETag of the current content; not compared when empty
+//   - modTime: Modification time of the current content; not compared when zero
+//
+// Returns:
+//   - bool: Whether 304 Not Modified should be returned
+func NotModified(r *http.Request, etag string, modTime time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return etag != "" && etagListMatch(inm, etag)
+	}
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modTime.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// HTTP 日期只精确到秒
+	return !modTime.Truncate(time.Second).After(t)
+}
+
+// WriteWithETag 写入内容并处理条件请求：设置 ETag 和 Last-Modified，条件满足时返回 304 且不写入内容
+// Content-Type 等其他响应头应在调用前设置
+// 参数:
+//   - w: 响应
+//   - r: 请求
+//   - content: 内容
+//   - modTime: 修改时间，零值时不设置 Last-Modified
+//
+// WriteWithETag writes content while handling conditional requests: it sets ETag and Last-Modified and answers 304 without a body when the conditions match.
+// Set Content-Type and other headers before calling it.
+// Parameters:
+//   - w: The response
+//   - r: The request
+//   - content: The content
+//   - modTime: Modification time; Last-Modified is not set when zero
+func WriteWithETag(w http.ResponseWriter, r *http.Request, content []byte, modTime time.Time) {
+	writeWithETag(w, r, content, ComputeETag(content), modTime)
+}
+
+// writeWithETag 使用已计算的 ETag 写入内容
+//
+// writeWithETag writes content with a precomputed ETag
+func writeWithETag(w http.ResponseWriter, r *http.Request, content []byte, etag string, modTime time.Time) {
+	h := w.Header()
+	h.Set("ETag", etag)
+	if !modTime.IsZero() {
+		h.Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+	if NotModified(r, etag, modTime) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	h.Set("Content-Length", strconv.Itoa(len(content)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(content)
+	}
+}
+
+// etagListMatch 对 If-None-Match 中的 ETag 列表做弱比较，"*" 匹配任意 ETag
+//
+// etagListMatch weakly compares the ETag list of If-None-Match; "*" matches any ETag
+func etagListMatch(list, etag string) bool {
+	want := strings.TrimPrefix(etag, "W/")
+	for item := range strings.SplitSeq(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "*" || strings.TrimPrefix(item, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheJSONOptions CacheJSON 的选项
+// Cache: 缓存实现，默认使用 cacheutil.NewMemoryCache；多实例部署时可使用分布式实现，所有实例会返回相同的 ETag
+// TTL: 缓存时长，默认 DefaultJSONCacheTTL
+// Key: 计算缓存键的函数，默认使用 "httputil:json:" 加请求的路径和查询参数
+//
+// CacheJSONOptions contains options for CacheJSON.
+// Cache: Cache implementation, defaults to cacheutil.NewMemoryCache; a distributed implementation makes every instance return the same ETag
+// TTL: Cache duration, defaults to DefaultJSONCacheTTL
+// Key: Function computing the cache key, defaults to "httputil:json:" plus the request path and query
+type CacheJSONOptions struct {
+	Cache cacheutil.Cache
+	TTL   time.Duration
+	Key   func(r *http.Request) string
+}
+
+// CacheJSON 缓存静态 JSON 接口（例如配置、字典数据）的中间件：GET 和 HEAD 请求的 200 响应会被缓存 TTL 时长，
+// 命中时直接返回缓存内容并支持 If-None-Match / If-Modified-Since 条件请求；其他请求直接交给 next 处理
+// 只缓存响应体和 Content-Type；不要用于按用户区分的响应，除非 Key 中包含用户标识
+// 参数:
+//   - next: 下一个处理器
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - http.Handler: 包装后的处理器
+//
+// CacheJSON is a middleware caching static JSON endpoints (configuration, dictionaries, ...): 200 responses to GET and HEAD are cached for TTL,
+// and hits are served from the cache with If-None-Match / If-Modified-Since support; other requests go straight to next.
+// Only the body and Content-Type are cached; do not use it for per-user responses unless Key includes the user identity.
+// Parameters:
+//   - next: The next handler
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - http.Handler: The wrapped handler
+func CacheJSON(next http.Handler, opts *CacheJSONOptions) http.Handler {
+	var o CacheJSONOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Cache == nil {
+		o.Cache = cacheutil.NewMemoryCache()
+	}
+	if o.TTL <= 0 {
+		o.TTL = DefaultJSONCacheTTL
+	}
+	if o.Key == nil {
+		o.Key = func(r *http.Request) string { return "httputil:json:" + r.URL.RequestURI() }
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := o.Key(r)
+		if data, ok, err := o.Cache.Get(r.Context(), key); err == nil && ok {
+			if e, ok := decodeCachedResponse(data); ok {
+				e.write(w, r)
+				return
+			}
+		}
+
+		rec := &responseRecorder{header: make(http.Header), status: http.StatusOK}
+		// HEAD 请求没有响应体，按 GET 生成以便缓存
+		r2 := r
+		if r.Method == http.MethodHead {
+			r2 = r.Clone(r.Context())
+			r2.Method = http.MethodGet
+		}
+		next.ServeHTTP(rec, r2)
+		if rec.status != http.StatusOK {
+			for k, vs := range rec.header {
+				w.Header()[k] = vs
+			}
+			w.WriteHeader(rec.status)
+			w.Write(rec.body.Bytes())
+			return
+		}
+
+		e := &cachedResponse{
+			etag:        ComputeETag(rec.body.Bytes()),
+			contentType: rec.header.Get("Content-Type"),
+			modTime:     time.Now().Truncate(time.Second),
+			body:        rec.body.Bytes(),
+		}
+		// 缓存写入失败只影响性能，仍然正常返回
+		_ = o.Cache.Set(r.Context(), key, e.encode(), o.TTL)
+		e.write(w, r)
+	})
+}
+
+// responseRecorder 记录处理器写入的响应
+//
+// responseRecorder records the response written by a handler
+type responseRecorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// Header 实现 http.ResponseWriter 接口
+//
+// Header implements the http.ResponseWriter interface.
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+// WriteHeader 实现 http.ResponseWriter 接口
+//
+// WriteHeader implements the http.ResponseWriter interface.
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+}
+
+// Write 实现 http.ResponseWriter 接口
+//
+// Write implements the http.ResponseWriter interface.
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(p)
+}
+
+// cachedResponse 缓存的响应
+//
+// cachedResponse is a cached response
+type cachedResponse struct {
+	etag        string
+	contentType string
+	modTime     time.Time
+	body        []byte
+}
+
+// encode 序列化为 "ETag\nContent-Type\nUnix 秒\n响应体"
+//
+// encode serializes as "ETag\nContent-Type\nUnix seconds\nbody"
+func (e *cachedResponse) encode() []byte {
+	var b bytes.Buffer
+	b.Grow(len(e.etag) + len(e.contentType) + len(e.body) + 24)
+	b.WriteString(e.etag)
+	b.WriteByte('\n')
+	b.WriteString(e.contentType)
+	b.WriteByte('\n')
+	b.WriteString(strconv.FormatInt(e.modTime.Unix(), 10))
+	b.WriteByte('\n')
+	b.Write(e.body)
+	return b.Bytes()
+}
+
+// decodeCachedResponse 反序列化 encode 的结果
+//
+// decodeCachedResponse deserializes the output of encode
+func decodeCachedResponse(data []byte) (*cachedResponse, bool) {
+	parts := bytes.SplitN(data, []byte{'\n'}, 4)
+	if len(parts) != 4 {
+		return nil, false
+	}
+	sec, err := strconv.ParseInt(string(parts[2]), 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &cachedResponse{etag: string(parts[0]), contentType: string(parts[1]), modTime: time.Unix(sec, 0), body: parts[3]}, true
+}
+
+// write 写出缓存的响应
+//
+// write writes the cached response
+func (e *cachedResponse) write(w http.ResponseWriter, r *http.Request) {
+	if e.contentType != "" {
+		w.Header().Set("Content-Type", e.contentType)
+	}
+	writeWithETag(w, r, e.body, e.etag, e.modTime)
+}
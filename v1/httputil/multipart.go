@@ -0,0 +1,206 @@
+package httputil
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/supergodk/go-utils/v1/tracing"
+)
+
+// ErrUploadTooLarge 表示上传文件的总大小超过了限制
+//
+// ErrUploadTooLarge indicates that the total size of the uploaded files exceeds the limit
+var ErrUploadTooLarge = errors.New("upload too large")
+
+// errRequestDone 请求结束后用于关闭管道，使写入协程退出
+//
+// errRequestDone closes the pipe once the request is over so the writing goroutine exits
+var errRequestDone = errors.New("request finished")
+
+// MultipartFile 待上传的文件
+// FieldName: 表单字段名
+// FileName: 文件名
+// ContentType: 内容类型，为空时先按文件扩展名检测，再嗅探内容开头
+// Reader: 文件内容，按流式读取，不会整体缓存到内存
+//
+// MultipartFile is a file to upload.
+// FieldName: Form field name
+// FileName: File name
+// ContentType: Content type; when empty it is detected from the file extension and then by sniffing the content
+// Reader: File content, streamed rather than buffered in memory
+type MultipartFile struct {
+	FieldName   string
+	FileName    string
+	ContentType string
+	Reader      io.Reader
+}
+
+// UploadOptions 上传选项
+// Client: HTTP 客户端，默认 http.DefaultClient
+// Method: 请求方法，默认 POST
+// Header: 额外的请求头，例如 Authorization
+// MaxTotalSize: 所有文件内容的总大小上限，0 表示不限制；超出时中止上传并返回 ErrUploadTooLarge
+//
+// UploadOptions contains upload options.
+// Client: HTTP client, defaults to http.DefaultClient
+// Method: Request method, defaults to POST
+// Header: Extra request headers, e.g. Authorization
+// MaxTotalSize: Limit on the total size of all file contents, 0 for no limit; exceeding it aborts the upload with ErrUploadTooLarge
+type UploadOptions struct {
+	Client       *http.Client
+	Method       string
+	Header       http.Header
+	MaxTotalSize int64
+}
+
+// UploadMultipart 以 multipart/form-data 流式上传表单字段和文件，边读边发，适用于把用户上传的文件转发给第三方
+// 普通字段按名称排序后写在文件之前；请求体长度未知，使用分块传输
+// 参数:
+//   - ctx: 上下文
+//   - url: 目标地址
+//   - fields: 普通表单字段，可以为 nil
+//   - files: 文件列表
+//   - opts: 上传选项，可以为 nil
+//
+// 返回:
+//   - *http.Response: 响应，调用方负责关闭 Body
+//   - error: 请求失败或读取文件失败时返回错误；超过总大小限制时返回 ErrUploadTooLarge
+//
+// UploadMultipart streams form fields and files as multipart/form-data, sending while reading, for proxying user uploads to third parties.
+// Plain fields are written before the files, sorted by name; the body length is unknown, so chunked transfer encoding is used.
+// Parameters:
+//   - ctx: Context
+//   - url: Target URL
+//   - fields: Plain form fields, may be nil
+//   - files: Files
+//   - opts: Upload options, may be nil
+//
+// Returns:
+//   - *http.Response: The response; the caller must close its Body
+//   - error: Returns an error if the request or reading a file fails; returns ErrUploadTooLarge if the total size limit is exceeded
+func UploadMultipart(ctx context.Context, url string, fields map[string]string, files []MultipartFile, opts *UploadOptions) (resp *http.Response, err error) {
+	var o UploadOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	if o.Method == "" {
+		o.Method = http.MethodPost
+	}
+	ctx, end := tracing.StartSpan(ctx, "httputil.UploadMultipart")
+	defer func() { end(err) }()
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	req, err := http.NewRequestWithContext(ctx, o.Method, url, pr)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range o.Header {
+		req.Header[k] = append([]string(nil), vs...)
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	// 记录写入端的错误，传输层返回的错误可能不会保留原始错误
+	var (
+		writeErr error
+		wg       sync.WaitGroup
+	)
+	wg.Go(func() {
+		writeErr = writeMultipart(mw, fields, files, o.MaxTotalSize)
+		if writeErr == nil {
+			writeErr = mw.Close()
+		}
+		pw.CloseWithError(writeErr)
+	})
+
+	resp, err = o.Client.Do(req)
+	pr.CloseWithError(errRequestDone)
+	wg.Wait()
+	// 服务端提前响应或传输失败时写入端会因管道关闭而出错，此时以 Do 的结果为准
+	if writeErr != nil && !errors.Is(writeErr, io.ErrClosedPipe) && !errors.Is(writeErr, errRequestDone) {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return nil, writeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// writeMultipart 依次写入字段和文件
+//
+// writeMultipart writes the fields and then the files
+func writeMultipart(mw *multipart.Writer, fields map[string]string, files []MultipartFile, maxTotal int64) error {
+	for _, k := range slices.Sorted(maps.Keys(fields)) {
+		if err := mw.WriteField(k, fields[k]); err != nil {
+			return err
+		}
+	}
+	var total int64
+	for _, f := range files {
+		br := bufio.NewReaderSize(f.Reader, 512)
+		contentType := f.ContentType
+		if contentType == "" {
+			contentType = mime.TypeByExtension(path.Ext(f.FileName))
+		}
+		if contentType == "" {
+			head, err := br.Peek(512)
+			if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+				return fmt.Errorf("%s: %w", f.FileName, err)
+			}
+			contentType = http.DetectContentType(head)
+		}
+
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(f.FieldName), escapeQuotes(f.FileName)))
+		h.Set("Content-Type", contentType)
+		part, err := mw.CreatePart(h)
+		if err != nil {
+			return err
+		}
+
+		var src io.Reader = br
+		if maxTotal > 0 {
+			// 多读一个字节用于判断是否超限
+			src = io.LimitReader(br, maxTotal-total+1)
+		}
+		n, err := io.Copy(part, src)
+		total += n
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.FileName, err)
+		}
+		if maxTotal > 0 && total > maxTotal {
+			return fmt.Errorf("%w: more than %d bytes", ErrUploadTooLarge, maxTotal)
+		}
+	}
+	return nil
+}
+
+// quoteEscaper 转义 Content-Disposition 中的引号和反斜杠，与 mime/multipart 一致
+//
+// quoteEscaper escapes quotes and backslashes in Content-Disposition, matching mime/multipart
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// escapeQuotes 转义引号和反斜杠
+//
+// escapeQuotes escapes quotes and backslashes
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
@@ -0,0 +1,362 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTokenFetch 表示获取 OAuth2 访问令牌失败
+//
+// ErrTokenFetch indicates that fetching an OAuth2 access token failed
+var ErrTokenFetch = errors.New("oauth2 token fetch failed")
+
+const (
+	// DefaultTokenRefreshBefore 默认在令牌过期前多久开始后台刷新
+	//
+	// DefaultTokenRefreshBefore is how long before expiry a token is refreshed in the background by default
+	DefaultTokenRefreshBefore = time.Minute
+	// tokenFetchTimeout 单次获取令牌的超时时间
+	//
+	// tokenFetchTimeout is the timeout of one token fetch
+	tokenFetchTimeout = 30 * time.Second
+)
+
+// ClientCredentialsConfig OAuth2 客户端凭证模式的配置
+// TokenURL: 令牌端点
+// ClientID: 客户端 ID
+// ClientSecret: 客户端密钥
+// Scopes: 申请的权限范围，可选
+// EndpointParams: 额外的表单参数，例如 audience，可选
+// AuthInBody: 为 true 时将凭证放在表单中，否则使用 HTTP Basic 认证（RFC 6749 推荐）
+// RefreshBefore: 在令牌过期前多久开始后台刷新，为 0 时使用 DefaultTokenRefreshBefore
+// HTTPClient: 请求令牌端点的客户端，为 nil 时使用 http.DefaultClient
+//
+// ClientCredentialsConfig is the configuration of the OAuth2 client credentials grant.
+// TokenURL: The token endpoint
+// ClientID: Client ID
+// ClientSecret: Client secret
+// Scopes: The requested scopes, optional
+// EndpointParams: Extra form parameters such as audience, optional
+// AuthInBody: When true the credentials are sent in the form, otherwise with HTTP Basic authentication (recommended by RFC 6749)
+// RefreshBefore: How long before expiry to refresh in the background; DefaultTokenRefreshBefore when 0
+// HTTPClient: The client for calling the token endpoint; http.DefaultClient when nil
+type ClientCredentialsConfig struct {
+	TokenURL       string
+	ClientID       string
+	ClientSecret   string
+	Scopes         []string
+	EndpointParams url.Values
+	AuthInBody     bool
+	RefreshBefore  time.Duration
+	HTTPClient     *http.Client
+}
+
+// Token OAuth2 访问令牌
+// AccessToken: 访问令牌
+// TokenType: 令牌类型，通常为 Bearer
+// ExpiresAt: 过期时间，为零值表示服务端未给出有效期
+//
+// Token is an OAuth2 access token.
+// AccessToken: The access token
+// TokenType: The token type, usually Bearer
+// ExpiresAt: Expiry time; the zero value means the server gave no lifetime
+type Token struct {
+	AccessToken string
+	TokenType   string
+	ExpiresAt   time.Time
+}
+
+// Valid 判断令牌是否存在且未过期
+//
+// Valid reports whether the token exists and has not expired.
+func (t *Token) Valid() bool {
+	return t != nil && t.AccessToken != "" && (t.ExpiresAt.IsZero() || time.Now().Before(t.ExpiresAt))
+}
+
+// TokenSource 获取并缓存客户端凭证令牌，令牌进入刷新窗口后在后台提前刷新，调用者继续使用旧令牌而不必等待
+// 并发调用共享同一次请求；并发安全
+//
+// TokenSource fetches and caches client credentials tokens. Once a token enters the refresh window it is refreshed in the background while callers keep using the old one without waiting.
+// Concurrent calls share one request; it is safe for concurrent use.
+type TokenSource struct {
+	cfg ClientCredentialsConfig
+
+	mu         sync.Mutex
+	token      *Token
+	err        error
+	refreshing chan struct{}
+}
+
+// NewTokenSource 创建客户端凭证令牌源
+// 参数:
+//   - cfg: 配置
+//
+// 返回:
+//   - *TokenSource: 令牌源，首次调用 Token 时才请求令牌
+//
+// NewTokenSource creates a client credentials token source.
+// Parameters:
+//   - cfg: Configuration
+//
+// Returns:
+//   - *TokenSource: The token source; the first token is requested on the first call to Token
+func NewTokenSource(cfg ClientCredentialsConfig) *TokenSource {
+	if cfg.RefreshBefore <= 0 {
+		cfg.RefreshBefore = DefaultTokenRefreshBefore
+	}
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	return &TokenSource{cfg: cfg}
+}
+
+// Token 返回有效的访问令牌；缓存的令牌有效时直接返回，否则等待获取新令牌
+// 参数:
+//   - ctx: 上下文，只控制本次等待，取消后后台请求仍会完成并缓存结果
+//
+// 返回:
+//   - *Token: 访问令牌
+//   - error: 获取失败时返回 ErrTokenFetch
+//
+// Token returns a valid access token: the cached token when it is valid, otherwise it waits for a new one.
+// Parameters:
+//   - ctx: Context that only bounds this wait; a cancelled wait still lets the background request finish and cache its result
+//
+// Returns:
+//   - *Token: The access token
+//   - error: Returns ErrTokenFetch if fetching fails
+func (ts *TokenSource) Token(ctx context.Context) (*Token, error) {
+	ts.mu.Lock()
+	tok := ts.token
+	if tok.Valid() {
+		if !tok.ExpiresAt.IsZero() && time.Until(tok.ExpiresAt) < ts.cfg.RefreshBefore {
+			ts.refreshLocked()
+		}
+		ts.mu.Unlock()
+		return tok, nil
+	}
+	done := ts.refreshLocked()
+	ts.mu.Unlock()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if ts.token.Valid() {
+		return ts.token, nil
+	}
+	if ts.err != nil {
+		return nil, ts.err
+	}
+	return nil, fmt.Errorf("%w: token expired on arrival", ErrTokenFetch)
+}
+
+// Invalidate 丢弃缓存的令牌，下次调用 Token 时重新获取，例如服务端提前吊销了令牌
+//
+// Invalidate discards the cached token so the next call to Token fetches a new one, e.g. when the server revoked it early.
+func (ts *TokenSource) Invalidate() {
+	ts.mu.Lock()
+	ts.token = nil
+	ts.mu.Unlock()
+}
+
+// invalidate 仅当缓存的仍是 tok 时丢弃，避免丢掉其他请求刚刷新的令牌
+//
+// invalidate discards the cached token only if it is still tok, so a token just refreshed by another request is kept
+func (ts *TokenSource) invalidate(tok *Token) {
+	ts.mu.Lock()
+	if ts.token == tok {
+		ts.token = nil
+	}
+	ts.mu.Unlock()
+}
+
+// refreshLocked 启动后台刷新（已有刷新在进行时复用），返回刷新完成时关闭的通道；调用者必须持有 mu
+//
+// refreshLocked starts a background refresh, reusing one already in flight, and returns a channel closed when it finishes; the caller must hold mu
+func (ts *TokenSource) refreshLocked() chan struct{} {
+	if ts.refreshing != nil {
+		return ts.refreshing
+	}
+	done := make(chan struct{})
+	ts.refreshing = done
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), tokenFetchTimeout)
+		defer cancel()
+		tok, err := ts.fetch(ctx)
+		ts.mu.Lock()
+		if err == nil {
+			ts.token = tok
+		}
+		ts.err = err
+		ts.refreshing = nil
+		ts.mu.Unlock()
+		close(done)
+	}()
+	return done
+}
+
+// tokenResponse 令牌端点的响应
+//
+// tokenResponse is the token endpoint's response
+type tokenResponse struct {
+	AccessToken      string      `json:"access_token"`
+	TokenType        string      `json:"token_type"`
+	ExpiresIn        json.Number `json:"expires_in"`
+	Error            string      `json:"error"`
+	ErrorDescription string      `json:"error_description"`
+}
+
+// fetch 请求令牌端点
+//
+// fetch calls the token endpoint
+func (ts *TokenSource) fetch(ctx context.Context) (*Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(ts.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(ts.cfg.Scopes, " "))
+	}
+	for k, v := range ts.cfg.EndpointParams {
+		form[k] = v
+	}
+	if ts.cfg.AuthInBody {
+		form.Set("client_id", ts.cfg.ClientID)
+		form.Set("client_secret", ts.cfg.ClientSecret)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ts.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenFetch, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !ts.cfg.AuthInBody {
+		// RFC 6749 2.3.1 要求先进行表单编码
+		req.SetBasicAuth(url.QueryEscape(ts.cfg.ClientID), url.QueryEscape(ts.cfg.ClientSecret))
+	}
+
+	start := time.Now()
+	resp, err := ts.cfg.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenFetch, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenFetch, err)
+	}
+	var tr tokenResponse
+	jsonErr := json.Unmarshal(body, &tr)
+	if resp.StatusCode != http.StatusOK || tr.Error != "" {
+		if tr.Error != "" {
+			return nil, fmt.Errorf("%w: status %d: %s", ErrTokenFetch, resp.StatusCode, strings.TrimSpace(tr.Error+" "+tr.ErrorDescription))
+		}
+		return nil, fmt.Errorf("%w: status %d", ErrTokenFetch, resp.StatusCode)
+	}
+	if jsonErr != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTokenFetch, jsonErr)
+	}
+	if tr.AccessToken == "" {
+		return nil, fmt.Errorf("%w: empty access_token", ErrTokenFetch)
+	}
+
+	tok := &Token{AccessToken: tr.AccessToken, TokenType: tr.TokenType}
+	if tr.ExpiresIn != "" {
+		secs, err := tr.ExpiresIn.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid expires_in: %v", ErrTokenFetch, err)
+		}
+		if secs > 0 {
+			// 从发出请求时计算，留出网络延迟的余量
+			tok.ExpiresAt = start.Add(time.Duration(secs) * time.Second)
+		}
+	}
+	return tok, nil
+}
+
+// OAuth2Transport 返回为每个请求注入访问令牌的 RoundTripper
+// 响应为 401 时丢弃令牌并用新令牌重试一次（请求体可重放时）
+// 参数:
+//   - base: 下层 RoundTripper，为 nil 时使用 http.DefaultTransport
+//   - ts: 令牌源
+//
+// 返回:
+//   - http.RoundTripper: 注入认证头的 RoundTripper
+//
+// OAuth2Transport returns a RoundTripper that injects the access token into every request.
+// On a 401 response it discards the token and retries once with a new one, if the request body can be replayed.
+// Parameters:
+//   - base: The underlying RoundTripper; http.DefaultTransport when nil
+//   - ts: The token source
+//
+// Returns:
+//   - http.RoundTripper: A RoundTripper that injects the authorization header
+func OAuth2Transport(base http.RoundTripper, ts *TokenSource) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &oauth2Transport{base: base, ts: ts}
+}
+
+// oauth2Transport 注入访问令牌的 RoundTripper
+//
+// oauth2Transport is a RoundTripper that injects access tokens
+type oauth2Transport struct {
+	base http.RoundTripper
+	ts   *TokenSource
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+//
+// RoundTrip implements the http.RoundTripper interface.
+func (t *oauth2Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	tok, err := t.ts.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(authorize(req, tok))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return resp, nil
+	}
+
+	t.ts.invalidate(tok)
+	tok, err = t.ts.Token(req.Context())
+	if err != nil {
+		// 保留原始的 401 响应，让调用者看到服务端的错误
+		return resp, nil
+	}
+	retry := authorize(req, tok)
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return resp, nil
+		}
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return t.base.RoundTrip(retry)
+}
+
+// authorize 返回带认证头的请求副本，RoundTripper 不得修改原请求
+//
+// authorize returns a copy of the request with the authorization header; a RoundTripper must not modify the original
+func authorize(req *http.Request, tok *Token) *http.Request {
+	r := req.Clone(req.Context())
+	typ := tok.TokenType
+	if typ == "" || strings.EqualFold(typ, "bearer") {
+		typ = "Bearer"
+	}
+	r.Header.Set("Authorization", typ+" "+tok.AccessToken)
+	return r
+}
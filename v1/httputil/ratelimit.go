@@ -0,0 +1,100 @@
+package httputil
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/ratelimit"
+)
+
+// RateLimitOptions RateLimit 的选项
+// Key: 计算限流键的函数，例如按用户 ID；返回空字符串时不限流；默认使用 r.RemoteAddr 中的 IP，
+// 部署在反向代理之后时应改为从可信的代理头中取客户端 IP，否则所有请求会共享代理的额度
+// FailClosed: 存储出错时是否拒绝请求（返回 503），默认放行，避免 Redis 故障导致服务不可用
+//
+// RateLimitOptions contains options for RateLimit.
+// Key: Function computing the limit key, e.g. by user ID; an empty key skips limiting; defaults to the IP in r.RemoteAddr.
+// Behind a reverse proxy, take the client IP from a trusted proxy header instead, otherwise every request shares the proxy's quota
+// FailClosed: Whether to reject requests (503) when the store fails; by default they are let through so a Redis outage does not take the service down
+type RateLimitOptions struct {
+	Key        func(r *http.Request) string
+	FailClosed bool
+}
+
+// RateLimit 限流中间件：按 Key 计算的键检查限流器，设置 RateLimit-Limit、RateLimit-Remaining、RateLimit-Reset 响应头，
+// 被拒绝时返回 429 和 Retry-After
+// 参数:
+//   - next: 下一个处理器
+//   - limiter: 限流器
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - http.Handler: 包装后的处理器
+//
+// RateLimit is a rate limiting middleware: it checks the limiter with the key computed by Key and sets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers,
+// answering 429 with Retry-After when the request is denied.
+// Parameters:
+//   - next: The next handler
+//   - limiter: The limiter
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - http.Handler: The wrapped handler
+func RateLimit(next http.Handler, limiter ratelimit.Limiter, opts *RateLimitOptions) http.Handler {
+	var o RateLimitOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Key == nil {
+		o.Key = remoteIP
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := o.Key(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		res, err := limiter.Allow(r.Context(), key)
+		if err != nil {
+			if o.FailClosed {
+				writeJSONError(w, http.StatusServiceUnavailable, "rate limiter unavailable")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", ceilSeconds(res.ResetAfter))
+		if !res.Allowed {
+			h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+			writeJSONError(w, http.StatusTooManyRequests, "too many requests")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// remoteIP 返回 r.RemoteAddr 中的 IP
+//
+// remoteIP returns the IP in r.RemoteAddr
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds 将时长向上取整为秒数字符串
+//
+// ceilSeconds rounds a duration up to whole seconds as a string
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
@@ -0,0 +1,415 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// RedactedValue 脱敏后的占位值
+//
+// RedactedValue is the placeholder for redacted values
+const RedactedValue = "REDACTED"
+
+// ErrNoRecording 表示回放时没有与请求匹配的记录
+//
+// ErrNoRecording indicates that no recorded interaction matches the request during replay
+var ErrNoRecording = errors.New("no recorded interaction matches the request")
+
+// DefaultRedactHeaders 默认脱敏的请求头和响应头
+//
+// DefaultRedactHeaders are the request and response headers redacted by default
+var DefaultRedactHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Api-Key",
+	"X-Amz-Security-Token",
+	"X-Amz-Content-Sha256",
+	"X-Amz-Date",
+}
+
+// DefaultRedactQuery 默认脱敏的查询参数，例如预签名 URL 中的签名
+//
+// DefaultRedactQuery are the query parameters redacted by default, such as the signature in presigned URLs
+var DefaultRedactQuery = []string{
+	"X-Amz-Signature",
+	"X-Amz-Credential",
+	"X-Amz-Security-Token",
+	"X-Amz-Date",
+	"Signature",
+	"access_token",
+}
+
+// RecordedRequest 记录的请求
+// Method: 请求方法
+// URL: 脱敏后的完整 URL
+// Header: 脱敏后的请求头
+// Body: 文本请求体
+// BodyBase64: 非 UTF-8 的请求体，Base64 编码
+//
+// RecordedRequest is a recorded request.
+// Method: The request method
+// URL: The full URL after redaction
+// Header: The request headers after redaction
+// Body: A text request body
+// BodyBase64: A request body that is not UTF-8, Base64 encoded
+type RecordedRequest struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// RecordedResponse 记录的响应
+// Status: 状态码
+// Header: 脱敏后的响应头
+// Body: 文本响应体
+// BodyBase64: 非 UTF-8 的响应体，Base64 编码
+//
+// RecordedResponse is a recorded response.
+// Status: The status code
+// Header: The response headers after redaction
+// Body: A text response body
+// BodyBase64: A response body that is not UTF-8, Base64 encoded
+type RecordedResponse struct {
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+// Interaction 一次请求和响应
+//
+// Interaction is one request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecorderOptions 录制和回放的选项，两端应使用相同的脱敏规则
+// RedactHeaders: 需要脱敏的头，为 nil 时使用 DefaultRedactHeaders
+// RedactQuery: 需要脱敏的查询参数，为 nil 时使用 DefaultRedactQuery
+// Redact: 额外的自定义脱敏函数，在内置规则之后调用，例如替换响应体中的令牌
+// Match: 回放时判断请求与记录是否匹配，默认比较方法、脱敏后的 URL 和请求体
+//
+// RecorderOptions contains options for recording and replay; both sides should use the same redaction rules.
+// RedactHeaders: Headers to redact; DefaultRedactHeaders when nil
+// RedactQuery: Query parameters to redact; DefaultRedactQuery when nil
+// Redact: An extra custom redaction function called after the built-in rules, e.g. to replace tokens in response bodies
+// Match: Decides during replay whether a request matches a recording; by default the method, redacted URL and body are compared
+type RecorderOptions struct {
+	RedactHeaders []string
+	RedactQuery   []string
+	Redact        func(*Interaction)
+	Match         func(req *RecordedRequest, recorded *RecordedRequest) bool
+}
+
+// withDefaults 返回填充默认值后的选项
+//
+// withDefaults returns the options with defaults filled in
+func (o *RecorderOptions) withDefaults() RecorderOptions {
+	var out RecorderOptions
+	if o != nil {
+		out = *o
+	}
+	if out.RedactHeaders == nil {
+		out.RedactHeaders = DefaultRedactHeaders
+	}
+	if out.RedactQuery == nil {
+		out.RedactQuery = DefaultRedactQuery
+	}
+	if out.Match == nil {
+		out.Match = defaultMatch
+	}
+	return out
+}
+
+// Recorder 录制经过的请求和响应的 RoundTripper，用于生成契约测试的黄金文件
+// 敏感的头和查询参数在记录前脱敏；并发安全
+//
+// Recorder is a RoundTripper that records the requests and responses passing through it, to produce golden files for contract tests.
+// Sensitive headers and query parameters are redacted before recording; it is safe for concurrent use.
+type Recorder struct {
+	base http.RoundTripper
+	path string
+	o    RecorderOptions
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder 创建录制器
+// 参数:
+//   - path: 黄金文件路径，Save 时写入
+//   - base: 实际发送请求的 RoundTripper，为 nil 时使用 http.DefaultTransport
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *Recorder: 录制器，测试结束时调用 Save
+//
+// NewRecorder creates a recorder.
+// Parameters:
+//   - path: The golden file path, written by Save
+//   - base: The RoundTripper that actually sends requests; http.DefaultTransport when nil
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *Recorder: The recorder; call Save at the end of the test
+func NewRecorder(path string, base http.RoundTripper, opts *RecorderOptions) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Recorder{base: base, path: path, o: opts.withDefaults()}
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+//
+// RoundTrip implements the http.RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := drainBody(&resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	in := Interaction{
+		Request:  recordRequest(req, reqBody, &r.o),
+		Response: RecordedResponse{Status: resp.StatusCode, Header: redactHeader(resp.Header, r.o.RedactHeaders)},
+	}
+	in.Response.Body, in.Response.BodyBase64 = encodeBody(respBody)
+	if r.o.Redact != nil {
+		r.o.Redact(&in)
+	}
+	r.mu.Lock()
+	r.interactions = append(r.interactions, in)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Interactions 返回已录制的交互
+//
+// Interactions returns the interactions recorded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save 将已录制的交互写入黄金文件（格式化的 JSON），目录不存在时自动创建
+//
+// Save writes the recorded interactions to the golden file as indented JSON, creating the directory if needed.
+func (r *Recorder) Save() error {
+	data, err := json.MarshalIndent(r.Interactions(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// Replayer 从黄金文件回放响应的 RoundTripper，不发起任何网络请求，用于离线测试
+// 每条记录只使用一次，按文件中的顺序匹配，因此相同的请求重复出现时依次得到各自记录的响应；并发安全
+//
+// Replayer is a RoundTripper that replays responses from a golden file without touching the network, for offline tests.
+// Each recording is used once, matched in file order, so a repeated request gets each of its recorded responses in turn; it is safe for concurrent use.
+type Replayer struct {
+	o RecorderOptions
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewReplayer 读取黄金文件并创建回放器
+// 参数:
+//   - path: Recorder.Save 写入的黄金文件
+//   - opts: 选项，脱敏规则应与录制时一致，可以为 nil
+//
+// 返回:
+//   - *Replayer: 回放器
+//   - error: 文件无法读取或解析时返回错误
+//
+// NewReplayer reads a golden file and creates a replayer.
+// Parameters:
+//   - path: A golden file written by Recorder.Save
+//   - opts: Options whose redaction rules should match the recording; may be nil
+//
+// Returns:
+//   - *Replayer: The replayer
+//   - error: Returns an error if the file cannot be read or parsed
+func NewReplayer(path string, opts *RecorderOptions) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &Replayer{o: opts.withDefaults(), interactions: interactions, used: make([]bool, len(interactions))}, nil
+}
+
+// RoundTrip 实现 http.RoundTripper 接口，没有匹配的记录时返回 ErrNoRecording
+//
+// RoundTrip implements the http.RoundTripper interface, returning ErrNoRecording when no recording matches.
+func (p *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := drainBody(&req.Body)
+	if err != nil {
+		return nil, err
+	}
+	rec := recordRequest(req, body, &p.o)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range p.interactions {
+		if p.used[i] || !p.o.Match(&rec, &p.interactions[i].Request) {
+			continue
+		}
+		p.used[i] = true
+		return replayResponse(req, &p.interactions[i].Response)
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoRecording, rec.Method, rec.URL)
+}
+
+// Unused 返回尚未被回放的记录数，测试结束时可据此检查是否缺少了预期的请求
+//
+// Unused returns how many recordings have not been replayed, so a test can check at the end that no expected request was skipped.
+func (p *Replayer) Unused() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n := 0
+	for _, u := range p.used {
+		if !u {
+			n++
+		}
+	}
+	return n
+}
+
+// drainBody 读出 body 的全部内容并替换为可重新读取的副本
+//
+// drainBody reads the whole body and replaces it with a re-readable copy
+func drainBody(body *io.ReadCloser) ([]byte, error) {
+	if *body == nil || *body == http.NoBody {
+		return nil, nil
+	}
+	data, err := io.ReadAll(*body)
+	(*body).Close()
+	if err != nil {
+		return nil, err
+	}
+	*body = io.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// recordRequest 将请求转换为脱敏后的记录
+//
+// recordRequest converts a request to a redacted recording
+func recordRequest(req *http.Request, body []byte, o *RecorderOptions) RecordedRequest {
+	u := *req.URL
+	q := u.Query()
+	changed := false
+	for _, name := range o.RedactQuery {
+		if q.Has(name) {
+			q.Set(name, RedactedValue)
+			changed = true
+		}
+	}
+	if changed {
+		u.RawQuery = q.Encode()
+	}
+	rec := RecordedRequest{Method: req.Method, URL: u.String(), Header: redactHeader(req.Header, o.RedactHeaders)}
+	rec.Body, rec.BodyBase64 = encodeBody(body)
+	return rec
+}
+
+// redactHeader 返回脱敏后的头副本
+//
+// redactHeader returns a redacted copy of the headers
+func redactHeader(h http.Header, names []string) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := h.Clone()
+	for _, name := range names {
+		if _, ok := out[http.CanonicalHeaderKey(name)]; ok {
+			out.Set(name, RedactedValue)
+		}
+	}
+	return out
+}
+
+// encodeBody 将内容编码为文本或 Base64
+//
+// encodeBody encodes the content as text or Base64
+func encodeBody(data []byte) (text, b64 string) {
+	if utf8.Valid(data) {
+		return string(data), ""
+	}
+	return "", base64.StdEncoding.EncodeToString(data)
+}
+
+// decodeBody 还原 encodeBody 编码的内容
+//
+// decodeBody restores content encoded by encodeBody
+func decodeBody(text, b64 string) ([]byte, error) {
+	if b64 != "" {
+		return base64.StdEncoding.DecodeString(b64)
+	}
+	return []byte(text), nil
+}
+
+// defaultMatch 比较方法、URL 和请求体
+//
+// defaultMatch compares the method, URL and body
+func defaultMatch(req, recorded *RecordedRequest) bool {
+	return req.Method == recorded.Method && req.URL == recorded.URL &&
+		req.Body == recorded.Body && req.BodyBase64 == recorded.BodyBase64
+}
+
+// replayResponse 由记录构造响应
+//
+// replayResponse builds a response from a recording
+func replayResponse(req *http.Request, rec *RecordedResponse) (*http.Response, error) {
+	body, err := decodeBody(rec.Body, rec.BodyBase64)
+	if err != nil {
+		return nil, err
+	}
+	header := rec.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
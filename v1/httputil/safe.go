@@ -0,0 +1,291 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/netutil"
+)
+
+// ErrBlockedDestination 表示请求的目标地址被 SafeTransport 拦截
+//
+// ErrBlockedDestination indicates that SafeTransport blocked the request's destination
+var ErrBlockedDestination = errors.New("blocked destination")
+
+// blockedPrefixes 标准库的 netip.Addr 方法没有覆盖、但同样不应从外部请求访问的地址段
+//
+// blockedPrefixes are ranges not covered by the netip.Addr predicates that outbound requests must not reach either
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // 本网络 / "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // 运营商级 NAT，包括阿里云元数据 100.100.100.200 / carrier-grade NAT, including Aliyun metadata 100.100.100.200
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF 协议分配 / IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // 文档 / documentation
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 中继 / 6to4 relay anycast
+	netip.MustParsePrefix("198.18.0.0/15"),   // 基准测试 / benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // 文档 / documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // 文档 / documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // 保留，包括广播地址 / reserved, including broadcast
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // 本地 NAT64 / local-use NAT64
+	netip.MustParsePrefix("100::/64"),        // 丢弃 / discard-only
+	netip.MustParsePrefix("2001::/32"),       // Teredo，可嵌入任意 IPv4 地址 / Teredo, which can embed any IPv4 address
+	netip.MustParsePrefix("2001:db8::/32"),   // 文档 / documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4，可嵌入任意 IPv4 地址 / 6to4, which can embed any IPv4 address
+	netip.MustParsePrefix("fec0::/10"),       // 已废弃的站点本地地址 / deprecated site-local
+}
+
+// nat64Prefix 知名 NAT64 前缀，地址的低 32 位是被转换的 IPv4 地址
+//
+// nat64Prefix is the well-known NAT64 prefix, whose low 32 bits are the translated IPv4 address
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// SafeTransportOptions SafeTransport 的选项
+// AllowCIDRs: 明确允许的地址段，优先于默认拦截的内网地址，例如允许回调部署在内网的固定服务
+// DenyCIDRs: 额外拦截的地址段，例如公司的公网出口，优先于 AllowCIDRs
+// AllowHosts: 不做地址检查的主机名，支持 "*.example.com" 匹配所有子域名
+// DenyHosts: 拦截的主机名，格式同 AllowHosts，优先于 AllowHosts
+// Ports: 允许的端口，为空时允许任意端口；Webhook 场景通常只需要 80 和 443
+// Base: 底层 Transport，会被复制，为 nil 时复制 http.DefaultTransport；代理会被禁用，否则检查的是代理的地址
+// DialTimeout: 单次连接的超时时间，默认 10 秒
+// Resolver: 解析主机名的解析器，默认 net.DefaultResolver，例如使用 netutil.NewDoHResolver 避免本地 DNS 被劫持
+//
+// SafeTransportOptions contains options for SafeTransport.
+// AllowCIDRs: Ranges explicitly allowed, taking precedence over the internal ranges blocked by default, e.g. to call back a fixed service inside the network
+// DenyCIDRs: Extra ranges to block, such as the company's public egress, taking precedence over AllowCIDRs
+// AllowHosts: Hostnames exempt from address checks; "*.example.com" matches every subdomain
+// DenyHosts: Hostnames to block, in the same form as AllowHosts, taking precedence over AllowHosts
+// Ports: Allowed ports; any port when empty. Webhooks usually only need 80 and 443
+// Base: The underlying Transport, which is cloned; http.DefaultTransport is cloned when nil. Proxies are disabled, since the proxy's address would be checked instead
+// DialTimeout: Timeout of one connection attempt, 10 seconds by default
+// Resolver: The resolver for hostnames, net.DefaultResolver by default; e.g. netutil.NewDoHResolver guards against a hijacked local DNS
+type SafeTransportOptions struct {
+	AllowCIDRs  []string
+	DenyCIDRs   []string
+	AllowHosts  []string
+	DenyHosts   []string
+	Ports       []int
+	Base        *http.Transport
+	DialTimeout time.Duration
+	Resolver    netutil.Resolver
+}
+
+// SafeTransport 防止服务端请求伪造（SSRF）的 RoundTripper，用于抓取用户提供的 URL，例如 Webhook 回调和链接预览
+// 默认拦截解析到回环、内网、链路本地（包括云元数据 169.254.169.254）、组播和保留地址的请求；
+// 检查在建立连接时对实际连接的 IP 进行，因此 DNS 重绑定和重定向到内网地址同样会被拦截
+//
+// SafeTransport is a RoundTripper guarding against server-side request forgery (SSRF), for fetching user-supplied URLs such as webhook callbacks and link previews.
+// By default it blocks requests resolving to loopback, private, link-local (including cloud metadata at 169.254.169.254), multicast and reserved addresses.
+// The check runs on the IP actually connected to, so DNS rebinding and redirects to internal addresses are blocked as well.
+type SafeTransport struct {
+	transport  *http.Transport
+	resolver   netutil.Resolver
+	dialer     *net.Dialer
+	allow      []netip.Prefix
+	deny       []netip.Prefix
+	allowHosts []string
+	denyHosts  []string
+	ports      []int
+}
+
+// NewSafeTransport 创建防 SSRF 的 RoundTripper
+// 参数:
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *SafeTransport: 可以作为 http.Client 的 Transport
+//   - error: 地址段格式无效时返回错误
+//
+// NewSafeTransport creates an SSRF-guarding RoundTripper.
+// Parameters:
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *SafeTransport: Usable as the Transport of an http.Client
+//   - error: Returns an error if a range is malformed
+func NewSafeTransport(opts *SafeTransportOptions) (*SafeTransport, error) {
+	var o SafeTransportOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.DialTimeout <= 0 {
+		o.DialTimeout = 10 * time.Second
+	}
+	if o.Resolver == nil {
+		o.Resolver = net.DefaultResolver
+	}
+	t := &SafeTransport{
+		resolver:   o.Resolver,
+		dialer:     &net.Dialer{Timeout: o.DialTimeout, KeepAlive: 30 * time.Second},
+		allowHosts: normalizeHosts(o.AllowHosts),
+		denyHosts:  normalizeHosts(o.DenyHosts),
+		ports:      o.Ports,
+	}
+	var err error
+	if t.allow, err = parsePrefixes(o.AllowCIDRs); err != nil {
+		return nil, err
+	}
+	if t.deny, err = parsePrefixes(o.DenyCIDRs); err != nil {
+		return nil, err
+	}
+
+	if o.Base != nil {
+		t.transport = o.Base.Clone()
+	} else {
+		t.transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	t.transport.Proxy = nil
+	t.transport.DialContext = t.dialContext
+	// 自定义 TLS 拨号会绕过 dialContext
+	t.transport.DialTLSContext = nil
+	return t, nil
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+//
+// RoundTrip implements the http.RoundTripper interface.
+func (t *SafeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("%w: scheme %q", ErrBlockedDestination, req.URL.Scheme)
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// CloseIdleConnections 关闭空闲连接
+//
+// CloseIdleConnections closes idle connections.
+func (t *SafeTransport) CloseIdleConnections() {
+	t.transport.CloseIdleConnections()
+}
+
+// Allowed 报告是否允许连接到 ip，可用于在保存 Webhook 地址时提前校验
+//
+// Allowed reports whether connecting to ip is allowed, useful for validating webhook addresses when they are saved.
+func (t *SafeTransport) Allowed(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	for _, p := range t.deny {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	for _, p := range t.allow {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	if nat64Prefix.Contains(ip) {
+		b := ip.As16()
+		ip = netip.AddrFrom4([4]byte(b[12:]))
+	}
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || ip.IsLoopback() {
+		return false
+	}
+	for _, p := range blockedPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// dialContext 解析主机名并只连接到通过检查的 IP
+//
+// dialContext resolves the hostname and connects only to IPs that pass the checks
+func (t *SafeTransport) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.ports) > 0 && !slices.Contains(t.ports, port) {
+		return nil, fmt.Errorf("%w: port %d", ErrBlockedDestination, port)
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if matchHost(t.denyHosts, host) {
+		return nil, fmt.Errorf("%w: host %s", ErrBlockedDestination, host)
+	}
+	trusted := matchHost(t.allowHosts, host)
+
+	var ips []netip.Addr
+	if ip, err := netip.ParseAddr(host); err == nil {
+		ips = []netip.Addr{ip}
+	} else if ips, err = t.resolver.LookupNetIP(ctx, "ip", host); err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		if !trusted && !t.Allowed(ip) {
+			if host == ip.String() {
+				lastErr = fmt.Errorf("%w: address %s", ErrBlockedDestination, ip)
+			} else {
+				lastErr = fmt.Errorf("%w: %s resolves to %s", ErrBlockedDestination, host, ip)
+			}
+			continue
+		}
+		conn, err := t.dialer.DialContext(ctx, network, net.JoinHostPort(ip.Unmap().String(), portStr))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no addresses for %s", host)
+	}
+	return nil, lastErr
+}
+
+// parsePrefixes 解析地址段，也接受单个 IP
+//
+// parsePrefixes parses ranges, also accepting single IPs
+func parsePrefixes(cidrs []string) ([]netip.Prefix, error) {
+	out := make([]netip.Prefix, 0, len(cidrs))
+	for _, s := range cidrs {
+		if p, err := netip.ParsePrefix(s); err == nil {
+			out = append(out, p.Masked())
+			continue
+		}
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", s)
+		}
+		out = append(out, netip.PrefixFrom(ip.Unmap(), ip.Unmap().BitLen()))
+	}
+	return out, nil
+}
+
+// normalizeHosts 把主机名转换为小写并去掉末尾的点
+//
+// normalizeHosts lower-cases hostnames and strips trailing dots
+func normalizeHosts(hosts []string) []string {
+	out := make([]string, len(hosts))
+	for i, h := range hosts {
+		out[i] = strings.TrimSuffix(strings.ToLower(h), ".")
+	}
+	return out
+}
+
+// matchHost 判断主机名是否匹配列表中的某项，"*.example.com" 匹配所有子域名但不匹配 example.com 本身
+//
+// matchHost reports whether a hostname matches an entry; "*.example.com" matches every subdomain but not example.com itself
+func matchHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		if suffix, ok := strings.CutPrefix(p, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+		} else if p == host {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/supergodk/go-utils/v1/tracing"
+)
+
+// TracingTransport 返回为每个请求创建名为 "HTTP <方法>" 的 span 的 RoundTripper，span 在收到响应头时结束，5xx 响应标记为失败
+// 参数:
+//   - base: 底层 RoundTripper，为 nil 时使用 http.DefaultTransport
+//
+// 返回:
+//   - http.RoundTripper: 包装后的 RoundTripper
+//
+// TracingTransport returns a RoundTripper creating a span named "HTTP <method>" for every request; the span ends when the response headers arrive, and 5xx responses mark it as failed.
+// Parameters:
+//   - base: The underlying RoundTripper; http.DefaultTransport when nil
+//
+// Returns:
+//   - http.RoundTripper: The wrapped RoundTripper
+func TracingTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &tracingTransport{base: base}
+}
+
+// tracingTransport 创建 span 的 RoundTripper
+//
+// tracingTransport is a RoundTripper creating spans
+type tracingTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+//
+// RoundTrip implements the http.RoundTripper interface.
+func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, end := tracing.StartSpan(req.Context(), "HTTP "+req.Method)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	switch {
+	case err != nil:
+		end(err)
+	case resp.StatusCode >= http.StatusInternalServerError:
+		end(fmt.Errorf("%s %s: status %d", req.Method, req.URL.Redacted(), resp.StatusCode))
+	default:
+		end(nil)
+	}
+	return resp, err
+}
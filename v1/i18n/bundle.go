@@ -0,0 +1,402 @@
+// Package i18n 提供轻量的多语言消息目录，内置中文和英文的复数规则及 zh/en 互相回退
+//
+// Package i18n provides a lightweight message catalog with built-in zh/en plural rules and zh/en fallback.
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"path"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// LangZh 中文
+	//
+	// LangZh is Chinese
+	LangZh = "zh"
+	// LangEn 英文
+	//
+	// LangEn is English
+	LangEn = "en"
+	// CountArg 用于选择复数形式的参数名
+	//
+	// CountArg is the argument name used to select the plural form
+	CountArg = "count"
+)
+
+// ErrLoadMessages 表示消息文件加载失败
+//
+// ErrLoadMessages indicates that loading a message file failed
+var ErrLoadMessages = errors.New("load messages failed")
+
+// Args 消息参数，消息中的 {name} 会被替换为 Args["name"]
+//
+// Args are message arguments; {name} in a message is replaced with Args["name"].
+type Args map[string]any
+
+// Translator 翻译器，其他包（例如校验和错误消息）通过该接口接入消息目录
+//
+// Translator translates message keys; other packages (such as validation and error messages) plug into the catalog through this interface.
+type Translator interface {
+	T(lang, key string, args Args) string
+}
+
+// PluralRule 复数规则，根据数量返回复数类别（"zero"、"one"、"other" 等）
+//
+// PluralRule returns the plural category ("zero", "one", "other", ...) for a count.
+type PluralRule func(n float64) string
+
+// message 一条消息，plural 非空时按复数类别选择
+//
+// message is a single message; when plural is non-empty the form is chosen by plural category
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+// Bundle 消息目录，并发安全
+//
+// Bundle is a message catalog, safe for concurrent use.
+type Bundle struct {
+	mu        sync.RWMutex
+	messages  map[string]map[string]message
+	rules     map[string]PluralRule
+	fallbacks []string
+}
+
+// NewBundle 创建消息目录
+// 查找顺序为：完整语言标签（如 zh-CN）、基础语言（zh）、fallbacks 中的语言，都没有时返回键本身
+// 参数:
+//   - fallbacks: 回退语言，为空时依次使用 LangZh 和 LangEn
+//
+// 返回:
+//   - *Bundle: 消息目录
+//
+// NewBundle creates a message catalog.
+// Lookup order is: the full language tag (e.g. zh-CN), the base language (zh), then the fallback languages; the key itself is returned when none match.
+// Parameters:
+//   - fallbacks: Fallback languages; LangZh then LangEn when empty
+//
+// Returns:
+//   - *Bundle: The message catalog
+func NewBundle(fallbacks ...string) *Bundle {
+	if len(fallbacks) == 0 {
+		fallbacks = []string{LangZh, LangEn}
+	}
+	return &Bundle{
+		messages:  make(map[string]map[string]message),
+		rules:     map[string]PluralRule{LangZh: zhPlural, LangEn: enPlural},
+		fallbacks: fallbacks,
+	}
+}
+
+// SetPluralRule 设置语言的复数规则，未设置规则的语言使用英文规则
+//
+// SetPluralRule sets the plural rule of a language; languages without a rule use the English rule.
+func (b *Bundle) SetPluralRule(lang string, rule PluralRule) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rules[normalizeLang(lang)] = rule
+}
+
+// Add 以代码方式添加消息，值为字符串或复数形式 map（键为 "zero"、"one"、"other"）
+// 参数:
+//   - lang: 语言
+//   - messages: 消息，嵌套的 map 会以 "." 连接成键
+//
+// Add adds messages in code; values are strings or plural-form maps (keyed by "zero", "one", "other").
+// Parameters:
+//   - lang: The language
+//   - messages: The messages; nested maps are joined into keys with "."
+func (b *Bundle) Add(lang string, messages map[string]any) {
+	flat := make(map[string]message)
+	flatten("", messages, flat)
+
+	lang = normalizeLang(lang)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	m := b.messages[lang]
+	if m == nil {
+		m = make(map[string]message, len(flat))
+		b.messages[lang] = m
+	}
+	for k, v := range flat {
+		m[k] = v
+	}
+}
+
+// LoadData 解析 JSON 或 YAML 格式的消息并添加到目录
+// 参数:
+//   - lang: 语言
+//   - data: 消息内容
+//   - format: "json" 或 "yaml"
+//
+// 返回:
+//   - error: 格式不支持或解析失败时返回 ErrLoadMessages
+//
+// LoadData parses messages in JSON or YAML format and adds them to the catalog.
+// Parameters:
+//   - lang: The language
+//   - data: The message content
+//   - format: "json" or "yaml"
+//
+// Returns:
+//   - error: Returns ErrLoadMessages if the format is unsupported or parsing fails
+func (b *Bundle) LoadData(lang string, data []byte, format string) error {
+	var messages map[string]any
+	var err error
+	switch strings.ToLower(format) {
+	case "json":
+		err = json.Unmarshal(data, &messages)
+	case "yaml", "yml":
+		err = yaml.Unmarshal(data, &messages)
+	default:
+		err = fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrLoadMessages, err)
+	}
+	b.Add(lang, messages)
+	return nil
+}
+
+// LoadFS 从文件系统（通常是 embed.FS）加载目录下的所有消息文件，文件名即语言，例如 zh.json、en.yaml、zh-TW.yml
+// 参数:
+//   - fsys: 文件系统
+//   - dir: 目录，"." 表示根目录
+//
+// 返回:
+//   - error: 读取或解析失败时返回 ErrLoadMessages
+//
+// LoadFS loads all message files in a directory of a file system (usually an embed.FS); the file name is the language, e.g. zh.json, en.yaml, zh-TW.yml.
+// Parameters:
+//   - fsys: The file system
+//   - dir: The directory; "." means the root
+//
+// Returns:
+//   - error: Returns ErrLoadMessages if reading or parsing fails
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrLoadMessages, err)
+	}
+	for _, e := range entries {
+		ext := path.Ext(e.Name())
+		if e.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrLoadMessages, err)
+		}
+		if err := b.LoadData(strings.TrimSuffix(e.Name(), ext), data, ext[1:]); err != nil {
+			return fmt.Errorf("%s: %w", e.Name(), err)
+		}
+	}
+	return nil
+}
+
+// T 翻译消息
+// 参数 Args[CountArg] 为数字时按该语言的复数规则选择复数形式，消息中的 {name} 替换为对应参数
+// 参数:
+//   - lang: 语言标签，例如 "zh-CN"、"en"
+//   - key: 消息键
+//   - args: 参数，可以为 nil
+//
+// 返回:
+//   - string: 翻译后的消息，找不到时返回 key
+//
+// T translates a message.
+// When Args[CountArg] is a number the plural form is chosen by the language's plural rule, and {name} in the message is replaced with the matching argument.
+// Parameters:
+//   - lang: Language tag, e.g. "zh-CN", "en"
+//   - key: Message key
+//   - args: Arguments, may be nil
+//
+// Returns:
+//   - string: The translated message, or key if not found
+func (b *Bundle) T(lang, key string, args Args) string {
+	b.mu.RLock()
+	msg, found, rule := b.lookup(lang, key)
+	b.mu.RUnlock()
+	if !found {
+		return key
+	}
+
+	text := msg.text
+	if len(msg.plural) > 0 {
+		text = msg.plural["other"]
+		if n, ok := toFloat(args[CountArg]); ok {
+			if n == 0 && msg.plural["zero"] != "" {
+				text = msg.plural["zero"]
+			} else if s := msg.plural[rule(n)]; s != "" {
+				text = s
+			}
+		}
+	}
+	return interpolate(text, args)
+}
+
+// Has 报告在 lang 及其回退语言中是否存在 key
+//
+// Has reports whether key exists in lang or its fallback languages.
+func (b *Bundle) Has(lang, key string) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, found, _ := b.lookup(lang, key)
+	return found
+}
+
+// lookup 按回退顺序查找消息，返回消息和所用语言的复数规则，调用方需持有读锁
+//
+// lookup finds a message in fallback order and returns it with the plural rule of the matched language; the caller must hold the read lock
+func (b *Bundle) lookup(lang, key string) (message, bool, PluralRule) {
+	lang = normalizeLang(lang)
+	base, _, _ := strings.Cut(lang, "-")
+	candidates := append([]string{lang, base}, b.fallbacks...)
+	for _, l := range candidates {
+		if msg, ok := b.messages[l][key]; ok {
+			rule := b.rules[l]
+			if rule == nil {
+				lb, _, _ := strings.Cut(l, "-")
+				if rule = b.rules[lb]; rule == nil {
+					rule = enPlural
+				}
+			}
+			return msg, true, rule
+		}
+	}
+	return message{}, false, nil
+}
+
+// normalizeLang 规范化语言标签：下划线替换为连字符，基础语言小写，例如 zh_cn -> zh-CN
+//
+// normalizeLang normalizes a language tag: underscores become hyphens and the base language is lowercased, e.g. zh_cn -> zh-CN
+func normalizeLang(lang string) string {
+	lang = strings.ReplaceAll(strings.TrimSpace(lang), "_", "-")
+	base, region, ok := strings.Cut(lang, "-")
+	if !ok {
+		return strings.ToLower(base)
+	}
+	if len(region) == 2 {
+		region = strings.ToUpper(region)
+	}
+	return strings.ToLower(base) + "-" + region
+}
+
+// flatten 将嵌套 map 展开为以 "." 连接的键，键全部为复数类别的 map 视为复数形式
+//
+// flatten expands nested maps into "."-joined keys; a map whose keys are all plural categories is treated as plural forms
+func flatten(prefix string, in map[string]any, out map[string]message) {
+	for k, v := range in {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if forms, ok := pluralForms(v); ok {
+				out[key] = message{plural: forms}
+			} else {
+				flatten(key, v, out)
+			}
+		case nil:
+		default:
+			out[key] = message{text: fmt.Sprint(v)}
+		}
+	}
+}
+
+// pluralForms 如果 map 的键全部是复数类别，返回复数形式
+//
+// pluralForms returns the plural forms if every key of the map is a plural category
+func pluralForms(m map[string]any) (map[string]string, bool) {
+	if len(m) == 0 {
+		return nil, false
+	}
+	forms := make(map[string]string, len(m))
+	for k, v := range m {
+		switch k {
+		case "zero", "one", "two", "few", "many", "other":
+		default:
+			return nil, false
+		}
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		forms[k] = s
+	}
+	return forms, true
+}
+
+// interpolate 将 {name} 替换为参数值
+//
+// interpolate replaces {name} with argument values
+func interpolate(text string, args Args) string {
+	if len(args) == 0 || !strings.Contains(text, "{") {
+		return text
+	}
+	pairs := make([]string, 0, len(args)*2)
+	for k, v := range args {
+		pairs = append(pairs, "{"+k+"}", fmt.Sprint(v))
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// toFloat 将数字类型的参数转换为 float64
+//
+// toFloat converts a numeric argument to float64
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// zhPlural 中文没有复数变化
+//
+// zhPlural: Chinese has no plural inflection
+func zhPlural(float64) string {
+	return "other"
+}
+
+// enPlural 英文整数 1 为单数，其余为复数
+//
+// enPlural: in English the integer 1 is singular and everything else plural
+func enPlural(n float64) string {
+	if math.Abs(n) == 1 {
+		return "one"
+	}
+	return "other"
+}
@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// langKey 语言在 context 中的键
+//
+// langKey is the context key of the language
+type langKey struct{}
+
+// WithLanguage 将语言写入 context
+//
+// WithLanguage stores the language in the context.
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey{}, lang)
+}
+
+// LanguageFromContext 从 context 读取语言，不存在时返回 def
+//
+// LanguageFromContext reads the language from the context, returning def if absent.
+func LanguageFromContext(ctx context.Context, def string) string {
+	if lang, ok := ctx.Value(langKey{}).(string); ok && lang != "" {
+		return lang
+	}
+	return def
+}
+
+// MatchLanguage 按 Accept-Language 头的权重（q 值）选择最合适的受支持语言
+// 先比较完整标签，再比较基础语言，例如 "zh-TW;q=0.9, en;q=0.8" 在只支持 zh 和 en 时返回 zh
+// 参数:
+//   - acceptLanguage: Accept-Language 头的值
+//   - supported: 受支持的语言，第一个作为默认值
+//
+// 返回:
+//   - string: 匹配到的语言，没有匹配时返回 supported[0]，supported 为空时返回空字符串
+//
+// MatchLanguage picks the best supported language by the weights (q values) of an Accept-Language header.
+// Full tags are compared first, then base languages; e.g. "zh-TW;q=0.9, en;q=0.8" yields zh when only zh and en are supported.
+// Parameters:
+//   - acceptLanguage: The Accept-Language header value
+//   - supported: Supported languages; the first is the default
+//
+// Returns:
+//   - string: The matched language, supported[0] if nothing matches, or an empty string if supported is empty
+func MatchLanguage(acceptLanguage string, supported ...string) string {
+	if len(supported) == 0 {
+		return ""
+	}
+	type tag struct {
+		lang string
+		q    float64
+	}
+	var tags []tag
+	for part := range strings.SplitSeq(acceptLanguage, ",") {
+		lang, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if lang == "" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		if q > 0 {
+			tags = append(tags, tag{normalizeLang(lang), q})
+		}
+	}
+	slices.SortStableFunc(tags, func(a, b tag) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	for _, t := range tags {
+		base, _, _ := strings.Cut(t.lang, "-")
+		for _, s := range supported {
+			if normalizeLang(s) == t.lang {
+				return s
+			}
+		}
+		for _, s := range supported {
+			sb, _, _ := strings.Cut(normalizeLang(s), "-")
+			if sb == base {
+				return s
+			}
+		}
+	}
+	return supported[0]
+}
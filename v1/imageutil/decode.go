@@ -0,0 +1,193 @@
+// Package imageutil 提供图片解码、缩放和缩略图生成相关的工具函数
+//
+// Package imageutil provides image decoding, resizing and thumbnail generation utility functions.
+package imageutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/webp"
+)
+
+const (
+	// FormatJPEG JPEG 格式
+	//
+	// FormatJPEG is the JPEG format
+	FormatJPEG = "jpeg"
+	// FormatPNG PNG 格式
+	//
+	// FormatPNG is the PNG format
+	FormatPNG = "png"
+	// FormatGIF GIF 格式
+	//
+	// FormatGIF is the GIF format
+	FormatGIF = "gif"
+	// FormatWebP WebP 格式
+	//
+	// FormatWebP is the WebP format
+	FormatWebP = "webp"
+
+	// DefaultMaxDecodeBytes 默认允许解码的最大字节数（32MB）
+	//
+	// DefaultMaxDecodeBytes is the default maximum number of bytes allowed to decode (32MB)
+	DefaultMaxDecodeBytes = 32 << 20
+	// DefaultMaxPixels 默认允许解码的最大像素数（4000 万），用于防御解压炸弹
+	//
+	// DefaultMaxPixels is the default maximum number of pixels allowed to decode (40 million), guarding against decompression bombs
+	DefaultMaxPixels = 40_000_000
+)
+
+var (
+	// ErrUnsupportedFormat 表示不支持的图片格式
+	//
+	// ErrUnsupportedFormat indicates an unsupported image format.
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	// ErrImageTooLarge 表示图片尺寸或文件大小超出限制
+	//
+	// ErrImageTooLarge indicates that the image dimensions or file size exceed the limit.
+	ErrImageTooLarge = errors.New("image too large")
+	// ErrDecodeImage 表示图片解码失败
+	//
+	// ErrDecodeImage indicates that image decoding failed.
+	ErrDecodeImage = errors.New("failed to decode image")
+)
+
+// DetectFormat 通过文件头魔数识别图片格式
+// 参数:
+//   - data: 图片数据（至少需要前 12 个字节）
+//
+// 返回:
+//   - 格式常量（FormatJPEG 等），无法识别时返回空字符串
+//
+// DetectFormat detects the image format by its magic bytes.
+// Parameters:
+//   - data: Image data (at least the first 12 bytes are required)
+//
+// Returns:
+//   - The format constant (FormatJPEG, etc.), or an empty string if not recognized
+func DetectFormat(data []byte) string {
+	switch {
+	case len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF:
+		return FormatJPEG
+	case len(data) >= 8 && bytes.Equal(data[:8], []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}):
+		return FormatPNG
+	case len(data) >= 6 && (bytes.Equal(data[:6], []byte("GIF87a")) || bytes.Equal(data[:6], []byte("GIF89a"))):
+		return FormatGIF
+	case len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return FormatWebP
+	}
+	return ""
+}
+
+// ContentType 返回图片格式对应的 MIME 类型，可直接用于对象存储上传
+//
+// ContentType returns the MIME type of the image format, which can be used directly for object storage uploads.
+func ContentType(format string) string {
+	switch format {
+	case FormatJPEG:
+		return "image/jpeg"
+	case FormatPNG:
+		return "image/png"
+	case FormatGIF:
+		return "image/gif"
+	case FormatWebP:
+		return "image/webp"
+	}
+	return "application/octet-stream"
+}
+
+// DecodeImage 读取并解码图片，自动识别 JPEG/PNG/GIF/WebP 格式
+// JPEG 图片会根据 EXIF 方向信息自动旋转为正确方向；GIF 只解码第一帧
+// 读取的数据超过 DefaultMaxDecodeBytes 或像素数超过 DefaultMaxPixels 时返回 ErrImageTooLarge
+// 参数:
+//   - r: 图片数据来源
+//
+// 返回:
+//   - image.Image: 解码后的图片
+//   - string: 图片格式（FormatJPEG 等）
+//   - error: 读取失败、格式不支持或解码失败时返回错误
+//
+// DecodeImage reads and decodes an image, automatically detecting JPEG/PNG/GIF/WebP formats.
+// JPEG images are automatically rotated to the correct orientation based on EXIF data; only the first frame of a GIF is decoded.
+// Returns ErrImageTooLarge if the data exceeds DefaultMaxDecodeBytes or the pixel count exceeds DefaultMaxPixels.
+// Parameters:
+//   - r: Source of the image data
+//
+// Returns:
+//   - image.Image: The decoded image
+//   - string: The image format (FormatJPEG, etc.)
+//   - error: Returns an error if reading fails, the format is unsupported or decoding fails
+func DecodeImage(r io.Reader) (image.Image, string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, DefaultMaxDecodeBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrDecodeImage, err)
+	}
+	if len(data) > DefaultMaxDecodeBytes {
+		return nil, "", fmt.Errorf("%w: more than %d bytes", ErrImageTooLarge, DefaultMaxDecodeBytes)
+	}
+	return DecodeImageBytes(data)
+}
+
+// DecodeImageBytes 与 DecodeImage 相同，但直接接受字节数据
+//
+// DecodeImageBytes is the same as DecodeImage but accepts byte data directly.
+func DecodeImageBytes(data []byte) (image.Image, string, error) {
+	format := DetectFormat(data)
+	if format == "" {
+		return nil, "", ErrUnsupportedFormat
+	}
+
+	// 先只解析尺寸，防止超大图片耗尽内存
+	cfg, err := decodeConfig(format, data)
+	if err != nil {
+		return nil, format, fmt.Errorf("%w: %v", ErrDecodeImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > DefaultMaxPixels {
+		return nil, format, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, cfg.Width, cfg.Height)
+	}
+
+	var img image.Image
+	switch format {
+	case FormatJPEG:
+		img, err = jpeg.Decode(bytes.NewReader(data))
+	case FormatPNG:
+		img, err = png.Decode(bytes.NewReader(data))
+	case FormatGIF:
+		img, err = gif.Decode(bytes.NewReader(data))
+	case FormatWebP:
+		img, err = webp.Decode(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, format, fmt.Errorf("%w: %v", ErrDecodeImage, err)
+	}
+
+	if format == FormatJPEG {
+		img = ApplyOrientation(img, ReadOrientation(data))
+	}
+	return img, format, nil
+}
+
+// decodeConfig 按格式解析图片尺寸
+//
+// decodeConfig decodes the image dimensions according to the format
+func decodeConfig(format string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case FormatJPEG:
+		return jpeg.DecodeConfig(r)
+	case FormatPNG:
+		return png.DecodeConfig(r)
+	case FormatGIF:
+		return gif.DecodeConfig(r)
+	case FormatWebP:
+		return webp.DecodeConfig(r)
+	}
+	return image.Config{}, ErrUnsupportedFormat
+}
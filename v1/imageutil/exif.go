@@ -0,0 +1,185 @@
+package imageutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+)
+
+// Orientation EXIF 方向值（1-8）
+//
+// Orientation is the EXIF orientation value (1-8)
+type Orientation int
+
+const (
+	// OrientationNormal 正常方向
+	//
+	// OrientationNormal is the normal orientation
+	OrientationNormal Orientation = 1
+	// OrientationFlipH 水平翻转
+	//
+	// OrientationFlipH is flipped horizontally
+	OrientationFlipH Orientation = 2
+	// OrientationRotate180 旋转 180 度
+	//
+	// OrientationRotate180 is rotated 180 degrees
+	OrientationRotate180 Orientation = 3
+	// OrientationFlipV 垂直翻转
+	//
+	// OrientationFlipV is flipped vertically
+	OrientationFlipV Orientation = 4
+	// OrientationTranspose 沿左上-右下对角线翻转
+	//
+	// OrientationTranspose is flipped along the top-left to bottom-right diagonal
+	OrientationTranspose Orientation = 5
+	// OrientationRotate90 需要顺时针旋转 90 度
+	//
+	// OrientationRotate90 requires a 90 degree clockwise rotation
+	OrientationRotate90 Orientation = 6
+	// OrientationTransverse 沿右上-左下对角线翻转
+	//
+	// OrientationTransverse is flipped along the top-right to bottom-left diagonal
+	OrientationTransverse Orientation = 7
+	// OrientationRotate270 需要顺时针旋转 270 度
+	//
+	// OrientationRotate270 requires a 270 degree clockwise rotation
+	OrientationRotate270 Orientation = 8
+)
+
+// exifOrientationTag EXIF 方向标签
+//
+// exifOrientationTag is the EXIF orientation tag
+const exifOrientationTag = 0x0112
+
+// ReadOrientation 从 JPEG 数据的 EXIF（APP1）段中读取方向信息
+// 没有 EXIF 信息或解析失败时返回 OrientationNormal
+//
+// ReadOrientation reads the orientation from the EXIF (APP1) segment of JPEG data.
+// Returns OrientationNormal if there is no EXIF data or parsing fails.
+func ReadOrientation(data []byte) Orientation {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return OrientationNormal
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return OrientationNormal
+		}
+		marker := data[pos+1]
+		// SOS 之后是图像数据，不会再有 EXIF
+		if marker == 0xDA || marker == 0xD9 {
+			return OrientationNormal
+		}
+		size := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if size < 2 || pos+2+size > len(data) {
+			return OrientationNormal
+		}
+		segment := data[pos+4 : pos+2+size]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return parseTIFFOrientation(segment[6:])
+		}
+		pos += 2 + size
+	}
+	return OrientationNormal
+}
+
+// parseTIFFOrientation 从 TIFF 结构的 IFD0 中读取方向标签
+//
+// parseTIFFOrientation reads the orientation tag from IFD0 of a TIFF structure
+func parseTIFFOrientation(tiff []byte) Orientation {
+	if len(tiff) < 8 {
+		return OrientationNormal
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return OrientationNormal
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return OrientationNormal
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) != exifOrientationTag {
+			continue
+		}
+		// 类型为 SHORT，值直接存放在条目的前两个字节
+		o := Orientation(order.Uint16(tiff[entry+8:]))
+		if o < OrientationNormal || o > OrientationRotate270 {
+			return OrientationNormal
+		}
+		return o
+	}
+	return OrientationNormal
+}
+
+// ApplyOrientation 根据 EXIF 方向值旋转/翻转图片，使其以正确方向显示
+// 参数:
+//   - img: 原始图片
+//   - o: EXIF 方向值
+//
+// 返回:
+//   - 校正后的图片，方向为 OrientationNormal 或无效时原样返回
+//
+// ApplyOrientation rotates/flips the image according to the EXIF orientation so that it displays correctly.
+// Parameters:
+//   - img: The original image
+//   - o: The EXIF orientation value
+//
+// Returns:
+//   - The corrected image, or the original image if the orientation is OrientationNormal or invalid
+func ApplyOrientation(img image.Image, o Orientation) image.Image {
+	if o <= OrientationNormal || o > OrientationRotate270 {
+		return img
+	}
+
+	b := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	// 5-8 需要交换宽高
+	dw, dh := w, h
+	if o >= OrientationTranspose {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch o {
+			case OrientationFlipH:
+				sx, sy = w-1-x, y
+			case OrientationRotate180:
+				sx, sy = w-1-x, h-1-y
+			case OrientationFlipV:
+				sx, sy = x, h-1-y
+			case OrientationTranspose:
+				sx, sy = y, x
+			case OrientationRotate90:
+				sx, sy = y, h-1-x
+			case OrientationTransverse:
+				sx, sy = w-1-y, h-1-x
+			case OrientationRotate270:
+				sx, sy = w-1-y, x
+			}
+			si := src.PixOffset(sx, sy)
+			di := dst.PixOffset(x, y)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,234 @@
+package imageutil
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// Filter 缩放使用的插值算法
+//
+// Filter is the interpolation algorithm used for resizing
+type Filter int
+
+const (
+	// FilterCatmullRom Catmull-Rom 插值，质量最好但速度最慢（默认）
+	//
+	// FilterCatmullRom is Catmull-Rom interpolation, the best quality but slowest (default)
+	FilterCatmullRom Filter = iota
+	// FilterBilinear 双线性插值，质量和速度较均衡
+	//
+	// FilterBilinear is bilinear interpolation, balancing quality and speed
+	FilterBilinear
+	// FilterApproxBiLinear 近似双线性插值，速度快
+	//
+	// FilterApproxBiLinear is approximate bilinear interpolation, fast
+	FilterApproxBiLinear
+	// FilterNearest 最近邻插值，速度最快，适合像素画
+	//
+	// FilterNearest is nearest-neighbor interpolation, the fastest, suitable for pixel art
+	FilterNearest
+)
+
+const (
+	// DefaultJPEGQuality 默认 JPEG 编码质量
+	//
+	// DefaultJPEGQuality is the default JPEG encoding quality
+	DefaultJPEGQuality = 85
+)
+
+// ResizeOptions 缩放选项
+// Filter: 插值算法，默认 FilterCatmullRom
+// Background: 透明像素的背景色，为 nil 时保留透明度（编码为 JPEG 时会使用白色）
+//
+// ResizeOptions contains options for resizing.
+// Filter: Interpolation algorithm, defaults to FilterCatmullRom
+// Background: Background color for transparent pixels, transparency is kept if nil (white is used when encoding to JPEG)
+type ResizeOptions struct {
+	Filter     Filter
+	Background color.Color
+}
+
+// interpolator 返回插值算法对应的实现
+//
+// interpolator returns the implementation of the interpolation algorithm
+func (o *ResizeOptions) interpolator() draw.Interpolator {
+	if o == nil {
+		return draw.CatmullRom
+	}
+	switch o.Filter {
+	case FilterBilinear:
+		return draw.BiLinear
+	case FilterApproxBiLinear:
+		return draw.ApproxBiLinear
+	case FilterNearest:
+		return draw.NearestNeighbor
+	}
+	return draw.CatmullRom
+}
+
+// Resize 将图片缩放到指定尺寸，不保持宽高比
+// 宽或高为 0 时按另一边等比计算
+// 参数:
+//   - img: 原始图片
+//   - width: 目标宽度
+//   - height: 目标高度
+//   - opts: 缩放选项，可以为 nil
+//
+// 返回:
+//   - 缩放后的图片
+//
+// Resize scales the image to the specified size without preserving the aspect ratio.
+// If the width or height is 0, it is computed proportionally from the other side.
+// Parameters:
+//   - img: The original image
+//   - width: Target width
+//   - height: Target height
+//   - opts: Resize options, may be nil
+//
+// Returns:
+//   - The resized image
+func Resize(img image.Image, width, height int, opts *ResizeOptions) image.Image {
+	b := img.Bounds()
+	if width <= 0 && height <= 0 {
+		return img
+	}
+	if width <= 0 {
+		width = max(1, b.Dx()*height/b.Dy())
+	}
+	if height <= 0 {
+		height = max(1, b.Dy()*width/b.Dx())
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	if opts != nil && opts.Background != nil {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		opts.interpolator().Scale(dst, dst.Bounds(), img, b, draw.Over, nil)
+		return dst
+	}
+	opts.interpolator().Scale(dst, dst.Bounds(), img, b, draw.Src, nil)
+	return dst
+}
+
+// Fit 在保持宽高比的前提下缩放图片，使其完整放入 maxWidth x maxHeight 的范围内
+// 图片本身已小于该范围时不会放大
+//
+// Fit scales the image while preserving the aspect ratio so that it fits entirely within maxWidth x maxHeight.
+// Images already smaller than the box are not enlarged.
+func Fit(img image.Image, maxWidth, maxHeight int, opts *ResizeOptions) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if maxWidth <= 0 || maxHeight <= 0 || (w <= maxWidth && h <= maxHeight) {
+		return img
+	}
+
+	// 比较 maxWidth/w 与 maxHeight/h，选择较小的缩放比例
+	if int64(maxWidth)*int64(h) <= int64(maxHeight)*int64(w) {
+		return Resize(img, maxWidth, max(1, h*maxWidth/w), opts)
+	}
+	return Resize(img, max(1, w*maxHeight/h), maxHeight, opts)
+}
+
+// Fill 在保持宽高比的前提下缩放并居中裁剪图片，使其恰好填满 width x height
+// 常用于头像、封面等需要固定尺寸的场景
+//
+// Fill scales and center-crops the image while preserving the aspect ratio so that it exactly fills width x height.
+// Commonly used for avatars, covers and other fixed-size scenarios.
+func Fill(img image.Image, width, height int, opts *ResizeOptions) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if width <= 0 || height <= 0 {
+		return img
+	}
+
+	// 计算与目标宽高比一致的最大裁剪区域
+	var crop image.Rectangle
+	if int64(w)*int64(height) > int64(h)*int64(width) {
+		cw := int(int64(h) * int64(width) / int64(height))
+		x0 := b.Min.X + (w-cw)/2
+		crop = image.Rect(x0, b.Min.Y, x0+cw, b.Max.Y)
+	} else {
+		ch := int(int64(w) * int64(height) / int64(width))
+		y0 := b.Min.Y + (h-ch)/2
+		crop = image.Rect(b.Min.X, y0, b.Max.X, y0+ch)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	op := draw.Src
+	if opts != nil && opts.Background != nil {
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(opts.Background), image.Point{}, draw.Src)
+		op = draw.Over
+	}
+	opts.interpolator().Scale(dst, dst.Bounds(), img, crop, op, nil)
+	return dst
+}
+
+// EncodeJPEG 将图片编码为 JPEG，透明像素会以白色背景合成
+// quality 取值 1-100，超出范围时使用 DefaultJPEGQuality
+//
+// EncodeJPEG encodes the image as JPEG; transparent pixels are composited over a white background.
+// quality ranges from 1 to 100; DefaultJPEGQuality is used when out of range.
+func EncodeJPEG(img image.Image, quality int) ([]byte, error) {
+	if quality < 1 || quality > 100 {
+		quality = DefaultJPEGQuality
+	}
+
+	// JPEG 不支持透明度，先合成到白色背景
+	b := img.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), img, b.Min, draw.Over)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode jpeg: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// EncodePNG 将图片编码为 PNG
+//
+// EncodePNG encodes the image as PNG.
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ThumbnailToJPEG 解码图片并生成 JPEG 缩略图，结果可直接用于对象存储上传（Content-Type 为 image/jpeg）
+// 会自动校正 EXIF 方向，并在保持宽高比的前提下缩放到 maxWidth x maxHeight 范围内
+// 参数:
+//   - data: 原始图片数据（JPEG/PNG/GIF/WebP）
+//   - maxWidth: 最大宽度
+//   - maxHeight: 最大高度
+//   - quality: JPEG 编码质量（1-100）
+//
+// 返回:
+//   - []byte: JPEG 缩略图数据
+//   - error: 解码或编码失败时返回错误
+//
+// ThumbnailToJPEG decodes the image and generates a JPEG thumbnail, ready for object storage uploads (Content-Type image/jpeg).
+// It automatically corrects EXIF orientation and scales the image within maxWidth x maxHeight preserving the aspect ratio.
+// Parameters:
+//   - data: Original image data (JPEG/PNG/GIF/WebP)
+//   - maxWidth: Maximum width
+//   - maxHeight: Maximum height
+//   - quality: JPEG encoding quality (1-100)
+//
+// Returns:
+//   - []byte: JPEG thumbnail data
+//   - error: Returns an error if decoding or encoding fails
+func ThumbnailToJPEG(data []byte, maxWidth, maxHeight, quality int) ([]byte, error) {
+	img, _, err := DecodeImageBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeJPEG(Fit(img, maxWidth, maxHeight, nil), quality)
+}
@@ -0,0 +1,58 @@
+package logutil
+
+import (
+	"context"
+	"log/slog"
+)
+
+// 上下文键类型，避免与其他包的键冲突
+//
+// Context key types, avoiding collisions with keys from other packages
+type (
+	requestIDKey struct{}
+	loggerKey    struct{}
+)
+
+// WithRequestID 将请求 ID 写入 context
+//
+// WithRequestID stores the request ID in the context.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext 从 context 中读取请求 ID
+//
+// RequestIDFromContext reads the request ID from the context.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithLogger 将 logger 写入 context，之后可以通过 FromContext 取出
+//
+// WithLogger stores the logger in the context so that it can be retrieved by FromContext.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext 从 context 中获取 logger，如果没有则返回 slog.Default()
+// 如果 context 中带有请求 ID，返回的 logger 会附带 request_id 字段
+//
+// FromContext retrieves the logger from the context, returning slog.Default() if none is present.
+// If the context carries a request ID, the returned logger includes the request_id field.
+func FromContext(ctx context.Context) *slog.Logger {
+	logger := slog.Default()
+	if ctx == nil {
+		return logger
+	}
+	if l, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok && l != nil {
+		logger = l
+	}
+	if id, ok := RequestIDFromContext(ctx); ok {
+		logger = logger.With(slog.String(KeyRequestID, id))
+	}
+	return logger
+}
@@ -0,0 +1,163 @@
+// Package logutil 提供基于 log/slog 的日志工具函数
+//
+// Package logutil provides logging utility functions based on log/slog.
+package logutil
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const (
+	// EnvLogLevel 读取日志级别的环境变量名
+	//
+	// EnvLogLevel is the name of the environment variable used to read the log level
+	EnvLogLevel = "LOG_LEVEL"
+
+	// KeyRequestID 请求 ID 在日志中的字段名
+	//
+	// KeyRequestID is the field name of the request ID in logs
+	KeyRequestID = "request_id"
+)
+
+// HandlerOptions 日志处理器选项
+// Level: 日志级别，如果为 nil 则从环境变量 EnvLogLevel 读取，默认 Info
+// AddSource: 是否输出调用位置
+// RedactRules: 脱敏规则，如果为 nil 则使用 DefaultRedactRules，传入空切片表示不脱敏
+//
+// HandlerOptions contains options for the log handler.
+// Level: Log level, read from the EnvLogLevel environment variable if nil, defaults to Info
+// AddSource: Whether to output the caller location
+// RedactRules: Redaction rules, DefaultRedactRules is used if nil, an empty slice disables redaction
+type HandlerOptions struct {
+	Level       slog.Leveler
+	AddSource   bool
+	RedactRules []RedactRule
+}
+
+// ParseLevel 将字符串解析为 slog.Level，支持 debug/info/warn/warning/error（不区分大小写）
+// 无法识别时返回 def
+//
+// ParseLevel parses a string into slog.Level, supporting debug/info/warn/warning/error (case-insensitive).
+// Returns def if the string is not recognized.
+func ParseLevel(s string, def slog.Level) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err == nil {
+		return level
+	}
+	return def
+}
+
+// LevelFromEnv 从环境变量 EnvLogLevel 读取日志级别，未设置或无效时返回 slog.LevelInfo
+//
+// LevelFromEnv reads the log level from the EnvLogLevel environment variable,
+// returning slog.LevelInfo if it is unset or invalid.
+func LevelFromEnv() slog.Level {
+	return ParseLevel(os.Getenv(EnvLogLevel), slog.LevelInfo)
+}
+
+// NewHandler 创建预配置的 JSON 日志处理器
+// 处理器会自动从 context 中提取请求 ID，并按脱敏规则处理敏感字段
+// 参数:
+//   - w: 日志输出目标，如果为 nil 则使用 os.Stdout
+//   - opts: 处理器选项，可以为 nil
+//
+// 返回:
+//   - slog.Handler: 配置好的日志处理器
+//
+// NewHandler creates a preconfigured JSON log handler.
+// The handler automatically extracts the request ID from the context and processes sensitive fields according to redaction rules.
+// Parameters:
+//   - w: Log output destination, os.Stdout is used if nil
+//   - opts: Handler options, may be nil
+//
+// Returns:
+//   - slog.Handler: The configured log handler
+func NewHandler(w io.Writer, opts *HandlerOptions) slog.Handler {
+	if w == nil {
+		w = os.Stdout
+	}
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+
+	level := opts.Level
+	if level == nil {
+		level = LevelFromEnv()
+	}
+
+	rules := opts.RedactRules
+	if rules == nil {
+		rules = DefaultRedactRules
+	}
+
+	jsonOpts := &slog.HandlerOptions{
+		Level:     level,
+		AddSource: opts.AddSource,
+	}
+	if len(rules) > 0 {
+		jsonOpts.ReplaceAttr = NewRedactor(rules).ReplaceAttr
+	}
+
+	return &contextHandler{Handler: slog.NewJSONHandler(w, jsonOpts)}
+}
+
+// New 使用 NewHandler 创建 *slog.Logger
+//
+// New creates a *slog.Logger using NewHandler.
+func New(w io.Writer, opts *HandlerOptions) *slog.Logger {
+	return slog.New(NewHandler(w, opts))
+}
+
+// contextHandler 在输出日志前从 context 中提取请求 ID
+// 如果已经通过 WithAttrs 附带了请求 ID，则不再重复添加
+//
+// contextHandler extracts the request ID from the context before emitting a record.
+// If the request ID has already been attached via WithAttrs, it is not added again.
+type contextHandler struct {
+	slog.Handler
+	hasRequestID bool
+}
+
+// Handle 实现 slog.Handler 接口
+//
+// Handle implements the slog.Handler interface.
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id, ok := RequestIDFromContext(ctx); ok && !h.hasRequestID {
+		r.AddAttrs(slog.String(KeyRequestID, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs 实现 slog.Handler 接口
+//
+// WithAttrs implements the slog.Handler interface.
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	has := h.hasRequestID
+	for _, a := range attrs {
+		if a.Key == KeyRequestID {
+			has = true
+		}
+	}
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), hasRequestID: has}
+}
+
+// WithGroup 实现 slog.Handler 接口
+//
+// WithGroup implements the slog.Handler interface.
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), hasRequestID: h.hasRequestID}
+}
@@ -0,0 +1,142 @@
+package logutil
+
+import (
+	"log/slog"
+	"regexp"
+
+	"github.com/supergodk/go-utils/v1/stringutil"
+)
+
+// RedactRule 脱敏规则
+// KeyPattern: 匹配字段名的正则表达式，为 nil 时不按字段名匹配
+// ValuePattern: 匹配字符串值的正则表达式，为 nil 时不按值匹配，匹配到的部分会被 Mask 替换
+// Mask: 脱敏函数，例如 stringutil.MaskPhone
+//
+// RedactRule is a redaction rule.
+// KeyPattern: Regular expression matching field names, no key matching if nil
+// ValuePattern: Regular expression matching string values, no value matching if nil; matched parts are replaced by Mask
+// Mask: Masking function, e.g. stringutil.MaskPhone
+type RedactRule struct {
+	KeyPattern   *regexp.Regexp
+	ValuePattern *regexp.Regexp
+	Mask         func(string) string
+}
+
+// DefaultRedactRules 默认脱敏规则，覆盖密码/令牌、手机号、邮箱、身份证号和银行卡号
+//
+// DefaultRedactRules are the default redaction rules, covering passwords/tokens, phone numbers,
+// emails, ID card numbers and bank card numbers.
+var DefaultRedactRules = []RedactRule{
+	{
+		KeyPattern: regexp.MustCompile(`(?i)(password|passwd|secret|token|authorization|api_?key|private_?key)`),
+		Mask:       stringutil.MaskAll,
+	},
+	{
+		KeyPattern: regexp.MustCompile(`(?i)(id_?card|identity)`),
+		Mask:       stringutil.MaskIDCard,
+	},
+	{
+		KeyPattern: regexp.MustCompile(`(?i)(bank_?card|card_?no)`),
+		Mask:       stringutil.MaskBankCard,
+	},
+	{
+		KeyPattern:   regexp.MustCompile(`(?i)(phone|mobile)`),
+		ValuePattern: regexp.MustCompile(`(?:^|\D)(1[3-9]\d{9})(?:\D|$)`),
+		Mask:         stringutil.MaskPhone,
+	},
+	{
+		KeyPattern:   regexp.MustCompile(`(?i)e?mail`),
+		ValuePattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+		Mask:         stringutil.MaskEmail,
+	},
+}
+
+// Redactor 按规则对日志字段进行脱敏
+//
+// Redactor redacts log fields according to rules
+type Redactor struct {
+	rules []RedactRule
+}
+
+// NewRedactor 创建脱敏器
+//
+// NewRedactor creates a redactor.
+func NewRedactor(rules []RedactRule) *Redactor {
+	return &Redactor{rules: rules}
+}
+
+// ReplaceAttr 可直接用作 slog.HandlerOptions.ReplaceAttr
+// 字段名匹配 KeyPattern 时对整个值脱敏；字符串值中匹配 ValuePattern 的部分也会被脱敏
+//
+// ReplaceAttr can be used directly as slog.HandlerOptions.ReplaceAttr.
+// When the field name matches KeyPattern the whole value is masked; parts of string values matching ValuePattern are masked as well.
+func (r *Redactor) ReplaceAttr(_ []string, a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		return a
+	}
+
+	for _, rule := range r.rules {
+		if rule.Mask == nil || rule.KeyPattern == nil {
+			continue
+		}
+		if rule.KeyPattern.MatchString(a.Key) {
+			return slog.String(a.Key, rule.Mask(v.String()))
+		}
+	}
+
+	if v.Kind() != slog.KindString {
+		return a
+	}
+	s := v.String()
+	changed := false
+	for _, rule := range r.rules {
+		if rule.Mask == nil || rule.ValuePattern == nil {
+			continue
+		}
+		masked := maskMatches(rule.ValuePattern, s, rule.Mask)
+		if masked != s {
+			s = masked
+			changed = true
+		}
+	}
+	if changed {
+		return slog.String(a.Key, s)
+	}
+	return a
+}
+
+// maskMatches 对 s 中所有匹配 re 的部分调用 mask，有捕获分组时只替换第一个分组
+//
+// maskMatches calls mask on every part of s matching re, replacing only the first group if one exists.
+func maskMatches(re *regexp.Regexp, s string, mask func(string) string) string {
+	matches := re.FindAllStringSubmatchIndex(s, -1)
+	if len(matches) == 0 {
+		return s
+	}
+	out := make([]byte, 0, len(s))
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		out = append(out, s[last:start]...)
+		out = append(out, mask(s[start:end])...)
+		last = end
+	}
+	out = append(out, s[last:]...)
+	return string(out)
+}
+
+// Secret 敏感字符串类型，作为日志值输出时总是被完全脱敏
+//
+// Secret is a sensitive string type that is always fully masked when emitted as a log value
+type Secret string
+
+// LogValue 实现 slog.LogValuer 接口
+//
+// LogValue implements the slog.LogValuer interface.
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(stringutil.MaskAll(string(s)))
+}
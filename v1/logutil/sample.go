@@ -0,0 +1,379 @@
+package logutil
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSampleKeys 采样和去重处理器默认最多跟踪的不同消息数
+//
+// DefaultMaxSampleKeys is the default number of distinct messages tracked by the sampling and dedupe handlers
+const DefaultMaxSampleKeys = 1000
+
+// 去重摘要日志的字段名
+//
+// Field names of dedupe summary records
+const (
+	// KeySuppressed 被抑制的日志条数
+	//
+	// KeySuppressed is the number of suppressed records
+	KeySuppressed = "suppressed"
+	// KeySuppressedMsg 被抑制的日志消息
+	//
+	// KeySuppressedMsg is the message of the suppressed records
+	KeySuppressedMsg = "suppressed_msg"
+)
+
+// sampleEntry 一种消息的采样状态
+//
+// sampleEntry is the sampling state of one message
+type sampleEntry struct {
+	count      int
+	windowEnd  time.Time
+	suppressed int
+	level      slog.Level
+	msg        string
+	handler    slog.Handler
+}
+
+// sampler 按消息记录采样状态，被同一处理器派生出的所有处理器共享
+//
+// sampler tracks sampling state by message, shared by every handler derived from the same handler
+type sampler struct {
+	mu      sync.Mutex
+	entries map[string]*sampleEntry
+	maxKeys int
+}
+
+// newSampler 创建 sampler
+//
+// newSampler creates a sampler
+func newSampler(maxKeys int) *sampler {
+	if maxKeys <= 0 {
+		maxKeys = DefaultMaxSampleKeys
+	}
+	return &sampler{entries: make(map[string]*sampleEntry), maxKeys: maxKeys}
+}
+
+// entry 返回 key 的状态，跟踪的消息数达到上限时先清理已过期的状态，仍然满时返回 nil，调用者应直接输出日志
+//
+// entry returns the state of key; when the limit is reached expired states are dropped first,
+// and nil is returned if it is still full, in which case the caller should emit the record as is
+func (s *sampler) entry(key string, now time.Time) *sampleEntry {
+	if e, ok := s.entries[key]; ok {
+		return e
+	}
+	if len(s.entries) >= s.maxKeys {
+		for k, e := range s.entries {
+			if e.suppressed == 0 && !now.Before(e.windowEnd) {
+				delete(s.entries, k)
+			}
+		}
+		if len(s.entries) >= s.maxKeys {
+			return nil
+		}
+	}
+	e := &sampleEntry{}
+	s.entries[key] = e
+	return e
+}
+
+// recordKey 默认的采样键：级别和消息
+//
+// recordKey is the default sampling key: the level and the message
+func recordKey(r slog.Record) string {
+	return r.Level.String() + "\x00" + r.Message
+}
+
+// samplingHandler EveryN 和 PerInterval 返回的处理器
+//
+// samplingHandler is the handler returned by EveryN and PerInterval
+type samplingHandler struct {
+	next     slog.Handler
+	s        *sampler
+	n        int
+	interval time.Duration
+}
+
+// EveryN 对相同级别和消息的日志只输出第 1、n+1、2n+1… 条，其余丢弃，用于循环中的高频日志
+// 通过 logger.With 派生的 logger 共享计数；最多跟踪 DefaultMaxSampleKeys 种消息，超过后计数重新开始
+// 参数:
+//   - next: 实际输出日志的处理器，例如 NewHandler 的返回值
+//   - n: 采样间隔，小于等于 1 时不采样
+//
+// 返回:
+//   - slog.Handler: 采样处理器
+//
+// EveryN emits only the 1st, (n+1)th, (2n+1)th... record with the same level and message and drops the rest, for high-frequency logs in loops.
+// Loggers derived with logger.With share the counts; at most DefaultMaxSampleKeys messages are tracked, after which counting starts over.
+// Parameters:
+//   - next: The handler that actually emits records, such as the one returned by NewHandler
+//   - n: The sampling interval; no sampling when n <= 1
+//
+// Returns:
+//   - slog.Handler: The sampling handler
+func EveryN(next slog.Handler, n int) slog.Handler {
+	if n <= 1 {
+		return next
+	}
+	return &samplingHandler{next: next, s: newSampler(0), n: n}
+}
+
+// PerInterval 对相同级别和消息的日志在每个 interval 内最多输出一条，其余丢弃；需要知道丢弃了多少条时使用 NewDedupeHandler
+// 参数:
+//   - next: 实际输出日志的处理器
+//   - interval: 时间间隔，小于等于 0 时不采样
+//
+// 返回:
+//   - slog.Handler: 采样处理器
+//
+// PerInterval emits at most one record with the same level and message per interval and drops the rest; use NewDedupeHandler to learn how many were dropped.
+// Parameters:
+//   - next: The handler that actually emits records
+//   - interval: The interval; no sampling when interval <= 0
+//
+// Returns:
+//   - slog.Handler: The sampling handler
+func PerInterval(next slog.Handler, interval time.Duration) slog.Handler {
+	if interval <= 0 {
+		return next
+	}
+	return &samplingHandler{next: next, s: newSampler(0), interval: interval}
+}
+
+// Enabled 实现 slog.Handler 接口
+//
+// Enabled implements the slog.Handler interface.
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle 实现 slog.Handler 接口
+//
+// Handle implements the slog.Handler interface.
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.allow(r) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+// allow 判断是否输出这条日志
+//
+// allow reports whether the record should be emitted
+func (h *samplingHandler) allow(r slog.Record) bool {
+	now := time.Now()
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+	e := h.s.entry(recordKey(r), now)
+	if e == nil {
+		return true
+	}
+	if h.n > 0 {
+		e.count++
+		return (e.count-1)%h.n == 0
+	}
+	if now.Before(e.windowEnd) {
+		return false
+	}
+	e.windowEnd = now.Add(h.interval)
+	return true
+}
+
+// WithAttrs 实现 slog.Handler 接口
+//
+// WithAttrs implements the slog.Handler interface.
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.next = h.next.WithAttrs(attrs)
+	return &c
+}
+
+// WithGroup 实现 slog.Handler 接口
+//
+// WithGroup implements the slog.Handler interface.
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.next = h.next.WithGroup(name)
+	return &c
+}
+
+// DedupeOptions 去重处理器选项
+// Window: 去重窗口，窗口内重复的日志被抑制，默认 1 分钟
+// Key: 判断两条日志是否相似的键，默认使用级别和消息
+// MaxKeys: 最多跟踪的不同键数，默认 DefaultMaxSampleKeys，超过后新的键不去重
+//
+// DedupeOptions contains options for the dedupe handler.
+// Window: The dedupe window; repeated records within it are suppressed, 1 minute by default
+// Key: The key deciding whether two records are similar, the level and message by default
+// MaxKeys: The most distinct keys tracked, DefaultMaxSampleKeys by default; new keys beyond it are not deduplicated
+type DedupeOptions struct {
+	Window  time.Duration
+	Key     func(slog.Record) string
+	MaxKeys int
+}
+
+// DedupeHandler 抑制重复日志的处理器，并用 "suppressed N similar messages" 摘要报告被抑制的条数
+// 一条日志输出后开始去重窗口，窗口内相似的日志被抑制；窗口结束后再出现相似日志时，先输出一条摘要，再输出这条日志并开始新的窗口
+// 摘要与原日志级别相同，带有 KeySuppressed 和 KeySuppressedMsg 字段；之后不再出现的日志的摘要由 Flush 输出
+//
+// DedupeHandler suppresses repeated records and reports how many were suppressed with "suppressed N similar messages" summaries.
+// Emitting a record starts a dedupe window in which similar records are suppressed; when a similar record appears after the window,
+// a summary is emitted first, then the record itself, starting a new window.
+// Summaries have the level of the original records and carry the KeySuppressed and KeySuppressedMsg fields; Flush emits the summaries of records that do not appear again.
+type DedupeHandler struct {
+	next   slog.Handler
+	s      *sampler
+	window time.Duration
+	key    func(slog.Record) string
+}
+
+// NewDedupeHandler 创建去重处理器
+// 参数:
+//   - next: 实际输出日志的处理器
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *DedupeHandler: 去重处理器
+//
+// NewDedupeHandler creates a dedupe handler.
+// Parameters:
+//   - next: The handler that actually emits records
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *DedupeHandler: The dedupe handler
+func NewDedupeHandler(next slog.Handler, opts *DedupeOptions) *DedupeHandler {
+	var o DedupeOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Window <= 0 {
+		o.Window = time.Minute
+	}
+	if o.Key == nil {
+		o.Key = recordKey
+	}
+	return &DedupeHandler{next: next, s: newSampler(o.MaxKeys), window: o.Window, key: o.Key}
+}
+
+// Enabled 实现 slog.Handler 接口
+//
+// Enabled implements the slog.Handler interface.
+func (h *DedupeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle 实现 slog.Handler 接口
+//
+// Handle implements the slog.Handler interface.
+func (h *DedupeHandler) Handle(ctx context.Context, r slog.Record) error {
+	now := time.Now()
+	h.s.mu.Lock()
+	e := h.s.entry(h.key(r), now)
+	if e == nil {
+		h.s.mu.Unlock()
+		return h.next.Handle(ctx, r)
+	}
+	if now.Before(e.windowEnd) {
+		e.suppressed++
+		h.s.mu.Unlock()
+		return nil
+	}
+	summary, ok := e.summary(now)
+	e.windowEnd = now.Add(h.window)
+	e.level, e.msg, e.handler = r.Level, r.Message, h.next
+	h.s.mu.Unlock()
+
+	if ok {
+		if err := summary.flush(ctx); err != nil {
+			return err
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+// Flush 输出所有待报告的摘要，应在退出前调用，或者定期调用以便及时看到不再重复的日志的摘要
+// 参数:
+//   - ctx: 传给底层处理器的 context
+//
+// 返回:
+//   - error: 底层处理器返回的第一个错误
+//
+// Flush emits every pending summary; call it before exiting, or periodically to see summaries of records that stopped repeating in time.
+// Parameters:
+//   - ctx: The context passed to the underlying handler
+//
+// Returns:
+//   - error: The first error returned by the underlying handler
+func (h *DedupeHandler) Flush(ctx context.Context) error {
+	now := time.Now()
+	var pending []pendingSummary
+	h.s.mu.Lock()
+	for _, e := range h.s.entries {
+		if s, ok := e.summary(now); ok {
+			pending = append(pending, s)
+		}
+	}
+	h.s.mu.Unlock()
+
+	var firstErr error
+	for _, s := range pending {
+		if err := s.flush(ctx); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// WithAttrs 实现 slog.Handler 接口
+//
+// WithAttrs implements the slog.Handler interface.
+func (h *DedupeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.next = h.next.WithAttrs(attrs)
+	return &c
+}
+
+// WithGroup 实现 slog.Handler 接口
+//
+// WithGroup implements the slog.Handler interface.
+func (h *DedupeHandler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.next = h.next.WithGroup(name)
+	return &c
+}
+
+// pendingSummary 待输出的摘要，在释放锁之后输出
+//
+// pendingSummary is a summary to emit once the lock is released
+type pendingSummary struct {
+	handler slog.Handler
+	record  slog.Record
+}
+
+// summary 取出被抑制的条数并生成摘要，没有被抑制的日志时返回 false
+//
+// summary takes the suppressed count and builds a summary, returning false if nothing was suppressed
+func (e *sampleEntry) summary(now time.Time) (pendingSummary, bool) {
+	if e.suppressed == 0 {
+		return pendingSummary{}, false
+	}
+	r := slog.NewRecord(now, e.level, fmt.Sprintf("suppressed %d similar messages", e.suppressed), 0)
+	r.AddAttrs(slog.Int(KeySuppressed, e.suppressed), slog.String(KeySuppressedMsg, e.msg))
+	e.suppressed = 0
+	return pendingSummary{handler: e.handler, record: r}, true
+}
+
+// flush 输出摘要
+//
+// flush emits the summary
+func (s pendingSummary) flush(ctx context.Context) error {
+	if !s.handler.Enabled(ctx, s.record.Level) {
+		return nil
+	}
+	return s.handler.Handle(ctx, s.record)
+}
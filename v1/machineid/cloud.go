@@ -0,0 +1,271 @@
+package machineid
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultAWSMetadataEndpoint AWS 实例元数据服务的地址
+	//
+	// DefaultAWSMetadataEndpoint is the address of the AWS instance metadata service
+	DefaultAWSMetadataEndpoint = "http://169.254.169.254"
+	// DefaultAliyunMetadataEndpoint 阿里云实例元数据服务的地址
+	//
+	// DefaultAliyunMetadataEndpoint is the address of the Aliyun instance metadata service
+	DefaultAliyunMetadataEndpoint = "http://100.100.100.200"
+	// DefaultMetadataTimeout 探测元数据服务的默认超时时间，不在云上时请求会一直挂起，因此较短
+	//
+	// DefaultMetadataTimeout is the default timeout for probing metadata services; it is short because requests hang when not on a cloud
+	DefaultMetadataTimeout = 2 * time.Second
+)
+
+// metadataClient 默认的元数据服务客户端；元数据服务是链路本地地址，不能经过 HTTP 代理
+//
+// metadataClient is the default metadata service client; metadata services live on link-local addresses and must not go through an HTTP proxy
+var metadataClient = &http.Client{Transport: &http.Transport{Proxy: nil}}
+
+// ErrNotCloud 表示没有探测到任何云厂商的元数据服务
+//
+// ErrNotCloud indicates that no cloud provider's metadata service was detected
+var ErrNotCloud = errors.New("not running on a supported cloud")
+
+// Provider 云厂商
+//
+// Provider is a cloud provider.
+type Provider string
+
+const (
+	// ProviderAWS 亚马逊云
+	//
+	// ProviderAWS is Amazon Web Services
+	ProviderAWS Provider = "aws"
+	// ProviderAliyun 阿里云
+	//
+	// ProviderAliyun is Alibaba Cloud
+	ProviderAliyun Provider = "aliyun"
+)
+
+// CloudInstance 云主机的元数据
+// Provider: 云厂商
+// Region: 地域，例如 us-east-1、cn-hangzhou
+// Zone: 可用区
+// InstanceID: 实例 ID
+// InstanceType: 实例规格
+//
+// CloudInstance is the metadata of a cloud instance.
+// Provider: The cloud provider
+// Region: Region, e.g. us-east-1 or cn-hangzhou
+// Zone: Availability zone
+// InstanceID: Instance ID
+// InstanceType: Instance type
+type CloudInstance struct {
+	Provider     Provider `json:"provider"`
+	Region       string   `json:"region"`
+	Zone         string   `json:"zone"`
+	InstanceID   string   `json:"instance_id"`
+	InstanceType string   `json:"instance_type"`
+}
+
+// CloudOptions 云元数据探测的选项
+// HTTPClient: 请求元数据服务的客户端，为 nil 时使用不走代理的默认客户端
+// Timeout: 整体超时时间，为 0 时使用 DefaultMetadataTimeout
+// AWSEndpoint: AWS 元数据服务地址，为空时使用 DefaultAWSMetadataEndpoint，测试时可以替换
+// AliyunEndpoint: 阿里云元数据服务地址，为空时使用 DefaultAliyunMetadataEndpoint
+//
+// CloudOptions contains options for cloud metadata detection.
+// HTTPClient: Client for the metadata services; a default client that bypasses proxies when nil
+// Timeout: Overall timeout; DefaultMetadataTimeout when 0
+// AWSEndpoint: AWS metadata service address; DefaultAWSMetadataEndpoint when empty, replaceable in tests
+// AliyunEndpoint: Aliyun metadata service address; DefaultAliyunMetadataEndpoint when empty
+type CloudOptions struct {
+	HTTPClient     *http.Client
+	Timeout        time.Duration
+	AWSEndpoint    string
+	AliyunEndpoint string
+}
+
+// DetectCloud 同时探测 AWS 和阿里云的元数据服务，返回先成功的那一个
+// 参数:
+//   - ctx: 上下文
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *CloudInstance: 实例元数据
+//   - error: 都探测失败时返回 ErrNotCloud
+//
+// DetectCloud probes the AWS and Aliyun metadata services concurrently and returns whichever succeeds first.
+// Parameters:
+//   - ctx: Context
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *CloudInstance: The instance metadata
+//   - error: Returns ErrNotCloud if every probe fails
+func DetectCloud(ctx context.Context, opts *CloudOptions) (*CloudInstance, error) {
+	o := cloudDefaults(opts)
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+
+	type result struct {
+		inst *CloudInstance
+		err  error
+	}
+	results := make(chan result, 2)
+	go func() {
+		inst, err := awsInstance(ctx, &o)
+		results <- result{inst, err}
+	}()
+	go func() {
+		inst, err := aliyunInstance(ctx, &o)
+		results <- result{inst, err}
+	}()
+	var errs []error
+	for range 2 {
+		r := <-results
+		if r.err == nil {
+			return r.inst, nil
+		}
+		errs = append(errs, r.err)
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNotCloud, errors.Join(errs...))
+}
+
+// AWSInstance 读取 AWS 实例元数据（IMDSv2）
+//
+// AWSInstance reads the AWS instance metadata (IMDSv2).
+func AWSInstance(ctx context.Context, opts *CloudOptions) (*CloudInstance, error) {
+	o := cloudDefaults(opts)
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+	return awsInstance(ctx, &o)
+}
+
+// AliyunInstance 读取阿里云 ECS 实例元数据，支持加固模式
+//
+// AliyunInstance reads the Aliyun ECS instance metadata, supporting hardened mode.
+func AliyunInstance(ctx context.Context, opts *CloudOptions) (*CloudInstance, error) {
+	o := cloudDefaults(opts)
+	ctx, cancel := context.WithTimeout(ctx, o.Timeout)
+	defer cancel()
+	return aliyunInstance(ctx, &o)
+}
+
+// cloudDefaults 返回填充默认值后的选项
+//
+// cloudDefaults returns the options with defaults filled in
+func cloudDefaults(opts *CloudOptions) CloudOptions {
+	var o CloudOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = metadataClient
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultMetadataTimeout
+	}
+	if o.AWSEndpoint == "" {
+		o.AWSEndpoint = DefaultAWSMetadataEndpoint
+	}
+	if o.AliyunEndpoint == "" {
+		o.AliyunEndpoint = DefaultAliyunMetadataEndpoint
+	}
+	o.AWSEndpoint = strings.TrimRight(o.AWSEndpoint, "/")
+	o.AliyunEndpoint = strings.TrimRight(o.AliyunEndpoint, "/")
+	return o
+}
+
+// awsInstance 通过 IMDSv2 会话令牌读取实例身份文档
+//
+// awsInstance reads the instance identity document with an IMDSv2 session token
+func awsInstance(ctx context.Context, o *CloudOptions) (*CloudInstance, error) {
+	token, err := metadataGet(ctx, o.HTTPClient, http.MethodPut, o.AWSEndpoint+"/latest/api/token",
+		map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+	if err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	doc, err := metadataGet(ctx, o.HTTPClient, http.MethodGet, o.AWSEndpoint+"/latest/dynamic/instance-identity/document",
+		map[string]string{"X-aws-ec2-metadata-token": token})
+	if err != nil {
+		return nil, fmt.Errorf("aws: %w", err)
+	}
+	var id struct {
+		Region           string `json:"region"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+	}
+	if err := json.Unmarshal([]byte(doc), &id); err != nil {
+		return nil, fmt.Errorf("aws: parse identity document: %w", err)
+	}
+	return &CloudInstance{
+		Provider:     ProviderAWS,
+		Region:       id.Region,
+		Zone:         id.AvailabilityZone,
+		InstanceID:   id.InstanceID,
+		InstanceType: id.InstanceType,
+	}, nil
+}
+
+// aliyunInstance 读取阿里云实例元数据，加固模式下先获取令牌，普通模式下获取令牌失败时直接访问
+//
+// aliyunInstance reads the Aliyun instance metadata, fetching a token first for hardened mode and going without one when that fails in normal mode
+func aliyunInstance(ctx context.Context, o *CloudOptions) (*CloudInstance, error) {
+	headers := map[string]string{}
+	if token, err := metadataGet(ctx, o.HTTPClient, http.MethodPut, o.AliyunEndpoint+"/latest/api/token",
+		map[string]string{"X-aliyun-ecs-metadata-token-ttl-seconds": "60"}); err == nil {
+		headers["X-aliyun-ecs-metadata-token"] = token
+	} else if ctx.Err() != nil {
+		return nil, fmt.Errorf("aliyun: %w", err)
+	}
+	inst := &CloudInstance{Provider: ProviderAliyun}
+	for _, f := range []struct {
+		path string
+		dst  *string
+	}{
+		{"region-id", &inst.Region},
+		{"zone-id", &inst.Zone},
+		{"instance-id", &inst.InstanceID},
+		{"instance/instance-type", &inst.InstanceType},
+	} {
+		v, err := metadataGet(ctx, o.HTTPClient, http.MethodGet, o.AliyunEndpoint+"/latest/meta-data/"+f.path, headers)
+		if err != nil {
+			return nil, fmt.Errorf("aliyun: %w", err)
+		}
+		*f.dst = v
+	}
+	return inst, nil
+}
+
+// metadataGet 请求元数据服务并返回去掉首尾空白的响应体
+//
+// metadataGet calls a metadata service and returns the response body with surrounding whitespace trimmed
+func metadataGet(ctx context.Context, client *http.Client, method, url string, headers map[string]string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return "", err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s %s: status %d", method, url, resp.StatusCode)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
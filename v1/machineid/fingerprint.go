@@ -0,0 +1,193 @@
+// Package machineid 提供稳定的主机/实例指纹和云厂商元数据探测
+//
+// Package machineid provides stable host/instance fingerprints and cloud metadata detection.
+package machineid
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ErrNoFingerprint 表示没有可用于生成指纹的信息来源
+//
+// ErrNoFingerprint indicates that no source is available for building a fingerprint
+var ErrNoFingerprint = errors.New("no fingerprint source available")
+
+// machineIDPaths Linux 上保存机器 ID 的文件
+//
+// machineIDPaths are the files holding the machine ID on Linux
+var machineIDPaths = []string{"/etc/machine-id", "/var/lib/dbus/machine-id"}
+
+// containerIDPattern 匹配 cgroup 和 mountinfo 中 64 位十六进制的容器 ID
+//
+// containerIDPattern matches 64-hex-digit container IDs in cgroup and mountinfo
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// virtualIfacePrefixes 虚拟网卡的名称前缀，这些网卡的 MAC 地址可能随容器或网桥变化
+//
+// virtualIfacePrefixes are name prefixes of virtual interfaces whose MAC addresses may change with containers or bridges
+var virtualIfacePrefixes = []string{"docker", "veth", "br-", "virbr", "vmnet", "cni", "flannel", "cali", "tun", "tap", "utun", "awdl", "llw"}
+
+// Sources 生成指纹使用的信息来源
+// MachineID: 操作系统的机器 ID（/etc/machine-id），可能为空
+// MACs: 物理网卡的 MAC 地址，已排序
+// ContainerID: 当前容器的 ID，不在容器中时为空
+// Hostname: 主机名
+//
+// Sources are the inputs to a fingerprint.
+// MachineID: The OS machine ID (/etc/machine-id), may be empty
+// MACs: MAC addresses of physical interfaces, sorted
+// ContainerID: The current container's ID; empty outside containers
+// Hostname: Host name
+type Sources struct {
+	MachineID   string
+	MACs        []string
+	ContainerID string
+	Hostname    string
+}
+
+// Collect 收集当前主机的指纹信息来源，读取失败的来源留空
+//
+// Collect gathers the fingerprint sources of the current host, leaving any source that cannot be read empty.
+func Collect() *Sources {
+	s := &Sources{MachineID: readMachineID(), MACs: physicalMACs(), ContainerID: readContainerID()}
+	s.Hostname, _ = os.Hostname()
+	return s
+}
+
+// Fingerprint 根据信息来源计算指纹（SHA-256 的十六进制）
+// 优先使用机器 ID，没有时使用 MAC 地址，都没有时使用主机名；在容器中时再加上容器 ID，使同一主机上的各个容器得到不同的指纹（容器重建后指纹随之变化）
+// 返回:
+//   - string: 指纹
+//   - error: 所有来源都为空时返回 ErrNoFingerprint
+//
+// Fingerprint computes the fingerprint (hex SHA-256) from the sources.
+// The machine ID is preferred, then the MAC addresses, then the host name; inside a container the container ID is added so containers on one host get different fingerprints (which change when the container is recreated).
+// Returns:
+//   - string: The fingerprint
+//   - error: Returns ErrNoFingerprint if every source is empty
+func (s *Sources) Fingerprint() (string, error) {
+	var base string
+	switch {
+	case s.MachineID != "":
+		base = "machine-id:" + s.MachineID
+	case len(s.MACs) > 0:
+		base = "mac:" + strings.Join(s.MACs, ",")
+	case s.Hostname != "":
+		base = "hostname:" + s.Hostname
+	}
+	if base == "" && s.ContainerID == "" {
+		return "", ErrNoFingerprint
+	}
+	if s.ContainerID != "" {
+		base += "\ncontainer:" + s.ContainerID
+	}
+	sum := sha256.Sum256([]byte(base))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// HostFingerprint 返回当前主机（或容器）的稳定指纹，重启后保持不变
+//
+// HostFingerprint returns a stable fingerprint of the current host (or container) that survives restarts.
+func HostFingerprint() (string, error) {
+	return Collect().Fingerprint()
+}
+
+// WorkerID 由主机指纹派生 [0, 2^bits) 内的工作节点 ID，可作为 Snowflake 等 ID 生成器的 worker ID 种子
+// 不同主机的 ID 可能冲突（概率约为 节点数²/2^(bits+1)），要求严格唯一时应由协调服务分配
+// 参数:
+//   - bits: ID 的位数，范围 1-63，例如 Snowflake 的 10
+//
+// 返回:
+//   - int64: 工作节点 ID
+//   - error: 无法生成指纹时返回 ErrNoFingerprint
+//
+// WorkerID derives a worker ID in [0, 2^bits) from the host fingerprint, usable as the worker ID seed of Snowflake-style ID generators.
+// IDs of different hosts may collide (with probability about nodes²/2^(bits+1)); allocate them through a coordinator when strict uniqueness is required.
+// Parameters:
+//   - bits: Number of bits, 1-63, e.g. 10 for Snowflake
+//
+// Returns:
+//   - int64: The worker ID
+//   - error: Returns ErrNoFingerprint if no fingerprint can be built
+func WorkerID(bits uint) (int64, error) {
+	bits = min(max(bits, 1), 63)
+	fp, err := HostFingerprint()
+	if err != nil {
+		return 0, err
+	}
+	raw, _ := hex.DecodeString(fp)
+	return int64(binary.BigEndian.Uint64(raw) >> (64 - bits)), nil
+}
+
+// readMachineID 读取机器 ID
+//
+// readMachineID reads the machine ID
+func readMachineID() string {
+	for _, p := range machineIDPaths {
+		if data, err := os.ReadFile(p); err == nil {
+			if id := strings.TrimSpace(string(data)); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+// readContainerID 从 cgroup 或 mountinfo 中读取容器 ID（cgroup v2 下需要从 mountinfo 中的 hostname 等挂载路径获取）
+//
+// readContainerID reads the container ID from cgroup or mountinfo (under cgroup v2 it comes from mount paths such as hostname in mountinfo)
+func readContainerID() string {
+	if data, err := os.ReadFile("/proc/self/cgroup"); err == nil {
+		if id := containerIDPattern.Find(data); id != nil {
+			return string(id)
+		}
+	}
+	data, err := os.ReadFile("/proc/self/mountinfo")
+	if err != nil {
+		return ""
+	}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Bytes()
+		if !bytes.Contains(line, []byte("/hostname")) && !bytes.Contains(line, []byte("/resolv.conf")) {
+			continue
+		}
+		if id := containerIDPattern.Find(line); id != nil {
+			return string(id)
+		}
+	}
+	return ""
+}
+
+// physicalMACs 返回物理网卡的 MAC 地址，排除回环、虚拟网卡和本地管理地址
+//
+// physicalMACs returns the MAC addresses of physical interfaces, excluding loopback, virtual interfaces and locally administered addresses
+func physicalMACs() []string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var macs []string
+	for _, iface := range ifaces {
+		mac := iface.HardwareAddr
+		if iface.Flags&net.FlagLoopback != 0 || len(mac) == 0 || mac[0]&0x02 != 0 {
+			continue
+		}
+		if slices.ContainsFunc(virtualIfacePrefixes, func(p string) bool { return strings.HasPrefix(iface.Name, p) }) {
+			continue
+		}
+		macs = append(macs, mac.String())
+	}
+	slices.Sort(macs)
+	return slices.Compact(macs)
+}
@@ -0,0 +1,116 @@
+package maputil
+
+// Change 一个键变化前后的值
+// Old: 旧值
+// New: 新值
+//
+// Change holds a key's value before and after a change.
+// Old: The old value
+// New: The new value
+type Change[V any] struct {
+	Old V
+	New V
+}
+
+// Diff 两个 map 的差异，三个字段均不为 nil
+// Added: 只在新 map 中存在的键和值
+// Removed: 只在旧 map 中存在的键和旧值
+// Changed: 两边都存在但值不同的键
+//
+// Diff is the difference between two maps; none of the fields is nil.
+// Added: Keys only in the new map, with their values
+// Removed: Keys only in the old map, with their old values
+// Changed: Keys in both maps whose values differ
+type Diff[K comparable, V any] struct {
+	Added   map[K]V
+	Removed map[K]V
+	Changed map[K]Change[V]
+}
+
+// DiffMaps 比较两个 map，用于配置变更审计和判断需要失效的缓存
+// 参数:
+//   - old: 旧 map，可以为 nil
+//   - updated: 新 map，可以为 nil
+//
+// 返回:
+//   - *Diff[K, V]: 差异
+//
+// DiffMaps compares two maps, for auditing configuration changes and deciding which caches to invalidate.
+// Parameters:
+//   - old: The old map, may be nil
+//   - updated: The new map, may be nil
+//
+// Returns:
+//   - *Diff[K, V]: The difference
+func DiffMaps[K, V comparable](old, updated map[K]V) *Diff[K, V] {
+	return DiffMapsFunc(old, updated, func(a, b V) bool { return a == b })
+}
+
+// DiffMapsFunc 与 DiffMaps 相同，但使用 eq 比较值，适用于值为切片、map 等不可比较的类型
+//
+// DiffMapsFunc is like DiffMaps but compares values with eq, for values such as slices and maps that are not comparable.
+func DiffMapsFunc[K comparable, V any](old, updated map[K]V, eq func(a, b V) bool) *Diff[K, V] {
+	d := &Diff[K, V]{
+		Added:   make(map[K]V),
+		Removed: make(map[K]V),
+		Changed: make(map[K]Change[V]),
+	}
+	for k, ov := range old {
+		nv, ok := updated[k]
+		switch {
+		case !ok:
+			d.Removed[k] = ov
+		case !eq(ov, nv):
+			d.Changed[k] = Change[V]{Old: ov, New: nv}
+		}
+	}
+	for k, nv := range updated {
+		if _, ok := old[k]; !ok {
+			d.Added[k] = nv
+		}
+	}
+	return d
+}
+
+// IsEmpty 判断是否没有任何差异
+//
+// IsEmpty reports whether there is no difference.
+func (d *Diff[K, V]) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Keys 返回所有新增、删除和变化的键，顺序不确定，例如用于逐个失效缓存
+//
+// Keys returns every added, removed and changed key in no particular order, e.g. for invalidating caches one by one.
+func (d *Diff[K, V]) Keys() []K {
+	keys := make([]K, 0, len(d.Added)+len(d.Removed)+len(d.Changed))
+	for k := range d.Added {
+		keys = append(keys, k)
+	}
+	for k := range d.Removed {
+		keys = append(keys, k)
+	}
+	for k := range d.Changed {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Apply 将差异应用到 m：写入新增和变化的键，删除被删除的键；对旧 map 应用后得到新 map
+// 参数:
+//   - m: 要修改的 map，不能为 nil
+//
+// Apply applies the difference to m: added and changed keys are written and removed keys deleted; applying it to the old map yields the new one.
+// Parameters:
+//   - m: The map to modify, must not be nil
+func (d *Diff[K, V]) Apply(m map[K]V) {
+	for k, v := range d.Added {
+		m[k] = v
+	}
+	for k, c := range d.Changed {
+		m[k] = c.New
+	}
+	for k := range d.Removed {
+		delete(m, k)
+	}
+}
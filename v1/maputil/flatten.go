@@ -0,0 +1,186 @@
+package maputil
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultFlattenSeparator Flatten 和 Unflatten 默认的键分隔符
+//
+// DefaultFlattenSeparator is the default key separator of Flatten and Unflatten
+const DefaultFlattenSeparator = "."
+
+// ErrFlattenConflict 表示展平的键互相冲突，例如同时存在 "a" 和 "a.b"
+//
+// ErrFlattenConflict indicates that flattened keys conflict, e.g. both "a" and "a.b" exist
+var ErrFlattenConflict = errors.New("conflicting flattened keys")
+
+// Flatten 把嵌套的 map 展平为单层，嵌套的键用 sep 连接，切片元素使用下标作为键，例如 {"a": {"b": [1, 2]}} 变为 {"a.b.0": 1, "a.b.1": 2}
+// 支持任意以 string 为键的 map 和任意切片或数组（例如 JSON、YAML 解码的结果），空的 map 和切片作为值保留以便 Unflatten 还原；
+// 结果可以直接交给 DiffMapsFunc 比较配置变更，或作为埋点事件的扁平属性
+// 注意: 原始键中包含 sep 时无法被 Unflatten 正确还原
+// 参数:
+//   - m: 嵌套的 map，不会被修改
+//   - sep: 分隔符，为空时使用 DefaultFlattenSeparator
+//
+// 返回:
+//   - map[string]any: 展平后的 map
+//
+// Flatten flattens a nested map into a single level, joining nested keys with sep and using indexes as keys for slice elements, e.g. {"a": {"b": [1, 2]}} becomes {"a.b.0": 1, "a.b.1": 2}.
+// Any map with string keys and any slice or array (such as decoded JSON or YAML) is supported; empty maps and slices are kept as values so Unflatten can restore them.
+// The result can be passed straight to DiffMapsFunc to compare configuration changes, or used as flat analytics event properties.
+// Note: original keys containing sep cannot be restored correctly by Unflatten.
+// Parameters:
+//   - m: The nested map, which is not modified
+//   - sep: Separator; DefaultFlattenSeparator when empty
+//
+// Returns:
+//   - map[string]any: The flattened map
+func Flatten(m map[string]any, sep string) map[string]any {
+	if sep == "" {
+		sep = DefaultFlattenSeparator
+	}
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		flattenValue(out, k, v, sep)
+	}
+	return out
+}
+
+// Unflatten 是 Flatten 的逆操作，把带分隔符的键还原为嵌套的 map
+// 一层中的键全部是从 0 开始的连续下标时还原为 []any，否则还原为 map[string]any
+// 参数:
+//   - flat: 展平的 map，不会被修改
+//   - sep: 分隔符，为空时使用 DefaultFlattenSeparator
+//
+// 返回:
+//   - map[string]any: 嵌套的 map
+//   - error: 键互相冲突（例如同时存在 "a" 和 "a.b"）时返回 ErrFlattenConflict
+//
+// Unflatten is the inverse of Flatten, restoring keys with separators into nested maps.
+// A level whose keys are all consecutive indexes starting at 0 becomes []any; otherwise it becomes map[string]any.
+// Parameters:
+//   - flat: The flattened map, which is not modified
+//   - sep: Separator; DefaultFlattenSeparator when empty
+//
+// Returns:
+//   - map[string]any: The nested map
+//   - error: Returns ErrFlattenConflict if keys conflict (e.g. both "a" and "a.b" exist)
+func Unflatten(flat map[string]any, sep string) (map[string]any, error) {
+	if sep == "" {
+		sep = DefaultFlattenSeparator
+	}
+	// 按键排序，使冲突时的错误信息稳定
+	keys := make([]string, 0, len(flat))
+	for k := range flat {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	root := make(map[string]any)
+	for _, key := range keys {
+		parts := strings.Split(key, sep)
+		node := root
+		for i, p := range parts[:len(parts)-1] {
+			child, ok := node[p]
+			if !ok {
+				next := make(map[string]any)
+				node[p] = next
+				node = next
+				continue
+			}
+			// 叶子值中的 map 已被 leafContainer 包装，这里的 map 一定是中间节点
+			next, ok := child.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w: %q and %q", ErrFlattenConflict, strings.Join(parts[:i+1], sep), key)
+			}
+			node = next
+		}
+		last := parts[len(parts)-1]
+		if _, ok := node[last]; ok {
+			return nil, fmt.Errorf("%w: %q", ErrFlattenConflict, key)
+		}
+		node[last] = leafValue(flat[key])
+	}
+	// 顶层始终是 map，只转换下面的各层
+	for k, v := range root {
+		root[k] = restoreSlices(v)
+	}
+	return root, nil
+}
+
+// flattenValue 递归地把值写入 out
+//
+// flattenValue writes a value into out recursively
+func flattenValue(out map[string]any, prefix string, v any, sep string) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String || rv.Len() == 0 {
+			break
+		}
+		iter := rv.MapRange()
+		for iter.Next() {
+			flattenValue(out, prefix+sep+iter.Key().String(), iter.Value().Interface(), sep)
+		}
+		return
+	case reflect.Slice, reflect.Array:
+		// []byte 视为单个值
+		if rv.Len() == 0 || rv.Type().Elem().Kind() == reflect.Uint8 {
+			break
+		}
+		for i := range rv.Len() {
+			flattenValue(out, prefix+sep+strconv.Itoa(i), rv.Index(i).Interface(), sep)
+		}
+		return
+	}
+	out[prefix] = v
+}
+
+// leafContainer 包装 Unflatten 输入中作为值出现的 map（例如 Flatten 保留的空 map），避免与中间节点混淆
+//
+// leafContainer wraps maps appearing as values in Unflatten's input (such as empty maps kept by Flatten) so they are not mistaken for intermediate nodes
+type leafContainer struct {
+	v any
+}
+
+// leafValue 返回写入树中的叶子值
+//
+// leafValue returns the leaf value stored in the tree
+func leafValue(v any) any {
+	if _, ok := v.(map[string]any); ok {
+		return leafContainer{v}
+	}
+	return v
+}
+
+// restoreSlices 把键为连续下标的 map 转换为切片，并解开叶子包装
+//
+// restoreSlices converts maps keyed by consecutive indexes into slices and unwraps leaf values
+func restoreSlices(node any) any {
+	switch n := node.(type) {
+	case leafContainer:
+		return n.v
+	case map[string]any:
+		for k, v := range n {
+			n[k] = restoreSlices(v)
+		}
+		if len(n) == 0 {
+			return n
+		}
+		s := make([]any, len(n))
+		for k, v := range n {
+			i, err := strconv.Atoi(k)
+			if err != nil || i < 0 || i >= len(n) || strconv.Itoa(i) != k {
+				return n
+			}
+			s[i] = v
+		}
+		return s
+	}
+	return node
+}
@@ -0,0 +1,208 @@
+package maputil
+
+import "sync"
+
+// lfuEntry LFU 链表节点
+//
+// lfuEntry is an LFU list node
+type lfuEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	freq       int
+	prev, next *lfuEntry[K, V]
+}
+
+// LFU 固定容量的最不经常使用缓存，Get 和 Set 均为 O(1)，访问次数相同时淘汰最久未使用的条目
+// 没有过期时间也不启动 goroutine，并发安全
+//
+// LFU is a fixed-capacity least-frequently-used cache with O(1) Get and Set; among entries with equal counts the least recently used is evicted.
+// It has no TTL and no goroutines and is safe for concurrent use.
+type LFU[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[K]*lfuEntry[K, V]
+	// buckets 每个访问次数对应一个哨兵节点，root.next 为该次数下最近使用的条目
+	buckets map[int]*lfuEntry[K, V]
+	minFreq int
+	onEvict func(K, V)
+}
+
+// NewLFU 创建 LFU 缓存
+// 参数:
+//   - capacity: 容量，小于 1 时按 1 处理
+//   - onEvict: 因容量不足淘汰条目时的回调（在锁外调用，Delete 和覆盖写入不会触发），可以为 nil
+//
+// 返回:
+//   - *LFU[K, V]: 缓存
+//
+// NewLFU creates an LFU cache.
+// Parameters:
+//   - capacity: The capacity, treated as 1 when less than 1
+//   - onEvict: Callback invoked when an entry is evicted for capacity (called outside the lock; Delete and overwrites do not trigger it), may be nil
+//
+// Returns:
+//   - *LFU[K, V]: The cache
+func NewLFU[K comparable, V any](capacity int, onEvict func(K, V)) *LFU[K, V] {
+	return &LFU[K, V]{
+		capacity: max(capacity, 1),
+		items:    make(map[K]*lfuEntry[K, V]),
+		buckets:  make(map[int]*lfuEntry[K, V]),
+		onEvict:  onEvict,
+	}
+}
+
+// Get 获取值并增加其访问次数
+//
+// Get returns the value and increments its access count.
+func (c *LFU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.touch(e)
+	return e.value, true
+}
+
+// Peek 获取值但不增加其访问次数
+//
+// Peek returns the value without incrementing its access count.
+func (c *LFU[K, V]) Peek(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		return e.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Set 写入值，已存在的条目会增加访问次数；容量已满时淘汰访问次数最少的条目
+// 返回:
+//   - bool: 是否有条目被淘汰
+//
+// Set stores the value; an existing entry has its access count incremented. When full, the least frequently used entry is evicted.
+// Returns:
+//   - bool: Whether an entry was evicted
+func (c *LFU[K, V]) Set(key K, value V) bool {
+	c.mu.Lock()
+	if e, ok := c.items[key]; ok {
+		e.value = value
+		c.touch(e)
+		c.mu.Unlock()
+		return false
+	}
+
+	var evicted *lfuEntry[K, V]
+	if len(c.items) >= c.capacity {
+		evicted = c.buckets[c.minFreq].prev
+		c.remove(evicted)
+		delete(c.items, evicted.key)
+	}
+	e := &lfuEntry[K, V]{key: key, value: value, freq: 1}
+	c.items[key] = e
+	c.insert(e)
+	c.minFreq = 1
+	c.mu.Unlock()
+
+	if evicted != nil && c.onEvict != nil {
+		c.onEvict(evicted.key, evicted.value)
+	}
+	return evicted != nil
+}
+
+// Delete 删除条目，返回条目是否存在；删除最小访问次数的最后一个条目时需要 O(n) 重新计算最小次数
+//
+// Delete removes an entry and reports whether it existed; removing the last entry with the minimum count takes O(n) to recompute the minimum.
+func (c *LFU[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		return false
+	}
+	c.remove(e)
+	delete(c.items, key)
+	if _, ok := c.buckets[c.minFreq]; !ok {
+		// 最小访问次数的桶已空，重新计算
+		c.minFreq = 0
+		for _, e := range c.items {
+			if c.minFreq == 0 || e.freq < c.minFreq {
+				c.minFreq = e.freq
+			}
+		}
+	}
+	return true
+}
+
+// Frequency 返回条目的访问次数，不存在时返回 0
+//
+// Frequency returns the access count of an entry, or 0 if absent.
+func (c *LFU[K, V]) Frequency(key K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		return e.freq
+	}
+	return 0
+}
+
+// Len 返回条目数
+//
+// Len returns the number of entries.
+func (c *LFU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// Clear 清空缓存，不触发淘汰回调
+//
+// Clear removes all entries without invoking the eviction callback.
+func (c *LFU[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.items)
+	clear(c.buckets)
+	c.minFreq = 0
+}
+
+// touch 将条目移到下一个访问次数的桶
+//
+// touch moves an entry to the bucket of the next access count
+func (c *LFU[K, V]) touch(e *lfuEntry[K, V]) {
+	c.remove(e)
+	if _, ok := c.buckets[e.freq]; !ok && c.minFreq == e.freq {
+		c.minFreq++
+	}
+	e.freq++
+	c.insert(e)
+}
+
+// insert 将条目插入其访问次数对应桶的头部
+//
+// insert adds an entry at the front of the bucket for its access count
+func (c *LFU[K, V]) insert(e *lfuEntry[K, V]) {
+	root, ok := c.buckets[e.freq]
+	if !ok {
+		root = &lfuEntry[K, V]{}
+		root.next, root.prev = root, root
+		c.buckets[e.freq] = root
+	}
+	e.prev, e.next = root, root.next
+	root.next.prev = e
+	root.next = e
+}
+
+// remove 将条目从桶中移除，桶为空时删除桶
+//
+// remove takes an entry out of its bucket, deleting the bucket when it becomes empty
+func (c *LFU[K, V]) remove(e *lfuEntry[K, V]) {
+	e.prev.next, e.next.prev = e.next, e.prev
+	e.prev, e.next = nil, nil
+	if root := c.buckets[e.freq]; root.next == root {
+		delete(c.buckets, e.freq)
+	}
+}
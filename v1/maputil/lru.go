@@ -0,0 +1,185 @@
+// Package maputil 提供 map 相关的工具函数和轻量的缓存结构
+//
+// Package maputil provides map utility functions and lightweight cache structures.
+package maputil
+
+import "sync"
+
+// lruEntry LRU 链表节点
+//
+// lruEntry is an LRU list node
+type lruEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *lruEntry[K, V]
+}
+
+// LRU 固定容量的最近最少使用缓存，没有过期时间也不启动 goroutine，并发安全
+// 适用于进程内的热点键缓存；需要 TTL、SetNX 等功能时使用 cacheutil
+//
+// LRU is a fixed-capacity least-recently-used cache with no TTL and no goroutines, safe for concurrent use.
+// It suits in-process hot-key caches; use cacheutil when TTLs, SetNX and the like are needed.
+type LRU[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	items    map[K]*lruEntry[K, V]
+	// root 哨兵节点，root.next 为最近使用，root.prev 为最久未使用
+	root    lruEntry[K, V]
+	onEvict func(K, V)
+}
+
+// NewLRU 创建 LRU 缓存
+// 参数:
+//   - capacity: 容量，小于 1 时按 1 处理
+//   - onEvict: 因容量不足淘汰条目时的回调（在锁外调用，Delete 和覆盖写入不会触发），可以为 nil
+//
+// 返回:
+//   - *LRU[K, V]: 缓存
+//
+// NewLRU creates an LRU cache.
+// Parameters:
+//   - capacity: The capacity, treated as 1 when less than 1
+//   - onEvict: Callback invoked when an entry is evicted for capacity (called outside the lock; Delete and overwrites do not trigger it), may be nil
+//
+// Returns:
+//   - *LRU[K, V]: The cache
+func NewLRU[K comparable, V any](capacity int, onEvict func(K, V)) *LRU[K, V] {
+	c := &LRU[K, V]{capacity: max(capacity, 1), items: make(map[K]*lruEntry[K, V]), onEvict: onEvict}
+	c.root.next, c.root.prev = &c.root, &c.root
+	return c
+}
+
+// Get 获取值并将其标记为最近使用
+//
+// Get returns the value and marks it as most recently used.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.moveToFront(e)
+	return e.value, true
+}
+
+// Peek 获取值但不改变其使用顺序
+//
+// Peek returns the value without changing its recency.
+func (c *LRU[K, V]) Peek(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.items[key]; ok {
+		return e.value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Set 写入值并将其标记为最近使用，容量已满时淘汰最久未使用的条目
+// 返回:
+//   - bool: 是否有条目被淘汰
+//
+// Set stores the value and marks it as most recently used, evicting the least recently used entry when full.
+// Returns:
+//   - bool: Whether an entry was evicted
+func (c *LRU[K, V]) Set(key K, value V) bool {
+	c.mu.Lock()
+	if e, ok := c.items[key]; ok {
+		e.value = value
+		c.moveToFront(e)
+		c.mu.Unlock()
+		return false
+	}
+
+	var evicted *lruEntry[K, V]
+	if len(c.items) >= c.capacity {
+		evicted = c.root.prev
+		c.unlink(evicted)
+		delete(c.items, evicted.key)
+	}
+	e := &lruEntry[K, V]{key: key, value: value}
+	c.items[key] = e
+	c.pushFront(e)
+	c.mu.Unlock()
+
+	if evicted != nil && c.onEvict != nil {
+		c.onEvict(evicted.key, evicted.value)
+	}
+	return evicted != nil
+}
+
+// Delete 删除条目，返回条目是否存在
+//
+// Delete removes an entry and reports whether it existed.
+func (c *LRU[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if ok {
+		c.unlink(e)
+		delete(c.items, key)
+	}
+	return ok
+}
+
+// Len 返回条目数
+//
+// Len returns the number of entries.
+func (c *LRU[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.items)
+}
+
+// Keys 按从最近使用到最久未使用的顺序返回所有键
+//
+// Keys returns all keys from most to least recently used.
+func (c *LRU[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]K, 0, len(c.items))
+	for e := c.root.next; e != &c.root; e = e.next {
+		keys = append(keys, e.key)
+	}
+	return keys
+}
+
+// Clear 清空缓存，不触发淘汰回调
+//
+// Clear removes all entries without invoking the eviction callback.
+func (c *LRU[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.items)
+	c.root.next, c.root.prev = &c.root, &c.root
+}
+
+// moveToFront 将节点移到链表头部
+//
+// moveToFront moves a node to the front of the list
+func (c *LRU[K, V]) moveToFront(e *lruEntry[K, V]) {
+	if c.root.next == e {
+		return
+	}
+	c.unlink(e)
+	c.pushFront(e)
+}
+
+// pushFront 将节点插入链表头部
+//
+// pushFront inserts a node at the front of the list
+func (c *LRU[K, V]) pushFront(e *lruEntry[K, V]) {
+	e.prev, e.next = &c.root, c.root.next
+	c.root.next.prev = e
+	c.root.next = e
+}
+
+// unlink 将节点从链表中移除
+//
+// unlink removes a node from the list
+func (c *LRU[K, V]) unlink(e *lruEntry[K, V]) {
+	e.prev.next, e.next.prev = e.next, e.prev
+	e.prev, e.next = nil, nil
+}
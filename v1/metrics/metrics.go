@@ -0,0 +1,272 @@
+// Package metrics 提供轻量的指标门面（带标签的 Counter、Gauge、Histogram），默认不做任何记录；
+// 应用调用 SetDefault 设置 Registry 后即可通过 Prometheus 文本格式导出，库代码无需依赖具体的指标系统
+//
+// Package metrics provides a lightweight metrics facade (Counter, Gauge and Histogram with labels) that records nothing by default;
+// once the application calls SetDefault with a Registry, metrics are exposed in the Prometheus text format, without library code depending on a metrics system.
+package metrics
+
+import "sync/atomic"
+
+// DefaultBuckets 直方图默认的桶边界（秒），与 Prometheus 客户端一致，适用于请求耗时
+//
+// DefaultBuckets are the default histogram bucket bounds (seconds), matching the Prometheus client, suited to request latencies
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Counter 只增不减的计数器
+// 标签值按创建时的标签名顺序传入，缺少的视为空字符串，多余的被忽略
+//
+// Counter is a monotonically increasing counter.
+// Label values are passed in the order of the label names given at creation; missing ones are empty and extra ones are ignored.
+type Counter interface {
+	// Inc 加 1
+	//
+	// Inc adds 1.
+	Inc(labelValues ...string)
+	// Add 增加 delta，delta 为负数时忽略
+	//
+	// Add adds delta; negative deltas are ignored.
+	Add(delta float64, labelValues ...string)
+}
+
+// Gauge 可增可减的仪表，例如连接数、队列长度
+// 标签值的规则与 Counter 相同
+//
+// Gauge is a value that goes up and down, e.g. connections or queue length.
+// Label values follow the same rules as Counter.
+type Gauge interface {
+	// Set 设置为 v
+	//
+	// Set sets the value to v.
+	Set(v float64, labelValues ...string)
+	// Add 增加 delta，可以为负数
+	//
+	// Add adds delta, which may be negative.
+	Add(delta float64, labelValues ...string)
+}
+
+// Histogram 直方图，例如请求耗时、响应大小
+// 标签值的规则与 Counter 相同
+//
+// Histogram is a histogram, e.g. of request latencies or response sizes.
+// Label values follow the same rules as Counter.
+type Histogram interface {
+	// Observe 记录一个观测值
+	//
+	// Observe records one observation.
+	Observe(v float64, labelValues ...string)
+}
+
+// Provider 指标提供者，同名同类型的指标重复创建时返回同一个指标
+//
+// Provider creates metrics; creating a metric again with the same name and type returns the same metric.
+type Provider interface {
+	// Counter 创建计数器
+	//
+	// Counter creates a counter.
+	Counter(name, help string, labelNames ...string) Counter
+	// Gauge 创建仪表
+	//
+	// Gauge creates a gauge.
+	Gauge(name, help string, labelNames ...string) Gauge
+	// Histogram 创建直方图，buckets 为空时使用 DefaultBuckets
+	//
+	// Histogram creates a histogram; DefaultBuckets is used when buckets is empty.
+	Histogram(name, help string, buckets []float64, labelNames ...string) Histogram
+}
+
+// Noop 不做任何记录的提供者，是默认的提供者
+//
+// Noop is a provider that records nothing; it is the default provider.
+var Noop Provider = noopProvider{}
+
+// noopProvider 不做任何记录的提供者
+//
+// noopProvider is a provider that records nothing
+type noopProvider struct{}
+
+// noopMetric 不做任何记录的指标
+//
+// noopMetric is a metric that records nothing
+type noopMetric struct{}
+
+// Counter 实现 Provider 接口
+//
+// Counter implements the Provider interface.
+func (noopProvider) Counter(string, string, ...string) Counter { return noopMetric{} }
+
+// Gauge 实现 Provider 接口
+//
+// Gauge implements the Provider interface.
+func (noopProvider) Gauge(string, string, ...string) Gauge { return noopMetric{} }
+
+// Histogram 实现 Provider 接口
+//
+// Histogram implements the Provider interface.
+func (noopProvider) Histogram(string, string, []float64, ...string) Histogram { return noopMetric{} }
+
+// Inc 实现 Counter 接口
+//
+// Inc implements the Counter interface.
+func (noopMetric) Inc(...string) {}
+
+// Add 实现 Counter 和 Gauge 接口
+//
+// Add implements the Counter and Gauge interfaces.
+func (noopMetric) Add(float64, ...string) {}
+
+// Set 实现 Gauge 接口
+//
+// Set implements the Gauge interface.
+func (noopMetric) Set(float64, ...string) {}
+
+// Observe 实现 Histogram 接口
+//
+// Observe implements the Histogram interface.
+func (noopMetric) Observe(float64, ...string) {}
+
+// providerState 当前的默认提供者及其版本，版本用于让已创建的指标感知 SetDefault
+//
+// providerState is the current default provider and its generation, which lets already created metrics notice SetDefault
+type providerState struct {
+	provider Provider
+	gen      uint64
+}
+
+// defaultState 当前的默认提供者
+//
+// defaultState holds the current default provider
+var defaultState atomic.Pointer[providerState]
+
+func init() {
+	defaultState.Store(&providerState{provider: Noop})
+}
+
+// SetDefault 设置默认提供者，通常在 main 中调用一次；之前通过 NewCounter 等创建的指标会转而记录到新的提供者
+// 参数:
+//   - p: 提供者，为 nil 时恢复为 Noop
+//
+// SetDefault sets the default provider, usually once in main; metrics created earlier through NewCounter and friends start recording to the new provider.
+// Parameters:
+//   - p: The provider; nil restores Noop
+func SetDefault(p Provider) {
+	if p == nil {
+		p = Noop
+	}
+	for {
+		old := defaultState.Load()
+		if defaultState.CompareAndSwap(old, &providerState{provider: p, gen: old.gen + 1}) {
+			return
+		}
+	}
+}
+
+// Default 返回当前的默认提供者
+//
+// Default returns the current default provider.
+func Default() Provider {
+	return defaultState.Load().provider
+}
+
+// lazy 跟随默认提供者的指标：每次记录时检查默认提供者是否变化，变化后重新创建底层指标
+//
+// lazy is a metric following the default provider: each record checks whether the default provider changed and recreates the underlying metric if so
+type lazy[T any] struct {
+	create func(Provider) T
+	cur    atomic.Pointer[lazyValue[T]]
+}
+
+// lazyValue 已创建的底层指标及其对应的提供者版本
+//
+// lazyValue is the created underlying metric and the provider generation it belongs to
+type lazyValue[T any] struct {
+	metric T
+	gen    uint64
+}
+
+// get 返回当前默认提供者对应的底层指标
+//
+// get returns the underlying metric for the current default provider
+func (l *lazy[T]) get() T {
+	state := defaultState.Load()
+	if v := l.cur.Load(); v != nil && v.gen == state.gen {
+		return v.metric
+	}
+	v := &lazyValue[T]{metric: l.create(state.provider), gen: state.gen}
+	l.cur.Store(v)
+	return v.metric
+}
+
+// lazyCounter 跟随默认提供者的计数器
+//
+// lazyCounter is a counter following the default provider
+type lazyCounter struct{ lazy[Counter] }
+
+// Inc 实现 Counter 接口
+//
+// Inc implements the Counter interface.
+func (c *lazyCounter) Inc(labelValues ...string) { c.get().Inc(labelValues...) }
+
+// Add 实现 Counter 接口
+//
+// Add implements the Counter interface.
+func (c *lazyCounter) Add(delta float64, labelValues ...string) { c.get().Add(delta, labelValues...) }
+
+// lazyGauge 跟随默认提供者的仪表
+//
+// lazyGauge is a gauge following the default provider
+type lazyGauge struct{ lazy[Gauge] }
+
+// Set 实现 Gauge 接口
+//
+// Set implements the Gauge interface.
+func (g *lazyGauge) Set(v float64, labelValues ...string) { g.get().Set(v, labelValues...) }
+
+// Add 实现 Gauge 接口
+//
+// Add implements the Gauge interface.
+func (g *lazyGauge) Add(delta float64, labelValues ...string) { g.get().Add(delta, labelValues...) }
+
+// lazyHistogram 跟随默认提供者的直方图
+//
+// lazyHistogram is a histogram following the default provider
+type lazyHistogram struct{ lazy[Histogram] }
+
+// Observe 实现 Histogram 接口
+//
+// Observe implements the Histogram interface.
+func (h *lazyHistogram) Observe(v float64, labelValues ...string) { h.get().Observe(v, labelValues...) }
+
+// NewCounter 创建记录到默认提供者的计数器，可以在包级变量中创建，应用稍后调用 SetDefault 也会生效
+// 参数:
+//   - name: 指标名，例如 "httputil_requests_total"
+//   - help: 说明
+//   - labelNames: 标签名
+//
+// NewCounter creates a counter recording to the default provider; it can be created in package-level variables and still takes effect when the application calls SetDefault later.
+// Parameters:
+//   - name: Metric name, e.g. "httputil_requests_total"
+//   - help: Description
+//   - labelNames: Label names
+func NewCounter(name, help string, labelNames ...string) Counter {
+	c := &lazyCounter{}
+	c.create = func(p Provider) Counter { return p.Counter(name, help, labelNames...) }
+	return c
+}
+
+// NewGauge 创建记录到默认提供者的仪表，参见 NewCounter
+//
+// NewGauge creates a gauge recording to the default provider; see NewCounter.
+func NewGauge(name, help string, labelNames ...string) Gauge {
+	g := &lazyGauge{}
+	g.create = func(p Provider) Gauge { return p.Gauge(name, help, labelNames...) }
+	return g
+}
+
+// NewHistogram 创建记录到默认提供者的直方图，buckets 为空时使用 DefaultBuckets，参见 NewCounter
+//
+// NewHistogram creates a histogram recording to the default provider, with DefaultBuckets when buckets is empty; see NewCounter.
+func NewHistogram(name, help string, buckets []float64, labelNames ...string) Histogram {
+	h := &lazyHistogram{}
+	h.create = func(p Provider) Histogram { return p.Histogram(name, help, buckets, labelNames...) }
+	return h
+}
@@ -0,0 +1,351 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// 指标类型
+//
+// Metric types
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// labelSep 拼接标签值作为序列键的分隔符
+//
+// labelSep separates label values when they are joined into a series key
+const labelSep = "\xff"
+
+// Registry 进程内的指标注册表，实现 Provider，并以 Prometheus 文本格式导出
+// 并发安全；同名但类型、标签不同的指标重复创建时 panic，这属于编程错误
+//
+// Registry is an in-process metrics registry implementing Provider and exporting the Prometheus text format.
+// It is safe for concurrent use; creating a metric again with the same name but a different type or labels panics, as that is a programming error.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]*metric
+}
+
+// NewRegistry 创建指标注册表
+//
+// NewRegistry creates a metrics registry.
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]*metric)}
+}
+
+// metric 一个指标及其所有标签组合的序列
+//
+// metric is a metric and the series of all its label combinations
+type metric struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	buckets    []float64
+
+	mu     sync.RWMutex
+	series map[string]*series
+}
+
+// series 一个标签组合的值；计数器和仪表使用 bits 保存浮点数，直方图使用 counts、sum、count
+//
+// series is the value of one label combination; counters and gauges keep a float in bits, histograms use counts, sum and count
+type series struct {
+	labelValues []string
+	bits        atomic.Uint64
+
+	mu     sync.Mutex
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Counter 实现 Provider 接口
+//
+// Counter implements the Provider interface.
+func (r *Registry) Counter(name, help string, labelNames ...string) Counter {
+	return (*counter)(r.register(name, help, typeCounter, nil, labelNames))
+}
+
+// Gauge 实现 Provider 接口
+//
+// Gauge implements the Provider interface.
+func (r *Registry) Gauge(name, help string, labelNames ...string) Gauge {
+	return (*gauge)(r.register(name, help, typeGauge, nil, labelNames))
+}
+
+// Histogram 实现 Provider 接口
+//
+// Histogram implements the Provider interface.
+func (r *Registry) Histogram(name, help string, buckets []float64, labelNames ...string) Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	return (*histogram)(r.register(name, help, typeHistogram, slices.Compact(buckets), labelNames))
+}
+
+// register 注册或返回已存在的指标
+//
+// register registers a metric or returns the existing one
+func (r *Registry) register(name, help, typ string, buckets []float64, labelNames []string) *metric {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if m, ok := r.metrics[name]; ok {
+		if m.typ != typ || !slices.Equal(m.labelNames, labelNames) || !slices.Equal(m.buckets, buckets) {
+			panic(fmt.Sprintf("metrics: %s already registered as a different %s", name, m.typ))
+		}
+		return m
+	}
+	m := &metric{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: slices.Clone(labelNames),
+		buckets:    buckets,
+		series:     make(map[string]*series),
+	}
+	r.metrics[name] = m
+	return m
+}
+
+// get 返回标签值对应的序列，不存在时创建
+//
+// get returns the series of the label values, creating it if missing
+func (m *metric) get(labelValues []string) *series {
+	values := make([]string, len(m.labelNames))
+	copy(values, labelValues)
+	key := strings.Join(values, labelSep)
+
+	m.mu.RLock()
+	s, ok := m.series[key]
+	m.mu.RUnlock()
+	if ok {
+		return s
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s, ok = m.series[key]; !ok {
+		s = &series{labelValues: values}
+		if m.typ == typeHistogram {
+			s.counts = make([]uint64, len(m.buckets))
+		}
+		m.series[key] = s
+	}
+	return s
+}
+
+// add 原子地增加浮点数
+//
+// add atomically adds to the float
+func (s *series) add(delta float64) {
+	for {
+		old := s.bits.Load()
+		if s.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+// counter Registry 中的计数器
+//
+// counter is a counter in a Registry
+type counter metric
+
+// Inc 实现 Counter 接口
+//
+// Inc implements the Counter interface.
+func (c *counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 实现 Counter 接口
+//
+// Add implements the Counter interface.
+func (c *counter) Add(delta float64, labelValues ...string) {
+	if delta <= 0 {
+		return
+	}
+	(*metric)(c).get(labelValues).add(delta)
+}
+
+// gauge Registry 中的仪表
+//
+// gauge is a gauge in a Registry
+type gauge metric
+
+// Set 实现 Gauge 接口
+//
+// Set implements the Gauge interface.
+func (g *gauge) Set(v float64, labelValues ...string) {
+	(*metric)(g).get(labelValues).bits.Store(math.Float64bits(v))
+}
+
+// Add 实现 Gauge 接口
+//
+// Add implements the Gauge interface.
+func (g *gauge) Add(delta float64, labelValues ...string) {
+	(*metric)(g).get(labelValues).add(delta)
+}
+
+// histogram Registry 中的直方图
+//
+// histogram is a histogram in a Registry
+type histogram metric
+
+// Observe 实现 Histogram 接口
+//
+// Observe implements the Histogram interface.
+func (h *histogram) Observe(v float64, labelValues ...string) {
+	s := (*metric)(h).get(labelValues)
+	// 只记录第一个满足 v <= 上界的桶，导出时再累加
+	i, _ := slices.BinarySearch(h.buckets, v)
+	s.mu.Lock()
+	if i < len(s.counts) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+	s.mu.Unlock()
+}
+
+// Handler 返回以 Prometheus 文本格式导出所有指标的处理器，通常挂载在 /metrics
+//
+// Handler returns a handler exporting all metrics in the Prometheus text format, usually mounted at /metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// WriteText 以 Prometheus 文本格式写出所有指标，指标按名称排序，序列按标签值排序
+//
+// WriteText writes all metrics in the Prometheus text format, with metrics sorted by name and series by label values.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	metrics := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		metrics = append(metrics, m)
+	}
+	r.mu.Unlock()
+	slices.SortFunc(metrics, func(a, b *metric) int { return strings.Compare(a.name, b.name) })
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.writeText(bw)
+	}
+	return bw.Flush()
+}
+
+// writeText 写出一个指标
+//
+// writeText writes one metric
+func (m *metric) writeText(w *bufio.Writer) {
+	m.mu.RLock()
+	all := make([]*series, 0, len(m.series))
+	for _, s := range m.series {
+		all = append(all, s)
+	}
+	m.mu.RUnlock()
+	if len(all) == 0 {
+		return
+	}
+	slices.SortFunc(all, func(a, b *series) int { return slices.Compare(a.labelValues, b.labelValues) })
+
+	if m.help != "" {
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, helpEscaper.Replace(m.help))
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.typ)
+	for _, s := range all {
+		labels := formatLabels(m.labelNames, s.labelValues)
+		if m.typ != typeHistogram {
+			writeSample(w, m.name, labels, "", math.Float64frombits(s.bits.Load()))
+			continue
+		}
+		s.mu.Lock()
+		counts, sum, count := slices.Clone(s.counts), s.sum, s.count
+		s.mu.Unlock()
+		var cumulative uint64
+		for i, bound := range m.buckets {
+			cumulative += counts[i]
+			writeSample(w, m.name+"_bucket", labels, `le="`+formatFloat(bound)+`"`, float64(cumulative))
+		}
+		writeSample(w, m.name+"_bucket", labels, `le="+Inf"`, float64(count))
+		writeSample(w, m.name+"_sum", labels, "", sum)
+		writeSample(w, m.name+"_count", labels, "", float64(count))
+	}
+}
+
+// helpEscaper 转义说明中的反斜杠和换行
+//
+// helpEscaper escapes backslashes and newlines in help text
+var helpEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// labelEscaper 转义标签值中的反斜杠、引号和换行
+//
+// labelEscaper escapes backslashes, quotes and newlines in label values
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels 格式化标签，例如 method="GET",code="200"
+//
+// formatLabels formats labels, e.g. method="GET",code="200"
+func formatLabels(names, values []string) string {
+	var b strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(name)
+		b.WriteString(`="`)
+		b.WriteString(labelEscaper.Replace(values[i]))
+		b.WriteByte('"')
+	}
+	return b.String()
+}
+
+// writeSample 写出一个样本，extra 为额外的标签（直方图的 le）
+//
+// writeSample writes one sample; extra is an additional label (le for histograms)
+func writeSample(w *bufio.Writer, name, labels, extra string, v float64) {
+	w.WriteString(name)
+	if labels != "" || extra != "" {
+		w.WriteByte('{')
+		w.WriteString(labels)
+		if labels != "" && extra != "" {
+			w.WriteByte(',')
+		}
+		w.WriteString(extra)
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+// formatFloat 按 Prometheus 文本格式格式化浮点数
+//
+// formatFloat formats a float for the Prometheus text format
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
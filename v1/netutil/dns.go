@@ -0,0 +1,365 @@
+// Package netutil 提供网络相关的工具函数
+//
+// Package netutil provides network utility functions.
+package netutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultResolveTimeout ResolveWithTimeout 每个解析器默认的超时时间
+//
+// DefaultResolveTimeout is the default timeout of each resolver in ResolveWithTimeout
+const DefaultResolveTimeout = 3 * time.Second
+
+// DNS 记录类型
+//
+// DNS record types
+const (
+	typeA    = 1
+	typeAAAA = 28
+)
+
+// maxDoHResponseSize DoH 响应的最大长度，DNS 消息本身不超过 64KB
+//
+// maxDoHResponseSize is the largest DoH response accepted; a DNS message cannot exceed 64KB
+const maxDoHResponseSize = 64 << 10
+
+// errMalformedResponse 表示 DNS 响应格式错误
+//
+// errMalformedResponse indicates a malformed DNS response
+var errMalformedResponse = errors.New("malformed DNS response")
+
+// Resolver 域名解析器，*net.Resolver、NewUDPResolver 和 NewDoHResolver 的返回值都实现了该接口
+//
+// Resolver resolves hostnames; *net.Resolver and the values returned by NewUDPResolver and NewDoHResolver all implement it.
+type Resolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// ResolveWithTimeout 解析主机名的所有 A 和 AAAA 记录，先使用系统解析器，失败时依次尝试 resolvers，返回第一个成功的结果
+// 用于健康检查和防 SSRF 的地址校验等不能长时间阻塞、又需要在系统 DNS 故障时有备用方案的场景；host 是 IP 时直接返回
+// 参数:
+//   - ctx: 上下文，取消后不再尝试
+//   - host: 主机名
+//   - timeout: 每个解析器的超时时间，小于等于 0 时使用 DefaultResolveTimeout
+//   - resolvers: 备用解析器，例如 NewUDPResolver("223.5.5.5") 或 NewDoHResolver("https://dns.alidns.com/dns-query", nil)
+//
+// 返回:
+//   - []netip.Addr: 去重后的地址，IPv4 在前
+//   - error: 所有解析器都失败时返回包含各解析器错误的错误
+//
+// ResolveWithTimeout resolves every A and AAAA record of a hostname, using the system resolver first and trying resolvers in turn on failure; the first success is returned.
+// It suits health checks and SSRF address validation, which must not block for long yet need a fallback when the system DNS fails; an IP host is returned as is.
+// Parameters:
+//   - ctx: Context; no further attempts are made once it is cancelled
+//   - host: The hostname
+//   - timeout: Timeout of each resolver; DefaultResolveTimeout when <= 0
+//   - resolvers: Fallback resolvers, e.g. NewUDPResolver("223.5.5.5") or NewDoHResolver("https://dns.alidns.com/dns-query", nil)
+//
+// Returns:
+//   - []netip.Addr: The deduplicated addresses, IPv4 first
+//   - error: Returns an error containing every resolver's error if all of them fail
+func ResolveWithTimeout(ctx context.Context, host string, timeout time.Duration, resolvers ...Resolver) ([]netip.Addr, error) {
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{ip.Unmap()}, nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultResolveTimeout
+	}
+
+	var errs []error
+	for _, r := range append([]Resolver{net.DefaultResolver}, resolvers...) {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+		ips, err := r.LookupNetIP(attemptCtx, "ip", host)
+		cancel()
+		if err == nil && len(ips) == 0 {
+			err = &net.DNSError{Err: "no addresses", Name: host, IsNotFound: true}
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return normalizeAddrs(ips), nil
+	}
+	return nil, fmt.Errorf("resolve %s: %w", host, errors.Join(errs...))
+}
+
+// normalizeAddrs 去掉 IPv4 映射、去重，并把 IPv4 地址排在前面
+//
+// normalizeAddrs unmaps, deduplicates and puts IPv4 addresses first
+func normalizeAddrs(ips []netip.Addr) []netip.Addr {
+	out := make([]netip.Addr, 0, len(ips))
+	for _, ip := range ips {
+		ip = ip.Unmap()
+		if !slices.Contains(out, ip) {
+			out = append(out, ip)
+		}
+	}
+	slices.SortStableFunc(out, func(a, b netip.Addr) int {
+		switch {
+		case a.Is4() == b.Is4():
+			return 0
+		case a.Is4():
+			return -1
+		}
+		return 1
+	})
+	return out
+}
+
+// NewUDPResolver 创建使用指定 DNS 服务器的解析器，通过 UDP 查询，响应被截断时改用 TCP
+// 参数:
+//   - server: DNS 服务器地址，例如 "223.5.5.5" 或 "[2400:3200::1]:53"，未指定端口时使用 53
+//
+// 返回:
+//   - *net.Resolver: 解析器
+//
+// NewUDPResolver creates a resolver using the given DNS server over UDP, switching to TCP when a response is truncated.
+// Parameters:
+//   - server: The DNS server address, e.g. "223.5.5.5" or "[2400:3200::1]:53"; port 53 is used when none is given
+//
+// Returns:
+//   - *net.Resolver: The resolver
+func NewUDPResolver(server string) *net.Resolver {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	var d net.Dialer
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// DoHResolver 通过 DNS over HTTPS（RFC 8484）查询的解析器，可以绕过被劫持或不可用的本地 DNS
+//
+// DoHResolver resolves over DNS over HTTPS (RFC 8484), bypassing a hijacked or unavailable local DNS.
+type DoHResolver struct {
+	url    string
+	client *http.Client
+}
+
+// NewDoHResolver 创建 DoH 解析器
+// 参数:
+//   - url: DoH 服务地址，例如 "https://dns.alidns.com/dns-query" 或 "https://cloudflare-dns.com/dns-query"
+//   - client: 发送请求的 HTTP 客户端，为 nil 时使用 http.DefaultClient
+//
+// 返回:
+//   - *DoHResolver: 解析器
+//
+// NewDoHResolver creates a DoH resolver.
+// Parameters:
+//   - url: The DoH endpoint, e.g. "https://dns.alidns.com/dns-query" or "https://cloudflare-dns.com/dns-query"
+//   - client: The HTTP client sending requests; http.DefaultClient when nil
+//
+// Returns:
+//   - *DoHResolver: The resolver
+func NewDoHResolver(url string, client *http.Client) *DoHResolver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &DoHResolver{url: url, client: client}
+}
+
+// LookupNetIP 实现 Resolver 接口，network 为 "ip" 时并发查询 A 和 AAAA 记录
+//
+// LookupNetIP implements the Resolver interface, querying A and AAAA records concurrently when network is "ip".
+func (r *DoHResolver) LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error) {
+	var types []uint16
+	switch network {
+	case "ip":
+		types = []uint16{typeA, typeAAAA}
+	case "ip4":
+		types = []uint16{typeA}
+	case "ip6":
+		types = []uint16{typeAAAA}
+	default:
+		return nil, &net.DNSError{Err: "unsupported network " + network, Name: host, Server: r.url}
+	}
+
+	results := make([][]netip.Addr, len(types))
+	errs := make([]error, len(types))
+	var wg sync.WaitGroup
+	for i, qtype := range types {
+		wg.Go(func() {
+			results[i], errs[i] = r.query(ctx, host, qtype)
+		})
+	}
+	wg.Wait()
+
+	var ips []netip.Addr
+	for _, res := range results {
+		ips = append(ips, res...)
+	}
+	if len(ips) > 0 {
+		// 只有 IPv4 或只有 IPv6 的主机，另一种记录可能查询失败
+		return ips, nil
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, Server: r.url, IsNotFound: true}
+}
+
+// query 发送一次 DoH 查询
+//
+// query sends one DoH query
+func (r *DoHResolver) query(ctx context.Context, host string, qtype uint16) ([]netip.Addr, error) {
+	dnsErr := func(msg string, temporary bool) error {
+		return &net.DNSError{Err: msg, Name: host, Server: r.url, IsTemporary: temporary}
+	}
+	msg, err := buildQuery(host, qtype)
+	if err != nil {
+		return nil, dnsErr(err.Error(), false)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, dnsErr(err.Error(), false)
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, &net.DNSError{Err: err.Error(), Name: host, Server: r.url, IsTimeout: errors.Is(ctx.Err(), context.DeadlineExceeded)}
+		}
+		return nil, dnsErr(err.Error(), true)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, dnsErr(fmt.Sprintf("http status %d", resp.StatusCode), resp.StatusCode >= 500)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDoHResponseSize))
+	if err != nil {
+		return nil, dnsErr(err.Error(), true)
+	}
+
+	ips, rcode, err := parseResponse(body, qtype)
+	switch {
+	case err != nil:
+		return nil, dnsErr(err.Error(), false)
+	case rcode == 3:
+		return nil, &net.DNSError{Err: "no such host", Name: host, Server: r.url, IsNotFound: true}
+	case rcode == 2:
+		return nil, dnsErr("server failure", true)
+	case rcode != 0:
+		return nil, dnsErr(fmt.Sprintf("rcode %d", rcode), false)
+	}
+	return ips, nil
+}
+
+// buildQuery 构造递归查询的 DNS 消息，按 RFC 8484 的建议 ID 为 0 以便缓存
+//
+// buildQuery builds a recursive DNS query message with ID 0, as RFC 8484 recommends for cacheability
+func buildQuery(host string, qtype uint16) ([]byte, error) {
+	name := strings.TrimSuffix(host, ".")
+	if name == "" || len(name) > 253 {
+		return nil, fmt.Errorf("invalid hostname %q", host)
+	}
+	// 头部：ID 0，RD 置位，1 个问题
+	msg := []byte{0, 0, 0x01, 0x00, 0, 1, 0, 0, 0, 0, 0, 0}
+	for label := range strings.SplitSeq(name, ".") {
+		if label == "" || len(label) > 63 {
+			return nil, fmt.Errorf("invalid hostname %q", host)
+		}
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, qtype)
+	msg = binary.BigEndian.AppendUint16(msg, 1) // IN
+	return msg, nil
+}
+
+// parseResponse 解析 DNS 响应，返回类型为 qtype 的地址记录和响应码
+//
+// parseResponse parses a DNS response, returning the address records of type qtype and the response code
+func parseResponse(msg []byte, qtype uint16) ([]netip.Addr, int, error) {
+	if len(msg) < 12 {
+		return nil, 0, errMalformedResponse
+	}
+	rcode := int(msg[3] & 0x0f)
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+	off := 12
+	var err error
+	for range qdcount {
+		if off, err = skipName(msg, off); err != nil {
+			return nil, 0, err
+		}
+		off += 4
+	}
+
+	var ips []netip.Addr
+	for range ancount {
+		if off, err = skipName(msg, off); err != nil {
+			return nil, 0, err
+		}
+		if off+10 > len(msg) {
+			return nil, 0, errMalformedResponse
+		}
+		typ := binary.BigEndian.Uint16(msg[off:])
+		rdlen := int(binary.BigEndian.Uint16(msg[off+8:]))
+		off += 10
+		if off+rdlen > len(msg) {
+			return nil, 0, errMalformedResponse
+		}
+		rdata := msg[off : off+rdlen]
+		off += rdlen
+		if typ != qtype {
+			// 例如 CNAME 记录
+			continue
+		}
+		if ip, ok := netip.AddrFromSlice(rdata); ok && (rdlen == 4 || rdlen == 16) {
+			ips = append(ips, ip)
+		}
+	}
+	return ips, rcode, nil
+}
+
+// skipName 跳过消息中 off 处的域名，支持压缩指针，返回域名之后的偏移量
+//
+// skipName skips the domain name at off, supporting compression pointers, and returns the offset after it
+func skipName(msg []byte, off int) (int, error) {
+	for {
+		if off >= len(msg) {
+			return 0, errMalformedResponse
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			return off + 1, nil
+		case n&0xc0 == 0xc0:
+			// 压缩指针占 2 字节，之后不再有标签
+			if off+2 > len(msg) {
+				return 0, errMalformedResponse
+			}
+			return off + 2, nil
+		case n&0xc0 != 0:
+			return 0, errMalformedResponse
+		}
+		off += 1 + n
+	}
+}
@@ -0,0 +1,418 @@
+package ossutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+const (
+	// DefaultAppendFlushSize AppendWriter 默认的自动刷新阈值
+	//
+	// DefaultAppendFlushSize is the default automatic flush threshold of AppendWriter
+	DefaultAppendFlushSize = 1 << 20
+
+	// minComposePartSize S3 分片上传中除最后一个分片外每个分片的最小大小
+	//
+	// minComposePartSize is the minimum size of every S3 multipart part except the last
+	minComposePartSize = 5 << 20
+	// maxCopyPartSize UploadPartCopy 单个分片的最大大小
+	//
+	// maxCopyPartSize is the maximum size of a single UploadPartCopy part
+	maxCopyPartSize = 5 << 30
+)
+
+var (
+	// ErrAppendConflict 表示对象在追加期间被其他写入者修改
+	//
+	// ErrAppendConflict indicates that the object was modified by another writer while appending
+	ErrAppendConflict = errors.New("object modified by another writer")
+	// ErrAppendWriterClosed 表示 AppendWriter 已关闭
+	//
+	// ErrAppendWriterClosed indicates that the AppendWriter has been closed
+	ErrAppendWriterClosed = errors.New("append writer closed")
+)
+
+// AppendMode AppendWriter 的追加方式
+//
+// AppendMode is how an AppendWriter appends
+type AppendMode int
+
+const (
+	// AppendCompose 通过重写或分片合并模拟追加，适用于 AWS S3 等不支持追加的服务（默认）
+	// 对象小于 5MB 时下载后与新数据一起重新上传，否则通过 UploadPartCopy 复制现有内容并上传新数据作为最后一个分片
+	//
+	// AppendCompose emulates appending by rewriting or multipart compose, for services without append support such as AWS S3 (the default).
+	// Objects under 5MB are downloaded and re-uploaded with the new data; larger ones are copied with UploadPartCopy and the new data uploaded as the last part.
+	AppendCompose AppendMode = iota
+	// AppendNative 使用阿里云 OSS 的 AppendObject 接口，对象必须是不存在的或可追加类型（Appendable）
+	//
+	// AppendNative uses the Aliyun OSS AppendObject API; the object must be absent or of the Appendable type
+	AppendNative
+)
+
+// AppendOptions AppendWriter 的选项
+// Mode: 追加方式，默认为 AppendCompose
+// FlushSize: 缓冲数据达到该大小时自动刷新，默认为 DefaultAppendFlushSize；AppendCompose 方式下每次刷新都会重写对象，不宜过小
+// ContentType: 创建对象时使用的内容类型，为空时追加到已有对象保留其类型，新对象按客户端的 ContentTypePolicy 决定
+//
+// AppendOptions contains options for an AppendWriter.
+// Mode: How to append, AppendCompose by default
+// FlushSize: Buffered data is flushed automatically once it reaches this size, DefaultAppendFlushSize by default; with AppendCompose every flush rewrites the object, so keep it reasonably large
+// ContentType: Content type used when creating the object; when empty an existing object keeps its type and a new one follows the client's ContentTypePolicy
+type AppendOptions struct {
+	Mode        AppendMode
+	FlushSize   int
+	ContentType string
+}
+
+// AppendWriter 向对象末尾增量写入数据，适用于把日志流式写入对象存储
+// 写入的数据先缓冲在内存中，达到 FlushSize 或调用 Flush、Close 时追加到对象；并发安全
+// 追加基于创建时对象的长度和 ETag，对象被其他写入者修改时返回 ErrAppendConflict，同一对象应只有一个 AppendWriter
+//
+// AppendWriter writes data incrementally to the end of an object, for streaming logs to object storage.
+// Written data is buffered in memory and appended once it reaches FlushSize or on Flush and Close; it is safe for concurrent use.
+// Appends build on the object's length and ETag as of creation; ErrAppendConflict is returned if another writer modifies the object, so keep a single AppendWriter per object.
+type AppendWriter struct {
+	c      *OssClient
+	ctx    context.Context
+	bucket string
+	key    string
+	o      AppendOptions
+
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	exists bool
+	size   int64
+	etag   string
+	closed bool
+}
+
+// NewAppendWriter 创建向 bucket/key 追加数据的写入器，对象已存在时从其末尾继续追加
+// 参数:
+//   - ctx: 上下文，用于写入器的所有请求
+//   - bucket: 存储桶
+//   - key: 对象键
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *AppendWriter: 写入器，使用完毕后必须调用 Close 写入剩余数据
+//   - error: 查询现有对象失败时返回错误
+//
+// NewAppendWriter creates a writer appending to bucket/key; an existing object is continued from its end.
+// Parameters:
+//   - ctx: Context used for all of the writer's requests
+//   - bucket: Bucket name
+//   - key: Object key
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *AppendWriter: The writer; Close must be called to write the remaining data
+//   - error: Returns an error if looking up the existing object fails
+func (c *OssClient) NewAppendWriter(ctx context.Context, bucket, key string, opts *AppendOptions) (*AppendWriter, error) {
+	w := &AppendWriter{c: c, ctx: ctx, bucket: bucket, key: key}
+	if opts != nil {
+		w.o = *opts
+	}
+	if w.o.FlushSize <= 0 {
+		w.o.FlushSize = DefaultAppendFlushSize
+	}
+
+	head, err := c.seClient.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	var notFound *types.NotFound
+	switch {
+	case errors.As(err, &notFound):
+	case err != nil:
+		return nil, err
+	default:
+		w.exists = true
+		w.size = aws.ToInt64(head.ContentLength)
+		w.etag = aws.ToString(head.ETag)
+		if w.o.ContentType == "" {
+			w.o.ContentType = aws.ToString(head.ContentType)
+		}
+	}
+	return w, nil
+}
+
+// Write 缓冲数据，缓冲区达到 FlushSize 时追加到对象
+//
+// Write buffers the data and appends it to the object once the buffer reaches FlushSize.
+func (w *AppendWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return 0, ErrAppendWriterClosed
+	}
+	w.buf.Write(p)
+	if w.buf.Len() >= w.o.FlushSize {
+		if err := w.flush(); err != nil {
+			return len(p), err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush 将缓冲的数据追加到对象，失败时数据保留在缓冲区中，可以重试
+//
+// Flush appends the buffered data to the object; on failure the data stays buffered and can be retried.
+func (w *AppendWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return ErrAppendWriterClosed
+	}
+	return w.flush()
+}
+
+// Close 追加剩余数据并关闭写入器，之后的写入返回 ErrAppendWriterClosed
+//
+// Close appends the remaining data and closes the writer; later writes return ErrAppendWriterClosed.
+func (w *AppendWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return nil
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.closed = true
+	return nil
+}
+
+// Size 返回对象当前的长度，不包括尚未刷新的数据
+//
+// Size returns the object's current length, excluding data not yet flushed.
+func (w *AppendWriter) Size() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.size
+}
+
+// flush 追加缓冲区中的数据，调用者必须持有 w.mu
+//
+// flush appends the buffered data; the caller must hold w.mu
+func (w *AppendWriter) flush() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	data := w.buf.Bytes()
+	var err error
+	switch {
+	case w.o.Mode == AppendNative:
+		err = w.appendNative(data)
+	case !w.exists:
+		err = w.put(data, false)
+	case w.size < minComposePartSize:
+		err = w.rewrite(data)
+	default:
+		err = w.compose(data)
+	}
+	if err != nil {
+		return err
+	}
+	w.buf.Reset()
+	return nil
+}
+
+// appendNative 通过 OSS 的 AppendObject 接口追加数据
+//
+// appendNative appends data through the OSS AppendObject API
+func (w *AppendWriter) appendNative(data []byte) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(w.bucket),
+		Key:           aws.String(w.key),
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	}
+	if w.size == 0 {
+		input.ContentType = aws.String(w.contentType(data))
+	}
+	out, err := w.c.seClient.PutObject(w.ctx, input, s3.WithAPIOptions(ossAppendObject(w.size)))
+	if err != nil {
+		if errorCode(err) == "PositionNotEqualToLength" {
+			return fmt.Errorf("%w: %v", ErrAppendConflict, err)
+		}
+		return err
+	}
+	next := w.size + int64(len(data))
+	if raw, ok := awsmiddleware.GetRawResponse(out.ResultMetadata).(*smithyhttp.Response); ok {
+		if p, err := strconv.ParseInt(raw.Header.Get("x-oss-next-append-position"), 10, 64); err == nil {
+			next = p
+		}
+	}
+	w.exists, w.size, w.etag = true, next, aws.ToString(out.ETag)
+	return nil
+}
+
+// rewrite 下载现有对象并与新数据一起重新上传
+//
+// rewrite downloads the existing object and uploads it again together with the new data
+func (w *AppendWriter) rewrite(data []byte) error {
+	out, err := w.c.seClient.GetObject(w.ctx, &s3.GetObjectInput{
+		Bucket:  aws.String(w.bucket),
+		Key:     aws.String(w.key),
+		IfMatch: aws.String(w.etag),
+	})
+	if err != nil {
+		return w.conflict(err)
+	}
+	existing, err := io.ReadAll(out.Body)
+	out.Body.Close()
+	if err != nil {
+		return err
+	}
+	return w.put(append(existing, data...), true)
+}
+
+// put 上传完整的对象内容，ifMatch 为 true 时要求对象未被修改，否则要求对象不存在
+//
+// put uploads the full object content, requiring the object to be unmodified when ifMatch is true and absent otherwise
+func (w *AppendWriter) put(content []byte, ifMatch bool) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(w.bucket),
+		Key:           aws.String(w.key),
+		Body:          bytes.NewReader(content),
+		ContentLength: aws.Int64(int64(len(content))),
+		ContentType:   aws.String(w.contentType(content)),
+	}
+	if ifMatch {
+		input.IfMatch = aws.String(w.etag)
+	} else {
+		input.IfNoneMatch = aws.String("*")
+	}
+	out, err := w.c.seClient.PutObject(w.ctx, input)
+	if err != nil {
+		return w.conflict(err)
+	}
+	w.exists, w.size, w.etag = true, int64(len(content)), aws.ToString(out.ETag)
+	return nil
+}
+
+// compose 通过分片上传合并现有对象和新数据：现有内容按不超过 5GB 的分片复制，新数据作为最后一个分片
+//
+// compose merges the existing object and the new data with a multipart upload: the existing content is copied in parts of at most 5GB and the new data is the last part
+func (w *AppendWriter) compose(data []byte) (err error) {
+	up, err := w.c.seClient.CreateMultipartUpload(w.ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(w.bucket),
+		Key:         aws.String(w.key),
+		ContentType: aws.String(w.contentType(data)),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_, _ = w.c.seClient.AbortMultipartUpload(context.WithoutCancel(w.ctx), &s3.AbortMultipartUploadInput{
+				Bucket:   aws.String(w.bucket),
+				Key:      aws.String(w.key),
+				UploadId: up.UploadId,
+			})
+		}
+	}()
+
+	// 均分复制范围，保证每个复制分片都不小于 5MB
+	copies := (w.size + maxCopyPartSize - 1) / maxCopyPartSize
+	partSize := (w.size + copies - 1) / copies
+	var parts []types.CompletedPart
+	for start := int64(0); start < w.size; start += partSize {
+		end := min(start+partSize, w.size) - 1
+		number := aws.Int32(int32(len(parts) + 1))
+		out, err := w.c.seClient.UploadPartCopy(w.ctx, &s3.UploadPartCopyInput{
+			Bucket:            aws.String(w.bucket),
+			Key:               aws.String(w.key),
+			UploadId:          up.UploadId,
+			PartNumber:        number,
+			CopySource:        aws.String(w.bucket + "/" + url.PathEscape(w.key)),
+			CopySourceIfMatch: aws.String(w.etag),
+			CopySourceRange:   aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		})
+		if err != nil {
+			return w.conflict(err)
+		}
+		parts = append(parts, types.CompletedPart{ETag: out.CopyPartResult.ETag, PartNumber: number})
+	}
+
+	number := aws.Int32(int32(len(parts) + 1))
+	part, err := w.c.seClient.UploadPart(w.ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(w.bucket),
+		Key:           aws.String(w.key),
+		UploadId:      up.UploadId,
+		PartNumber:    number,
+		Body:          bytes.NewReader(data),
+		ContentLength: aws.Int64(int64(len(data))),
+	})
+	if err != nil {
+		return err
+	}
+	parts = append(parts, types.CompletedPart{ETag: part.ETag, PartNumber: number})
+
+	done, err := w.c.seClient.CompleteMultipartUpload(w.ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(w.bucket),
+		Key:             aws.String(w.key),
+		UploadId:        up.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return err
+	}
+	w.size += int64(len(data))
+	w.etag = aws.ToString(done.ETag)
+	return nil
+}
+
+// contentType 返回创建对象时使用的内容类型
+//
+// contentType returns the content type used when creating the object
+func (w *AppendWriter) contentType(head []byte) string {
+	if w.o.ContentType != "" {
+		return w.o.ContentType
+	}
+	return w.c.contentTypes.Detect(w.key, head)
+}
+
+// conflict 将条件请求失败转换为 ErrAppendConflict
+//
+// conflict converts failed conditional requests to ErrAppendConflict
+func (w *AppendWriter) conflict(err error) error {
+	switch errorCode(err) {
+	case "PreconditionFailed", "ConditionalRequestConflict", "NoSuchKey":
+		return fmt.Errorf("%w: %v", ErrAppendConflict, err)
+	}
+	return err
+}
+
+// ossAppendObject 将 PutObject 请求改写为 OSS 的 AppendObject 请求（POST ?append&position=），在签名之前执行
+//
+// ossAppendObject rewrites a PutObject request into an OSS AppendObject request (POST ?append&position=), before signing
+func ossAppendObject(position int64) func(*middleware.Stack) error {
+	return func(stack *middleware.Stack) error {
+		return stack.Build.Add(middleware.BuildMiddlewareFunc("OSSAppendObject", func(
+			ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler,
+		) (middleware.BuildOutput, middleware.Metadata, error) {
+			if req, ok := in.Request.(*smithyhttp.Request); ok {
+				req.Method = http.MethodPost
+				query := "append&position=" + strconv.FormatInt(position, 10)
+				if req.URL.RawQuery != "" {
+					query += "&" + req.URL.RawQuery
+				}
+				req.URL.RawQuery = query
+			}
+			return next.HandleBuild(ctx, in)
+		}), middleware.After)
+	}
+}
@@ -0,0 +1,488 @@
+package ossutil
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/cacheutil"
+)
+
+const (
+	// DefaultCachedMaxObjectSize CachedStorage 默认缓存的最大对象大小
+	//
+	// DefaultCachedMaxObjectSize is the default largest object size cached by CachedStorage
+	DefaultCachedMaxObjectSize = 1 << 20
+	// DefaultCachedMaxAge CachedStorage 默认的免校验时间
+	//
+	// DefaultCachedMaxAge is the default time CachedStorage serves an entry without revalidating
+	DefaultCachedMaxAge = time.Minute
+	// DefaultCachedTTL 使用 cacheutil.Cache 时缓存项默认的过期时间
+	//
+	// DefaultCachedTTL is the default entry expiry when backed by a cacheutil.Cache
+	DefaultCachedTTL = 24 * time.Hour
+	// DefaultCachedDiskSize 使用本地磁盘时默认的缓存总大小上限
+	//
+	// DefaultCachedDiskSize is the default total size limit when backed by local disk
+	DefaultCachedDiskSize = 1 << 30
+	// DefaultCachedPrefix 使用 cacheutil.Cache 时默认的键前缀
+	//
+	// DefaultCachedPrefix is the default key prefix when backed by a cacheutil.Cache
+	DefaultCachedPrefix = "ossutil:object:"
+)
+
+// CachedStorageOptions CachedStorage 的选项
+// Cache: 缓存后端，与 Dir 二选一；都为空时使用 cacheutil.NewMemoryCache()，注意内存缓存没有大小上限
+// Prefix: 使用 Cache 时的键前缀，默认为 DefaultCachedPrefix
+// TTL: 使用 Cache 时缓存项的过期时间，默认为 DefaultCachedTTL
+// Dir: 本地磁盘缓存目录，按最近最少使用淘汰
+// MaxDiskSize: 磁盘缓存的总大小上限（字节），默认为 DefaultCachedDiskSize
+// MaxObjectSize: 只缓存不超过该大小的对象，默认为 DefaultCachedMaxObjectSize，更大的对象直接透传
+// MaxAge: 缓存项在该时间内直接使用，超过后通过 Head 比较 ETag 校验，默认为 DefaultCachedMaxAge
+//
+// CachedStorageOptions contains options for CachedStorage.
+// Cache: Cache backend, exclusive with Dir; cacheutil.NewMemoryCache() is used when both are empty, which has no size limit
+// Prefix: Key prefix when using Cache, DefaultCachedPrefix by default
+// TTL: Entry expiry when using Cache, DefaultCachedTTL by default
+// Dir: Local disk cache directory, evicted least recently used first
+// MaxDiskSize: Total size limit of the disk cache in bytes, DefaultCachedDiskSize by default
+// MaxObjectSize: Only objects up to this size are cached, DefaultCachedMaxObjectSize by default; larger objects pass straight through
+// MaxAge: Entries are used as is for this long, then revalidated by comparing the ETag from Head, DefaultCachedMaxAge by default
+type CachedStorageOptions struct {
+	Cache         cacheutil.Cache
+	Prefix        string
+	TTL           time.Duration
+	Dir           string
+	MaxDiskSize   int64
+	MaxObjectSize int64
+	MaxAge        time.Duration
+}
+
+// CachedStorage 为 ObjectStorage 增加读缓存，适用于频繁读取的小文件（例如图标、配置和静态资源）
+// 缓存项超过 MaxAge 后通过 Head 比较 ETag 校验，未变化时继续使用；校验请求失败时返回旧内容以保证可用性
+// 通过 CachedStorage 的 Put 和 Delete 会使对应缓存项失效，绕过它的修改最多在 MaxAge 后被发现
+//
+// CachedStorage adds a read cache to an ObjectStorage, for small, frequently read objects such as icons, configuration and static assets.
+// Entries older than MaxAge are revalidated by comparing the ETag from Head and kept if unchanged; a failed revalidation serves the stale content to stay available.
+// Put and Delete through CachedStorage invalidate the entry; changes made around it are noticed within MaxAge.
+type CachedStorage struct {
+	next  ObjectStorage
+	o     CachedStorageOptions
+	store objectCache
+}
+
+// NewCachedStorage 创建带缓存的对象存储
+// 参数:
+//   - next: 被包装的对象存储，例如 *OssClient
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *CachedStorage: 带缓存的对象存储
+//   - error: 磁盘缓存目录无法创建或读取时返回错误
+//
+// NewCachedStorage creates a cached object storage.
+// Parameters:
+//   - next: The wrapped object storage, e.g. *OssClient
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *CachedStorage: The cached object storage
+//   - error: Returns an error if the disk cache directory cannot be created or read
+func NewCachedStorage(next ObjectStorage, opts *CachedStorageOptions) (*CachedStorage, error) {
+	s := &CachedStorage{next: next}
+	if opts != nil {
+		s.o = *opts
+	}
+	if s.o.MaxObjectSize <= 0 {
+		s.o.MaxObjectSize = DefaultCachedMaxObjectSize
+	}
+	if s.o.MaxAge <= 0 {
+		s.o.MaxAge = DefaultCachedMaxAge
+	}
+
+	if s.o.Dir != "" {
+		if s.o.MaxDiskSize <= 0 {
+			s.o.MaxDiskSize = DefaultCachedDiskSize
+		}
+		store, err := newDiskCache(s.o.Dir, s.o.MaxDiskSize)
+		if err != nil {
+			return nil, err
+		}
+		s.store = store
+		return s, nil
+	}
+
+	if s.o.Cache == nil {
+		s.o.Cache = cacheutil.NewMemoryCache()
+	}
+	if s.o.Prefix == "" {
+		s.o.Prefix = DefaultCachedPrefix
+	}
+	if s.o.TTL <= 0 {
+		s.o.TTL = DefaultCachedTTL
+	}
+	s.store = &cacheStore{cache: s.o.Cache, prefix: s.o.Prefix, ttl: s.o.TTL}
+	return s, nil
+}
+
+// cachedObject 缓存项的头部
+// Info: 对象元信息
+// ValidatedAt: 最近一次读取或校验的时间
+//
+// cachedObject is the header of a cache entry
+// Info: The object's metadata
+// ValidatedAt: When the entry was last fetched or validated
+type cachedObject struct {
+	Info        ObjectInfo `json:"info"`
+	ValidatedAt time.Time  `json:"validated_at"`
+}
+
+// Get 实现 ObjectStorage 接口，优先从缓存读取
+//
+// Get implements the ObjectStorage interface, reading from the cache first.
+func (s *CachedStorage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, *ObjectInfo, error) {
+	ck := bucket + "/" + key
+	if head, data, ok := s.lookup(ctx, ck); ok {
+		if time.Since(head.ValidatedAt) < s.o.MaxAge {
+			return cachedBody(head, data)
+		}
+		info, err := s.next.Head(ctx, bucket, key)
+		switch {
+		case errors.Is(err, ErrObjectNotFound):
+			_ = s.store.remove(ctx, ck)
+			return nil, nil, err
+		case err != nil:
+			// 无法校验时返回旧内容
+			return cachedBody(head, data)
+		case info.ETag != "" && info.ETag == head.Info.ETag:
+			head.ValidatedAt = time.Now()
+			s.save(ctx, ck, head, data)
+			return cachedBody(head, data)
+		}
+	}
+
+	body, info, err := s.next.Get(ctx, bucket, key)
+	if err != nil {
+		if errors.Is(err, ErrObjectNotFound) {
+			_ = s.store.remove(ctx, ck)
+		}
+		return nil, nil, err
+	}
+	if info.Size > s.o.MaxObjectSize {
+		return body, info, nil
+	}
+	data, err := io.ReadAll(io.LimitReader(body, s.o.MaxObjectSize+1))
+	if err != nil {
+		body.Close()
+		return nil, nil, err
+	}
+	if int64(len(data)) > s.o.MaxObjectSize {
+		// 大小未知且超出上限，把已读的内容拼接回去后透传
+		return readCloser{Reader: io.MultiReader(bytes.NewReader(data), body), Closer: body}, info, nil
+	}
+	body.Close()
+	s.save(ctx, ck, &cachedObject{Info: *info, ValidatedAt: time.Now()}, data)
+	return io.NopCloser(bytes.NewReader(data)), info, nil
+}
+
+// Head 实现 ObjectStorage 接口，直接查询被包装的存储
+//
+// Head implements the ObjectStorage interface, querying the wrapped storage directly.
+func (s *CachedStorage) Head(ctx context.Context, bucket, key string) (*ObjectInfo, error) {
+	return s.next.Head(ctx, bucket, key)
+}
+
+// Put 实现 ObjectStorage 接口，上传后使缓存项失效
+//
+// Put implements the ObjectStorage interface, invalidating the entry after uploading.
+func (s *CachedStorage) Put(ctx context.Context, bucket, key string, body io.Reader, opts *PutOptions) error {
+	err := s.next.Put(ctx, bucket, key, body, opts)
+	_ = s.Invalidate(ctx, bucket, key)
+	return err
+}
+
+// Delete 实现 ObjectStorage 接口，删除后使缓存项失效
+//
+// Delete implements the ObjectStorage interface, invalidating the entry after deleting.
+func (s *CachedStorage) Delete(ctx context.Context, bucket, key string) error {
+	err := s.next.Delete(ctx, bucket, key)
+	_ = s.Invalidate(ctx, bucket, key)
+	return err
+}
+
+// Invalidate 使对象的缓存项失效，例如收到对象变更事件时调用
+//
+// Invalidate drops the object's cache entry, e.g. on receiving an object change event.
+func (s *CachedStorage) Invalidate(ctx context.Context, bucket, key string) error {
+	return s.store.remove(ctx, bucket+"/"+key)
+}
+
+// lookup 读取并解码缓存项，缓存出错或数据损坏时视为未命中
+//
+// lookup reads and decodes a cache entry; cache errors and corrupt data count as misses
+func (s *CachedStorage) lookup(ctx context.Context, ck string) (*cachedObject, []byte, bool) {
+	raw, ok, err := s.store.load(ctx, ck)
+	if err != nil || !ok || len(raw) < 4 {
+		return nil, nil, false
+	}
+	n := binary.BigEndian.Uint32(raw)
+	if uint64(len(raw)-4) < uint64(n) {
+		return nil, nil, false
+	}
+	var head cachedObject
+	if err := json.Unmarshal(raw[4:4+n], &head); err != nil {
+		return nil, nil, false
+	}
+	return &head, raw[4+n:], true
+}
+
+// save 编码并写入缓存项，写入失败时忽略
+//
+// save encodes and writes a cache entry, ignoring write failures
+func (s *CachedStorage) save(ctx context.Context, ck string, head *cachedObject, data []byte) {
+	meta, err := json.Marshal(head)
+	if err != nil {
+		return
+	}
+	raw := make([]byte, 4, 4+len(meta)+len(data))
+	binary.BigEndian.PutUint32(raw, uint32(len(meta)))
+	raw = append(append(raw, meta...), data...)
+	_ = s.store.store(ctx, ck, raw)
+}
+
+// cachedBody 返回缓存内容的读取器和元信息的副本
+//
+// cachedBody returns a reader over the cached content and a copy of the metadata
+func cachedBody(head *cachedObject, data []byte) (io.ReadCloser, *ObjectInfo, error) {
+	info := head.Info
+	return io.NopCloser(bytes.NewReader(data)), &info, nil
+}
+
+// readCloser 组合 Reader 和 Closer
+//
+// readCloser combines a Reader and a Closer
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// objectCache CachedStorage 的缓存后端
+//
+// objectCache is the cache backend of CachedStorage
+type objectCache interface {
+	load(ctx context.Context, key string) ([]byte, bool, error)
+	store(ctx context.Context, key string, value []byte) error
+	remove(ctx context.Context, key string) error
+}
+
+// cacheStore 基于 cacheutil.Cache 的缓存后端
+//
+// cacheStore is a cache backend based on cacheutil.Cache
+type cacheStore struct {
+	cache  cacheutil.Cache
+	prefix string
+	ttl    time.Duration
+}
+
+// load 实现 objectCache 接口
+//
+// load implements the objectCache interface
+func (c *cacheStore) load(ctx context.Context, key string) ([]byte, bool, error) {
+	return c.cache.Get(ctx, c.prefix+key)
+}
+
+// store 实现 objectCache 接口
+//
+// store implements the objectCache interface
+func (c *cacheStore) store(ctx context.Context, key string, value []byte) error {
+	return c.cache.Set(ctx, c.prefix+key, value, c.ttl)
+}
+
+// remove 实现 objectCache 接口
+//
+// remove implements the objectCache interface
+func (c *cacheStore) remove(ctx context.Context, key string) error {
+	return c.cache.Delete(ctx, c.prefix+key)
+}
+
+// diskCache 基于本地目录的缓存后端，总大小超过上限时淘汰最近最少使用的文件
+// 文件名为键的 SHA-256 摘要，重启后按文件修改时间恢复使用顺序
+//
+// diskCache is a cache backend based on a local directory, evicting the least recently used files once the total size exceeds the limit
+// Files are named by the SHA-256 digest of the key; the usage order is recovered from modification times after a restart
+type diskCache struct {
+	dir string
+	max int64
+
+	mu    sync.Mutex
+	lru   *list.List
+	items map[string]*list.Element
+	total int64
+}
+
+// diskEntry 磁盘缓存中的一个文件
+//
+// diskEntry is one file in the disk cache
+type diskEntry struct {
+	name string
+	size int64
+}
+
+// newDiskCache 创建磁盘缓存，读取目录中已有的文件
+//
+// newDiskCache creates a disk cache, picking up files already in the directory
+func newDiskCache(dir string, maxSize int64) (*diskCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	type existing struct {
+		diskEntry
+		mod time.Time
+	}
+	var files []existing
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		if filepath.Ext(e.Name()) == ".tmp" {
+			// 上次异常退出时遗留的临时文件
+			os.Remove(filepath.Join(dir, e.Name()))
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, existing{diskEntry{name: e.Name(), size: info.Size()}, info.ModTime()})
+	}
+	slices.SortFunc(files, func(a, b existing) int { return a.mod.Compare(b.mod) })
+
+	d := &diskCache{dir: dir, max: maxSize, lru: list.New(), items: make(map[string]*list.Element)}
+	for _, f := range files {
+		d.items[f.name] = d.lru.PushFront(f.diskEntry)
+		d.total += f.size
+	}
+	d.mu.Lock()
+	d.evict()
+	d.mu.Unlock()
+	return d, nil
+}
+
+// load 实现 objectCache 接口
+//
+// load implements the objectCache interface
+func (d *diskCache) load(_ context.Context, key string) ([]byte, bool, error) {
+	name := diskName(key)
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	d.mu.Lock()
+	if el, ok := d.items[name]; ok {
+		d.lru.MoveToFront(el)
+	}
+	d.mu.Unlock()
+	return data, true, nil
+}
+
+// store 实现 objectCache 接口
+//
+// store implements the objectCache interface
+func (d *diskCache) store(_ context.Context, key string, value []byte) error {
+	if int64(len(value)) > d.max {
+		return nil
+	}
+	name := diskName(key)
+	path := filepath.Join(d.dir, name)
+	// 先写临时文件再重命名，避免读到写了一半的文件
+	tmp, err := os.CreateTemp(d.dir, name+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if el, ok := d.items[name]; ok {
+		d.total -= el.Value.(diskEntry).size
+		d.lru.Remove(el)
+	}
+	d.items[name] = d.lru.PushFront(diskEntry{name: name, size: int64(len(value))})
+	d.total += int64(len(value))
+	d.evict()
+	return nil
+}
+
+// remove 实现 objectCache 接口
+//
+// remove implements the objectCache interface
+func (d *diskCache) remove(_ context.Context, key string) error {
+	name := diskName(key)
+	d.mu.Lock()
+	if el, ok := d.items[name]; ok {
+		d.total -= el.Value.(diskEntry).size
+		d.lru.Remove(el)
+		delete(d.items, name)
+	}
+	d.mu.Unlock()
+	err := os.Remove(filepath.Join(d.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// evict 删除最近最少使用的文件直到总大小不超过上限，调用者必须持有 d.mu
+//
+// evict removes the least recently used files until the total size is within the limit; the caller must hold d.mu
+func (d *diskCache) evict() {
+	for d.total > d.max {
+		el := d.lru.Back()
+		if el == nil {
+			return
+		}
+		e := el.Value.(diskEntry)
+		d.lru.Remove(el)
+		delete(d.items, e.name)
+		d.total -= e.size
+		os.Remove(filepath.Join(d.dir, e.name))
+	}
+}
+
+// diskName 返回键对应的文件名
+//
+// diskName returns the file name for a key
+func diskName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,6 +1,11 @@
+// Package ossutil 提供对象存储（S3 及兼容 S3 协议的服务）相关的工具函数
+//
+// Package ossutil provides object storage utility functions for S3 and S3-compatible services.
 package ossutil
 
 import (
+	"context"
+
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 )
@@ -8,13 +13,56 @@ import (
 // type
 
 type OssClient struct {
-	seClient *s3.Client
+	seClient     *s3.Client
+	contentTypes *ContentTypePolicy
+	cacheControl *CacheControlPolicy
 }
 
 func NewOssClient(region string) *OssClient {
-	return &OssClient{
-		seClient: s3.NewFromConfig(aws.Config{
-			Region: region,
-		}),
-	}
+	return NewOssClientFromConfig(aws.Config{
+		Region: region,
+	})
+}
+
+// NewOssClientFromConfig 使用完整的 aws.Config 创建客户端，可通过 optFns 指定兼容 S3 协议的服务端点（例如阿里云 OSS）
+// 每个 S3 操作都会通过 tracing 包创建 span
+// 参数:
+//   - cfg: AWS 配置，包含区域和凭证
+//   - optFns: S3 客户端选项，例如设置 BaseEndpoint 或 UsePathStyle
+//
+// 返回:
+//   - *OssClient: 客户端
+//
+// NewOssClientFromConfig creates a client from a full aws.Config; optFns can point it at S3-compatible endpoints (such as Aliyun OSS).
+// Every S3 operation creates a span through the tracing package.
+// Parameters:
+//   - cfg: AWS configuration including region and credentials
+//   - optFns: S3 client options, e.g. setting BaseEndpoint or UsePathStyle
+//
+// Returns:
+//   - *OssClient: The client
+func NewOssClientFromConfig(cfg aws.Config, optFns ...func(*s3.Options)) *OssClient {
+	return &OssClient{seClient: s3.NewFromConfig(cfg, append([]func(*s3.Options){traceOperations}, optFns...)...)}
+}
+
+// S3 返回底层的 S3 客户端，用于本包未封装的操作
+//
+// S3 returns the underlying S3 client for operations this package does not wrap.
+func (c *OssClient) S3() *s3.Client {
+	return c.seClient
+}
+
+// NewOssClientWithCredentials 使用指定的凭证提供者创建客户端，例如 NewAssumeRoleProvider 或 NewAliyunSTSProvider 的返回值
+//
+// NewOssClientWithCredentials creates a client with the given credentials provider, such as one returned by NewAssumeRoleProvider or NewAliyunSTSProvider.
+func NewOssClientWithCredentials(region string, provider aws.CredentialsProvider, optFns ...func(*s3.Options)) *OssClient {
+	return NewOssClientFromConfig(aws.Config{Region: region, Credentials: provider}, optFns...)
+}
+
+// Ping 检查存储桶是否可访问（HeadBucket），可用于健康检查
+//
+// Ping checks that a bucket is reachable (HeadBucket); useful for health checks.
+func (c *OssClient) Ping(ctx context.Context, bucket string) error {
+	_, err := c.seClient.HeadBucket(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)})
+	return err
 }
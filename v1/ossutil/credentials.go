@@ -0,0 +1,423 @@
+package ossutil
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+
+	"github.com/supergodk/go-utils/v1/tracing"
+)
+
+const (
+	// DefaultCredentialExpiryWindow 临时凭证在过期前多久开始刷新
+	//
+	// DefaultCredentialExpiryWindow is how long before expiry temporary credentials are refreshed
+	DefaultCredentialExpiryWindow = 5 * time.Minute
+	// DefaultAssumeRoleDuration 临时凭证的默认有效期
+	//
+	// DefaultAssumeRoleDuration is the default lifetime of temporary credentials
+	DefaultAssumeRoleDuration = time.Hour
+	// DefaultAliyunSTSEndpoint 阿里云 STS 的默认服务端点
+	//
+	// DefaultAliyunSTSEndpoint is the default Aliyun STS endpoint
+	DefaultAliyunSTSEndpoint = "https://sts.aliyuncs.com"
+)
+
+// ErrAssumeRole 表示获取临时凭证失败
+//
+// ErrAssumeRole indicates that fetching temporary credentials failed
+var ErrAssumeRole = errors.New("assume role failed")
+
+// CredentialFetcher 获取一组（通常是临时的）凭证，返回的凭证应设置 CanExpire 和 Expires
+//
+// CredentialFetcher fetches a set of (usually temporary) credentials; returned credentials should set CanExpire and Expires.
+type CredentialFetcher func(ctx context.Context) (aws.Credentials, error)
+
+// Retrieve 调用 f 获取凭证，使 CredentialFetcher 实现 aws.CredentialsProvider
+//
+// Retrieve calls f to fetch credentials, making CredentialFetcher an aws.CredentialsProvider.
+func (f CredentialFetcher) Retrieve(ctx context.Context) (aws.Credentials, error) {
+	return f(ctx)
+}
+
+// AssumeRoleOptions 扮演角色的选项
+// RoleARN: 要扮演的角色 ARN，必填
+// SessionName: 会话名称，为空时使用 "go-utils-<时间戳>"
+// ExternalID: 跨账号扮演时的外部 ID（仅 AWS）
+// Policy: 进一步限制权限的会话策略 JSON
+// Duration: 凭证有效期，默认 DefaultAssumeRoleDuration
+// Region: AWS STS 区域，为空时使用全局端点（仅 AWS）
+// Endpoint: 自定义 STS 端点
+// HTTPClient: 发起请求的 HTTP 客户端，默认 http.DefaultClient
+//
+// AssumeRoleOptions contains options for assuming a role.
+// RoleARN: ARN of the role to assume, required
+// SessionName: Session name, defaults to "go-utils-<timestamp>"
+// ExternalID: External ID for cross-account assumption (AWS only)
+// Policy: Session policy JSON further restricting permissions
+// Duration: Credential lifetime, defaults to DefaultAssumeRoleDuration
+// Region: AWS STS region; the global endpoint is used when empty (AWS only)
+// Endpoint: Custom STS endpoint
+// HTTPClient: HTTP client for requests, defaults to http.DefaultClient
+type AssumeRoleOptions struct {
+	RoleARN     string
+	SessionName string
+	ExternalID  string
+	Policy      string
+	Duration    time.Duration
+	Region      string
+	Endpoint    string
+	HTTPClient  *http.Client
+}
+
+// withDefaults 返回填充了默认值的选项副本
+//
+// withDefaults returns a copy of the options with defaults filled in
+func (o AssumeRoleOptions) withDefaults() AssumeRoleOptions {
+	if o.SessionName == "" {
+		o.SessionName = "go-utils-" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	if o.Duration <= 0 {
+		o.Duration = DefaultAssumeRoleDuration
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = http.DefaultClient
+	}
+	return o
+}
+
+// NewCredentialProvider 为任意凭证获取函数增加缓存，并在凭证过期前自动刷新
+// 适用于临时凭证由其他服务下发的场景（例如移动端通过业务后端获取的 STS 凭证）
+// 参数:
+//   - fetch: 凭证获取函数
+//   - expiryWindow: 提前刷新的时间，小于等于 0 时使用 DefaultCredentialExpiryWindow
+//
+// 返回:
+//   - *aws.CredentialsCache: 可直接赋值给 aws.Config.Credentials 的凭证提供者，并发安全
+//
+// NewCredentialProvider adds caching to any credential fetcher and refreshes the credentials before they expire.
+// It suits temporary credentials issued by another service (such as STS credentials a mobile app obtains from its backend).
+// Parameters:
+//   - fetch: The credential fetcher
+//   - expiryWindow: How early to refresh; DefaultCredentialExpiryWindow is used when <= 0
+//
+// Returns:
+//   - *aws.CredentialsCache: A provider that can be assigned to aws.Config.Credentials, safe for concurrent use
+func NewCredentialProvider(fetch CredentialFetcher, expiryWindow time.Duration) *aws.CredentialsCache {
+	if expiryWindow <= 0 {
+		expiryWindow = DefaultCredentialExpiryWindow
+	}
+	return aws.NewCredentialsCache(fetch, func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = expiryWindow
+	})
+}
+
+// NewAssumeRoleProvider 创建通过 AWS STS AssumeRole 获取临时凭证的提供者，带缓存和自动刷新
+// 参数:
+//   - base: 用于调用 STS 的基础凭证
+//   - opts: 扮演角色的选项
+//
+// 返回:
+//   - *aws.CredentialsCache: 凭证提供者
+//
+// NewAssumeRoleProvider creates a provider that fetches temporary credentials with AWS STS AssumeRole, with caching and automatic refresh.
+// Parameters:
+//   - base: Base credentials used to call STS
+//   - opts: Options for assuming the role
+//
+// Returns:
+//   - *aws.CredentialsCache: The credentials provider
+func NewAssumeRoleProvider(base aws.CredentialsProvider, opts AssumeRoleOptions) *aws.CredentialsCache {
+	return NewCredentialProvider(AssumeRole(base, opts), 0)
+}
+
+// NewAliyunSTSProvider 创建通过阿里云 STS AssumeRole 获取临时凭证的提供者，带缓存和自动刷新
+// 参数:
+//   - accessKeyID: RAM 用户的 AccessKey ID
+//   - accessKeySecret: RAM 用户的 AccessKey Secret
+//   - opts: 扮演角色的选项
+//
+// 返回:
+//   - *aws.CredentialsCache: 凭证提供者
+//
+// NewAliyunSTSProvider creates a provider that fetches temporary credentials with Aliyun STS AssumeRole, with caching and automatic refresh.
+// Parameters:
+//   - accessKeyID: AccessKey ID of the RAM user
+//   - accessKeySecret: AccessKey Secret of the RAM user
+//   - opts: Options for assuming the role
+//
+// Returns:
+//   - *aws.CredentialsCache: The credentials provider
+func NewAliyunSTSProvider(accessKeyID, accessKeySecret string, opts AssumeRoleOptions) *aws.CredentialsCache {
+	return NewCredentialProvider(AliyunAssumeRole(accessKeyID, accessKeySecret, opts), 0)
+}
+
+// assumeRoleResponse AWS STS AssumeRole 的响应
+//
+// assumeRoleResponse is the AWS STS AssumeRole response
+type assumeRoleResponse struct {
+	Credentials struct {
+		AccessKeyID     string    `xml:"AccessKeyId"`
+		SecretAccessKey string    `xml:"SecretAccessKey"`
+		SessionToken    string    `xml:"SessionToken"`
+		Expiration      time.Time `xml:"Expiration"`
+	} `xml:"AssumeRoleResult>Credentials"`
+}
+
+// stsErrorResponse AWS STS 的错误响应
+//
+// stsErrorResponse is the AWS STS error response
+type stsErrorResponse struct {
+	Code    string `xml:"Error>Code"`
+	Message string `xml:"Error>Message"`
+}
+
+// AssumeRole 返回调用 AWS STS AssumeRole 的凭证获取函数（不带缓存）
+// 参数:
+//   - base: 用于调用 STS 的基础凭证
+//   - opts: 扮演角色的选项
+//
+// 返回:
+//   - CredentialFetcher: 凭证获取函数，失败时返回 ErrAssumeRole
+//
+// AssumeRole returns a credential fetcher that calls AWS STS AssumeRole (without caching).
+// Parameters:
+//   - base: Base credentials used to call STS
+//   - opts: Options for assuming the role
+//
+// Returns:
+//   - CredentialFetcher: The credential fetcher, returning ErrAssumeRole on failure
+func AssumeRole(base aws.CredentialsProvider, opts AssumeRoleOptions) CredentialFetcher {
+	opts = opts.withDefaults()
+	region, endpoint := opts.Region, opts.Endpoint
+	if region == "" {
+		region = "us-east-1"
+	}
+	if endpoint == "" {
+		endpoint = "https://sts.amazonaws.com"
+		if opts.Region != "" {
+			endpoint = "https://sts." + opts.Region + ".amazonaws.com"
+		}
+	}
+	signer := v4.NewSigner()
+
+	return func(ctx context.Context) (aws.Credentials, error) {
+		baseCreds, err := base.Retrieve(ctx)
+		if err != nil {
+			return aws.Credentials{}, fmt.Errorf("%w: %v", ErrAssumeRole, err)
+		}
+
+		form := url.Values{}
+		form.Set("Action", "AssumeRole")
+		form.Set("Version", "2011-06-15")
+		form.Set("RoleArn", opts.RoleARN)
+		form.Set("RoleSessionName", opts.SessionName)
+		form.Set("DurationSeconds", strconv.Itoa(int(opts.Duration/time.Second)))
+		if opts.ExternalID != "" {
+			form.Set("ExternalId", opts.ExternalID)
+		}
+		if opts.Policy != "" {
+			form.Set("Policy", opts.Policy)
+		}
+		body := form.Encode()
+		sum := sha256.Sum256([]byte(body))
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+		if err != nil {
+			return aws.Credentials{}, fmt.Errorf("%w: %v", ErrAssumeRole, err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		if err := signer.SignHTTP(ctx, baseCreds, req, hex.EncodeToString(sum[:]), "sts", region, time.Now()); err != nil {
+			return aws.Credentials{}, fmt.Errorf("%w: %v", ErrAssumeRole, err)
+		}
+
+		data, status, err := doSTSRequest(opts.HTTPClient, req)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+		if status != http.StatusOK {
+			var e stsErrorResponse
+			if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+				return aws.Credentials{}, fmt.Errorf("%w: %s: %s", ErrAssumeRole, e.Code, e.Message)
+			}
+			return aws.Credentials{}, fmt.Errorf("%w: status %d", ErrAssumeRole, status)
+		}
+
+		var resp assumeRoleResponse
+		if err := xml.Unmarshal(data, &resp); err != nil {
+			return aws.Credentials{}, fmt.Errorf("%w: %v", ErrAssumeRole, err)
+		}
+		c := resp.Credentials
+		if c.AccessKeyID == "" {
+			return aws.Credentials{}, fmt.Errorf("%w: empty credentials", ErrAssumeRole)
+		}
+		return aws.Credentials{
+			AccessKeyID:     c.AccessKeyID,
+			SecretAccessKey: c.SecretAccessKey,
+			SessionToken:    c.SessionToken,
+			Source:          "AssumeRole",
+			CanExpire:       true,
+			Expires:         c.Expiration,
+		}, nil
+	}
+}
+
+// aliyunAssumeRoleResponse 阿里云 STS AssumeRole 的响应
+//
+// aliyunAssumeRoleResponse is the Aliyun STS AssumeRole response
+type aliyunAssumeRoleResponse struct {
+	Code        string `json:"Code"`
+	Message     string `json:"Message"`
+	Credentials struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		AccessKeySecret string    `json:"AccessKeySecret"`
+		SecurityToken   string    `json:"SecurityToken"`
+		Expiration      time.Time `json:"Expiration"`
+	} `json:"Credentials"`
+}
+
+// AliyunAssumeRole 返回调用阿里云 STS AssumeRole 的凭证获取函数（不带缓存）
+// 参数:
+//   - accessKeyID: RAM 用户的 AccessKey ID
+//   - accessKeySecret: RAM 用户的 AccessKey Secret
+//   - opts: 扮演角色的选项，Duration 最短 15 分钟
+//
+// 返回:
+//   - CredentialFetcher: 凭证获取函数，失败时返回 ErrAssumeRole
+//
+// AliyunAssumeRole returns a credential fetcher that calls Aliyun STS AssumeRole (without caching).
+// Parameters:
+//   - accessKeyID: AccessKey ID of the RAM user
+//   - accessKeySecret: AccessKey Secret of the RAM user
+//   - opts: Options for assuming the role; Duration is at least 15 minutes
+//
+// Returns:
+//   - CredentialFetcher: The credential fetcher, returning ErrAssumeRole on failure
+func AliyunAssumeRole(accessKeyID, accessKeySecret string, opts AssumeRoleOptions) CredentialFetcher {
+	opts = opts.withDefaults()
+	if opts.Endpoint == "" {
+		opts.Endpoint = DefaultAliyunSTSEndpoint
+	}
+
+	return func(ctx context.Context) (aws.Credentials, error) {
+		nonce := make([]byte, 16)
+		rand.Read(nonce)
+		params := map[string]string{
+			"Action":           "AssumeRole",
+			"Version":          "2015-04-01",
+			"Format":           "JSON",
+			"AccessKeyId":      accessKeyID,
+			"SignatureMethod":  "HMAC-SHA1",
+			"SignatureVersion": "1.0",
+			"SignatureNonce":   hex.EncodeToString(nonce),
+			"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+			"RoleArn":          opts.RoleARN,
+			"RoleSessionName":  opts.SessionName,
+			"DurationSeconds":  strconv.Itoa(int(opts.Duration / time.Second)),
+		}
+		if opts.Policy != "" {
+			params["Policy"] = opts.Policy
+		}
+		query := aliyunCanonicalQuery(params)
+		query += "&Signature=" + aliyunPercentEncode(aliyunSign(http.MethodGet, query, accessKeySecret))
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, opts.Endpoint+"/?"+query, nil)
+		if err != nil {
+			return aws.Credentials{}, fmt.Errorf("%w: %v", ErrAssumeRole, err)
+		}
+		data, status, err := doSTSRequest(opts.HTTPClient, req)
+		if err != nil {
+			return aws.Credentials{}, err
+		}
+
+		var resp aliyunAssumeRoleResponse
+		if err := json.Unmarshal(data, &resp); err != nil {
+			return aws.Credentials{}, fmt.Errorf("%w: status %d: %v", ErrAssumeRole, status, err)
+		}
+		if status != http.StatusOK || resp.Code != "" {
+			return aws.Credentials{}, fmt.Errorf("%w: %s: %s", ErrAssumeRole, resp.Code, resp.Message)
+		}
+		c := resp.Credentials
+		if c.AccessKeyID == "" {
+			return aws.Credentials{}, fmt.Errorf("%w: empty credentials", ErrAssumeRole)
+		}
+		return aws.Credentials{
+			AccessKeyID:     c.AccessKeyID,
+			SecretAccessKey: c.AccessKeySecret,
+			SessionToken:    c.SecurityToken,
+			Source:          "AliyunAssumeRole",
+			CanExpire:       true,
+			Expires:         c.Expiration,
+		}, nil
+	}
+}
+
+// doSTSRequest 发送 STS 请求并读取响应体
+//
+// doSTSRequest sends an STS request and reads the response body
+func doSTSRequest(client *http.Client, req *http.Request) (data []byte, status int, err error) {
+	ctx, end := tracing.StartSpan(req.Context(), "ossutil.AssumeRole")
+	defer func() { end(err) }()
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrAssumeRole, err)
+	}
+	defer resp.Body.Close()
+	data, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", ErrAssumeRole, err)
+	}
+	return data, resp.StatusCode, nil
+}
+
+// aliyunCanonicalQuery 按参数名排序并编码查询字符串
+//
+// aliyunCanonicalQuery sorts parameters by name and encodes the query string
+func aliyunCanonicalQuery(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = aliyunPercentEncode(k) + "=" + aliyunPercentEncode(params[k])
+	}
+	return strings.Join(pairs, "&")
+}
+
+// aliyunSign 计算 RPC 风格的签名
+//
+// aliyunSign computes the RPC style signature
+func aliyunSign(method, canonicalQuery, secret string) string {
+	stringToSign := method + "&" + aliyunPercentEncode("/") + "&" + aliyunPercentEncode(canonicalQuery)
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// aliyunPercentEncode 按阿里云规则进行 URL 编码（空格为 %20，* 为 %2A，~ 不编码）
+//
+// aliyunPercentEncode URL-encodes according to Aliyun rules (space as %20, * as %2A, ~ unescaped)
+func aliyunPercentEncode(s string) string {
+	s = url.QueryEscape(s)
+	return strings.NewReplacer("+", "%20", "*", "%2A", "%7E", "~").Replace(s)
+}
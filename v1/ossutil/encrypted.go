@@ -0,0 +1,216 @@
+package ossutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+
+	"github.com/supergodk/go-utils/v1/cryptoutil"
+)
+
+const (
+	// MetadataKeyVersion 加密对象的元数据键，值为加密时使用的主密钥版本，可用于查找尚未迁移到新主密钥的对象
+	//
+	// MetadataKeyVersion is the metadata key of encrypted objects holding the master key version used for encryption, useful for finding objects not yet migrated to a new master key
+	MetadataKeyVersion = "cse-key-version"
+	// MetadataPlainContentType 加密对象的元数据键，值为明文的内容类型
+	//
+	// MetadataPlainContentType is the metadata key of encrypted objects holding the plaintext's content type
+	MetadataPlainContentType = "cse-content-type"
+	// MetadataPlainSize 加密对象的元数据键，值为明文的大小（字节）
+	//
+	// MetadataPlainSize is the metadata key of encrypted objects holding the plaintext's size in bytes
+	MetadataPlainSize = "cse-size"
+	// encryptedContentType 加密对象在存储中的内容类型
+	//
+	// encryptedContentType is the stored content type of encrypted objects
+	encryptedContentType = "application/octet-stream"
+	// encryptionOverhead 信封密文相对明文的最大额外长度的宽松估计，用于在下载前检查大小
+	//
+	// encryptionOverhead is a loose upper bound on how much longer an envelope ciphertext is than its plaintext, used to check sizes before downloading
+	encryptionOverhead = 1 << 17
+)
+
+// ErrNotEncrypted 表示读取到的对象没有客户端加密的元数据
+//
+// ErrNotEncrypted indicates that an object read back lacks the client-side encryption metadata
+var ErrNotEncrypted = errors.New("object is not client-side encrypted")
+
+// EncryptedStorageOptions EncryptedStorage 的选项
+// AAD: 返回绑定到密文的附加认证数据，默认为对象键，使密文无法被替换到其他键下；对象被改名或迁移到其他前缀时需要相应地调整
+// AllowPlaintext: 读取没有加密元数据的对象时原样返回，而不是返回 ErrNotEncrypted，用于逐步加密已有的存储桶
+// MaxObjectSize: 加密和解密都需要把整个对象读入内存，超过该大小（字节）的对象会被拒绝；为 0 时不限制
+//
+// EncryptedStorageOptions contains options for EncryptedStorage.
+// AAD: Returns the additional authenticated data bound to the ciphertext, the object key by default, so ciphertexts cannot be swapped under other keys; adjust it when objects are renamed or migrated to another prefix
+// AllowPlaintext: Return objects without encryption metadata as is instead of ErrNotEncrypted, for encrypting an existing bucket gradually
+// MaxObjectSize: Both encryption and decryption hold the whole object in memory, so objects larger than this many bytes are rejected; no limit when 0
+type EncryptedStorageOptions struct {
+	AAD            func(bucket, key string) []byte
+	AllowPlaintext bool
+	MaxObjectSize  int64
+}
+
+// EncryptedStorage 为 ObjectStorage 增加客户端加密，上传前用 cryptoutil.Encryptor 做信封加密，下载后解密，适用于存放个人信息的存储桶
+// 存储服务只能看到密文；主密钥版本、明文的内容类型和大小记录在对象元数据中，Get 和 Head 返回的 ObjectInfo 描述的是明文
+// 主密钥轮换后旧对象仍可透明读取，重新上传即迁移到新主密钥
+//
+// EncryptedStorage adds client-side encryption to an ObjectStorage, envelope-encrypting with a cryptoutil.Encryptor before upload and decrypting after download, for buckets holding personal data.
+// The storage service only ever sees ciphertext; the master key version and the plaintext's content type and size are recorded in object metadata, and the ObjectInfo returned by Get and Head describes the plaintext.
+// Old objects stay readable after a master key rotation, and uploading them again migrates them to the new master key.
+type EncryptedStorage struct {
+	next ObjectStorage
+	enc  *cryptoutil.Encryptor
+	o    EncryptedStorageOptions
+}
+
+// NewEncryptedStorage 创建客户端加密的对象存储
+// 参数:
+//   - next: 被包装的对象存储，例如 *OssClient
+//   - enc: 信封加密器
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *EncryptedStorage: 客户端加密的对象存储
+//
+// NewEncryptedStorage creates a client-side encrypted object storage.
+// Parameters:
+//   - next: The wrapped object storage, e.g. *OssClient
+//   - enc: The envelope encryptor
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *EncryptedStorage: The client-side encrypted object storage
+func NewEncryptedStorage(next ObjectStorage, enc *cryptoutil.Encryptor, opts *EncryptedStorageOptions) *EncryptedStorage {
+	s := &EncryptedStorage{next: next, enc: enc}
+	if opts != nil {
+		s.o = *opts
+	}
+	if s.o.AAD == nil {
+		s.o.AAD = func(_, key string) []byte { return []byte(key) }
+	}
+	return s
+}
+
+// Get 实现 ObjectStorage 接口，读取并解密对象
+//
+// Get implements the ObjectStorage interface, reading and decrypting the object.
+func (s *EncryptedStorage) Get(ctx context.Context, bucket, key string) (io.ReadCloser, *ObjectInfo, error) {
+	body, info, err := s.next.Get(ctx, bucket, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, ok := info.Metadata[MetadataKeyVersion]; !ok {
+		if s.o.AllowPlaintext {
+			return body, info, nil
+		}
+		body.Close()
+		return nil, nil, fmt.Errorf("%w: %s/%s", ErrNotEncrypted, bucket, key)
+	}
+	defer body.Close()
+	if s.o.MaxObjectSize > 0 && info.Size > s.o.MaxObjectSize+encryptionOverhead {
+		return nil, nil, fmt.Errorf("%s/%s: encrypted object of %d bytes exceeds the size limit", bucket, key, info.Size)
+	}
+	ciphertext, err := io.ReadAll(body)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := s.enc.Decrypt(ctx, ciphertext, s.o.AAD(bucket, key))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s/%s: %w", bucket, key, err)
+	}
+	plain := plainInfo(info)
+	plain.Size = int64(len(plaintext))
+	return io.NopCloser(bytes.NewReader(plaintext)), plain, nil
+}
+
+// Head 实现 ObjectStorage 接口，返回明文的元信息
+//
+// Head implements the ObjectStorage interface, returning the plaintext's metadata.
+func (s *EncryptedStorage) Head(ctx context.Context, bucket, key string) (*ObjectInfo, error) {
+	info, err := s.next.Head(ctx, bucket, key)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := info.Metadata[MetadataKeyVersion]; !ok {
+		if s.o.AllowPlaintext {
+			return info, nil
+		}
+		return nil, fmt.Errorf("%w: %s/%s", ErrNotEncrypted, bucket, key)
+	}
+	return plainInfo(info), nil
+}
+
+// Put 实现 ObjectStorage 接口，加密后上传
+// opts 中的 ContentType 记录在元数据中，为空时不做嗅探（嗅探需要明文）；ContentLength 会被忽略
+//
+// Put implements the ObjectStorage interface, encrypting before uploading.
+// The ContentType in opts is recorded in metadata, and no sniffing happens when it is empty since that would need the plaintext; ContentLength is ignored.
+func (s *EncryptedStorage) Put(ctx context.Context, bucket, key string, body io.Reader, opts *PutOptions) error {
+	var o PutOptions
+	if opts != nil {
+		o = *opts
+	}
+	r := body
+	if s.o.MaxObjectSize > 0 {
+		r = io.LimitReader(body, s.o.MaxObjectSize+1)
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if s.o.MaxObjectSize > 0 && int64(len(plaintext)) > s.o.MaxObjectSize {
+		return fmt.Errorf("%s/%s: object exceeds the size limit of %d bytes", bucket, key, s.o.MaxObjectSize)
+	}
+	ciphertext, err := s.enc.Encrypt(ctx, plaintext, s.o.AAD(bucket, key))
+	if err != nil {
+		return err
+	}
+	version, err := cryptoutil.EnvelopeKeyVersion(ciphertext)
+	if err != nil {
+		return err
+	}
+
+	meta := maps.Clone(o.Metadata)
+	if meta == nil {
+		meta = make(map[string]string, 3)
+	}
+	meta[MetadataKeyVersion] = version
+	meta[MetadataPlainSize] = strconv.Itoa(len(plaintext))
+	if o.ContentType != "" {
+		meta[MetadataPlainContentType] = o.ContentType
+	}
+	return s.next.Put(ctx, bucket, key, bytes.NewReader(ciphertext), &PutOptions{
+		ContentType:   encryptedContentType,
+		CacheControl:  o.CacheControl,
+		ContentLength: int64(len(ciphertext)),
+		Metadata:      meta,
+	})
+}
+
+// Delete 实现 ObjectStorage 接口
+//
+// Delete implements the ObjectStorage interface.
+func (s *EncryptedStorage) Delete(ctx context.Context, bucket, key string) error {
+	return s.next.Delete(ctx, bucket, key)
+}
+
+// plainInfo 把加密对象的元信息转换为明文的元信息，去掉加密相关的元数据
+//
+// plainInfo converts an encrypted object's metadata into the plaintext's, removing the encryption metadata
+func plainInfo(info *ObjectInfo) *ObjectInfo {
+	plain := *info
+	plain.ContentType = info.Metadata[MetadataPlainContentType]
+	if size, err := strconv.ParseInt(info.Metadata[MetadataPlainSize], 10, 64); err == nil {
+		plain.Size = size
+	}
+	plain.Metadata = maps.Clone(info.Metadata)
+	delete(plain.Metadata, MetadataKeyVersion)
+	delete(plain.Metadata, MetadataPlainContentType)
+	delete(plain.Metadata, MetadataPlainSize)
+	return &plain
+}
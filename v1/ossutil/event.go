@@ -0,0 +1,476 @@
+package ossutil
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/md5"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/tracing"
+)
+
+// DefaultCallbackMaxBodySize OSS 回调请求体的默认大小上限
+//
+// DefaultCallbackMaxBodySize is the default maximum size of an OSS callback body
+const DefaultCallbackMaxBodySize = 1 << 20
+
+var (
+	// ErrInvalidEvent 表示无法解析的事件通知
+	//
+	// ErrInvalidEvent indicates an event notification that cannot be parsed
+	ErrInvalidEvent = errors.New("invalid event notification")
+	// ErrInvalidCallbackSignature 表示 OSS 回调签名校验失败
+	//
+	// ErrInvalidCallbackSignature indicates that OSS callback signature verification failed
+	ErrInvalidCallbackSignature = errors.New("invalid oss callback signature")
+
+	// ossPublicKeys 已下载的 OSS 回调公钥，键是清理后的路径
+	//
+	// ossPublicKeys caches downloaded OSS callback public keys, keyed by cleaned path
+	ossPublicKeys = struct {
+		sync.Mutex
+		m map[string]*rsa.PublicKey
+	}{m: make(map[string]*rsa.PublicKey)}
+)
+
+const (
+	// ossPublicKeyHost OSS 回调公钥所在的主机，防止攻击者通过伪造的公钥地址绕过签名校验
+	//
+	// ossPublicKeyHost is the host serving OSS callback public keys, preventing forged key URLs from bypassing verification
+	ossPublicKeyHost = "gosspublic.alicdn.com"
+
+	// maxOSSPublicKeys 最多缓存的 OSS 回调公钥数
+	//
+	// maxOSSPublicKeys is the most OSS callback public keys cached
+	maxOSSPublicKeys = 16
+)
+
+// S3EventRecord S3 事件通知中的一条记录
+// EventName: 事件名称，例如 ObjectCreated:Put
+// EventTime: 事件时间
+// Region: 区域
+// Bucket: 存储桶
+// Key: 对象键（已解码）
+// Size: 对象大小
+// ETag: 对象 ETag
+// VersionID: 版本 ID
+// Sequencer: 用于判断同一对象事件先后顺序的序列号
+// PrincipalID: 触发事件的主体
+// SourceIP: 请求来源 IP
+//
+// S3EventRecord is a record of an S3 event notification.
+// EventName: Event name, e.g. ObjectCreated:Put
+// EventTime: Event time
+// Region: Region
+// Bucket: Bucket
+// Key: Object key (decoded)
+// Size: Object size
+// ETag: Object ETag
+// VersionID: Version ID
+// Sequencer: Sequence number for ordering events of the same object
+// PrincipalID: Principal that triggered the event
+// SourceIP: Source IP of the request
+type S3EventRecord struct {
+	EventName   string
+	EventTime   time.Time
+	Region      string
+	Bucket      string
+	Key         string
+	Size        int64
+	ETag        string
+	VersionID   string
+	Sequencer   string
+	PrincipalID string
+	SourceIP    string
+}
+
+// s3EventEnvelope 可能包裹 S3 事件的各种载荷：S3 事件本身、Lambda 的 SQS/SNS 记录、SNS 通知
+//
+// s3EventEnvelope covers the payloads that may wrap S3 events: the S3 event itself, Lambda SQS/SNS records and SNS notifications
+type s3EventEnvelope struct {
+	Records []struct {
+		// Lambda 的 SNS 记录使用 EventSource，JSON 字段名匹配不区分大小写
+		EventSource  string    `json:"eventSource"`
+		EventName    string    `json:"eventName"`
+		EventTime    time.Time `json:"eventTime"`
+		AwsRegion    string    `json:"awsRegion"`
+		UserIdentity struct {
+			PrincipalID string `json:"principalId"`
+		} `json:"userIdentity"`
+		RequestParameters struct {
+			SourceIPAddress string `json:"sourceIPAddress"`
+		} `json:"requestParameters"`
+		S3 struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key       string `json:"key"`
+				Size      int64  `json:"size"`
+				ETag      string `json:"eTag"`
+				VersionID string `json:"versionId"`
+				Sequencer string `json:"sequencer"`
+			} `json:"object"`
+		} `json:"s3"`
+		// SQS 记录
+		Body string `json:"body"`
+		// SNS 记录
+		Sns struct {
+			Message string `json:"Message"`
+		} `json:"Sns"`
+	} `json:"Records"`
+	// SNS 通知
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+	// S3 测试事件
+	Event string `json:"Event"`
+}
+
+// ParseS3Event 解析 S3 事件通知，自动拆开 SNS 通知、SQS 消息体以及 Lambda 收到的 SQS/SNS 事件
+// s3:TestEvent 测试事件返回空切片
+// 参数:
+//   - data: 事件载荷，可以是 S3 事件 JSON、SNS 通知、SQS 消息体或 Lambda 事件
+//
+// 返回:
+//   - []S3EventRecord: 事件记录，对象键已进行 URL 解码
+//   - error: 如果载荷无法解析，返回 ErrInvalidEvent
+//
+// ParseS3Event parses S3 event notifications, unwrapping SNS notifications, SQS message bodies and SQS/SNS events received by Lambda.
+// An s3:TestEvent yields an empty slice.
+// Parameters:
+//   - data: The event payload: an S3 event JSON, an SNS notification, an SQS message body or a Lambda event
+//
+// Returns:
+//   - []S3EventRecord: Event records with URL-decoded object keys
+//   - error: Returns ErrInvalidEvent if the payload cannot be parsed
+func ParseS3Event(data []byte) ([]S3EventRecord, error) {
+	return parseS3Event(data, 0)
+}
+
+// parseS3Event 递归拆开包裹层，depth 防止恶意载荷无限嵌套
+//
+// parseS3Event unwraps envelopes recursively; depth guards against maliciously deep nesting
+func parseS3Event(data []byte, depth int) ([]S3EventRecord, error) {
+	if depth > 3 {
+		return nil, fmt.Errorf("%w: too deeply nested", ErrInvalidEvent)
+	}
+	var env s3EventEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if env.Event == "s3:TestEvent" {
+		return []S3EventRecord{}, nil
+	}
+	if env.Type == "Notification" {
+		return parseS3Event([]byte(env.Message), depth+1)
+	}
+	if env.Records == nil {
+		return nil, fmt.Errorf("%w: no records", ErrInvalidEvent)
+	}
+
+	records := []S3EventRecord{}
+	for _, r := range env.Records {
+		switch r.EventSource {
+		case "aws:sqs":
+			inner, err := parseS3Event([]byte(r.Body), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, inner...)
+		case "aws:sns":
+			inner, err := parseS3Event([]byte(r.Sns.Message), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, inner...)
+		case "aws:s3":
+			key, err := url.QueryUnescape(r.S3.Object.Key)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+			}
+			records = append(records, S3EventRecord{
+				EventName:   r.EventName,
+				EventTime:   r.EventTime,
+				Region:      r.AwsRegion,
+				Bucket:      r.S3.Bucket.Name,
+				Key:         key,
+				Size:        r.S3.Object.Size,
+				ETag:        r.S3.Object.ETag,
+				VersionID:   r.S3.Object.VersionID,
+				Sequencer:   r.S3.Object.Sequencer,
+				PrincipalID: r.UserIdentity.PrincipalID,
+				SourceIP:    r.RequestParameters.SourceIPAddress,
+			})
+		default:
+			return nil, fmt.Errorf("%w: unsupported event source %q", ErrInvalidEvent, r.EventSource)
+		}
+	}
+	return records, nil
+}
+
+// OSSCallback 阿里云 OSS 上传回调的内容
+// 回调体由上传时的 callbackBody 模板决定，常用变量会解析到对应字段，全部变量保存在 Fields 中
+// Bucket: 存储桶（${bucket}）
+// Object: 对象键（${object}）
+// ETag: 对象 ETag（${etag}）
+// Size: 对象大小（${size}）
+// MimeType: 对象类型（${mimeType}）
+// Fields: 回调体中的全部字段
+//
+// OSSCallback is the content of an Aliyun OSS upload callback.
+// The body is determined by the callbackBody template used for the upload; common variables are parsed into fields and all variables are kept in Fields.
+// Bucket: Bucket (${bucket})
+// Object: Object key (${object})
+// ETag: Object ETag (${etag})
+// Size: Object size (${size})
+// MimeType: Object MIME type (${mimeType})
+// Fields: All fields of the callback body
+type OSSCallback struct {
+	Bucket   string
+	Object   string
+	ETag     string
+	Size     int64
+	MimeType string
+	Fields   map[string]string
+}
+
+// OSSCallbackOptions OSS 回调解析选项
+// HTTPClient: 下载回调公钥使用的客户端，默认使用带超时的客户端
+// MaxBodySize: 回调体大小上限，默认 DefaultCallbackMaxBodySize
+//
+// OSSCallbackOptions contains options for parsing OSS callbacks.
+// HTTPClient: Client used to download the callback public key, defaults to a client with a timeout
+// MaxBodySize: Maximum callback body size, defaults to DefaultCallbackMaxBodySize
+type OSSCallbackOptions struct {
+	HTTPClient  *http.Client
+	MaxBodySize int64
+}
+
+// ParseOSSCallback 校验阿里云 OSS 上传回调的签名并解析回调体
+// 签名使用 x-oss-pub-key-url 指向的 OSS 官方公钥（只接受 gosspublic.alicdn.com 上的地址，总是通过 HTTPS 下载）对
+// "URL 解码后的路径 + 查询字符串 + \n + 回调体" 进行 RSA/MD5 校验，公钥下载后按路径缓存
+// 参数:
+//   - r: OSS 发来的回调请求
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *OSSCallback: 回调内容，支持 application/x-www-form-urlencoded 和 application/json 两种回调体
+//   - error: 签名无效时返回 ErrInvalidCallbackSignature，回调体无法解析时返回 ErrInvalidEvent
+//
+// ParseOSSCallback verifies the signature of an Aliyun OSS upload callback and parses its body.
+// The signature over "URL-decoded path + query string + \n + body" is verified with RSA/MD5 using the official OSS public key referenced by x-oss-pub-key-url
+// (only URLs on gosspublic.alicdn.com are accepted and keys are always downloaded over HTTPS); downloaded keys are cached by path.
+// Parameters:
+//   - r: The callback request sent by OSS
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *OSSCallback: The callback content; application/x-www-form-urlencoded and application/json bodies are supported
+//   - error: Returns ErrInvalidCallbackSignature if the signature is invalid, or ErrInvalidEvent if the body cannot be parsed
+func ParseOSSCallback(r *http.Request, opts *OSSCallbackOptions) (*OSSCallback, error) {
+	var o OSSCallbackOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.HTTPClient == nil {
+		o.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if o.MaxBodySize <= 0 {
+		o.MaxBodySize = DefaultCallbackMaxBodySize
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, o.MaxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if int64(len(body)) > o.MaxBodySize {
+		return nil, fmt.Errorf("%w: body too large", ErrInvalidEvent)
+	}
+	if err := verifyOSSCallback(r, body, o.HTTPClient); err != nil {
+		return nil, err
+	}
+
+	fields := map[string]string{}
+	if strings.HasPrefix(strings.ToLower(r.Header.Get("Content-Type")), "application/json") {
+		var raw map[string]any
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+		for k, v := range raw {
+			if s, ok := v.(string); ok {
+				fields[k] = s
+			} else {
+				b, _ := json.Marshal(v)
+				fields[k] = string(b)
+			}
+		}
+	} else {
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+		}
+		for k := range values {
+			fields[k] = values.Get(k)
+		}
+	}
+
+	cb := &OSSCallback{
+		Bucket:   fields["bucket"],
+		Object:   fields["object"],
+		ETag:     fields["etag"],
+		MimeType: fields["mimeType"],
+		Fields:   fields,
+	}
+	if s := fields["size"]; s != "" {
+		if cb.Size, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return nil, fmt.Errorf("%w: invalid size %q", ErrInvalidEvent, s)
+		}
+	}
+	return cb, nil
+}
+
+// verifyOSSCallback 校验 OSS 回调签名
+//
+// verifyOSSCallback verifies the OSS callback signature
+func verifyOSSCallback(r *http.Request, body []byte, client *http.Client) error {
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get("Authorization"))
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("%w: missing or malformed authorization", ErrInvalidCallbackSignature)
+	}
+	keyURL, err := base64.StdEncoding.DecodeString(r.Header.Get("X-Oss-Pub-Key-Url"))
+	if err != nil || len(keyURL) == 0 {
+		return fmt.Errorf("%w: missing or malformed x-oss-pub-key-url", ErrInvalidCallbackSignature)
+	}
+	pub, err := ossPublicKey(r.Context(), client, string(keyURL))
+	if err != nil {
+		return err
+	}
+
+	path, err := url.PathUnescape(r.URL.EscapedPath())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCallbackSignature, err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(path)
+	if r.URL.RawQuery != "" {
+		buf.WriteString("?" + r.URL.RawQuery)
+	}
+	buf.WriteByte('\n')
+	buf.Write(body)
+
+	sum := md5.Sum(buf.Bytes())
+	if err := rsa.VerifyPKCS1v15(pub, crypto.MD5, sum[:], sig); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCallbackSignature, err)
+	}
+	return nil
+}
+
+// ossPublicKey 下载并缓存 OSS 回调公钥
+// 地址写成 http 时也改用 https 下载，否则网络路径上的攻击者可以返回自己的公钥伪造回调；缓存按清理后的路径区分，忽略查询字符串
+//
+// ossPublicKey downloads and caches an OSS callback public key.
+// An http URL is still fetched over https, as an attacker on the network path could otherwise serve their own key and forge callbacks;
+// the cache is keyed by the cleaned path, ignoring the query string
+func ossPublicKey(ctx context.Context, client *http.Client, keyURL string) (*rsa.PublicKey, error) {
+	u, err := url.Parse(keyURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.User != nil ||
+		!strings.EqualFold(u.Host, ossPublicKeyHost) {
+		return nil, fmt.Errorf("%w: untrusted public key url %q", ErrInvalidCallbackSignature, keyURL)
+	}
+	keyPath := path.Clean("/" + u.Path)
+	if keyPath == "/" {
+		return nil, fmt.Errorf("%w: untrusted public key url %q", ErrInvalidCallbackSignature, keyURL)
+	}
+
+	ossPublicKeys.Lock()
+	key, ok := ossPublicKeys.m[keyPath]
+	ossPublicKeys.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	ctx, end := tracing.StartSpan(ctx, "ossutil.FetchCallbackPublicKey")
+	key, err = fetchOSSPublicKey(ctx, client, (&url.URL{Scheme: "https", Host: ossPublicKeyHost, Path: keyPath}).String())
+	end(err)
+	if err != nil {
+		return nil, err
+	}
+
+	ossPublicKeys.Lock()
+	if len(ossPublicKeys.m) >= maxOSSPublicKeys {
+		// 官方公钥只有少数几个，满了说明有大量轮换，丢掉任意一个即可
+		for k := range ossPublicKeys.m {
+			delete(ossPublicKeys.m, k)
+			break
+		}
+	}
+	ossPublicKeys.m[keyPath] = key
+	ossPublicKeys.Unlock()
+	return key, nil
+}
+
+// fetchOSSPublicKey 下载并解析 OSS 回调公钥
+//
+// fetchOSSPublicKey downloads and parses an OSS callback public key
+func fetchOSSPublicKey(ctx context.Context, client *http.Client, keyURL string) (*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, keyURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCallbackSignature, err)
+	}
+	// 不跟随降级到 http 的重定向
+	c := *client
+	checkRedirect := client.CheckRedirect
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to non-https url %s", req.URL)
+		}
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: fetch public key: %v", ErrInvalidCallbackSignature, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: fetch public key: status %d", ErrInvalidCallbackSignature, resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("%w: fetch public key: %v", ErrInvalidCallbackSignature, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: public key is not PEM", ErrInvalidCallbackSignature)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCallbackSignature, err)
+	}
+	key, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: public key is not RSA", ErrInvalidCallbackSignature)
+	}
+	return key, nil
+}
@@ -0,0 +1,387 @@
+package ossutil
+
+import (
+	"bufio"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// DefaultMigrateConcurrency 迁移时默认的并发数
+//
+// DefaultMigrateConcurrency is the default concurrency of a migration
+const DefaultMigrateConcurrency = 8
+
+var (
+	// ErrMigrateIncomplete 表示部分对象迁移失败，详见 MigrateResult.Failures
+	//
+	// ErrMigrateIncomplete indicates that some objects failed to migrate; see MigrateResult.Failures
+	ErrMigrateIncomplete = errors.New("bucket migration incomplete")
+	// ErrChecksumMismatch 表示迁移后的对象校验和与源对象不一致
+	//
+	// ErrChecksumMismatch indicates that the migrated object's checksum does not match the source
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+)
+
+// MigrateOptions 存储桶迁移选项
+// SrcBucket: 源存储桶
+// DstBucket: 目标存储桶
+// Prefix: 只迁移该前缀下的对象，为空时迁移全部
+// DstPrefix: 目标键前缀，目标键为 DstPrefix 加上去掉 Prefix 后的源键
+// Concurrency: 并发数，默认 DefaultMigrateConcurrency
+// ServerSideCopy: 使用服务端复制（CopyObject），源和目标需位于同一服务且目标凭证可以读取源对象；源和目标是同一个客户端时自动启用
+// SkipExisting: 目标对象已存在且大小和 ETag 一致时跳过
+// ManifestPath: 清单文件路径，每迁移成功一个对象追加一行，再次运行时跳过清单中的对象以实现断点续传
+// Progress: 每处理完一个对象调用一次的进度回调，可能被并发调用
+//
+// MigrateOptions contains options for bucket migration.
+// SrcBucket: Source bucket
+// DstBucket: Destination bucket
+// Prefix: Only objects under this prefix are migrated; all objects when empty
+// DstPrefix: Destination key prefix; the destination key is DstPrefix plus the source key with Prefix removed
+// Concurrency: Concurrency, defaults to DefaultMigrateConcurrency
+// ServerSideCopy: Use server-side copy (CopyObject); source and destination must be on the same service and the destination credentials must be able to read the source; enabled automatically when source and destination are the same client
+// SkipExisting: Skip objects whose destination already exists with the same size and ETag
+// ManifestPath: Manifest file path; a line is appended for every migrated object, and objects in the manifest are skipped on later runs to resume
+// Progress: Progress callback invoked after every object, possibly concurrently
+type MigrateOptions struct {
+	SrcBucket      string
+	DstBucket      string
+	Prefix         string
+	DstPrefix      string
+	Concurrency    int
+	ServerSideCopy bool
+	SkipExisting   bool
+	ManifestPath   string
+	Progress       func(MigrateProgress)
+}
+
+// MigrateProgress 迁移进度
+// Key: 刚处理完的源对象键
+// Err: 该对象的错误，成功或跳过时为 nil
+// Copied: 累计复制的对象数
+// Skipped: 累计跳过的对象数
+// Failed: 累计失败的对象数
+// Bytes: 累计复制的字节数
+//
+// MigrateProgress is the progress of a migration.
+// Key: Source key of the object just processed
+// Err: Error of that object, nil when copied or skipped
+// Copied: Cumulative number of copied objects
+// Skipped: Cumulative number of skipped objects
+// Failed: Cumulative number of failed objects
+// Bytes: Cumulative number of copied bytes
+type MigrateProgress struct {
+	Key     string
+	Err     error
+	Copied  int64
+	Skipped int64
+	Failed  int64
+	Bytes   int64
+}
+
+// MigrateFailure 迁移失败的对象
+// Key: 源对象键
+// Err: 错误
+//
+// MigrateFailure is an object that failed to migrate.
+// Key: Source key
+// Err: The error
+type MigrateFailure struct {
+	Key string
+	Err error
+}
+
+// MigrateResult 迁移结果
+// Copied: 复制的对象数
+// Skipped: 跳过的对象数
+// Bytes: 复制的字节数
+// Failures: 失败的对象
+//
+// MigrateResult is the result of a migration.
+// Copied: Number of copied objects
+// Skipped: Number of skipped objects
+// Bytes: Number of copied bytes
+// Failures: Objects that failed
+type MigrateResult struct {
+	Copied   int64
+	Skipped  int64
+	Bytes    int64
+	Failures []MigrateFailure
+}
+
+// migrateObject 待迁移的对象
+//
+// migrateObject is an object to migrate
+type migrateObject struct {
+	key  string
+	size int64
+	etag string
+}
+
+// MigrateBucket 在存储桶之间迁移对象，可跨区域或跨服务商
+// 列出源对象后并发复制，能用服务端复制时使用服务端复制，否则流式下载再上传；复制后校验大小和 MD5/ETag。
+// 单个对象不能超过 5 GiB（单次 PUT/COPY 的上限）
+// 参数:
+//   - ctx: 上下文，取消后停止派发新对象
+//   - src: 源客户端
+//   - dst: 目标客户端
+//   - opts: 迁移选项
+//
+// 返回:
+//   - *MigrateResult: 迁移结果
+//   - error: 列举失败、ctx 被取消或读写清单失败时返回错误；部分对象失败时返回 ErrMigrateIncomplete
+//
+// MigrateBucket migrates objects between buckets, across regions or providers.
+// It lists the source and copies concurrently, using server-side copy where possible and otherwise streaming download and upload; sizes and MD5/ETags are verified after copying.
+// Individual objects must not exceed 5 GiB (the single PUT/COPY limit).
+// Parameters:
+//   - ctx: Context; cancelling it stops dispatching new objects
+//   - src: Source client
+//   - dst: Destination client
+//   - opts: Migration options
+//
+// Returns:
+//   - *MigrateResult: The migration result
+//   - error: Returns an error if listing fails, ctx is cancelled or the manifest cannot be read or written; returns ErrMigrateIncomplete if some objects failed
+func MigrateBucket(ctx context.Context, src, dst *OssClient, opts *MigrateOptions) (*MigrateResult, error) {
+	var o MigrateOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultMigrateConcurrency
+	}
+	serverSide := o.ServerSideCopy || src == dst
+
+	done, err := loadManifest(o.ManifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var manifest *os.File
+	if o.ManifestPath != "" {
+		manifest, err = os.OpenFile(o.ManifestPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		defer manifest.Close()
+	}
+
+	var (
+		result          MigrateResult
+		mu              sync.Mutex
+		copied, skipped atomic.Int64
+		failed, bytes   atomic.Int64
+		wg              sync.WaitGroup
+		jobs            = make(chan migrateObject)
+	)
+	report := func(key string, err error) {
+		if o.Progress != nil {
+			o.Progress(MigrateProgress{
+				Key: key, Err: err,
+				Copied: copied.Load(), Skipped: skipped.Load(), Failed: failed.Load(), Bytes: bytes.Load(),
+			})
+		}
+	}
+
+	for range o.Concurrency {
+		wg.Go(func() {
+			for obj := range jobs {
+				dstKey := o.DstPrefix + strings.TrimPrefix(obj.key, o.Prefix)
+				copiedObj, err := migrateOne(ctx, src, dst, &o, serverSide, obj, dstKey)
+				switch {
+				case err != nil:
+					failed.Add(1)
+					mu.Lock()
+					result.Failures = append(result.Failures, MigrateFailure{Key: obj.key, Err: err})
+					mu.Unlock()
+				case copiedObj:
+					copied.Add(1)
+					bytes.Add(obj.size)
+				default:
+					skipped.Add(1)
+				}
+				if err == nil && manifest != nil {
+					line, _ := json.Marshal(obj.key)
+					mu.Lock()
+					_, werr := manifest.Write(append(line, '\n'))
+					mu.Unlock()
+					if werr != nil {
+						err = werr
+					}
+				}
+				report(obj.key, err)
+			}
+		})
+	}
+
+	listErr := func() error {
+		defer close(jobs)
+		p := s3.NewListObjectsV2Paginator(src.seClient, &s3.ListObjectsV2Input{
+			Bucket: aws.String(o.SrcBucket),
+			Prefix: aws.String(o.Prefix),
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return err
+			}
+			for _, obj := range page.Contents {
+				key := aws.ToString(obj.Key)
+				if _, ok := done[key]; ok {
+					skipped.Add(1)
+					report(key, nil)
+					continue
+				}
+				select {
+				case jobs <- migrateObject{key: key, size: aws.ToInt64(obj.Size), etag: aws.ToString(obj.ETag)}:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		return nil
+	}()
+	wg.Wait()
+
+	result.Copied, result.Skipped, result.Bytes = copied.Load(), skipped.Load(), bytes.Load()
+	if listErr != nil {
+		return &result, listErr
+	}
+	if len(result.Failures) > 0 {
+		return &result, fmt.Errorf("%w: %d objects failed", ErrMigrateIncomplete, len(result.Failures))
+	}
+	return &result, nil
+}
+
+// migrateOne 迁移单个对象，返回是否实际复制
+//
+// migrateOne migrates a single object and reports whether it was actually copied
+func migrateOne(ctx context.Context, src, dst *OssClient, o *MigrateOptions, serverSide bool, obj migrateObject, dstKey string) (bool, error) {
+	if o.SkipExisting {
+		head, err := dst.seClient.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(o.DstBucket), Key: aws.String(dstKey)})
+		if err == nil && aws.ToInt64(head.ContentLength) == obj.size && sameETag(obj.etag, aws.ToString(head.ETag)) {
+			return false, nil
+		}
+	}
+
+	var sum string
+	if serverSide {
+		_, err := dst.seClient.CopyObject(ctx, &s3.CopyObjectInput{
+			Bucket:     aws.String(o.DstBucket),
+			Key:        aws.String(dstKey),
+			CopySource: aws.String(o.SrcBucket + "/" + url.PathEscape(obj.key)),
+		})
+		if err != nil {
+			return false, err
+		}
+	} else {
+		out, err := src.seClient.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(o.SrcBucket), Key: aws.String(obj.key)})
+		if err != nil {
+			return false, err
+		}
+		defer out.Body.Close()
+		h := md5.New()
+		_, err = dst.seClient.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        aws.String(o.DstBucket),
+			Key:           aws.String(dstKey),
+			Body:          io.TeeReader(out.Body, h),
+			ContentLength: out.ContentLength,
+			ContentType:   out.ContentType,
+			Metadata:      out.Metadata,
+		})
+		if err != nil {
+			return false, err
+		}
+		sum = hex.EncodeToString(h.Sum(nil))
+		if isMD5ETag(obj.etag) && !strings.EqualFold(unquote(obj.etag), sum) {
+			return false, fmt.Errorf("%w: source etag %s, transferred md5 %s", ErrChecksumMismatch, obj.etag, sum)
+		}
+	}
+
+	head, err := dst.seClient.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(o.DstBucket), Key: aws.String(dstKey)})
+	if err != nil {
+		return false, err
+	}
+	if size := aws.ToInt64(head.ContentLength); size != obj.size {
+		return false, fmt.Errorf("%w: source size %d, destination size %d", ErrChecksumMismatch, obj.size, size)
+	}
+	dstETag := aws.ToString(head.ETag)
+	if sum != "" && isMD5ETag(dstETag) && !strings.EqualFold(unquote(dstETag), sum) {
+		return false, fmt.Errorf("%w: transferred md5 %s, destination etag %s", ErrChecksumMismatch, sum, dstETag)
+	}
+	if serverSide && !sameETag(obj.etag, dstETag) {
+		return false, fmt.Errorf("%w: source etag %s, destination etag %s", ErrChecksumMismatch, obj.etag, dstETag)
+	}
+	return true, nil
+}
+
+// loadManifest 读取清单中已迁移的键，文件不存在时返回空集合
+//
+// loadManifest reads the migrated keys from the manifest; an empty set is returned when the file does not exist
+func loadManifest(path string) (map[string]struct{}, error) {
+	done := make(map[string]struct{})
+	if path == "" {
+		return done, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var key string
+		// 忽略中断时写了一半的行
+		if json.Unmarshal(sc.Bytes(), &key) == nil {
+			done[key] = struct{}{}
+		}
+	}
+	return done, sc.Err()
+}
+
+// sameETag 比较两个 ETag，任一方不是 MD5 格式（例如分片上传的 ETag）时视为一致
+//
+// sameETag compares two ETags; they are considered equal when either is not an MD5 (e.g. a multipart upload ETag)
+func sameETag(a, b string) bool {
+	if !isMD5ETag(a) || !isMD5ETag(b) {
+		return true
+	}
+	return strings.EqualFold(unquote(a), unquote(b))
+}
+
+// isMD5ETag 判断 ETag 是否为对象内容的 MD5（单次上传的对象）
+//
+// isMD5ETag reports whether the ETag is the MD5 of the object content (single-part uploads)
+func isMD5ETag(etag string) bool {
+	s := unquote(etag)
+	if len(s) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// unquote 去掉 ETag 两侧的引号
+//
+// unquote removes the quotes around an ETag
+func unquote(etag string) string {
+	return strings.Trim(etag, `"`)
+}
@@ -0,0 +1,220 @@
+package ossutil
+
+import (
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// DefaultContentType 无法识别类型时使用的 Content-Type
+//
+// DefaultContentType is the Content-Type used when the type cannot be detected
+const DefaultContentType = "application/octet-stream"
+
+// sniffLen 嗅探内容类型时读取的字节数，与 http.DetectContentType 一致
+//
+// sniffLen is the number of bytes read for content sniffing, matching http.DetectContentType
+const sniffLen = 512
+
+// staticContentTypes 静态站点常见扩展名的内容类型，优先于系统的 MIME 表，保证不同机器上的结果一致
+//
+// staticContentTypes maps common static-site extensions to content types; it takes precedence over the system MIME table so results are the same on every machine
+var staticContentTypes = map[string]string{
+	".html":        "text/html; charset=utf-8",
+	".htm":         "text/html; charset=utf-8",
+	".css":         "text/css; charset=utf-8",
+	".js":          "text/javascript; charset=utf-8",
+	".mjs":         "text/javascript; charset=utf-8",
+	".json":        "application/json",
+	".map":         "application/json",
+	".webmanifest": "application/manifest+json",
+	".xml":         "application/xml",
+	".txt":         "text/plain; charset=utf-8",
+	".svg":         "image/svg+xml",
+	".png":         "image/png",
+	".jpg":         "image/jpeg",
+	".jpeg":        "image/jpeg",
+	".gif":         "image/gif",
+	".webp":        "image/webp",
+	".avif":        "image/avif",
+	".ico":         "image/x-icon",
+	".woff":        "font/woff",
+	".woff2":       "font/woff2",
+	".ttf":         "font/ttf",
+	".otf":         "font/otf",
+	".wasm":        "application/wasm",
+	".pdf":         "application/pdf",
+	".mp4":         "video/mp4",
+	".webm":        "video/webm",
+	".mp3":         "audio/mpeg",
+}
+
+// ContentTypePolicy 上传时自动确定 Content-Type 的策略：先按扩展名匹配，匹配不到时嗅探内容
+// Extensions: 自定义扩展名到类型的映射，键为带点的小写扩展名（例如 ".wasm"），优先于内置表和系统 MIME 表
+// Default: 无法识别时使用的类型，默认 DefaultContentType
+// DisableSniff: 为 true 时不嗅探内容，扩展名无法识别时直接使用 Default
+//
+// ContentTypePolicy determines the Content-Type on upload: extensions are matched first and the content is sniffed when that fails.
+// Extensions: Custom extension-to-type mapping keyed by lowercase extensions with the dot (e.g. ".wasm"); it takes precedence over the built-in table and the system MIME table
+// Default: Type used when nothing matches, defaults to DefaultContentType
+// DisableSniff: When true, content is not sniffed and Default is used directly for unknown extensions
+type ContentTypePolicy struct {
+	Extensions   map[string]string
+	Default      string
+	DisableSniff bool
+}
+
+// ByExtension 按对象键的扩展名确定类型，无法识别时返回空字符串
+//
+// ByExtension determines the type from the object key's extension and returns an empty string when it is unknown.
+func (p *ContentTypePolicy) ByExtension(key string) string {
+	ext := strings.ToLower(path.Ext(key))
+	if ext == "" {
+		return ""
+	}
+	if p != nil {
+		if t, ok := p.Extensions[ext]; ok {
+			return t
+		}
+	}
+	if t, ok := staticContentTypes[ext]; ok {
+		return t
+	}
+	return mime.TypeByExtension(ext)
+}
+
+// Detect 确定对象的 Content-Type
+// 参数:
+//   - key: 对象键
+//   - head: 内容开头最多 512 字节，扩展名无法识别时用于嗅探，可为 nil
+//
+// 返回:
+//   - string: Content-Type，不会为空
+//
+// Detect determines the Content-Type of an object.
+// Parameters:
+//   - key: Object key
+//   - head: Up to the first 512 bytes of the content, sniffed when the extension is unknown; may be nil
+//
+// Returns:
+//   - string: The Content-Type, never empty
+func (p *ContentTypePolicy) Detect(key string, head []byte) string {
+	if t := p.ByExtension(key); t != "" {
+		return t
+	}
+	if p.sniff() && len(head) > 0 {
+		// DetectContentType 无法识别时返回 application/octet-stream，此时改用 Default
+		if t := http.DetectContentType(head); t != DefaultContentType {
+			return t
+		}
+	}
+	if p != nil && p.Default != "" {
+		return p.Default
+	}
+	return DefaultContentType
+}
+
+// sniff 报告是否需要嗅探内容
+//
+// sniff reports whether content should be sniffed
+func (p *ContentTypePolicy) sniff() bool {
+	return p == nil || !p.DisableSniff
+}
+
+// CacheControlRule 缓存策略规则
+// Prefix: 对象键前缀，为空时匹配所有键
+// Extensions: 扩展名列表（带点，忽略大小写），为空时匹配所有扩展名
+// Value: 匹配时使用的 Cache-Control 值
+//
+// CacheControlRule is a cache-control rule.
+// Prefix: Object key prefix; matches every key when empty
+// Extensions: Extensions with the dot, case-insensitive; matches every extension when empty
+// Value: The Cache-Control value used on a match
+type CacheControlRule struct {
+	Prefix     string
+	Extensions []string
+	Value      string
+}
+
+// match 报告规则是否匹配对象键
+//
+// match reports whether the rule matches the object key
+func (r *CacheControlRule) match(key string) bool {
+	if !strings.HasPrefix(key, r.Prefix) {
+		return false
+	}
+	if len(r.Extensions) == 0 {
+		return true
+	}
+	ext := path.Ext(key)
+	for _, e := range r.Extensions {
+		if strings.EqualFold(e, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheControlPolicy 上传时按前缀和扩展名自动设置 Cache-Control 的策略
+// Rules: 规则列表，按顺序匹配，使用第一条匹配的规则
+// Default: 没有规则匹配时使用的值，为空时不设置
+//
+// CacheControlPolicy sets Cache-Control on upload by prefix and extension.
+// Rules: Rules matched in order; the first match wins
+// Default: Value used when no rule matches; nothing is set when empty
+type CacheControlPolicy struct {
+	Rules   []CacheControlRule
+	Default string
+}
+
+// For 返回对象键对应的 Cache-Control 值，可能为空
+//
+// For returns the Cache-Control value for the object key, which may be empty.
+func (p *CacheControlPolicy) For(key string) string {
+	if p == nil {
+		return ""
+	}
+	for i := range p.Rules {
+		if p.Rules[i].match(key) {
+			return p.Rules[i].Value
+		}
+	}
+	return p.Default
+}
+
+// StaticSiteCacheControl 返回适用于静态站点部署的缓存策略：HTML 和清单文件每次都向源站校验，
+// 带哈希文件名的脚本、样式、图片和字体等资源长期缓存，其余文件缓存 1 小时
+//
+// StaticSiteCacheControl returns a cache-control policy suited to static-site deployments: HTML and manifest files are revalidated on every request,
+// scripts, styles, images, fonts and other assets with hashed file names are cached long-term, and everything else is cached for an hour.
+func StaticSiteCacheControl() *CacheControlPolicy {
+	return &CacheControlPolicy{
+		Rules: []CacheControlRule{
+			{Extensions: []string{".html", ".htm", ".json", ".webmanifest", ".xml", ".txt"}, Value: "no-cache"},
+			{
+				Extensions: []string{".js", ".mjs", ".css", ".map", ".png", ".jpg", ".jpeg", ".gif", ".webp", ".avif", ".svg", ".ico", ".woff", ".woff2", ".ttf", ".otf", ".wasm"},
+				Value:      "public, max-age=31536000, immutable",
+			},
+		},
+		Default: "public, max-age=3600",
+	}
+}
+
+// SetContentTypePolicy 设置上传（Put、SyncDir）时使用的 Content-Type 策略，为 nil 时使用默认策略（按扩展名和内容嗅探）
+// 应在客户端被并发使用之前调用
+//
+// SetContentTypePolicy sets the Content-Type policy used on upload (Put, SyncDir); nil selects the default policy (extension and sniffing).
+// Call it before the client is used concurrently.
+func (c *OssClient) SetContentTypePolicy(p *ContentTypePolicy) {
+	c.contentTypes = p
+}
+
+// SetCacheControlPolicy 设置上传（Put、SyncDir）时使用的 Cache-Control 策略，为 nil 时不自动设置
+// 应在客户端被并发使用之前调用
+//
+// SetCacheControlPolicy sets the Cache-Control policy used on upload (Put, SyncDir); nil disables automatic Cache-Control.
+// Call it before the client is used concurrently.
+func (c *OssClient) SetCacheControlPolicy(p *CacheControlPolicy) {
+	c.cacheControl = p
+}
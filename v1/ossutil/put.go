@@ -0,0 +1,349 @@
+package ossutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/supergodk/go-utils/v1/fileutil"
+)
+
+// DefaultSyncConcurrency SyncDir 的默认并发数
+//
+// DefaultSyncConcurrency is the default concurrency of SyncDir
+const DefaultSyncConcurrency = 8
+
+// ErrSyncIncomplete 表示同步时部分文件失败
+//
+// ErrSyncIncomplete indicates that some files failed during a sync
+var ErrSyncIncomplete = errors.New("sync incomplete")
+
+// PutOptions 上传选项，未设置的 ContentType 和 CacheControl 由客户端的策略决定
+// ContentType: 内容类型，为空时按 ContentTypePolicy 检测
+// CacheControl: 缓存控制，为空时按 CacheControlPolicy 设置
+// ContentLength: 内容长度，body 不可 Seek 且长度已知时建议设置，0 表示未知
+// Metadata: 自定义元数据
+//
+// PutOptions contains upload options; ContentType and CacheControl left empty are decided by the client's policies.
+// ContentType: Content type; detected with the ContentTypePolicy when empty
+// CacheControl: Cache control; set from the CacheControlPolicy when empty
+// ContentLength: Content length, recommended when body is not seekable but its length is known; 0 means unknown
+// Metadata: Custom metadata
+type PutOptions struct {
+	ContentType   string
+	CacheControl  string
+	ContentLength int64
+	Metadata      map[string]string
+}
+
+// Put 上传对象，并自动应用客户端的 Content-Type 和 Cache-Control 策略
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - key: 对象键
+//   - body: 对象内容，实现 io.Seeker 时嗅探后会回到原位置，否则嗅探读取的内容会拼接回去
+//   - opts: 上传选项，可以为 nil
+//
+// 返回:
+//   - error: 读取内容或请求失败时返回错误
+//
+// Put uploads an object, applying the client's Content-Type and Cache-Control policies automatically.
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - key: Object key
+//   - body: Object content; if it implements io.Seeker it is rewound after sniffing, otherwise the sniffed bytes are stitched back in front
+//   - opts: Upload options, may be nil
+//
+// Returns:
+//   - error: Returns an error if reading the content or the request fails
+func (c *OssClient) Put(ctx context.Context, bucket, key string, body io.Reader, opts *PutOptions) error {
+	var o PutOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.ContentType == "" {
+		var err error
+		o.ContentType, body, err = c.detectContentType(key, body)
+		if err != nil {
+			return err
+		}
+	}
+	if o.CacheControl == "" {
+		o.CacheControl = c.cacheControl.For(key)
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(key),
+		Body:        body,
+		ContentType: aws.String(o.ContentType),
+		Metadata:    o.Metadata,
+	}
+	if o.CacheControl != "" {
+		input.CacheControl = aws.String(o.CacheControl)
+	}
+	if o.ContentLength > 0 {
+		input.ContentLength = aws.Int64(o.ContentLength)
+	}
+	_, err := c.seClient.PutObject(ctx, input)
+	return err
+}
+
+// detectContentType 按策略确定内容类型，需要嗅探时读取内容开头并返回可从头读取的 body
+//
+// detectContentType determines the content type by policy; when sniffing is needed it reads the head of the content and returns a body readable from the start
+func (c *OssClient) detectContentType(key string, body io.Reader) (string, io.Reader, error) {
+	p := c.contentTypes
+	if p.ByExtension(key) != "" || !p.sniff() {
+		return p.Detect(key, nil), body, nil
+	}
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(body, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", nil, err
+	}
+	head = head[:n]
+	if s, ok := body.(io.Seeker); ok {
+		if _, err := s.Seek(int64(-n), io.SeekCurrent); err != nil {
+			return "", nil, err
+		}
+	} else {
+		body = io.MultiReader(bytes.NewReader(head), body)
+	}
+	return p.Detect(key, head), body, nil
+}
+
+// SyncOptions 目录同步选项
+// Concurrency: 并发数，默认 DefaultSyncConcurrency
+// Delete: 为 true 时删除前缀下本地不存在的对象，被 Include / Exclude 过滤掉的对象不会被删除
+// DryRun: 为 true 时只计算差异，不上传也不删除
+// Include: 同步的文件模式，为空时同步所有文件，语法见 fileutil.WalkOptions
+// Exclude: 不同步的文件和目录模式，例如 ".git"、"*.map"
+//
+// SyncOptions contains directory sync options.
+// Concurrency: Concurrency, defaults to DefaultSyncConcurrency
+// Delete: When true, objects under the prefix that no longer exist locally are deleted; objects filtered out by Include / Exclude are never deleted
+// DryRun: When true, only the differences are computed; nothing is uploaded or deleted
+// Include: Patterns of files to sync; every file when empty. See fileutil.WalkOptions for the syntax
+// Exclude: Patterns of files and directories not to sync, e.g. ".git", "*.map"
+type SyncOptions struct {
+	Concurrency int
+	Delete      bool
+	DryRun      bool
+	Include     []string
+	Exclude     []string
+}
+
+// SyncResult 目录同步结果，各列表均为已排序的对象键
+// Uploaded: 上传的对象
+// Skipped: 内容未变化而跳过的对象
+// Deleted: 删除的对象
+//
+// SyncResult is the result of a directory sync; every list holds sorted object keys.
+// Uploaded: Uploaded objects
+// Skipped: Objects skipped because their content is unchanged
+// Deleted: Deleted objects
+type SyncResult struct {
+	Uploaded []string
+	Skipped  []string
+	Deleted  []string
+}
+
+// syncJob 同步任务，path 为空表示删除
+//
+// syncJob is a sync task; an empty path means deletion
+type syncJob struct {
+	key  string
+	path string
+	etag string
+}
+
+// SyncDir 将本地目录同步到存储桶前缀下，常用于静态站点部署
+// 通过比较本地文件的 MD5 与对象的 ETag 跳过未变化的文件（分片上传的对象无法比较，总会重新上传），
+// 上传时自动应用客户端的 Content-Type 和 Cache-Control 策略；不跟随符号链接
+// 参数:
+//   - ctx: 上下文，取消后不再处理新的文件
+//   - dir: 本地目录
+//   - bucket: 存储桶
+//   - prefix: 对象键前缀，非空且不以 "/" 结尾时自动补上
+//   - opts: 同步选项，可以为 nil
+//
+// 返回:
+//   - *SyncResult: 同步结果
+//   - error: 遍历目录、列举对象失败或 ctx 被取消时返回错误；部分文件失败时返回包装了各文件错误的 ErrSyncIncomplete
+//
+// SyncDir syncs a local directory to a bucket prefix, typically for static-site deployments.
+// Unchanged files are skipped by comparing their MD5 with the object ETag (multipart-uploaded objects cannot be compared and are always re-uploaded),
+// and the client's Content-Type and Cache-Control policies are applied on upload; symlinks are not followed.
+// Parameters:
+//   - ctx: Context; cancelling it stops processing new files
+//   - dir: Local directory
+//   - bucket: Bucket name
+//   - prefix: Object key prefix; a trailing "/" is added when it is non-empty and lacks one
+//   - opts: Sync options, may be nil
+//
+// Returns:
+//   - *SyncResult: The sync result
+//   - error: Returns an error if walking the directory or listing objects fails or ctx is cancelled; returns ErrSyncIncomplete wrapping the per-file errors if some files failed
+func (c *OssClient) SyncDir(ctx context.Context, dir, bucket, prefix string, opts *SyncOptions) (*SyncResult, error) {
+	var o SyncOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultSyncConcurrency
+	}
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	filter, err := fileutil.NewPathFilter(o.Include, o.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	remote := make(map[string]string)
+	p := s3.NewListObjectsV2Paginator(c.seClient, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			remote[aws.ToString(obj.Key)] = aws.ToString(obj.ETag)
+		}
+	}
+
+	var (
+		result SyncResult
+		mu     sync.Mutex
+		errs   []error
+		wg     sync.WaitGroup
+		jobs   = make(chan syncJob)
+	)
+	for range o.Concurrency {
+		wg.Go(func() {
+			for job := range jobs {
+				outcome, err := c.syncOne(ctx, bucket, job, o.DryRun)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", job.key, err))
+				} else {
+					switch outcome {
+					case syncUploaded:
+						result.Uploaded = append(result.Uploaded, job.key)
+					case syncSkipped:
+						result.Skipped = append(result.Skipped, job.key)
+					case syncDeleted:
+						result.Deleted = append(result.Deleted, job.key)
+					}
+				}
+				mu.Unlock()
+			}
+		})
+	}
+
+	send := func(job syncJob) error {
+		select {
+		case jobs <- job:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	walkErr := func() error {
+		defer close(jobs)
+		// 不上传符号链接，避免把目录之外的文件同步到存储桶
+		err := fileutil.WalkFiltered(ctx, dir, func(e fileutil.WalkEntry) error {
+			key := prefix + e.Path
+			etag := remote[key]
+			delete(remote, key)
+			return send(syncJob{key: key, path: filepath.Join(dir, filepath.FromSlash(e.Path)), etag: etag})
+		}, &fileutil.WalkOptions{Include: o.Include, Exclude: o.Exclude, Symlinks: fileutil.SymlinkSkip})
+		if err != nil || !o.Delete {
+			return err
+		}
+		for key := range remote {
+			if !filter.Match(strings.TrimPrefix(key, prefix)) {
+				continue
+			}
+			if err := send(syncJob{key: key}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}()
+	wg.Wait()
+
+	slices.Sort(result.Uploaded)
+	slices.Sort(result.Skipped)
+	slices.Sort(result.Deleted)
+	if walkErr != nil {
+		return &result, walkErr
+	}
+	if len(errs) > 0 {
+		return &result, fmt.Errorf("%w: %d files failed: %w", ErrSyncIncomplete, len(errs), errors.Join(errs...))
+	}
+	return &result, nil
+}
+
+// syncOutcome 单个同步任务的结果
+//
+// syncOutcome is the outcome of a single sync task
+type syncOutcome int
+
+const (
+	syncUploaded syncOutcome = iota
+	syncSkipped
+	syncDeleted
+)
+
+// syncOne 执行单个同步任务
+//
+// syncOne performs a single sync task
+func (c *OssClient) syncOne(ctx context.Context, bucket string, job syncJob, dryRun bool) (syncOutcome, error) {
+	if job.path == "" {
+		if dryRun {
+			return syncDeleted, nil
+		}
+		_, err := c.seClient.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(job.key)})
+		return syncDeleted, err
+	}
+
+	f, err := os.Open(job.path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	if isMD5ETag(job.etag) {
+		h := md5.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return 0, err
+		}
+		if strings.EqualFold(unquote(job.etag), hex.EncodeToString(h.Sum(nil))) {
+			return syncSkipped, nil
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+	}
+	if dryRun {
+		return syncUploaded, nil
+	}
+	return syncUploaded, c.Put(ctx, bucket, job.key, f, nil)
+}
@@ -0,0 +1,360 @@
+package ossutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+
+	"github.com/supergodk/go-utils/v1/timeutil"
+)
+
+const (
+	// DefaultRestoreConcurrency RestorePrefix 的默认并发数
+	//
+	// DefaultRestoreConcurrency is the default concurrency of RestorePrefix
+	DefaultRestoreConcurrency = 16
+	// DefaultRestorePollInterval WaitForRestore 的初始轮询间隔
+	//
+	// DefaultRestorePollInterval is the initial polling interval of WaitForRestore
+	DefaultRestorePollInterval = 30 * time.Second
+	// DefaultRestoreMaxPollInterval WaitForRestore 的最大轮询间隔
+	//
+	// DefaultRestoreMaxPollInterval is the maximum polling interval of WaitForRestore
+	DefaultRestoreMaxPollInterval = 15 * time.Minute
+)
+
+var (
+	// ErrNotArchived 表示对象不是归档存储类型，无需解冻即可读取
+	//
+	// ErrNotArchived indicates that the object is not in an archive storage class and can be read without restoring
+	ErrNotArchived = errors.New("object is not archived")
+	// ErrRestoreIncomplete 表示批量解冻时部分对象失败
+	//
+	// ErrRestoreIncomplete indicates that some objects failed during a bulk restore
+	ErrRestoreIncomplete = errors.New("restore incomplete")
+)
+
+// RestoreStatus 归档对象的解冻状态
+// Archived: 对象是否为需要解冻的归档存储类型
+// Ongoing: 是否正在解冻
+// Restored: 是否已解冻完成，可以读取
+// ExpiresAt: 解冻副本的过期时间，未解冻时为零值
+//
+// RestoreStatus is the restore status of an archived object.
+// Archived: Whether the object is in an archive storage class that needs restoring
+// Ongoing: Whether a restore is in progress
+// Restored: Whether the restore has completed and the object is readable
+// ExpiresAt: Expiry of the restored copy; zero when not restored
+type RestoreStatus struct {
+	Archived  bool
+	Ongoing   bool
+	Restored  bool
+	ExpiresAt time.Time
+}
+
+// RestoreObject 发起归档对象的解冻请求，解冻已在进行中时视为成功
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - key: 对象键
+//   - tier: 解冻优先级，例如 types.TierStandard、types.TierBulk、types.TierExpedited，为空时使用服务端默认值
+//   - days: 解冻副本保留的天数
+//
+// 返回:
+//   - error: 请求失败时返回错误；对象不是归档类型时返回 ErrNotArchived
+//
+// RestoreObject requests a restore of an archived object; a restore that is already in progress counts as success.
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - key: Object key
+//   - tier: Retrieval tier, e.g. types.TierStandard, types.TierBulk, types.TierExpedited; the service default when empty
+//   - days: Number of days to keep the restored copy
+//
+// Returns:
+//   - error: Returns an error if the request fails; returns ErrNotArchived if the object is not archived
+func (c *OssClient) RestoreObject(ctx context.Context, bucket, key string, tier types.Tier, days int32) error {
+	req := &types.RestoreRequest{Days: aws.Int32(days)}
+	if tier != "" {
+		req.GlacierJobParameters = &types.GlacierJobParameters{Tier: tier}
+	}
+	_, err := c.seClient.RestoreObject(ctx, &s3.RestoreObjectInput{
+		Bucket:         aws.String(bucket),
+		Key:            aws.String(key),
+		RestoreRequest: req,
+	})
+	switch errorCode(err) {
+	case "":
+		return err
+	case "RestoreAlreadyInProgress":
+		return nil
+	case "InvalidObjectState", "ObjectAlreadyInActiveTierError":
+		return fmt.Errorf("%w: %v", ErrNotArchived, err)
+	}
+	return err
+}
+
+// GetRestoreStatus 查询对象的解冻状态
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - key: 对象键
+//
+// 返回:
+//   - *RestoreStatus: 解冻状态
+//   - error: 请求失败时返回错误
+//
+// GetRestoreStatus queries the restore status of an object.
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - key: Object key
+//
+// Returns:
+//   - *RestoreStatus: The restore status
+//   - error: Returns an error if the request fails
+func (c *OssClient) GetRestoreStatus(ctx context.Context, bucket, key string) (*RestoreStatus, error) {
+	head, err := c.seClient.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, err
+	}
+	st := parseRestoreHeader(aws.ToString(head.Restore))
+	st.Archived = isArchiveClass(string(head.StorageClass))
+	return st, nil
+}
+
+// WaitRestoreOptions WaitForRestore 的选项
+// Interval: 初始轮询间隔，默认 DefaultRestorePollInterval
+// MaxInterval: 最大轮询间隔，默认 DefaultRestoreMaxPollInterval；每次轮询后间隔翻倍直至该值
+// Jitter: 轮询间隔的随机抖动比例，默认 0.1
+//
+// WaitRestoreOptions contains options for WaitForRestore.
+// Interval: Initial polling interval, defaults to DefaultRestorePollInterval
+// MaxInterval: Maximum polling interval, defaults to DefaultRestoreMaxPollInterval; the interval doubles after each poll up to this value
+// Jitter: Random jitter fraction of the polling interval, defaults to 0.1
+type WaitRestoreOptions struct {
+	Interval    time.Duration
+	MaxInterval time.Duration
+	Jitter      float64
+}
+
+// WaitForRestore 以指数退避轮询，直到对象解冻完成
+// 标准优先级的解冻通常需要数小时，调用方应通过 ctx 设置合理的超时
+// 参数:
+//   - ctx: 上下文，取消后停止等待
+//   - bucket: 存储桶
+//   - key: 对象键
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *RestoreStatus: 解冻完成后的状态
+//   - error: 请求失败或 ctx 结束时返回错误；对象不是归档类型时返回 ErrNotArchived；没有进行中的解冻时返回错误，避免无限等待
+//
+// WaitForRestore polls with exponential backoff until the object has been restored.
+// Standard-tier restores often take hours, so callers should set a sensible timeout on ctx.
+// Parameters:
+//   - ctx: Context; cancelling it stops waiting
+//   - bucket: Bucket name
+//   - key: Object key
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *RestoreStatus: The status once restored
+//   - error: Returns an error if a request fails or ctx ends; returns ErrNotArchived if the object is not archived; returns an error when no restore is in progress, rather than waiting forever
+func (c *OssClient) WaitForRestore(ctx context.Context, bucket, key string, opts *WaitRestoreOptions) (*RestoreStatus, error) {
+	var o WaitRestoreOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Interval <= 0 {
+		o.Interval = DefaultRestorePollInterval
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = DefaultRestoreMaxPollInterval
+	}
+	if o.Jitter == 0 {
+		o.Jitter = 0.1
+	}
+
+	interval := o.Interval
+	for {
+		st, err := c.GetRestoreStatus(ctx, bucket, key)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case !st.Archived:
+			return st, ErrNotArchived
+		case st.Restored:
+			return st, nil
+		case !st.Ongoing:
+			return st, fmt.Errorf("ossutil: no restore in progress for %s/%s", bucket, key)
+		}
+		if err := timeutil.SleepJitterContext(ctx, interval, o.Jitter); err != nil {
+			return nil, err
+		}
+		interval = min(interval*2, o.MaxInterval)
+	}
+}
+
+// RestorePrefixOptions RestorePrefix 的选项
+// Concurrency: 并发数，默认 DefaultRestoreConcurrency
+//
+// RestorePrefixOptions contains options for RestorePrefix.
+// Concurrency: Concurrency, defaults to DefaultRestoreConcurrency
+type RestorePrefixOptions struct {
+	Concurrency int
+}
+
+// RestorePrefix 为前缀下所有归档存储类型的对象发起解冻请求，非归档对象会被跳过
+// 参数:
+//   - ctx: 上下文，取消后不再处理新的对象
+//   - bucket: 存储桶
+//   - prefix: 对象键前缀，为空时处理整个存储桶
+//   - tier: 解冻优先级，大量对象建议使用 types.TierBulk
+//   - days: 解冻副本保留的天数
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - []string: 成功发起解冻的对象键，可逐个传给 WaitForRestore
+//   - error: 列举失败或 ctx 被取消时返回错误；部分对象失败时返回包装了各对象错误的 ErrRestoreIncomplete
+//
+// RestorePrefix requests restores for every archived object under a prefix; objects that are not archived are skipped.
+// Parameters:
+//   - ctx: Context; cancelling it stops dispatching new objects
+//   - bucket: Bucket name
+//   - prefix: Object key prefix; the whole bucket is processed when empty
+//   - tier: Retrieval tier; types.TierBulk is recommended for many objects
+//   - days: Number of days to keep the restored copies
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - []string: Keys of the objects whose restore was requested, each of which can be passed to WaitForRestore
+//   - error: Returns an error if listing fails or ctx is cancelled; returns ErrRestoreIncomplete wrapping the per-object errors if some objects failed
+func (c *OssClient) RestorePrefix(ctx context.Context, bucket, prefix string, tier types.Tier, days int32, opts *RestorePrefixOptions) ([]string, error) {
+	var o RestorePrefixOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultRestoreConcurrency
+	}
+
+	var (
+		mu        sync.Mutex
+		requested []string
+		errs      []error
+		wg        sync.WaitGroup
+		jobs      = make(chan string)
+	)
+	for range o.Concurrency {
+		wg.Go(func() {
+			for key := range jobs {
+				err := c.RestoreObject(ctx, bucket, key, tier, days)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				} else {
+					requested = append(requested, key)
+				}
+				mu.Unlock()
+			}
+		})
+	}
+
+	listErr := func() error {
+		defer close(jobs)
+		p := s3.NewListObjectsV2Paginator(c.seClient, &s3.ListObjectsV2Input{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(prefix),
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return err
+			}
+			for _, obj := range page.Contents {
+				if !isArchiveClass(string(obj.StorageClass)) {
+					continue
+				}
+				select {
+				case jobs <- aws.ToString(obj.Key):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		return nil
+	}()
+	wg.Wait()
+
+	if listErr != nil {
+		return requested, listErr
+	}
+	if len(errs) > 0 {
+		return requested, fmt.Errorf("%w: %d objects failed: %w", ErrRestoreIncomplete, len(errs), errors.Join(errs...))
+	}
+	return requested, nil
+}
+
+// isArchiveClass 判断存储类型是否需要解冻后才能读取，包括 S3 的 GLACIER、DEEP_ARCHIVE 和阿里云 OSS 的 Archive、ColdArchive、DeepColdArchive
+//
+// isArchiveClass reports whether the storage class must be restored before reading, covering S3 GLACIER and DEEP_ARCHIVE and Aliyun OSS Archive, ColdArchive and DeepColdArchive
+func isArchiveClass(class string) bool {
+	switch strings.ToUpper(class) {
+	case "GLACIER", "DEEP_ARCHIVE", "ARCHIVE", "COLDARCHIVE", "DEEPCOLDARCHIVE":
+		return true
+	}
+	return false
+}
+
+// parseRestoreHeader 解析 x-amz-restore 响应头，例如 `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`
+//
+// parseRestoreHeader parses the x-amz-restore response header, e.g. `ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"`
+func parseRestoreHeader(h string) *RestoreStatus {
+	st := &RestoreStatus{}
+	if h == "" {
+		return st
+	}
+	if v, ok := restoreAttr(h, "ongoing-request"); ok {
+		st.Ongoing = v == "true"
+		st.Restored = v == "false"
+	}
+	if v, ok := restoreAttr(h, "expiry-date"); ok {
+		if t, err := http.ParseTime(v); err == nil {
+			st.ExpiresAt = t
+		}
+	}
+	return st
+}
+
+// restoreAttr 从 x-amz-restore 响应头中取出带引号的属性值
+//
+// restoreAttr extracts a quoted attribute value from the x-amz-restore header
+func restoreAttr(h, name string) (string, bool) {
+	_, rest, ok := strings.Cut(h, name+`="`)
+	if !ok {
+		return "", false
+	}
+	v, _, ok := strings.Cut(rest, `"`)
+	return v, ok
+}
+
+// errorCode 返回服务端错误码，err 不是服务端错误时返回空字符串
+//
+// errorCode returns the service error code, or an empty string if err is not a service error
+func errorCode(err error) string {
+	var apiErr interface{ ErrorCode() string }
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
@@ -0,0 +1,122 @@
+package ossutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// ErrObjectNotFound 表示对象不存在
+//
+// ErrObjectNotFound indicates that the object does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectInfo 对象的元信息
+// Key: 对象键
+// Size: 对象大小（字节）
+// ETag: 对象的 ETag，内容变化时改变
+// ContentType: 内容类型
+// LastModified: 最后修改时间
+// Metadata: 自定义元数据
+//
+// ObjectInfo is an object's metadata.
+// Key: Object key
+// Size: Object size in bytes
+// ETag: The object's ETag, which changes with the content
+// ContentType: Content type
+// LastModified: Last modification time
+// Metadata: Custom metadata
+type ObjectInfo struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ETag         string            `json:"etag"`
+	ContentType  string            `json:"content_type,omitempty"`
+	LastModified time.Time         `json:"last_modified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// ObjectStorage 对象存储的基本读写操作，*OssClient 实现了该接口，CachedStorage 等包装器也实现了该接口，可以互相叠加
+//
+// ObjectStorage is the basic read and write operations of object storage; *OssClient implements it, as do wrappers such as CachedStorage, so they can be stacked.
+type ObjectStorage interface {
+	// Get 读取对象内容和元信息，对象不存在时返回 ErrObjectNotFound，调用者必须关闭返回的 io.ReadCloser
+	//
+	// Get reads the object's content and metadata, returning ErrObjectNotFound if it does not exist; the caller must close the returned io.ReadCloser.
+	Get(ctx context.Context, bucket, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Head 读取对象的元信息，对象不存在时返回 ErrObjectNotFound
+	//
+	// Head reads the object's metadata, returning ErrObjectNotFound if it does not exist.
+	Head(ctx context.Context, bucket, key string) (*ObjectInfo, error)
+	// Put 上传对象
+	//
+	// Put uploads an object.
+	Put(ctx context.Context, bucket, key string, body io.Reader, opts *PutOptions) error
+	// Delete 删除对象，对象不存在时不返回错误
+	//
+	// Delete deletes the object; no error is returned if it does not exist.
+	Delete(ctx context.Context, bucket, key string) error
+}
+
+// Get 实现 ObjectStorage 接口
+//
+// Get implements the ObjectStorage interface.
+func (c *OssClient) Get(ctx context.Context, bucket, key string) (io.ReadCloser, *ObjectInfo, error) {
+	out, err := c.seClient.GetObject(ctx, &s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, nil, notFoundError(err)
+	}
+	return out.Body, &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ETag:         aws.ToString(out.ETag),
+		ContentType:  aws.ToString(out.ContentType),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+// Head 实现 ObjectStorage 接口
+//
+// Head implements the ObjectStorage interface.
+func (c *OssClient) Head(ctx context.Context, bucket, key string) (*ObjectInfo, error) {
+	out, err := c.seClient.HeadObject(ctx, &s3.HeadObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if err != nil {
+		return nil, notFoundError(err)
+	}
+	return &ObjectInfo{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ETag:         aws.ToString(out.ETag),
+		ContentType:  aws.ToString(out.ContentType),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+// Delete 实现 ObjectStorage 接口
+//
+// Delete implements the ObjectStorage interface.
+func (c *OssClient) Delete(ctx context.Context, bucket, key string) error {
+	_, err := c.seClient.DeleteObject(ctx, &s3.DeleteObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	return err
+}
+
+// notFoundError 将对象不存在的服务端错误转换为 ErrObjectNotFound
+//
+// notFoundError converts the service's not-found errors to ErrObjectNotFound
+func notFoundError(err error) error {
+	var (
+		noSuchKey *types.NoSuchKey
+		notFound  *types.NotFound
+	)
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return fmt.Errorf("%w: %v", ErrObjectNotFound, err)
+	}
+	return err
+}
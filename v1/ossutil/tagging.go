@@ -0,0 +1,348 @@
+package ossutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// DefaultTagConcurrency TagPrefix 的默认并发数
+//
+// DefaultTagConcurrency is the default concurrency of TagPrefix
+const DefaultTagConcurrency = 16
+
+// ErrTagIncomplete 表示批量打标签时部分对象失败
+//
+// ErrTagIncomplete indicates that some objects failed during bulk tagging
+var ErrTagIncomplete = errors.New("tagging incomplete")
+
+// GetObjectTags 获取对象的标签
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - key: 对象键
+//
+// 返回:
+//   - map[string]string: 标签，对象没有标签时为空 map
+//   - error: 请求失败时返回错误
+//
+// GetObjectTags gets the tags of an object.
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - key: Object key
+//
+// Returns:
+//   - map[string]string: The tags; an empty map if the object has none
+//   - error: Returns an error if the request fails
+func (c *OssClient) GetObjectTags(ctx context.Context, bucket, key string) (map[string]string, error) {
+	out, err := c.seClient.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string, len(out.TagSet))
+	for _, t := range out.TagSet {
+		tags[aws.ToString(t.Key)] = aws.ToString(t.Value)
+	}
+	return tags, nil
+}
+
+// SetObjectTags 设置对象的标签，会替换对象已有的全部标签（S3 每个对象最多 10 个标签）
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - key: 对象键
+//   - tags: 标签
+//
+// 返回:
+//   - error: 请求失败时返回错误
+//
+// SetObjectTags sets the tags of an object, replacing all existing tags (S3 allows at most 10 tags per object).
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - key: Object key
+//   - tags: The tags
+//
+// Returns:
+//   - error: Returns an error if the request fails
+func (c *OssClient) SetObjectTags(ctx context.Context, bucket, key string, tags map[string]string) error {
+	_, err := c.seClient.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:  aws.String(bucket),
+		Key:     aws.String(key),
+		Tagging: &types.Tagging{TagSet: toTagSet(tags)},
+	})
+	return err
+}
+
+// DeleteObjectTags 删除对象的全部标签
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - key: 对象键
+//
+// 返回:
+//   - error: 请求失败时返回错误
+//
+// DeleteObjectTags deletes all tags of an object.
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - key: Object key
+//
+// Returns:
+//   - error: Returns an error if the request fails
+func (c *OssClient) DeleteObjectTags(ctx context.Context, bucket, key string) error {
+	_, err := c.seClient.DeleteObjectTagging(ctx, &s3.DeleteObjectTaggingInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+// TagPrefixOptions 批量打标签的选项
+// Concurrency: 并发数，默认 DefaultTagConcurrency
+// Replace: 为 true 时替换对象已有的标签，否则与已有标签合并（同名标签被覆盖），合并需要额外读取一次标签
+//
+// TagPrefixOptions contains options for bulk tagging.
+// Concurrency: Concurrency, defaults to DefaultTagConcurrency
+// Replace: When true, existing tags are replaced; otherwise they are merged (same-named tags are overwritten), which costs an extra read per object
+type TagPrefixOptions struct {
+	Concurrency int
+	Replace     bool
+}
+
+// TagPrefix 为前缀下的所有对象打标签，常用于成本分摊和按标签的保留策略
+// 参数:
+//   - ctx: 上下文，取消后不再处理新的对象
+//   - bucket: 存储桶
+//   - prefix: 对象键前缀，为空时处理整个存储桶
+//   - tags: 要设置的标签
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - int: 成功打标签的对象数
+//   - error: 列举失败或 ctx 被取消时返回错误；部分对象失败时返回包装了各对象错误的 ErrTagIncomplete
+//
+// TagPrefix tags all objects under a prefix, typically for cost allocation and tag-based retention.
+// Parameters:
+//   - ctx: Context; cancelling it stops dispatching new objects
+//   - bucket: Bucket name
+//   - prefix: Object key prefix; the whole bucket is processed when empty
+//   - tags: The tags to set
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - int: Number of objects tagged successfully
+//   - error: Returns an error if listing fails or ctx is cancelled; returns ErrTagIncomplete wrapping the per-object errors if some objects failed
+func (c *OssClient) TagPrefix(ctx context.Context, bucket, prefix string, tags map[string]string, opts *TagPrefixOptions) (int, error) {
+	var o TagPrefixOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultTagConcurrency
+	}
+
+	var (
+		tagged atomic.Int64
+		mu     sync.Mutex
+		errs   []error
+		wg     sync.WaitGroup
+		jobs   = make(chan string)
+	)
+	for range o.Concurrency {
+		wg.Go(func() {
+			for key := range jobs {
+				err := c.tagObject(ctx, bucket, key, tags, o.Replace)
+				if err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("%s: %w", key, err))
+					mu.Unlock()
+					continue
+				}
+				tagged.Add(1)
+			}
+		})
+	}
+
+	listErr := func() error {
+		defer close(jobs)
+		p := s3.NewListObjectsV2Paginator(c.seClient, &s3.ListObjectsV2Input{
+			Bucket: aws.String(bucket),
+			Prefix: aws.String(prefix),
+		})
+		for p.HasMorePages() {
+			page, err := p.NextPage(ctx)
+			if err != nil {
+				return err
+			}
+			for _, obj := range page.Contents {
+				select {
+				case jobs <- aws.ToString(obj.Key):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+		return nil
+	}()
+	wg.Wait()
+
+	n := int(tagged.Load())
+	if listErr != nil {
+		return n, listErr
+	}
+	if len(errs) > 0 {
+		return n, fmt.Errorf("%w: %d objects failed: %w", ErrTagIncomplete, len(errs), errors.Join(errs...))
+	}
+	return n, nil
+}
+
+// tagObject 为单个对象设置或合并标签
+//
+// tagObject sets or merges the tags of a single object
+func (c *OssClient) tagObject(ctx context.Context, bucket, key string, tags map[string]string, replace bool) error {
+	if !replace {
+		existing, err := c.GetObjectTags(ctx, bucket, key)
+		if err != nil {
+			return err
+		}
+		maps.Copy(existing, tags)
+		tags = existing
+	}
+	return c.SetObjectTags(ctx, bucket, key, tags)
+}
+
+// TagLifecycleFilter 构建按标签（可选再加前缀）匹配对象的生命周期过滤条件
+// 只有一个标签且没有前缀时使用 Tag 条件，否则使用 And 条件
+// 参数:
+//   - prefix: 对象键前缀，可以为空
+//   - tags: 对象必须同时带有的标签
+//
+// 返回:
+//   - *types.LifecycleRuleFilter: 过滤条件
+//
+// TagLifecycleFilter builds a lifecycle filter matching objects by tags (and optionally a prefix).
+// A single tag without a prefix uses the Tag condition; otherwise the And condition is used.
+// Parameters:
+//   - prefix: Object key prefix, may be empty
+//   - tags: Tags an object must all carry
+//
+// Returns:
+//   - *types.LifecycleRuleFilter: The filter
+func TagLifecycleFilter(prefix string, tags map[string]string) *types.LifecycleRuleFilter {
+	tagSet := toTagSet(tags)
+	switch {
+	case prefix == "" && len(tagSet) == 1:
+		return &types.LifecycleRuleFilter{Tag: &tagSet[0]}
+	case len(tagSet) == 0:
+		return &types.LifecycleRuleFilter{Prefix: aws.String(prefix)}
+	}
+	and := &types.LifecycleRuleAndOperator{Tags: tagSet}
+	if prefix != "" {
+		and.Prefix = aws.String(prefix)
+	}
+	return &types.LifecycleRuleFilter{And: and}
+}
+
+// TagExpirationRule 构建在创建若干天后删除带有指定标签的对象的生命周期规则
+// 参数:
+//   - id: 规则 ID
+//   - tags: 对象必须同时带有的标签
+//   - days: 对象创建后多少天过期
+//
+// 返回:
+//   - types.LifecycleRule: 已启用的生命周期规则
+//
+// TagExpirationRule builds a lifecycle rule that deletes objects carrying the given tags a number of days after creation.
+// Parameters:
+//   - id: Rule ID
+//   - tags: Tags an object must all carry
+//   - days: Days after creation until objects expire
+//
+// Returns:
+//   - types.LifecycleRule: An enabled lifecycle rule
+func TagExpirationRule(id string, tags map[string]string, days int32) types.LifecycleRule {
+	return types.LifecycleRule{
+		ID:         aws.String(id),
+		Status:     types.ExpirationStatusEnabled,
+		Filter:     TagLifecycleFilter("", tags),
+		Expiration: &types.LifecycleExpiration{Days: aws.Int32(days)},
+	}
+}
+
+// TagTransitionRule 构建在创建若干天后将带有指定标签的对象转为其他存储类型的生命周期规则
+// 参数:
+//   - id: 规则 ID
+//   - tags: 对象必须同时带有的标签
+//   - days: 对象创建后多少天转换
+//   - class: 目标存储类型，例如 types.TransitionStorageClassGlacier
+//
+// 返回:
+//   - types.LifecycleRule: 已启用的生命周期规则
+//
+// TagTransitionRule builds a lifecycle rule that moves objects carrying the given tags to another storage class a number of days after creation.
+// Parameters:
+//   - id: Rule ID
+//   - tags: Tags an object must all carry
+//   - days: Days after creation until objects transition
+//   - class: Target storage class, e.g. types.TransitionStorageClassGlacier
+//
+// Returns:
+//   - types.LifecycleRule: An enabled lifecycle rule
+func TagTransitionRule(id string, tags map[string]string, days int32, class types.TransitionStorageClass) types.LifecycleRule {
+	return types.LifecycleRule{
+		ID:          aws.String(id),
+		Status:      types.ExpirationStatusEnabled,
+		Filter:      TagLifecycleFilter("", tags),
+		Transitions: []types.Transition{{Days: aws.Int32(days), StorageClass: class}},
+	}
+}
+
+// PutLifecycleRules 设置存储桶的生命周期规则，会替换存储桶已有的全部规则
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - rules: 生命周期规则，例如 TagExpirationRule 的返回值
+//
+// 返回:
+//   - error: 请求失败时返回错误
+//
+// PutLifecycleRules sets the lifecycle rules of a bucket, replacing all existing rules.
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - rules: Lifecycle rules, e.g. as returned by TagExpirationRule
+//
+// Returns:
+//   - error: Returns an error if the request fails
+func (c *OssClient) PutLifecycleRules(ctx context.Context, bucket string, rules ...types.LifecycleRule) error {
+	_, err := c.seClient.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucket),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{Rules: rules},
+	})
+	return err
+}
+
+// toTagSet 将标签 map 转换为按键排序的 TagSet
+//
+// toTagSet converts a tag map to a TagSet sorted by key
+func toTagSet(tags map[string]string) []types.Tag {
+	tagSet := make([]types.Tag, 0, len(tags))
+	for _, k := range slices.Sorted(maps.Keys(tags)) {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(k), Value: aws.String(tags[k])})
+	}
+	return tagSet
+}
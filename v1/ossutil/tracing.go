@@ -0,0 +1,25 @@
+package ossutil
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+
+	"github.com/supergodk/go-utils/v1/tracing"
+)
+
+// traceOperations 为每个 S3 操作创建名为 "ossutil.<操作名>" 的 span（例如 ossutil.PutObject），包括通过 S3() 直接发起的调用
+//
+// traceOperations creates a span named "ossutil.<operation>" (e.g. ossutil.PutObject) for every S3 operation, including calls made directly through S3()
+func traceOperations(o *s3.Options) {
+	o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+		return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("GoUtilsTracing",
+			func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+				ctx, end := tracing.StartSpan(ctx, "ossutil."+middleware.GetOperationName(ctx))
+				out, md, err := next.HandleInitialize(ctx, in)
+				end(err)
+				return out, md, err
+			}), middleware.Before)
+	})
+}
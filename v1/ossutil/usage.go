@@ -0,0 +1,322 @@
+package ossutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/supergodk/go-utils/v1/cacheutil"
+)
+
+const (
+	// DefaultUsageCacheTTL UsageCache 默认的缓存时间
+	//
+	// DefaultUsageCacheTTL is the default time UsageCache keeps a usage report
+	DefaultUsageCacheTTL = 5 * time.Minute
+	// DefaultUsageCachePrefix UsageCache 默认的键前缀
+	//
+	// DefaultUsageCachePrefix is the default key prefix of UsageCache
+	DefaultUsageCachePrefix = "ossutil:usage:"
+)
+
+// ErrQuotaExceeded 表示写入后会超出存储配额
+//
+// ErrQuotaExceeded indicates that a write would exceed the storage quota
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// ClassUsage 某个存储类型的用量
+// Objects: 对象数
+// Bytes: 总字节数
+//
+// ClassUsage is the usage of one storage class.
+// Objects: Number of objects
+// Bytes: Total bytes
+type ClassUsage struct {
+	Objects int64 `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// PrefixUsage 前缀下的存储用量
+// Objects: 对象数
+// Bytes: 总字节数
+// ByStorageClass: 按存储类型（例如 STANDARD、GLACIER）细分的用量，未返回存储类型的对象计入 STANDARD
+// ComputedAt: 统计完成的时间
+//
+// PrefixUsage is the storage usage under a prefix.
+// Objects: Number of objects
+// Bytes: Total bytes
+// ByStorageClass: Usage broken down by storage class (e.g. STANDARD, GLACIER); objects listed without a storage class count as STANDARD
+// ComputedAt: When the report was computed
+type PrefixUsage struct {
+	Objects        int64                 `json:"objects"`
+	Bytes          int64                 `json:"bytes"`
+	ByStorageClass map[string]ClassUsage `json:"by_storage_class,omitempty"`
+	ComputedAt     time.Time             `json:"computed_at"`
+}
+
+// GetPrefixUsage 列举前缀下的所有对象并统计对象数和总字节数，自动处理分页
+// 大前缀需要多次列举请求，耗时与对象数成正比，频繁调用（例如每次上传前检查配额）时应使用 UsageCache
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - prefix: 对象键前缀，例如 "tenants/42/"，为空时统计整个存储桶
+//
+// 返回:
+//   - *PrefixUsage: 用量
+//   - error: 列举失败时返回错误
+//
+// GetPrefixUsage lists every object under a prefix and counts objects and total bytes, handling pagination.
+// Large prefixes take many list requests, so the time grows with the object count; use UsageCache for frequent calls such as a quota check before every upload.
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - prefix: Object key prefix, e.g. "tenants/42/"; the whole bucket is counted when empty
+//
+// Returns:
+//   - *PrefixUsage: The usage
+//   - error: Returns an error if listing fails
+func (c *OssClient) GetPrefixUsage(ctx context.Context, bucket, prefix string) (*PrefixUsage, error) {
+	usage := &PrefixUsage{ByStorageClass: make(map[string]ClassUsage)}
+	p := s3.NewListObjectsV2Paginator(c.seClient, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucket),
+		Prefix: aws.String(prefix),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range page.Contents {
+			size := aws.ToInt64(obj.Size)
+			class := string(obj.StorageClass)
+			if class == "" {
+				class = "STANDARD"
+			}
+			cu := usage.ByStorageClass[class]
+			cu.Objects++
+			cu.Bytes += size
+			usage.ByStorageClass[class] = cu
+			usage.Objects++
+			usage.Bytes += size
+		}
+	}
+	usage.ComputedAt = time.Now()
+	return usage, nil
+}
+
+// UsageCacheOptions UsageCache 的选项
+// Cache: 缓存后端，默认 cacheutil.NewMemoryCache()；多实例部署时使用共享的缓存（例如 Redis）可以避免每个实例各自列举
+// Prefix: 缓存键前缀，默认为 DefaultUsageCachePrefix
+// TTL: 用量的缓存时间，默认为 DefaultUsageCacheTTL
+//
+// UsageCacheOptions contains options for UsageCache.
+// Cache: Cache backend, cacheutil.NewMemoryCache() by default; a shared cache (e.g. Redis) saves every instance of a multi-instance deployment from listing on its own
+// Prefix: Cache key prefix, DefaultUsageCachePrefix by default
+// TTL: How long usage is cached, DefaultUsageCacheTTL by default
+type UsageCacheOptions struct {
+	Cache  cacheutil.Cache
+	Prefix string
+	TTL    time.Duration
+}
+
+// UsageCache 缓存 GetPrefixUsage 的结果，用于按租户前缀执行存储配额；并发安全
+// 同一进程内对同一前缀的并发未命中只会触发一次列举；通过 Add 在上传或删除后修正缓存的用量，使缓存期内的配额检查仍然准确
+// 多个实例同时 Add 时可能丢失更新，因此用量只是近似值，误差在下次重新统计时消除
+//
+// UsageCache caches GetPrefixUsage results for enforcing storage quotas on per-tenant prefixes; it is safe for concurrent use.
+// Concurrent misses for the same prefix within a process trigger a single listing; Add corrects the cached usage after uploads or deletions so quota checks stay accurate while cached.
+// Concurrent Add calls from several instances may lose updates, so the usage is approximate until the next recount.
+type UsageCache struct {
+	client *OssClient
+	o      UsageCacheOptions
+
+	mu       sync.Mutex
+	inflight map[string]*usageCall
+}
+
+// usageCall 进行中的一次统计
+//
+// usageCall is a usage count in progress
+type usageCall struct {
+	done  chan struct{}
+	usage *PrefixUsage
+	err   error
+}
+
+// NewUsageCache 创建用量缓存
+// 参数:
+//   - client: 对象存储客户端
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *UsageCache: 用量缓存
+//
+// NewUsageCache creates a usage cache.
+// Parameters:
+//   - client: The object storage client
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *UsageCache: The usage cache
+func NewUsageCache(client *OssClient, opts *UsageCacheOptions) *UsageCache {
+	u := &UsageCache{client: client, inflight: make(map[string]*usageCall)}
+	if opts != nil {
+		u.o = *opts
+	}
+	if u.o.Cache == nil {
+		u.o.Cache = cacheutil.NewMemoryCache()
+	}
+	if u.o.Prefix == "" {
+		u.o.Prefix = DefaultUsageCachePrefix
+	}
+	if u.o.TTL <= 0 {
+		u.o.TTL = DefaultUsageCacheTTL
+	}
+	return u
+}
+
+// Get 返回前缀的用量，缓存未命中或已过期时重新统计；缓存后端出错时视为未命中
+//
+// Get returns the usage of a prefix, recounting when the cache misses or has expired; cache backend errors count as misses.
+func (u *UsageCache) Get(ctx context.Context, bucket, prefix string) (*PrefixUsage, error) {
+	ck := u.o.Prefix + bucket + "/" + prefix
+	if usage, ok := u.load(ctx, ck); ok {
+		return usage, nil
+	}
+
+	u.mu.Lock()
+	if call, ok := u.inflight[ck]; ok {
+		u.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.usage, call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	call := &usageCall{done: make(chan struct{})}
+	u.inflight[ck] = call
+	u.mu.Unlock()
+
+	call.usage, call.err = u.client.GetPrefixUsage(ctx, bucket, prefix)
+	if call.err == nil {
+		u.save(ctx, ck, call.usage)
+	}
+	u.mu.Lock()
+	delete(u.inflight, ck)
+	u.mu.Unlock()
+	close(call.done)
+	return call.usage, call.err
+}
+
+// Add 修正缓存的用量，例如上传成功后传入 (1, size)，删除后传入 (-1, -size)；只修正总数，不修正 ByStorageClass；未缓存时不做任何事，下次 Get 会重新统计
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - prefix: 对象键前缀，必须与 Get 使用的前缀一致
+//   - objects: 对象数的变化
+//   - bytes: 字节数的变化
+//
+// 返回:
+//   - error: 写入缓存失败时返回错误
+//
+// Add corrects the cached usage, e.g. (1, size) after a successful upload and (-1, -size) after a deletion; only the totals are corrected, not ByStorageClass, and nothing happens when nothing is cached, as the next Get recounts.
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - prefix: Object key prefix, which must match the one passed to Get
+//   - objects: Change in the object count
+//   - bytes: Change in bytes
+//
+// Returns:
+//   - error: Returns an error if writing the cache fails
+func (u *UsageCache) Add(ctx context.Context, bucket, prefix string, objects, bytes int64) error {
+	ck := u.o.Prefix + bucket + "/" + prefix
+	usage, ok := u.load(ctx, ck)
+	if !ok {
+		return nil
+	}
+	usage.Objects = max(usage.Objects+objects, 0)
+	usage.Bytes = max(usage.Bytes+bytes, 0)
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return err
+	}
+	// 保持原来的过期时间，避免频繁修正导致用量一直不被重新统计
+	ttl := u.o.TTL - time.Since(usage.ComputedAt)
+	if ttl <= 0 {
+		return u.o.Cache.Delete(ctx, ck)
+	}
+	return u.o.Cache.Set(ctx, ck, data, ttl)
+}
+
+// Invalidate 删除缓存的用量，下次 Get 会重新统计
+//
+// Invalidate drops the cached usage so the next Get recounts.
+func (u *UsageCache) Invalidate(ctx context.Context, bucket, prefix string) error {
+	return u.o.Cache.Delete(ctx, u.o.Prefix+bucket+"/"+prefix)
+}
+
+// CheckQuota 检查写入 incoming 字节后前缀的总字节数是否超过 limit
+// 参数:
+//   - ctx: 上下文
+//   - bucket: 存储桶
+//   - prefix: 租户的对象键前缀
+//   - limit: 配额（字节）
+//   - incoming: 将要写入的字节数
+//
+// 返回:
+//   - error: 超出配额时返回 ErrQuotaExceeded，统计失败时返回错误
+//
+// CheckQuota checks whether the prefix's total bytes would exceed limit after writing incoming bytes.
+// Parameters:
+//   - ctx: Context
+//   - bucket: Bucket name
+//   - prefix: The tenant's object key prefix
+//   - limit: The quota in bytes
+//   - incoming: Bytes about to be written
+//
+// Returns:
+//   - error: Returns ErrQuotaExceeded if the quota would be exceeded, or an error if counting fails
+func (u *UsageCache) CheckQuota(ctx context.Context, bucket, prefix string, limit, incoming int64) error {
+	usage, err := u.Get(ctx, bucket, prefix)
+	if err != nil {
+		return err
+	}
+	if usage.Bytes+incoming > limit {
+		return fmt.Errorf("%w: %s/%s uses %d of %d bytes, %d more requested", ErrQuotaExceeded, bucket, prefix, usage.Bytes, limit, incoming)
+	}
+	return nil
+}
+
+// load 读取并解码缓存的用量
+//
+// load reads and decodes a cached usage
+func (u *UsageCache) load(ctx context.Context, ck string) (*PrefixUsage, bool) {
+	data, ok, err := u.o.Cache.Get(ctx, ck)
+	if err != nil || !ok {
+		return nil, false
+	}
+	var usage PrefixUsage
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, false
+	}
+	return &usage, true
+}
+
+// save 编码并写入缓存的用量，写入失败时忽略
+//
+// save encodes and writes a cached usage, ignoring write failures
+func (u *UsageCache) save(ctx context.Context, ck string, usage *PrefixUsage) {
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return
+	}
+	_ = u.o.Cache.Set(ctx, ck, data, u.o.TTL)
+}
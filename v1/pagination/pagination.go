@@ -0,0 +1,264 @@
+// Package pagination 提供偏移分页和游标（keyset）分页相关的工具函数，用于统一各服务的分页接口
+//
+// Package pagination provides offset and cursor (keyset) pagination helpers that standardize paginated APIs across services.
+package pagination
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+
+	"github.com/supergodk/go-utils/v1/encodingutil"
+)
+
+const (
+	// DefaultPageSize 默认每页条数
+	//
+	// DefaultPageSize is the default page size
+	DefaultPageSize = 20
+	// DefaultMaxPageSize 默认每页最大条数
+	//
+	// DefaultMaxPageSize is the default maximum page size
+	DefaultMaxPageSize = 100
+)
+
+// PageRequest 分页请求，页码和游标二选一，设置了 Cursor 时使用游标分页
+// Page: 页码，从 1 开始
+// PageSize: 每页条数
+// Cursor: 上一页响应中的 NextCursor
+//
+// PageRequest is a pagination request; use either a page number or a cursor, with Cursor taking precedence when set.
+// Page: Page number, starting at 1
+// PageSize: Number of items per page
+// Cursor: The NextCursor from the previous page's response
+type PageRequest struct {
+	Page     int    `json:"page,omitempty"`
+	PageSize int    `json:"page_size,omitempty"`
+	Cursor   string `json:"cursor,omitempty"`
+}
+
+// ParsePageRequest 从 URL 查询参数 page、page_size 和 cursor 解析分页请求，并使用默认限制规范化
+// 无法解析的数字按未设置处理
+// 参数:
+//   - q: URL 查询参数，例如 r.URL.Query()
+//
+// 返回:
+//   - PageRequest: 规范化后的分页请求
+//
+// ParsePageRequest parses a pagination request from the URL query parameters page, page_size and cursor and normalizes it with the default limits.
+// Unparsable numbers are treated as unset.
+// Parameters:
+//   - q: URL query parameters, e.g. r.URL.Query()
+//
+// Returns:
+//   - PageRequest: The normalized pagination request
+func ParsePageRequest(q url.Values) PageRequest {
+	page, _ := strconv.Atoi(q.Get("page"))
+	size, _ := strconv.Atoi(q.Get("page_size"))
+	return PageRequest{Page: page, PageSize: size, Cursor: q.Get("cursor")}.Normalize(DefaultPageSize, DefaultMaxPageSize)
+}
+
+// FromOffset 将 offset/limit 转换为分页请求，offset 不是 limit 的整数倍时向下取整到所在页
+// 参数:
+//   - offset: 跳过的条数
+//   - limit: 每页条数
+//
+// 返回:
+//   - PageRequest: 分页请求
+//
+// FromOffset converts offset/limit into a pagination request; an offset that is not a multiple of limit is rounded down to its page.
+// Parameters:
+//   - offset: Number of items to skip
+//   - limit: Number of items per page
+//
+// Returns:
+//   - PageRequest: The pagination request
+func FromOffset(offset, limit int) PageRequest {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	return PageRequest{Page: max(offset, 0)/limit + 1, PageSize: limit}
+}
+
+// Normalize 返回限制在合理范围内的分页请求：页码至少为 1，每页条数为 0 时使用 defaultSize，且不超过 maxSize
+// 参数:
+//   - defaultSize: 默认每页条数
+//   - maxSize: 每页最大条数
+//
+// 返回:
+//   - PageRequest: 规范化后的分页请求
+//
+// Normalize returns the request clamped to sane bounds: the page is at least 1, a zero page size becomes defaultSize, and the size never exceeds maxSize.
+// Parameters:
+//   - defaultSize: Default page size
+//   - maxSize: Maximum page size
+//
+// Returns:
+//   - PageRequest: The normalized pagination request
+func (p PageRequest) Normalize(defaultSize, maxSize int) PageRequest {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PageSize <= 0 {
+		p.PageSize = defaultSize
+	}
+	p.PageSize = min(p.PageSize, maxSize)
+	return p
+}
+
+// Offset 返回当前页需要跳过的条数，溢出时返回 math.MaxInt
+//
+// Offset returns the number of items to skip for the current page, or math.MaxInt on overflow.
+func (p PageRequest) Offset() int {
+	if p.Page <= 1 || p.PageSize <= 0 {
+		return 0
+	}
+	if p.Page-1 > math.MaxInt/p.PageSize {
+		return math.MaxInt
+	}
+	return (p.Page - 1) * p.PageSize
+}
+
+// Limit 返回每页条数
+//
+// Limit returns the page size.
+func (p PageRequest) Limit() int {
+	return p.PageSize
+}
+
+// QueryLimit 返回游标分页查询时应使用的 LIMIT（多取一条用于判断是否还有下一页），与 KeysetPage 配合使用
+//
+// QueryLimit returns the LIMIT to use for cursor queries (one extra row to detect a next page), to be used with KeysetPage.
+func (p PageRequest) QueryLimit() int {
+	return p.PageSize + 1
+}
+
+// PageResponse 分页响应
+// Items: 当前页的数据
+// Page: 当前页码，游标分页时为 0
+// PageSize: 每页条数
+// Total: 总条数，未知时为 -1
+// TotalPages: 总页数，总条数未知时为 -1
+// HasMore: 是否还有下一页
+// NextCursor: 下一页的游标，仅游标分页时设置
+//
+// PageResponse is a pagination response.
+// Items: Items of the current page
+// Page: Current page number, 0 for cursor pagination
+// PageSize: Number of items per page
+// Total: Total number of items, -1 if unknown
+// TotalPages: Total number of pages, -1 if the total is unknown
+// HasMore: Whether there is a next page
+// NextCursor: Cursor of the next page, only set for cursor pagination
+type PageResponse[T any] struct {
+	Items      []T    `json:"items"`
+	Page       int    `json:"page,omitempty"`
+	PageSize   int    `json:"page_size"`
+	Total      int64  `json:"total"`
+	TotalPages int64  `json:"total_pages"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPageResponse 根据偏移分页的结果创建分页响应
+// 参数:
+//   - items: 当前页的数据
+//   - req: 规范化后的分页请求
+//   - total: 总条数，未知时传 -1（此时根据本页是否满页判断 HasMore）
+//
+// 返回:
+//   - PageResponse[T]: 分页响应，Items 为 nil 时会替换为空切片
+//
+// NewPageResponse creates a pagination response from an offset query result.
+// Parameters:
+//   - items: Items of the current page
+//   - req: The normalized pagination request
+//   - total: Total number of items; pass -1 if unknown (HasMore is then inferred from whether the page is full)
+//
+// Returns:
+//   - PageResponse[T]: The response; nil Items are replaced with an empty slice
+func NewPageResponse[T any](items []T, req PageRequest, total int64) PageResponse[T] {
+	if items == nil {
+		items = []T{}
+	}
+	resp := PageResponse[T]{Items: items, Page: req.Page, PageSize: req.PageSize, Total: total, TotalPages: -1}
+	if total < 0 {
+		resp.Total = -1
+		resp.HasMore = req.PageSize > 0 && len(items) >= req.PageSize
+		return resp
+	}
+	if req.PageSize > 0 {
+		resp.TotalPages = (total + int64(req.PageSize) - 1) / int64(req.PageSize)
+	}
+	resp.HasMore = int64(req.Offset())+int64(len(items)) < total
+	return resp
+}
+
+// KeysetPage 根据游标分页查询的结果创建分页响应
+// 查询应按排序键有序且使用 req.QueryLimit() 作为 LIMIT；多出的一条会被截掉，并用本页最后一条的排序键生成 NextCursor
+// 参数:
+//   - items: 查询结果，最多 req.QueryLimit() 条
+//   - req: 规范化后的分页请求
+//   - key: 返回数据排序键的函数，例如 (created_at, id)
+//
+// 返回:
+//   - PageResponse[T]: 分页响应，Total 和 TotalPages 为 -1
+//   - error: 如果排序键无法编码，返回错误
+//
+// KeysetPage creates a pagination response from a cursor query result.
+// The query should be ordered by the sort key and use req.QueryLimit() as its LIMIT; the extra row is dropped and NextCursor is built from the last returned item's sort key.
+// Parameters:
+//   - items: Query results, at most req.QueryLimit() rows
+//   - req: The normalized pagination request
+//   - key: Function returning an item's sort key, e.g. (created_at, id)
+//
+// Returns:
+//   - PageResponse[T]: The response, with Total and TotalPages set to -1
+//   - error: Returns an error if the sort key cannot be encoded
+func KeysetPage[T, K any](items []T, req PageRequest, key func(T) K) (PageResponse[T], error) {
+	resp := PageResponse[T]{PageSize: req.PageSize, Total: -1, TotalPages: -1}
+	if len(items) > req.PageSize {
+		items = items[:req.PageSize]
+		resp.HasMore = true
+	}
+	if items == nil {
+		items = []T{}
+	}
+	resp.Items = items
+	if resp.HasMore && len(items) > 0 {
+		cursor, err := encodingutil.EncodeCursor(key(items[len(items)-1]))
+		if err != nil {
+			return resp, err
+		}
+		resp.NextCursor = cursor
+	}
+	return resp, nil
+}
+
+// DecodeCursor 解码分页请求中的游标，没有游标时返回 K 的零值和 false
+// 参数:
+//   - req: 分页请求
+//
+// 返回:
+//   - K: 上一页最后一条的排序键
+//   - bool: 是否带有游标
+//   - error: 如果游标无效，返回 encodingutil.ErrInvalidCursor
+//
+// DecodeCursor decodes the cursor of a pagination request, returning the zero K and false when there is none.
+// Parameters:
+//   - req: The pagination request
+//
+// Returns:
+//   - K: Sort key of the last item of the previous page
+//   - bool: Whether the request has a cursor
+//   - error: Returns encodingutil.ErrInvalidCursor if the cursor is invalid
+func DecodeCursor[K any](req PageRequest) (K, bool, error) {
+	var k K
+	if req.Cursor == "" {
+		return k, false, nil
+	}
+	if err := encodingutil.DecodeCursor(req.Cursor, &k); err != nil {
+		return k, false, err
+	}
+	return k, true, nil
+}
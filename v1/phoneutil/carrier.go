@@ -0,0 +1,104 @@
+package phoneutil
+
+// Carrier 中国大陆手机号运营商
+//
+// Carrier is the carrier of a mainland China mobile number
+type Carrier string
+
+const (
+	// CarrierUnknown 未知运营商
+	//
+	// CarrierUnknown represents an unknown carrier
+	CarrierUnknown Carrier = ""
+	// CarrierChinaMobile 中国移动
+	//
+	// CarrierChinaMobile represents China Mobile
+	CarrierChinaMobile Carrier = "China Mobile"
+	// CarrierChinaUnicom 中国联通
+	//
+	// CarrierChinaUnicom represents China Unicom
+	CarrierChinaUnicom Carrier = "China Unicom"
+	// CarrierChinaTelecom 中国电信
+	//
+	// CarrierChinaTelecom represents China Telecom
+	CarrierChinaTelecom Carrier = "China Telecom"
+	// CarrierChinaBroadnet 中国广电
+	//
+	// CarrierChinaBroadnet represents China Broadnet
+	CarrierChinaBroadnet Carrier = "China Broadnet"
+	// CarrierVirtual 虚拟运营商（170/171/162/165/167 号段）
+	//
+	// CarrierVirtual represents mobile virtual network operators (170/171/162/165/167 segments)
+	CarrierVirtual Carrier = "Virtual"
+)
+
+// carrierPrefixes 号段（前 3 位）到运营商的映射
+//
+// carrierPrefixes maps number segments (first 3 digits) to carriers
+var carrierPrefixes = map[string]Carrier{
+	// 中国移动
+	"134": CarrierChinaMobile, "135": CarrierChinaMobile, "136": CarrierChinaMobile,
+	"137": CarrierChinaMobile, "138": CarrierChinaMobile, "139": CarrierChinaMobile,
+	"147": CarrierChinaMobile, "148": CarrierChinaMobile, "150": CarrierChinaMobile,
+	"151": CarrierChinaMobile, "152": CarrierChinaMobile, "157": CarrierChinaMobile,
+	"158": CarrierChinaMobile, "159": CarrierChinaMobile, "172": CarrierChinaMobile,
+	"178": CarrierChinaMobile, "182": CarrierChinaMobile, "183": CarrierChinaMobile,
+	"184": CarrierChinaMobile, "187": CarrierChinaMobile, "188": CarrierChinaMobile,
+	"195": CarrierChinaMobile, "197": CarrierChinaMobile, "198": CarrierChinaMobile,
+	// 中国联通
+	"130": CarrierChinaUnicom, "131": CarrierChinaUnicom, "132": CarrierChinaUnicom,
+	"145": CarrierChinaUnicom, "146": CarrierChinaUnicom, "155": CarrierChinaUnicom,
+	"156": CarrierChinaUnicom, "166": CarrierChinaUnicom, "175": CarrierChinaUnicom,
+	"176": CarrierChinaUnicom, "185": CarrierChinaUnicom, "186": CarrierChinaUnicom,
+	"196": CarrierChinaUnicom,
+	// 中国电信
+	"133": CarrierChinaTelecom, "149": CarrierChinaTelecom, "153": CarrierChinaTelecom,
+	"173": CarrierChinaTelecom, "174": CarrierChinaTelecom, "177": CarrierChinaTelecom,
+	"180": CarrierChinaTelecom, "181": CarrierChinaTelecom, "189": CarrierChinaTelecom,
+	"190": CarrierChinaTelecom, "191": CarrierChinaTelecom, "193": CarrierChinaTelecom,
+	"199": CarrierChinaTelecom,
+	// 中国广电
+	"192": CarrierChinaBroadnet,
+	// 虚拟运营商
+	"162": CarrierVirtual, "165": CarrierVirtual, "167": CarrierVirtual,
+	"170": CarrierVirtual, "171": CarrierVirtual,
+}
+
+// DetectCarrier 根据号段识别中国大陆手机号的运营商
+// 参数:
+//   - mobile: 11 位中国大陆手机号（不含 +86）
+//
+// 返回:
+//   - 运营商，无法识别时返回 CarrierUnknown
+//
+// DetectCarrier detects the carrier of a mainland China mobile number by its segment.
+// Parameters:
+//   - mobile: 11-digit mainland China mobile number (without +86)
+//
+// Returns:
+//   - The carrier, or CarrierUnknown if it cannot be detected
+func DetectCarrier(mobile string) Carrier {
+	if len(mobile) != 11 {
+		return CarrierUnknown
+	}
+	// 1349 为卫星电话号段，归属中国电信
+	if mobile[:4] == "1349" {
+		return CarrierChinaTelecom
+	}
+	return carrierPrefixes[mobile[:3]]
+}
+
+// isChinaMobileNumber 判断是否为 11 位且号段已知的中国大陆手机号
+//
+// isChinaMobileNumber reports whether the input is an 11-digit mainland China mobile number with a known segment
+func isChinaMobileNumber(s string) bool {
+	if len(s) != 11 || s[0] != '1' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return DetectCarrier(s) != CarrierUnknown
+}
@@ -0,0 +1,420 @@
+// Package phoneutil 提供手机号解析、格式化和运营商识别相关的工具函数
+//
+// Package phoneutil provides phone number parsing, formatting and carrier detection utility functions.
+package phoneutil
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/supergodk/go-utils/v1/stringutil"
+)
+
+const (
+	// RegionCN 中国大陆
+	//
+	// RegionCN represents mainland China
+	RegionCN = "CN"
+	// RegionHK 中国香港
+	//
+	// RegionHK represents Hong Kong, China
+	RegionHK = "HK"
+	// RegionMO 中国澳门
+	//
+	// RegionMO represents Macao, China
+	RegionMO = "MO"
+	// RegionTW 中国台湾
+	//
+	// RegionTW represents Taiwan, China
+	RegionTW = "TW"
+	// RegionUS 美国（北美编号计划）
+	//
+	// RegionUS represents the United States (North American Numbering Plan)
+	RegionUS = "US"
+)
+
+// Format 号码输出格式
+//
+// Format is the output format of a phone number
+type Format int
+
+const (
+	// FormatE164 E.164 格式，例如 "+8613812345678"
+	//
+	// FormatE164 is the E.164 format, e.g. "+8613812345678"
+	FormatE164 Format = iota
+	// FormatInternational 国际格式，例如 "+86 138 1234 5678"
+	//
+	// FormatInternational is the international format, e.g. "+86 138 1234 5678"
+	FormatInternational
+	// FormatNational 国内格式，例如 "138 1234 5678"
+	//
+	// FormatNational is the national format, e.g. "138 1234 5678"
+	FormatNational
+)
+
+var (
+	// ErrInvalidPhone 表示无效的手机号
+	//
+	// ErrInvalidPhone indicates an invalid phone number.
+	ErrInvalidPhone = errors.New("invalid phone number")
+	// ErrUnknownRegion 表示未知的国家/地区
+	//
+	// ErrUnknownRegion indicates an unknown country or region.
+	ErrUnknownRegion = errors.New("unknown phone region")
+)
+
+// regionCallingCodes 国家/地区代码到国际电话区号的映射
+//
+// regionCallingCodes maps region codes to international calling codes
+var regionCallingCodes = map[string]string{
+	RegionCN: "86",
+	RegionHK: "852",
+	RegionMO: "853",
+	RegionTW: "886",
+	RegionUS: "1",
+	"CA":     "1",
+	"JP":     "81",
+	"KR":     "82",
+	"SG":     "65",
+	"MY":     "60",
+	"TH":     "66",
+	"VN":     "84",
+	"PH":     "63",
+	"ID":     "62",
+	"IN":     "91",
+	"AU":     "61",
+	"NZ":     "64",
+	"GB":     "44",
+	"FR":     "33",
+	"DE":     "49",
+	"IT":     "39",
+	"ES":     "34",
+	"RU":     "7",
+	"AE":     "971",
+	"BR":     "55",
+	"MX":     "52",
+}
+
+// trunkPrefixRegions 使用国内长途前缀 0 的国家/地区，只有这些地区的国内写法才去掉开头的 0
+// 意大利等地区的 0 是号码本身的一部分，国际写法中也会保留，例如 "+39 06 1234 5678"
+//
+// trunkPrefixRegions are the regions that use the national trunk prefix 0; only their national forms have a leading 0 stripped.
+// In regions such as Italy the 0 is part of the number itself and is kept in the international form too, e.g. "+39 06 1234 5678"
+var trunkPrefixRegions = map[string]bool{
+	RegionCN: true,
+	RegionTW: true,
+	"JP":     true,
+	"KR":     true,
+	"MY":     true,
+	"TH":     true,
+	"VN":     true,
+	"PH":     true,
+	"ID":     true,
+	"IN":     true,
+	"AU":     true,
+	"NZ":     true,
+	"GB":     true,
+	"FR":     true,
+	"DE":     true,
+	"AE":     true,
+	"BR":     true,
+}
+
+// callingCodeRegions 国际电话区号到首选国家/地区代码的映射
+//
+// callingCodeRegions maps international calling codes to the preferred region code
+var callingCodeRegions = func() map[string]string {
+	m := make(map[string]string, len(regionCallingCodes))
+	for region, code := range regionCallingCodes {
+		if existing, ok := m[code]; ok && existing < region {
+			continue
+		}
+		m[code] = region
+	}
+	// 北美编号计划共用区号 1，默认归属美国
+	m["1"] = RegionUS
+	return m
+}()
+
+// Phone 解析后的电话号码
+// CountryCode: 国际电话区号（不含 "+"），例如 "86"
+// NationalNumber: 国内号码（不含国内长途前缀 0，意大利等没有长途前缀的地区保留开头的 0），例如 "13812345678"
+// Region: 国家/地区代码，例如 "CN"
+//
+// Phone is a parsed phone number.
+// CountryCode: International calling code (without "+"), e.g. "86"
+// NationalNumber: National number (without the trunk prefix 0; regions without one, such as Italy, keep their leading 0), e.g. "13812345678"
+// Region: Region code, e.g. "CN"
+type Phone struct {
+	CountryCode    string
+	NationalNumber string
+	Region         string
+}
+
+// ParsePhone 解析电话号码并规范化
+// 支持 "+8613812345678"、"008613812345678"、"138-1234-5678"、"(0755) 1234 5678" 等写法
+// 没有国际区号时使用 defaultRegion 对应的区号
+// 参数:
+//   - raw: 原始号码字符串
+//   - defaultRegion: 默认国家/地区代码，例如 RegionCN，为空时默认为 RegionCN
+//
+// 返回:
+//   - *Phone: 解析后的号码
+//   - error: 号码无效或地区未知时返回错误
+//
+// ParsePhone parses and normalizes a phone number.
+// Supports forms such as "+8613812345678", "008613812345678", "138-1234-5678" and "(0755) 1234 5678".
+// When there is no international calling code, the code of defaultRegion is used.
+// Parameters:
+//   - raw: The raw number string
+//   - defaultRegion: Default region code, e.g. RegionCN, defaults to RegionCN if empty
+//
+// Returns:
+//   - *Phone: The parsed number
+//   - error: Returns an error if the number is invalid or the region is unknown
+func ParsePhone(raw string, defaultRegion string) (*Phone, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return nil, fmt.Errorf("%w: empty input", ErrInvalidPhone)
+	}
+
+	international := false
+	if strings.HasPrefix(s, "+") {
+		international = true
+		s = s[1:]
+	}
+
+	digits, ok := stripSeparators(s)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q contains invalid characters", ErrInvalidPhone, raw)
+	}
+	if !international && strings.HasPrefix(digits, "00") {
+		international = true
+		digits = digits[2:]
+	}
+
+	var p Phone
+	if international {
+		code, region := splitCallingCode(digits)
+		if code == "" {
+			return nil, fmt.Errorf("%w: %q has an unknown calling code", ErrUnknownRegion, raw)
+		}
+		p.CountryCode = code
+		p.Region = region
+		p.NationalNumber = digits[len(code):]
+	} else {
+		if defaultRegion == "" {
+			defaultRegion = RegionCN
+		}
+		region := strings.ToUpper(defaultRegion)
+		code, ok := regionCallingCodes[region]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownRegion, defaultRegion)
+		}
+		p.CountryCode = code
+		p.Region = region
+		p.NationalNumber = digits
+		// 中国大陆号码允许携带国家区号但省略 "+"，例如 "8613812345678"
+		if region == RegionCN && len(digits) == 13 && strings.HasPrefix(digits, "861") {
+			p.NationalNumber = digits[2:]
+		}
+		// 国内写法去掉长途前缀 0，国际写法中的 0 属于号码本身
+		if trunkPrefixRegions[region] {
+			p.NationalNumber = strings.TrimPrefix(p.NationalNumber, "0")
+		}
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %q", err, raw)
+	}
+	return &p, nil
+}
+
+// MustParsePhone 与 ParsePhone 相同，但解析失败时 panic，适用于常量号码
+//
+// MustParsePhone is the same as ParsePhone but panics on failure, suitable for constant numbers.
+func MustParsePhone(raw string, defaultRegion string) *Phone {
+	p, err := ParsePhone(raw, defaultRegion)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// NormalizeE164 将号码规范化为 E.164 格式，是 ParsePhone(...).E164() 的简写
+//
+// NormalizeE164 normalizes a number to the E.164 format, shorthand for ParsePhone(...).E164().
+func NormalizeE164(raw string, defaultRegion string) (string, error) {
+	p, err := ParsePhone(raw, defaultRegion)
+	if err != nil {
+		return "", err
+	}
+	return p.E164(), nil
+}
+
+// IsValidChinaMobile 判断是否为有效的中国大陆手机号（11 位，可带 +86 前缀）
+//
+// IsValidChinaMobile reports whether the input is a valid mainland China mobile number (11 digits, optionally prefixed with +86).
+func IsValidChinaMobile(raw string) bool {
+	p, err := ParsePhone(raw, RegionCN)
+	return err == nil && p.IsChinaMobile()
+}
+
+// E164 返回 E.164 格式的号码，例如 "+8613812345678"
+//
+// E164 returns the number in E.164 format, e.g. "+8613812345678".
+func (p *Phone) E164() string {
+	return "+" + p.CountryCode + p.NationalNumber
+}
+
+// String 返回 E.164 格式的号码，实现 fmt.Stringer 接口
+//
+// String returns the number in E.164 format, implementing the fmt.Stringer interface.
+func (p *Phone) String() string {
+	return p.E164()
+}
+
+// Format 按指定格式输出号码
+//
+// Format outputs the number in the specified format.
+func (p *Phone) Format(f Format) string {
+	switch f {
+	case FormatInternational:
+		return "+" + p.CountryCode + " " + p.groupedNational()
+	case FormatNational:
+		if p.Region == RegionCN && !p.IsChinaMobile() {
+			return "0" + p.groupedNational()
+		}
+		return p.groupedNational()
+	default:
+		return p.E164()
+	}
+}
+
+// Mask 返回脱敏后的国内号码，例如 "138****5678"
+//
+// Mask returns the masked national number, e.g. "138****5678".
+func (p *Phone) Mask() string {
+	return stringutil.MaskPhone(p.NationalNumber)
+}
+
+// MaskE164 返回脱敏后的 E.164 号码，例如 "+86138****5678"
+//
+// MaskE164 returns the masked E.164 number, e.g. "+86138****5678".
+func (p *Phone) MaskE164() string {
+	return "+" + p.CountryCode + p.Mask()
+}
+
+// IsChinaMobile 判断是否为中国大陆手机号
+//
+// IsChinaMobile reports whether the number is a mainland China mobile number.
+func (p *Phone) IsChinaMobile() bool {
+	return p.CountryCode == "86" && isChinaMobileNumber(p.NationalNumber)
+}
+
+// Carrier 返回中国大陆手机号的运营商，非中国大陆手机号返回 CarrierUnknown
+//
+// Carrier returns the carrier of a mainland China mobile number, or CarrierUnknown for other numbers.
+func (p *Phone) Carrier() Carrier {
+	if !p.IsChinaMobile() {
+		return CarrierUnknown
+	}
+	return DetectCarrier(p.NationalNumber)
+}
+
+// validate 校验号码长度和格式
+//
+// validate checks the length and format of the number
+func (p *Phone) validate() error {
+	n := len(p.NationalNumber)
+	// E.164 规定号码总长度不超过 15 位
+	if n == 0 || len(p.CountryCode)+n > 15 {
+		return ErrInvalidPhone
+	}
+
+	switch p.Region {
+	case RegionCN:
+		// 手机号以 13-19 开头
+		if n >= 2 && p.NationalNumber[0] == '1' && p.NationalNumber[1] >= '3' {
+			if !isChinaMobileNumber(p.NationalNumber) {
+				return ErrInvalidPhone
+			}
+			return nil
+		}
+		// 北京固定电话：区号 10 + 8 位号码
+		if strings.HasPrefix(p.NationalNumber, "10") {
+			if n != 10 {
+				return ErrInvalidPhone
+			}
+			return nil
+		}
+		// 其他固定电话：区号（2-3 位，不含 0，不以 1 开头）+ 7-8 位号码
+		if p.NationalNumber[0] == '1' || n < 9 || n > 11 {
+			return ErrInvalidPhone
+		}
+	case RegionHK, RegionMO:
+		if n != 8 {
+			return ErrInvalidPhone
+		}
+	case RegionUS, "CA":
+		if n != 10 {
+			return ErrInvalidPhone
+		}
+	default:
+		if n < 4 || n > 14 {
+			return ErrInvalidPhone
+		}
+	}
+	return nil
+}
+
+// groupedNational 将国内号码分组显示
+//
+// groupedNational groups the national number for display
+func (p *Phone) groupedNational() string {
+	n := p.NationalNumber
+	switch {
+	case p.IsChinaMobile():
+		return n[:3] + " " + n[3:7] + " " + n[7:]
+	case (p.Region == RegionHK || p.Region == RegionMO) && len(n) == 8:
+		return n[:4] + " " + n[4:]
+	case (p.Region == RegionUS || p.Region == "CA") && len(n) == 10:
+		return "(" + n[:3] + ") " + n[3:6] + "-" + n[6:]
+	}
+	return n
+}
+
+// stripSeparators 去除号码中的分隔符（空格、全角空格、连字符、点、括号），并确认剩余字符都是数字
+//
+// stripSeparators removes separators (spaces, full-width spaces, hyphens, dots, parentheses) and ensures the rest are digits
+func stripSeparators(s string) (string, bool) {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')' || r == '\u3000':
+		default:
+			return "", false
+		}
+	}
+	return b.String(), b.Len() > 0
+}
+
+// splitCallingCode 按最长匹配取出国际电话区号（1-3 位）
+//
+// splitCallingCode extracts the international calling code (1-3 digits) by longest match
+func splitCallingCode(digits string) (string, string) {
+	for l := 3; l >= 1; l-- {
+		if len(digits) <= l {
+			continue
+		}
+		if region, ok := callingCodeRegions[digits[:l]]; ok {
+			return digits[:l], region
+		}
+	}
+	return "", ""
+}
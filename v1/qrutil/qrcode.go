@@ -0,0 +1,236 @@
+// Package qrutil 提供二维码生成相关的工具函数
+//
+// Package qrutil provides QR code generation utility functions.
+package qrutil
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/supergodk/go-utils/v1/imageutil"
+)
+
+// Level 二维码纠错级别
+//
+// Level is the QR code error-correction level
+type Level int
+
+const (
+	// LevelDefault 零值，使用默认的 LevelMedium
+	//
+	// LevelDefault is the zero value, using the default LevelMedium
+	LevelDefault Level = iota
+	// LevelLow 低纠错级别，可恢复约 7% 的数据
+	//
+	// LevelLow is the low level, recovering about 7% of data
+	LevelLow
+	// LevelMedium 中纠错级别，可恢复约 15% 的数据（默认）
+	//
+	// LevelMedium is the medium level, recovering about 15% of data (default)
+	LevelMedium
+	// LevelQuartile 较高纠错级别，可恢复约 25% 的数据
+	//
+	// LevelQuartile is the quartile level, recovering about 25% of data
+	LevelQuartile
+	// LevelHigh 高纠错级别，可恢复约 30% 的数据
+	//
+	// LevelHigh is the high level, recovering about 30% of data
+	LevelHigh
+)
+
+const (
+	// DefaultLogoRatio 默认 Logo 边长占二维码边长的比例
+	//
+	// DefaultLogoRatio is the default ratio of the logo side length to the QR code side length
+	DefaultLogoRatio = 0.2
+	// MaxLogoRatio 允许的最大 Logo 比例，超过后二维码可能无法识别
+	//
+	// MaxLogoRatio is the maximum allowed logo ratio, beyond which the QR code may become unreadable
+	MaxLogoRatio = 0.3
+)
+
+var (
+	// ErrEmptyContent 表示二维码内容为空
+	//
+	// ErrEmptyContent indicates that the QR code content is empty.
+	ErrEmptyContent = errors.New("qr code content is empty")
+	// ErrGenerateQRCode 表示二维码生成失败
+	//
+	// ErrGenerateQRCode indicates that QR code generation failed.
+	ErrGenerateQRCode = errors.New("failed to generate qr code")
+)
+
+// Options 二维码生成选项
+// Level: 纠错级别，零值 LevelDefault 表示 LevelMedium；设置 Logo 时至少为 LevelHigh
+// Foreground: 前景色，默认黑色
+// Background: 背景色，默认白色
+// DisableBorder: 是否去掉四周的静默区
+// Logo: 覆盖在二维码中心的 Logo 图片，为 nil 时不添加
+// LogoRatio: Logo 边长占二维码边长的比例，默认 DefaultLogoRatio，最大 MaxLogoRatio
+//
+// Options contains options for QR code generation.
+// Level: Error-correction level; the zero value LevelDefault means LevelMedium; at least LevelHigh when a logo is set
+// Foreground: Foreground color, defaults to black
+// Background: Background color, defaults to white
+// DisableBorder: Whether to remove the surrounding quiet zone
+// Logo: Logo image overlaid at the center of the QR code, none if nil
+// LogoRatio: Ratio of the logo side length to the QR code side length, defaults to DefaultLogoRatio, at most MaxLogoRatio
+type Options struct {
+	Level         Level
+	Foreground    color.Color
+	Background    color.Color
+	DisableBorder bool
+	Logo          image.Image
+	LogoRatio     float64
+}
+
+// GenerateQRCodeImage 生成二维码图片
+// 参数:
+//   - content: 二维码内容，例如支付链接或邀请链接
+//   - size: 图片边长（像素）
+//   - opts: 生成选项，可以为 nil
+//
+// 返回:
+//   - image.Image: 二维码图片
+//   - error: 内容为空或生成失败时返回错误
+//
+// GenerateQRCodeImage generates a QR code image.
+// Parameters:
+//   - content: QR code content, e.g. a payment or invite link
+//   - size: Image side length in pixels
+//   - opts: Generation options, may be nil
+//
+// Returns:
+//   - image.Image: The QR code image
+//   - error: Returns an error if the content is empty or generation fails
+func GenerateQRCodeImage(content string, size int, opts *Options) (image.Image, error) {
+	if content == "" {
+		return nil, ErrEmptyContent
+	}
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	level := opts.Level
+	if level == LevelDefault {
+		level = LevelMedium
+	}
+	// Logo 会遮挡部分模块，需要更高的纠错级别
+	if opts.Logo != nil && level < LevelHigh {
+		level = LevelHigh
+	}
+
+	qr, err := qrcode.New(content, recoveryLevel(level))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGenerateQRCode, err)
+	}
+	if opts.Foreground != nil {
+		qr.ForegroundColor = opts.Foreground
+	}
+	if opts.Background != nil {
+		qr.BackgroundColor = opts.Background
+	}
+	qr.DisableBorder = opts.DisableBorder
+
+	img := qr.Image(size)
+	if opts.Logo == nil {
+		return img, nil
+	}
+	return overlayLogo(img, opts.Logo, opts.LogoRatio, qr.BackgroundColor), nil
+}
+
+// GenerateQRCode 生成 PNG 格式的二维码
+// 参数:
+//   - content: 二维码内容
+//   - size: 图片边长（像素）
+//   - opts: 生成选项，可以为 nil
+//
+// 返回:
+//   - []byte: PNG 图片数据
+//   - error: 内容为空或生成失败时返回错误
+//
+// GenerateQRCode generates a QR code in PNG format.
+// Parameters:
+//   - content: QR code content
+//   - size: Image side length in pixels
+//   - opts: Generation options, may be nil
+//
+// Returns:
+//   - []byte: PNG image data
+//   - error: Returns an error if the content is empty or generation fails
+func GenerateQRCode(content string, size int, opts *Options) ([]byte, error) {
+	img, err := GenerateQRCodeImage(content, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := imageutil.EncodePNG(img)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGenerateQRCode, err)
+	}
+	return data, nil
+}
+
+// GenerateQRCodeDataURL 生成 base64 data URL 格式的二维码，可直接用于 HTML 的 img 标签
+// 例如 "data:image/png;base64,iVBORw0KGgo..."
+//
+// GenerateQRCodeDataURL generates a QR code as a base64 data URL that can be used directly in an HTML img tag,
+// e.g. "data:image/png;base64,iVBORw0KGgo...".
+func GenerateQRCodeDataURL(content string, size int, opts *Options) (string, error) {
+	data, err := GenerateQRCode(content, size, opts)
+	if err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// overlayLogo 将 Logo 缩放后绘制在二维码中心，并在 Logo 周围留出背景色边框
+//
+// overlayLogo scales the logo and draws it at the center of the QR code, leaving a background-colored margin around it
+func overlayLogo(qr image.Image, logo image.Image, ratio float64, bg color.Color) image.Image {
+	if ratio <= 0 {
+		ratio = DefaultLogoRatio
+	}
+	if ratio > MaxLogoRatio {
+		ratio = MaxLogoRatio
+	}
+
+	b := qr.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), qr, b.Min, draw.Src)
+
+	side := int(float64(min(b.Dx(), b.Dy())) * ratio)
+	if side <= 0 {
+		return dst
+	}
+	scaled := imageutil.Fit(logo, side, side, nil)
+	lb := scaled.Bounds()
+
+	// 绘制背景边框，让 Logo 与二维码模块之间有清晰的分隔
+	pad := max(2, side/20)
+	x0 := (b.Dx() - lb.Dx()) / 2
+	y0 := (b.Dy() - lb.Dy()) / 2
+	frame := image.Rect(x0-pad, y0-pad, x0+lb.Dx()+pad, y0+lb.Dy()+pad)
+	draw.Draw(dst, frame, image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(dst, image.Rect(x0, y0, x0+lb.Dx(), y0+lb.Dy()), scaled, lb.Min, draw.Over)
+	return dst
+}
+
+// recoveryLevel 将纠错级别转换为底层库的类型
+//
+// recoveryLevel converts the error-correction level to the underlying library type
+func recoveryLevel(l Level) qrcode.RecoveryLevel {
+	switch l {
+	case LevelLow:
+		return qrcode.Low
+	case LevelQuartile:
+		return qrcode.High
+	case LevelHigh:
+		return qrcode.Highest
+	}
+	return qrcode.Medium
+}
@@ -0,0 +1,130 @@
+package queueutil
+
+// Deque 基于可增长环形缓冲区的双端队列，两端的操作均为均摊 O(1)
+// 注意: Deque 不是并发安全的，多个 goroutine 使用时需要自行加锁
+//
+// Deque is a double-ended queue backed by a growable ring buffer, with amortized O(1) operations at both ends.
+// Note: Deque is not safe for concurrent use; guard it with a lock when shared between goroutines.
+type Deque[T any] struct {
+	buf  []T
+	head int
+	size int
+}
+
+// NewDeque 创建双端队列
+// 参数:
+//   - capacity: 初始容量，不足时自动扩容
+//
+// 返回:
+//   - *Deque[T]: 双端队列
+//
+// NewDeque creates a deque.
+// Parameters:
+//   - capacity: Initial capacity; it grows automatically
+//
+// Returns:
+//   - *Deque[T]: The deque
+func NewDeque[T any](capacity int) *Deque[T] {
+	return &Deque[T]{buf: make([]T, max(capacity, 1))}
+}
+
+// PushBack 在队尾添加元素
+//
+// PushBack adds an element at the back.
+func (d *Deque[T]) PushBack(v T) {
+	d.grow()
+	d.buf[(d.head+d.size)%len(d.buf)] = v
+	d.size++
+}
+
+// PushFront 在队首添加元素
+//
+// PushFront adds an element at the front.
+func (d *Deque[T]) PushFront(v T) {
+	d.grow()
+	d.head = (d.head - 1 + len(d.buf)) % len(d.buf)
+	d.buf[d.head] = v
+	d.size++
+}
+
+// PopFront 取出队首元素，队列为空时返回 false
+//
+// PopFront removes the front element, returning false if the deque is empty.
+func (d *Deque[T]) PopFront() (T, bool) {
+	var zero T
+	if d.size == 0 {
+		return zero, false
+	}
+	v := d.buf[d.head]
+	d.buf[d.head] = zero
+	d.head = (d.head + 1) % len(d.buf)
+	d.size--
+	return v, true
+}
+
+// PopBack 取出队尾元素，队列为空时返回 false
+//
+// PopBack removes the back element, returning false if the deque is empty.
+func (d *Deque[T]) PopBack() (T, bool) {
+	var zero T
+	if d.size == 0 {
+		return zero, false
+	}
+	i := (d.head + d.size - 1) % len(d.buf)
+	v := d.buf[i]
+	d.buf[i] = zero
+	d.size--
+	return v, true
+}
+
+// Front 返回队首元素但不取出
+//
+// Front returns the front element without removing it.
+func (d *Deque[T]) Front() (T, bool) {
+	if d.size == 0 {
+		var zero T
+		return zero, false
+	}
+	return d.buf[d.head], true
+}
+
+// Back 返回队尾元素但不取出
+//
+// Back returns the back element without removing it.
+func (d *Deque[T]) Back() (T, bool) {
+	if d.size == 0 {
+		var zero T
+		return zero, false
+	}
+	return d.buf[(d.head+d.size-1)%len(d.buf)], true
+}
+
+// At 返回从队首起第 i 个元素，i 越界时 panic
+//
+// At returns the i-th element from the front; it panics if i is out of range.
+func (d *Deque[T]) At(i int) T {
+	if i < 0 || i >= d.size {
+		panic("queueutil: Deque index out of range")
+	}
+	return d.buf[(d.head+i)%len(d.buf)]
+}
+
+// Len 返回元素数
+//
+// Len returns the number of elements.
+func (d *Deque[T]) Len() int {
+	return d.size
+}
+
+// grow 缓冲区已满时扩容为两倍
+//
+// grow doubles the buffer when it is full
+func (d *Deque[T]) grow() {
+	if d.size < len(d.buf) {
+		return
+	}
+	buf := make([]T, len(d.buf)*2)
+	n := copy(buf, d.buf[d.head:])
+	copy(buf[n:], d.buf[:d.head])
+	d.buf, d.head = buf, 0
+}
@@ -0,0 +1,96 @@
+package queueutil
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// PriorityQueue 基于二叉堆的优先级队列，并发安全
+//
+// PriorityQueue is a binary-heap priority queue, safe for concurrent use.
+type PriorityQueue[T any] struct {
+	mu sync.Mutex
+	h  priorityHeap[T]
+}
+
+// NewPriorityQueue 创建优先级队列
+// 参数:
+//   - less: 比较函数，less(a, b) 为 true 时 a 先出队；例如 func(a, b Job) bool { return a.Priority > b.Priority }
+//
+// 返回:
+//   - *PriorityQueue[T]: 优先级队列
+//
+// NewPriorityQueue creates a priority queue.
+// Parameters:
+//   - less: Comparator; a is dequeued first when less(a, b) is true, e.g. func(a, b Job) bool { return a.Priority > b.Priority }
+//
+// Returns:
+//   - *PriorityQueue[T]: The priority queue
+func NewPriorityQueue[T any](less func(a, b T) bool) *PriorityQueue[T] {
+	return &PriorityQueue[T]{h: priorityHeap[T]{less: less}}
+}
+
+// Push 添加元素，O(log n)
+//
+// Push adds an element in O(log n).
+func (q *PriorityQueue[T]) Push(v T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	heap.Push(&q.h, v)
+}
+
+// Pop 取出优先级最高的元素，O(log n)，队列为空时返回 false
+//
+// Pop removes the highest-priority element in O(log n), returning false if the queue is empty.
+func (q *PriorityQueue[T]) Pop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return heap.Pop(&q.h).(T), true
+}
+
+// Peek 返回优先级最高的元素但不取出，队列为空时返回 false
+//
+// Peek returns the highest-priority element without removing it, returning false if the queue is empty.
+func (q *PriorityQueue[T]) Peek() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.h.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return q.h.items[0], true
+}
+
+// Len 返回元素数
+//
+// Len returns the number of elements.
+func (q *PriorityQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.h.items)
+}
+
+// priorityHeap 实现 heap.Interface
+//
+// priorityHeap implements heap.Interface
+type priorityHeap[T any] struct {
+	items []T
+	less  func(a, b T) bool
+}
+
+func (h *priorityHeap[T]) Len() int           { return len(h.items) }
+func (h *priorityHeap[T]) Less(i, j int) bool { return h.less(h.items[i], h.items[j]) }
+func (h *priorityHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *priorityHeap[T]) Push(x any)         { h.items = append(h.items, x.(T)) }
+func (h *priorityHeap[T]) Pop() any {
+	n := len(h.items) - 1
+	v := h.items[n]
+	var zero T
+	h.items[n] = zero
+	h.items = h.items[:n]
+	return v
+}
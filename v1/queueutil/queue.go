@@ -0,0 +1,198 @@
+// Package queueutil 提供有界队列、优先级队列和双端队列等泛型容器，用于后台任务处理
+//
+// Package queueutil provides generic containers such as bounded queues, priority queues and deques for background job processing.
+package queueutil
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrQueueClosed 表示队列已关闭
+	//
+	// ErrQueueClosed indicates that the queue is closed
+	ErrQueueClosed = errors.New("queue closed")
+)
+
+// Queue 基于环形缓冲区的有界 FIFO 队列，并发安全，同时提供阻塞和非阻塞操作
+//
+// Queue is a bounded FIFO queue backed by a ring buffer, safe for concurrent use, with both blocking and non-blocking operations.
+type Queue[T any] struct {
+	mu     sync.Mutex
+	buf    []T
+	head   int
+	size   int
+	closed bool
+	// changed 队列状态变化时关闭并替换，用于唤醒阻塞的调用方
+	changed chan struct{}
+}
+
+// NewQueue 创建有界队列
+// 参数:
+//   - capacity: 容量，小于 1 时按 1 处理
+//
+// 返回:
+//   - *Queue[T]: 队列
+//
+// NewQueue creates a bounded queue.
+// Parameters:
+//   - capacity: The capacity, treated as 1 when less than 1
+//
+// Returns:
+//   - *Queue[T]: The queue
+func NewQueue[T any](capacity int) *Queue[T] {
+	return &Queue[T]{buf: make([]T, max(capacity, 1)), changed: make(chan struct{})}
+}
+
+// notify 唤醒所有等待者，调用方需持有锁
+//
+// notify wakes all waiters; the caller must hold the lock
+func (q *Queue[T]) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// TryPush 非阻塞地入队
+// 返回:
+//   - bool: 队列已满或已关闭时返回 false
+//
+// TryPush enqueues without blocking.
+// Returns:
+//   - bool: false if the queue is full or closed
+func (q *Queue[T]) TryPush(v T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || q.size == len(q.buf) {
+		return false
+	}
+	q.buf[(q.head+q.size)%len(q.buf)] = v
+	q.size++
+	q.notify()
+	return true
+}
+
+// Push 入队，队列已满时阻塞直到有空位
+// 返回:
+//   - error: 队列已关闭时返回 ErrQueueClosed，ctx 结束时返回 ctx.Err()
+//
+// Push enqueues, blocking while the queue is full.
+// Returns:
+//   - error: ErrQueueClosed if the queue is closed, or ctx.Err() when ctx is done
+func (q *Queue[T]) Push(ctx context.Context, v T) error {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return ErrQueueClosed
+		}
+		if q.size < len(q.buf) {
+			q.buf[(q.head+q.size)%len(q.buf)] = v
+			q.size++
+			q.notify()
+			q.mu.Unlock()
+			return nil
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// TryPop 非阻塞地出队
+// 返回:
+//   - T: 队首元素
+//   - bool: 队列为空时返回 false
+//
+// TryPop dequeues without blocking.
+// Returns:
+//   - T: The head element
+//   - bool: false if the queue is empty
+func (q *Queue[T]) TryPop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pop()
+}
+
+// Pop 出队，队列为空时阻塞直到有元素；关闭后仍可取出剩余的元素
+// 返回:
+//   - T: 队首元素
+//   - error: 队列已关闭且为空时返回 ErrQueueClosed，ctx 结束时返回 ctx.Err()
+//
+// Pop dequeues, blocking while the queue is empty; remaining elements can still be taken after Close.
+// Returns:
+//   - T: The head element
+//   - error: ErrQueueClosed if the queue is closed and empty, or ctx.Err() when ctx is done
+func (q *Queue[T]) Pop(ctx context.Context) (T, error) {
+	for {
+		q.mu.Lock()
+		if v, ok := q.pop(); ok {
+			q.mu.Unlock()
+			return v, nil
+		}
+		if q.closed {
+			q.mu.Unlock()
+			var zero T
+			return zero, ErrQueueClosed
+		}
+		changed := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+// pop 取出队首元素，调用方需持有锁
+//
+// pop removes the head element; the caller must hold the lock
+func (q *Queue[T]) pop() (T, bool) {
+	var zero T
+	if q.size == 0 {
+		return zero, false
+	}
+	v := q.buf[q.head]
+	q.buf[q.head] = zero
+	q.head = (q.head + 1) % len(q.buf)
+	q.size--
+	q.notify()
+	return v, true
+}
+
+// Len 返回队列中的元素数
+//
+// Len returns the number of elements in the queue.
+func (q *Queue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.size
+}
+
+// Cap 返回队列容量
+//
+// Cap returns the capacity of the queue.
+func (q *Queue[T]) Cap() int {
+	return len(q.buf)
+}
+
+// Close 关闭队列：之后的入队操作返回 ErrQueueClosed，阻塞的调用方会被唤醒，剩余元素仍可出队；重复调用无影响
+//
+// Close closes the queue: later pushes return ErrQueueClosed, blocked callers are woken and remaining elements can still be popped; repeated calls are no-ops.
+func (q *Queue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !q.closed {
+		q.closed = true
+		q.notify()
+	}
+}
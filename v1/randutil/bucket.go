@@ -0,0 +1,185 @@
+package randutil
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"math/bits"
+)
+
+// ErrInvalidWeights 表示分桶权重无效（为空或总和为 0）
+//
+// ErrInvalidWeights indicates invalid bucket weights (empty or summing to 0).
+var ErrInvalidWeights = errors.New("bucket weights must be non-empty with a positive sum")
+
+// Bucket 实验分桶
+// Name: 分桶名称，例如 "control"、"variant_a"
+// Weight: 权重，各分桶按权重比例分配流量，0 表示不分配
+//
+// Bucket is an experiment bucket.
+// Name: Bucket name, e.g. "control", "variant_a"
+// Weight: Weight; traffic is split in proportion to the weights, 0 means no traffic
+type Bucket struct {
+	Name   string
+	Weight uint32
+}
+
+// Exposure 曝光事件，在用户被分配到分桶时产生，用于记录实验曝光日志
+// Experiment: 实验名称
+// UserID: 用户 ID
+// Bucket: 分配到的分桶名称
+//
+// Exposure is an exposure event, produced when a user is assigned to a bucket, for experiment exposure logging.
+// Experiment: Experiment name
+// UserID: User ID
+// Bucket: Name of the assigned bucket
+type Exposure struct {
+	Experiment string
+	UserID     string
+	Bucket     string
+}
+
+// AssignBucket 根据用户 ID 和实验名称的稳定哈希为用户分配分桶，同一用户在同一实验中总是落在同一分桶，不同实验之间的分配相互独立
+// 修改分桶或权重会使部分用户改变分桶，正在进行的实验应避免修改；需要重新分流时可更换实验名称
+// 参数:
+//   - userID: 用户 ID
+//   - experiment: 实验名称
+//   - buckets: 分桶及权重，顺序参与计算
+//
+// 返回:
+//   - string: 分桶名称
+//   - error: 权重为空或总和为 0 时返回 ErrInvalidWeights
+//
+// AssignBucket assigns a user to a bucket by a stable hash of the user ID and experiment name; a user always lands in the same bucket of an experiment, and assignments of different experiments are independent.
+// Changing the buckets or weights moves some users to other buckets, so avoid it for running experiments; rename the experiment to reshuffle deliberately.
+// Parameters:
+//   - userID: User ID
+//   - experiment: Experiment name
+//   - buckets: Buckets and weights; their order matters
+//
+// Returns:
+//   - string: The bucket name
+//   - error: Returns ErrInvalidWeights if the weights are empty or sum to 0
+func AssignBucket(userID, experiment string, buckets []Bucket) (string, error) {
+	total, err := totalWeight(buckets)
+	if err != nil {
+		return "", err
+	}
+	return pickBucket(buckets, total, stableHash(experiment, userID)), nil
+}
+
+// ExperimentOptions 实验选项
+// OnExposure: 曝光回调，每次分配都会调用，可用于写入曝光日志；为 nil 时不回调
+//
+// ExperimentOptions contains experiment options.
+// OnExposure: Exposure callback invoked on every assignment, e.g. to write exposure logs; nil disables it
+type ExperimentOptions struct {
+	OnExposure func(Exposure)
+}
+
+// Experiment A/B 实验，预先校验分桶权重，并在分配时触发曝光回调
+// 并发安全
+//
+// Experiment is an A/B experiment that validates bucket weights up front and fires an exposure callback on assignment.
+// It is safe for concurrent use.
+type Experiment struct {
+	name       string
+	buckets    []Bucket
+	total      uint64
+	onExposure func(Exposure)
+}
+
+// NewExperiment 创建 A/B 实验
+// 参数:
+//   - name: 实验名称，参与哈希计算，修改后所有用户会重新分配
+//   - buckets: 分桶及权重
+//   - opts: 实验选项，可为 nil
+//
+// 返回:
+//   - *Experiment: 实验
+//   - error: 权重为空或总和为 0 时返回 ErrInvalidWeights
+//
+// NewExperiment creates an A/B experiment.
+// Parameters:
+//   - name: Experiment name; it is part of the hash, so changing it reassigns every user
+//   - buckets: Buckets and weights
+//   - opts: Experiment options, can be nil
+//
+// Returns:
+//   - *Experiment: The experiment
+//   - error: Returns ErrInvalidWeights if the weights are empty or sum to 0
+func NewExperiment(name string, buckets []Bucket, opts *ExperimentOptions) (*Experiment, error) {
+	total, err := totalWeight(buckets)
+	if err != nil {
+		return nil, err
+	}
+	e := &Experiment{name: name, buckets: append([]Bucket(nil), buckets...), total: total}
+	if opts != nil {
+		e.onExposure = opts.OnExposure
+	}
+	return e, nil
+}
+
+// Name 返回实验名称
+//
+// Name returns the experiment name.
+func (e *Experiment) Name() string {
+	return e.name
+}
+
+// Assign 为用户分配分桶并触发曝光回调
+//
+// Assign assigns the user to a bucket and fires the exposure callback.
+func (e *Experiment) Assign(userID string) string {
+	bucket := e.Peek(userID)
+	if e.onExposure != nil {
+		e.onExposure(Exposure{Experiment: e.name, UserID: userID, Bucket: bucket})
+	}
+	return bucket
+}
+
+// Peek 返回用户所在的分桶但不触发曝光回调，用于只读场景（例如后台查询），避免污染曝光数据
+//
+// Peek returns the user's bucket without firing the exposure callback, for read-only uses (e.g. admin lookups) that must not pollute exposure data.
+func (e *Experiment) Peek(userID string) string {
+	return pickBucket(e.buckets, e.total, stableHash(e.name, userID))
+}
+
+// totalWeight 计算权重总和
+//
+// totalWeight computes the sum of the weights
+func totalWeight(buckets []Bucket) (uint64, error) {
+	var total uint64
+	for _, b := range buckets {
+		total += uint64(b.Weight)
+	}
+	if total == 0 {
+		return 0, ErrInvalidWeights
+	}
+	return total, nil
+}
+
+// stableHash 计算实验名称和用户 ID 的稳定哈希，结果不随进程或平台变化
+//
+// stableHash computes a stable hash of the experiment name and user ID that does not vary across processes or platforms
+func stableHash(experiment, userID string) uint64 {
+	h := sha256.New()
+	h.Write([]byte(experiment))
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+	return binary.BigEndian.Uint64(h.Sum(nil))
+}
+
+// pickBucket 将哈希值映射到 [0, total) 后按权重选择分桶
+//
+// pickBucket maps the hash onto [0, total) and selects a bucket by weight
+func pickBucket(buckets []Bucket, total, hash uint64) string {
+	point, _ := bits.Mul64(hash, total)
+	for _, b := range buckets {
+		if point < uint64(b.Weight) {
+			return b.Name
+		}
+		point -= uint64(b.Weight)
+	}
+	return buckets[len(buckets)-1].Name
+}
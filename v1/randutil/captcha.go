@@ -0,0 +1,170 @@
+package randutil
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"math/big"
+	"strings"
+	"unicode"
+)
+
+// CaptchaCharset 验证码字符集
+//
+// CaptchaCharset is the character set of a captcha code.
+type CaptchaCharset int
+
+const (
+	// CaptchaAlphanumeric 数字和大写字母，去掉易混淆的 0/O、1/I/L（默认）
+	//
+	// CaptchaAlphanumeric uses digits and uppercase letters without the confusable 0/O and 1/I/L (the default)
+	CaptchaAlphanumeric CaptchaCharset = iota
+	// CaptchaDigits 仅数字
+	//
+	// CaptchaDigits uses digits only
+	CaptchaDigits
+)
+
+// captchaAlphabets 各字符集的字符
+//
+// captchaAlphabets holds the characters of each charset.
+var captchaAlphabets = map[CaptchaCharset]string{
+	CaptchaAlphanumeric: "23456789ABCDEFGHJKMNPQRSTUVWXYZ",
+	CaptchaDigits:       "0123456789",
+}
+
+// CaptchaOptions GenerateCaptchaCode 的选项
+// Charset: 字符集，默认 CaptchaAlphanumeric
+// GroupSize: 展示形式中每组的字符数，例如 4 时显示为 "ABCD EFGH"；为 0 时不分组
+// GroupSeparator: 展示形式中组之间的分隔符，默认 " "
+//
+// CaptchaOptions contains options for GenerateCaptchaCode.
+// Charset: Character set; CaptchaAlphanumeric by default
+// GroupSize: Characters per group in the display form, e.g. 4 shows "ABCD EFGH"; no grouping when 0
+// GroupSeparator: Separator between groups in the display form; " " by default
+type CaptchaOptions struct {
+	Charset        CaptchaCharset
+	GroupSize      int
+	GroupSeparator string
+}
+
+// CaptchaCode 生成的验证码
+// Display: 展示给用户的形式，可能包含分组分隔符
+// Normalized: 用于比较的规范形式，与 NormalizeCaptchaCode 处理用户输入的结果一致，应保存这个值
+//
+// CaptchaCode is a generated captcha code.
+// Display: The form shown to users, possibly containing group separators
+// Normalized: The canonical form for comparison, matching what NormalizeCaptchaCode produces from user input; this is the value to store
+type CaptchaCode struct {
+	Display    string
+	Normalized string
+}
+
+// GenerateCaptchaCode 使用加密安全的随机数生成验证码，字母数字字符集中不包含易混淆的 0/O、1/I/L
+// 参数:
+//   - length: 验证码长度（不含分隔符），必须为正整数
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - CaptchaCode: 展示形式和规范形式
+//   - error: 长度无效时返回 ErrInvalidLength，读取随机数失败时返回错误
+//
+// GenerateCaptchaCode generates a captcha code using cryptographically secure random numbers; the alphanumeric charset excludes the confusable 0/O and 1/I/L.
+// Parameters:
+//   - length: Code length excluding separators, must be a positive integer
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - CaptchaCode: The display and normalized forms
+//   - error: Returns ErrInvalidLength if the length is invalid, or an error if reading random numbers fails
+func GenerateCaptchaCode(length int, opts *CaptchaOptions) (CaptchaCode, error) {
+	if length <= 0 {
+		return CaptchaCode{}, ErrInvalidLength
+	}
+	var o CaptchaOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.GroupSeparator == "" {
+		o.GroupSeparator = " "
+	}
+	alphabet, ok := captchaAlphabets[o.Charset]
+	if !ok {
+		alphabet = captchaAlphabets[CaptchaAlphanumeric]
+	}
+	size := big.NewInt(int64(len(alphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return CaptchaCode{}, err
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	normalized := string(code)
+	if o.GroupSize <= 0 || o.GroupSize >= length {
+		return CaptchaCode{Display: normalized, Normalized: normalized}, nil
+	}
+	var b strings.Builder
+	for i := 0; i < length; i += o.GroupSize {
+		if i > 0 {
+			b.WriteString(o.GroupSeparator)
+		}
+		b.WriteString(normalized[i:min(i+o.GroupSize, length)])
+	}
+	return CaptchaCode{Display: b.String(), Normalized: normalized}, nil
+}
+
+// NormalizeCaptchaCode 把用户输入转换为规范形式：去掉空白和连字符，转换为大写，并把易混淆的字母 O、I、L 分别还原为数字 0、1、1
+// 由于字母数字字符集不包含这些字符，还原不会造成误判，同时让仅数字的验证码容忍用户输错
+// 参数:
+//   - input: 用户输入
+//
+// 返回:
+//   - string: 规范形式
+//
+// NormalizeCaptchaCode converts user input into the canonical form: whitespace and hyphens are removed, letters are uppercased, and the confusable letters O, I and L are mapped back to the digits 0, 1 and 1.
+// As the alphanumeric charset contains none of these characters the mapping never causes false matches, while digits-only codes tolerate such typos.
+// Parameters:
+//   - input: The user input
+//
+// Returns:
+//   - string: The canonical form
+func NormalizeCaptchaCode(input string) string {
+	var b strings.Builder
+	b.Grow(len(input))
+	for _, r := range input {
+		if unicode.IsSpace(r) || r == '-' {
+			continue
+		}
+		switch r = unicode.ToUpper(r); r {
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// VerifyCaptchaCode 以恒定时间比较规范形式与用户输入，用户输入会先经过 NormalizeCaptchaCode 处理
+// 参数:
+//   - normalized: 保存的规范形式，即 CaptchaCode.Normalized
+//   - input: 用户输入
+//
+// 返回:
+//   - bool: 是否匹配
+//
+// VerifyCaptchaCode compares a normalized code with user input in constant time, normalizing the input with NormalizeCaptchaCode first.
+// Parameters:
+//   - normalized: The stored canonical form, i.e. CaptchaCode.Normalized
+//   - input: The user input
+//
+// Returns:
+//   - bool: Whether they match
+func VerifyCaptchaCode(normalized, input string) bool {
+	if normalized == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(normalized), []byte(NormalizeCaptchaCode(input))) == 1
+}
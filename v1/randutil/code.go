@@ -0,0 +1,45 @@
+package randutil
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+// ErrInvalidLength 表示无效的长度参数
+//
+// ErrInvalidLength indicates an invalid length parameter.
+var ErrInvalidLength = errors.New("length must be a positive integer")
+
+// GenerateNumericCode 使用加密安全的随机数生成指定长度的数字验证码
+// 与 GenerateRandomDigits 不同，结果以字符串返回并且允许以 0 开头，适用于短信、邮件验证码
+// 参数:
+//   - length: 验证码长度，必须为正整数
+//
+// 返回:
+//   - string: 生成的验证码
+//   - error: 如果参数无效或读取随机数失败，返回错误
+//
+// GenerateNumericCode generates a numeric verification code of the given length using cryptographically secure random numbers.
+// Unlike GenerateRandomDigits, the result is returned as a string and may start with 0, suitable for SMS and email verification codes.
+// Parameters:
+//   - length: Code length, must be a positive integer
+//
+// Returns:
+//   - string: The generated code
+//   - error: Returns an error if the parameter is invalid or reading random numbers fails
+func GenerateNumericCode(length int) (string, error) {
+	if length <= 0 {
+		return "", ErrInvalidLength
+	}
+	ten := big.NewInt(10)
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, ten)
+		if err != nil {
+			return "", err
+		}
+		code[i] = byte('0' + n.Int64())
+	}
+	return string(code), nil
+}
@@ -0,0 +1,169 @@
+package randutil
+
+import (
+	"bufio"
+	"crypto/rand"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// DefaultPassphraseSeparator 默认的单词分隔符
+//
+// DefaultPassphraseSeparator is the default word separator.
+const DefaultPassphraseSeparator = "-"
+
+// ErrInvalidWordlist 表示单词表无效（少于 2 个单词、包含空单词或重复单词）
+//
+// ErrInvalidWordlist indicates an invalid wordlist (fewer than 2 words, or containing empty or duplicate words).
+var ErrInvalidWordlist = errors.New("invalid wordlist")
+
+//go:embed wordlist_en.txt
+var defaultWordlistData string
+
+// defaultWordlist 解析后的内置单词表
+//
+// defaultWordlist is the parsed built-in wordlist.
+var defaultWordlist = sync.OnceValue(func() []string {
+	return strings.Fields(defaultWordlistData)
+})
+
+// DefaultWordlist 返回内置的英文单词表的副本
+// 按 EFF 单词表的思路挑选：均为 3 到 8 个小写字母的常见具体名词或形容词，避免生僻、易拼错和不雅的词，每个单词约 10 比特熵
+//
+// DefaultWordlist returns a copy of the built-in English wordlist.
+// It follows the approach of the EFF wordlists: common, concrete nouns and adjectives of 3 to 8 lowercase letters, avoiding obscure, easily misspelled or offensive words, giving about 10 bits of entropy per word.
+func DefaultWordlist() []string {
+	return append([]string(nil), defaultWordlist()...)
+}
+
+// GeneratePassphrase 使用加密安全的随机数从单词表中均匀选取单词组成口令，适用于需要用户手动输入的恢复码
+// 安全性取决于单词数和单词表大小，可以用 PassphraseEntropy 计算；使用内置单词表时 6 个单词约 60 比特
+// 参数:
+//   - words: 单词数，必须为正整数
+//   - separator: 单词分隔符，为空时使用 DefaultPassphraseSeparator
+//   - wordlist: 单词表，为 nil 时使用内置英文单词表；面向中文用户时可以传入拼音单词表，例如 []string{"anquan", "beijing", ...}
+//
+// 返回:
+//   - string: 生成的口令
+//   - error: 单词数无效时返回 ErrInvalidLength，单词表无效时返回 ErrInvalidWordlist，读取随机数失败时返回错误
+//
+// GeneratePassphrase builds a passphrase from words picked uniformly from a wordlist using cryptographically secure random numbers, suitable for recovery codes that users must type.
+// Its strength depends on the number of words and the wordlist size and can be computed with PassphraseEntropy; 6 words from the built-in wordlist give about 60 bits.
+// Parameters:
+//   - words: Number of words, must be a positive integer
+//   - separator: Word separator; DefaultPassphraseSeparator when empty
+//   - wordlist: The wordlist; the built-in English wordlist when nil. For Chinese users a pinyin wordlist may be passed, e.g. []string{"anquan", "beijing", ...}
+//
+// Returns:
+//   - string: The generated passphrase
+//   - error: Returns ErrInvalidLength if words is invalid, ErrInvalidWordlist if the wordlist is invalid, or an error if reading random numbers fails
+func GeneratePassphrase(words int, separator string, wordlist []string) (string, error) {
+	if words <= 0 {
+		return "", ErrInvalidLength
+	}
+	if separator == "" {
+		separator = DefaultPassphraseSeparator
+	}
+	if wordlist == nil {
+		wordlist = defaultWordlist()
+	} else if err := validateWordlist(wordlist); err != nil {
+		return "", err
+	}
+	size := big.NewInt(int64(len(wordlist)))
+	picked := make([]string, words)
+	for i := range picked {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		picked[i] = wordlist[n.Int64()]
+	}
+	return strings.Join(picked, separator), nil
+}
+
+// PassphraseEntropy 返回从大小为 wordlistSize 的单词表中均匀选取 words 个单词组成的口令的熵（比特）
+// 参数:
+//   - words: 单词数
+//   - wordlistSize: 单词表大小，为 0 时使用内置单词表的大小
+//
+// 返回:
+//   - float64: 熵，单位为比特
+//
+// PassphraseEntropy returns the entropy in bits of a passphrase of words words picked uniformly from a wordlist of wordlistSize words.
+// Parameters:
+//   - words: Number of words
+//   - wordlistSize: Wordlist size; the size of the built-in wordlist when 0
+//
+// Returns:
+//   - float64: The entropy in bits
+func PassphraseEntropy(words, wordlistSize int) float64 {
+	if wordlistSize == 0 {
+		wordlistSize = len(defaultWordlist())
+	}
+	if words <= 0 || wordlistSize < 2 {
+		return 0
+	}
+	return float64(words) * math.Log2(float64(wordlistSize))
+}
+
+// ParseWordlist 读取每行一个单词的单词表，例如自定义的拼音单词表或 EFF 发布的单词表文件
+// 忽略空行和以 # 开头的行；行中有多列时取最后一列，因此可以直接读取 "11111\tabacus" 格式的骰子单词表
+// 参数:
+//   - r: 单词表内容
+//
+// 返回:
+//   - []string: 单词表
+//   - error: 读取失败时返回错误，单词表无效时返回 ErrInvalidWordlist
+//
+// ParseWordlist reads a wordlist with one word per line, such as a custom pinyin wordlist or a wordlist file published by the EFF.
+// Blank lines and lines starting with # are ignored; when a line has several columns the last one is used, so dice wordlists in "11111\tabacus" form can be read directly.
+// Parameters:
+//   - r: The wordlist content
+//
+// Returns:
+//   - []string: The wordlist
+//   - error: Returns an error if reading fails, or ErrInvalidWordlist if the wordlist is invalid
+func ParseWordlist(r io.Reader) ([]string, error) {
+	var list []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		list = append(list, fields[len(fields)-1])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := validateWordlist(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// validateWordlist 检查单词表至少有 2 个单词且没有空单词或重复单词，重复单词会使选取不均匀
+//
+// validateWordlist checks that a wordlist has at least 2 words and no empty or duplicate words, as duplicates would skew the selection.
+func validateWordlist(wordlist []string) error {
+	if len(wordlist) < 2 {
+		return fmt.Errorf("%w: need at least 2 words, got %d", ErrInvalidWordlist, len(wordlist))
+	}
+	seen := make(map[string]struct{}, len(wordlist))
+	for _, w := range wordlist {
+		if w == "" {
+			return fmt.Errorf("%w: empty word", ErrInvalidWordlist)
+		}
+		if _, ok := seen[w]; ok {
+			return fmt.Errorf("%w: duplicate word %q", ErrInvalidWordlist, w)
+		}
+		seen[w] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package randutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPoolBufferSize 熵池每次生成的随机字节数
+	//
+	// DefaultPoolBufferSize is the number of random bytes the entropy pool generates per refill
+	DefaultPoolBufferSize = 4096
+	// DefaultPoolReseedInterval 熵池从 crypto/rand 重新获取种子的默认间隔
+	//
+	// DefaultPoolReseedInterval is the default interval at which the entropy pool reseeds from crypto/rand
+	DefaultPoolReseedInterval = time.Minute
+	// DefaultPoolReseedBytes 熵池输出多少字节后从 crypto/rand 重新获取种子
+	//
+	// DefaultPoolReseedBytes is the number of output bytes after which the entropy pool reseeds from crypto/rand
+	DefaultPoolReseedBytes = 64 << 20
+
+	// poolKeySize AES-256 密钥长度
+	//
+	// poolKeySize is the AES-256 key size
+	poolKeySize = 32
+)
+
+// PoolOptions 熵池选项
+// BufferSize: 每次生成的随机字节数，默认 DefaultPoolBufferSize
+// ReseedInterval: 重新获取种子的间隔，默认 DefaultPoolReseedInterval
+// ReseedBytes: 输出多少字节后重新获取种子，默认 DefaultPoolReseedBytes
+//
+// PoolOptions contains options for the entropy pool.
+// BufferSize: Number of random bytes generated per refill, defaults to DefaultPoolBufferSize
+// ReseedInterval: Reseed interval, defaults to DefaultPoolReseedInterval
+// ReseedBytes: Number of output bytes after which to reseed, defaults to DefaultPoolReseedBytes
+type PoolOptions struct {
+	BufferSize     int
+	ReseedInterval time.Duration
+	ReseedBytes    int
+}
+
+// EntropyPool 带缓冲的安全随机数池，适合每个请求生成 nonce 等高频场景
+// 以 crypto/rand 的 32 字节种子作为 AES-256-CTR 密钥扩展出随机字节，每次填充缓冲区后立即用密钥流的开头替换密钥
+// （快速密钥擦除），即使内存泄露也无法推算之前的输出；并按时间和输出量定期从 crypto/rand 重新获取种子
+//
+// EntropyPool is a buffered secure random pool suited to hot paths such as per-request nonce generation.
+// It expands a 32-byte crypto/rand seed with AES-256-CTR and replaces the key with the start of the keystream on every refill
+// (fast key erasure), so a memory disclosure cannot reveal earlier output; it also reseeds from crypto/rand periodically by time and output volume.
+type EntropyPool struct {
+	mu       sync.Mutex
+	opts     PoolOptions
+	key      [poolKeySize]byte
+	buf      []byte
+	pos      int
+	output   int
+	seededAt time.Time
+}
+
+var (
+	// defaultPool 包级函数使用的熵池
+	//
+	// defaultPool is the entropy pool used by the package level functions
+	defaultPool     *EntropyPool
+	defaultPoolErr  error
+	defaultPoolOnce sync.Once
+)
+
+// NewEntropyPool 创建熵池
+// 参数:
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *EntropyPool: 熵池，可以并发使用
+//   - error: 如果从 crypto/rand 读取种子失败，返回错误
+//
+// NewEntropyPool creates an entropy pool.
+// Parameters:
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *EntropyPool: The entropy pool, safe for concurrent use
+//   - error: Returns an error if reading the seed from crypto/rand fails
+func NewEntropyPool(opts *PoolOptions) (*EntropyPool, error) {
+	p := &EntropyPool{}
+	if opts != nil {
+		p.opts = *opts
+	}
+	if p.opts.BufferSize <= 0 {
+		p.opts.BufferSize = DefaultPoolBufferSize
+	}
+	if p.opts.ReseedInterval <= 0 {
+		p.opts.ReseedInterval = DefaultPoolReseedInterval
+	}
+	if p.opts.ReseedBytes <= 0 {
+		p.opts.ReseedBytes = DefaultPoolReseedBytes
+	}
+	p.buf = make([]byte, poolKeySize+p.opts.BufferSize)
+	if err := p.reseed(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Read 用安全随机字节填满 b，实现 io.Reader，可替代 crypto/rand.Reader
+// 返回:
+//   - int: 写入的字节数，成功时总是 len(b)
+//   - error: 仅在重新获取种子失败时返回错误
+//
+// Read fills b with secure random bytes, implementing io.Reader as a replacement for crypto/rand.Reader.
+// Returns:
+//   - int: Number of bytes written, always len(b) on success
+//   - error: Returns an error only if reseeding fails
+func (p *EntropyPool) Read(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	n := 0
+	for n < len(b) {
+		if p.pos == len(p.buf) {
+			if p.output >= p.opts.ReseedBytes || time.Since(p.seededAt) >= p.opts.ReseedInterval {
+				if err := p.reseed(); err != nil {
+					return n, err
+				}
+			} else {
+				p.refill()
+			}
+		}
+		c := copy(b[n:], p.buf[p.pos:])
+		// 已输出的字节立即清除
+		clear(p.buf[p.pos : p.pos+c])
+		p.pos += c
+		n += c
+	}
+	p.output += n
+	return n, nil
+}
+
+// Bytes 返回 n 个安全随机字节
+//
+// Bytes returns n secure random bytes.
+func (p *EntropyPool) Bytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := p.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Uint64 返回一个安全随机的 uint64
+//
+// Uint64 returns a secure random uint64.
+func (p *EntropyPool) Uint64() (uint64, error) {
+	var b [8]byte
+	if _, err := p.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+// ReadRandom 使用包级默认熵池用安全随机字节填满 b
+//
+// ReadRandom fills b with secure random bytes from the package level default entropy pool.
+func ReadRandom(b []byte) (int, error) {
+	defaultPoolOnce.Do(func() {
+		defaultPool, defaultPoolErr = NewEntropyPool(nil)
+	})
+	if defaultPoolErr != nil {
+		return 0, defaultPoolErr
+	}
+	return defaultPool.Read(b)
+}
+
+// reseed 从 crypto/rand 获取新密钥并填充缓冲区，调用方需持有锁
+//
+// reseed fetches a new key from crypto/rand and refills the buffer; the caller must hold the lock
+func (p *EntropyPool) reseed() error {
+	if _, err := rand.Read(p.key[:]); err != nil {
+		return err
+	}
+	p.seededAt = time.Now()
+	p.output = 0
+	p.refill()
+	return nil
+}
+
+// refill 用当前密钥生成密钥流，前 32 字节作为下一个密钥，其余作为输出，调用方需持有锁
+// 每个密钥只使用一次，因此固定使用全零 IV 是安全的
+//
+// refill generates a keystream with the current key, using the first 32 bytes as the next key and the rest as output; the caller must hold the lock.
+// Each key is used only once, so a fixed all-zero IV is safe.
+func (p *EntropyPool) refill() {
+	block, err := aes.NewCipher(p.key[:])
+	if err != nil {
+		// 密钥长度固定为 32 字节，不会出错
+		panic(err)
+	}
+	var iv [aes.BlockSize]byte
+	clear(p.buf)
+	cipher.NewCTR(block, iv[:]).XORKeyStream(p.buf, p.buf)
+	copy(p.key[:], p.buf[:poolKeySize])
+	clear(p.buf[:poolKeySize])
+	p.pos = poolKeySize
+}
@@ -0,0 +1,267 @@
+// Package ratelimit 提供基于可插拔存储的限流器，支持滑动窗口和 GCRA 算法，存储可以是进程内存或 Redis，适用于按用户或 IP 的分布式限流
+//
+// Package ratelimit provides rate limiters over a pluggable store, with sliding-window and GCRA algorithms and in-memory or Redis stores, for distributed per-user or per-IP limiting.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrInvalidLimit 表示限流参数无效
+//
+// ErrInvalidLimit indicates invalid limit parameters
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit 限流参数
+// Rate: 每个周期允许的请求数
+// Period: 周期
+// Burst: 允许的突发请求数，只对 GCRA 生效，默认等于 Rate
+//
+// Limit contains rate limit parameters.
+// Rate: Number of requests allowed per period
+// Period: The period
+// Burst: Number of requests allowed in a burst, GCRA only; defaults to Rate
+type Limit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// PerSecond 返回每秒 rate 次的限流参数
+//
+// PerSecond returns a limit of rate requests per second.
+func PerSecond(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Second}
+}
+
+// PerMinute 返回每分钟 rate 次的限流参数
+//
+// PerMinute returns a limit of rate requests per minute.
+func PerMinute(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Minute}
+}
+
+// PerHour 返回每小时 rate 次的限流参数
+//
+// PerHour returns a limit of rate requests per hour.
+func PerHour(rate int) Limit {
+	return Limit{Rate: rate, Period: time.Hour}
+}
+
+// burst 返回生效的突发数
+//
+// burst returns the effective burst
+func (l Limit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Rate
+}
+
+// validate 校验限流参数
+//
+// validate validates the limit
+func (l Limit) validate() error {
+	if l.Rate <= 0 || l.Period <= 0 || l.Burst < 0 {
+		return ErrInvalidLimit
+	}
+	return nil
+}
+
+// Result 限流结果
+// Allowed: 是否允许
+// Limit: 限额（滑动窗口为 Rate，GCRA 为 Burst）
+// Remaining: 剩余可用次数
+// RetryAfter: 被拒绝时距离下次可能允许的时长，允许时为 0
+// ResetAfter: 距离额度完全恢复的时长
+//
+// Result is the result of a rate limit check.
+// Allowed: Whether the request is allowed
+// Limit: The quota (Rate for sliding windows, Burst for GCRA)
+// Remaining: Remaining requests
+// RetryAfter: When denied, how long until a request may be allowed; 0 when allowed
+// ResetAfter: How long until the quota is fully restored
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+// Limiter 限流器
+//
+// Limiter is a rate limiter.
+type Limiter interface {
+	// Allow 检查 key 的一次请求是否允许，允许时计入额度
+	//
+	// Allow checks whether one request for key is allowed, counting it when allowed.
+	Allow(ctx context.Context, key string) (*Result, error)
+	// AllowN 检查 key 的 n 次请求是否允许，允许时计入额度；被拒绝时不消耗额度
+	//
+	// AllowN checks whether n requests for key are allowed, counting them when allowed; denied requests consume nothing.
+	AllowN(ctx context.Context, key string, n int) (*Result, error)
+}
+
+// Store 限流状态存储，各算法的读-改-写在存储内部原子完成，使多个实例共享同一份额度
+//
+// Store holds rate limit state; each algorithm's read-modify-write happens atomically inside the store, so several instances share one quota.
+type Store interface {
+	// GCRA 执行 GCRA（通用信元速率算法）检查
+	//
+	// GCRA performs a GCRA (generic cell rate algorithm) check.
+	GCRA(ctx context.Context, key string, limit Limit, n int) (*Result, error)
+	// SlidingWindow 执行滑动窗口计数检查
+	//
+	// SlidingWindow performs a sliding-window counter check.
+	SlidingWindow(ctx context.Context, key string, limit Limit, n int) (*Result, error)
+}
+
+// gcraLimiter GCRA 限流器
+//
+// gcraLimiter is a GCRA limiter
+type gcraLimiter struct {
+	store Store
+	limit Limit
+}
+
+// NewGCRA 创建 GCRA 限流器：请求按 Period/Rate 的固定间隔匀速恢复额度，最多累积 Burst 次，可平滑突发流量且每个 key 只存储一个时间戳
+// 参数:
+//   - store: 状态存储
+//   - limit: 限流参数
+//
+// 返回:
+//   - Limiter: 限流器
+//   - error: 参数无效时返回 ErrInvalidLimit
+//
+// NewGCRA creates a GCRA limiter: quota is restored evenly at intervals of Period/Rate and accumulates up to Burst, smoothing bursts while storing a single timestamp per key.
+// Parameters:
+//   - store: The state store
+//   - limit: The limit
+//
+// Returns:
+//   - Limiter: The limiter
+//   - error: Returns ErrInvalidLimit if the limit is invalid
+func NewGCRA(store Store, limit Limit) (Limiter, error) {
+	if err := limit.validate(); err != nil {
+		return nil, err
+	}
+	return &gcraLimiter{store: store, limit: limit}, nil
+}
+
+// Allow 实现 Limiter 接口
+//
+// Allow implements the Limiter interface.
+func (l *gcraLimiter) Allow(ctx context.Context, key string) (*Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN 实现 Limiter 接口
+//
+// AllowN implements the Limiter interface.
+func (l *gcraLimiter) AllowN(ctx context.Context, key string, n int) (*Result, error) {
+	return l.store.GCRA(ctx, key, l.limit, n)
+}
+
+// slidingWindowLimiter 滑动窗口限流器
+//
+// slidingWindowLimiter is a sliding-window limiter
+type slidingWindowLimiter struct {
+	store Store
+	limit Limit
+}
+
+// NewSlidingWindow 创建滑动窗口限流器：按上一个窗口的计数加权估算最近一个周期内的请求数，避免固定窗口在边界处出现两倍突发
+// 参数:
+//   - store: 状态存储
+//   - limit: 限流参数，Burst 不生效
+//
+// 返回:
+//   - Limiter: 限流器
+//   - error: 参数无效时返回 ErrInvalidLimit
+//
+// NewSlidingWindow creates a sliding-window limiter: the number of requests in the last period is estimated by weighting the previous window's count, avoiding the double burst fixed windows allow at their edges.
+// Parameters:
+//   - store: The state store
+//   - limit: The limit; Burst is ignored
+//
+// Returns:
+//   - Limiter: The limiter
+//   - error: Returns ErrInvalidLimit if the limit is invalid
+func NewSlidingWindow(store Store, limit Limit) (Limiter, error) {
+	if err := limit.validate(); err != nil {
+		return nil, err
+	}
+	return &slidingWindowLimiter{store: store, limit: limit}, nil
+}
+
+// Allow 实现 Limiter 接口
+//
+// Allow implements the Limiter interface.
+func (l *slidingWindowLimiter) Allow(ctx context.Context, key string) (*Result, error) {
+	return l.AllowN(ctx, key, 1)
+}
+
+// AllowN 实现 Limiter 接口
+//
+// AllowN implements the Limiter interface.
+func (l *slidingWindowLimiter) AllowN(ctx context.Context, key string, n int) (*Result, error) {
+	return l.store.SlidingWindow(ctx, key, l.limit, n)
+}
+
+// gcra 按 GCRA 计算结果，tat 为理论到达时间（纳秒），返回结果和新的 tat
+//
+// gcra computes a GCRA result; tat is the theoretical arrival time in nanoseconds; it returns the result and the new tat
+func gcra(now, tat int64, limit Limit, n int) (*Result, int64) {
+	emission := int64(limit.Period) / int64(limit.Rate)
+	burst := limit.burst()
+	tolerance := emission * int64(burst)
+	tat = max(tat, now)
+	newTAT := tat + emission*int64(n)
+	diff := newTAT - now
+	if diff > tolerance {
+		return &Result{
+			Limit:      burst,
+			Remaining:  max(int((tolerance-(tat-now))/emission), 0),
+			RetryAfter: time.Duration(diff - tolerance),
+			ResetAfter: time.Duration(tat - now),
+		}, tat
+	}
+	return &Result{
+		Allowed:    true,
+		Limit:      burst,
+		Remaining:  int((tolerance - diff) / emission),
+		ResetAfter: time.Duration(diff),
+	}, newTAT
+}
+
+// slidingWindow 按滑动窗口计算结果，elapsed 为当前窗口已过去的时长，prev 和 cur 为上一个和当前窗口的计数
+//
+// slidingWindow computes a sliding-window result; elapsed is the time passed in the current window, prev and cur are the counts of the previous and current windows
+func slidingWindow(elapsed time.Duration, prev, cur int64, limit Limit, n int) *Result {
+	window := limit.Period
+	weight := 1 - float64(elapsed)/float64(window)
+	estimated := float64(prev)*weight + float64(cur)
+	rate := float64(limit.Rate)
+	if estimated+float64(n) > rate {
+		// 当前窗口剩余额度足够时，等上一个窗口的权重衰减到能容纳 n 次为止，否则等到下一个窗口
+		retry := window - elapsed
+		if free := rate - float64(cur) - float64(n); free >= 0 && prev > 0 {
+			retry = time.Duration((1-free/float64(prev))*float64(window)) - elapsed
+		}
+		return &Result{
+			Limit:      limit.Rate,
+			Remaining:  max(int(rate-estimated), 0),
+			RetryAfter: max(retry, 0),
+			ResetAfter: 2*window - elapsed,
+		}
+	}
+	return &Result{
+		Allowed:    true,
+		Limit:      limit.Rate,
+		Remaining:  max(int(rate-estimated-float64(n)), 0),
+		ResetAfter: 2*window - elapsed,
+	}
+}
@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval 内存存储清理过期状态的最小间隔
+//
+// memorySweepInterval is the minimum interval between sweeps of expired state in the memory store
+const memorySweepInterval = time.Minute
+
+// memoryEntry 内存存储中一个 key 的状态
+//
+// memoryEntry is the state of one key in the memory store
+type memoryEntry struct {
+	tat      int64
+	window   int64
+	prev     int64
+	cur      int64
+	expireAt int64
+}
+
+// MemoryStore 进程内存储，适用于单实例部署和测试；过期状态在写入时定期清理
+// 并发安全
+//
+// MemoryStore is an in-process store for single-instance deployments and tests; expired state is swept periodically on writes.
+// It is safe for concurrent use.
+type MemoryStore struct {
+	mu        sync.Mutex
+	gcra      map[string]*memoryEntry
+	windows   map[string]*memoryEntry
+	lastSweep int64
+}
+
+// NewMemoryStore 创建进程内存储
+//
+// NewMemoryStore creates an in-process store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		gcra:      make(map[string]*memoryEntry),
+		windows:   make(map[string]*memoryEntry),
+		lastSweep: time.Now().UnixNano(),
+	}
+}
+
+// GCRA 实现 Store 接口
+//
+// GCRA implements the Store interface.
+func (s *MemoryStore) GCRA(_ context.Context, key string, limit Limit, n int) (*Result, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	e := s.gcra[key]
+	var tat int64
+	if e != nil {
+		tat = e.tat
+	}
+	res, newTAT := gcra(now, tat, limit, n)
+	if res.Allowed {
+		if e == nil {
+			e = &memoryEntry{}
+			s.gcra[key] = e
+		}
+		e.tat, e.expireAt = newTAT, newTAT
+	}
+	return res, nil
+}
+
+// SlidingWindow 实现 Store 接口
+//
+// SlidingWindow implements the Store interface.
+func (s *MemoryStore) SlidingWindow(_ context.Context, key string, limit Limit, n int) (*Result, error) {
+	now := time.Now().UnixNano()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	window := int64(limit.Period)
+	idx := now / window
+	e := s.windows[key]
+	if e == nil {
+		e = &memoryEntry{window: idx}
+		s.windows[key] = e
+	}
+	switch {
+	case e.window == idx-1:
+		e.window, e.prev, e.cur = idx, e.cur, 0
+	case e.window < idx-1:
+		e.window, e.prev, e.cur = idx, 0, 0
+	}
+	res := slidingWindow(time.Duration(now-idx*window), e.prev, e.cur, limit, n)
+	if res.Allowed {
+		e.cur += int64(n)
+	}
+	e.expireAt = (idx + 2) * window
+	return res, nil
+}
+
+// Reset 清除 key 的所有状态，例如用户登录成功后重置失败次数限制
+//
+// Reset clears all state of key, e.g. resetting a failed-login limit after a successful login.
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.gcra, key)
+	delete(s.windows, key)
+	return nil
+}
+
+// sweep 定期清理过期状态，调用方需持有锁
+//
+// sweep periodically removes expired state; the caller must hold the lock
+func (s *MemoryStore) sweep(now int64) {
+	if now-s.lastSweep < int64(memorySweepInterval) {
+		return
+	}
+	s.lastSweep = now
+	for _, m := range []map[string]*memoryEntry{s.gcra, s.windows} {
+		for k, e := range m {
+			if e.expireAt <= now {
+				delete(m, k)
+			}
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultRedisPrefix Redis 存储默认的键前缀
+//
+// DefaultRedisPrefix is the default key prefix of the Redis store
+const DefaultRedisPrefix = "ratelimit:"
+
+// gcraScript GCRA 的 Lua 脚本，时间取 Redis 服务器时间（微秒），避免各实例时钟不一致
+// 返回 {是否允许, 剩余次数, 重试等待微秒, 恢复等待微秒}
+//
+// gcraScript is the GCRA Lua script; time comes from the Redis server (microseconds) so instance clocks do not matter
+// It returns {allowed, remaining, retry-after µs, reset-after µs}
+var gcraScript = redis.NewScript(`
+local emission = tonumber(ARGV[1])
+local tolerance = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local tat = now
+local stored = redis.call('GET', KEYS[1])
+if stored then
+  tat = math.max(tonumber(stored), now)
+end
+local newTat = tat + emission * n
+local diff = newTat - now
+if diff > tolerance then
+  return {0, math.max(math.floor((tolerance - (tat - now)) / emission), 0), diff - tolerance, tat - now}
+end
+redis.call('SET', KEYS[1], string.format('%.0f', newTat), 'PX', math.max(math.ceil(diff / 1000), 1))
+return {1, math.floor((tolerance - diff) / emission), 0, diff}
+`)
+
+// slidingWindowScript 滑动窗口的 Lua 脚本，每个 key 使用一个哈希保存当前和上一个窗口的计数
+// 返回 {是否允许, 剩余次数, 重试等待微秒, 恢复等待微秒}
+//
+// slidingWindowScript is the sliding-window Lua script; each key uses one hash holding the current and previous window counts
+// It returns {allowed, remaining, retry-after µs, reset-after µs}
+var slidingWindowScript = redis.NewScript(`
+local window = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local idx = math.floor(now / window)
+local elapsed = now - idx * window
+local curField = string.format('%.0f', idx)
+local prevField = string.format('%.0f', idx - 1)
+local fields = redis.call('HGETALL', KEYS[1])
+local prev, cur = 0, 0
+for i = 1, #fields, 2 do
+  local f = fields[i]
+  if f == curField then
+    cur = tonumber(fields[i + 1])
+  elseif f == prevField then
+    prev = tonumber(fields[i + 1])
+  else
+    redis.call('HDEL', KEYS[1], f)
+  end
+end
+local estimated = prev * (1 - elapsed / window) + cur
+local reset = 2 * window - elapsed
+if estimated + n > rate then
+  local retry = window - elapsed
+  local free = rate - cur - n
+  if free >= 0 and prev > 0 then
+    retry = math.floor((1 - free / prev) * window) - elapsed
+  end
+  return {0, math.max(math.floor(rate - estimated), 0), math.max(retry, 0), reset}
+end
+redis.call('HINCRBY', KEYS[1], curField, n)
+redis.call('PEXPIRE', KEYS[1], math.ceil(reset / 1000))
+return {1, math.max(math.floor(rate - estimated - n), 0), 0, reset}
+`)
+
+// RedisStore 基于 Redis Lua 脚本的存储，读-改-写在脚本内原子完成，可在多个实例间共享额度
+// 支持单机、哨兵和集群模式（每个脚本只访问一个键）
+//
+// RedisStore is a store built on Redis Lua scripts; the read-modify-write runs atomically inside the script, so instances share quotas.
+// Standalone, sentinel and cluster deployments are supported (each script touches a single key).
+type RedisStore struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// NewRedisStore 创建 Redis 存储
+// 参数:
+//   - client: Redis 客户端，例如 *redis.Client 或 *redis.ClusterClient
+//   - prefix: 键前缀，为空时使用 DefaultRedisPrefix
+//
+// 返回:
+//   - *RedisStore: 存储
+//
+// NewRedisStore creates a Redis store.
+// Parameters:
+//   - client: Redis client, e.g. *redis.Client or *redis.ClusterClient
+//   - prefix: Key prefix; DefaultRedisPrefix when empty
+//
+// Returns:
+//   - *RedisStore: The store
+func NewRedisStore(client redis.Cmdable, prefix string) *RedisStore {
+	if prefix == "" {
+		prefix = DefaultRedisPrefix
+	}
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// GCRA 实现 Store 接口
+//
+// GCRA implements the Store interface.
+func (s *RedisStore) GCRA(ctx context.Context, key string, limit Limit, n int) (*Result, error) {
+	emission := max(limit.Period.Microseconds()/int64(limit.Rate), 1)
+	burst := limit.burst()
+	res, err := s.run(ctx, gcraScript, s.prefix+"gcra:"+key, emission, emission*int64(burst), n)
+	if err != nil {
+		return nil, err
+	}
+	res.Limit = burst
+	return res, nil
+}
+
+// SlidingWindow 实现 Store 接口
+//
+// SlidingWindow implements the Store interface.
+func (s *RedisStore) SlidingWindow(ctx context.Context, key string, limit Limit, n int) (*Result, error) {
+	res, err := s.run(ctx, slidingWindowScript, s.prefix+"sw:"+key, max(limit.Period.Microseconds(), 1), limit.Rate, n)
+	if err != nil {
+		return nil, err
+	}
+	res.Limit = limit.Rate
+	return res, nil
+}
+
+// Reset 清除 key 的所有状态，例如用户登录成功后重置失败次数限制
+//
+// Reset clears all state of key, e.g. resetting a failed-login limit after a successful login.
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	// 集群模式下两个键可能位于不同的槽，逐个删除
+	for _, k := range []string{s.prefix + "gcra:" + key, s.prefix + "sw:" + key} {
+		if err := s.client.Del(ctx, k).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run 执行脚本并解析 {是否允许, 剩余次数, 重试等待微秒, 恢复等待微秒}
+//
+// run executes a script and parses {allowed, remaining, retry-after µs, reset-after µs}
+func (s *RedisStore) run(ctx context.Context, script *redis.Script, key string, args ...any) (*Result, error) {
+	vals, err := script.Run(ctx, s.client, []string{key}, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(vals) != 4 {
+		return nil, fmt.Errorf("ratelimit: unexpected script result %v", vals)
+	}
+	return &Result{
+		Allowed:    vals[0] == 1,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Microsecond,
+		ResetAfter: time.Duration(vals[3]) * time.Microsecond,
+	}, nil
+}
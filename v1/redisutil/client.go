@@ -0,0 +1,118 @@
+// Package redisutil 提供常用的 Redis 模式：分布式锁、限流脚本和带类型的 JSON 读写
+//
+// Package redisutil provides common Redis patterns: distributed locks, rate-limit scripts and typed JSON reads and writes.
+package redisutil
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/supergodk/go-utils/v1/cacheutil"
+)
+
+// Client go-redis 客户端的轻量包装，所有键自动加上前缀
+// Client 实现了 cacheutil.Cache 接口，可作为 cacheutil 的远程缓存层
+//
+// Client is a thin wrapper over a go-redis client that prefixes every key.
+// Client implements the cacheutil.Cache interface, so it can serve as cacheutil's remote tier.
+type Client struct {
+	rdb    redis.Cmdable
+	prefix string
+}
+
+var _ cacheutil.Cache = (*Client)(nil)
+
+// incrScript INCR 并在键新建时设置过期时间
+//
+// incrScript runs INCR and sets the expiry when the key is created
+var incrScript = redis.NewScript(`
+local v = redis.call('INCR', KEYS[1])
+if v == 1 and tonumber(ARGV[1]) > 0 then
+  redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+return v
+`)
+
+// NewClient 创建包装客户端
+// 参数:
+//   - rdb: Redis 客户端，例如 *redis.Client 或 *redis.ClusterClient
+//   - prefix: 键前缀，例如 "myapp:"，可以为空
+//
+// 返回:
+//   - *Client: 包装客户端
+//
+// NewClient creates a wrapped client.
+// Parameters:
+//   - rdb: Redis client, e.g. *redis.Client or *redis.ClusterClient
+//   - prefix: Key prefix such as "myapp:", may be empty
+//
+// Returns:
+//   - *Client: The wrapped client
+func NewClient(rdb redis.Cmdable, prefix string) *Client {
+	return &Client{rdb: rdb, prefix: prefix}
+}
+
+// Redis 返回底层的 Redis 客户端，用于执行包装未覆盖的命令（注意需要自行加前缀）
+//
+// Redis returns the underlying Redis client for commands the wrapper does not cover (add the prefix yourself).
+func (c *Client) Redis() redis.Cmdable {
+	return c.rdb
+}
+
+// Key 返回加上前缀后的完整键
+//
+// Key returns the full key with the prefix applied.
+func (c *Client) Key(key string) string {
+	return c.prefix + key
+}
+
+// Get 实现 cacheutil.Cache 接口
+//
+// Get implements the cacheutil.Cache interface.
+func (c *Client) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	v, err := c.rdb.Get(ctx, c.Key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return v, true, nil
+}
+
+// Set 实现 cacheutil.Cache 接口
+//
+// Set implements the cacheutil.Cache interface.
+func (c *Client) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.rdb.Set(ctx, c.Key(key), value, expiration(ttl)).Err()
+}
+
+// SetNX 实现 cacheutil.Cache 接口
+//
+// SetNX implements the cacheutil.Cache interface.
+func (c *Client) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	return c.rdb.SetNX(ctx, c.Key(key), value, expiration(ttl)).Result()
+}
+
+// Incr 实现 cacheutil.Cache 接口
+//
+// Incr implements the cacheutil.Cache interface.
+func (c *Client) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return incrScript.Run(ctx, c.rdb, []string{c.Key(key)}, expiration(ttl).Milliseconds()).Int64()
+}
+
+// Delete 实现 cacheutil.Cache 接口
+//
+// Delete implements the cacheutil.Cache interface.
+func (c *Client) Delete(ctx context.Context, key string) error {
+	return c.rdb.Del(ctx, c.Key(key)).Err()
+}
+
+// expiration 将 ttl 转换为 go-redis 的过期参数，小于等于 0 表示永不过期（go-redis 中负数有 KEEPTTL 的特殊含义）
+//
+// expiration converts ttl to a go-redis expiration; values <= 0 mean no expiry (negative values mean KEEPTTL in go-redis)
+func expiration(ttl time.Duration) time.Duration {
+	return max(ttl, 0)
+}
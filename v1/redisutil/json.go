@@ -0,0 +1,107 @@
+package redisutil
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/gziputil"
+)
+
+// DefaultCompressThreshold SetJSON 默认的压缩阈值，JSON 达到该大小时使用 gzip 压缩后存储
+//
+// DefaultCompressThreshold is SetJSON's default compression threshold; JSON of at least this size is stored gzip-compressed
+const DefaultCompressThreshold = 1024
+
+// JSONOptions SetJSON 的选项
+// CompressThreshold: 压缩阈值（字节），为 0 时使用 DefaultCompressThreshold，为负数时不压缩
+//
+// JSONOptions contains options for SetJSON.
+// CompressThreshold: Compression threshold in bytes; DefaultCompressThreshold when 0, no compression when negative
+type JSONOptions struct {
+	CompressThreshold int
+}
+
+// SetJSON 将 value 序列化为 JSON 后写入 key，较大的值使用 gzip 压缩
+// 参数:
+//   - ctx: 上下文
+//   - c: 客户端
+//   - key: 键（不含前缀）
+//   - value: 要保存的值
+//   - ttl: 过期时间，小于等于 0 表示永不过期
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - error: 序列化或写入失败时返回错误
+//
+// SetJSON serializes value to JSON and writes it to key, gzip-compressing larger values.
+// Parameters:
+//   - ctx: Context
+//   - c: The client
+//   - key: Key without the prefix
+//   - value: The value to store
+//   - ttl: Expiry; values <= 0 mean no expiry
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - error: Returns an error if serialization or the write fails
+func SetJSON[T any](ctx context.Context, c *Client, key string, value T, ttl time.Duration, opts *JSONOptions) error {
+	var o JSONOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.CompressThreshold == 0 {
+		o.CompressThreshold = DefaultCompressThreshold
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("redisutil: marshal %s: %w", key, err)
+	}
+	// JSON 文本不会以 gzip 魔数开头，读取时据此区分，无需额外的标记
+	if o.CompressThreshold > 0 && len(data) >= o.CompressThreshold {
+		if data, err = gziputil.Gzip(data, gzip.BestSpeed); err != nil {
+			return err
+		}
+	}
+	return c.Set(ctx, key, data, ttl)
+}
+
+// GetJSON 读取 key 并反序列化为 T，自动识别 SetJSON 压缩过的值
+// 参数:
+//   - ctx: 上下文
+//   - c: 客户端
+//   - key: 键（不含前缀）
+//
+// 返回:
+//   - T: 读取到的值
+//   - bool: 键是否存在
+//   - error: 读取或反序列化失败时返回错误
+//
+// GetJSON reads key and deserializes it into T, recognizing values compressed by SetJSON.
+// Parameters:
+//   - ctx: Context
+//   - c: The client
+//   - key: Key without the prefix
+//
+// Returns:
+//   - T: The value read
+//   - bool: Whether the key exists
+//   - error: Returns an error if the read or deserialization fails
+func GetJSON[T any](ctx context.Context, c *Client, key string) (T, bool, error) {
+	var v T
+	data, ok, err := c.Get(ctx, key)
+	if err != nil || !ok {
+		return v, false, err
+	}
+	if gziputil.IsGzipped(data) {
+		if data, err = gziputil.UnGzip(data); err != nil {
+			return v, false, err
+		}
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, false, fmt.Errorf("redisutil: unmarshal %s: %w", key, err)
+	}
+	return v, true, nil
+}
@@ -0,0 +1,244 @@
+package redisutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultLockTTL 锁默认的过期时间
+	//
+	// DefaultLockTTL is the default lock expiry
+	DefaultLockTTL = 30 * time.Second
+	// DefaultLockRetryInterval 等待锁时默认的重试间隔
+	//
+	// DefaultLockRetryInterval is the default retry interval while waiting for a lock
+	DefaultLockRetryInterval = 100 * time.Millisecond
+)
+
+var (
+	// ErrLockNotAcquired 表示锁已被其他持有者占用
+	//
+	// ErrLockNotAcquired indicates that the lock is held by another owner
+	ErrLockNotAcquired = errors.New("lock not acquired")
+	// ErrLockLost 表示锁已过期或被其他持有者获取
+	//
+	// ErrLockLost indicates that the lock expired or was taken by another owner
+	ErrLockLost = errors.New("lock lost")
+)
+
+// acquireScript 获取锁并递增栅栏令牌，两个键使用相同的哈希标签，集群模式下位于同一个槽
+//
+// acquireScript acquires the lock and increments the fencing token; both keys share a hash tag so they live in one cluster slot
+var acquireScript = redis.NewScript(`
+if redis.call('SET', KEYS[1], ARGV[1], 'NX', 'PX', ARGV[2]) then
+  return redis.call('INCR', KEYS[2])
+end
+return 0
+`)
+
+// refreshScript 仅当锁仍属于调用者时延长过期时间
+//
+// refreshScript extends the expiry only while the caller still owns the lock
+var refreshScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript 仅当锁仍属于调用者时删除
+//
+// releaseScript deletes the lock only while the caller still owns it
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// LockOptions 获取锁的选项
+// TTL: 锁的过期时间，为 0 时使用 DefaultLockTTL
+// Wait: 锁被占用时最多等待多久，为 0 时只尝试一次
+// RetryInterval: 等待时的重试间隔，为 0 时使用 DefaultLockRetryInterval
+// AutoRenew: 是否在后台每隔 TTL/3 自动续期，直到 Release；续期失败时 Lost 通道关闭
+//
+// LockOptions contains options for acquiring a lock.
+// TTL: Lock expiry; DefaultLockTTL when 0
+// Wait: How long to wait at most while the lock is held; 0 tries once
+// RetryInterval: Retry interval while waiting; DefaultLockRetryInterval when 0
+// AutoRenew: Whether to renew every TTL/3 in the background until Release; the Lost channel is closed when renewal fails
+type LockOptions struct {
+	TTL           time.Duration
+	Wait          time.Duration
+	RetryInterval time.Duration
+	AutoRenew     bool
+}
+
+// Lock 已获取的分布式锁
+// Redis 锁无法保证绝对互斥（例如进程暂停超过 TTL），写入受保护的资源时应携带 Token，由资源拒绝比已见过的更小的令牌
+//
+// Lock is an acquired distributed lock.
+// A Redis lock cannot guarantee strict mutual exclusion (e.g. a process paused beyond the TTL), so writes to the protected resource should carry Token and the resource should reject tokens smaller than one it has seen.
+type Lock struct {
+	c     *Client
+	key   string
+	owner string
+	token int64
+	ttl   time.Duration
+
+	lost     chan struct{}
+	lostOnce sync.Once
+	stop     chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// Lock 获取名为 name 的分布式锁
+// 参数:
+//   - ctx: 上下文，用于取消等待
+//   - name: 锁名称
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *Lock: 锁，用完后必须调用 Release
+//   - error: 锁被占用且等待超时时返回 ErrLockNotAcquired
+//
+// Lock acquires the distributed lock named name.
+// Parameters:
+//   - ctx: Context for cancelling the wait
+//   - name: Lock name
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *Lock: The lock; Release must be called when done
+//   - error: Returns ErrLockNotAcquired if the lock is held and the wait timed out
+func (c *Client) Lock(ctx context.Context, name string, opts *LockOptions) (*Lock, error) {
+	var o LockOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.TTL <= 0 {
+		o.TTL = DefaultLockTTL
+	}
+	if o.RetryInterval <= 0 {
+		o.RetryInterval = DefaultLockRetryInterval
+	}
+
+	var buf [16]byte
+	_, _ = rand.Read(buf[:])
+	lk := &Lock{
+		c:     c,
+		key:   c.Key("lock:{" + name + "}"),
+		owner: hex.EncodeToString(buf[:]),
+		ttl:   o.TTL,
+		lost:  make(chan struct{}),
+		stop:  make(chan struct{}),
+	}
+	deadline := time.Now().Add(o.Wait)
+	for {
+		token, err := acquireScript.Run(ctx, c.rdb, []string{lk.key, lk.key + ":fence"}, lk.owner, o.TTL.Milliseconds()).Int64()
+		if err != nil {
+			return nil, err
+		}
+		if token > 0 {
+			lk.token = token
+			break
+		}
+		if !time.Now().Before(deadline) {
+			return nil, ErrLockNotAcquired
+		}
+		timer := time.NewTimer(min(o.RetryInterval, time.Until(deadline)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	if o.AutoRenew {
+		lk.wg.Add(1)
+		go lk.renew()
+	}
+	return lk, nil
+}
+
+// Token 返回栅栏令牌，每次成功获取锁时单调递增
+//
+// Token returns the fencing token, which increases monotonically with every successful acquisition.
+func (lk *Lock) Token() int64 {
+	return lk.token
+}
+
+// Lost 返回在自动续期失败时关闭的通道，持有者应停止访问受保护的资源
+//
+// Lost returns a channel closed when auto-renewal fails; the holder should stop touching the protected resource.
+func (lk *Lock) Lost() <-chan struct{} {
+	return lk.lost
+}
+
+// Refresh 将锁的过期时间重置为 TTL
+//
+// Refresh resets the lock's expiry to the TTL.
+func (lk *Lock) Refresh(ctx context.Context) error {
+	ok, err := refreshScript.Run(ctx, lk.c.rdb, []string{lk.key}, lk.owner, lk.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return err
+	}
+	if ok == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// Release 停止自动续期并释放锁，锁已丢失时返回 ErrLockLost；可以重复调用
+//
+// Release stops auto-renewal and releases the lock, returning ErrLockLost if it was already lost; it may be called more than once.
+func (lk *Lock) Release(ctx context.Context) error {
+	lk.stopOnce.Do(func() { close(lk.stop) })
+	lk.wg.Wait()
+	n, err := releaseScript.Run(ctx, lk.c.rdb, []string{lk.key}, lk.owner).Int64()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+// renew 每隔 TTL/3 续期，直到 Release 或续期失败
+//
+// renew renews every TTL/3 until Release or a failed renewal
+func (lk *Lock) renew() {
+	defer lk.wg.Done()
+	ticker := time.NewTicker(max(lk.ttl/3, time.Millisecond))
+	defer ticker.Stop()
+	renewed := time.Now()
+	for {
+		select {
+		case <-lk.stop:
+			return
+		case <-ticker.C:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), lk.ttl/3)
+		err := lk.Refresh(ctx)
+		cancel()
+		if err == nil {
+			renewed = time.Now()
+			continue
+		}
+		// 网络错误时锁可能仍然有效，直到明确丢失或距上次续期超过 TTL 才放弃
+		if errors.Is(err, ErrLockLost) || time.Since(renewed) >= lk.ttl {
+			lk.lostOnce.Do(func() { close(lk.lost) })
+			return
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package schedule 提供 cron 表达式解析和轻量的定时任务调度器
+//
+// Package schedule provides cron expression parsing and a lightweight job scheduler.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSpec 表示 cron 表达式无效
+//
+// ErrInvalidSpec indicates that a cron expression is invalid
+var ErrInvalidSpec = errors.New("invalid cron spec")
+
+// maxSearchYears Next 向后搜索的最大年数，超出时认为表达式永远不会触发（例如 2 月 30 日）
+//
+// maxSearchYears is how many years Next searches ahead before deciding the spec never fires (e.g. February 30)
+const maxSearchYears = 5
+
+// field 一个 cron 字段的取值范围和名称
+//
+// field is the value range and names of one cron field
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = field{name: "second", min: 0, max: 59}
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	domField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 星期允许 7 表示周日，解析后折算为 0
+	dowField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+// descriptors 预定义的表达式
+//
+// descriptors are the predefined expressions
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Spec 解析后的 cron 表达式
+//
+// Spec is a parsed cron expression.
+type Spec struct {
+	raw string
+
+	second, minute, hour, dom, month, dow uint64
+	// domStar、dowStar 记录日和星期是否为 *，两者都受限时按标准 cron 取并集
+	domStar, dowStar bool
+	// every 不为 0 时表示 @every 固定间隔
+	every time.Duration
+}
+
+// Parse 解析 cron 表达式
+// 支持标准的 5 个字段（分 时 日 月 星期）和带秒的 6 个字段，字段支持 *、?、列表、范围、步长以及 JAN-DEC、SUN-SAT 名称
+// 还支持 @yearly、@monthly、@weekly、@daily、@hourly 等预定义表达式和 @every <时长>（例如 @every 90s）
+// 日和星期都不是 * 时，任意一个匹配即触发，与标准 cron 一致
+// 参数:
+//   - spec: cron 表达式，例如 "*/5 9-18 * * MON-FRI"
+//
+// 返回:
+//   - *Spec: 解析结果
+//   - error: 表达式无效时返回 ErrInvalidSpec
+//
+// Parse parses a cron expression.
+// The standard 5 fields (minute hour day month weekday) and a 6-field form with seconds are supported; fields accept *, ?, lists, ranges, steps and the names JAN-DEC and SUN-SAT.
+// The predefined @yearly, @monthly, @weekly, @daily, @hourly and similar descriptors are supported, as is @every <duration> (e.g. @every 90s).
+// When neither day of month nor day of week is *, matching either one fires, as in standard cron.
+// Parameters:
+//   - spec: The cron expression, e.g. "*/5 9-18 * * MON-FRI"
+//
+// Returns:
+//   - *Spec: The parsed expression
+//   - error: Returns ErrInvalidSpec if the expression is invalid
+func Parse(spec string) (*Spec, error) {
+	raw := strings.TrimSpace(spec)
+	expr := raw
+	if strings.HasPrefix(expr, "@") {
+		if d, ok := strings.CutPrefix(expr, "@every "); ok {
+			every, err := time.ParseDuration(strings.TrimSpace(d))
+			if err != nil || every < time.Second {
+				return nil, fmt.Errorf("%w: %q: @every needs a duration of at least 1s", ErrInvalidSpec, raw)
+			}
+			return &Spec{raw: raw, every: every}, nil
+		}
+		var ok bool
+		if expr, ok = descriptors[strings.ToLower(expr)]; !ok {
+			return nil, fmt.Errorf("%w: unknown descriptor %q", ErrInvalidSpec, raw)
+		}
+	}
+
+	fields := strings.Fields(expr)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%w: %q: expected 5 or 6 fields, got %d", ErrInvalidSpec, raw, len(fields))
+	}
+
+	s := &Spec{raw: raw}
+	var err error
+	targets := []struct {
+		bits *uint64
+		f    field
+	}{
+		{&s.second, secondField}, {&s.minute, minuteField}, {&s.hour, hourField},
+		{&s.dom, domField}, {&s.month, monthField}, {&s.dow, dowField},
+	}
+	for i, t := range targets {
+		if *t.bits, err = parseField(fields[i], t.f); err != nil {
+			return nil, fmt.Errorf("%w: %q: %v", ErrInvalidSpec, raw, err)
+		}
+	}
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domStar = isStar(fields[3])
+	s.dowStar = isStar(fields[5])
+	return s, nil
+}
+
+// MustParse 与 Parse 相同，但表达式无效时 panic，适用于常量表达式
+//
+// MustParse is like Parse but panics if the expression is invalid; it suits constant expressions.
+func MustParse(spec string) *Spec {
+	s, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// String 返回原始表达式
+//
+// String returns the original expression.
+func (s *Spec) String() string {
+	return s.raw
+}
+
+// Next 返回 t 之后的下一个触发时间，使用 t 的时区计算
+// 夏令时开始时不存在的时刻会被跳过，结束时重复的时刻只触发一次
+// 参数:
+//   - t: 起始时间（不包含）
+//
+// 返回:
+//   - time.Time: 下一个触发时间，表达式永远不会触发时返回零值
+//
+// Next returns the next firing time after t, computed in t's location.
+// Wall times that do not exist when daylight saving starts are skipped, and times repeated when it ends fire once.
+// Parameters:
+//   - t: Start time (exclusive)
+//
+// Returns:
+//   - time.Time: The next firing time, or the zero time if the spec never fires
+func (s *Spec) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every).Truncate(time.Second)
+	}
+	loc := t.Location()
+	y, m, d := t.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	end := day.AddDate(maxSearchYears, 0, 0)
+	for first := true; day.Before(end); day, first = day.AddDate(0, 0, 1), false {
+		if !s.matchDay(day) {
+			continue
+		}
+		for h := range 24 {
+			if s.hour&(1<<h) == 0 || first && h < t.Hour()-2 {
+				// 首日跳过明显早于 t 的小时，保留两小时余量以覆盖时区偏移的变化
+				continue
+			}
+			for mi := range 60 {
+				if s.minute&(1<<mi) == 0 {
+					continue
+				}
+				for sec := range 60 {
+					if s.second&(1<<sec) == 0 {
+						continue
+					}
+					c := time.Date(day.Year(), day.Month(), day.Day(), h, mi, sec, 0, loc)
+					// time.Date 会把不存在的时刻规范化到其他时刻，通过比较钟面时间排除
+					if c.Hour() != h || c.Minute() != mi || !c.After(t) {
+						continue
+					}
+					return c
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// matchDay 判断日期是否匹配日、月和星期字段
+//
+// matchDay reports whether a date matches the day, month and weekday fields
+func (s *Spec) matchDay(day time.Time) bool {
+	if s.month&(1<<uint(day.Month())) == 0 {
+		return false
+	}
+	domOK := s.dom&(1<<uint(day.Day())) != 0
+	dowOK := s.dow&(1<<uint(day.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domOK && dowOK
+	}
+	return domOK || dowOK
+}
+
+// isStar 判断字段是否不受限（* 或 ?）
+//
+// isStar reports whether a field is unrestricted (* or ?)
+func isStar(expr string) bool {
+	return expr == "*" || expr == "?"
+}
+
+// parseField 将一个字段解析为位集合
+//
+// parseField parses one field into a bit set
+func parseField(expr string, f field) (uint64, error) {
+	var set uint64
+	for part := range strings.SplitSeq(expr, ",") {
+		lo, hi, step := f.min, f.max, 1
+		rng := part
+		if r, st, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(st)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("%s: invalid step %q", f.name, st)
+			}
+			rng, step = r, n
+		}
+		switch {
+		case isStar(rng):
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(a); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(b); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s: invalid range %q", f.name, rng)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/15" 表示从 5 开始每 15 个单位，没有步长时只取单个值
+			if step == 1 {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	if bits.OnesCount64(set) == 0 {
+		return 0, fmt.Errorf("%s: empty", f.name)
+	}
+	return set, nil
+}
+
+// value 解析字段中的单个值或名称
+//
+// value parses a single value or name of the field
+func (f field) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s: value %q out of range %d-%d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
@@ -0,0 +1,388 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/errorutil"
+)
+
+// ErrStopped 表示调度器已停止
+//
+// ErrStopped indicates that the scheduler has stopped
+var ErrStopped = errors.New("scheduler stopped")
+
+// OverlapPolicy 上一次执行尚未结束时到达新触发时间的处理策略
+//
+// OverlapPolicy decides what happens when a job is due while its previous run is still going.
+type OverlapPolicy int
+
+const (
+	// OverlapSkip 跳过本次触发（默认）
+	//
+	// OverlapSkip skips the firing (the default)
+	OverlapSkip OverlapPolicy = iota
+	// OverlapDelay 在上一次结束后立即补执行一次，期间的多次触发合并为一次
+	//
+	// OverlapDelay runs once more right after the previous run ends; several firings in between coalesce into one
+	OverlapDelay
+	// OverlapAllow 在新的 goroutine 中并发执行
+	//
+	// OverlapAllow runs concurrently in a new goroutine
+	OverlapAllow
+)
+
+// JobID 任务标识
+//
+// JobID identifies a job.
+type JobID int
+
+// Options 调度器选项
+// Location: 任务默认的时区，为 nil 时使用 time.Local
+// OnError: 任务返回错误或 panic（*errorutil.PanicError）时的回调，可以为 nil
+//
+// Options contains scheduler options.
+// Location: The jobs' default time zone; time.Local when nil
+// OnError: Callback invoked when a job returns an error or panics (*errorutil.PanicError), may be nil
+type Options struct {
+	Location *time.Location
+	OnError  func(name string, err error)
+}
+
+// JobOptions 任务选项
+// Name: 任务名称，传给 OnError，默认为表达式本身
+// Location: 计算触发时间使用的时区，为 nil 时使用调度器的时区
+// Overlap: 重叠执行策略，默认 OverlapSkip
+// Jitter: 每次触发前额外等待 [0, Jitter) 内的随机时长，用于错开集群中的执行时间
+// Timeout: 单次执行的超时时间，为 0 时不限制
+//
+// JobOptions contains job options.
+// Name: Job name passed to OnError; defaults to the spec itself
+// Location: Time zone for computing firing times; the scheduler's when nil
+// Overlap: Overlap policy, defaults to OverlapSkip
+// Jitter: Extra random wait in [0, Jitter) before each firing, to spread runs across a fleet
+// Timeout: Timeout of a single run; unlimited when 0
+type JobOptions struct {
+	Name     string
+	Location *time.Location
+	Overlap  OverlapPolicy
+	Jitter   time.Duration
+	Timeout  time.Duration
+}
+
+// Entry 任务的调度信息
+// ID: 任务标识
+// Name: 任务名称
+// Spec: 表达式
+// Next: 下一次触发时间（不含抖动）
+// Prev: 上一次开始执行的时间，尚未执行时为零值
+//
+// Entry is a job's scheduling state.
+// ID: Job identifier
+// Name: Job name
+// Spec: The expression
+// Next: Next firing time, without jitter
+// Prev: When the last run started; the zero time if it has not run
+type Entry struct {
+	ID   JobID
+	Name string
+	Spec string
+	Next time.Time
+	Prev time.Time
+}
+
+// Scheduler 定时任务调度器，每个任务使用一个 goroutine 等待触发时间；并发安全
+// 任务中的 panic 会被捕获并交给 OnError，不会导致进程崩溃
+//
+// Scheduler runs jobs on cron schedules, with one goroutine per job waiting for its firing time; it is safe for concurrent use.
+// Panics in jobs are recovered and passed to OnError, so they never crash the process.
+type Scheduler struct {
+	o Options
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	jobs    map[JobID]*job
+	nextID  JobID
+	started bool
+	stopped bool
+	loops   sync.WaitGroup
+	runs    sync.WaitGroup
+}
+
+// job 一个已注册的任务
+//
+// job is a registered job
+type job struct {
+	id   JobID
+	spec *Spec
+	fn   func(ctx context.Context) error
+	o    JobOptions
+
+	stop    chan struct{}
+	trigger chan struct{}
+
+	mu   sync.Mutex
+	next time.Time
+	prev time.Time
+}
+
+// New 创建调度器，调用 Start 后开始调度
+// 参数:
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *Scheduler: 调度器
+//
+// New creates a scheduler; scheduling begins after Start.
+// Parameters:
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *Scheduler: The scheduler
+func New(opts *Options) *Scheduler {
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	if o.Location == nil {
+		o.Location = time.Local
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{o: o, ctx: ctx, cancel: cancel, jobs: make(map[JobID]*job)}
+}
+
+// AddJob 注册任务，调度器已启动时立即开始调度
+// 参数:
+//   - spec: cron 表达式，语法见 Parse
+//   - fn: 任务，收到的 ctx 在 Stop 的等待超时后取消
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - JobID: 任务标识，用于 Remove
+//   - error: 表达式无效时返回 ErrInvalidSpec，调度器已停止时返回 ErrStopped
+//
+// AddJob registers a job; if the scheduler is running it is scheduled immediately.
+// Parameters:
+//   - spec: The cron expression; see Parse for the syntax
+//   - fn: The job; its ctx is cancelled when Stop's wait times out
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - JobID: The job identifier, for Remove
+//   - error: Returns ErrInvalidSpec for an invalid spec, or ErrStopped if the scheduler has stopped
+func (s *Scheduler) AddJob(spec string, fn func(ctx context.Context) error, opts *JobOptions) (JobID, error) {
+	parsed, err := Parse(spec)
+	if err != nil {
+		return 0, err
+	}
+	var o JobOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.Name == "" {
+		o.Name = parsed.String()
+	}
+	if o.Location == nil {
+		o.Location = s.o.Location
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return 0, ErrStopped
+	}
+	s.nextID++
+	j := &job{id: s.nextID, spec: parsed, fn: fn, o: o, stop: make(chan struct{})}
+	s.jobs[j.id] = j
+	if s.started {
+		s.launch(j)
+	}
+	return j.id, nil
+}
+
+// Remove 移除任务，正在执行的那一次会继续完成
+//
+// Remove removes a job; a run in progress is allowed to finish.
+func (s *Scheduler) Remove(id JobID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j, ok := s.jobs[id]; ok {
+		delete(s.jobs, id)
+		close(j.stop)
+	}
+}
+
+// Entries 返回所有任务的调度信息，按下一次触发时间排序
+//
+// Entries returns every job's scheduling state, sorted by next firing time.
+func (s *Scheduler) Entries() []Entry {
+	s.mu.Lock()
+	entries := make([]Entry, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		j.mu.Lock()
+		entries = append(entries, Entry{ID: j.id, Name: j.o.Name, Spec: j.spec.String(), Next: j.next, Prev: j.prev})
+		j.mu.Unlock()
+	}
+	s.mu.Unlock()
+	sort.Slice(entries, func(a, b int) bool {
+		if entries[a].Next.Equal(entries[b].Next) {
+			return entries[a].ID < entries[b].ID
+		}
+		return entries[a].Next.Before(entries[b].Next)
+	})
+	return entries
+}
+
+// Start 开始调度，重复调用无效
+//
+// Start begins scheduling; repeated calls have no effect.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started || s.stopped {
+		return
+	}
+	s.started = true
+	for _, j := range s.jobs {
+		s.launch(j)
+	}
+}
+
+// Stop 停止调度并等待正在执行的任务结束
+// 参数:
+//   - ctx: 限制等待时间，结束后取消任务的 ctx 并立即返回
+//
+// 返回:
+//   - error: 等待超时时返回 ctx 的错误
+//
+// Stop stops scheduling and waits for running jobs to finish.
+// Parameters:
+//   - ctx: Bounds the wait; when it ends the jobs' ctx is cancelled and Stop returns immediately
+//
+// Returns:
+//   - error: ctx's error if the wait timed out
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.stopped {
+		s.stopped = true
+		for id, j := range s.jobs {
+			delete(s.jobs, id)
+			close(j.stop)
+		}
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.loops.Wait()
+		s.runs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancel()
+		return nil
+	case <-ctx.Done():
+		s.cancel()
+		return ctx.Err()
+	}
+}
+
+// launch 启动任务的调度循环；调用者必须持有 mu
+//
+// launch starts a job's scheduling loop; the caller must hold mu
+func (s *Scheduler) launch(j *job) {
+	switch j.o.Overlap {
+	case OverlapSkip:
+		j.trigger = make(chan struct{})
+	case OverlapDelay:
+		j.trigger = make(chan struct{}, 1)
+	}
+	if j.trigger != nil {
+		s.runs.Add(1)
+		go s.worker(j)
+	}
+	s.loops.Add(1)
+	go s.loop(j)
+}
+
+// loop 等待触发时间并分发执行
+//
+// loop waits for firing times and dispatches runs
+func (s *Scheduler) loop(j *job) {
+	defer s.loops.Done()
+	if j.trigger != nil {
+		defer close(j.trigger)
+	}
+	for {
+		next := j.spec.Next(time.Now().In(j.o.Location))
+		j.mu.Lock()
+		j.next = next
+		j.mu.Unlock()
+		if next.IsZero() {
+			return
+		}
+		wait := time.Until(next)
+		if j.o.Jitter > 0 {
+			wait += rand.N(j.o.Jitter)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-j.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		switch j.o.Overlap {
+		case OverlapAllow:
+			s.runs.Add(1)
+			go func() {
+				defer s.runs.Done()
+				s.run(j)
+			}()
+		default:
+			// OverlapSkip 的通道无缓冲，工作者忙时丢弃；OverlapDelay 的通道有一个缓冲，合并多次触发
+			select {
+			case j.trigger <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// worker 依次执行触发的任务
+//
+// worker runs triggered jobs one at a time
+func (s *Scheduler) worker(j *job) {
+	defer s.runs.Done()
+	for range j.trigger {
+		s.run(j)
+	}
+}
+
+// run 执行一次任务，捕获 panic 并上报错误
+//
+// run executes a job once, recovering panics and reporting errors
+func (s *Scheduler) run(j *job) {
+	j.mu.Lock()
+	j.prev = time.Now()
+	j.mu.Unlock()
+
+	ctx := s.ctx
+	if j.o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, j.o.Timeout)
+		defer cancel()
+	}
+	err := errorutil.Recover(func() error { return j.fn(ctx) })
+	if err != nil && s.o.OnError != nil {
+		s.o.OnError(j.o.Name, err)
+	}
+}
@@ -0,0 +1,221 @@
+package semverutil
+
+import (
+	"fmt"
+	"strings"
+)
+
+// comparator 单个比较条件
+//
+// comparator is a single comparison
+type comparator struct {
+	op      string
+	version Version
+}
+
+// match 报告 v 是否满足比较条件
+//
+// match reports whether v satisfies the comparison
+func (c comparator) match(v Version) bool {
+	n := v.Compare(c.version)
+	switch c.op {
+	case "=":
+		return n == 0
+	case "!=":
+		return n != 0
+	case ">":
+		return n > 0
+	case ">=":
+		return n >= 0
+	case "<":
+		return n < 0
+	default: // "<="
+		return n <= 0
+	}
+}
+
+// Constraint 版本约束，由 "||" 分隔的若干组条件构成，组内的条件以空格或逗号分隔且需同时满足
+// 支持的运算符：=、==、!=、>、>=、<、<=、~（允许修订号变化）、^（允许不改变最左侧非零位的变化）；
+// 也支持 x 通配符，例如 "1.2.x"
+//
+// Constraint is a version constraint made of "||"-separated groups; the comparisons within a group are separated by spaces or commas and must all hold.
+// Supported operators: =, ==, !=, >, >=, <, <=, ~ (patch-level changes) and ^ (changes that keep the left-most non-zero part);
+// x wildcards such as "1.2.x" are supported too.
+type Constraint struct {
+	raw    string
+	groups [][]comparator
+}
+
+// ParseConstraint 解析版本约束
+// 参数:
+//   - s: 约束，例如 ">=1.2.0 <2.0.0"、"^1.4 || ~2.0.3"
+//
+// 返回:
+//   - *Constraint: 约束
+//   - error: 格式错误时返回 ErrInvalidConstraint
+//
+// ParseConstraint parses a version constraint.
+// Parameters:
+//   - s: The constraint, e.g. ">=1.2.0 <2.0.0", "^1.4 || ~2.0.3"
+//
+// Returns:
+//   - *Constraint: The constraint
+//   - error: Returns ErrInvalidConstraint if malformed
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: s}
+	for group := range strings.SplitSeq(s, "||") {
+		var comps []comparator
+		fields := strings.FieldsFunc(group, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' })
+		for i := 0; i < len(fields); i++ {
+			f := fields[i]
+			// 允许运算符与版本号之间有空格，例如 ">= 1.2.0"
+			if strings.Trim(f, "=!<>~^") == "" && i+1 < len(fields) {
+				f += fields[i+1]
+				i++
+			}
+			parsed, err := parseComparator(f)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %q: %v", ErrInvalidConstraint, s, err)
+			}
+			comps = append(comps, parsed...)
+		}
+		if len(comps) == 0 {
+			return nil, fmt.Errorf("%w: %q: empty group", ErrInvalidConstraint, s)
+		}
+		c.groups = append(c.groups, comps)
+	}
+	return c, nil
+}
+
+// MustParseConstraint 与 ParseConstraint 相同，但出错时 panic
+//
+// MustParseConstraint is like ParseConstraint but panics on error.
+func MustParseConstraint(s string) *Constraint {
+	c, err := ParseConstraint(s)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// String 返回原始约束字符串
+//
+// String returns the original constraint string.
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Match 报告版本是否满足约束
+// 带先行版本标识的版本只按普通的优先级比较，例如 "2.0.0-beta" 满足 "<2.0.0"
+//
+// Match reports whether the version satisfies the constraint.
+// Versions with pre-release identifiers are compared by plain precedence, so "2.0.0-beta" satisfies "<2.0.0".
+func (c *Constraint) Match(v Version) bool {
+	for _, group := range c.groups {
+		ok := true
+		for _, comp := range group {
+			if !comp.match(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Check 解析约束和版本号，并报告版本是否满足约束
+// 参数:
+//   - constraint: 约束
+//   - version: 版本号
+//
+// 返回:
+//   - bool: 是否满足
+//   - error: 约束或版本号格式错误时返回 ErrInvalidConstraint 或 ErrInvalidVersion
+//
+// Check parses a constraint and a version string and reports whether the version satisfies the constraint.
+// Parameters:
+//   - constraint: The constraint
+//   - version: The version string
+//
+// Returns:
+//   - bool: Whether it is satisfied
+//   - error: Returns ErrInvalidConstraint or ErrInvalidVersion if either is malformed
+func Check(constraint, version string) (bool, error) {
+	c, err := ParseConstraint(constraint)
+	if err != nil {
+		return false, err
+	}
+	v, err := ParseSemver(version)
+	if err != nil {
+		return false, err
+	}
+	return c.Match(v), nil
+}
+
+// parseComparator 解析单个条件，~、^ 和通配符会展开为一对范围条件
+//
+// parseComparator parses a single comparison; ~, ^ and wildcards expand to a pair of range comparisons
+func parseComparator(s string) ([]comparator, error) {
+	op := ""
+	for _, prefix := range []string{">=", "<=", "!=", "==", ">", "<", "=", "~", "^"} {
+		if rest, ok := strings.CutPrefix(s, prefix); ok {
+			op, s = prefix, rest
+			break
+		}
+	}
+	if op == "==" || op == "" {
+		op = "="
+	}
+
+	// 通配符: 1.x、1.2.x、*
+	parts := strings.Split(strings.TrimPrefix(s, "v"), ".")
+	wild := -1
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			wild = i
+			break
+		}
+	}
+	if wild >= 0 {
+		parts = parts[:wild]
+	}
+	if len(parts) == 0 {
+		return []comparator{{op: ">=", version: Version{}}}, nil
+	}
+	v, err := ParseSemver(strings.Join(parts, "."))
+	if err != nil {
+		return nil, err
+	}
+	// 只写到次版本号或主版本号时，"~1.2" 与 "1.2.x" 等价
+	given := len(parts)
+	if wild >= 0 && op == "=" {
+		op = "~"
+	}
+
+	switch op {
+	case "~":
+		upper := Version{Major: v.Major + 1}
+		if given >= 2 {
+			upper = Version{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	case "^":
+		var upper Version
+		switch {
+		case v.Major > 0 || given == 1:
+			upper = Version{Major: v.Major + 1}
+		case v.Minor > 0 || given == 2:
+			upper = Version{Minor: v.Minor + 1}
+		default:
+			upper = Version{Patch: v.Patch + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}, nil
+	}
+	if wild >= 0 {
+		return nil, fmt.Errorf("wildcard not allowed with %s", op)
+	}
+	return []comparator{{op, v}}, nil
+}
@@ -0,0 +1,269 @@
+// Package semverutil 提供语义化版本（SemVer 2.0.0）解析、比较和约束匹配相关的工具函数，用于客户端版本控制和迁移逻辑
+//
+// Package semverutil provides semantic version (SemVer 2.0.0) parsing, comparison and constraint matching, for client-version gating and migration logic.
+package semverutil
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidVersion 表示版本号格式错误
+	//
+	// ErrInvalidVersion indicates that a version string is malformed
+	ErrInvalidVersion = errors.New("invalid version")
+	// ErrInvalidConstraint 表示版本约束格式错误
+	//
+	// ErrInvalidConstraint indicates that a version constraint is malformed
+	ErrInvalidConstraint = errors.New("invalid version constraint")
+)
+
+// Version 语义化版本
+// Major/Minor/Patch: 主版本号、次版本号、修订号
+// Prerelease: 先行版本标识，例如 "beta.1"
+// Build: 构建元数据，不参与比较
+//
+// Version is a semantic version.
+// Major/Minor/Patch: Major, minor and patch numbers
+// Prerelease: Pre-release identifiers, e.g. "beta.1"
+// Build: Build metadata, ignored in comparisons
+type Version struct {
+	Major      uint64
+	Minor      uint64
+	Patch      uint64
+	Prerelease string
+	Build      string
+}
+
+// ParseSemver 解析版本号，允许 "v" 前缀，缺少的次版本号和修订号视为 0（例如客户端上报的 "2.1"）
+// 参数:
+//   - s: 版本号，例如 "v1.2.3-beta.1+build.5"
+//
+// 返回:
+//   - Version: 版本
+//   - error: 格式错误时返回 ErrInvalidVersion
+//
+// ParseSemver parses a version string; a "v" prefix is allowed and missing minor and patch numbers count as 0 (e.g. "2.1" reported by clients).
+// Parameters:
+//   - s: Version string, e.g. "v1.2.3-beta.1+build.5"
+//
+// Returns:
+//   - Version: The version
+//   - error: Returns ErrInvalidVersion if malformed
+func ParseSemver(s string) (Version, error) {
+	var v Version
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	var hasBuild, hasPre bool
+	rest, v.Build, hasBuild = strings.Cut(rest, "+")
+	rest, v.Prerelease, hasPre = strings.Cut(rest, "-")
+
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 || rest == "" {
+		return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+	nums := [3]*uint64{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := parseNumber(p)
+		if err != nil {
+			return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+		}
+		*nums[i] = n
+	}
+	if hasPre && !validIdentifiers(v.Prerelease, true) {
+		return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+	if hasBuild && !validIdentifiers(v.Build, false) {
+		return Version{}, fmt.Errorf("%w: %q", ErrInvalidVersion, s)
+	}
+	return v, nil
+}
+
+// MustParseSemver 与 ParseSemver 相同，但出错时 panic，用于常量版本号
+//
+// MustParseSemver is like ParseSemver but panics on error, for constant version strings.
+func MustParseSemver(s string) Version {
+	v, err := ParseSemver(s)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// String 返回版本号的规范形式（不带 "v" 前缀）
+//
+// String returns the canonical form of the version (without a "v" prefix).
+func (v Version) String() string {
+	s := strconv.FormatUint(v.Major, 10) + "." + strconv.FormatUint(v.Minor, 10) + "." + strconv.FormatUint(v.Patch, 10)
+	if v.Prerelease != "" {
+		s += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare 按 SemVer 优先级比较两个版本，忽略构建元数据
+// 返回:
+//   - int: v < o 返回 -1，相等返回 0，v > o 返回 1
+//
+// Compare compares two versions by SemVer precedence, ignoring build metadata.
+// Returns:
+//   - int: -1 if v < o, 0 if equal, 1 if v > o
+func (v Version) Compare(o Version) int {
+	if c := cmp.Compare(v.Major, o.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, o.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, o.Patch); c != 0 {
+		return c
+	}
+	return comparePrerelease(v.Prerelease, o.Prerelease)
+}
+
+// LessThan 报告 v 是否低于 o
+//
+// LessThan reports whether v is lower than o.
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// Compare 解析并比较两个版本号字符串
+// 参数:
+//   - a: 版本号
+//   - b: 版本号
+//
+// 返回:
+//   - int: a < b 返回 -1，相等返回 0，a > b 返回 1
+//   - error: 任一版本号格式错误时返回 ErrInvalidVersion
+//
+// Compare parses and compares two version strings.
+// Parameters:
+//   - a: A version string
+//   - b: A version string
+//
+// Returns:
+//   - int: -1 if a < b, 0 if equal, 1 if a > b
+//   - error: Returns ErrInvalidVersion if either version is malformed
+func Compare(a, b string) (int, error) {
+	va, err := ParseSemver(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := ParseSemver(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// Sort 按从低到高的顺序原地排序版本
+//
+// Sort sorts versions in place from lowest to highest.
+func Sort(versions []Version) {
+	slices.SortStableFunc(versions, Version.Compare)
+}
+
+// SortStrings 按从低到高的顺序原地排序版本号字符串，无法解析的排在最前面并保持原有顺序
+//
+// SortStrings sorts version strings in place from lowest to highest; unparsable strings go first in their original order.
+func SortStrings(versions []string) {
+	parsed := make(map[string]*Version, len(versions))
+	for _, s := range versions {
+		if v, err := ParseSemver(s); err == nil {
+			parsed[s] = &v
+		}
+	}
+	slices.SortStableFunc(versions, func(a, b string) int {
+		va, vb := parsed[a], parsed[b]
+		switch {
+		case va == nil && vb == nil:
+			return 0
+		case va == nil:
+			return -1
+		case vb == nil:
+			return 1
+		}
+		return va.Compare(*vb)
+	})
+}
+
+// comparePrerelease 比较先行版本标识，没有先行版本的优先级更高
+//
+// comparePrerelease compares pre-release identifiers; a version without one has higher precedence
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(as), len(bs)) {
+		an, aErr := strconv.ParseUint(as[i], 10, 64)
+		bn, bErr := strconv.ParseUint(bs[i], 10, 64)
+		var c int
+		switch {
+		case aErr == nil && bErr == nil:
+			c = cmp.Compare(an, bn)
+		case aErr == nil:
+			// 数字标识符的优先级低于字母标识符
+			c = -1
+		case bErr == nil:
+			c = 1
+		default:
+			c = strings.Compare(as[i], bs[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// parseNumber 解析不带前导零的版本号数字
+//
+// parseNumber parses a version number without leading zeros
+func parseNumber(s string) (uint64, error) {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return 0, ErrInvalidVersion
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// validIdentifiers 校验以 "." 分隔的标识符，先行版本的数字标识符不能有前导零
+//
+// validIdentifiers validates "."-separated identifiers; numeric pre-release identifiers must not have leading zeros
+func validIdentifiers(s string, prerelease bool) bool {
+	if s == "" {
+		return false
+	}
+	for id := range strings.SplitSeq(s, ".") {
+		if id == "" {
+			return false
+		}
+		numeric := true
+		for _, r := range id {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				numeric = false
+			default:
+				return false
+			}
+		}
+		if prerelease && numeric && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,173 @@
+package signutil
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// AlipaySignTypeRSA2 支付宝 RSA2（SHA256withRSA）签名类型
+//
+// AlipaySignTypeRSA2 is the Alipay RSA2 (SHA256withRSA) sign type
+const AlipaySignTypeRSA2 = "RSA2"
+
+// AlipaySigner 支付宝开放平台请求签名器
+//
+// AlipaySigner is the Alipay open platform request signer.
+type AlipaySigner struct {
+	privateKey *rsa.PrivateKey
+}
+
+// NewAlipaySigner 创建支付宝请求签名器
+// 参数:
+//   - privateKey: 应用私钥，支持 PEM 或支付宝开放平台工具生成的裸 base64 格式
+//
+// 返回:
+//   - *AlipaySigner: 签名器
+//   - error: 如果私钥无效，返回错误
+//
+// NewAlipaySigner creates an Alipay request signer.
+// Parameters:
+//   - privateKey: Application private key, PEM or the bare base64 format generated by the Alipay tools
+//
+// Returns:
+//   - *AlipaySigner: The signer
+//   - error: Returns an error if the private key is invalid
+func NewAlipaySigner(privateKey []byte) (*AlipaySigner, error) {
+	key, err := ParseRSAPrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &AlipaySigner{privateKey: key}, nil
+}
+
+// Sign 计算请求参数的 RSA2 签名（sign 参数不参与签名，sign_type 参与签名）
+//
+// Sign computes the RSA2 signature of request parameters (sign is excluded, sign_type is included).
+func (s *AlipaySigner) Sign(params url.Values) (string, error) {
+	return SignSHA256WithRSA(s.privateKey, []byte(AlipaySignContent(params, "sign")))
+}
+
+// SignParams 设置 sign_type 并计算签名，返回可直接作为请求参数的副本
+//
+// SignParams sets sign_type, computes the signature and returns a copy ready to be used as request parameters.
+func (s *AlipaySigner) SignParams(params url.Values) (url.Values, error) {
+	out := make(url.Values, len(params)+2)
+	for k, v := range params {
+		out[k] = append([]string(nil), v...)
+	}
+	out.Set("sign_type", AlipaySignTypeRSA2)
+	sign, err := s.Sign(out)
+	if err != nil {
+		return nil, err
+	}
+	out.Set("sign", sign)
+	return out, nil
+}
+
+// AlipayVerifier 支付宝应答和异步通知的验签器
+//
+// AlipayVerifier verifies Alipay responses and asynchronous notifications.
+type AlipayVerifier struct {
+	publicKey *rsa.PublicKey
+}
+
+// NewAlipayVerifier 创建支付宝验签器
+// 参数:
+//   - alipayPublicKey: 支付宝公钥（不是应用公钥），支持 PEM、证书或裸 base64 格式
+//
+// 返回:
+//   - *AlipayVerifier: 验签器
+//   - error: 如果公钥无效，返回错误
+//
+// NewAlipayVerifier creates an Alipay verifier.
+// Parameters:
+//   - alipayPublicKey: The Alipay public key (not the application public key), PEM, certificate or bare base64
+//
+// Returns:
+//   - *AlipayVerifier: The verifier
+//   - error: Returns an error if the public key is invalid
+func NewAlipayVerifier(alipayPublicKey []byte) (*AlipayVerifier, error) {
+	pub, err := ParseRSAPublicKey(alipayPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &AlipayVerifier{publicKey: pub}, nil
+}
+
+// VerifyNotification 验证异步通知的签名（sign 和 sign_type 均不参与签名）
+// 参数:
+//   - form: 通知的表单参数，通常为 r.PostForm
+//
+// 返回:
+//   - error: 签名无效时返回 ErrInvalidSignature
+//
+// VerifyNotification verifies the signature of an asynchronous notification (both sign and sign_type are excluded).
+// Parameters:
+//   - form: Form parameters of the notification, usually r.PostForm
+//
+// Returns:
+//   - error: Returns ErrInvalidSignature if the signature is invalid
+func (v *AlipayVerifier) VerifyNotification(form url.Values) error {
+	if t := form.Get("sign_type"); t != "" && t != AlipaySignTypeRSA2 {
+		return fmt.Errorf("%w: unsupported sign_type %s", ErrInvalidSignature, t)
+	}
+	sign := form.Get("sign")
+	if sign == "" {
+		return fmt.Errorf("%w: missing sign", ErrInvalidSignature)
+	}
+	return VerifySHA256WithRSA(v.publicKey, []byte(AlipaySignContent(form, "sign", "sign_type")), sign)
+}
+
+// VerifyResponse 验证同步应答的签名
+// 参数:
+//   - content: 应答 JSON 中 xxx_response 字段的原始内容（不能重新序列化）
+//   - sign: 应答 JSON 中的 sign 字段
+//
+// 返回:
+//   - error: 签名无效时返回 ErrInvalidSignature
+//
+// VerifyResponse verifies the signature of a synchronous response.
+// Parameters:
+//   - content: Raw content of the xxx_response field in the response JSON (must not be re-serialized)
+//   - sign: The sign field of the response JSON
+//
+// Returns:
+//   - error: Returns ErrInvalidSignature if the signature is invalid
+func (v *AlipayVerifier) VerifyResponse(content []byte, sign string) error {
+	return VerifySHA256WithRSA(v.publicKey, content, sign)
+}
+
+// AlipaySignContent 生成待签名字符串：按参数名 ASCII 排序，跳过空值和 exclude 中的参数，以 "k=v&k=v" 连接（值不做 URL 编码）
+//
+// AlipaySignContent builds the string to sign: parameters sorted by ASCII name, skipping empty values and excluded names,
+// joined as "k=v&k=v" (values are not URL-encoded).
+func AlipaySignContent(params url.Values, exclude ...string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		skip := false
+		for _, e := range exclude {
+			if k == e {
+				skip = true
+				break
+			}
+		}
+		if !skip && params.Get(k) != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte('&')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(params.Get(k))
+	}
+	return b.String()
+}
@@ -0,0 +1,143 @@
+// Package signutil 提供国内支付渠道的签名与验签工具函数，包括微信支付 APIv3 和支付宝开放平台
+//
+// Package signutil provides signing and verification utility functions for Chinese payment channels,
+// including WeChat Pay APIv3 and the Alipay open platform.
+package signutil
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/supergodk/go-utils/v1/cryptoutil"
+)
+
+var (
+	// ErrInvalidSignature 表示签名验证失败
+	//
+	// ErrInvalidSignature indicates that signature verification failed.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrInvalidKey 表示密钥无效或不是 RSA 密钥
+	//
+	// ErrInvalidKey indicates that the key is invalid or not an RSA key.
+	ErrInvalidKey = errors.New("invalid rsa key")
+)
+
+// ParseRSAPrivateKey 解析 RSA 私钥
+// 支持 PEM 格式，以及支付宝开放平台常见的不带 PEM 头尾的 base64 密钥
+// 参数:
+//   - data: 私钥内容
+//
+// 返回:
+//   - *rsa.PrivateKey: RSA 私钥
+//   - error: 如果解析失败或不是 RSA 密钥，返回错误
+//
+// ParseRSAPrivateKey parses an RSA private key.
+// Supports PEM, as well as bare base64 keys without PEM armor as commonly provided by the Alipay open platform.
+// Parameters:
+//   - data: Private key content
+//
+// Returns:
+//   - *rsa.PrivateKey: RSA private key
+//   - error: Returns an error if parsing fails or it is not an RSA key
+func ParseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	key, err := cryptoutil.ParsePrivateKeyPEM(armor(data, "PRIVATE KEY"))
+	if err != nil {
+		// 裸 base64 也可能是 PKCS#1 格式
+		key, err = cryptoutil.ParsePrivateKeyPEM(armor(data, "RSA PRIVATE KEY"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+		}
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: got %T", ErrInvalidKey, key)
+	}
+	return rsaKey, nil
+}
+
+// ParseRSAPublicKey 解析 RSA 公钥
+// 支持 PEM 格式的公钥或证书，以及不带 PEM 头尾的 base64 公钥
+// 参数:
+//   - data: 公钥或证书内容
+//
+// 返回:
+//   - *rsa.PublicKey: RSA 公钥
+//   - error: 如果解析失败或不是 RSA 密钥，返回错误
+//
+// ParseRSAPublicKey parses an RSA public key.
+// Supports a PEM public key or certificate, as well as bare base64 public keys without PEM armor.
+// Parameters:
+//   - data: Public key or certificate content
+//
+// Returns:
+//   - *rsa.PublicKey: RSA public key
+//   - error: Returns an error if parsing fails or it is not an RSA key
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	key, err := cryptoutil.ParsePublicKeyPEM(armor(data, "PUBLIC KEY"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%w: got %T", ErrInvalidKey, key)
+	}
+	return rsaKey, nil
+}
+
+// SignSHA256WithRSA 使用 SHA256withRSA（PKCS#1 v1.5）签名并返回 base64 编码的签名
+//
+// SignSHA256WithRSA signs with SHA256withRSA (PKCS#1 v1.5) and returns the base64 encoded signature.
+func SignSHA256WithRSA(key *rsa.PrivateKey, message []byte) (string, error) {
+	digest := sha256.Sum256(message)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// VerifySHA256WithRSA 验证 base64 编码的 SHA256withRSA（PKCS#1 v1.5）签名
+//
+// VerifySHA256WithRSA verifies a base64 encoded SHA256withRSA (PKCS#1 v1.5) signature.
+func VerifySHA256WithRSA(pub *rsa.PublicKey, message []byte, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	digest := sha256.Sum256(message)
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// armor 如果数据不是 PEM 格式，则将其作为 base64 DER 包装为指定类型的 PEM 块
+//
+// armor wraps the data as a PEM block of the given type if it is not already PEM, treating it as base64 DER
+func armor(data []byte, blockType string) []byte {
+	if block, _ := pem.Decode(data); block != nil {
+		return data
+	}
+	der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(string(data)), ""))
+	if err != nil {
+		return data
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+// nonceStr 生成 32 位随机字符串
+//
+// nonceStr generates a 32-character random string
+func nonceStr() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return strings.ToUpper(hex.EncodeToString(b))
+}
@@ -0,0 +1,459 @@
+package signutil
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// WechatPayAuthSchema 微信支付 APIv3 的认证类型
+	//
+	// WechatPayAuthSchema is the authentication schema of WeChat Pay APIv3
+	WechatPayAuthSchema = "WECHATPAY2-SHA256-RSA2048"
+
+	// WechatPayMaxClockSkew 应答和回调中时间戳允许的最大偏差
+	//
+	// WechatPayMaxClockSkew is the maximum allowed skew of timestamps in responses and notifications
+	WechatPayMaxClockSkew = 5 * time.Minute
+
+	// 微信支付应答和回调中的签名相关头
+	//
+	// Signature related headers in WeChat Pay responses and notifications
+	HeaderWechatPayTimestamp = "Wechatpay-Timestamp"
+	HeaderWechatPayNonce     = "Wechatpay-Nonce"
+	HeaderWechatPaySignature = "Wechatpay-Signature"
+	HeaderWechatPaySerial    = "Wechatpay-Serial"
+)
+
+var (
+	// ErrUnknownSerial 表示找不到应答或回调中序列号对应的平台证书或公钥
+	//
+	// ErrUnknownSerial indicates that no platform certificate or public key matches the serial in the response or notification.
+	ErrUnknownSerial = errors.New("unknown wechat pay certificate serial")
+	// ErrTimestampExpired 表示应答或回调的时间戳超出允许范围，可能是重放请求
+	//
+	// ErrTimestampExpired indicates that the timestamp is out of the allowed range, possibly a replayed request.
+	ErrTimestampExpired = errors.New("wechat pay timestamp expired")
+	// ErrDecryptResource 表示解密回调资源或平台证书失败
+	//
+	// ErrDecryptResource indicates that decrypting the notification resource or platform certificate failed.
+	ErrDecryptResource = errors.New("failed to decrypt wechat pay resource")
+)
+
+// WechatPaySigner 微信支付 APIv3 请求签名器（商户侧）
+//
+// WechatPaySigner is the WeChat Pay APIv3 request signer (merchant side).
+type WechatPaySigner struct {
+	mchID      string
+	serialNo   string
+	privateKey *rsa.PrivateKey
+}
+
+// NewWechatPaySigner 创建微信支付请求签名器
+// 参数:
+//   - mchID: 商户号
+//   - serialNo: 商户 API 证书序列号
+//   - privateKeyPEM: 商户 API 私钥（apiclient_key.pem）
+//
+// 返回:
+//   - *WechatPaySigner: 签名器
+//   - error: 如果私钥无效，返回错误
+//
+// NewWechatPaySigner creates a WeChat Pay request signer.
+// Parameters:
+//   - mchID: Merchant ID
+//   - serialNo: Serial number of the merchant API certificate
+//   - privateKeyPEM: Merchant API private key (apiclient_key.pem)
+//
+// Returns:
+//   - *WechatPaySigner: The signer
+//   - error: Returns an error if the private key is invalid
+func NewWechatPaySigner(mchID, serialNo string, privateKeyPEM []byte) (*WechatPaySigner, error) {
+	key, err := ParseRSAPrivateKey(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &WechatPaySigner{mchID: mchID, serialNo: serialNo, privateKey: key}, nil
+}
+
+// Authorization 为请求生成 Authorization 头的值
+// 参数:
+//   - method: HTTP 方法，例如 "POST"
+//   - canonicalURL: 请求路径和查询参数，例如 "/v3/pay/transactions/jsapi"
+//   - body: 请求体，GET 请求为空
+//
+// 返回:
+//   - string: Authorization 头的值
+//   - error: 如果签名失败，返回错误
+//
+// Authorization generates the value of the Authorization header for a request.
+// Parameters:
+//   - method: HTTP method, e.g. "POST"
+//   - canonicalURL: Request path and query, e.g. "/v3/pay/transactions/jsapi"
+//   - body: Request body, empty for GET requests
+//
+// Returns:
+//   - string: Value of the Authorization header
+//   - error: Returns an error if signing fails
+func (s *WechatPaySigner) Authorization(method, canonicalURL string, body []byte) (string, error) {
+	nonce := nonceStr()
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	message := method + "\n" + canonicalURL + "\n" + timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+	signature, err := SignSHA256WithRSA(s.privateKey, []byte(message))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`%s mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		WechatPayAuthSchema, s.mchID, nonce, signature, timestamp, s.serialNo), nil
+}
+
+// SignRequest 为 http.Request 设置 Authorization 头，请求体会被读取后重新设置
+//
+// SignRequest sets the Authorization header on an http.Request; the body is read and restored.
+func (s *WechatPaySigner) SignRequest(req *http.Request) error {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	auth, err := s.Authorization(req.Method, req.URL.RequestURI(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", auth)
+	req.Header.Set("Accept", "application/json")
+	return nil
+}
+
+// SignJSAPI 生成 JSAPI/小程序调起支付所需的参数
+// 参数:
+//   - appID: 公众号或小程序 AppID
+//   - prepayID: 下单接口返回的 prepay_id
+//
+// 返回:
+//   - map[string]string: 包含 appId、timeStamp、nonceStr、package、signType、paySign 的参数
+//   - error: 如果签名失败，返回错误
+//
+// SignJSAPI generates the parameters needed to invoke payment from JSAPI or mini programs.
+// Parameters:
+//   - appID: AppID of the official account or mini program
+//   - prepayID: prepay_id returned by the order API
+//
+// Returns:
+//   - map[string]string: Parameters appId, timeStamp, nonceStr, package, signType and paySign
+//   - error: Returns an error if signing fails
+func (s *WechatPaySigner) SignJSAPI(appID, prepayID string) (map[string]string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce := nonceStr()
+	pkg := "prepay_id=" + prepayID
+	paySign, err := SignSHA256WithRSA(s.privateKey, []byte(appID+"\n"+timestamp+"\n"+nonce+"\n"+pkg+"\n"))
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"appId":     appID,
+		"timeStamp": timestamp,
+		"nonceStr":  nonce,
+		"package":   pkg,
+		"signType":  "RSA",
+		"paySign":   paySign,
+	}, nil
+}
+
+// WechatPayVerifier 微信支付应答和回调的验签器
+// 同时支持平台证书（按证书序列号）和微信支付公钥（按公钥 ID，例如 "PUB_KEY_ID_..."），查找时忽略大小写
+//
+// WechatPayVerifier verifies signatures of WeChat Pay responses and notifications.
+// It supports both platform certificates (by certificate serial) and WeChat Pay public keys (by key ID, e.g. "PUB_KEY_ID_..."), matched case-insensitively.
+type WechatPayVerifier struct {
+	mu   sync.RWMutex
+	keys map[string]wechatPayKey
+}
+
+// wechatPayKey 平台公钥及其有效期（公钥模式下有效期为零值）
+//
+// wechatPayKey is a platform public key with its validity period (zero for public key mode)
+type wechatPayKey struct {
+	pub       *rsa.PublicKey
+	notAfter  time.Time
+	notBefore time.Time
+}
+
+// NewWechatPayVerifier 创建微信支付验签器
+//
+// NewWechatPayVerifier creates a WeChat Pay verifier.
+func NewWechatPayVerifier() *WechatPayVerifier {
+	return &WechatPayVerifier{keys: make(map[string]wechatPayKey)}
+}
+
+// AddCertificate 添加平台证书，序列号取自证书本身
+//
+// AddCertificate adds a platform certificate; the serial is taken from the certificate itself.
+func (v *WechatPayVerifier) AddCertificate(cert *x509.Certificate) error {
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: certificate key is %T", ErrInvalidKey, cert.PublicKey)
+	}
+	v.mu.Lock()
+	v.keys[wechatPaySerialKey(CertificateSerial(cert))] = wechatPayKey{pub: pub, notBefore: cert.NotBefore, notAfter: cert.NotAfter}
+	v.mu.Unlock()
+	return nil
+}
+
+// AddCertificatePEM 添加 PEM 编码的平台证书
+//
+// AddCertificatePEM adds a PEM encoded platform certificate.
+func (v *WechatPayVerifier) AddCertificatePEM(data []byte) error {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("%w: no PEM block found", ErrInvalidKey)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidKey, err)
+	}
+	return v.AddCertificate(cert)
+}
+
+// AddPublicKey 添加微信支付公钥
+// 参数:
+//   - keyID: 公钥 ID，即回调中 Wechatpay-Serial 头的值
+//   - publicKeyPEM: PEM 编码的微信支付公钥
+//
+// AddPublicKey adds a WeChat Pay public key.
+// Parameters:
+//   - keyID: Public key ID, i.e. the value of the Wechatpay-Serial header in notifications
+//   - publicKeyPEM: PEM encoded WeChat Pay public key
+func (v *WechatPayVerifier) AddPublicKey(keyID string, publicKeyPEM []byte) error {
+	pub, err := ParseRSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	v.keys[wechatPaySerialKey(keyID)] = wechatPayKey{pub: pub}
+	v.mu.Unlock()
+	return nil
+}
+
+// LoadPlatformCertificates 解析 /v3/certificates 接口的应答，解密并添加其中的平台证书
+// 参数:
+//   - body: 接口应答体（应先使用 Verify 验证应答签名）
+//   - apiV3Key: 商户 APIv3 密钥
+//
+// 返回:
+//   - int: 添加的证书数量
+//   - error: 如果解析或解密失败，返回错误
+//
+// LoadPlatformCertificates parses the response of the /v3/certificates API, decrypts and adds the platform certificates in it.
+// Parameters:
+//   - body: Response body (its signature should be verified with Verify first)
+//   - apiV3Key: Merchant APIv3 key
+//
+// Returns:
+//   - int: Number of certificates added
+//   - error: Returns an error if parsing or decryption fails
+func (v *WechatPayVerifier) LoadPlatformCertificates(body []byte, apiV3Key string) (int, error) {
+	var resp struct {
+		Data []struct {
+			SerialNo           string            `json:"serial_no"`
+			EncryptCertificate WechatPayResource `json:"encrypt_certificate"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, err
+	}
+	for i, item := range resp.Data {
+		plain, err := item.EncryptCertificate.Decrypt(apiV3Key)
+		if err != nil {
+			return i, err
+		}
+		if err := v.AddCertificatePEM(plain); err != nil {
+			return i, err
+		}
+	}
+	return len(resp.Data), nil
+}
+
+// Verify 验证应答或回调的签名
+// 参数:
+//   - header: 应答或回调的 HTTP 头
+//   - body: 应答或回调的原始请求体
+//
+// 返回:
+//   - error: 签名无效返回 ErrInvalidSignature，找不到证书返回 ErrUnknownSerial，时间戳过期返回 ErrTimestampExpired
+//
+// Verify verifies the signature of a response or notification.
+// Parameters:
+//   - header: HTTP headers of the response or notification
+//   - body: Raw body of the response or notification
+//
+// Returns:
+//   - error: ErrInvalidSignature for an invalid signature, ErrUnknownSerial if no certificate matches, ErrTimestampExpired for a stale timestamp
+func (v *WechatPayVerifier) Verify(header http.Header, body []byte) error {
+	timestamp := header.Get(HeaderWechatPayTimestamp)
+	nonce := header.Get(HeaderWechatPayNonce)
+	signature := header.Get(HeaderWechatPaySignature)
+	serial := header.Get(HeaderWechatPaySerial)
+	if timestamp == "" || nonce == "" || signature == "" || serial == "" {
+		return fmt.Errorf("%w: missing signature headers", ErrInvalidSignature)
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", ErrInvalidSignature)
+	}
+	now := time.Now()
+	if d := now.Sub(time.Unix(ts, 0)); d > WechatPayMaxClockSkew || d < -WechatPayMaxClockSkew {
+		return ErrTimestampExpired
+	}
+
+	v.mu.RLock()
+	key, ok := v.keys[wechatPaySerialKey(serial)]
+	v.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownSerial, serial)
+	}
+	if !key.notAfter.IsZero() && (now.After(key.notAfter) || now.Before(key.notBefore)) {
+		return fmt.Errorf("%w: certificate %s is not valid at this time", ErrUnknownSerial, serial)
+	}
+
+	return VerifySHA256WithRSA(key.pub, []byte(timestamp+"\n"+nonce+"\n"+string(body)+"\n"), signature)
+}
+
+// WechatPayResource 回调或平台证书中的加密数据（AEAD_AES_256_GCM）
+//
+// WechatPayResource is the encrypted data in notifications or platform certificates (AEAD_AES_256_GCM).
+type WechatPayResource struct {
+	Algorithm      string `json:"algorithm"`
+	Ciphertext     string `json:"ciphertext"`
+	AssociatedData string `json:"associated_data"`
+	Nonce          string `json:"nonce"`
+	OriginalType   string `json:"original_type"`
+}
+
+// Decrypt 使用 APIv3 密钥解密数据
+//
+// Decrypt decrypts the data with the APIv3 key.
+func (r *WechatPayResource) Decrypt(apiV3Key string) ([]byte, error) {
+	if len(apiV3Key) != 32 {
+		return nil, fmt.Errorf("%w: apiv3 key must be 32 bytes", ErrDecryptResource)
+	}
+	ciphertext, err := base64.StdEncoding.DecodeString(r.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptResource, err)
+	}
+	block, err := aes.NewCipher([]byte(apiV3Key))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptResource, err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(r.Nonce))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptResource, err)
+	}
+	plain, err := gcm.Open(nil, []byte(r.Nonce), ciphertext, []byte(r.AssociatedData))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDecryptResource, err)
+	}
+	return plain, nil
+}
+
+// WechatPayNotification 微信支付回调通知
+// ID: 通知 ID
+// CreateTime: 通知创建时间
+// EventType: 通知类型，例如 "TRANSACTION.SUCCESS"
+// Summary: 回调摘要
+// Resource: 加密的通知数据
+// Plaintext: 解密后的通知数据（JSON）
+//
+// WechatPayNotification is a WeChat Pay callback notification.
+// ID: Notification ID
+// CreateTime: Creation time of the notification
+// EventType: Event type, e.g. "TRANSACTION.SUCCESS"
+// Summary: Summary
+// Resource: Encrypted notification data
+// Plaintext: Decrypted notification data (JSON)
+type WechatPayNotification struct {
+	ID           string            `json:"id"`
+	CreateTime   string            `json:"create_time"`
+	EventType    string            `json:"event_type"`
+	ResourceType string            `json:"resource_type"`
+	Summary      string            `json:"summary"`
+	Resource     WechatPayResource `json:"resource"`
+	Plaintext    []byte            `json:"-"`
+}
+
+// Unmarshal 将解密后的通知数据解析到 v
+//
+// Unmarshal parses the decrypted notification data into v.
+func (n *WechatPayNotification) Unmarshal(v any) error {
+	return json.Unmarshal(n.Plaintext, v)
+}
+
+// ParseNotification 验证回调签名并解密通知数据
+// 参数:
+//   - r: 回调请求，请求体会被读取
+//   - apiV3Key: 商户 APIv3 密钥
+//
+// 返回:
+//   - *WechatPayNotification: 解密后的通知
+//   - error: 如果验签或解密失败，返回错误
+//
+// ParseNotification verifies the notification signature and decrypts the notification data.
+// Parameters:
+//   - r: Notification request; its body is consumed
+//   - apiV3Key: Merchant APIv3 key
+//
+// Returns:
+//   - *WechatPayNotification: The decrypted notification
+//   - error: Returns an error if verification or decryption fails
+func (v *WechatPayVerifier) ParseNotification(r *http.Request, apiV3Key string) (*WechatPayNotification, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if err := v.Verify(r.Header, body); err != nil {
+		return nil, err
+	}
+
+	var n WechatPayNotification
+	if err := json.Unmarshal(body, &n); err != nil {
+		return nil, err
+	}
+	if n.Plaintext, err = n.Resource.Decrypt(apiV3Key); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// CertificateSerial 返回证书序列号的十六进制大写形式（按字节编码，保留开头的 0），与微信支付使用的格式一致
+//
+// CertificateSerial returns the certificate serial number in upper-case hex (encoded byte by byte, keeping leading zeros), matching the format used by WeChat Pay.
+func CertificateSerial(cert *x509.Certificate) string {
+	return strings.ToUpper(hex.EncodeToString(cert.SerialNumber.Bytes()))
+}
+
+// wechatPaySerialKey 返回序列号或公钥 ID 在验签器中的键，序列号比较时忽略大小写
+//
+// wechatPaySerialKey returns the verifier key of a serial or public key ID; serials are compared case-insensitively
+func wechatPaySerialKey(serial string) string {
+	return strings.ToUpper(strings.TrimSpace(serial))
+}
@@ -0,0 +1,77 @@
+package sliceutil
+
+import (
+	"unicode/utf8"
+
+	"github.com/supergodk/go-utils/v1/stringutil"
+)
+
+// Cluster 一组相似的元素
+// Representative: 代表元素，即该组中最先出现的元素
+// Members: 组内所有元素（包括代表元素），按原顺序排列
+// Indexes: Members 中各元素在原切片中的下标
+//
+// Cluster is a group of similar elements.
+// Representative: The representative, i.e. the first element of the group
+// Members: Every element in the group (including the representative), in original order
+// Indexes: Indexes in the original slice of the elements in Members
+type Cluster[T any] struct {
+	Representative T
+	Members        []T
+	Indexes        []int
+}
+
+// DedupeBySimilarity 把键相似的元素归为一组，用于合并用户提交的地址、公司名等近似重复的条目
+// 按顺序处理每个元素，与已有各组的代表元素比较，相似度（stringutil.Similarity）不低于 threshold 时加入最相似的组，否则成为新组的代表，
+// 因此应先把更可信的元素（例如最新的或已验证的）排在前面；时间复杂度 O(n*组数*键长²)，适合数千条以内的数据
+// 参数:
+//   - slice: 切片，不会被修改
+//   - key: 取比较键的函数，通常在这里做小写、去空白等规范化
+//   - threshold: 相似度阈值，范围 (0, 1]，例如 0.85；为 1 时只合并键完全相同的元素
+//
+// 返回:
+//   - []Cluster[T]: 各组，按代表元素在原切片中的顺序排列；去重后的结果即各组的 Representative
+//
+// DedupeBySimilarity groups elements with similar keys, for merging near-duplicate entries such as user-submitted addresses or company names.
+// Elements are processed in order and compared with each existing group's representative; an element joins the most similar group when the similarity (stringutil.Similarity) is at least threshold, and otherwise becomes the representative of a new group,
+// so put the more trustworthy elements (e.g. the newest or verified ones) first. It takes O(n*groups*key length²) time and suits up to a few thousand elements.
+// Parameters:
+//   - slice: The slice, which is not modified
+//   - key: Returns the comparison key; normalisation such as lower-casing or removing whitespace usually goes here
+//   - threshold: Similarity threshold in (0, 1], e.g. 0.85; 1 merges only identical keys
+//
+// Returns:
+//   - []Cluster[T]: The groups, in the order of their representatives in the original slice; the deduplicated result is the groups' Representative values
+func DedupeBySimilarity[T any](slice []T, key func(T) string, threshold float64) []Cluster[T] {
+	type leader struct {
+		key   string
+		runes int
+	}
+	var clusters []Cluster[T]
+	var leaders []leader
+	for i, v := range slice {
+		k := key(v)
+		n := utf8.RuneCountInString(k)
+		best, bestSim := -1, threshold
+		for c, l := range leaders {
+			// 相似度不超过短串与长串的长度比，长度相差太大时无需计算编辑距离
+			if lo, hi := min(n, l.runes), max(n, l.runes); hi > 0 && float64(lo)/float64(hi) < bestSim {
+				continue
+			}
+			if s := stringutil.Similarity(k, l.key); s >= bestSim && (best < 0 || s > bestSim) {
+				best, bestSim = c, s
+				if s == 1 {
+					break
+				}
+			}
+		}
+		if best < 0 {
+			clusters = append(clusters, Cluster[T]{Representative: v, Members: []T{v}, Indexes: []int{i}})
+			leaders = append(leaders, leader{key: k, runes: n})
+			continue
+		}
+		clusters[best].Members = append(clusters[best].Members, v)
+		clusters[best].Indexes = append(clusters[best].Indexes, i)
+	}
+	return clusters
+}
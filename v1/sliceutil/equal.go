@@ -0,0 +1,127 @@
+package sliceutil
+
+import "slices"
+
+// EqualUnordered 判断两个切片是否包含相同的元素（多重集合比较），忽略顺序但考虑重复次数
+// 参数:
+//   - a: 第一个切片
+//   - b: 第二个切片
+//
+// 返回:
+//   - 两个切片的元素及其出现次数都相同时返回 true，nil 与空切片视为相等
+//
+// EqualUnordered reports whether two slices contain the same elements (multiset comparison), ignoring order but respecting multiplicity.
+// Parameters:
+//   - a: The first slice
+//   - b: The second slice
+//
+// Returns:
+//   - true if both slices have the same elements with the same counts; nil and empty slices are equal
+func EqualUnordered[T comparable](a, b []T) bool {
+	return EqualUnorderedBy(a, b, func(v T) T { return v })
+}
+
+// EqualUnorderedBy 按 key 比较两个切片是否包含相同的元素，忽略顺序但考虑重复次数，适合比较不可比较的结构体
+// 参数:
+//   - a: 第一个切片
+//   - b: 第二个切片
+//   - key: 取键函数，键相同的元素视为相等
+//
+// 返回:
+//   - 两个切片按键统计的次数都相同时返回 true
+//
+// EqualUnorderedBy reports whether two slices contain the same elements by key, ignoring order but respecting multiplicity; useful for non-comparable structs.
+// Parameters:
+//   - a: The first slice
+//   - b: The second slice
+//   - key: The key function; elements with equal keys are considered equal
+//
+// Returns:
+//   - true if both slices have the same counts per key
+func EqualUnorderedBy[T any, K comparable](a, b []T, key func(T) K) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	counts := make(map[K]int, len(a))
+	for _, v := range a {
+		counts[key(v)]++
+	}
+	for _, v := range b {
+		k := key(v)
+		if counts[k] == 0 {
+			return false
+		}
+		counts[k]--
+	}
+	return true
+}
+
+// EqualBy 使用自定义比较函数按顺序比较两个切片，两个切片的元素类型可以不同
+// 参数:
+//   - a: 第一个切片
+//   - b: 第二个切片
+//   - eq: 比较函数
+//
+// 返回:
+//   - 长度相同且每个位置的元素都满足 eq 时返回 true
+//
+// EqualBy compares two slices in order using a custom comparator; the element types may differ.
+// Parameters:
+//   - a: The first slice
+//   - b: The second slice
+//   - eq: The comparator
+//
+// Returns:
+//   - true if the lengths are equal and eq holds at every index
+func EqualBy[T, U any](a []T, b []U, eq func(T, U) bool) bool {
+	return slices.EqualFunc(a, b, eq)
+}
+
+// HasDuplicates 判断切片中是否存在重复元素
+// 参数:
+//   - slice: 切片
+//
+// 返回:
+//   - 存在重复元素时返回 true
+//
+// HasDuplicates reports whether the slice contains duplicate elements.
+// Parameters:
+//   - slice: The slice
+//
+// Returns:
+//   - true if any element appears more than once
+func HasDuplicates[T comparable](slice []T) bool {
+	seen := make(map[T]struct{}, len(slice))
+	for _, v := range slice {
+		if _, ok := seen[v]; ok {
+			return true
+		}
+		seen[v] = struct{}{}
+	}
+	return false
+}
+
+// FindDuplicates 返回切片中重复出现的元素，每个元素只返回一次，按第二次出现的顺序排列
+// 参数:
+//   - slice: 切片
+//
+// 返回:
+//   - 重复元素组成的新切片，没有重复时返回空切片
+//
+// FindDuplicates returns the elements that appear more than once, each returned once, ordered by their second occurrence.
+// Parameters:
+//   - slice: The slice
+//
+// Returns:
+//   - A new slice of the duplicated elements; an empty slice when there are none
+func FindDuplicates[T comparable](slice []T) []T {
+	counts := make(map[T]int, len(slice))
+	result := []T{}
+	for _, v := range slice {
+		counts[v]++
+		if counts[v] == 2 {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,92 @@
+package sliceutil
+
+import "slices"
+
+// Rotate 原地将切片向左旋转 k 位（k 为负数时向右旋转），不分配内存
+// 例如 Rotate([1 2 3 4 5], 2) 得到 [3 4 5 1 2]
+// 参数:
+//   - slice: 要旋转的切片
+//   - k: 旋转的位数，可以大于切片长度
+//
+// Rotate rotates the slice left by k positions in place (right when k is negative) without allocating.
+// For example, Rotate([1 2 3 4 5], 2) yields [3 4 5 1 2].
+// Parameters:
+//   - slice: The slice to rotate
+//   - k: Number of positions, may exceed the length
+func Rotate[T any](slice []T, k int) {
+	n := len(slice)
+	if n < 2 {
+		return
+	}
+	k %= n
+	if k < 0 {
+		k += n
+	}
+	if k == 0 {
+		return
+	}
+	// 三次反转：先分别反转两段，再整体反转
+	slices.Reverse(slice[:k])
+	slices.Reverse(slice[k:])
+	slices.Reverse(slice)
+}
+
+// Reverse 原地反转切片，不分配内存
+//
+// Reverse reverses the slice in place without allocating.
+func Reverse[T any](slice []T) {
+	slices.Reverse(slice)
+}
+
+// RemoveIndexUnordered 用最后一个元素覆盖下标 i 处的元素并缩短切片，O(1) 且不分配内存，但不保持元素顺序
+// 被移出的尾部位置会清零，避免持有已删除元素的引用
+// 参数:
+//   - slice: 原始切片
+//   - i: 要删除的下标，越界时 panic
+//
+// 返回:
+//   - []T: 长度减一的切片，与原切片共享底层数组
+//
+// RemoveIndexUnordered overwrites index i with the last element and shortens the slice; it is O(1) and allocation-free but does not keep order.
+// The vacated tail slot is zeroed so the removed element is not retained.
+// Parameters:
+//   - slice: The original slice
+//   - i: The index to remove; panics if out of range
+//
+// Returns:
+//   - []T: The slice shortened by one, sharing the original backing array
+func RemoveIndexUnordered[T any](slice []T, i int) []T {
+	last := len(slice) - 1
+	slice[i] = slice[last]
+	var zero T
+	slice[last] = zero
+	return slice[:last]
+}
+
+// MoveToFront 原地将第一个满足条件的元素移到切片开头，其余元素保持相对顺序，不分配内存
+// 常用于维护最近使用列表
+// 参数:
+//   - slice: 要调整的切片
+//   - match: 匹配条件
+//
+// 返回:
+//   - bool: 是否找到满足条件的元素
+//
+// MoveToFront moves the first element satisfying match to the front in place, keeping the relative order of the others, without allocating.
+// It is commonly used to maintain most-recently-used lists.
+// Parameters:
+//   - slice: The slice to adjust
+//   - match: The match condition
+//
+// Returns:
+//   - bool: Whether a matching element was found
+func MoveToFront[T any](slice []T, match func(T) bool) bool {
+	i := slices.IndexFunc(slice, match)
+	if i < 0 {
+		return false
+	}
+	v := slice[i]
+	copy(slice[1:i+1], slice[:i])
+	slice[0] = v
+	return true
+}
@@ -0,0 +1,153 @@
+package sliceutil
+
+import (
+	"iter"
+	"slices"
+)
+
+// FromSeq 将迭代器中的元素收集为切片
+// 参数:
+//   - seq: 迭代器
+//
+// 返回:
+//   - 包含所有元素的新切片，迭代器为空时返回空切片而不是 nil
+//
+// FromSeq collects the elements of an iterator into a slice.
+// Parameters:
+//   - seq: The iterator
+//
+// Returns:
+//   - A new slice containing all elements; an empty slice rather than nil when the iterator is empty
+func FromSeq[T any](seq iter.Seq[T]) []T {
+	return slices.AppendSeq([]T{}, seq)
+}
+
+// ToSeq 返回按顺序遍历切片元素的迭代器
+// 参数:
+//   - slice: 切片
+//
+// 返回:
+//   - 迭代器，遍历期间修改切片会影响后续产出的元素
+//
+// ToSeq returns an iterator over the elements of a slice in order.
+// Parameters:
+//   - slice: The slice
+//
+// Returns:
+//   - The iterator; modifying the slice during iteration affects the elements yielded later
+func ToSeq[T any](slice []T) iter.Seq[T] {
+	return slices.Values(slice)
+}
+
+// Map 惰性地对迭代器的每个元素应用 fn，不会生成中间切片
+// 参数:
+//   - seq: 源迭代器
+//   - fn: 转换函数
+//
+// 返回:
+//   - 产出转换结果的迭代器
+//
+// Map lazily applies fn to every element of an iterator without materializing an intermediate slice.
+// Parameters:
+//   - seq: The source iterator
+//   - fn: The mapping function
+//
+// Returns:
+//   - An iterator yielding the mapped results
+func Map[T, U any](seq iter.Seq[T], fn func(T) U) iter.Seq[U] {
+	return func(yield func(U) bool) {
+		for v := range seq {
+			if !yield(fn(v)) {
+				return
+			}
+		}
+	}
+}
+
+// Filter 惰性地保留满足 keep 的元素
+// 参数:
+//   - seq: 源迭代器
+//   - keep: 判断函数，返回 true 的元素会被保留
+//
+// 返回:
+//   - 产出保留元素的迭代器
+//
+// Filter lazily keeps the elements that satisfy keep.
+// Parameters:
+//   - seq: The source iterator
+//   - keep: The predicate; elements for which it returns true are kept
+//
+// Returns:
+//   - An iterator yielding the kept elements
+func Filter[T any](seq iter.Seq[T], keep func(T) bool) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Take 惰性地产出前 n 个元素，之后立即停止源迭代器
+// 参数:
+//   - seq: 源迭代器
+//   - n: 最多产出的元素数，<= 0 时不产出任何元素
+//
+// 返回:
+//   - 产出前 n 个元素的迭代器
+//
+// Take lazily yields the first n elements and then stops the source iterator.
+// Parameters:
+//   - seq: The source iterator
+//   - n: Maximum number of elements to yield; nothing is yielded when <= 0
+//
+// Returns:
+//   - An iterator yielding the first n elements
+func Take[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		if n <= 0 {
+			return
+		}
+		i := 0
+		for v := range seq {
+			if !yield(v) {
+				return
+			}
+			i++
+			if i >= n {
+				return
+			}
+		}
+	}
+}
+
+// Skip 惰性地跳过前 n 个元素
+// 参数:
+//   - seq: 源迭代器
+//   - n: 要跳过的元素数
+//
+// 返回:
+//   - 产出剩余元素的迭代器
+//
+// Skip lazily skips the first n elements.
+// Parameters:
+//   - seq: The source iterator
+//   - n: Number of elements to skip
+//
+// Returns:
+//   - An iterator yielding the remaining elements
+func Skip[T any](seq iter.Seq[T], n int) iter.Seq[T] {
+	return func(yield func(T) bool) {
+		i := 0
+		for v := range seq {
+			if i < n {
+				i++
+				continue
+			}
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package sliceutil
+
+import (
+	"cmp"
+	"math"
+	mathrand "math/rand/v2"
+	"slices"
+
+	"github.com/supergodk/go-utils/v1/randutil"
+)
+
+// ShuffleSeeded 按 seed 原地打乱切片，相同的 seed 和长度总是得到相同的顺序，适用于需要复现排序结果的推荐流实验
+// 参数:
+//   - slice: 切片，原地修改
+//   - seed: 种子，例如由用户 ID 和日期哈希得到
+//
+// ShuffleSeeded shuffles the slice in place by seed; the same seed and length always give the same order, for feed-ranking experiments that must reproduce orderings.
+// Parameters:
+//   - slice: The slice, modified in place
+//   - seed: The seed, e.g. a hash of the user ID and date
+func ShuffleSeeded[T any](slice []T, seed uint64) {
+	ShuffleWith(slice, randutil.NewSeededSource(seed))
+}
+
+// ShuffleWith 使用指定的随机数源原地打乱切片（Fisher-Yates），算法固定，结果只取决于随机数源的输出
+//
+// ShuffleWith shuffles the slice in place with the given source (Fisher-Yates); the algorithm is fixed, so the result depends only on the source's output.
+func ShuffleWith[T any](slice []T, src randutil.Source) {
+	for i := len(slice) - 1; i > 0; i-- {
+		j := randutil.Uint64N(src, uint64(i+1))
+		slice[i], slice[j] = slice[j], slice[i]
+	}
+}
+
+// WeightedShuffle 按权重原地随机排序：权重越大的元素越可能排在前面，排在第一位的概率与权重成正比
+// 权重小于等于 0 的元素排在最后并保持原有顺序
+// 参数:
+//   - slice: 切片，原地修改
+//   - weight: 权重函数，每个元素只调用一次
+//
+// WeightedShuffle randomly orders the slice in place by weight: heavier elements tend to come first, and the chance of coming first is proportional to the weight.
+// Elements with weights not above 0 go last, in their original order.
+// Parameters:
+//   - slice: The slice, modified in place
+//   - weight: The weight function, called once per element
+func WeightedShuffle[T any](slice []T, weight func(T) float64) {
+	WeightedShuffleWith(slice, weight, randutil.NewSeededSource(mathrand.Uint64()))
+}
+
+// WeightedShuffleWith 与 WeightedShuffle 相同，但使用指定的随机数源，例如 randutil.NewSeededSource 以复现结果
+//
+// WeightedShuffleWith is like WeightedShuffle but uses the given source, e.g. randutil.NewSeededSource to reproduce results.
+func WeightedShuffleWith[T any](slice []T, weight func(T) float64, src randutil.Source) {
+	// Efraimidis-Spirakis：每个元素取指数分布的键 -ln(U)/w，按键升序排列
+	type entry struct {
+		v   T
+		key float64
+	}
+	entries := make([]entry, len(slice))
+	for i, v := range slice {
+		key := math.Inf(1)
+		if w := weight(v); w > 0 {
+			key = -math.Log(1-randutil.Float64(src)) / w
+		}
+		entries[i] = entry{v: v, key: key}
+	}
+	slices.SortStableFunc(entries, func(a, b entry) int { return cmp.Compare(a.key, b.key) })
+	for i, e := range entries {
+		slice[i] = e.v
+	}
+}
@@ -0,0 +1,105 @@
+package sliceutil
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+)
+
+// InsertSorted 将元素插入升序切片并保持有序，相等的元素插入在已有元素之后
+// 参数:
+//   - slice: 升序切片
+//   - v: 要插入的元素
+//
+// 返回:
+//   - 插入后的切片，与 append 一样可能复用原切片的底层数组
+//
+// InsertSorted inserts an element into an ascending slice keeping it sorted; equal elements are inserted after existing ones.
+// Parameters:
+//   - slice: The ascending slice
+//   - v: The element to insert
+//
+// Returns:
+//   - The slice after insertion, which may reuse the original backing array like append
+func InsertSorted[T cmp.Ordered](slice []T, v T) []T {
+	i := sort.Search(len(slice), func(i int) bool { return cmp.Less(v, slice[i]) })
+	return slices.Insert(slice, i, v)
+}
+
+// InsertSortedBy 将元素插入按 key 升序的切片并保持有序，相等的键插入在已有元素之后
+// 参数:
+//   - slice: 按 key 升序的切片
+//   - v: 要插入的元素
+//   - key: 取键函数
+//
+// 返回:
+//   - 插入后的切片，与 append 一样可能复用原切片的底层数组
+//
+// InsertSortedBy inserts an element into a slice sorted ascending by key, keeping it sorted; equal keys are inserted after existing ones.
+// Parameters:
+//   - slice: The slice sorted ascending by key
+//   - v: The element to insert
+//   - key: The key function
+//
+// Returns:
+//   - The slice after insertion, which may reuse the original backing array like append
+func InsertSortedBy[T any, K cmp.Ordered](slice []T, v T, key func(T) K) []T {
+	k := key(v)
+	i := sort.Search(len(slice), func(i int) bool { return cmp.Less(k, key(slice[i])) })
+	return slices.Insert(slice, i, v)
+}
+
+// SearchBy 在按 key 升序的切片中二分查找目标键
+// 参数:
+//   - slice: 按 key 升序的切片
+//   - key: 取键函数
+//   - target: 目标键
+//
+// 返回:
+//   - int: 第一个键不小于 target 的元素下标，即 target 应插入的位置
+//   - bool: 该位置的键是否等于 target
+//
+// SearchBy binary searches a slice sorted ascending by key for the target key.
+// Parameters:
+//   - slice: The slice sorted ascending by key
+//   - key: The key function
+//   - target: The target key
+//
+// Returns:
+//   - int: Index of the first element whose key is not less than target, i.e. where target would be inserted
+//   - bool: Whether the key at that index equals target
+func SearchBy[T any, K cmp.Ordered](slice []T, key func(T) K, target K) (int, bool) {
+	return slices.BinarySearchFunc(slice, target, func(e T, t K) int {
+		return cmp.Compare(key(e), t)
+	})
+}
+
+// RangeBetween 返回按 key 升序的切片中键位于 [low, high] 闭区间内的元素
+// 参数:
+//   - slice: 按 key 升序的切片
+//   - key: 取键函数
+//   - low: 下界（包含）
+//   - high: 上界（包含）
+//
+// 返回:
+//   - 原切片的子切片（共享底层数组），没有匹配元素或 low > high 时返回空切片
+//
+// RangeBetween returns the elements of a slice sorted ascending by key whose keys fall within the closed interval [low, high].
+// Parameters:
+//   - slice: The slice sorted ascending by key
+//   - key: The key function
+//   - low: Lower bound (inclusive)
+//   - high: Upper bound (inclusive)
+//
+// Returns:
+//   - A sub-slice of the original (sharing its backing array); an empty slice when nothing matches or low > high
+func RangeBetween[T any, K cmp.Ordered](slice []T, key func(T) K, low, high K) []T {
+	if cmp.Less(high, low) {
+		return slice[:0:0]
+	}
+	from, _ := SearchBy(slice, key, low)
+	to := from + sort.Search(len(slice)-from, func(i int) bool {
+		return cmp.Less(high, key(slice[from+i]))
+	})
+	return slice[from:to:to]
+}
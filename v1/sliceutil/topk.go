@@ -0,0 +1,164 @@
+package sliceutil
+
+import (
+	"cmp"
+	"slices"
+)
+
+// TopKBy 返回按 key 最大的 k 个元素，按 key 降序排列，键相等时先出现的元素在前
+// 使用大小为 k 的堆，时间复杂度 O(n log k)，每个元素只调用一次 key，适用于从大切片中取排行榜前几名
+// 参数:
+//   - slice: 切片，不会被修改
+//   - k: 数量，大于切片长度时返回全部元素，小于等于 0 时返回 nil
+//   - key: 取键函数
+//
+// 返回:
+//   - 最大的 k 个元素
+//
+// TopKBy returns the k elements with the largest keys, sorted by key descending; among equal keys earlier elements come first.
+// It uses a heap of size k for O(n log k) time and calls key once per element, for taking the top of a leaderboard from a large slice.
+// Parameters:
+//   - slice: The slice, which is not modified
+//   - k: Count; all elements are returned when it exceeds the length, nil when it is not positive
+//   - key: The key function
+//
+// Returns:
+//   - The k largest elements
+func TopKBy[T any, K cmp.Ordered](slice []T, k int, key func(T) K) []T {
+	return selectK(slice, k, key, func(a, b K) int { return cmp.Compare(b, a) })
+}
+
+// BottomKBy 返回按 key 最小的 k 个元素，按 key 升序排列，键相等时先出现的元素在前，参见 TopKBy
+//
+// BottomKBy returns the k elements with the smallest keys, sorted by key ascending; among equal keys earlier elements come first. See TopKBy.
+func BottomKBy[T any, K cmp.Ordered](slice []T, k int, key func(T) K) []T {
+	return selectK(slice, k, key, cmp.Compare[K])
+}
+
+// MaxBy 返回 key 最大的元素，有多个时返回第一个
+// 参数:
+//   - slice: 切片
+//   - key: 取键函数
+//
+// 返回:
+//   - T: key 最大的元素
+//   - bool: 切片为空时返回 false
+//
+// MaxBy returns the element with the largest key, the first one if there are several.
+// Parameters:
+//   - slice: The slice
+//   - key: The key function
+//
+// Returns:
+//   - T: The element with the largest key
+//   - bool: false if the slice is empty
+func MaxBy[T any, K cmp.Ordered](slice []T, key func(T) K) (T, bool) {
+	return extremeBy(slice, key, func(a, b K) bool { return cmp.Less(b, a) })
+}
+
+// MinBy 返回 key 最小的元素，有多个时返回第一个，参见 MaxBy
+//
+// MinBy returns the element with the smallest key, the first one if there are several. See MaxBy.
+func MinBy[T any, K cmp.Ordered](slice []T, key func(T) K) (T, bool) {
+	return extremeBy(slice, key, cmp.Less[K])
+}
+
+// extremeBy 返回 better 意义下最好的元素
+//
+// extremeBy returns the best element according to better
+func extremeBy[T any, K cmp.Ordered](slice []T, key func(T) K, better func(a, b K) bool) (T, bool) {
+	if len(slice) == 0 {
+		var zero T
+		return zero, false
+	}
+	best, bestKey := slice[0], key(slice[0])
+	for _, v := range slice[1:] {
+		if k := key(v); better(k, bestKey) {
+			best, bestKey = v, k
+		}
+	}
+	return best, true
+}
+
+// keyed 带键和原始下标的元素
+//
+// keyed is an element with its key and original index
+type keyed[T any, K cmp.Ordered] struct {
+	v   T
+	k   K
+	idx int
+}
+
+// selectK 选出 order 意义下排在最前的 k 个元素；堆顶是已选元素中最差的一个，新元素优于堆顶时替换
+//
+// selectK selects the first k elements according to order; the heap root is the worst selected element and is replaced by better newcomers
+func selectK[T any, K cmp.Ordered](slice []T, k int, key func(T) K, order func(a, b K) int) []T {
+	if k <= 0 || len(slice) == 0 {
+		return nil
+	}
+	k = min(k, len(slice))
+	// compare 按 order 比较，键相等时下标小的在前
+	compare := func(a, b keyed[T, K]) int {
+		if c := order(a.k, b.k); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.idx, b.idx)
+	}
+	// worse 报告 a 是否排在 b 之后，堆按此构成最差元素在顶的堆
+	worse := func(a, b keyed[T, K]) bool { return compare(a, b) > 0 }
+
+	h := make([]keyed[T, K], 0, k)
+	for i, v := range slice {
+		e := keyed[T, K]{v: v, k: key(v), idx: i}
+		if len(h) < k {
+			h = append(h, e)
+			siftUp(h, len(h)-1, worse)
+			continue
+		}
+		if worse(h[0], e) {
+			h[0] = e
+			siftDown(h, 0, worse)
+		}
+	}
+
+	slices.SortFunc(h, compare)
+	out := make([]T, len(h))
+	for i, e := range h {
+		out[i] = e.v
+	}
+	return out
+}
+
+// siftUp 将下标 i 的元素上移以恢复堆序
+//
+// siftUp moves the element at i up to restore the heap order
+func siftUp[E any](h []E, i int, before func(a, b E) bool) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !before(h[i], h[parent]) {
+			return
+		}
+		h[i], h[parent] = h[parent], h[i]
+		i = parent
+	}
+}
+
+// siftDown 将下标 i 的元素下移以恢复堆序
+//
+// siftDown moves the element at i down to restore the heap order
+func siftDown[E any](h []E, i int, before func(a, b E) bool) {
+	for {
+		top := i
+		if l := 2*i + 1; l < len(h) && before(h[l], h[top]) {
+			top = l
+		}
+		if r := 2*i + 2; r < len(h) && before(h[r], h[top]) {
+			top = r
+		}
+		if top == i {
+			return
+		}
+		h[i], h[top] = h[top], h[i]
+		i = top
+	}
+}
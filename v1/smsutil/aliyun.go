@@ -0,0 +1,187 @@
+package smsutil
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/phoneutil"
+)
+
+// AliyunEndpoint 阿里云短信服务默认接入地址
+//
+// AliyunEndpoint is the default Aliyun SMS service endpoint
+const AliyunEndpoint = "https://dysmsapi.aliyuncs.com/"
+
+// AliyunConfig 阿里云短信配置
+// AccessKeyID、AccessKeySecret: 访问密钥
+// SignName: 默认短信签名
+// RegionID: 地域，默认 "cn-hangzhou"
+// Endpoint: 接入地址，默认 AliyunEndpoint
+// HTTPClient: 自定义 HTTP 客户端，默认超时 DefaultHTTPTimeout
+//
+// AliyunConfig is the Aliyun SMS configuration.
+// AccessKeyID, AccessKeySecret: Access key
+// SignName: Default SMS signature
+// RegionID: Region, defaults to "cn-hangzhou"
+// Endpoint: Endpoint, defaults to AliyunEndpoint
+// HTTPClient: Custom HTTP client, defaults to a client with DefaultHTTPTimeout
+type AliyunConfig struct {
+	AccessKeyID     string
+	AccessKeySecret string
+	SignName        string
+	RegionID        string
+	Endpoint        string
+	HTTPClient      *http.Client
+}
+
+// AliyunSender 阿里云短信发送器
+//
+// AliyunSender is the Aliyun SMS sender.
+type AliyunSender struct {
+	cfg    AliyunConfig
+	client *http.Client
+}
+
+// NewAliyunSender 创建阿里云短信发送器
+//
+// NewAliyunSender creates an Aliyun SMS sender.
+func NewAliyunSender(cfg AliyunConfig) *AliyunSender {
+	if cfg.RegionID == "" {
+		cfg.RegionID = "cn-hangzhou"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = AliyunEndpoint
+	}
+	return &AliyunSender{cfg: cfg, client: httpClientOrDefault(cfg.HTTPClient)}
+}
+
+// aliyunResponse 阿里云 SendSms 响应
+//
+// aliyunResponse is the Aliyun SendSms response
+type aliyunResponse struct {
+	Code      string `json:"Code"`
+	Message   string `json:"Message"`
+	RequestID string `json:"RequestId"`
+	BizID     string `json:"BizId"`
+}
+
+// Send 实现 SMSSender 接口，使用 RPC 风格的 HMAC-SHA1 签名调用 SendSms
+//
+// Send implements the SMSSender interface, calling SendSms with the RPC style HMAC-SHA1 signature.
+func (s *AliyunSender) Send(ctx context.Context, req *SendRequest) error {
+	if err := req.validate(); err != nil {
+		return err
+	}
+	phone, err := phoneutil.ParsePhone(req.Phone, phoneutil.RegionCN)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	// 中国大陆号码直接使用 11 位号码，其它号码使用 "区号+号码"
+	number := phone.NationalNumber
+	if phone.CountryCode != "86" {
+		number = phone.CountryCode + phone.NationalNumber
+	}
+
+	signName := req.SignName
+	if signName == "" {
+		signName = s.cfg.SignName
+	}
+	params := make(map[string]string, len(req.Params))
+	for _, p := range req.Params {
+		params[p.Name] = p.Value
+	}
+	templateParam, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	nonce := make([]byte, 16)
+	rand.Read(nonce)
+	query := map[string]string{
+		"AccessKeyId":      s.cfg.AccessKeyID,
+		"Action":           "SendSms",
+		"Format":           "JSON",
+		"PhoneNumbers":     number,
+		"RegionId":         s.cfg.RegionID,
+		"SignName":         signName,
+		"SignatureMethod":  "HMAC-SHA1",
+		"SignatureNonce":   hex.EncodeToString(nonce),
+		"SignatureVersion": "1.0",
+		"TemplateCode":     req.TemplateID,
+		"TemplateParam":    string(templateParam),
+		"Timestamp":        time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+		"Version":          "2017-05-25",
+	}
+	canonical := aliyunCanonicalQuery(query)
+	signature := aliyunSign(http.MethodGet, canonical, s.cfg.AccessKeySecret)
+
+	reqURL := s.cfg.Endpoint + "?Signature=" + aliyunPercentEncode(signature) + "&" + canonical
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSendSMS, err)
+	}
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSendSMS, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSendSMS, err)
+	}
+	var result aliyunResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("%w: status %d: %v", ErrSendSMS, resp.StatusCode, err)
+	}
+	if result.Code != "OK" {
+		return &ProviderError{Provider: "aliyun", Code: result.Code, Message: result.Message, RequestID: result.RequestID}
+	}
+	return nil
+}
+
+// aliyunCanonicalQuery 按参数名排序并编码查询参数
+//
+// aliyunCanonicalQuery sorts query parameters by name and encodes them
+func aliyunCanonicalQuery(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = aliyunPercentEncode(k) + "=" + aliyunPercentEncode(params[k])
+	}
+	return strings.Join(pairs, "&")
+}
+
+// aliyunSign 计算 RPC 风格的签名
+//
+// aliyunSign computes the RPC style signature
+func aliyunSign(method, canonicalQuery, secret string) string {
+	stringToSign := method + "&" + aliyunPercentEncode("/") + "&" + aliyunPercentEncode(canonicalQuery)
+	mac := hmac.New(sha1.New, []byte(secret+"&"))
+	mac.Write([]byte(stringToSign))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// aliyunPercentEncode 按阿里云规则进行 URL 编码（空格为 %20，* 为 %2A，~ 不编码）
+//
+// aliyunPercentEncode URL-encodes according to Aliyun rules (space as %20, * as %2A, ~ unescaped)
+func aliyunPercentEncode(s string) string {
+	s = url.QueryEscape(s)
+	return strings.NewReplacer("+", "%20", "*", "%2A", "%7E", "~").Replace(s)
+}
@@ -0,0 +1,275 @@
+package smsutil
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/cacheutil"
+	"github.com/supergodk/go-utils/v1/phoneutil"
+	"github.com/supergodk/go-utils/v1/randutil"
+)
+
+const (
+	// DefaultCodeLength 默认验证码长度
+	//
+	// DefaultCodeLength is the default verification code length
+	DefaultCodeLength = 6
+	// DefaultCodeTTL 默认验证码有效期
+	//
+	// DefaultCodeTTL is the default verification code validity period
+	DefaultCodeTTL = 5 * time.Minute
+	// DefaultResendInterval 默认同一手机号两次发送的最小间隔
+	//
+	// DefaultResendInterval is the default minimum interval between two sends to the same phone
+	DefaultResendInterval = time.Minute
+	// DefaultMaxPerDay 默认同一手机号每天最多发送次数
+	//
+	// DefaultMaxPerDay is the default maximum number of sends per phone per day
+	DefaultMaxPerDay = 10
+	// DefaultMaxAttempts 默认每个验证码最多校验次数
+	//
+	// DefaultMaxAttempts is the default maximum number of verification attempts per code
+	DefaultMaxAttempts = 5
+	// DefaultCodeParamName 默认的验证码模板参数名
+	//
+	// DefaultCodeParamName is the default template parameter name of the code
+	DefaultCodeParamName = "code"
+	// DefaultKeyPrefix 默认的缓存键前缀
+	//
+	// DefaultKeyPrefix is the default cache key prefix
+	DefaultKeyPrefix = "smscode:"
+)
+
+var (
+	// ErrTooFrequent 表示发送过于频繁
+	//
+	// ErrTooFrequent indicates that codes are sent too frequently.
+	ErrTooFrequent = errors.New("verification code sent too frequently")
+	// ErrDailyLimitExceeded 表示超过每日发送上限
+	//
+	// ErrDailyLimitExceeded indicates that the daily sending limit has been exceeded.
+	ErrDailyLimitExceeded = errors.New("daily verification code limit exceeded")
+	// ErrCodeExpired 表示验证码不存在或已过期
+	//
+	// ErrCodeExpired indicates that the verification code does not exist or has expired.
+	ErrCodeExpired = errors.New("verification code expired or not found")
+	// ErrCodeMismatch 表示验证码错误
+	//
+	// ErrCodeMismatch indicates that the verification code is wrong.
+	ErrCodeMismatch = errors.New("verification code mismatch")
+	// ErrTooManyAttempts 表示校验失败次数过多，验证码已作废
+	//
+	// ErrTooManyAttempts indicates too many failed attempts; the code has been invalidated.
+	ErrTooManyAttempts = errors.New("too many verification attempts")
+)
+
+// CodeOptions 验证码管理选项
+// TemplateID: 验证码短信模板 ID（必填）
+// ParamName: 模板中验证码参数名，默认 DefaultCodeParamName
+// Length: 验证码长度，默认 DefaultCodeLength
+// TTL: 验证码有效期，默认 DefaultCodeTTL
+// ResendInterval: 同一手机号两次发送的最小间隔，默认 DefaultResendInterval
+// MaxPerDay: 同一手机号每天最多发送次数，默认 DefaultMaxPerDay，小于 0 表示不限制
+// MaxAttempts: 每个验证码最多校验次数，默认 DefaultMaxAttempts
+// KeyPrefix: 缓存键前缀，默认 DefaultKeyPrefix；不同业务场景（登录、注册等）应使用不同前缀
+//
+// CodeOptions contains options for verification code management.
+// TemplateID: Template ID of the verification SMS (required)
+// ParamName: Name of the code parameter in the template, defaults to DefaultCodeParamName
+// Length: Code length, defaults to DefaultCodeLength
+// TTL: Code validity period, defaults to DefaultCodeTTL
+// ResendInterval: Minimum interval between two sends to the same phone, defaults to DefaultResendInterval
+// MaxPerDay: Maximum sends per phone per day, defaults to DefaultMaxPerDay; negative means unlimited
+// MaxAttempts: Maximum verification attempts per code, defaults to DefaultMaxAttempts
+// KeyPrefix: Cache key prefix, defaults to DefaultKeyPrefix; different scenarios (login, sign-up...) should use different prefixes
+type CodeOptions struct {
+	TemplateID     string
+	ParamName      string
+	Length         int
+	TTL            time.Duration
+	ResendInterval time.Duration
+	MaxPerDay      int
+	MaxAttempts    int
+	KeyPrefix      string
+}
+
+// VerificationCodeManager 短信验证码管理器，负责生成、发送、限流和校验
+//
+// VerificationCodeManager manages SMS verification codes: generation, sending, rate limiting and verification.
+type VerificationCodeManager struct {
+	sender SMSSender
+	cache  cacheutil.Cache
+	opts   CodeOptions
+}
+
+// NewVerificationCodeManager 创建短信验证码管理器
+// 参数:
+//   - sender: 短信发送器
+//   - cache: 验证码存储，多实例部署时应使用分布式缓存
+//   - opts: 验证码选项，可以为 nil（此时 TemplateID 为空，只适用于自定义发送器）
+//
+// NewVerificationCodeManager creates an SMS verification code manager.
+// Parameters:
+//   - sender: SMS sender
+//   - cache: Code storage; a distributed cache should be used for multi-instance deployments
+//   - opts: Code options, may be nil (TemplateID is then empty, which only suits custom senders)
+func NewVerificationCodeManager(sender SMSSender, cache cacheutil.Cache, opts *CodeOptions) *VerificationCodeManager {
+	m := &VerificationCodeManager{sender: sender, cache: cache}
+	if opts != nil {
+		m.opts = *opts
+	}
+	if m.opts.ParamName == "" {
+		m.opts.ParamName = DefaultCodeParamName
+	}
+	if m.opts.Length <= 0 {
+		m.opts.Length = DefaultCodeLength
+	}
+	if m.opts.TTL <= 0 {
+		m.opts.TTL = DefaultCodeTTL
+	}
+	if m.opts.ResendInterval <= 0 {
+		m.opts.ResendInterval = DefaultResendInterval
+	}
+	if m.opts.MaxPerDay == 0 {
+		m.opts.MaxPerDay = DefaultMaxPerDay
+	}
+	if m.opts.MaxAttempts <= 0 {
+		m.opts.MaxAttempts = DefaultMaxAttempts
+	}
+	if m.opts.KeyPrefix == "" {
+		m.opts.KeyPrefix = DefaultKeyPrefix
+	}
+	return m
+}
+
+// Send 生成验证码并发送到手机号，新验证码会使之前的验证码失效
+// 参数:
+//   - ctx: 上下文
+//   - phone: 手机号
+//
+// 返回:
+//   - error: 发送过于频繁返回 ErrTooFrequent，超过每日上限返回 ErrDailyLimitExceeded，其它情况返回存储或发送错误
+//
+// Send generates a verification code and sends it to the phone; a new code invalidates the previous one.
+// Parameters:
+//   - ctx: Context
+//   - phone: Phone number
+//
+// Returns:
+//   - error: ErrTooFrequent if sent too frequently, ErrDailyLimitExceeded if the daily limit is exceeded, otherwise storage or sending errors
+func (m *VerificationCodeManager) Send(ctx context.Context, phone string) error {
+	key, err := normalizePhone(phone)
+	if err != nil {
+		return err
+	}
+
+	cooldownKey := m.opts.KeyPrefix + "cooldown:" + key
+	ok, err := m.cache.SetNX(ctx, cooldownKey, []byte("1"), m.opts.ResendInterval)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrTooFrequent
+	}
+
+	if m.opts.MaxPerDay > 0 {
+		dailyKey := m.opts.KeyPrefix + "daily:" + time.Now().Format("20060102") + ":" + key
+		n, err := m.cache.Incr(ctx, dailyKey, 24*time.Hour)
+		if err != nil {
+			return err
+		}
+		if n > int64(m.opts.MaxPerDay) {
+			return ErrDailyLimitExceeded
+		}
+	}
+
+	code, err := randutil.GenerateNumericCode(m.opts.Length)
+	if err != nil {
+		m.cache.Delete(ctx, cooldownKey)
+		return err
+	}
+	codeKey := m.opts.KeyPrefix + "code:" + key
+	if err := m.cache.Set(ctx, codeKey, []byte(code), m.opts.TTL); err != nil {
+		m.cache.Delete(ctx, cooldownKey)
+		return err
+	}
+	m.cache.Delete(ctx, m.opts.KeyPrefix+"attempts:"+key)
+
+	err = m.sender.Send(ctx, &SendRequest{
+		Phone:      phone,
+		TemplateID: m.opts.TemplateID,
+		Params:     []Param{{Name: m.opts.ParamName, Value: code}},
+	})
+	if err != nil {
+		// 发送失败时允许用户立即重试
+		m.cache.Delete(ctx, codeKey)
+		m.cache.Delete(ctx, cooldownKey)
+		return err
+	}
+	return nil
+}
+
+// Verify 校验验证码，校验成功后验证码立即作废，失败次数过多时验证码也会作废
+// 参数:
+//   - ctx: 上下文
+//   - phone: 手机号
+//   - code: 用户输入的验证码
+//
+// 返回:
+//   - error: 校验成功返回 nil，否则返回 ErrCodeExpired、ErrCodeMismatch 或 ErrTooManyAttempts
+//
+// Verify checks the verification code. The code is invalidated immediately on success, and also after too many failures.
+// Parameters:
+//   - ctx: Context
+//   - phone: Phone number
+//   - code: Code entered by the user
+//
+// Returns:
+//   - error: nil on success, otherwise ErrCodeExpired, ErrCodeMismatch or ErrTooManyAttempts
+func (m *VerificationCodeManager) Verify(ctx context.Context, phone, code string) error {
+	key, err := normalizePhone(phone)
+	if err != nil {
+		return err
+	}
+	codeKey := m.opts.KeyPrefix + "code:" + key
+	attemptsKey := m.opts.KeyPrefix + "attempts:" + key
+
+	stored, ok, err := m.cache.Get(ctx, codeKey)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrCodeExpired
+	}
+
+	attempts, err := m.cache.Incr(ctx, attemptsKey, m.opts.TTL)
+	if err != nil {
+		return err
+	}
+	if attempts > int64(m.opts.MaxAttempts) {
+		m.cache.Delete(ctx, codeKey)
+		m.cache.Delete(ctx, attemptsKey)
+		return ErrTooManyAttempts
+	}
+
+	if subtle.ConstantTimeCompare(stored, []byte(code)) != 1 {
+		return ErrCodeMismatch
+	}
+	m.cache.Delete(ctx, codeKey)
+	m.cache.Delete(ctx, attemptsKey)
+	return nil
+}
+
+// normalizePhone 将手机号规范化为 E.164 格式，作为缓存键的一部分
+//
+// normalizePhone normalizes the phone to E.164 for use in cache keys
+func normalizePhone(phone string) (string, error) {
+	e164, err := phoneutil.NormalizeE164(phone, phoneutil.RegionCN)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	return e164, nil
+}
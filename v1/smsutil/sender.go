@@ -0,0 +1,119 @@
+// Package smsutil 提供与短信服务商无关的短信发送接口及阿里云、腾讯云实现，以及短信验证码管理
+//
+// Package smsutil provides a provider-agnostic SMS sending interface with Aliyun and Tencent Cloud implementations,
+// as well as SMS verification code management.
+package smsutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DefaultHTTPTimeout 短信服务商接口的默认请求超时
+//
+// DefaultHTTPTimeout is the default request timeout for SMS provider APIs
+const DefaultHTTPTimeout = 10 * time.Second
+
+var (
+	// ErrSendSMS 表示发送短信失败
+	//
+	// ErrSendSMS indicates that sending the SMS failed.
+	ErrSendSMS = errors.New("failed to send sms")
+	// ErrInvalidRequest 表示短信请求参数不完整
+	//
+	// ErrInvalidRequest indicates that the SMS request is incomplete.
+	ErrInvalidRequest = errors.New("invalid sms request")
+)
+
+// Param 模板参数
+// 阿里云按 Name 填充模板变量；腾讯云模板变量是按序号排列的，只使用 Value 并按切片顺序填充
+//
+// Param is a template parameter.
+// Aliyun fills template variables by Name; Tencent Cloud variables are positional, so only Value is used, in slice order.
+type Param struct {
+	Name  string
+	Value string
+}
+
+// SendRequest 短信发送请求
+// Phone: 手机号，不带区号时按中国大陆号码处理
+// TemplateID: 模板 ID（阿里云为 TemplateCode）
+// Params: 模板参数
+// SignName: 短信签名，为空时使用服务商配置中的签名
+//
+// SendRequest is an SMS sending request.
+// Phone: Phone number, treated as a mainland China number when it has no calling code
+// TemplateID: Template ID (TemplateCode for Aliyun)
+// Params: Template parameters
+// SignName: SMS signature, the one in the provider config is used when empty
+type SendRequest struct {
+	Phone      string
+	TemplateID string
+	Params     []Param
+	SignName   string
+}
+
+// SMSSender 短信发送接口
+//
+// SMSSender is the SMS sending interface.
+type SMSSender interface {
+	// Send 发送一条模板短信
+	//
+	// Send sends a template SMS.
+	Send(ctx context.Context, req *SendRequest) error
+}
+
+// ProviderError 服务商返回的业务错误，可以通过 errors.As 获取
+// Provider: 服务商名称
+// Code: 服务商错误码，例如阿里云的 "isv.BUSINESS_LIMIT_CONTROL"
+// Message: 错误描述
+// RequestID: 服务商请求 ID，便于排查
+//
+// ProviderError is a business error returned by the provider, retrievable with errors.As.
+// Provider: Provider name
+// Code: Provider error code, e.g. "isv.BUSINESS_LIMIT_CONTROL" for Aliyun
+// Message: Error description
+// RequestID: Provider request ID, for troubleshooting
+type ProviderError struct {
+	Provider  string
+	Code      string
+	Message   string
+	RequestID string
+}
+
+// Error 实现 error 接口
+//
+// Error implements the error interface.
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s sms error %s: %s (request id %s)", e.Provider, e.Code, e.Message, e.RequestID)
+}
+
+// Unwrap 返回 ErrSendSMS
+//
+// Unwrap returns ErrSendSMS.
+func (e *ProviderError) Unwrap() error {
+	return ErrSendSMS
+}
+
+// validate 校验请求的必填字段
+//
+// validate checks the required fields of the request
+func (r *SendRequest) validate() error {
+	if r == nil || r.Phone == "" || r.TemplateID == "" {
+		return fmt.Errorf("%w: phone and template id are required", ErrInvalidRequest)
+	}
+	return nil
+}
+
+// httpClientOrDefault 返回自定义的 HTTP 客户端，未设置时返回带默认超时的客户端
+//
+// httpClientOrDefault returns the custom HTTP client, or a client with the default timeout if unset
+func httpClientOrDefault(c *http.Client) *http.Client {
+	if c != nil {
+		return c
+	}
+	return &http.Client{Timeout: DefaultHTTPTimeout}
+}
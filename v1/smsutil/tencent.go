@@ -0,0 +1,210 @@
+package smsutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/phoneutil"
+)
+
+// TencentEndpoint 腾讯云短信服务默认接入地址
+//
+// TencentEndpoint is the default Tencent Cloud SMS service endpoint
+const TencentEndpoint = "https://sms.tencentcloudapi.com"
+
+// TencentConfig 腾讯云短信配置
+// SecretID、SecretKey: 访问密钥
+// SDKAppID: 短信应用 ID
+// SignName: 默认短信签名
+// Region: 地域，默认 "ap-guangzhou"
+// Endpoint: 接入地址，默认 TencentEndpoint
+// HTTPClient: 自定义 HTTP 客户端，默认超时 DefaultHTTPTimeout
+//
+// TencentConfig is the Tencent Cloud SMS configuration.
+// SecretID, SecretKey: Access key
+// SDKAppID: SMS application ID
+// SignName: Default SMS signature
+// Region: Region, defaults to "ap-guangzhou"
+// Endpoint: Endpoint, defaults to TencentEndpoint
+// HTTPClient: Custom HTTP client, defaults to a client with DefaultHTTPTimeout
+type TencentConfig struct {
+	SecretID   string
+	SecretKey  string
+	SDKAppID   string
+	SignName   string
+	Region     string
+	Endpoint   string
+	HTTPClient *http.Client
+}
+
+// TencentSender 腾讯云短信发送器
+//
+// TencentSender is the Tencent Cloud SMS sender.
+type TencentSender struct {
+	cfg    TencentConfig
+	client *http.Client
+}
+
+// NewTencentSender 创建腾讯云短信发送器
+//
+// NewTencentSender creates a Tencent Cloud SMS sender.
+func NewTencentSender(cfg TencentConfig) *TencentSender {
+	if cfg.Region == "" {
+		cfg.Region = "ap-guangzhou"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = TencentEndpoint
+	}
+	return &TencentSender{cfg: cfg, client: httpClientOrDefault(cfg.HTTPClient)}
+}
+
+// tencentRequest 腾讯云 SendSms 请求体
+//
+// tencentRequest is the Tencent Cloud SendSms request body
+type tencentRequest struct {
+	PhoneNumberSet   []string `json:"PhoneNumberSet"`
+	SmsSdkAppID      string   `json:"SmsSdkAppId"`
+	SignName         string   `json:"SignName"`
+	TemplateID       string   `json:"TemplateId"`
+	TemplateParamSet []string `json:"TemplateParamSet,omitempty"`
+}
+
+// tencentResponse 腾讯云 SendSms 响应
+//
+// tencentResponse is the Tencent Cloud SendSms response
+type tencentResponse struct {
+	Response struct {
+		Error *struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"Error"`
+		SendStatusSet []struct {
+			Code    string `json:"Code"`
+			Message string `json:"Message"`
+		} `json:"SendStatusSet"`
+		RequestID string `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// Send 实现 SMSSender 接口，使用 TC3-HMAC-SHA256 签名调用 SendSms
+//
+// Send implements the SMSSender interface, calling SendSms with the TC3-HMAC-SHA256 signature.
+func (s *TencentSender) Send(ctx context.Context, req *SendRequest) error {
+	if err := req.validate(); err != nil {
+		return err
+	}
+	phone, err := phoneutil.ParsePhone(req.Phone, phoneutil.RegionCN)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	endpoint, err := url.Parse(s.cfg.Endpoint)
+	if err != nil {
+		return fmt.Errorf("%w: invalid endpoint: %v", ErrInvalidRequest, err)
+	}
+
+	signName := req.SignName
+	if signName == "" {
+		signName = s.cfg.SignName
+	}
+	values := make([]string, len(req.Params))
+	for i, p := range req.Params {
+		values[i] = p.Value
+	}
+	payload, err := json.Marshal(tencentRequest{
+		PhoneNumberSet:   []string{phone.E164()},
+		SmsSdkAppID:      s.cfg.SDKAppID,
+		SignName:         signName,
+		TemplateID:       req.TemplateID,
+		TemplateParamSet: values,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+
+	const contentType = "application/json; charset=utf-8"
+	timestamp := time.Now().Unix()
+	authorization := tencentAuthorization(s.cfg.SecretID, s.cfg.SecretKey, endpoint.Host, contentType, payload, timestamp)
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSendSMS, err)
+	}
+	httpReq.Header.Set("Content-Type", contentType)
+	httpReq.Header.Set("Authorization", authorization)
+	httpReq.Header.Set("X-TC-Action", "SendSms")
+	httpReq.Header.Set("X-TC-Version", "2021-01-11")
+	httpReq.Header.Set("X-TC-Region", s.cfg.Region)
+	httpReq.Header.Set("X-TC-Timestamp", strconv.FormatInt(timestamp, 10))
+
+	resp, err := s.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSendSMS, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSendSMS, err)
+	}
+	var result tencentResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("%w: status %d: %v", ErrSendSMS, resp.StatusCode, err)
+	}
+	r := result.Response
+	if r.Error != nil {
+		return &ProviderError{Provider: "tencent", Code: r.Error.Code, Message: r.Error.Message, RequestID: r.RequestID}
+	}
+	for _, status := range r.SendStatusSet {
+		if status.Code != "Ok" {
+			return &ProviderError{Provider: "tencent", Code: status.Code, Message: status.Message, RequestID: r.RequestID}
+		}
+	}
+	return nil
+}
+
+// tencentAuthorization 计算 TC3-HMAC-SHA256 签名并返回 Authorization 头
+//
+// tencentAuthorization computes the TC3-HMAC-SHA256 signature and returns the Authorization header
+func tencentAuthorization(secretID, secretKey, host, contentType string, payload []byte, timestamp int64) string {
+	const (
+		algorithm     = "TC3-HMAC-SHA256"
+		service       = "sms"
+		signedHeaders = "content-type;host"
+	)
+	payloadHash := sha256.Sum256(payload)
+	canonicalRequest := "POST\n/\n\n" +
+		"content-type:" + contentType + "\nhost:" + host + "\n\n" +
+		signedHeaders + "\n" + hex.EncodeToString(payloadHash[:])
+
+	date := time.Unix(timestamp, 0).UTC().Format(time.DateOnly)
+	scope := date + "/" + service + "/tc3_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := algorithm + "\n" + strconv.FormatInt(timestamp, 10) + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	secretDate := hmacSHA256([]byte("TC3"+secretKey), date)
+	secretService := hmacSHA256(secretDate, service)
+	secretSigning := hmacSHA256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(secretSigning, stringToSign))
+
+	return algorithm + " Credential=" + secretID + "/" + scope +
+		", SignedHeaders=" + signedHeaders + ", Signature=" + signature
+}
+
+// hmacSHA256 计算 HMAC-SHA256
+//
+// hmacSHA256 computes HMAC-SHA256
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,114 @@
+// Package streamutil 提供 io.Reader、io.Writer 相关的工具，包括计数、限长、进度回调和批量关闭
+//
+// Package streamutil provides io.Reader and io.Writer helpers, including counting, length limits, progress callbacks and closing in bulk.
+package streamutil
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// CountingReader 统计已读取字节数的 Reader，Count 可在其他 goroutine 中调用
+//
+// CountingReader is a Reader that counts the bytes read; Count may be called from other goroutines.
+type CountingReader struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+// NewCountingReader 创建计数 Reader
+//
+// NewCountingReader creates a counting Reader.
+func NewCountingReader(r io.Reader) *CountingReader {
+	return &CountingReader{r: r}
+}
+
+// Read 读取数据并累加字节数
+//
+// Read reads data and adds to the byte count.
+func (c *CountingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// Count 返回已读取的字节数
+//
+// Count returns the number of bytes read.
+func (c *CountingReader) Count() int64 {
+	return c.n.Load()
+}
+
+// CountingWriter 统计已写入字节数的 Writer，Count 可在其他 goroutine 中调用
+//
+// CountingWriter is a Writer that counts the bytes written; Count may be called from other goroutines.
+type CountingWriter struct {
+	w io.Writer
+	n atomic.Int64
+}
+
+// NewCountingWriter 创建计数 Writer，w 为 nil 时只计数不写入（等价于 io.Discard）
+//
+// NewCountingWriter creates a counting Writer; with a nil w it only counts (like io.Discard).
+func NewCountingWriter(w io.Writer) *CountingWriter {
+	if w == nil {
+		w = io.Discard
+	}
+	return &CountingWriter{w: w}
+}
+
+// Write 写入数据并累加字节数
+//
+// Write writes data and adds to the byte count.
+func (c *CountingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// Count 返回已写入的字节数
+//
+// Count returns the number of bytes written.
+func (c *CountingWriter) Count() int64 {
+	return c.n.Load()
+}
+
+// multiCloser 依次关闭多个 Closer
+//
+// multiCloser closes several Closers in turn
+type multiCloser struct {
+	closers []io.Closer
+	once    sync.Once
+	err     error
+}
+
+// MultiCloser 返回一个关闭所有 closers 的 Closer
+// 按与传入相反的顺序关闭（与 defer 一致，例如先关闭压缩 Writer 再关闭文件），即使某个失败也会继续关闭其余的，
+// 返回合并后的错误；重复调用只关闭一次并返回相同的错误
+//
+// MultiCloser returns a Closer that closes all closers.
+// They are closed in reverse order (like defer, e.g. the compressing Writer before the file) and a failure does not stop the rest;
+// the errors are joined. Repeated calls close only once and return the same error.
+func MultiCloser(closers ...io.Closer) io.Closer {
+	return &multiCloser{closers: closers}
+}
+
+// Close 关闭所有 Closer
+//
+// Close closes all Closers.
+func (m *multiCloser) Close() error {
+	m.once.Do(func() {
+		var errs []error
+		for i := len(m.closers) - 1; i >= 0; i-- {
+			if c := m.closers[i]; c != nil {
+				if err := c.Close(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+		m.err = errors.Join(errs...)
+	})
+	return m.err
+}
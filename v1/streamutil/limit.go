@@ -0,0 +1,88 @@
+package streamutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrWriteLimit 表示写入的数据超过了 LimitedWriter 的限制，可用 errors.Is 匹配 *LimitError
+//
+// ErrWriteLimit indicates that data written exceeded a LimitedWriter's limit; it matches *LimitError with errors.Is
+var ErrWriteLimit = errors.New("write limit exceeded")
+
+// LimitError LimitedWriter 超限时返回的错误
+// Limit: 允许写入的最大字节数
+// Attempted: 尝试写入的总字节数（至少为超限时的累计值）
+//
+// LimitError is the error a LimitedWriter returns when the limit is exceeded.
+// Limit: Maximum number of bytes allowed
+// Attempted: Total number of bytes attempted (at least the running total when the limit was hit)
+type LimitError struct {
+	Limit     int64
+	Attempted int64
+}
+
+// Error 返回错误描述
+//
+// Error returns the error description.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%v: attempted %d bytes, limit %d", ErrWriteLimit, e.Attempted, e.Limit)
+}
+
+// Is 使 errors.Is(err, ErrWriteLimit) 成立
+//
+// Is makes errors.Is(err, ErrWriteLimit) hold.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrWriteLimit
+}
+
+// LimitedWriter 最多写入 limit 字节的 Writer
+// 超限的那次写入会先写入剩余的配额，然后返回 *LimitError；之后的写入都返回同样的错误
+//
+// LimitedWriter is a Writer that writes at most limit bytes.
+// The write that crosses the limit first writes the remaining quota and then returns *LimitError; later writes return the same error.
+type LimitedWriter struct {
+	w       io.Writer
+	limit   int64
+	written int64
+	err     *LimitError
+}
+
+// NewLimitedWriter 创建限长 Writer
+//
+// NewLimitedWriter creates a length-limited Writer.
+func NewLimitedWriter(w io.Writer, limit int64) *LimitedWriter {
+	return &LimitedWriter{w: w, limit: limit}
+}
+
+// Write 写入数据，超限时返回 *LimitError
+//
+// Write writes data, returning *LimitError when the limit is exceeded.
+func (l *LimitedWriter) Write(p []byte) (int, error) {
+	if l.err != nil {
+		l.err.Attempted += int64(len(p))
+		return 0, l.err
+	}
+	remaining := l.limit - l.written
+	if int64(len(p)) <= remaining {
+		n, err := l.w.Write(p)
+		l.written += int64(n)
+		return n, err
+	}
+
+	n, err := l.w.Write(p[:max(remaining, 0)])
+	l.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+	l.err = &LimitError{Limit: l.limit, Attempted: l.written - int64(n) + int64(len(p))}
+	return n, l.err
+}
+
+// Written 返回已写入的字节数
+//
+// Written returns the number of bytes written.
+func (l *LimitedWriter) Written() int64 {
+	return l.written
+}
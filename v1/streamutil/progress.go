@@ -0,0 +1,101 @@
+package streamutil
+
+import (
+	"io"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/timeutil"
+)
+
+// DefaultProgressInterval 进度回调的默认最小间隔
+//
+// DefaultProgressInterval is the default minimum interval between progress callbacks
+const DefaultProgressInterval = 500 * time.Millisecond
+
+// Progress 读取进度
+// Read: 已读取的字节数
+// Total: 总字节数，未知时为 -1
+// Done: 是否已读到结尾或出错
+// Err: 读取出错时的错误（io.EOF 不算错误）
+//
+// Progress is the read progress.
+// Read: Number of bytes read
+// Total: Total number of bytes, -1 if unknown
+// Done: Whether the end was reached or an error occurred
+// Err: The read error, if any (io.EOF is not an error)
+type Progress struct {
+	Read  int64
+	Total int64
+	Done  bool
+	Err   error
+}
+
+// Percent 返回完成百分比（0-100），总字节数未知时返回 -1
+//
+// Percent returns the completion percentage (0-100), or -1 if the total is unknown.
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+	return min(float64(p.Read)*100/float64(p.Total), 100)
+}
+
+// ProgressReader 在读取时回调进度的 Reader，回调经过节流，结束时（EOF 或出错）总会回调一次
+//
+// ProgressReader is a Reader that reports progress while reading; callbacks are throttled, and one final callback always fires at EOF or on error.
+type ProgressReader struct {
+	r        io.Reader
+	total    int64
+	read     int64
+	fn       func(Progress)
+	throttle *timeutil.Throttle
+	done     bool
+}
+
+// NewProgressReader 创建进度 Reader
+// 参数:
+//   - r: 源 Reader
+//   - total: 总字节数（例如 Content-Length），未知时传 -1
+//   - interval: 两次回调之间的最小间隔，<= 0 时使用 DefaultProgressInterval
+//   - fn: 进度回调，在调用 Read 的 goroutine 中同步执行
+//
+// 返回:
+//   - *ProgressReader: 进度 Reader
+//
+// NewProgressReader creates a progress Reader.
+// Parameters:
+//   - r: The source Reader
+//   - total: Total number of bytes (such as Content-Length); pass -1 if unknown
+//   - interval: Minimum interval between callbacks; DefaultProgressInterval is used when <= 0
+//   - fn: The progress callback, run synchronously on the goroutine calling Read
+//
+// Returns:
+//   - *ProgressReader: The progress Reader
+func NewProgressReader(r io.Reader, total int64, interval time.Duration, fn func(Progress)) *ProgressReader {
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+	return &ProgressReader{r: r, total: total, fn: fn, throttle: timeutil.NewThrottle(interval)}
+}
+
+// Read 读取数据并按需回调进度
+//
+// Read reads data and reports progress as needed.
+func (p *ProgressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if p.done {
+		return n, err
+	}
+	if err != nil {
+		p.done = true
+		prog := Progress{Read: p.read, Total: p.total, Done: true}
+		if err != io.EOF {
+			prog.Err = err
+		}
+		p.fn(prog)
+	} else if n > 0 && p.throttle.Allow() {
+		p.fn(Progress{Read: p.read, Total: p.total})
+	}
+	return n, err
+}
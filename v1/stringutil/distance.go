@@ -0,0 +1,71 @@
+package stringutil
+
+// Levenshtein 计算两个字符串的编辑距离（插入、删除、替换一个字符各计 1），按字符（rune）计算，支持中文
+// 时间复杂度 O(len(a)*len(b))，空间复杂度 O(min(len(a), len(b)))
+// 参数:
+//   - a: 第一个字符串
+//   - b: 第二个字符串
+//
+// 返回:
+//   - 编辑距离
+//
+// Levenshtein computes the edit distance between two strings (one insertion, deletion or substitution costs 1), counted in characters (runes) so Chinese is supported.
+// It takes O(len(a)*len(b)) time and O(min(len(a), len(b))) space.
+// Parameters:
+//   - a: The first string
+//   - b: The second string
+//
+// Returns:
+//   - The edit distance
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) < len(rb) {
+		ra, rb = rb, ra
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cur := row[j]
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			row[j] = min(row[j]+1, row[j-1]+1, prev+cost)
+			prev = cur
+		}
+	}
+	return row[len(rb)]
+}
+
+// Similarity 返回基于编辑距离的相似度，范围 [0, 1]，1 表示完全相同，计算方式为 1 - 编辑距离/较长字符串的字符数
+// 比较前不做任何规范化，需要忽略大小写、空白或全半角差异时应先自行处理
+// 参数:
+//   - a: 第一个字符串
+//   - b: 第二个字符串
+//
+// 返回:
+//   - 相似度，两个空字符串的相似度为 1
+//
+// Similarity returns an edit-distance-based similarity in [0, 1], where 1 means identical, computed as 1 - distance/character count of the longer string.
+// No normalisation is applied; fold case, whitespace or full-width characters beforehand if they should not count.
+// Parameters:
+//   - a: The first string
+//   - b: The second string
+//
+// Returns:
+//   - The similarity; two empty strings have similarity 1
+func Similarity(a, b string) float64 {
+	n := max(len([]rune(a)), len([]rune(b)))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(n)
+}
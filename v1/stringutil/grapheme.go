@@ -0,0 +1,154 @@
+package stringutil
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// zeroWidthJoiner 零宽连接符，用于组合 emoji 序列
+//
+// zeroWidthJoiner is the zero width joiner that combines emoji sequences
+const zeroWidthJoiner = '\u200d'
+
+// VisualLength 返回字符串中用户可见字符（字素簇）的数量
+// emoji 及其肤色修饰、ZWJ 组合序列（例如 👨‍👩‍👧）、国旗（例如 🇨🇳）、键帽（例如 1️⃣）与组合附加符号各计为 1
+// 适用于昵称等按"字数"限制长度的场景，按 rune 计数会把一个 emoji 算作多个字符
+// 参数:
+//   - s: 字符串
+//
+// 返回:
+//   - 字素簇数量
+//
+// VisualLength returns the number of user-perceived characters (grapheme clusters) in the string.
+// Emoji with skin tone modifiers, ZWJ sequences (e.g. 👨‍👩‍👧), flags (e.g. 🇨🇳), keycaps (e.g. 1️⃣) and combining marks each count as 1.
+// It suits length limits on nicknames and the like, where counting runes counts one emoji as several characters.
+// Parameters:
+//   - s: The string
+//
+// Returns:
+//   - The number of grapheme clusters
+func VisualLength(s string) int {
+	count := 0
+	for len(s) > 0 {
+		s = s[graphemeLen(s):]
+		count++
+	}
+	return count
+}
+
+// TruncateGraphemes 按字素簇数截断字符串，与 Truncate 相同但不会拆开 emoji 和组合字符，参见 VisualLength
+// 结果总长度（包括 suffix）不超过 maxLen 个字素簇
+// 参数:
+//   - s: 原始字符串
+//   - maxLen: 最大字素簇数
+//   - suffix: 截断后追加的后缀，例如 DefaultEllipsis
+//
+// 返回:
+//   - 截断后的字符串
+//
+// TruncateGraphemes truncates the string by grapheme cluster count; it is like Truncate but never splits emoji or combined characters. See VisualLength.
+// The total length of the result (including suffix) does not exceed maxLen grapheme clusters.
+// Parameters:
+//   - s: The original string
+//   - maxLen: Maximum number of grapheme clusters
+//   - suffix: Suffix appended after truncation, e.g. DefaultEllipsis
+//
+// Returns:
+//   - The truncated string
+func TruncateGraphemes(s string, maxLen int, suffix string) string {
+	if maxLen <= 0 {
+		return ""
+	}
+	if VisualLength(s) <= maxLen {
+		return s
+	}
+
+	keep := maxLen - VisualLength(suffix)
+	if keep <= 0 {
+		// 后缀本身已超过长度限制，只截断不追加
+		keep = maxLen
+		suffix = ""
+	}
+
+	end := 0
+	for range keep {
+		end += graphemeLen(s[end:])
+	}
+	return s[:end] + suffix
+}
+
+// graphemeLen 返回 s 开头的字素簇的字节长度，s 为空时返回 0
+// 实现了 UAX #29 中与 emoji 和组合符号相关的规则，未处理韩文字母（Jamo）组合和 Prepend 字符
+//
+// graphemeLen returns the byte length of the grapheme cluster at the start of s, or 0 if s is empty
+// It implements the UAX #29 rules relevant to emoji and combining marks; Hangul jamo sequences and Prepend characters are not handled
+func graphemeLen(s string) int {
+	if s == "" {
+		return 0
+	}
+	first, n := utf8.DecodeRuneInString(s)
+	if first == '\r' && len(s) > n && s[n] == '\n' {
+		return n + 1
+	}
+	if first < 0x20 || first == 0x7F {
+		return n
+	}
+
+	// 国旗由两个区域指示符组成
+	if isRegionalIndicator(first) {
+		if r, size := utf8.DecodeRuneInString(s[n:]); isRegionalIndicator(r) {
+			n += size
+		}
+	}
+
+	pictographic := isPictographic(first)
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		switch {
+		case isGraphemeExtend(r):
+			n += size
+		case r == zeroWidthJoiner:
+			n += size
+			// ZWJ 之后的 emoji 与前面的 emoji 组成一个字素簇
+			if next, nsize := utf8.DecodeRuneInString(s[n:]); pictographic && isPictographic(next) {
+				n += nsize
+			}
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// isGraphemeExtend 判断 r 是否附加在前一个字符上：组合符号（包括变体选择符和键帽）、emoji 肤色修饰符和标签字符
+//
+// isGraphemeExtend reports whether r attaches to the preceding character: combining marks (variation selectors and the keycap included), emoji skin tone modifiers and tag characters
+func isGraphemeExtend(r rune) bool {
+	return unicode.Is(unicode.M, r) ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) ||
+		(r >= 0xE0020 && r <= 0xE007F)
+}
+
+// isRegionalIndicator 判断 r 是否为区域指示符（🇦 到 🇿）
+//
+// isRegionalIndicator reports whether r is a regional indicator (🇦 to 🇿)
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// isPictographic 粗略判断 r 是否为 emoji 图形字符（Extended_Pictographic）
+//
+// isPictographic roughly reports whether r is an emoji pictograph (Extended_Pictographic)
+func isPictographic(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF,
+		r >= 0x2600 && r <= 0x27BF,
+		r >= 0x2300 && r <= 0x23FF,
+		r >= 0x2B00 && r <= 0x2BFF,
+		r >= 0x2190 && r <= 0x21FF,
+		r == 0x00A9, r == 0x00AE, r == 0x203C, r == 0x2049, r == 0x2122, r == 0x2139,
+		r == 0x3030, r == 0x303D, r == 0x3297, r == 0x3299:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+// Package stringutil 提供字符串处理相关的工具函数
+//
+// Package stringutil provides string manipulation utility functions.
+package stringutil
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaskRune 默认的脱敏字符
+//
+// DefaultMaskRune is the default masking character
+const DefaultMaskRune = '*'
+
+// MaskMiddle 保留字符串开头 keepStart 个字符和结尾 keepEnd 个字符，中间部分用 mask 替换
+// 按 rune 处理，支持中文等多字节字符
+// 如果字符串长度不足 keepStart+keepEnd，则只保留第一个字符（长度为 1 时全部替换）
+// 参数:
+//   - s: 原始字符串
+//   - keepStart: 开头保留的字符数
+//   - keepEnd: 结尾保留的字符数
+//   - mask: 用于替换的字符
+//
+// 返回:
+//   - 脱敏后的字符串
+//
+// MaskMiddle keeps keepStart characters at the beginning and keepEnd characters at the end, replacing the middle with mask.
+// It works on runes, supporting multi-byte characters such as Chinese.
+// If the string is shorter than keepStart+keepEnd, only the first character is kept (fully masked when the length is 1).
+// Parameters:
+//   - s: The original string
+//   - keepStart: Number of characters to keep at the beginning
+//   - keepEnd: Number of characters to keep at the end
+//   - mask: The replacement character
+//
+// Returns:
+//   - The masked string
+func MaskMiddle(s string, keepStart, keepEnd int, mask rune) string {
+	runes := []rune(s)
+	n := len(runes)
+	if n == 0 {
+		return s
+	}
+	if keepStart < 0 {
+		keepStart = 0
+	}
+	if keepEnd < 0 {
+		keepEnd = 0
+	}
+
+	// 长度不足时降级处理，避免泄露全部内容
+	if keepStart+keepEnd >= n {
+		keepEnd = 0
+		keepStart = 1
+		if n == 1 {
+			keepStart = 0
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	b.WriteString(string(runes[:keepStart]))
+	for i := keepStart; i < n-keepEnd; i++ {
+		b.WriteRune(mask)
+	}
+	b.WriteString(string(runes[n-keepEnd:]))
+	return b.String()
+}
+
+// MaskDigits 保留格式的数字脱敏：只替换数字，空格、连字符、括号等分隔符保持不变，适用于带格式的手机号、身份证号等
+// 开头 keepStart 个和结尾 keepEnd 个数字原样保留；数字总数不足 keepStart+keepEnd 时全部替换，避免泄露全部内容
+// 参数:
+//   - s: 原始字符串，例如 "+86 138-1234-5678"
+//   - keepStart: 开头保留的数字个数
+//   - keepEnd: 结尾保留的数字个数
+//   - mask: 用于替换的字符
+//
+// 返回:
+//   - 脱敏后的字符串，例如 "+86 138-****-5678"（keepStart 为 5 时）
+//
+// MaskDigits masks digits while preserving the format: only digits are replaced and separators such as spaces, hyphens and parentheses are kept, which suits formatted phone and ID numbers.
+// The first keepStart and last keepEnd digits are kept; if there are no more than keepStart+keepEnd digits, all of them are masked to avoid revealing the whole value.
+// Parameters:
+//   - s: The original string, e.g. "+86 138-1234-5678"
+//   - keepStart: Number of leading digits to keep
+//   - keepEnd: Number of trailing digits to keep
+//   - mask: The replacement character
+//
+// Returns:
+//   - The masked string, e.g. "+86 138-****-5678" (with keepStart 5)
+func MaskDigits(s string, keepStart, keepEnd int, mask rune) string {
+	total := 0
+	for i := range len(s) {
+		if s[i] >= '0' && s[i] <= '9' {
+			total++
+		}
+	}
+	keepStart, keepEnd = max(keepStart, 0), max(keepEnd, 0)
+	if keepStart+keepEnd >= total {
+		keepStart, keepEnd = 0, 0
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	seen := 0
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			b.WriteRune(r)
+			continue
+		}
+		if seen >= keepStart && seen < total-keepEnd {
+			r = mask
+		}
+		b.WriteRune(r)
+		seen++
+	}
+	return b.String()
+}
+
+// MaskPhone 手机号脱敏，保留前 3 位和后 4 位，例如 "13812345678" -> "138****5678"
+//
+// MaskPhone masks a phone number, keeping the first 3 and last 4 digits, e.g. "13812345678" -> "138****5678".
+func MaskPhone(phone string) string {
+	return MaskMiddle(phone, 3, 4, DefaultMaskRune)
+}
+
+// MaskEmail 邮箱脱敏，保留用户名首字符和完整域名，例如 "alice@example.com" -> "a****@example.com"
+// 如果不是合法的邮箱格式，则按 MaskMiddle 处理
+//
+// MaskEmail masks an email address, keeping the first character of the local part and the full domain,
+// e.g. "alice@example.com" -> "a****@example.com".
+// If the input is not a valid email format, it is handled by MaskMiddle.
+func MaskEmail(email string) string {
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 {
+		return MaskMiddle(email, 1, 0, DefaultMaskRune)
+	}
+	local := email[:at]
+	_, size := utf8.DecodeRuneInString(local)
+	return local[:size] + strings.Repeat(string(DefaultMaskRune), 4) + email[at:]
+}
+
+// MaskIDCard 身份证号脱敏，保留前 4 位和后 4 位，例如 "110101199001011234" -> "1101**********1234"
+//
+// MaskIDCard masks an ID card number, keeping the first 4 and last 4 characters,
+// e.g. "110101199001011234" -> "1101**********1234".
+func MaskIDCard(id string) string {
+	return MaskMiddle(id, 4, 4, DefaultMaskRune)
+}
+
+// MaskName 姓名脱敏，只保留第一个字符，例如 "张三丰" -> "张**"
+//
+// MaskName masks a personal name, keeping only the first character, e.g. "张三丰" -> "张**".
+func MaskName(name string) string {
+	return MaskMiddle(name, 1, 0, DefaultMaskRune)
+}
+
+// MaskBankCard 银行卡号脱敏，保留前 6 位和后 4 位
+//
+// MaskBankCard masks a bank card number, keeping the first 6 and last 4 digits.
+func MaskBankCard(card string) string {
+	return MaskMiddle(card, 6, 4, DefaultMaskRune)
+}
+
+// MaskAll 将字符串全部替换为固定长度的脱敏字符，不泄露原始长度，常用于密码、令牌等
+//
+// MaskAll replaces the whole string with a fixed-length mask without revealing the original length,
+// commonly used for passwords, tokens, etc.
+func MaskAll(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.Repeat(string(DefaultMaskRune), 6)
+}
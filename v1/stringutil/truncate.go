@@ -0,0 +1,52 @@
+package stringutil
+
+import "unicode/utf8"
+
+// DefaultEllipsis 默认的截断后缀
+//
+// DefaultEllipsis is the default truncation suffix
+const DefaultEllipsis = "..."
+
+// Truncate 按字符（rune）数截断字符串，超出 maxLen 时截断并追加 suffix
+// 结果总长度（包括 suffix）不超过 maxLen，支持中文等多字节字符
+// 参数:
+//   - s: 原始字符串
+//   - maxLen: 最大字符数
+//   - suffix: 截断后追加的后缀，例如 DefaultEllipsis
+//
+// 返回:
+//   - 截断后的字符串
+//
+// Truncate truncates the string by character (rune) count, appending suffix when it exceeds maxLen.
+// The total length of the result (including suffix) does not exceed maxLen; multi-byte characters such as Chinese are supported.
+// Parameters:
+//   - s: The original string
+//   - maxLen: Maximum number of characters
+//   - suffix: Suffix appended after truncation, e.g. DefaultEllipsis
+//
+// Returns:
+//   - The truncated string
+func Truncate(s string, maxLen int, suffix string) string {
+	if maxLen <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+
+	keep := maxLen - utf8.RuneCountInString(suffix)
+	if keep <= 0 {
+		// 后缀本身已超过长度限制，只截断不追加
+		keep = maxLen
+		suffix = ""
+	}
+
+	count := 0
+	for i := range s {
+		if count == keep {
+			return s[:i] + suffix
+		}
+		count++
+	}
+	return s
+}
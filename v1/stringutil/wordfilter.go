@@ -0,0 +1,318 @@
+package stringutil
+
+import (
+	"bufio"
+	"cmp"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WordMatch 一次敏感词匹配
+// Word: 匹配到的敏感词（加载时的原文）
+// Start: 匹配在文本中的起始字节偏移（包含）
+// End: 匹配在文本中的结束字节偏移（不包含），s[Start:End] 即匹配到的原文，可能包含被跳过的字符
+//
+// WordMatch is one sensitive word match.
+// Word: The matched word, as loaded
+// Start: Byte offset where the match starts in the text (inclusive)
+// End: Byte offset where the match ends in the text (exclusive); s[Start:End] is the matched text, possibly including skipped characters
+type WordMatch struct {
+	Word  string
+	Start int
+	End   int
+}
+
+// WordFilterOptions 敏感词过滤器的选项
+// IgnoreCase: 是否忽略大小写
+// Skip: 匹配时跳过的字符，例如空格和标点，用于识别 "敏 感*词" 这类插入干扰字符的写法；为 nil 时不跳过
+//
+// WordFilterOptions contains options for a WordFilter.
+// IgnoreCase: Whether to ignore case
+// Skip: Characters skipped while matching, such as spaces and punctuation, to catch words padded with noise like "b a-d"; nothing is skipped when nil
+type WordFilterOptions struct {
+	IgnoreCase bool
+	Skip       func(r rune) bool
+}
+
+// wordNode Aho-Corasick 自动机的节点
+// next: 子节点
+// fail: 失配时跳转的节点
+// words: 以该节点结尾的敏感词下标，包括通过失配链可达的后缀词
+//
+// wordNode is a node of the Aho-Corasick automaton
+// next: Child nodes
+// fail: Node to jump to on mismatch
+// words: Indexes of the words ending at this node, including suffix words reachable through the fail chain
+type wordNode struct {
+	next  map[rune]int
+	fail  int
+	words []int
+}
+
+// WordFilter 基于 Aho-Corasick 自动机的敏感词过滤器，匹配时间与文本长度成线性关系，与词库大小无关
+// 并发安全，加载词库期间的查询使用旧的自动机
+//
+// WordFilter is a sensitive word filter built on an Aho-Corasick automaton; matching is linear in the text length regardless of the dictionary size.
+// It is safe for concurrent use; queries during loading use the previous automaton.
+type WordFilter struct {
+	opts WordFilterOptions
+
+	// loadMu 串行化词库加载，避免并发加载时丢失词
+	loadMu sync.Mutex
+	mu     sync.RWMutex
+	words  []string
+	// lengths 每个敏感词规范化后的字符数
+	lengths []int
+	nodes   []wordNode
+}
+
+// NewWordFilter 创建敏感词过滤器
+// 参数:
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - *WordFilter: 过滤器
+//
+// NewWordFilter creates a sensitive word filter.
+// Parameters:
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - *WordFilter: The filter
+func NewWordFilter(opts *WordFilterOptions) *WordFilter {
+	f := &WordFilter{nodes: []wordNode{{}}}
+	if opts != nil {
+		f.opts = *opts
+	}
+	return f
+}
+
+// LoadWords 添加敏感词并重建自动机，空字符串和重复的词被忽略
+//
+// LoadWords adds sensitive words and rebuilds the automaton; empty and duplicate words are ignored.
+func (f *WordFilter) LoadWords(words ...string) {
+	f.loadMu.Lock()
+	defer f.loadMu.Unlock()
+	f.mu.RLock()
+	all := slices.Clone(f.words)
+	f.mu.RUnlock()
+	all = append(all, words...)
+	f.rebuild(all)
+}
+
+// LoadWordsFrom 从 r 中按行读取敏感词并添加，忽略空行和以 # 开头的注释行
+//
+// LoadWordsFrom reads sensitive words from r, one per line, and adds them; blank lines and lines starting with # are ignored.
+func (f *WordFilter) LoadWordsFrom(r io.Reader) error {
+	var words []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	f.LoadWords(words...)
+	return nil
+}
+
+// Contains 判断文本是否包含任意敏感词
+//
+// Contains reports whether the text contains any sensitive word.
+func (f *WordFilter) Contains(s string) bool {
+	found := false
+	f.scan(s, func(WordMatch) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// FindAll 返回文本中所有的敏感词匹配，包括相互重叠的匹配，按 Start 升序、较长的在前排列
+//
+// FindAll returns every sensitive word match in the text, overlapping ones included, sorted by Start ascending with longer matches first.
+func (f *WordFilter) FindAll(s string) []WordMatch {
+	var matches []WordMatch
+	f.scan(s, func(m WordMatch) bool {
+		matches = append(matches, m)
+		return true
+	})
+	slices.SortFunc(matches, func(a, b WordMatch) int {
+		if c := cmp.Compare(a.Start, b.Start); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.End, a.End)
+	})
+	return matches
+}
+
+// Replace 将文本中所有敏感词覆盖的字符替换为 mask，每个字符替换为一个 mask，被跳过的干扰字符同样被替换
+// 参数:
+//   - s: 文本
+//   - mask: 替换字符，例如 DefaultMaskRune
+//
+// 返回:
+//   - 替换后的文本
+//
+// Replace replaces every character covered by a sensitive word with mask, one mask per character, noise characters skipped inside a match included.
+// Parameters:
+//   - s: The text
+//   - mask: The replacement character, e.g. DefaultMaskRune
+//
+// Returns:
+//   - The text after replacement
+func (f *WordFilter) Replace(s string, mask rune) string {
+	// 合并重叠的匹配区间
+	var spans [][2]int
+	for _, m := range f.FindAll(s) {
+		if n := len(spans); n > 0 && m.Start <= spans[n-1][1] {
+			spans[n-1][1] = max(spans[n-1][1], m.End)
+			continue
+		}
+		spans = append(spans, [2]int{m.Start, m.End})
+	}
+	if len(spans) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	prev := 0
+	for _, sp := range spans {
+		b.WriteString(s[prev:sp[0]])
+		for range utf8.RuneCountInString(s[sp[0]:sp[1]]) {
+			b.WriteRune(mask)
+		}
+		prev = sp[1]
+	}
+	b.WriteString(s[prev:])
+	return b.String()
+}
+
+// normalize 按选项规范化字符
+//
+// normalize normalizes a character according to the options
+func (f *WordFilter) normalize(r rune) rune {
+	if f.opts.IgnoreCase {
+		return unicode.ToLower(r)
+	}
+	return r
+}
+
+// rebuild 用 words 重建自动机
+//
+// rebuild rebuilds the automaton from words
+func (f *WordFilter) rebuild(words []string) {
+	var (
+		kept    []string
+		lengths []int
+		seen    = make(map[string]bool, len(words))
+		nodes   = []wordNode{{}}
+	)
+	for _, w := range words {
+		var norm []rune
+		for _, r := range w {
+			if f.opts.Skip != nil && f.opts.Skip(r) {
+				continue
+			}
+			norm = append(norm, f.normalize(r))
+		}
+		key := string(norm)
+		if len(norm) == 0 || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		cur := 0
+		for _, r := range norm {
+			next, ok := nodes[cur].next[r]
+			if !ok {
+				if nodes[cur].next == nil {
+					nodes[cur].next = make(map[rune]int)
+				}
+				next = len(nodes)
+				nodes[cur].next[r] = next
+				nodes = append(nodes, wordNode{})
+			}
+			cur = next
+		}
+		nodes[cur].words = append(nodes[cur].words, len(kept))
+		kept = append(kept, w)
+		lengths = append(lengths, len(norm))
+	}
+
+	// 按层次遍历计算失配链接，并合并后缀节点的输出
+	queue := make([]int, 0, len(nodes))
+	for _, child := range nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range nodes[cur].next {
+			fail := nodes[cur].fail
+			for fail != 0 && !hasChild(nodes[fail], r) {
+				fail = nodes[fail].fail
+			}
+			fail = nodes[fail].next[r]
+			nodes[child].fail = fail
+			nodes[child].words = append(nodes[child].words, nodes[fail].words...)
+			queue = append(queue, child)
+		}
+	}
+
+	f.mu.Lock()
+	f.words, f.lengths, f.nodes = kept, lengths, nodes
+	f.mu.Unlock()
+}
+
+// hasChild 判断节点是否有字符 r 的子节点
+//
+// hasChild reports whether the node has a child for r
+func hasChild(n wordNode, r rune) bool {
+	_, ok := n.next[r]
+	return ok
+}
+
+// scan 扫描文本，对每个匹配调用 yield，yield 返回 false 时停止
+//
+// scan scans the text, calling yield for each match and stopping when it returns false
+func (f *WordFilter) scan(s string, yield func(WordMatch) bool) {
+	f.mu.RLock()
+	words, lengths, nodes := f.words, f.lengths, f.nodes
+	f.mu.RUnlock()
+	if len(words) == 0 {
+		return
+	}
+
+	// starts 记录每个参与匹配的字符的起始字节偏移，用于还原匹配的起点
+	var starts []int
+	cur := 0
+	for i, r := range s {
+		if f.opts.Skip != nil && f.opts.Skip(r) {
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		end := i + size
+		r = f.normalize(r)
+		starts = append(starts, i)
+		for cur != 0 && !hasChild(nodes[cur], r) {
+			cur = nodes[cur].fail
+		}
+		cur = nodes[cur].next[r]
+		for _, w := range nodes[cur].words {
+			m := WordMatch{Word: words[w], Start: starts[len(starts)-lengths[w]], End: end}
+			if !yield(m) {
+				return
+			}
+		}
+	}
+}
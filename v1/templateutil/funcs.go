@@ -0,0 +1,84 @@
+// Package templateutil 提供邮件、通知等模板的渲染工具，内置常用的格式化函数，并支持布局、局部模板和解析缓存
+//
+// Package templateutil provides rendering utilities for email and notification templates,
+// with built-in formatting functions, layout/partial support and caching of parsed templates.
+package templateutil
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/currencyutil"
+	"github.com/supergodk/go-utils/v1/stringutil"
+	"github.com/supergodk/go-utils/v1/timeutil"
+)
+
+// DefaultFuncs 返回内置的模板函数
+// 时间: formatTime(t, layout)、formatTimeIn(t, layout, tz)、formatUnix(ts, layout, tz)
+// 字符串: truncate(s, n)、maskPhone、maskEmail、maskName、maskIDCard、upper、lower、trim、join(sep, list)
+// 金额: money(m)（带币种）、moneyAmount(m)（不带币种）、cents(amount, currency)
+// 其他: default(def, v)
+//
+// DefaultFuncs returns the built-in template functions.
+// Time: formatTime(t, layout), formatTimeIn(t, layout, tz), formatUnix(ts, layout, tz)
+// Strings: truncate(s, n), maskPhone, maskEmail, maskName, maskIDCard, upper, lower, trim, join(sep, list)
+// Money: money(m) (with currency), moneyAmount(m) (without currency), cents(amount, currency)
+// Other: default(def, v)
+func DefaultFuncs() map[string]any {
+	return map[string]any{
+		"formatTime": func(t time.Time, layout string) string {
+			return t.Format(layout)
+		},
+		"formatTimeIn": timeutil.FormatInLocation,
+		"formatUnix":   timeutil.FormatUnix,
+
+		"truncate": func(s string, n int) string {
+			return stringutil.Truncate(s, n, stringutil.DefaultEllipsis)
+		},
+		"maskPhone":  stringutil.MaskPhone,
+		"maskEmail":  stringutil.MaskEmail,
+		"maskName":   stringutil.MaskName,
+		"maskIDCard": stringutil.MaskIDCard,
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"join": func(sep string, list []string) string {
+			return strings.Join(list, sep)
+		},
+
+		"money": func(m currencyutil.Money) string {
+			return m.String()
+		},
+		"moneyAmount": func(m currencyutil.Money) string {
+			return m.Decimal()
+		},
+		"cents": func(amount int64, currency string) string {
+			return currencyutil.New(amount, currency).Decimal()
+		},
+
+		"default": func(def, v any) any {
+			if isEmpty(v) {
+				return def
+			}
+			return v
+		},
+	}
+}
+
+// isEmpty 判断模板值是否为空（nil、零值数字、空字符串）
+//
+// isEmpty reports whether a template value is empty (nil, zero number, empty string)
+func isEmpty(v any) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	case bool:
+		return !x
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(x) == "0"
+	}
+	return false
+}
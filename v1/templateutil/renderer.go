@@ -0,0 +1,270 @@
+package templateutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"maps"
+	"path"
+	"sync"
+	texttemplate "text/template"
+)
+
+const (
+	// DefaultLayoutDir 默认的布局模板目录
+	//
+	// DefaultLayoutDir is the default directory of layout templates
+	DefaultLayoutDir = "layouts"
+	// DefaultPartialDir 默认的局部模板目录
+	//
+	// DefaultPartialDir is the default directory of partial templates
+	DefaultPartialDir = "partials"
+)
+
+var (
+	// ErrTemplateNotFound 表示模板文件不存在
+	//
+	// ErrTemplateNotFound indicates that the template file does not exist.
+	ErrTemplateNotFound = errors.New("template not found")
+	// ErrRenderTemplate 表示模板解析或渲染失败
+	//
+	// ErrRenderTemplate indicates that parsing or rendering the template failed.
+	ErrRenderTemplate = errors.New("failed to render template")
+)
+
+// Options 渲染器选项
+// LayoutDir: 布局模板目录，默认 DefaultLayoutDir
+// PartialDir: 局部模板目录，默认 DefaultPartialDir
+// Text: 是否使用 text/template（用于纯文本邮件、短信），默认使用 html/template 自动转义
+// Funcs: 额外的模板函数，会覆盖同名的内置函数
+// DisableCache: 是否禁用解析缓存，开发环境下修改模板后无需重启
+//
+// Options contains options for the renderer.
+// LayoutDir: Directory of layout templates, defaults to DefaultLayoutDir
+// PartialDir: Directory of partial templates, defaults to DefaultPartialDir
+// Text: Whether to use text/template (for plain-text emails and SMS); html/template with auto-escaping is used by default
+// Funcs: Extra template functions, overriding built-in functions with the same name
+// DisableCache: Whether to disable the parse cache, so template changes take effect without restarting in development
+type Options struct {
+	LayoutDir    string
+	PartialDir   string
+	Text         bool
+	Funcs        map[string]any
+	DisableCache bool
+}
+
+// executor html/template 和 text/template 的公共接口
+//
+// executor is the common interface of html/template and text/template
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data any) error
+}
+
+// Renderer 模板渲染器
+// 每个页面模板会与布局目录和局部模板目录下的所有模板一起解析，结果按页面缓存
+// 模板通过文件名（不含目录）引用，例如 {{template "footer.html" .}}
+//
+// Renderer is a template renderer.
+// Each page template is parsed together with all templates in the layout and partial directories, and the result is cached per page.
+// Templates are referenced by file name (without directory), e.g. {{template "footer.html" .}}
+type Renderer struct {
+	fsys  fs.FS
+	opts  Options
+	funcs map[string]any
+
+	mu    sync.RWMutex
+	cache map[string]executor
+}
+
+// NewRenderer 创建模板渲染器
+// 参数:
+//   - fsys: 模板文件系统，通常为 embed.FS
+//   - opts: 渲染器选项，可以为 nil
+//
+// NewRenderer creates a template renderer.
+// Parameters:
+//   - fsys: Template file system, usually an embed.FS
+//   - opts: Renderer options, may be nil
+func NewRenderer(fsys fs.FS, opts *Options) *Renderer {
+	r := &Renderer{
+		fsys:  fsys,
+		cache: make(map[string]executor),
+	}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.LayoutDir == "" {
+		r.opts.LayoutDir = DefaultLayoutDir
+	}
+	if r.opts.PartialDir == "" {
+		r.opts.PartialDir = DefaultPartialDir
+	}
+	r.funcs = DefaultFuncs()
+	maps.Copy(r.funcs, r.opts.Funcs)
+	return r
+}
+
+// Render 渲染页面模板
+// 参数:
+//   - w: 输出目标
+//   - page: 页面模板路径（相对于 fsys），例如 "emails/welcome.html"
+//   - data: 模板数据
+//
+// 返回:
+//   - error: 模板不存在、解析失败或渲染失败时返回错误
+//
+// Render renders a page template.
+// Parameters:
+//   - w: Output destination
+//   - page: Page template path (relative to fsys), e.g. "emails/welcome.html"
+//   - data: Template data
+//
+// Returns:
+//   - error: Returns an error if the template does not exist, parsing fails or rendering fails
+func (r *Renderer) Render(w io.Writer, page string, data any) error {
+	return r.execute(w, page, path.Base(page), data)
+}
+
+// RenderLayout 使用布局渲染页面模板
+// 布局中通过 {{block "content" .}}{{end}} 预留位置，页面中通过 {{define "content"}}...{{end}} 填充
+// 参数:
+//   - w: 输出目标
+//   - layout: 布局模板文件名，例如 "base.html"
+//   - page: 页面模板路径（相对于 fsys）
+//   - data: 模板数据
+//
+// 返回:
+//   - error: 模板不存在、解析失败或渲染失败时返回错误
+//
+// RenderLayout renders a page template with a layout.
+// The layout reserves slots with {{block "content" .}}{{end}} and the page fills them with {{define "content"}}...{{end}}.
+// Parameters:
+//   - w: Output destination
+//   - layout: Layout template file name, e.g. "base.html"
+//   - page: Page template path (relative to fsys)
+//   - data: Template data
+//
+// Returns:
+//   - error: Returns an error if a template does not exist, parsing fails or rendering fails
+func (r *Renderer) RenderLayout(w io.Writer, layout, page string, data any) error {
+	return r.execute(w, page, path.Base(layout), data)
+}
+
+// RenderString 渲染页面模板并返回字符串，常用于邮件正文
+//
+// RenderString renders a page template and returns it as a string, commonly used for email bodies.
+func (r *Renderer) RenderString(page string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := r.Render(&buf, page, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// RenderLayoutString 使用布局渲染页面模板并返回字符串
+//
+// RenderLayoutString renders a page template with a layout and returns it as a string.
+func (r *Renderer) RenderLayoutString(layout, page string, data any) (string, error) {
+	var buf bytes.Buffer
+	if err := r.RenderLayout(&buf, layout, page, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Reset 清空解析缓存
+//
+// Reset clears the parse cache.
+func (r *Renderer) Reset() {
+	r.mu.Lock()
+	r.cache = make(map[string]executor)
+	r.mu.Unlock()
+}
+
+// execute 获取（或解析）页面模板集合并执行指定名称的模板
+// 先渲染到缓冲区，避免出错时向 w 写入不完整的内容
+//
+// execute gets (or parses) the template set of the page and executes the template with the given name.
+// It renders into a buffer first to avoid writing incomplete content to w on error.
+func (r *Renderer) execute(w io.Writer, page, name string, data any) error {
+	tmpl, err := r.load(page)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrRenderTemplate, page, err)
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// load 从缓存中获取页面模板集合，不存在时解析
+//
+// load gets the template set of the page from the cache, parsing it if absent
+func (r *Renderer) load(page string) (executor, error) {
+	if !r.opts.DisableCache {
+		r.mu.RLock()
+		tmpl, ok := r.cache[page]
+		r.mu.RUnlock()
+		if ok {
+			return tmpl, nil
+		}
+	}
+
+	tmpl, err := r.parse(page)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.opts.DisableCache {
+		r.mu.Lock()
+		r.cache[page] = tmpl
+		r.mu.Unlock()
+	}
+	return tmpl, nil
+}
+
+// parse 按布局、局部模板、页面的顺序解析模板，页面中的 define 可以覆盖布局中的 block
+//
+// parse parses templates in the order layouts, partials, page, so that defines in the page override blocks in the layout
+func (r *Renderer) parse(page string) (executor, error) {
+	if _, err := fs.Stat(r.fsys, page); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, page)
+	}
+
+	var files []string
+	for _, dir := range []string{r.opts.LayoutDir, r.opts.PartialDir} {
+		matches, err := fs.Glob(r.fsys, path.Join(dir, "*"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRenderTemplate, err)
+		}
+		for _, m := range matches {
+			if m == page {
+				continue
+			}
+			if info, err := fs.Stat(r.fsys, m); err != nil || info.IsDir() {
+				continue
+			}
+			files = append(files, m)
+		}
+	}
+	files = append(files, page)
+
+	var (
+		tmpl executor
+		err  error
+	)
+	if r.opts.Text {
+		tmpl, err = texttemplate.New(path.Base(page)).Funcs(r.funcs).ParseFS(r.fsys, files...)
+	} else {
+		tmpl, err = htmltemplate.New(path.Base(page)).Funcs(r.funcs).ParseFS(r.fsys, files...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrRenderTemplate, page, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,72 @@
+package testutil
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/timeutil"
+)
+
+// FrozenClock 冻结的时钟，只在调用 Set 或 Advance 时变化；并发安全
+//
+// FrozenClock is a frozen clock that only moves on Set or Advance; it is safe for concurrent use.
+type FrozenClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// FreezeTime 把 timeutil 的包级时钟冻结在给定时间，测试结束时自动恢复
+// 使用 timeutil.Now 的功能（ExpiresAt、GetCurrentMonthTime 等）都会看到冻结的时间；由于时钟是全局的，不要与 t.Parallel 一起使用
+// 参数:
+//   - t: 测试对象
+//   - at: 冻结的时间，为零值时使用当前时间
+//
+// 返回:
+//   - *FrozenClock: 可以拨动的时钟
+//
+// FreezeTime freezes the timeutil package clock at the given time and restores it when the test ends.
+// Features reading timeutil.Now (ExpiresAt, GetCurrentMonthTime and so on) see the frozen time; since the clock is global, do not combine it with t.Parallel.
+// Parameters:
+//   - t: The test
+//   - at: The frozen time; the current time when zero
+//
+// Returns:
+//   - *FrozenClock: The clock, which can be moved
+func FreezeTime(t testing.TB, at time.Time) *FrozenClock {
+	t.Helper()
+	if at.IsZero() {
+		at = time.Now()
+	}
+	c := &FrozenClock{now: at}
+	t.Cleanup(timeutil.SetClock(c))
+	return c
+}
+
+// Now 实现 timeutil.Clock 接口
+//
+// Now implements the timeutil.Clock interface.
+func (c *FrozenClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set 把时钟设置为给定时间
+//
+// Set sets the clock to the given time.
+func (c *FrozenClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
+
+// Advance 把时钟拨快 d，d 为负数时拨慢，返回拨动后的时间
+//
+// Advance moves the clock forward by d (backward when d is negative) and returns the new time.
+func (c *FrozenClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
@@ -0,0 +1,180 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DefaultJWKSKeyID FakeJWKSServer 未指定 kid 时使用的密钥 ID
+//
+// DefaultJWKSKeyID is the key ID FakeJWKSServer uses when no kid is given
+const DefaultJWKSKeyID = "test-key"
+
+// FakeJWKS 假的 JWKS 服务，用自己生成的 RSA 密钥签发 RS256 令牌，并在 URL 上以 JWKS 格式发布公钥；并发安全
+// 可以代替 Apple 等身份提供方的公钥地址，测试令牌校验、密钥轮换和缓存
+//
+// FakeJWKS is a fake JWKS server that signs RS256 tokens with RSA keys it generates and publishes the public keys in JWKS format at its URL; it is safe for concurrent use.
+// It can stand in for the key endpoint of identity providers such as Apple to test token verification, key rotation and caching.
+type FakeJWKS struct {
+	srv      *httptest.Server
+	requests atomic.Int64
+
+	mu   sync.RWMutex
+	keys map[string]*rsa.PrivateKey
+}
+
+// FakeJWKSServer 启动假的 JWKS 服务，测试结束时自动关闭
+// 参数:
+//   - t: 测试对象
+//   - kids: 初始密钥的 ID，为空时生成一个 DefaultJWKSKeyID 密钥
+//
+// 返回:
+//   - *FakeJWKS: 假的 JWKS 服务
+//
+// FakeJWKSServer starts a fake JWKS server that is closed when the test ends.
+// Parameters:
+//   - t: The test
+//   - kids: IDs of the initial keys; one DefaultJWKSKeyID key is generated when empty
+//
+// Returns:
+//   - *FakeJWKS: The fake JWKS server
+func FakeJWKSServer(t testing.TB, kids ...string) *FakeJWKS {
+	t.Helper()
+	if len(kids) == 0 {
+		kids = []string{DefaultJWKSKeyID}
+	}
+	f := &FakeJWKS{keys: make(map[string]*rsa.PrivateKey)}
+	for _, kid := range kids {
+		f.AddKey(t, kid)
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+// URL 返回发布 JWKS 的地址
+//
+// URL returns the address serving the JWKS.
+func (f *FakeJWKS) URL() string {
+	return f.srv.URL
+}
+
+// Requests 返回 JWKS 被请求的次数，用于断言缓存行为
+//
+// Requests returns how many times the JWKS was requested, for asserting caching behaviour.
+func (f *FakeJWKS) Requests() int64 {
+	return f.requests.Load()
+}
+
+// AddKey 生成并发布一个新密钥，已存在同名密钥时替换，用于模拟密钥轮换
+//
+// AddKey generates and publishes a new key, replacing any key with the same ID, to simulate key rotation.
+func (f *FakeJWKS) AddKey(t testing.TB, kid string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("FakeJWKS: generate key: %v", err)
+	}
+	f.mu.Lock()
+	f.keys[kid] = key
+	f.mu.Unlock()
+	return key
+}
+
+// RemoveKey 停止发布指定密钥
+//
+// RemoveKey stops publishing the given key.
+func (f *FakeJWKS) RemoveKey(kid string) {
+	f.mu.Lock()
+	delete(f.keys, kid)
+	f.mu.Unlock()
+}
+
+// PublicKey 返回指定密钥的公钥，不存在时返回 nil
+//
+// PublicKey returns the public key of the given key, or nil if it does not exist.
+func (f *FakeJWKS) PublicKey(kid string) *rsa.PublicKey {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if key, ok := f.keys[kid]; ok {
+		return &key.PublicKey
+	}
+	return nil
+}
+
+// Sign 用指定密钥签发 RS256 令牌，头部带上 kid
+// 参数:
+//   - t: 测试对象
+//   - kid: 密钥 ID，密钥不存在时终止测试
+//   - claims: 令牌声明，例如 {"sub": "user-1", "exp": ...}
+//
+// 返回:
+//   - string: 签名后的令牌
+//
+// Sign issues an RS256 token with the given key, carrying kid in the header.
+// Parameters:
+//   - t: The test
+//   - kid: Key ID; the test stops if the key does not exist
+//   - claims: Token claims, e.g. {"sub": "user-1", "exp": ...}
+//
+// Returns:
+//   - string: The signed token
+func (f *FakeJWKS) Sign(t testing.TB, kid string, claims map[string]any) string {
+	t.Helper()
+	f.mu.RLock()
+	key, ok := f.keys[kid]
+	f.mu.RUnlock()
+	if !ok {
+		t.Fatalf("FakeJWKS: unknown kid %q", kid)
+	}
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	tok.Header["kid"] = kid
+	signed, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("FakeJWKS: sign: %v", err)
+	}
+	return signed
+}
+
+// serve 以 JWKS 格式输出所有公钥，按 kid 排序
+//
+// serve writes every public key in JWKS format, sorted by kid
+func (f *FakeJWKS) serve(w http.ResponseWriter, _ *http.Request) {
+	f.requests.Add(1)
+	type jwk struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+	}
+	f.mu.RLock()
+	keys := make([]jwk, 0, len(f.keys))
+	for kid, key := range f.keys {
+		keys = append(keys, jwk{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	f.mu.RUnlock()
+	slices.SortFunc(keys, func(a, b jwk) int { return strings.Compare(a.Kid, b.Kid) })
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{"keys": keys})
+}
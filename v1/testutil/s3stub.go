@@ -0,0 +1,439 @@
+package testutil
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/supergodk/go-utils/v1/ossutil"
+)
+
+// S3Stub 基于 httptest 的内存 S3 服务，使用路径风格的地址；并发安全
+// 支持 HeadBucket、PutObject（含 If-Match/If-None-Match 条件）、GetObject、HeadObject、DeleteObject、CopyObject 和 ListObjectsV2，
+// 其他操作返回 501 NotImplemented
+//
+// S3Stub is an httptest-based in-memory S3 server using path-style addressing; it is safe for concurrent use.
+// It supports HeadBucket, PutObject (including If-Match/If-None-Match conditions), GetObject, HeadObject, DeleteObject, CopyObject and ListObjectsV2;
+// other operations return 501 NotImplemented.
+type S3Stub struct {
+	srv *httptest.Server
+
+	mu      sync.Mutex
+	buckets map[string]map[string]*StubObject
+}
+
+// StubObject S3Stub 中保存的对象
+// Data: 对象内容
+// ContentType: 内容类型
+// CacheControl: 缓存控制头
+// Metadata: 用户元数据（x-amz-meta-*，键为小写）
+// ETag: 内容的 MD5 十六进制，不含引号
+// LastModified: 最后修改时间
+//
+// StubObject is an object stored in an S3Stub.
+// Data: Object content
+// ContentType: Content type
+// CacheControl: Cache-Control header
+// Metadata: User metadata (x-amz-meta-*, lower-case keys)
+// ETag: Hex MD5 of the content, without quotes
+// LastModified: Last modification time
+type StubObject struct {
+	Data         []byte
+	ContentType  string
+	CacheControl string
+	Metadata     map[string]string
+	ETag         string
+	LastModified time.Time
+}
+
+// NewS3Stub 启动内存 S3 服务并创建给定的存储桶，测试结束时自动关闭
+// 参数:
+//   - t: 测试对象
+//   - buckets: 预先创建的存储桶，访问其他存储桶返回 NoSuchBucket
+//
+// 返回:
+//   - *S3Stub: 内存 S3 服务
+//
+// NewS3Stub starts an in-memory S3 server with the given buckets and closes it when the test ends.
+// Parameters:
+//   - t: The test
+//   - buckets: Buckets to create; other buckets return NoSuchBucket
+//
+// Returns:
+//   - *S3Stub: The in-memory S3 server
+func NewS3Stub(t testing.TB, buckets ...string) *S3Stub {
+	t.Helper()
+	s := &S3Stub{buckets: make(map[string]map[string]*StubObject)}
+	for _, b := range buckets {
+		s.buckets[b] = make(map[string]*StubObject)
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.srv.Close)
+	return s
+}
+
+// URL 返回服务地址，作为 S3 客户端的 BaseEndpoint
+//
+// URL returns the server address, to be used as the S3 client's BaseEndpoint.
+func (s *S3Stub) URL() string {
+	return s.srv.URL
+}
+
+// Options 返回把 S3 客户端指向本服务的选项：路径风格、静态凭证，并且只在必需时计算校验和
+//
+// Options returns S3 client options pointing at this server: path-style addressing, static credentials and checksums only when required.
+func (s *S3Stub) Options() func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(s.srv.URL)
+		o.UsePathStyle = true
+		o.Region = "us-east-1"
+		o.Credentials = aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "test", SecretAccessKey: "test"}, nil
+		})
+		o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+		o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+	}
+}
+
+// Client 返回连接本服务的 ossutil 客户端
+//
+// Client returns an ossutil client connected to this server.
+func (s *S3Stub) Client() *ossutil.OssClient {
+	return ossutil.NewOssClientFromConfig(aws.Config{Region: "us-east-1"}, s.Options())
+}
+
+// CreateBucket 创建存储桶，已存在时不做任何事
+//
+// CreateBucket creates a bucket; it does nothing if the bucket exists.
+func (s *S3Stub) CreateBucket(bucket string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.buckets[bucket]; !ok {
+		s.buckets[bucket] = make(map[string]*StubObject)
+	}
+}
+
+// PutObject 直接写入对象，用于准备测试数据；存储桶不存在时自动创建
+//
+// PutObject writes an object directly, for seeding test data; the bucket is created if missing.
+func (s *S3Stub) PutObject(bucket, key string, data []byte, contentType string) {
+	s.CreateBucket(bucket)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.buckets[bucket][key] = newStubObject(data, contentType, "", nil)
+}
+
+// Object 返回对象的副本，不存在时返回 false
+//
+// Object returns a copy of the object, or false if it does not exist.
+func (s *S3Stub) Object(bucket, key string) (StubObject, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	obj, ok := s.buckets[bucket][key]
+	if !ok {
+		return StubObject{}, false
+	}
+	cp := *obj
+	cp.Data = slices.Clone(obj.Data)
+	return cp, true
+}
+
+// Keys 返回存储桶中所有对象的键，已排序
+//
+// Keys returns the keys of every object in the bucket, sorted.
+func (s *S3Stub) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for k := range s.buckets[bucket] {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// newStubObject 创建对象并计算 ETag
+//
+// newStubObject creates an object and computes its ETag
+func newStubObject(data []byte, contentType, cacheControl string, meta map[string]string) *StubObject {
+	sum := md5.Sum(data)
+	if contentType == "" {
+		contentType = "binary/octet-stream"
+	}
+	return &StubObject{
+		Data:         data,
+		ContentType:  contentType,
+		CacheControl: cacheControl,
+		Metadata:     meta,
+		ETag:         hex.EncodeToString(sum[:]),
+		LastModified: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// serve 分发 S3 请求
+//
+// serve dispatches S3 requests
+func (s *S3Stub) serve(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	objects, ok := s.buckets[bucket]
+	if !ok {
+		s3Error(w, r, http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist")
+		return
+	}
+
+	switch {
+	case key == "" && r.Method == http.MethodHead:
+		w.WriteHeader(http.StatusOK)
+	case key == "" && r.Method == http.MethodGet && q.Get("list-type") == "2":
+		s.listObjects(w, bucket, objects, q)
+	case key == "" || len(subresources(q)) > 0:
+		s3Error(w, r, http.StatusNotImplemented, "NotImplemented", "The S3 stub does not implement this operation")
+	case r.Method == http.MethodPut && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.copyObject(w, r, objects, key)
+	case r.Method == http.MethodPut:
+		s.putObject(w, r, objects, key)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		obj, ok := objects[key]
+		if !ok {
+			s3Error(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist")
+			return
+		}
+		writeObjectHeaders(w, obj)
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.Data)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.Data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s3Error(w, r, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed")
+	}
+}
+
+// putObject 处理 PutObject
+//
+// putObject handles PutObject
+func (s *S3Stub) putObject(w http.ResponseWriter, r *http.Request, objects map[string]*StubObject, key string) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		s3Error(w, r, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	existing, exists := objects[key]
+	if m := r.Header.Get("If-None-Match"); m == "*" && exists {
+		s3Error(w, r, http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+		return
+	}
+	if m := r.Header.Get("If-Match"); m != "" && (!exists || strings.Trim(m, `"`) != existing.ETag) {
+		s3Error(w, r, http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+		return
+	}
+	obj := newStubObject(data, r.Header.Get("Content-Type"), r.Header.Get("Cache-Control"), userMetadata(r.Header))
+	objects[key] = obj
+	w.Header().Set("ETag", `"`+obj.ETag+`"`)
+	w.WriteHeader(http.StatusOK)
+}
+
+// copyObject 处理 CopyObject，支持 COPY 和 REPLACE 元数据指令
+//
+// copyObject handles CopyObject, supporting the COPY and REPLACE metadata directives
+func (s *S3Stub) copyObject(w http.ResponseWriter, r *http.Request, objects map[string]*StubObject, key string) {
+	source, err := url.PathUnescape(strings.TrimPrefix(r.Header.Get("X-Amz-Copy-Source"), "/"))
+	if err != nil {
+		s3Error(w, r, http.StatusBadRequest, "InvalidArgument", "Invalid copy source")
+		return
+	}
+	source, _, _ = strings.Cut(source, "?")
+	srcBucket, srcKey, _ := strings.Cut(source, "/")
+	src, ok := s.buckets[srcBucket][srcKey]
+	if !ok {
+		s3Error(w, r, http.StatusNotFound, "NoSuchKey", "The specified key does not exist")
+		return
+	}
+	if m := r.Header.Get("X-Amz-Copy-Source-If-Match"); m != "" && strings.Trim(m, `"`) != src.ETag {
+		s3Error(w, r, http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+		return
+	}
+	obj := newStubObject(slices.Clone(src.Data), src.ContentType, src.CacheControl, src.Metadata)
+	if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		obj = newStubObject(obj.Data, r.Header.Get("Content-Type"), r.Header.Get("Cache-Control"), userMetadata(r.Header))
+	}
+	objects[key] = obj
+	writeXML(w, http.StatusOK, struct {
+		XMLName      xml.Name `xml:"CopyObjectResult"`
+		ETag         string   `xml:"ETag"`
+		LastModified string   `xml:"LastModified"`
+	}{ETag: `"` + obj.ETag + `"`, LastModified: obj.LastModified.Format(time.RFC3339)})
+}
+
+// listObjects 处理 ListObjectsV2，支持 prefix、delimiter、max-keys、start-after 和 continuation-token
+//
+// listObjects handles ListObjectsV2, supporting prefix, delimiter, max-keys, start-after and continuation-token
+func (s *S3Stub) listObjects(w http.ResponseWriter, bucket string, objects map[string]*StubObject, q url.Values) {
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	maxKeys := 1000
+	if v, err := strconv.Atoi(q.Get("max-keys")); err == nil && v >= 0 {
+		maxKeys = min(v, 1000)
+	}
+	after := q.Get("start-after")
+	if token := q.Get("continuation-token"); token != "" {
+		// 续传令牌是上一页最后一个键的十六进制
+		if raw, err := hex.DecodeString(token); err == nil {
+			after = string(raw)
+		}
+	}
+
+	keys := make([]string, 0, len(objects))
+	for k := range objects {
+		if strings.HasPrefix(k, prefix) && k > after {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+
+	type content struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		ETag         string `xml:"ETag"`
+		Size         int    `xml:"Size"`
+		StorageClass string `xml:"StorageClass"`
+	}
+	type commonPrefix struct {
+		Prefix string `xml:"Prefix"`
+	}
+	result := struct {
+		XMLName               xml.Name       `xml:"ListBucketResult"`
+		Name                  string         `xml:"Name"`
+		Prefix                string         `xml:"Prefix"`
+		Delimiter             string         `xml:"Delimiter,omitempty"`
+		MaxKeys               int            `xml:"MaxKeys"`
+		KeyCount              int            `xml:"KeyCount"`
+		IsTruncated           bool           `xml:"IsTruncated"`
+		NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+		Contents              []content      `xml:"Contents"`
+		CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+	}{Name: bucket, Prefix: prefix, Delimiter: delimiter, MaxKeys: maxKeys}
+
+	var last string
+	for _, k := range keys {
+		if result.KeyCount == maxKeys {
+			result.IsTruncated = true
+			result.NextContinuationToken = hex.EncodeToString([]byte(last))
+			break
+		}
+		if delimiter != "" {
+			if i := strings.Index(k[len(prefix):], delimiter); i >= 0 {
+				cp := k[:len(prefix)+i+len(delimiter)]
+				if n := len(result.CommonPrefixes); n == 0 || result.CommonPrefixes[n-1].Prefix != cp {
+					result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{cp})
+					result.KeyCount++
+				}
+				// 公共前缀下的所有键合并为一项，续传令牌指向该前缀的末尾
+				last = cp + "\U0010FFFF"
+				continue
+			}
+		}
+		obj := objects[k]
+		result.Contents = append(result.Contents, content{
+			Key:          k,
+			LastModified: obj.LastModified.Format(time.RFC3339),
+			ETag:         `"` + obj.ETag + `"`,
+			Size:         len(obj.Data),
+			StorageClass: "STANDARD",
+		})
+		result.KeyCount++
+		last = k
+	}
+	writeXML(w, http.StatusOK, result)
+}
+
+// subresources 返回请求中的子资源参数（例如 tagging、uploads），这些操作本服务不支持
+//
+// subresources returns the sub-resource parameters of a request (such as tagging or uploads), which this server does not support
+func subresources(q url.Values) []string {
+	var subs []string
+	for k := range q {
+		switch k {
+		case "x-id", "list-type", "prefix", "delimiter", "max-keys", "start-after", "continuation-token", "encoding-type", "fetch-owner":
+		default:
+			subs = append(subs, k)
+		}
+	}
+	return subs
+}
+
+// writeObjectHeaders 写入对象的响应头
+//
+// writeObjectHeaders writes an object's response headers
+func writeObjectHeaders(w http.ResponseWriter, obj *StubObject) {
+	h := w.Header()
+	h.Set("ETag", `"`+obj.ETag+`"`)
+	h.Set("Content-Type", obj.ContentType)
+	h.Set("Last-Modified", obj.LastModified.Format(http.TimeFormat))
+	if obj.CacheControl != "" {
+		h.Set("Cache-Control", obj.CacheControl)
+	}
+	for k, v := range obj.Metadata {
+		h.Set("X-Amz-Meta-"+k, v)
+	}
+}
+
+// userMetadata 提取 x-amz-meta-* 请求头
+//
+// userMetadata extracts the x-amz-meta-* request headers
+func userMetadata(h http.Header) map[string]string {
+	var meta map[string]string
+	for k, v := range h {
+		if name, ok := strings.CutPrefix(strings.ToLower(k), "x-amz-meta-"); ok && len(v) > 0 {
+			if meta == nil {
+				meta = make(map[string]string)
+			}
+			meta[name] = v[0]
+		}
+	}
+	return meta
+}
+
+// s3Error 写入 S3 格式的错误响应，HEAD 请求不带响应体
+//
+// s3Error writes an S3-style error response, without a body for HEAD requests
+func s3Error(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if r.Method == http.MethodHead {
+		w.WriteHeader(status)
+		return
+	}
+	writeXML(w, status, struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string   `xml:"Code"`
+		Message string   `xml:"Message"`
+	}{Code: code, Message: message})
+}
+
+// writeXML 写入 XML 响应
+//
+// writeXML writes an XML response
+func writeXML(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = io.WriteString(w, xml.Header)
+	_ = xml.NewEncoder(w).Encode(v)
+}
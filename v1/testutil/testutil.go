@@ -0,0 +1,139 @@
+// Package testutil 提供测试本仓库功能时常用的断言和测试夹具：JSON 比较、临时文件、冻结时间、假的 JWKS 服务和 S3 服务
+//
+// Package testutil provides assertions and fixtures for testing features of this repository: JSON comparison, temporary files, frozen time, and fake JWKS and S3 servers.
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// RequireJSONEq 断言两个 JSON 在语义上相等（忽略空白和对象键的顺序），不相等时以缩进格式输出双方并终止测试
+// 参数:
+//   - t: 测试对象
+//   - want: 期望值，string、[]byte 和 json.RawMessage 按 JSON 文本解析，其他值先序列化为 JSON
+//   - got: 实际值，规则同 want
+//
+// RequireJSONEq asserts that two JSON documents are semantically equal (ignoring whitespace and object key order); otherwise it prints both indented and stops the test.
+// Parameters:
+//   - t: The test
+//   - want: Expected value; string, []byte and json.RawMessage are parsed as JSON text, other values are marshalled to JSON first
+//   - got: Actual value, same rules as want
+func RequireJSONEq(t testing.TB, want, got any) {
+	t.Helper()
+	w, err := normalizeJSON(want)
+	if err != nil {
+		t.Fatalf("RequireJSONEq: invalid want: %v", err)
+	}
+	g, err := normalizeJSON(got)
+	if err != nil {
+		t.Fatalf("RequireJSONEq: invalid got: %v", err)
+	}
+	if !reflect.DeepEqual(w, g) {
+		t.Fatalf("JSON not equal\nwant:\n%s\ngot:\n%s", indentJSON(w), indentJSON(g))
+	}
+}
+
+// TempDirWithFiles 创建测试结束后自动删除的临时目录，并写入给定的文件
+// 参数:
+//   - t: 测试对象
+//   - files: 文件相对路径（使用 /）到内容的映射，会自动创建中间目录
+//
+// 返回:
+//   - string: 临时目录的路径
+//
+// TempDirWithFiles creates a temporary directory removed when the test ends and writes the given files into it.
+// Parameters:
+//   - t: The test
+//   - files: Map of relative file paths (using /) to contents; intermediate directories are created
+//
+// Returns:
+//   - string: Path of the temporary directory
+func TempDirWithFiles(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		rel := filepath.FromSlash(name)
+		if !filepath.IsLocal(rel) {
+			t.Fatalf("TempDirWithFiles: %q is not a local path", name)
+		}
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("TempDirWithFiles: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("TempDirWithFiles: %v", err)
+		}
+	}
+	return dir
+}
+
+// normalizeJSON 把参数解析为通用的 JSON 值，数字保留为 json.Number 以避免精度差异
+//
+// normalizeJSON parses the argument into a generic JSON value, keeping numbers as json.Number to avoid precision differences
+func normalizeJSON(v any) (any, error) {
+	var data []byte
+	switch x := v.(type) {
+	case string:
+		data = []byte(x)
+	case []byte:
+		data = x
+	case json.RawMessage:
+		data = x
+	default:
+		var err error
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return canonicalNumbers(out), nil
+}
+
+// canonicalNumbers 把 json.Number 规范化，使 1.0 和 1、1e2 和 100 相等
+//
+// canonicalNumbers normalises json.Number values so that 1.0 equals 1 and 1e2 equals 100
+func canonicalNumbers(v any) any {
+	switch x := v.(type) {
+	case json.Number:
+		// 整数保持原样，避免超过 2^53 的值丢失精度
+		if !strings.ContainsAny(x.String(), ".eE") {
+			return x
+		}
+		if f, err := x.Float64(); err == nil {
+			b, _ := json.Marshal(f)
+			return json.Number(b)
+		}
+		return x
+	case map[string]any:
+		for k, e := range x {
+			x[k] = canonicalNumbers(e)
+		}
+	case []any:
+		for i, e := range x {
+			x[i] = canonicalNumbers(e)
+		}
+	}
+	return v
+}
+
+// indentJSON 以缩进格式输出 JSON 值，用于失败信息
+//
+// indentJSON formats a JSON value with indentation for failure messages
+func indentJSON(v any) string {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
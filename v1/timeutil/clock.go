@@ -0,0 +1,75 @@
+package timeutil
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock 时钟，返回当前时间
+//
+// Clock returns the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc 把函数适配为 Clock
+//
+// ClockFunc adapts a function to a Clock.
+type ClockFunc func() time.Time
+
+// Now 实现 Clock 接口
+//
+// Now implements the Clock interface.
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock 使用 time.Now 的系统时钟
+//
+// SystemClock is the system clock backed by time.Now.
+var SystemClock Clock = ClockFunc(time.Now)
+
+// clockHolder 包装 Clock，以便原子替换
+//
+// clockHolder wraps a Clock so it can be swapped atomically
+type clockHolder struct {
+	c Clock
+}
+
+// currentClock 包内使用的时钟，为 nil 时使用 SystemClock
+//
+// currentClock is the clock used by the package; SystemClock when nil
+var currentClock atomic.Pointer[clockHolder]
+
+// Now 返回包级时钟的当前时间，ExpiresIn、IsExpired、GetCurrentMonthTime 等函数都通过它取当前时间
+// 超时、间隔等依赖单调时钟的功能（RunEvery、TimingWheel、Throttle）不受影响
+//
+// Now returns the current time of the package clock; ExpiresIn, IsExpired, GetCurrentMonthTime and similar functions read the time through it.
+// Features relying on the monotonic clock for timeouts and intervals (RunEvery, TimingWheel, Throttle) are not affected.
+func Now() time.Time {
+	if h := currentClock.Load(); h != nil {
+		return h.c.Now()
+	}
+	return time.Now()
+}
+
+// SetClock 替换包级时钟，主要用于测试中冻结或拨动时间
+// 参数:
+//   - c: 新的时钟，为 nil 时恢复为 SystemClock
+//
+// 返回:
+//   - func(): 恢复为替换前时钟的函数
+//
+// SetClock replaces the package clock, mainly to freeze or move time in tests.
+// Parameters:
+//   - c: The new clock; SystemClock is restored when nil
+//
+// Returns:
+//   - func(): Restores the clock in place before the call
+func SetClock(c Clock) func() {
+	if c == nil {
+		c = SystemClock
+	}
+	prev := currentClock.Swap(&clockHolder{c})
+	return func() { currentClock.Store(prev) }
+}
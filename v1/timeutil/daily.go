@@ -0,0 +1,161 @@
+package timeutil
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"time"
+)
+
+// ErrInvalidLocalTime 表示钟面时间格式无效
+//
+// ErrInvalidLocalTime indicates that a wall-clock time is malformed
+var ErrInvalidLocalTime = errors.New("invalid local time")
+
+// DSTGapPolicy 钟面时间因夏令时开始而不存在时（例如 02:30 被跳过）的处理策略
+//
+// DSTGapPolicy decides what happens when the wall-clock time does not exist because daylight saving starts (e.g. 02:30 is skipped).
+type DSTGapPolicy int
+
+const (
+	// DSTGapShift 按跳过的时长顺延，例如 02:30 变为 03:30（默认），与大多数日历应用一致
+	//
+	// DSTGapShift moves the time forward by the length of the gap, e.g. 02:30 becomes 03:30 (the default), as most calendar apps do
+	DSTGapShift DSTGapPolicy = iota
+	// DSTGapSkip 当天不触发
+	//
+	// DSTGapSkip skips the day
+	DSTGapSkip
+)
+
+// DSTOverlapPolicy 钟面时间因夏令时结束而出现两次时（例如 01:30 重复）的处理策略
+//
+// DSTOverlapPolicy decides what happens when the wall-clock time occurs twice because daylight saving ends (e.g. 01:30 repeats).
+type DSTOverlapPolicy int
+
+const (
+	// DSTOverlapFirst 只取第一次（夏令时中的那次，默认）
+	//
+	// DSTOverlapFirst takes only the first occurrence (the one still in daylight saving, the default)
+	DSTOverlapFirst DSTOverlapPolicy = iota
+	// DSTOverlapLast 只取第二次
+	//
+	// DSTOverlapLast takes only the second occurrence
+	DSTOverlapLast
+	// DSTOverlapBoth 两次都取
+	//
+	// DSTOverlapBoth takes both occurrences
+	DSTOverlapBoth
+)
+
+// DailyScheduleOptions ExpandDailySchedule 的选项
+// Gap: 钟面时间不存在时的策略，默认 DSTGapShift
+// Overlap: 钟面时间重复时的策略，默认 DSTOverlapFirst
+//
+// DailyScheduleOptions contains options for ExpandDailySchedule.
+// Gap: Policy when the wall-clock time does not exist; DSTGapShift by default
+// Overlap: Policy when the wall-clock time repeats; DSTOverlapFirst by default
+type DailyScheduleOptions struct {
+	Gap     DSTGapPolicy
+	Overlap DSTOverlapPolicy
+}
+
+// ExpandDailySchedule 展开每天固定钟面时间的提醒，返回 [from, to) 内的具体时刻
+// 与直接使用 time.Date 不同，夏令时切换当天的行为由选项明确指定，而不是依赖 time.Date 未定义的规范化结果
+// 参数:
+//   - localTime: 钟面时间，格式为 "15:04" 或 "15:04:05"，例如 "09:30"
+//   - loc: 时区，为 nil 时使用 from 的时区
+//   - from: 开始时间（包含）
+//   - to: 结束时间（不包含）
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - iter.Seq[time.Time]: 按时间顺序的触发时刻，位于 loc 时区
+//   - error: 钟面时间格式无效时返回 ErrInvalidLocalTime
+//
+// ExpandDailySchedule expands a reminder at a fixed wall-clock time every day into the concrete instants within [from, to).
+// Unlike using time.Date directly, behaviour on DST transition days is stated explicitly by the options instead of relying on time.Date's unspecified normalisation.
+// Parameters:
+//   - localTime: Wall-clock time in "15:04" or "15:04:05" form, e.g. "09:30"
+//   - loc: Time zone; the time zone of from is used when nil
+//   - from: Start time (inclusive)
+//   - to: End time (exclusive)
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - iter.Seq[time.Time]: The firing instants in order, in loc
+//   - error: Returns ErrInvalidLocalTime if the wall-clock time is malformed
+func ExpandDailySchedule(localTime string, loc *time.Location, from, to time.Time, opts *DailyScheduleOptions) (iter.Seq[time.Time], error) {
+	clock, err := time.Parse("15:04:05", localTime)
+	if err != nil {
+		if clock, err = time.Parse("15:04", localTime); err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidLocalTime, localTime)
+		}
+	}
+	var o DailyScheduleOptions
+	if opts != nil {
+		o = *opts
+	}
+	loc = rangeLocation(from, loc)
+	h, m, s := clock.Clock()
+
+	return func(yield func(time.Time) bool) {
+		// 从前一天开始，因为顺延后的时刻可能跨过零点
+		f := from.In(loc)
+		for day := time.Date(f.Year(), f.Month(), f.Day()-1, 12, 0, 0, 0, loc); day.Before(to.Add(24 * time.Hour)); day = day.AddDate(0, 0, 1) {
+			for _, t := range resolveWallClock(day.Year(), day.Month(), day.Day(), h, m, s, loc, o) {
+				if t.Before(from) {
+					continue
+				}
+				if !t.Before(to) {
+					return
+				}
+				if !yield(t) {
+					return
+				}
+			}
+		}
+	}, nil
+}
+
+// resolveWallClock 返回某天某个钟面时间对应的时刻：通常一个，夏令时开始时为零个（按 Gap 策略处理），结束时为两个（按 Overlap 策略处理）
+//
+// resolveWallClock returns the instants of a wall-clock time on a day: usually one, none when daylight saving starts (handled by the Gap policy) and two when it ends (handled by the Overlap policy)
+func resolveWallClock(year int, month time.Month, day, hour, minute, sec int, loc *time.Location, o DailyScheduleOptions) []time.Time {
+	naive := time.Date(year, month, day, hour, minute, sec, 0, time.UTC)
+	// 切换前后的偏移：钟面时间前后 12 小时各取一次，足以覆盖任何一次切换
+	_, before := naive.Add(-12 * time.Hour).In(loc).Zone()
+	_, after := naive.Add(12 * time.Hour).In(loc).Zone()
+
+	var found []time.Time
+	for _, off := range []int{before, after} {
+		t := naive.Add(-time.Duration(off) * time.Second).In(loc)
+		if len(found) > 0 && found[0].Equal(t) {
+			continue
+		}
+		if y, mo, d := t.Date(); y == year && mo == month && d == day && t.Hour() == hour && t.Minute() == minute && t.Second() == sec {
+			found = append(found, t)
+		}
+	}
+
+	switch {
+	case len(found) == 0:
+		if o.Gap == DSTGapSkip {
+			return nil
+		}
+		// 按切换前的偏移解释钟面时间，结果正好顺延跳过的时长
+		return []time.Time{naive.Add(-time.Duration(before) * time.Second).In(loc)}
+	case len(found) == 2:
+		if found[1].Before(found[0]) {
+			found[0], found[1] = found[1], found[0]
+		}
+		switch o.Overlap {
+		case DSTOverlapLast:
+			return found[1:]
+		case DSTOverlapBoth:
+			return found
+		}
+		return found[:1]
+	}
+	return found
+}
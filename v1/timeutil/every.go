@@ -0,0 +1,122 @@
+package timeutil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/errorutil"
+)
+
+// ScheduleMode 周期任务的调度模式
+//
+// ScheduleMode is the scheduling mode of a periodic task
+type ScheduleMode int
+
+const (
+	// FixedRate 按固定频率执行：第 n 次在起始时间 + n*interval 触发，执行耗时不会累积成漂移；执行超过一个周期时跳过错过的触发
+	//
+	// FixedRate runs at a fixed rate: run n fires at start + n*interval, so execution time never accumulates into drift; missed ticks are skipped when a run overruns
+	FixedRate ScheduleMode = iota
+	// FixedDelay 按固定间隔执行：上一次执行结束后等待 interval 再执行下一次
+	//
+	// FixedDelay runs with a fixed delay: it waits interval after each run finishes before the next
+	FixedDelay
+)
+
+// RunEveryOptions 周期任务选项
+// Mode: 调度模式，默认 FixedRate
+// Jitter: 每次触发时间的随机抖动比例（相对 interval），用于错开集群中的执行时间，例如 0.1
+// Immediate: 为 true 时启动后立即执行一次，否则等待第一个周期
+// AllowOverlap: 仅 FixedRate 有效，为 true 时上一次未结束也会在新的 goroutine 中按时执行，默认跳过以避免重叠
+// OnError: 任务返回错误或 panic（*errorutil.PanicError）时的回调，可以为 nil
+//
+// RunEveryOptions contains options for periodic tasks.
+// Mode: Scheduling mode, defaults to FixedRate
+// Jitter: Random jitter fraction (relative to interval) applied to each firing to spread runs across a fleet, e.g. 0.1
+// Immediate: When true, run once right after starting instead of waiting for the first period
+// AllowOverlap: FixedRate only; when true a run starts on time in a new goroutine even if the previous one is still going, otherwise ticks are skipped to prevent overlap
+// OnError: Callback invoked when the task returns an error or panics (*errorutil.PanicError), may be nil
+type RunEveryOptions struct {
+	Mode         ScheduleMode
+	Jitter       float64
+	Immediate    bool
+	AllowOverlap bool
+	OnError      func(error)
+}
+
+// RunEvery 周期性地执行 fn，直到 ctx 结束；fn 中的 panic 会被捕获，不会导致进程崩溃
+// 用于替代手写的 ticker 循环，返回前会等待正在执行的任务结束
+// 参数:
+//   - ctx: 上下文，结束后停止调度，并传给 fn
+//   - interval: 周期，必须大于 0，否则 panic
+//   - fn: 任务
+//   - opts: 选项，可以为 nil
+//
+// 返回:
+//   - error: ctx.Err()
+//
+// RunEvery runs fn periodically until ctx is done; panics in fn are recovered and do not crash the process.
+// It replaces hand-written ticker loops and waits for in-flight runs before returning.
+// Parameters:
+//   - ctx: Context; scheduling stops when it is done, and it is passed to fn
+//   - interval: The period; panics unless greater than 0
+//   - fn: The task
+//   - opts: Options, may be nil
+//
+// Returns:
+//   - error: ctx.Err()
+func RunEvery(ctx context.Context, interval time.Duration, fn func(ctx context.Context) error, opts *RunEveryOptions) error {
+	var o RunEveryOptions
+	if opts != nil {
+		o = *opts
+	}
+	if interval <= 0 {
+		panic("timeutil: RunEvery interval must be positive")
+	}
+
+	run := func() {
+		err := errorutil.Recover(func() error { return fn(ctx) })
+		if err != nil && o.OnError != nil {
+			o.OnError(err)
+		}
+	}
+
+	if o.Mode == FixedDelay {
+		if !o.Immediate {
+			if err := SleepContext(ctx, Jitter(interval, o.Jitter)); err != nil {
+				return err
+			}
+		}
+		for {
+			run()
+			if err := SleepContext(ctx, Jitter(interval, o.Jitter)); err != nil {
+				return err
+			}
+		}
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	next := time.Now()
+	if !o.Immediate {
+		next = next.Add(interval)
+	}
+	for {
+		// 抖动只影响本次的触发时间，不改变后续的基准时间，因此不会累积
+		offset := Jitter(interval, o.Jitter) - interval
+		if err := SleepContext(ctx, time.Until(next.Add(offset))); err != nil {
+			return err
+		}
+		if o.AllowOverlap {
+			wg.Go(run)
+		} else {
+			run()
+		}
+		next = next.Add(interval)
+		if now := time.Now(); !next.After(now) {
+			// 执行超过了一个或多个周期，跳到下一个未来的触发时间
+			next = next.Add((now.Sub(next)/interval + 1) * interval)
+		}
+	}
+}
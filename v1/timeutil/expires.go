@@ -0,0 +1,144 @@
+package timeutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ExpiresAt 过期时间，零值表示永不过期
+// JSON 序列化为 Unix 秒（与 JWT 的 exp 一致），反序列化同时接受 Unix 秒数字和 RFC3339 字符串；
+// 文本序列化（例如写入配置或查询参数）使用 RFC3339
+//
+// ExpiresAt is an expiry time; the zero value means it never expires.
+// It marshals to JSON as Unix seconds (matching the JWT exp claim) and unmarshals from either Unix seconds or an RFC3339 string;
+// text marshalling (e.g. in configuration or query strings) uses RFC3339.
+type ExpiresAt struct {
+	time.Time
+}
+
+// ExpiresIn 返回从现在起 ttl 后过期的时间
+//
+// ExpiresIn returns an expiry ttl from now.
+func ExpiresIn(ttl time.Duration) ExpiresAt {
+	return ExpiresAt{Time: Now().Add(ttl)}
+}
+
+// ExpiresAtUnix 根据 Unix 秒创建过期时间，0 表示永不过期
+//
+// ExpiresAtUnix creates an expiry from Unix seconds; 0 means it never expires.
+func ExpiresAtUnix(sec int64) ExpiresAt {
+	if sec == 0 {
+		return ExpiresAt{}
+	}
+	return ExpiresAt{Time: time.Unix(sec, 0)}
+}
+
+// IsExpired 判断是否已过期
+// 参数:
+//   - skew: 容忍的时钟偏差；正数表示在过期后仍宽限 skew（适合校验对方签发的令牌），
+//     负数表示提前 |skew| 视为过期（适合在缓存或预签名 URL 真正失效前刷新）
+//
+// 返回:
+//   - bool: 是否已过期，零值永远返回 false
+//
+// IsExpired reports whether the expiry has passed.
+// Parameters:
+//   - skew: Tolerated clock skew; positive grants skew of leeway after expiry (suitable for validating tokens issued elsewhere),
+//     negative treats it as expired |skew| early (suitable for refreshing caches or presigned URLs before they actually expire)
+//
+// Returns:
+//   - bool: Whether it has expired; always false for the zero value
+func (e ExpiresAt) IsExpired(skew time.Duration) bool {
+	if e.IsZero() {
+		return false
+	}
+	return !Now().Add(-skew).Before(e.Time)
+}
+
+// TimeLeft 返回距离过期的剩余时间，已过期时返回 0，零值返回最大的 time.Duration
+//
+// TimeLeft returns the time remaining until expiry: 0 once expired, and the maximum time.Duration for the zero value.
+func (e ExpiresAt) TimeLeft() time.Duration {
+	if e.IsZero() {
+		return time.Duration(math.MaxInt64)
+	}
+	return max(e.Time.Sub(Now()), 0)
+}
+
+// MarshalJSON 序列化为 Unix 秒，零值序列化为 null
+//
+// MarshalJSON marshals to Unix seconds; the zero value marshals to null.
+func (e ExpiresAt) MarshalJSON() ([]byte, error) {
+	if e.IsZero() {
+		return []byte("null"), nil
+	}
+	return strconv.AppendInt(nil, e.Unix(), 10), nil
+}
+
+// UnmarshalJSON 从 Unix 秒（可带小数）或 RFC3339 字符串反序列化，null、0 和空字符串表示永不过期
+//
+// UnmarshalJSON unmarshals from Unix seconds (optionally fractional) or an RFC3339 string; null, 0 and an empty string mean it never expires.
+func (e *ExpiresAt) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*e = ExpiresAt{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return e.UnmarshalText([]byte(s))
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("timeutil: invalid expiry %s", data)
+	}
+	if sec, err := n.Int64(); err == nil {
+		*e = ExpiresAtUnix(sec)
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return fmt.Errorf("timeutil: invalid expiry %s", data)
+	}
+	if f == 0 {
+		*e = ExpiresAt{}
+		return nil
+	}
+	sec, frac := math.Modf(f)
+	*e = ExpiresAt{Time: time.Unix(int64(sec), int64(frac*1e9))}
+	return nil
+}
+
+// MarshalText 序列化为 RFC3339，零值序列化为空字符串
+//
+// MarshalText marshals to RFC3339; the zero value marshals to an empty string.
+func (e ExpiresAt) MarshalText() ([]byte, error) {
+	if e.IsZero() {
+		return []byte{}, nil
+	}
+	return []byte(e.Format(time.RFC3339)), nil
+}
+
+// UnmarshalText 从 RFC3339 字符串反序列化，空字符串表示永不过期
+//
+// UnmarshalText unmarshals from an RFC3339 string; an empty string means it never expires.
+func (e *ExpiresAt) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*e = ExpiresAt{}
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, string(data))
+	if err != nil {
+		return err
+	}
+	*e = ExpiresAt{Time: t}
+	return nil
+}
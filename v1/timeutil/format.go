@@ -0,0 +1,75 @@
+package timeutil
+
+import (
+	"sync"
+	"time"
+)
+
+// locationCache 时区缓存，避免每次都读取时区数据库
+//
+// locationCache caches time zones to avoid reading the time zone database every time
+var locationCache sync.Map
+
+// LoadLocation 加载时区并缓存结果，空字符串表示本地时区
+// 参数:
+//   - name: 时区名称，例如 LocationShanghai
+//
+// 返回:
+//   - *time.Location: 时区
+//   - error: 如果时区名称无效，返回错误
+//
+// LoadLocation loads a time zone and caches the result; an empty string means the local time zone.
+// Parameters:
+//   - name: Time zone name, e.g. LocationShanghai
+//
+// Returns:
+//   - *time.Location: The time zone
+//   - error: Returns an error if the time zone name is invalid
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" || name == LocationLocal {
+		return time.Local, nil
+	}
+	if loc, ok := locationCache.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locationCache.Store(name, loc)
+	return loc, nil
+}
+
+// FormatInLocation 将时间转换到指定时区后按 layout 格式化
+// 参数:
+//   - t: 时间
+//   - layout: 格式，例如 time.DateTime
+//   - locationName: 时区名称，空字符串表示本地时区
+//
+// 返回:
+//   - 格式化后的字符串
+//   - error: 如果时区名称无效，返回错误
+//
+// FormatInLocation converts the time to the specified time zone and formats it with layout.
+// Parameters:
+//   - t: The time
+//   - layout: Layout, e.g. time.DateTime
+//   - locationName: Time zone name, an empty string means the local time zone
+//
+// Returns:
+//   - The formatted string
+//   - error: Returns an error if the time zone name is invalid
+func FormatInLocation(t time.Time, layout, locationName string) (string, error) {
+	loc, err := LoadLocation(locationName)
+	if err != nil {
+		return "", err
+	}
+	return t.In(loc).Format(layout), nil
+}
+
+// FormatUnix 将 Unix 时间戳（秒）在指定时区下按 layout 格式化
+//
+// FormatUnix formats a Unix timestamp (seconds) with layout in the specified time zone.
+func FormatUnix(timestamp int64, layout, locationName string) (string, error) {
+	return FormatInLocation(time.Unix(timestamp, 0), layout, locationName)
+}
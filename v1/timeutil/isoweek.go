@@ -0,0 +1,67 @@
+package timeutil
+
+import "time"
+
+// FirstDayOfISOWeek 返回 ISO 8601 周年 year 第 week 周的周一零点
+// ISO 周从周一开始，每年的第 1 周是包含 1 月 4 日（即包含该年第一个周四）的那一周，因此第 1 周可能从上一年的 12 月开始，
+// 年末的几天也可能属于下一年的第 1 周；不能用 1 月 1 日加 (week-1)*7 天推算
+// 参数:
+//   - year: ISO 周年，与 time.Time.ISOWeek 返回的年份一致
+//   - week: 周数，超出 1..WeeksInYear(year) 时顺延到相邻的年份
+//   - loc: 时区，为 nil 时使用 time.Local
+//
+// 返回:
+//   - time.Time: 该周周一零点
+//
+// FirstDayOfISOWeek returns midnight on the Monday of ISO 8601 week week of week-based year year.
+// ISO weeks start on Monday and week 1 is the week containing January 4th (i.e. the year's first Thursday), so week 1 may start in December of the previous year
+// and the last days of a year may belong to week 1 of the next; it cannot be derived as January 1st plus (week-1)*7 days.
+// Parameters:
+//   - year: The ISO week-based year, as returned by time.Time.ISOWeek
+//   - week: The week number; values outside 1..WeeksInYear(year) roll over into the adjacent years
+//   - loc: Time zone; time.Local when nil
+//
+// Returns:
+//   - time.Time: Midnight on the Monday of the week
+func FirstDayOfISOWeek(year, week int, loc *time.Location) time.Time {
+	if loc == nil {
+		loc = time.Local
+	}
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, loc)
+	// 周一为 0，周日为 6
+	offset := (int(jan4.Weekday()) + 6) % 7
+	return time.Date(year, time.January, 4-offset+(week-1)*7, 0, 0, 0, 0, loc)
+}
+
+// ISOWeekRange 返回 ISO 周年 year 第 week 周的时间段 [周一零点, 下周一零点)
+// 边界按 loc 时区计算，夏令时切换所在的周可能不是整 168 小时
+// 参数:
+//   - year: ISO 周年
+//   - week: 周数
+//   - loc: 时区，为 nil 时使用 time.Local
+//
+// 返回:
+//   - Period: 该周的时间段
+//
+// ISOWeekRange returns the period [Monday midnight, next Monday midnight) of ISO week week of week-based year year.
+// Boundaries are computed in loc, so a week with a DST transition may not last exactly 168 hours.
+// Parameters:
+//   - year: The ISO week-based year
+//   - week: The week number
+//   - loc: Time zone; time.Local when nil
+//
+// Returns:
+//   - Period: The period of the week
+func ISOWeekRange(year, week int, loc *time.Location) Period {
+	start := FirstDayOfISOWeek(year, week, loc)
+	return Period{Start: start, End: time.Date(start.Year(), start.Month(), start.Day()+7, 0, 0, 0, 0, start.Location())}
+}
+
+// WeeksInYear 返回 ISO 周年 year 的周数（52 或 53）
+//
+// WeeksInYear returns the number of weeks (52 or 53) in ISO week-based year year.
+func WeeksInYear(year int) int {
+	// 12 月 28 日总是属于当年的最后一周
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return week
+}
@@ -0,0 +1,335 @@
+package timeutil
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultNTPTimeout 单个 NTP 查询的默认超时
+	//
+	// DefaultNTPTimeout is the default timeout of a single NTP query
+	DefaultNTPTimeout = 5 * time.Second
+	// DefaultDriftThreshold 默认的时钟偏差告警阈值
+	//
+	// DefaultDriftThreshold is the default clock drift warning threshold
+	DefaultDriftThreshold = time.Second
+	// DefaultDriftCheckInterval 默认的时钟偏差检查间隔
+	//
+	// DefaultDriftCheckInterval is the default clock drift check interval
+	DefaultDriftCheckInterval = 10 * time.Minute
+
+	// ntpEpochOffset 1900-01-01 到 1970-01-01 的秒数
+	//
+	// ntpEpochOffset is the number of seconds from 1900-01-01 to 1970-01-01
+	ntpEpochOffset = 2208988800
+)
+
+// DefaultNTPServers 默认的 NTP 服务器
+//
+// DefaultNTPServers are the default NTP servers
+var DefaultNTPServers = []string{"ntp.aliyun.com", "ntp.tencent.com", "time.cloudflare.com", "pool.ntp.org"}
+
+var (
+	// ErrNTPQuery 表示 NTP 查询失败
+	//
+	// ErrNTPQuery indicates that an NTP query failed
+	ErrNTPQuery = errors.New("ntp query failed")
+)
+
+// NTPResult 单个 NTP 服务器的查询结果
+// Server: 服务器地址
+// Offset: 本地时钟相对服务器的偏差，正数表示本地时钟慢
+// RTT: 往返时延
+// Stratum: 服务器层级
+//
+// NTPResult is the query result of a single NTP server.
+// Server: Server address
+// Offset: Offset of the local clock relative to the server; positive means the local clock is behind
+// RTT: Round-trip time
+// Stratum: Server stratum
+type NTPResult struct {
+	Server  string
+	Offset  time.Duration
+	RTT     time.Duration
+	Stratum int
+}
+
+// ClockDrift 时钟偏差检测结果
+// Offset: 所有成功结果的偏差中位数，正数表示本地时钟慢
+// Results: 成功的查询结果
+// Errors: 失败的查询
+//
+// ClockDrift is the result of clock drift detection.
+// Offset: Median offset of all successful results; positive means the local clock is behind
+// Results: Successful query results
+// Errors: Failed queries
+type ClockDrift struct {
+	Offset  time.Duration
+	Results []NTPResult
+	Errors  []error
+}
+
+// QueryNTP 使用 SNTP 协议查询单个服务器
+// 参数:
+//   - ctx: 上下文，未设置截止时间时使用 DefaultNTPTimeout
+//   - server: 服务器地址，不带端口时使用 123
+//
+// 返回:
+//   - *NTPResult: 查询结果
+//   - error: 如果查询失败或应答无效，返回错误
+//
+// QueryNTP queries a single server using SNTP.
+// Parameters:
+//   - ctx: Context; DefaultNTPTimeout is used when it has no deadline
+//   - server: Server address; port 123 is used when absent
+//
+// Returns:
+//   - *NTPResult: The query result
+//   - error: Returns an error if the query fails or the reply is invalid
+func QueryNTP(ctx context.Context, server string) (*NTPResult, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultNTPTimeout)
+		defer cancel()
+	}
+	addr := server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		addr = net.JoinHostPort(server, "123")
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNTPQuery, server, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// LI=0，VN=4，Mode=3（客户端）
+	req := make([]byte, 48)
+	req[0] = 0x23
+	t1 := time.Now()
+	binary.BigEndian.PutUint64(req[40:], toNTPTime(t1))
+	if _, err := conn.Write(req); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNTPQuery, server, err)
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	t4 := time.Now()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNTPQuery, server, err)
+	}
+	if n < 48 {
+		return nil, fmt.Errorf("%w: %s: short reply", ErrNTPQuery, server)
+	}
+	mode := resp[0] & 0x07
+	stratum := int(resp[1])
+	if mode != 4 {
+		return nil, fmt.Errorf("%w: %s: unexpected mode %d", ErrNTPQuery, server, mode)
+	}
+	if stratum == 0 || stratum > 15 {
+		return nil, fmt.Errorf("%w: %s: unsynchronized server (stratum %d)", ErrNTPQuery, server, stratum)
+	}
+	if binary.BigEndian.Uint64(resp[24:]) != binary.BigEndian.Uint64(req[40:]) {
+		return nil, fmt.Errorf("%w: %s: originate timestamp mismatch", ErrNTPQuery, server)
+	}
+
+	t2 := fromNTPTime(binary.BigEndian.Uint64(resp[32:]))
+	t3 := fromNTPTime(binary.BigEndian.Uint64(resp[40:]))
+	return &NTPResult{
+		Server:  server,
+		Offset:  (t2.Sub(t1) + t3.Sub(t4)) / 2,
+		RTT:     t4.Sub(t1) - t3.Sub(t2),
+		Stratum: stratum,
+	}, nil
+}
+
+// CheckClockDrift 并发查询多个 NTP 服务器并返回本地时钟偏差（取中位数，避免单个服务器异常）
+// 参数:
+//   - ctx: 上下文
+//   - servers: NTP 服务器，为空时使用 DefaultNTPServers
+//
+// 返回:
+//   - *ClockDrift: 检测结果
+//   - error: 所有服务器都查询失败时返回错误
+//
+// CheckClockDrift queries multiple NTP servers concurrently and reports the local clock offset (the median, to tolerate a faulty server).
+// Parameters:
+//   - ctx: Context
+//   - servers: NTP servers; DefaultNTPServers is used when empty
+//
+// Returns:
+//   - *ClockDrift: The detection result
+//   - error: Returns an error if all servers fail
+func CheckClockDrift(ctx context.Context, servers []string) (*ClockDrift, error) {
+	if len(servers) == 0 {
+		servers = DefaultNTPServers
+	}
+
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		drift ClockDrift
+	)
+	for _, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := QueryNTP(ctx, server)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				drift.Errors = append(drift.Errors, err)
+				return
+			}
+			drift.Results = append(drift.Results, *r)
+		}()
+	}
+	wg.Wait()
+
+	if len(drift.Results) == 0 {
+		return &drift, errors.Join(drift.Errors...)
+	}
+	offsets := make([]time.Duration, len(drift.Results))
+	for i, r := range drift.Results {
+		offsets[i] = r.Offset
+	}
+	slices.Sort(offsets)
+	mid := len(offsets) / 2
+	if len(offsets)%2 == 0 {
+		drift.Offset = (offsets[mid-1] + offsets[mid]) / 2
+	} else {
+		drift.Offset = offsets[mid]
+	}
+	return &drift, nil
+}
+
+// DriftGuardOptions 时钟偏差守护选项
+// Servers: NTP 服务器，默认 DefaultNTPServers
+// Threshold: 告警阈值，默认 DefaultDriftThreshold
+// Interval: 检查间隔，默认 DefaultDriftCheckInterval
+// Logger: 日志记录器，默认 slog.Default()
+// OnDrift: 偏差超过阈值时的回调，可以为 nil
+//
+// DriftGuardOptions contains options for the clock drift guard.
+// Servers: NTP servers, defaults to DefaultNTPServers
+// Threshold: Warning threshold, defaults to DefaultDriftThreshold
+// Interval: Check interval, defaults to DefaultDriftCheckInterval
+// Logger: Logger, defaults to slog.Default()
+// OnDrift: Callback invoked when the drift exceeds the threshold, may be nil
+type DriftGuardOptions struct {
+	Servers   []string
+	Threshold time.Duration
+	Interval  time.Duration
+	Logger    *slog.Logger
+	OnDrift   func(offset time.Duration)
+}
+
+// DriftGuard 定期检查时钟偏差，超过阈值时记录警告日志
+// JWT 的 exp/nbf 校验依赖主机时钟，偏差过大会导致令牌被误判为过期或尚未生效
+//
+// DriftGuard periodically checks the clock drift and logs a warning when it exceeds the threshold.
+// JWT exp/nbf validation depends on the host clock, so large drift makes tokens look expired or not yet valid.
+type DriftGuard struct {
+	opts   DriftGuardOptions
+	offset atomic.Int64
+}
+
+// NewDriftGuard 创建时钟偏差守护
+//
+// NewDriftGuard creates a clock drift guard.
+func NewDriftGuard(opts *DriftGuardOptions) *DriftGuard {
+	g := &DriftGuard{}
+	if opts != nil {
+		g.opts = *opts
+	}
+	if g.opts.Threshold <= 0 {
+		g.opts.Threshold = DefaultDriftThreshold
+	}
+	if g.opts.Interval <= 0 {
+		g.opts.Interval = DefaultDriftCheckInterval
+	}
+	if g.opts.Logger == nil {
+		g.opts.Logger = slog.Default()
+	}
+	return g
+}
+
+// Check 立即检查一次时钟偏差
+// 返回:
+//   - time.Duration: 本地时钟偏差
+//   - error: 所有服务器都查询失败时返回错误
+//
+// Check checks the clock drift once, immediately.
+// Returns:
+//   - time.Duration: The local clock offset
+//   - error: Returns an error if all servers fail
+func (g *DriftGuard) Check(ctx context.Context) (time.Duration, error) {
+	drift, err := CheckClockDrift(ctx, g.opts.Servers)
+	if err != nil {
+		g.opts.Logger.WarnContext(ctx, "clock drift check failed", "error", err)
+		return 0, err
+	}
+	g.offset.Store(int64(drift.Offset))
+	if drift.Offset > g.opts.Threshold || drift.Offset < -g.opts.Threshold {
+		g.opts.Logger.WarnContext(ctx, "clock drift exceeds threshold",
+			"offset", drift.Offset, "threshold", g.opts.Threshold, "servers", len(drift.Results))
+		if g.opts.OnDrift != nil {
+			g.opts.OnDrift(drift.Offset)
+		}
+	}
+	return drift.Offset, nil
+}
+
+// Offset 返回最近一次检查得到的时钟偏差
+//
+// Offset returns the clock offset from the most recent check.
+func (g *DriftGuard) Offset() time.Duration {
+	return time.Duration(g.offset.Load())
+}
+
+// Run 立即检查一次，然后按间隔定期检查，直到 ctx 被取消
+//
+// Run checks once immediately and then periodically at the interval until ctx is cancelled.
+func (g *DriftGuard) Run(ctx context.Context) {
+	ticker := time.NewTicker(g.opts.Interval)
+	defer ticker.Stop()
+	for {
+		g.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// toNTPTime 将时间转换为 64 位 NTP 时间戳
+//
+// toNTPTime converts a time to a 64-bit NTP timestamp
+func toNTPTime(t time.Time) uint64 {
+	sec := uint64(t.Unix()) + ntpEpochOffset
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return sec<<32 | frac
+}
+
+// fromNTPTime 将 64 位 NTP 时间戳转换为时间
+//
+// fromNTPTime converts a 64-bit NTP timestamp to a time
+func fromNTPTime(v uint64) time.Time {
+	sec := int64(v>>32) - ntpEpochOffset
+	nsec := (v & 0xffffffff) * 1e9 >> 32
+	return time.Unix(sec, int64(nsec))
+}
@@ -0,0 +1,133 @@
+package timeutil
+
+import (
+	"iter"
+	"time"
+)
+
+// Period 时间段，左闭右开 [Start, End)
+// Start: 开始时间（包含）
+// End: 结束时间（不包含），即下一个时间段的开始
+//
+// Period is a half-open time period [Start, End).
+// Start: Start time (inclusive)
+// End: End time (exclusive), i.e. the start of the next period
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains 判断时间是否落在时间段内
+//
+// Contains reports whether the time falls within the period.
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// IterateDays 按自然日遍历与 [start, end) 有交集的每一天
+// 边界按 loc 时区的零点计算并通过 AddDate 推进，因此夏令时切换当天的时长可能为 23 或 25 小时
+// 参数:
+//   - start: 开始时间（包含）
+//   - end: 结束时间（不包含）
+//   - loc: 时区，为 nil 时使用 start 的时区
+//
+// 返回:
+//   - iter.Seq[Period]: 每一天的时间段
+//
+// IterateDays iterates over every calendar day that overlaps [start, end).
+// Boundaries are midnights in loc and advanced with AddDate, so a day with a DST transition may last 23 or 25 hours.
+// Parameters:
+//   - start: Start time (inclusive)
+//   - end: End time (exclusive)
+//   - loc: Time zone; the time zone of start is used when nil
+//
+// Returns:
+//   - iter.Seq[Period]: The period of each day
+func IterateDays(start, end time.Time, loc *time.Location) iter.Seq[Period] {
+	loc = rangeLocation(start, loc)
+	s := start.In(loc)
+	first := time.Date(s.Year(), s.Month(), s.Day(), 0, 0, 0, 0, loc)
+	return iteratePeriods(first, end, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+	})
+}
+
+// IterateWeeks 按自然周遍历与 [start, end) 有交集的每一周
+// 参数:
+//   - start: 开始时间（包含）
+//   - end: 结束时间（不包含）
+//   - loc: 时区，为 nil 时使用 start 的时区
+//   - weekStart: 每周的第一天，国内通常为 time.Monday
+//
+// 返回:
+//   - iter.Seq[Period]: 每一周的时间段
+//
+// IterateWeeks iterates over every calendar week that overlaps [start, end).
+// Parameters:
+//   - start: Start time (inclusive)
+//   - end: End time (exclusive)
+//   - loc: Time zone; the time zone of start is used when nil
+//   - weekStart: First day of the week, usually time.Monday in China
+//
+// Returns:
+//   - iter.Seq[Period]: The period of each week
+func IterateWeeks(start, end time.Time, loc *time.Location, weekStart time.Weekday) iter.Seq[Period] {
+	loc = rangeLocation(start, loc)
+	s := start.In(loc)
+	back := (int(s.Weekday()) - int(weekStart) + 7) % 7
+	first := time.Date(s.Year(), s.Month(), s.Day()-back, 0, 0, 0, 0, loc)
+	return iteratePeriods(first, end, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day()+7, 0, 0, 0, 0, loc)
+	})
+}
+
+// IterateMonths 按自然月遍历与 [start, end) 有交集的每一个月
+// 参数:
+//   - start: 开始时间（包含）
+//   - end: 结束时间（不包含）
+//   - loc: 时区，为 nil 时使用 start 的时区
+//
+// 返回:
+//   - iter.Seq[Period]: 每个月的时间段，Start 为当月 1 日零点
+//
+// IterateMonths iterates over every calendar month that overlaps [start, end).
+// Parameters:
+//   - start: Start time (inclusive)
+//   - end: End time (exclusive)
+//   - loc: Time zone; the time zone of start is used when nil
+//
+// Returns:
+//   - iter.Seq[Period]: The period of each month, with Start at midnight on the 1st
+func IterateMonths(start, end time.Time, loc *time.Location) iter.Seq[Period] {
+	loc = rangeLocation(start, loc)
+	s := start.In(loc)
+	first := time.Date(s.Year(), s.Month(), 1, 0, 0, 0, 0, loc)
+	return iteratePeriods(first, end, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+	})
+}
+
+// rangeLocation 返回遍历使用的时区
+//
+// rangeLocation returns the time zone used for iteration
+func rangeLocation(start time.Time, loc *time.Location) *time.Location {
+	if loc == nil {
+		return start.Location()
+	}
+	return loc
+}
+
+// iteratePeriods 从 first 开始按 next 推进，直到时间段的开始不早于 end
+//
+// iteratePeriods advances from first using next until a period starts at or after end
+func iteratePeriods(first, end time.Time, next func(time.Time) time.Time) iter.Seq[Period] {
+	return func(yield func(Period) bool) {
+		for cur := first; cur.Before(end); {
+			n := next(cur)
+			if !yield(Period{Start: cur, End: n}) {
+				return
+			}
+			cur = n
+		}
+	}
+}
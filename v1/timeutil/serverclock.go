@@ -0,0 +1,220 @@
+package timeutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultServerClockMaxAge 低延迟样本的有效期，超过后即使新样本的往返时间更长也会采用
+//
+// DefaultServerClockMaxAge is how long a low-latency sample is preferred; after that a new sample is accepted even if its round trip is longer
+const DefaultServerClockMaxAge = 10 * time.Minute
+
+// ErrServerTime 表示获取服务端时间失败
+//
+// ErrServerTime indicates that fetching the server time failed
+var ErrServerTime = errors.New("server time sync failed")
+
+// ServerTime 服务端时间接口的响应
+// ServerTimeMs: 服务端处理请求时的 Unix 毫秒时间
+// ClientTimeMs: 原样返回请求中的 client_time_ms，客户端可以据此计算往返时间，未传时为 0
+//
+// ServerTime is the response of the server time endpoint.
+// ServerTimeMs: Unix milliseconds when the server handled the request
+// ClientTimeMs: The request's client_time_ms echoed back, letting clients compute the round trip; 0 when not sent
+type ServerTime struct {
+	ServerTimeMs int64 `json:"server_time_ms"`
+	ClientTimeMs int64 `json:"client_time_ms,omitempty"`
+}
+
+// ServerTimeHandler 返回服务端时间的 HTTP 处理函数，响应为 ServerTime 的 JSON，禁止缓存
+// 客户端可以在查询参数 client_time_ms 中传入本地的 Unix 毫秒时间，响应中会原样返回
+//
+// ServerTimeHandler is an HTTP handler returning the server time as ServerTime JSON, with caching disabled.
+// Clients may pass their local Unix milliseconds in the client_time_ms query parameter, which is echoed back.
+func ServerTimeHandler(w http.ResponseWriter, r *http.Request) {
+	resp := ServerTime{ServerTimeMs: Now().UnixMilli()}
+	resp.ClientTimeMs, _ = strconv.ParseInt(r.URL.Query().Get("client_time_ms"), 10, 64)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// ServerClock 按与服务端的时间偏差校正后的时钟，用于客户端时钟不准时一致地计算倒计时、令牌过期等；并发安全
+// 偏差按 NTP 的方式估算：假设请求和响应的网络延迟相同，服务端时间对应请求发出和收到响应的中点
+// 实现了 Clock 接口，未同步时与本地时钟一致
+//
+// ServerClock is a clock corrected by the offset to the server's time, so clients with skewed clocks compute countdowns, token expiry and the like consistently; it is safe for concurrent use.
+// The offset is estimated the way NTP does: assuming equal request and response latency, the server time corresponds to the midpoint between sending the request and receiving the response.
+// It implements the Clock interface and matches the local clock until synced.
+type ServerClock struct {
+	maxAge time.Duration
+
+	mu       sync.RWMutex
+	offset   time.Duration
+	rtt      time.Duration
+	syncedAt time.Time
+}
+
+// NewServerClock 创建未同步的服务端时钟
+// 参数:
+//   - maxAge: 低延迟样本的有效期，为 0 时使用 DefaultServerClockMaxAge
+//
+// 返回:
+//   - *ServerClock: 服务端时钟
+//
+// NewServerClock creates an unsynced server clock.
+// Parameters:
+//   - maxAge: How long a low-latency sample is preferred; DefaultServerClockMaxAge when 0
+//
+// Returns:
+//   - *ServerClock: The server clock
+func NewServerClock(maxAge time.Duration) *ServerClock {
+	if maxAge <= 0 {
+		maxAge = DefaultServerClockMaxAge
+	}
+	return &ServerClock{maxAge: maxAge}
+}
+
+// Observe 记录一次测量：往返时间更短或当前样本已超过有效期时采用，网络抖动大的样本不会覆盖精确的样本
+// 参数:
+//   - sent: 本地发出请求的时间
+//   - received: 本地收到响应的时间
+//   - server: 响应中的服务端时间
+//
+// 返回:
+//   - bool: 是否采用了该样本
+//
+// Observe records a measurement; it is adopted when its round trip is shorter or the current sample has expired, so noisy samples do not overwrite precise ones.
+// Parameters:
+//   - sent: When the request was sent, in local time
+//   - received: When the response arrived, in local time
+//   - server: The server time from the response
+//
+// Returns:
+//   - bool: Whether the sample was adopted
+func (c *ServerClock) Observe(sent, received, server time.Time) bool {
+	rtt := max(received.Sub(sent), 0)
+	offset := server.Sub(sent.Add(rtt / 2))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.syncedAt.IsZero() && rtt > c.rtt && received.Sub(c.syncedAt) < c.maxAge {
+		return false
+	}
+	c.offset, c.rtt, c.syncedAt = offset, rtt, received
+	return true
+}
+
+// SetOffset 直接设置偏差，例如使用登录握手中服务端下发的时间差
+//
+// SetOffset sets the offset directly, e.g. from a time difference returned in a login handshake.
+func (c *ServerClock) SetOffset(offset time.Duration) {
+	c.mu.Lock()
+	c.offset, c.rtt, c.syncedAt = offset, 0, Now()
+	c.mu.Unlock()
+}
+
+// Offset 返回服务端时间减去本地时间的偏差，正数表示本地时钟偏慢
+//
+// Offset returns the server time minus the local time; positive means the local clock is behind.
+func (c *ServerClock) Offset() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.offset
+}
+
+// RTT 返回被采用样本的往返时间，偏差的误差不超过它的一半
+//
+// RTT returns the round trip of the adopted sample; the offset's error is at most half of it.
+func (c *ServerClock) RTT() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.rtt
+}
+
+// Synced 报告是否已经同步过
+//
+// Synced reports whether the clock has been synced.
+func (c *ServerClock) Synced() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.syncedAt.IsZero()
+}
+
+// Now 返回估算的服务端当前时间，实现 Clock 接口
+//
+// Now returns the estimated current server time, implementing the Clock interface.
+func (c *ServerClock) Now() time.Time {
+	return Now().Add(c.Offset())
+}
+
+// Until 返回距离服务端时间 t 的时长，例如服务端下发的活动结束时间
+//
+// Until returns the duration until the server time t, e.g. an event end time sent by the server.
+func (c *ServerClock) Until(t time.Time) time.Duration {
+	return t.Sub(c.Now())
+}
+
+// Since 返回服务端时间 t 之后经过的时长
+//
+// Since returns the time elapsed since the server time t.
+func (c *ServerClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Sync 请求 ServerTimeHandler 提供的接口并记录一次测量
+// 参数:
+//   - ctx: 上下文
+//   - client: HTTP 客户端，为 nil 时使用 http.DefaultClient
+//   - endpoint: 服务端时间接口的地址
+//
+// 返回:
+//   - error: 请求失败或响应无效时返回 ErrServerTime
+//
+// Sync calls an endpoint served by ServerTimeHandler and records a measurement.
+// Parameters:
+//   - ctx: Context
+//   - client: HTTP client; http.DefaultClient when nil
+//   - endpoint: Address of the server time endpoint
+//
+// Returns:
+//   - error: Returns ErrServerTime if the request fails or the response is invalid
+func (c *ServerClock) Sync(ctx context.Context, client *http.Client, endpoint string) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrServerTime, err)
+	}
+	sent := Now()
+	q := u.Query()
+	q.Set("client_time_ms", strconv.FormatInt(sent.UnixMilli(), 10))
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrServerTime, err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrServerTime, err)
+	}
+	defer resp.Body.Close()
+	received := Now()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d", ErrServerTime, resp.StatusCode)
+	}
+	var st ServerTime
+	if err := json.NewDecoder(resp.Body).Decode(&st); err != nil || st.ServerTimeMs <= 0 {
+		return fmt.Errorf("%w: invalid response", ErrServerTime)
+	}
+	c.Observe(sent, received, time.UnixMilli(st.ServerTimeMs))
+	return nil
+}
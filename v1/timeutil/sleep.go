@@ -0,0 +1,76 @@
+package timeutil
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// SleepContext 睡眠 d，ctx 结束时提前返回；与 time.Sleep 不同，不会在服务关闭时拖住 goroutine
+// 参数:
+//   - ctx: 上下文
+//   - d: 睡眠时长，<= 0 时立即返回（ctx 已结束时仍返回其错误）
+//
+// 返回:
+//   - error: 完整睡眠返回 nil，提前返回时返回 ctx.Err()
+//
+// SleepContext sleeps for d, returning early when ctx is done; unlike time.Sleep it does not hold goroutines up during shutdown.
+// Parameters:
+//   - ctx: Context
+//   - d: Sleep duration; returns immediately when <= 0 (still reporting ctx's error if it is already done)
+//
+// Returns:
+//   - error: nil after a full sleep, or ctx.Err() when returning early
+func SleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Jitter 返回在 [base*(1-jitterFrac), base*(1+jitterFrac)] 内均匀分布的随机时长，用于错开集群中周期任务的执行时间
+// 参数:
+//   - base: 基准时长
+//   - jitterFrac: 抖动比例，限制在 [0, 1] 内，例如 0.1 表示 ±10%
+//
+// 返回:
+//   - time.Duration: 随机时长，base <= 0 时返回 base
+//
+// Jitter returns a random duration uniformly distributed in [base*(1-jitterFrac), base*(1+jitterFrac)], for spreading periodic jobs across a fleet.
+// Parameters:
+//   - base: Base duration
+//   - jitterFrac: Jitter fraction, clamped to [0, 1]; e.g. 0.1 means ±10%
+//
+// Returns:
+//   - time.Duration: The random duration, or base when base <= 0
+func Jitter(base time.Duration, jitterFrac float64) time.Duration {
+	jitterFrac = min(max(jitterFrac, 0), 1)
+	if base <= 0 || jitterFrac == 0 {
+		return base
+	}
+	delta := float64(base) * jitterFrac
+	return time.Duration(float64(base) - delta + rand.Float64()*2*delta)
+}
+
+// SleepJitter 睡眠 Jitter(base, jitterFrac) 并返回实际的睡眠时长
+//
+// SleepJitter sleeps for Jitter(base, jitterFrac) and returns the duration slept.
+func SleepJitter(base time.Duration, jitterFrac float64) time.Duration {
+	d := Jitter(base, jitterFrac)
+	time.Sleep(d)
+	return d
+}
+
+// SleepJitterContext 与 SleepJitter 相同，但 ctx 结束时提前返回 ctx.Err()
+//
+// SleepJitterContext is like SleepJitter but returns ctx.Err() early when ctx is done.
+func SleepJitterContext(ctx context.Context, base time.Duration, jitterFrac float64) error {
+	return SleepContext(ctx, Jitter(base, jitterFrac))
+}
@@ -0,0 +1,46 @@
+package timeutil
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Throttle 节流器，在每个时间间隔内最多放行一次，并发安全，零值不节流
+//
+// Throttle allows at most one event per interval; it is safe for concurrent use and its zero value never throttles.
+type Throttle struct {
+	interval time.Duration
+	last     atomic.Int64
+}
+
+// NewThrottle 创建节流器
+// 参数:
+//   - interval: 两次放行之间的最小间隔，<= 0 时总是放行
+//
+// 返回:
+//   - *Throttle: 节流器，第一次调用 Allow 总是放行
+//
+// NewThrottle creates a throttle.
+// Parameters:
+//   - interval: Minimum interval between two allowed events; always allows when <= 0
+//
+// Returns:
+//   - *Throttle: The throttle; the first call to Allow is always allowed
+func NewThrottle(interval time.Duration) *Throttle {
+	return &Throttle{interval: interval}
+}
+
+// Allow 报告本次事件是否放行，放行时记录当前时间
+//
+// Allow reports whether this event is allowed, recording the current time when it is.
+func (t *Throttle) Allow() bool {
+	if t.interval <= 0 {
+		return true
+	}
+	now := time.Now().UnixNano()
+	last := t.last.Load()
+	if last != 0 && now-last < int64(t.interval) {
+		return false
+	}
+	return t.last.CompareAndSwap(last, now)
+}
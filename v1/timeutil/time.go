@@ -109,7 +109,7 @@ const (
 //   - First value: Unix timestamp of 00:00:00 on the first day of the current month
 //   - Second value: Unix timestamp of 23:59:59 on the last day of the current month
 func GetCurrentMonthTime() (int64, int64) {
-	now := time.Now()
+	now := Now()
 	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
 	endOfMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, now.Location()).Add(-time.Second)
 	return startOfMonth.Unix(), endOfMonth.Unix()
@@ -132,7 +132,7 @@ func GetCurrentMonthTime() (int64, int64) {
 //   - Returns true if the absolute difference between the current time and the given timestamp is less than or equal to timeRange, otherwise returns false
 func JudgeTimeInRange(timestamp int64, timeRange time.Duration) bool {
 	t := time.Unix(timestamp, 0)
-	now := time.Now()
+	now := Now()
 	duration := now.Sub(t).Abs() // 使用 Abs() 更简洁
 	return duration <= timeRange
 }
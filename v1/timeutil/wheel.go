@@ -0,0 +1,252 @@
+package timeutil
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultWheelTick 时间轮的默认刻度
+	//
+	// DefaultWheelTick is the default tick of a timing wheel
+	DefaultWheelTick = 10 * time.Millisecond
+	// DefaultWheelSize 时间轮每层的默认槽数
+	//
+	// DefaultWheelSize is the default number of slots per level of a timing wheel
+	DefaultWheelSize = 256
+
+	// wheelLevels 时间轮的层数，默认配置下可覆盖约 1.3 年，更远的任务在最高层循环等待
+	//
+	// wheelLevels is the number of levels; with the defaults it spans about 1.3 years, and later tasks wait in the top level
+	wheelLevels = 4
+)
+
+// TimingWheel 分层时间轮，适合管理数十万个待触发的超时（例如连接或会话过期）
+// 添加和取消都是 O(1)，每个任务只分配一个 Timer，比大量 time.AfterFunc 更节省内存和调度开销；
+// 代价是触发精度为一个刻度
+//
+// TimingWheel is a hierarchical timing wheel suited to hundreds of thousands of pending timeouts (e.g. connection or session expiry).
+// Scheduling and cancellation are O(1) and each task allocates only a Timer, which is cheaper in memory and scheduling than many time.AfterFunc calls;
+// the trade-off is a precision of one tick.
+type TimingWheel struct {
+	tick    time.Duration
+	size    uint64
+	start   time.Time
+	mu      sync.Mutex
+	current uint64
+	levels  [wheelLevels][]wheelBucket
+	spans   [wheelLevels + 1]uint64
+	count   int
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// Timer 时间轮中的一个任务
+//
+// Timer is a task scheduled on a timing wheel.
+type Timer struct {
+	wheel  *TimingWheel
+	expiry uint64
+	fn     func()
+	bucket *wheelBucket
+	prev   *Timer
+	next   *Timer
+}
+
+// wheelBucket 时间轮的槽，保存任务的侵入式双向链表
+//
+// wheelBucket is a slot of the wheel holding an intrusive doubly linked list of timers
+type wheelBucket struct {
+	head *Timer
+}
+
+// NewTimingWheel 创建并启动时间轮，不再使用时需要调用 Stop
+// 参数:
+//   - tick: 刻度，即触发精度，<= 0 时使用 DefaultWheelTick
+//   - size: 每层的槽数，<= 1 时使用 DefaultWheelSize
+//
+// 返回:
+//   - *TimingWheel: 时间轮
+//
+// NewTimingWheel creates and starts a timing wheel; call Stop when it is no longer needed.
+// Parameters:
+//   - tick: Tick, i.e. the firing precision; DefaultWheelTick is used when <= 0
+//   - size: Number of slots per level; DefaultWheelSize is used when <= 1
+//
+// Returns:
+//   - *TimingWheel: The timing wheel
+func NewTimingWheel(tick time.Duration, size int) *TimingWheel {
+	if tick <= 0 {
+		tick = DefaultWheelTick
+	}
+	if size <= 1 {
+		size = DefaultWheelSize
+	}
+	tw := &TimingWheel{
+		tick:  tick,
+		size:  uint64(size),
+		start: time.Now(),
+		stop:  make(chan struct{}),
+	}
+	tw.spans[0] = 1
+	for i := range wheelLevels {
+		tw.levels[i] = make([]wheelBucket, size)
+		tw.spans[i+1] = tw.spans[i] * tw.size
+	}
+	go tw.run()
+	return tw
+}
+
+// Schedule 在 delay 后执行 fn，fn 在单独的 goroutine 中执行（与 time.AfterFunc 相同）
+// 参数:
+//   - delay: 延迟，向上取整到刻度，最少一个刻度
+//   - fn: 要执行的函数
+//
+// 返回:
+//   - *Timer: 任务，可用于取消
+//
+// Schedule runs fn after delay; fn runs in its own goroutine (as with time.AfterFunc).
+// Parameters:
+//   - delay: Delay, rounded up to the tick with a minimum of one tick
+//   - fn: The function to run
+//
+// Returns:
+//   - *Timer: The task, which can be cancelled
+func (tw *TimingWheel) Schedule(delay time.Duration, fn func()) *Timer {
+	ticks := uint64(1)
+	if delay > tw.tick {
+		ticks = uint64((delay + tw.tick - 1) / tw.tick)
+	}
+
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	t := &Timer{wheel: tw, expiry: tw.current + ticks, fn: fn}
+	tw.add(t)
+	tw.count++
+	return t
+}
+
+// Cancel 取消任务
+// 返回:
+//   - bool: 如果任务在触发前被取消返回 true，已触发或已取消返回 false
+//
+// Cancel cancels the task.
+// Returns:
+//   - bool: true if the task was cancelled before firing, false if it already fired or was cancelled
+func (t *Timer) Cancel() bool {
+	tw := t.wheel
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if t.bucket == nil {
+		return false
+	}
+	t.bucket.remove(t)
+	tw.count--
+	return true
+}
+
+// Len 返回待触发的任务数
+//
+// Len returns the number of pending tasks.
+func (tw *TimingWheel) Len() int {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.count
+}
+
+// Stop 停止时间轮，未触发的任务不再执行
+//
+// Stop stops the timing wheel; pending tasks will not run.
+func (tw *TimingWheel) Stop() {
+	tw.once.Do(func() { close(tw.stop) })
+}
+
+// run 按刻度推进时间轮，按实际经过的时间追赶，避免 Ticker 丢弃的刻度导致任务延后
+//
+// run advances the wheel every tick, catching up by elapsed time so ticks dropped by the Ticker do not delay tasks
+func (tw *TimingWheel) run() {
+	ticker := time.NewTicker(tw.tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-tw.stop:
+			return
+		case now := <-ticker.C:
+			target := uint64(now.Sub(tw.start) / tw.tick)
+			tw.mu.Lock()
+			for tw.current < target {
+				tw.advance()
+			}
+			tw.mu.Unlock()
+		}
+	}
+}
+
+// advance 推进一个刻度：先将高层到期的槽降级，再触发第 0 层当前槽的任务，调用方需持有锁
+//
+// advance moves forward one tick: it cascades due slots of higher levels down first, then fires the current slot of level 0; the caller must hold the lock
+func (tw *TimingWheel) advance() {
+	tw.current++
+	for i := 1; i < wheelLevels; i++ {
+		if tw.current%tw.spans[i] != 0 {
+			break
+		}
+		b := &tw.levels[i][tw.current/tw.spans[i]%tw.size]
+		for t := b.head; t != nil; t = b.head {
+			b.remove(t)
+			tw.add(t)
+		}
+	}
+
+	b := &tw.levels[0][tw.current%tw.size]
+	for t := b.head; t != nil; t = b.head {
+		b.remove(t)
+		tw.count--
+		go t.fn()
+	}
+}
+
+// add 按剩余刻度将任务放入对应层的槽，已到期的任务放入第 0 层当前槽，调用方需持有锁
+//
+// add places the timer into the slot of the level matching its remaining ticks, due timers going into the current slot of level 0; the caller must hold the lock
+func (tw *TimingWheel) add(t *Timer) {
+	expiry := max(t.expiry, tw.current)
+	delta := expiry - tw.current
+	for i := range wheelLevels {
+		if delta < tw.spans[i+1] {
+			tw.levels[i][expiry/tw.spans[i]%tw.size].push(t)
+			return
+		}
+	}
+	// 超出最高层范围，放入最高层最远的槽，降级时重新计算
+	top := wheelLevels - 1
+	tw.levels[top][(tw.current/tw.spans[top]+tw.size-1)%tw.size].push(t)
+}
+
+// push 将任务加入链表头部
+//
+// push adds the timer to the head of the list
+func (b *wheelBucket) push(t *Timer) {
+	t.bucket = b
+	t.prev = nil
+	t.next = b.head
+	if b.head != nil {
+		b.head.prev = t
+	}
+	b.head = t
+}
+
+// remove 将任务从链表中移除
+//
+// remove unlinks the timer from the list
+func (b *wheelBucket) remove(t *Timer) {
+	if t.prev != nil {
+		t.prev.next = t.next
+	} else {
+		b.head = t.next
+	}
+	if t.next != nil {
+		t.next.prev = t.prev
+	}
+	t.bucket, t.prev, t.next = nil, nil, nil
+}
@@ -0,0 +1,54 @@
+// Package oteltracing 提供 tracing.Tracer 的 OpenTelemetry 适配器，单独成包以免不使用 OpenTelemetry 的应用引入其依赖
+//
+// Package oteltracing provides an OpenTelemetry adapter for tracing.Tracer; it is a separate package so applications not using OpenTelemetry do not pull in its dependencies.
+package oteltracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/supergodk/go-utils/v1/tracing"
+)
+
+// InstrumentationName NewGlobal 使用的 instrumentation 名称
+//
+// InstrumentationName is the instrumentation name used by NewGlobal
+const InstrumentationName = "github.com/supergodk/go-utils"
+
+// tracer 把 tracing.Tracer 转发到 OpenTelemetry 的 trace.Tracer
+//
+// tracer forwards tracing.Tracer to an OpenTelemetry trace.Tracer
+type tracer struct {
+	t trace.Tracer
+}
+
+// New 基于 OpenTelemetry 追踪器创建 tracing.Tracer，通常配合 tracing.SetDefault 使用
+//
+// New creates a tracing.Tracer backed by an OpenTelemetry tracer, usually passed to tracing.SetDefault.
+func New(t trace.Tracer) tracing.Tracer {
+	return &tracer{t: t}
+}
+
+// NewGlobal 基于全局 TracerProvider（otel.SetTracerProvider）创建 tracing.Tracer，之后设置的全局提供者同样生效
+//
+// NewGlobal creates a tracing.Tracer backed by the global TracerProvider (otel.SetTracerProvider); providers set later take effect as well.
+func NewGlobal() tracing.Tracer {
+	return New(otel.Tracer(InstrumentationName))
+}
+
+// StartSpan 实现 tracing.Tracer 接口，err 不为 nil 时记录错误并将状态设为 Error
+//
+// StartSpan implements the tracing.Tracer interface; a non-nil err is recorded and sets the status to Error.
+func (t *tracer) StartSpan(ctx context.Context, name string) (context.Context, func(error)) {
+	ctx, span := t.t.Start(ctx, name)
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
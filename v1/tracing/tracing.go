@@ -0,0 +1,93 @@
+// Package tracing 提供最小的链路追踪接口，本库中发起网络调用的模块（cryptoutil、ossutil、httputil）通过它创建 span，默认不做任何记录；
+// 应用调用 SetDefault 设置实现（例如 oteltracing 子包中的 OpenTelemetry 适配器）后，这些调用会自动出现在链路中
+//
+// Package tracing provides a minimal tracing interface that the network-calling modules of this library (cryptoutil, ossutil, httputil) use to create spans; it records nothing by default.
+// Once the application calls SetDefault with an implementation (e.g. the OpenTelemetry adapter in the oteltracing subpackage), those calls show up in traces automatically.
+package tracing
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// Tracer 链路追踪器
+//
+// Tracer is a tracer.
+type Tracer interface {
+	// StartSpan 创建名为 name 的 span，返回携带该 span 的上下文和结束函数；结束函数只能调用一次，err 不为 nil 时 span 标记为失败
+	//
+	// StartSpan starts a span named name and returns a context carrying it along with an end function; call end exactly once, and a non-nil err marks the span as failed.
+	StartSpan(ctx context.Context, name string) (context.Context, func(err error))
+}
+
+// Noop 不做任何记录的追踪器，是默认的追踪器
+//
+// Noop is a tracer that records nothing; it is the default tracer.
+var Noop Tracer = noopTracer{}
+
+// noopTracer 不做任何记录的追踪器
+//
+// noopTracer is a tracer that records nothing
+type noopTracer struct{}
+
+// StartSpan 实现 Tracer 接口
+//
+// StartSpan implements the Tracer interface.
+func (noopTracer) StartSpan(ctx context.Context, _ string) (context.Context, func(error)) {
+	return ctx, noopEnd
+}
+
+// noopEnd 不做任何事的结束函数
+//
+// noopEnd is an end function that does nothing
+func noopEnd(error) {}
+
+// tracerHolder 包装 Tracer 以便原子地替换
+//
+// tracerHolder wraps a Tracer so it can be swapped atomically
+type tracerHolder struct {
+	tracer Tracer
+}
+
+// defaultTracer 当前的默认追踪器
+//
+// defaultTracer holds the current default tracer
+var defaultTracer atomic.Pointer[tracerHolder]
+
+func init() {
+	defaultTracer.Store(&tracerHolder{tracer: Noop})
+}
+
+// SetDefault 设置默认追踪器，通常在 main 中调用一次
+// 参数:
+//   - t: 追踪器，为 nil 时恢复为 Noop
+//
+// SetDefault sets the default tracer, usually once in main.
+// Parameters:
+//   - t: The tracer; nil restores Noop
+func SetDefault(t Tracer) {
+	if t == nil {
+		t = Noop
+	}
+	defaultTracer.Store(&tracerHolder{tracer: t})
+}
+
+// Default 返回当前的默认追踪器
+//
+// Default returns the current default tracer.
+func Default() Tracer {
+	return defaultTracer.Load().tracer
+}
+
+// StartSpan 使用默认追踪器创建 span，用法：
+//
+//	ctx, end := tracing.StartSpan(ctx, "ossutil.PutObject")
+//	defer func() { end(err) }()
+//
+// StartSpan starts a span with the default tracer, used as:
+//
+//	ctx, end := tracing.StartSpan(ctx, "ossutil.PutObject")
+//	defer func() { end(err) }()
+func StartSpan(ctx context.Context, name string) (context.Context, func(err error)) {
+	return Default().StartSpan(ctx, name)
+}
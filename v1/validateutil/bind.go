@@ -0,0 +1,468 @@
+package validateutil
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/supergodk/go-utils/v1/i18n"
+)
+
+// DefaultBindMaxBodySize Bind 默认允许的最大请求体大小
+//
+// DefaultBindMaxBodySize is the default largest request body accepted by Bind
+const DefaultBindMaxBodySize = 1 << 20
+
+// 绑定错误的错误码
+//
+// Error codes of bind errors
+const (
+	// CodeInvalidBody 请求体无法解析
+	//
+	// CodeInvalidBody means the request body cannot be parsed
+	CodeInvalidBody = "invalid_body"
+	// CodeBodyTooLarge 请求体超过大小限制
+	//
+	// CodeBodyTooLarge means the request body exceeds the size limit
+	CodeBodyTooLarge = "body_too_large"
+	// CodeUnsupportedMediaType 不支持的 Content-Type
+	//
+	// CodeUnsupportedMediaType means the Content-Type is not supported
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	// CodeValidationFailed 参数校验失败
+	//
+	// CodeValidationFailed means parameter validation failed
+	CodeValidationFailed = "validation_failed"
+)
+
+// BindError 绑定请求失败，WriteError 把它写成统一的错误响应
+// Status: HTTP 状态码，通常为 400，请求体过大时为 413，Content-Type 不支持时为 415
+// Code: 错误码，例如 CodeValidationFailed
+// Message: 英文错误信息
+// MessageZh: 中文错误信息
+// Fields: 各字段的错误
+// Err: 底层错误
+//
+// BindError is a failure to bind a request, written by WriteError as a uniform error response.
+// Status: HTTP status, usually 400; 413 for an oversized body and 415 for an unsupported Content-Type
+// Code: Error code, e.g. CodeValidationFailed
+// Message: English error message
+// MessageZh: Chinese error message
+// Fields: The field errors
+// Err: The underlying error
+type BindError struct {
+	Status    int
+	Code      string
+	Message   string
+	MessageZh string
+	Fields    []FieldError
+	Err       error
+}
+
+// Error 实现 error 接口
+//
+// Error implements the error interface.
+func (e *BindError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+// Unwrap 支持 errors.Is / errors.As
+//
+// Unwrap supports errors.Is / errors.As.
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// Bind 把请求解码到结构体并校验，用于不使用 Web 框架的 net/http 服务
+// 查询参数总是先解码；有请求体时按 Content-Type 解码：JSON（包括 +json）、application/x-www-form-urlencoded 和 multipart/form-data（只解码普通字段，文件用 r.FormFile 读取）
+// 查询参数和表单字段按 form 标签匹配，没有时依次使用 json 标签和字段名；支持字符串、布尔、数字、time.Duration、实现了 encoding.TextUnmarshaler 的类型（例如 time.Time）以及它们的指针和切片
+// 解码完成后调用 Validate 校验
+// 参数:
+//   - r: HTTP 请求，请求体最多读取 DefaultBindMaxBodySize 字节
+//   - dst: 结构体指针
+//
+// 返回:
+//   - error: 失败时返回 *BindError，可以交给 WriteError；dst 不是结构体指针或标签无效时返回普通错误
+//
+// Bind decodes a request into a struct and validates it, for net/http services not using a web framework.
+// The query string is always decoded first; a body is decoded by Content-Type: JSON (including +json), application/x-www-form-urlencoded and multipart/form-data (plain fields only; read files with r.FormFile).
+// Query parameters and form fields are matched by the form tag, falling back to the json tag and then the field name; strings, bools, numbers, time.Duration, encoding.TextUnmarshaler types (such as time.Time) and pointers and slices of them are supported.
+// Validate runs once decoding is done.
+// Parameters:
+//   - r: The HTTP request; at most DefaultBindMaxBodySize bytes of the body are read
+//   - dst: A pointer to a struct
+//
+// Returns:
+//   - error: *BindError on failure, which can be passed to WriteError; a plain error if dst is not a pointer to a struct or a tag is invalid
+func Bind(r *http.Request, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("validateutil: Bind requires a non-nil pointer to a struct, got %T", dst)
+	}
+	if err := decodeValues(r.URL.Query(), rv.Elem()); err != nil {
+		return err
+	}
+	if err := bindBody(r, dst, rv.Elem()); err != nil {
+		return err
+	}
+
+	err := Validate(dst)
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		return &BindError{
+			Status:    http.StatusBadRequest,
+			Code:      CodeValidationFailed,
+			Message:   "validation failed",
+			MessageZh: "参数校验失败",
+			Fields:    ve.Fields,
+		}
+	}
+	return err
+}
+
+// bindBody 按 Content-Type 解码请求体
+//
+// bindBody decodes the request body by Content-Type
+func bindBody(r *http.Request, dst any, v reflect.Value) error {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+	ct := r.Header.Get("Content-Type")
+	mediaType, _, err := mime.ParseMediaType(ct)
+	if ct != "" && err != nil {
+		return unsupportedMediaType(ct)
+	}
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		data, err := io.ReadAll(io.LimitReader(r.Body, DefaultBindMaxBodySize+1))
+		if err != nil {
+			return invalidBody(err)
+		}
+		if len(data) > DefaultBindMaxBodySize {
+			return bodyTooLarge()
+		}
+		if len(data) == 0 {
+			return nil
+		}
+		return decodeJSON(data, dst)
+	case mediaType == "application/x-www-form-urlencoded":
+		r.Body = http.MaxBytesReader(nil, r.Body, DefaultBindMaxBodySize)
+		if err := r.ParseForm(); err != nil {
+			return formError(err)
+		}
+		return decodeValues(r.PostForm, v)
+	case mediaType == "multipart/form-data":
+		// ParseMultipartForm 只限制内存，文件部分会写入磁盘，总大小需要另外限制
+		r.Body = http.MaxBytesReader(nil, r.Body, DefaultBindMaxBodySize)
+		if err := r.ParseMultipartForm(DefaultBindMaxBodySize); err != nil {
+			return formError(err)
+		}
+		return decodeValues(url.Values(r.MultipartForm.Value), v)
+	case mediaType == "":
+		// 没有 Content-Type 且请求体为空白时视为没有请求体
+		data, err := io.ReadAll(io.LimitReader(r.Body, 512))
+		if err == nil && strings.TrimSpace(string(data)) == "" {
+			return nil
+		}
+	}
+	return unsupportedMediaType(ct)
+}
+
+// decodeJSON 解码 JSON 请求体，类型不匹配时返回字段错误
+//
+// decodeJSON decodes a JSON body, returning field errors on type mismatches
+func decodeJSON(data []byte, dst any) error {
+	err := json.Unmarshal(data, dst)
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &BindError{
+			Status:    http.StatusBadRequest,
+			Code:      CodeInvalidBody,
+			Message:   "invalid request body",
+			MessageZh: "请求体格式错误",
+			Fields:    []FieldError{typeFieldError(typeErr.Field, typeErr.Type.String())},
+			Err:       err,
+		}
+	}
+	return invalidBody(err)
+}
+
+// decodeValues 把查询参数或表单字段解码到结构体，嵌入的结构体会展开
+//
+// decodeValues decodes query parameters or form fields into a struct, flattening embedded structs
+func decodeValues(values url.Values, v reflect.Value) error {
+	if len(values) == 0 {
+		return nil
+	}
+	var fields []FieldError
+	decodeStruct(values, v, &fields)
+	if len(fields) > 0 {
+		return &BindError{
+			Status:    http.StatusBadRequest,
+			Code:      CodeInvalidBody,
+			Message:   "invalid parameters",
+			MessageZh: "参数格式错误",
+			Fields:    fields,
+		}
+	}
+	return nil
+}
+
+// decodeStruct 逐个字段解码，把类型错误追加到 out
+//
+// decodeStruct decodes field by field, appending type errors to out
+func decodeStruct(values url.Values, v reflect.Value, out *[]FieldError) {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		fv := v.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct && sf.Tag.Get("form") == "" {
+			decodeStruct(values, fv, out)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		name := formName(sf)
+		vals, ok := values[name]
+		if name == "-" || !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setField(fv, vals); err != nil {
+			*out = append(*out, typeFieldError(name, fv.Type().String()))
+		}
+	}
+}
+
+// setField 把字符串值设置到字段，切片字段接收所有值，其他字段取第一个值
+//
+// setField stores string values in a field; slice fields take every value and other fields the first one
+func setField(v reflect.Value, vals []string) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && !isTextUnmarshaler(v) {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setScalar(s.Index(i), val); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setScalar(v, vals[0])
+}
+
+// setScalar 解析单个字符串值
+//
+// setScalar parses a single string value
+func setScalar(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		if err := setScalar(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if isTextUnmarshaler(v) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == reflect.TypeFor[time.Duration]() {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		if s == "on" {
+			// HTML 复选框选中时的默认值
+			s = "true"
+		}
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// isTextUnmarshaler 判断字段的指针是否实现了 encoding.TextUnmarshaler
+//
+// isTextUnmarshaler reports whether a pointer to the field implements encoding.TextUnmarshaler
+func isTextUnmarshaler(v reflect.Value) bool {
+	return v.CanAddr() && reflect.PointerTo(v.Type()).Implements(reflect.TypeFor[encoding.TextUnmarshaler]())
+}
+
+// formName 返回字段在查询参数和表单中的名称：依次取 form 标签、json 标签和字段名
+//
+// formName returns a field's name in query parameters and forms: the form tag, then the json tag, then the field name
+func formName(sf reflect.StructField) string {
+	for _, key := range []string{"form", "json"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// typeFieldError 生成类型不匹配的字段错误
+//
+// typeFieldError builds a type mismatch field error
+func typeFieldError(field, typ string) FieldError {
+	return FieldError{
+		Field:     field,
+		Rule:      "type",
+		Param:     typ,
+		Message:   fmt.Sprintf("%s has an invalid format, expected %s", field, typ),
+		MessageZh: fmt.Sprintf("%s 格式不正确，应为 %s", field, typ),
+	}
+}
+
+// invalidBody 生成请求体无法解析的错误
+//
+// invalidBody builds an unparsable body error
+func invalidBody(err error) *BindError {
+	return &BindError{Status: http.StatusBadRequest, Code: CodeInvalidBody, Message: "invalid request body", MessageZh: "请求体格式错误", Err: err}
+}
+
+// bodyTooLarge 生成请求体过大的错误
+//
+// bodyTooLarge builds an oversized body error
+func bodyTooLarge() *BindError {
+	return &BindError{Status: http.StatusRequestEntityTooLarge, Code: CodeBodyTooLarge, Message: "request body too large", MessageZh: "请求体过大"}
+}
+
+// unsupportedMediaType 生成 Content-Type 不支持的错误
+//
+// unsupportedMediaType builds an unsupported Content-Type error
+func unsupportedMediaType(ct string) *BindError {
+	return &BindError{
+		Status:    http.StatusUnsupportedMediaType,
+		Code:      CodeUnsupportedMediaType,
+		Message:   fmt.Sprintf("unsupported content type %q", ct),
+		MessageZh: fmt.Sprintf("不支持的 Content-Type %q", ct),
+	}
+}
+
+// formError 转换解析表单的错误，区分请求体过大
+//
+// formError converts a form parsing error, telling oversized bodies apart
+func formError(err error) *BindError {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) || errors.Is(err, multipart.ErrMessageTooLarge) {
+		return bodyTooLarge()
+	}
+	return invalidBody(err)
+}
+
+// errorPayload WriteError 写出的 JSON 响应
+// Code: HTTP 状态码，与 httputil 中间件的错误响应一致
+// Error: 错误码
+// Message: 按请求语言选择的错误信息
+// Fields: 各字段的错误
+//
+// errorPayload is the JSON response written by WriteError
+// Code: HTTP status, consistent with the error responses of the httputil middlewares
+// Error: Error code
+// Message: The error message in the request's language
+// Fields: The field errors
+type errorPayload struct {
+	Code    int            `json:"code"`
+	Error   string         `json:"error"`
+	Message string         `json:"message"`
+	Fields  []fieldPayload `json:"fields,omitempty"`
+}
+
+// fieldPayload 错误响应中的字段错误
+//
+// fieldPayload is a field error in the error response
+type fieldPayload struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// WriteError 把 Bind 返回的错误写成统一的 JSON 错误响应，例如
+// {"code":400,"error":"validation_failed","message":"参数校验失败","fields":[{"field":"name","rule":"required","message":"name 不能为空"}]}
+// 信息的语言优先取 i18n.WithLanguage 写入 context 的语言，否则按 Accept-Language 在 en 和 zh 中选择；不是 *BindError 的错误写成 500
+// 参数:
+//   - w: 响应
+//   - r: 请求，用于选择语言
+//   - err: Bind 返回的错误
+//
+// WriteError writes an error returned by Bind as a uniform JSON error response, e.g.
+// {"code":400,"error":"validation_failed","message":"validation failed","fields":[{"field":"name","rule":"required","message":"name is required"}]}
+// The message language is the one stored in the context by i18n.WithLanguage, otherwise chosen between en and zh by Accept-Language; errors other than *BindError are written as 500.
+// Parameters:
+//   - w: The response
+//   - r: The request, used to choose the language
+//   - err: The error returned by Bind
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	lang := i18n.LanguageFromContext(r.Context(), i18n.MatchLanguage(r.Header.Get("Accept-Language"), "en", "zh"))
+	zh := strings.HasPrefix(strings.ToLower(lang), "zh")
+
+	payload := errorPayload{Code: http.StatusInternalServerError, Error: "internal_error", Message: "internal server error"}
+	if zh {
+		payload.Message = "服务器内部错误"
+	}
+	var be *BindError
+	if errors.As(err, &be) {
+		payload.Code, payload.Error, payload.Message = be.Status, be.Code, be.Message
+		if zh {
+			payload.Message = be.MessageZh
+		}
+		for _, f := range be.Fields {
+			fp := fieldPayload{Field: f.Field, Rule: f.Rule, Param: f.Param, Message: f.Message}
+			if zh {
+				fp.Message = f.MessageZh
+			}
+			payload.Fields = append(payload.Fields, fp)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(payload.Code)
+	json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,378 @@
+// Package validateutil 提供基于结构体标签的参数校验和 net/http 请求绑定
+//
+// Package validateutil provides struct-tag-based validation and net/http request binding.
+package validateutil
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Validator 由需要自定义校验（例如字段之间的约束）的类型实现，在标签规则全部通过后调用
+// 返回 *ValidationError 或 FieldError 时按字段错误处理，其他错误作为整体的校验失败
+//
+// Validator is implemented by types needing custom validation (such as constraints across fields); it is called after every tag rule passes.
+// Returning a *ValidationError or FieldError is treated as field errors; any other error is an overall validation failure.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError 单个字段的校验错误
+// Field: 字段路径，使用 JSON 名称，例如 "items[0].name"
+// Rule: 未通过的规则，例如 "required"、"max"
+// Param: 规则参数，例如 max=20 中的 "20"
+// Message: 英文错误信息
+// MessageZh: 中文错误信息
+//
+// FieldError is the validation error of one field.
+// Field: The field path using JSON names, e.g. "items[0].name"
+// Rule: The failed rule, e.g. "required" or "max"
+// Param: The rule parameter, e.g. "20" in max=20
+// Message: English error message
+// MessageZh: Chinese error message
+type FieldError struct {
+	Field     string `json:"field"`
+	Rule      string `json:"rule"`
+	Param     string `json:"param,omitempty"`
+	Message   string `json:"message"`
+	MessageZh string `json:"message_zh"`
+}
+
+// Error 实现 error 接口
+//
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationError 校验失败，包含所有未通过的字段
+// Fields: 各字段的错误，按字段声明顺序排列
+//
+// ValidationError is a validation failure listing every failed field.
+// Fields: The field errors, in field declaration order
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error 实现 error 接口
+//
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Message
+	}
+	return "validation failed: " + strings.Join(msgs, "; ")
+}
+
+// Validate 按 validate 标签校验结构体，嵌套的结构体、结构体切片和 map 的值会递归校验
+// 支持的规则（用逗号分隔，例如 `validate:"required,max=20"`）:
+//   - required: 不能为零值（空字符串、0、nil、空切片等）
+//   - omitempty: 为零值时跳过其他规则，用于可选字段
+//   - min=n / max=n: 数字的取值范围；字符串的字符数；切片和 map 的元素个数
+//   - len=n: 字符串的字符数或切片和 map 的元素个数必须等于 n
+//   - oneof=a b c: 必须是空格分隔的值之一
+//   - email: 有效的邮箱地址
+//   - url: 带协议和主机的绝对 URL
+//
+// 值为 nil 的指针字段且没有 required 时跳过其他规则；所有标签规则通过后，实现了 Validator 的值会调用 Validate
+// 参数:
+//   - v: 结构体或结构体指针
+//
+// 返回:
+//   - error: 校验失败时返回 *ValidationError，标签无效时返回普通错误
+//
+// Validate validates a struct by its validate tags, recursing into nested structs, slices of structs and map values.
+// Supported rules (comma separated, e.g. `validate:"required,max=20"`):
+//   - required: Must not be the zero value (empty string, 0, nil, empty slice, ...)
+//   - omitempty: Skip the other rules for the zero value, for optional fields
+//   - min=n / max=n: The range for numbers; the character count for strings; the element count for slices and maps
+//   - len=n: The character count of a string or the element count of a slice or map must equal n
+//   - oneof=a b c: Must be one of the space-separated values
+//   - email: A valid email address
+//   - url: An absolute URL with a scheme and host
+//
+// Nil pointer fields without required skip the other rules; once every tag rule passes, values implementing Validator have Validate called.
+// Parameters:
+//   - v: A struct or a pointer to a struct
+//
+// Returns:
+//   - error: *ValidationError if validation fails, or a plain error if a tag is invalid
+func Validate(v any) error {
+	var fields []FieldError
+	if err := validateValue(reflect.ValueOf(v), "", &fields); err != nil {
+		return err
+	}
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// validateValue 递归校验值，把字段错误追加到 out
+//
+// validateValue validates a value recursively, appending field errors to out
+func validateValue(v reflect.Value, path string, out *[]FieldError) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == reflect.TypeFor[time.Time]() {
+			return nil
+		}
+		before := len(*out)
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			fv := v.Field(i)
+			name := fieldName(sf)
+			if name == "-" {
+				continue
+			}
+			fpath := name
+			if sf.Anonymous && sf.Tag.Get("json") == "" {
+				// 嵌入的结构体字段提升到外层
+				fpath = path
+			} else if path != "" {
+				fpath = path + "." + name
+			}
+			ok, err := checkRules(fv, sf.Tag.Get("validate"), fpath, out)
+			if err != nil {
+				return fmt.Errorf("validateutil: field %s: %w", sf.Name, err)
+			}
+			if ok {
+				if err := validateValue(fv, fpath, out); err != nil {
+					return err
+				}
+			}
+		}
+		if len(*out) == before {
+			return callValidator(v, path, out)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if err := validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), out); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		slices.SortFunc(keys, func(a, b reflect.Value) int { return strings.Compare(fmt.Sprint(a), fmt.Sprint(b)) })
+		for _, k := range keys {
+			if err := validateValue(v.MapIndex(k), fmt.Sprintf("%s[%v]", path, k), out); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// callValidator 调用 Validator 接口，把返回的错误转换为字段错误
+//
+// callValidator calls the Validator interface and converts the returned error into field errors
+func callValidator(v reflect.Value, path string, out *[]FieldError) error {
+	var validator Validator
+	if v.CanAddr() {
+		validator, _ = v.Addr().Interface().(Validator)
+	}
+	if validator == nil {
+		validator, _ = v.Interface().(Validator)
+	}
+	if validator == nil {
+		return nil
+	}
+	switch err := validator.Validate().(type) {
+	case nil:
+	case *ValidationError:
+		for _, f := range err.Fields {
+			f.Field = joinPath(path, f.Field)
+			*out = append(*out, f)
+		}
+	case FieldError:
+		err.Field = joinPath(path, err.Field)
+		*out = append(*out, err)
+	default:
+		*out = append(*out, FieldError{Field: path, Rule: "custom", Message: err.Error(), MessageZh: err.Error()})
+	}
+	return nil
+}
+
+// checkRules 检查字段的标签规则，返回是否应继续递归校验字段的值
+//
+// checkRules checks a field's tag rules, returning whether the field's value should be validated recursively
+func checkRules(v reflect.Value, tag, path string, out *[]FieldError) (bool, error) {
+	if tag == "" || tag == "-" {
+		return true, nil
+	}
+	rules := strings.Split(tag, ",")
+	if v.IsZero() {
+		if slices.Contains(rules, "required") {
+			*out = append(*out, newFieldError(path, "required", "", v))
+			return false, nil
+		}
+		if slices.Contains(rules, "omitempty") || v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			return false, nil
+		}
+	}
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		ok, err := checkRule(v, name, param)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			*out = append(*out, newFieldError(path, name, param, v))
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// checkRule 检查单条规则
+//
+// checkRule checks a single rule
+func checkRule(v reflect.Value, name, param string) (bool, error) {
+	switch name {
+	case "", "required", "omitempty":
+		return true, nil
+	case "min", "max", "len":
+		n, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s parameter %q", name, param)
+		}
+		size, ok := measure(v, name == "len")
+		if !ok {
+			return false, fmt.Errorf("rule %s does not apply to %s", name, v.Kind())
+		}
+		switch name {
+		case "min":
+			return size >= n, nil
+		case "max":
+			return size <= n, nil
+		}
+		return size == n, nil
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		return slices.Contains(strings.Fields(param), s), nil
+	case "email":
+		if v.Kind() != reflect.String {
+			return false, fmt.Errorf("rule email does not apply to %s", v.Kind())
+		}
+		addr, err := mail.ParseAddress(v.String())
+		return err == nil && addr.Address == v.String() && addr.Name == "", nil
+	case "url":
+		if v.Kind() != reflect.String {
+			return false, fmt.Errorf("rule url does not apply to %s", v.Kind())
+		}
+		u, err := url.Parse(v.String())
+		return err == nil && u.Scheme != "" && u.Host != "", nil
+	}
+	return false, fmt.Errorf("unknown rule %q", name)
+}
+
+// measure 返回 min、max 和 len 比较的量：数字的值、字符串的字符数、容器的元素个数；len 不适用于数字
+//
+// measure returns what min, max and len compare: a number's value, a string's character count or a container's element count; len does not apply to numbers
+func measure(v reflect.Value, lenRule bool) (float64, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), !lenRule
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), !lenRule
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), !lenRule
+	}
+	return 0, false
+}
+
+// newFieldError 生成带中英文信息的字段错误
+//
+// newFieldError builds a field error with Chinese and English messages
+func newFieldError(path, rule, param string, v reflect.Value) FieldError {
+	fe := FieldError{Field: path, Rule: rule, Param: param}
+	kind := v.Kind()
+	isString := kind == reflect.String
+	isCount := kind == reflect.Slice || kind == reflect.Array || kind == reflect.Map
+	switch rule {
+	case "required":
+		fe.Message, fe.MessageZh = path+" is required", path+" 不能为空"
+	case "min":
+		switch {
+		case isString:
+			fe.Message, fe.MessageZh = fmt.Sprintf("%s must be at least %s characters", path, param), fmt.Sprintf("%s 不能少于 %s 个字符", path, param)
+		case isCount:
+			fe.Message, fe.MessageZh = fmt.Sprintf("%s must contain at least %s items", path, param), fmt.Sprintf("%s 至少需要 %s 项", path, param)
+		default:
+			fe.Message, fe.MessageZh = fmt.Sprintf("%s must be at least %s", path, param), fmt.Sprintf("%s 不能小于 %s", path, param)
+		}
+	case "max":
+		switch {
+		case isString:
+			fe.Message, fe.MessageZh = fmt.Sprintf("%s must be at most %s characters", path, param), fmt.Sprintf("%s 不能超过 %s 个字符", path, param)
+		case isCount:
+			fe.Message, fe.MessageZh = fmt.Sprintf("%s must contain at most %s items", path, param), fmt.Sprintf("%s 最多 %s 项", path, param)
+		default:
+			fe.Message, fe.MessageZh = fmt.Sprintf("%s must be at most %s", path, param), fmt.Sprintf("%s 不能大于 %s", path, param)
+		}
+	case "len":
+		if isString {
+			fe.Message, fe.MessageZh = fmt.Sprintf("%s must be exactly %s characters", path, param), fmt.Sprintf("%s 必须是 %s 个字符", path, param)
+		} else {
+			fe.Message, fe.MessageZh = fmt.Sprintf("%s must contain exactly %s items", path, param), fmt.Sprintf("%s 必须是 %s 项", path, param)
+		}
+	case "oneof":
+		opts := strings.Join(strings.Fields(param), ", ")
+		fe.Message, fe.MessageZh = fmt.Sprintf("%s must be one of %s", path, opts), fmt.Sprintf("%s 必须是 %s 之一", path, opts)
+	case "email":
+		fe.Message, fe.MessageZh = path+" must be a valid email address", path+" 必须是有效的邮箱地址"
+	case "url":
+		fe.Message, fe.MessageZh = path+" must be a valid URL", path+" 必须是有效的 URL"
+	}
+	return fe
+}
+
+// fieldName 返回字段在错误信息和表单中使用的名称：依次取 json 标签、form 标签和字段名
+//
+// fieldName returns the name used for a field in error messages and forms: the json tag, then the form tag, then the field name
+func fieldName(sf reflect.StructField) string {
+	for _, key := range []string{"json", "form"} {
+		if name, _, _ := strings.Cut(sf.Tag.Get(key), ","); name != "" {
+			return name
+		}
+	}
+	return sf.Name
+}
+
+// joinPath 连接字段路径
+//
+// joinPath joins field paths
+func joinPath(parent, child string) string {
+	switch {
+	case parent == "":
+		return child
+	case child == "":
+		return parent
+	}
+	return parent + "." + child
+}